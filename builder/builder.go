@@ -2,16 +2,26 @@ package builder
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
+	"time"
 
 	"cloud.google.com/go/datastore"
+	"cloud.google.com/go/datastore/apiv1/datastorepb"
+	"github.com/AndroX7/gostore"
+	contextKey "github.com/AndroX7/gostore/key"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Builder constructs Datastore queries
 type Builder struct {
-	kind   string
-	params QueryParams
+	kind      string
+	namespace string
+	params    QueryParams
 }
 
 // New creates a new query builder
@@ -30,6 +40,23 @@ func (b *Builder) Kind(kind string) *Builder {
 	return b
 }
 
+// GetKind returns the kind set with Kind
+func (b *Builder) GetKind() string {
+	return b.kind
+}
+
+// Namespace sets the namespace the query runs in, which also applies to the
+// ancestor key
+func (b *Builder) Namespace(namespace string) *Builder {
+	b.namespace = namespace
+	return b
+}
+
+// GetNamespace returns the namespace set with Namespace
+func (b *Builder) GetNamespace() string {
+	return b.namespace
+}
+
 // Filter adds a filter condition
 func (b *Builder) Filter(field string, operator FilterOperator, value interface{}) *Builder {
 	b.params.Filters = append(b.params.Filters, FilterParam{
@@ -71,6 +98,16 @@ func (b *Builder) Order(field string, direction OrderDirection) *Builder {
 	return b
 }
 
+// GetFilters returns the filters added so far
+func (b *Builder) GetFilters() []FilterParam {
+	return b.params.Filters
+}
+
+// GetOrders returns the orders added so far
+func (b *Builder) GetOrders() []OrderParam {
+	return b.params.Orders
+}
+
 // OrderAsc adds ascending order
 func (b *Builder) OrderAsc(field string) *Builder {
 	return b.Order(field, Ascending)
@@ -117,7 +154,8 @@ func (b *Builder) KeysOnly() *Builder {
 	return b
 }
 
-// Ancestor sets ancestor filter
+// Ancestor sets ancestor filter. id may be any type accepted by
+// key.NormalizeID; any other is reported by Validate.
 func (b *Builder) Ancestor(kind string, id interface{}) *Builder {
 	b.params.Ancestor = &AncestorParam{
 		Kind: kind,
@@ -126,16 +164,38 @@ func (b *Builder) Ancestor(kind string, id interface{}) *Builder {
 	return b
 }
 
-// Build constructs the Datastore query
+// Clone returns an independent copy of the builder
+func (b *Builder) Clone() *Builder {
+	params := b.params
+	params.Filters = append([]FilterParam(nil), b.params.Filters...)
+	params.Orders = append([]OrderParam(nil), b.params.Orders...)
+	params.Select = append([]string(nil), b.params.Select...)
+	if b.params.Ancestor != nil {
+		ancestor := *b.params.Ancestor
+		params.Ancestor = &ancestor
+	}
+	return &Builder{kind: b.kind, namespace: b.namespace, params: params}
+}
+
+// Build constructs the Datastore query. A query with an invalid ancestor
+// (see Validate) fails when run rather than dropping the ancestor filter.
 func (b *Builder) Build() *datastore.Query {
+	if r := indexRecorder.Load(); r != nil {
+		r.record(b)
+	}
+
 	query := datastore.NewQuery(b.kind)
+	if b.namespace != "" {
+		query = query.Namespace(b.namespace)
+	}
 
 	// Apply filters
 	for _, filter := range b.params.Filters {
-		query = query.Filter(
-			fmt.Sprintf("%s %s", filter.Field, filter.Operator),
-			filter.Value,
-		)
+		value := filter.Value
+		if filter.Operator == In {
+			value = interfaceSlice(value)
+		}
+		query = query.FilterField(filter.Field, string(filter.Operator), value)
 	}
 
 	// Apply ordering
@@ -179,31 +239,81 @@ func (b *Builder) Build() *datastore.Query {
 		query = query.KeysOnly()
 	}
 
-	// Apply ancestor
+	// Apply ancestor; a nil key makes the query fail
 	if b.params.Ancestor != nil {
-		var key *datastore.Key
-		switch id := b.params.Ancestor.ID.(type) {
-		case string:
-			key = datastore.NameKey(b.params.Ancestor.Kind, id, nil)
-		case int64:
-			key = datastore.IDKey(b.params.Ancestor.Kind, id, nil)
-		}
-		if key != nil {
-			query = query.Ancestor(key)
-		}
+		key, _ := b.ancestorKey()
+		query = query.Ancestor(key)
 	}
 
 	return query
 }
 
-// Execute runs the query and returns results
-func (b *Builder) Execute(ctx context.Context, client *datastore.Client, dest interface{}) (*PaginationResult, error) {
+// ErrInvalidAncestor is returned by Validate for an ancestor ID that
+// key.NormalizeID rejects
+var ErrInvalidAncestor = errors.New("invalid ancestor")
+
+// Validate reports an input Build cannot turn into the query it describes:
+// an error matching ErrInvalidAncestor if the ancestor ID is invalid. The
+// methods running the query return it before sending anything.
+func (b *Builder) Validate() error {
+	if b.params.Ancestor == nil {
+		return nil
+	}
+	_, err := b.ancestorKey()
+	return err
+}
+
+// ancestorKey builds the key of the ancestor filter
+func (b *Builder) ancestorKey() (*datastore.Key, error) {
+	ancestor := b.params.Ancestor
+	id, err := contextKey.NormalizeID(ancestor.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrInvalidAncestor, ancestor.Kind, err)
+	}
+
+	var key *datastore.Key
+	if name, ok := id.(string); ok {
+		key = datastore.NameKey(ancestor.Kind, name, nil)
+	} else {
+		key = datastore.IDKey(ancestor.Kind, id.(int64), nil)
+	}
+	key.Namespace = b.namespace
+	return key, nil
+}
+
+// interfaceSlice converts a slice to the []interface{} Datastore takes as the
+// value of an IN filter
+func interfaceSlice(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return v
+	}
+	if _, ok := v.([]interface{}); ok {
+		return v
+	}
+	values := make([]interface{}, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values
+}
+
+// Execute runs the query and returns results. A query Datastore has no
+// composite index for fails with an *ErrMissingIndex.
+func (b *Builder) Execute(ctx context.Context, client gostore.Client, dest interface{}) (*PaginationResult, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
 	query := b.Build()
 
+	start := time.Now()
 	keys, err := client.GetAll(ctx, query, dest)
+	err = missingIndex(b, err)
+	b.logQuery(ctx, start, len(keys), err)
 	if err != nil {
 		return nil, err
 	}
+	contextKey.SetIDs(dest, keys)
 
 	pagination := &PaginationResult{
 		Total:   len(keys),
@@ -213,15 +323,45 @@ func (b *Builder) Execute(ctx context.Context, client *datastore.Client, dest in
 	return pagination, nil
 }
 
-// ExecuteWithCursor runs query and returns cursor for next page
-func (b *Builder) ExecuteWithCursor(ctx context.Context, client *datastore.Client, dest interface{}) (*PaginationResult, error) {
+// First loads the first matching entity into dest, returning
+// datastore.ErrNoSuchEntity when nothing matches
+func (b *Builder) First(ctx context.Context, client gostore.Client, dest interface{}) (*datastore.Key, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	first := b.Clone().Limit(1)
+	query := first.Build()
+
+	start := time.Now()
+	key, err := client.Run(ctx, query).Next(dest)
+	if err == iterator.Done {
+		first.logQuery(ctx, start, 0, nil)
+		return nil, datastore.ErrNoSuchEntity
+	}
+	if err != nil {
+		err = missingIndex(b, err)
+		first.logQuery(ctx, start, 0, err)
+		return nil, err
+	}
+	first.logQuery(ctx, start, 1, nil)
+	contextKey.SetID(dest, key)
+	return key, nil
+}
+
+// ExecuteWithCursor runs query and returns cursor for next page. It fails
+// like Execute without a composite index.
+func (b *Builder) ExecuteWithCursor(ctx context.Context, client gostore.Client, dest interface{}) (_ *PaginationResult, err error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
 	query := b.Build()
 
+	start := time.Now()
 	it := client.Run(ctx, query)
 
 	count := 0
+	defer func() { b.logQuery(ctx, start, count, err) }()
 	var lastCursor datastore.Cursor
-	var err error
 
 	for {
 		_, err = it.Next(dest)
@@ -229,7 +369,7 @@ func (b *Builder) ExecuteWithCursor(ctx context.Context, client *datastore.Clien
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, missingIndex(b, err)
 		}
 
 		count++
@@ -254,29 +394,167 @@ func (b *Builder) ExecuteWithCursor(ctx context.Context, client *datastore.Clien
 	return pagination, nil
 }
 
-// Count counts matching entities
-func (b *Builder) Count(ctx context.Context, client *datastore.Client) (int, error) {
-	// Create a copy to avoid modifying the original builder
-	countBuilder := &Builder{
-		kind:   b.kind,
-		params: b.params,
+// Count counts matching entities by iterating a keys-only query, without
+// retaining the keys
+func (b *Builder) Count(ctx context.Context, client gostore.Client) (int, error) {
+	return b.CountUpTo(ctx, client, 0)
+}
+
+// CountUpTo counts matching entities like Count but stops at limit, so
+// checks such as "are there more than 100?" end early. A limit <= 0 counts
+// everything.
+func (b *Builder) CountUpTo(ctx context.Context, client gostore.Client, limit int) (int, error) {
+	if err := b.Validate(); err != nil {
+		return 0, err
+	}
+	countBuilder := b.Clone().KeysOnly()
+	if limit > 0 && (countBuilder.params.Limit <= 0 || limit < countBuilder.params.Limit) {
+		countBuilder.Limit(limit)
 	}
-	countBuilder.KeysOnly()
 
-	query := countBuilder.Build()
+	start := time.Now()
+	count, err := countKeys(ctx, client.Run(ctx, countBuilder.Build()))
+	err = missingIndex(b, err)
+	countBuilder.logQuery(ctx, start, count, err)
+	return count, err
+}
+
+// countCheckInterval is how many keys countKeys reads between context checks
+const countCheckInterval = 1000
 
-	keys, err := client.GetAll(ctx, query, nil)
+// keyIterator is the part of datastore.Iterator used by countKeys
+type keyIterator interface {
+	Next(dst interface{}) (*datastore.Key, error)
+}
+
+func countKeys(ctx context.Context, it keyIterator) (int, error) {
+	count := 0
+	for {
+		if count%countCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return count, err
+			}
+		}
+
+		_, err := it.Next(nil)
+		if err == iterator.Done {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+// CountAggregate counts matching entities with a server-side aggregation
+// query instead of fetching keys. Where aggregations are unsupported it fails
+// with codes.Unimplemented without logging the query, as callers then fall
+// back to Count, which logs it.
+func (b *Builder) CountAggregate(ctx context.Context, client gostore.Client) (count int, err error) {
+	if err := b.Validate(); err != nil {
+		return 0, err
+	}
+	query := b.Build().NewAggregationQuery().WithCount(countAlias)
+
+	start := time.Now()
+	defer func() {
+		if status.Code(err) != codes.Unimplemented {
+			b.logQuery(ctx, start, count, err)
+		}
+	}()
+	result, err := client.RunAggregationQuery(ctx, query)
 	if err != nil {
+		return 0, missingIndex(b, err)
+	}
+
+	value, ok := result[countAlias].(*datastorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("unexpected count result type %T", result[countAlias])
+	}
+
+	return int(value.GetIntegerValue()), nil
+}
+
+// SumAggregate sums field over matching entities with a server-side
+// aggregation query. Entities without field, or storing it noindex, are left
+// out.
+func (b *Builder) SumAggregate(ctx context.Context, client gostore.Client, field string) (float64, error) {
+	if err := b.Validate(); err != nil {
+		return 0, err
+	}
+	query := b.Build().NewAggregationQuery().WithSum(field, sumAlias)
+	return b.numberAggregate(ctx, client, query, sumAlias)
+}
+
+// AvgAggregate averages field over matching entities with a server-side
+// aggregation query. It returns 0 if no entity has field.
+func (b *Builder) AvgAggregate(ctx context.Context, client gostore.Client, field string) (float64, error) {
+	if err := b.Validate(); err != nil {
 		return 0, err
 	}
+	query := b.Build().NewAggregationQuery().WithAvg(field, avgAlias)
+	return b.numberAggregate(ctx, client, query, avgAlias)
+}
+
+// numberAggregate runs an aggregation query over b and reads the integer,
+// double or null result under alias as a float64
+func (b *Builder) numberAggregate(ctx context.Context, client gostore.Client, query *datastore.AggregationQuery, alias string) (_ float64, err error) {
+	start := time.Now()
+	defer func() { b.logQuery(ctx, start, 0, err) }()
+
+	result, err := client.RunAggregationQuery(ctx, query)
+	if err != nil {
+		return 0, missingIndex(b, err)
+	}
+
+	value, ok := result[alias].(*datastorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("unexpected %s result type %T", alias, result[alias])
+	}
 
-	return len(keys), nil
+	switch v := value.GetValueType().(type) {
+	case *datastorepb.Value_IntegerValue:
+		return float64(v.IntegerValue), nil
+	case *datastorepb.Value_DoubleValue:
+		return v.DoubleValue, nil
+	case *datastorepb.Value_NullValue:
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unexpected %s result value %v", alias, value)
 }
 
+const (
+	countAlias = "count"
+	sumAlias   = "sum"
+	avgAlias   = "avg"
+)
+
 func encodeCursor(cursor datastore.Cursor) string {
 	return cursor.String()
 }
 
+// ErrInvalidCursor is returned for a cursor string that was not produced by
+// a query
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ValidateCursor checks that s is a cursor as returned in NextCursor,
+// returning an error matching ErrInvalidCursor otherwise. Build ignores an
+// invalid cursor, so callers accepting cursors from clients should check them
+// first.
+func ValidateCursor(s string) error {
+	_, err := decodeCursor(s)
+	return err
+}
+
 func decodeCursor(s string) (datastore.Cursor, error) {
-	return datastore.DecodeCursor(s)
+	cursor, err := datastore.DecodeCursor(s)
+	if err != nil {
+		return cursor, fmt.Errorf("%w %q: %v", ErrInvalidCursor, s, err)
+	}
+	// Decoding is lenient; a cursor we issued encodes back to itself
+	if cursor.String() != strings.TrimRight(s, "=") {
+		return cursor, fmt.Errorf("%w %q", ErrInvalidCursor, s)
+	}
+	return cursor, nil
 }
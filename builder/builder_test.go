@@ -388,3 +388,52 @@ func TestCursorHelpers(t *testing.T) {
 		}
 	})
 }
+
+func TestClone(t *testing.T) {
+	t.Run("Clone is independent of the original", func(t *testing.T) {
+		b := New().
+			Kind("users").
+			Where("status", "active").
+			OrderDesc("created_at").
+			Select("name").
+			Ancestor("orgs", "acme").
+			Limit(10)
+
+		c := b.Clone()
+		c.Where("age", 30).OrderAsc("name").Select("email").Limit(1)
+		c.params.Ancestor.ID = "other"
+
+		if len(b.params.Filters) != 1 {
+			t.Errorf("expected 1 filter on original, got %d", len(b.params.Filters))
+		}
+		if len(b.params.Orders) != 1 {
+			t.Errorf("expected 1 order on original, got %d", len(b.params.Orders))
+		}
+		if len(b.params.Select) != 1 {
+			t.Errorf("expected 1 select field on original, got %d", len(b.params.Select))
+		}
+		if b.params.Limit != 10 {
+			t.Errorf("expected limit 10 on original, got %d", b.params.Limit)
+		}
+		if b.params.Ancestor.ID != "acme" {
+			t.Errorf("expected ancestor 'acme' on original, got '%v'", b.params.Ancestor.ID)
+		}
+
+		if c.kind != "users" || len(c.params.Filters) != 2 {
+			t.Errorf("expected clone to keep kind and add a filter, got kind '%s' with %d filters", c.kind, len(c.params.Filters))
+		}
+	})
+}
+
+func TestNamespace(t *testing.T) {
+	t.Run("Set namespace", func(t *testing.T) {
+		b := New().Kind("users").Namespace("tenant-a")
+
+		if b.GetNamespace() != "tenant-a" {
+			t.Errorf("expected namespace 'tenant-a', got '%s'", b.GetNamespace())
+		}
+		if c := b.Clone(); c.GetNamespace() != "tenant-a" {
+			t.Errorf("expected clone to keep namespace, got '%s'", c.GetNamespace())
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"google.golang.org/api/iterator"
+)
+
+// fakeKeys yields n keys, then iterator.Done
+type fakeKeys struct {
+	n    int
+	read int
+}
+
+func (f *fakeKeys) Next(dst interface{}) (*datastore.Key, error) {
+	if f.read == f.n {
+		return nil, iterator.Done
+	}
+	f.read++
+	return datastore.IDKey("users", int64(f.read), nil), nil
+}
+
+func TestCountKeys(t *testing.T) {
+	t.Run("Counts every key", func(t *testing.T) {
+		count, err := countKeys(context.Background(), &fakeKeys{n: 2500})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if count != 2500 {
+			t.Errorf("expected 2500, got %d", count)
+		}
+	})
+
+	t.Run("Stops when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		it := &fakeKeys{n: 2500}
+		_, err := countKeys(ctx, it)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if it.read != 0 {
+			t.Errorf("expected no keys read, got %d", it.read)
+		}
+	})
+}
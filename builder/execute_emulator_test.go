@@ -0,0 +1,132 @@
+package builder_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	testutil.StopEmulator()
+	os.Exit(code)
+}
+
+// seedUsers stores CreateTestUsers in the emulator under their IDs
+func seedUsers(t *testing.T) (context.Context, gostore.Client) {
+	t.Helper()
+
+	ctx := context.Background()
+	client := gostore.Wrap(testutil.StartEmulator(t))
+
+	users := testutil.CreateTestUsers()
+	keys := make([]*datastore.Key, len(users))
+	for i, u := range users {
+		keys[i] = datastore.NameKey("users", u.ID, nil)
+	}
+	if _, err := client.PutMulti(ctx, keys, users); err != nil {
+		t.Fatalf("failed to seed users: %v", err)
+	}
+	return ctx, client
+}
+
+func TestExecute(t *testing.T) {
+	ctx, client := seedUsers(t)
+
+	t.Run("Filter and order", func(t *testing.T) {
+		var users []testutil.TestUser
+		result, err := builder.New().Kind("users").
+			Where("status", "active").
+			OrderDesc("age").
+			Execute(ctx, client, &users)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+
+		if result.Total != 3 || result.HasMore {
+			t.Errorf("expected 3 results and no more, got %+v", result)
+		}
+		var ids []string
+		for _, u := range users {
+			ids = append(ids, u.ID)
+		}
+		if len(ids) != 3 || ids[0] != "user1" || ids[1] != "user4" || ids[2] != "user2" {
+			t.Errorf("expected [user1 user4 user2] with IDs set, got %v", ids)
+		}
+	})
+
+	t.Run("Full page has more", func(t *testing.T) {
+		var users []testutil.TestUser
+		result, err := builder.New().Kind("users").OrderAsc("age").Limit(2).Execute(ctx, client, &users)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result.Total != 2 || !result.HasMore {
+			t.Errorf("expected a full page with more, got %+v", result)
+		}
+	})
+
+	t.Run("IN filter", func(t *testing.T) {
+		var users []testutil.TestUser
+		result, err := builder.New().Kind("users").
+			WhereIn("email", []interface{}{"john@example.com", "bob@example.com"}).
+			Execute(ctx, client, &users)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result.Total != 2 {
+			t.Errorf("expected 2 users, got %d", result.Total)
+		}
+	})
+}
+
+func TestExecuteWithCursor(t *testing.T) {
+	ctx, client := seedUsers(t)
+
+	var seen int
+	b := builder.New().Kind("users").OrderAsc("age").Limit(3)
+	for pages := 0; ; pages++ {
+		if pages > 2 {
+			t.Fatal("paging did not stop")
+		}
+
+		var user testutil.TestUser
+		result, err := b.ExecuteWithCursor(ctx, client, &user)
+		if err != nil {
+			t.Fatalf("ExecuteWithCursor failed: %v", err)
+		}
+		seen += result.Total
+		if !result.HasMore {
+			break
+		}
+		b.Cursor(result.NextCursor)
+	}
+
+	if seen != 4 {
+		t.Errorf("expected 4 users over all pages, got %d", seen)
+	}
+}
+
+func TestFirst(t *testing.T) {
+	ctx, client := seedUsers(t)
+
+	var user testutil.TestUser
+	key, err := builder.New().Kind("users").OrderDesc("age").First(ctx, client, &user)
+	if err != nil {
+		t.Fatalf("First failed: %v", err)
+	}
+	if key.Name != "user3" || user.ID != "user3" {
+		t.Errorf("expected user3, got key %v and ID %q", key, user.ID)
+	}
+
+	_, err = builder.New().Kind("users").Where("status", "banned").First(ctx, client, &user)
+	if !errors.Is(err, datastore.ErrNoSuchEntity) {
+		t.Errorf("expected ErrNoSuchEntity, got %v", err)
+	}
+}
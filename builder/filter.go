@@ -1,6 +1,7 @@
 package builder
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 	"time"
@@ -9,6 +10,9 @@ import (
 // FilterBuilder helps build complex filters
 type FilterBuilder struct {
 	filters []FilterParam
+	naming  NamingStrategy
+	// err is the first unknown op met by FromStruct
+	err error
 }
 
 // NewFilter creates a new filter builder
@@ -108,7 +112,22 @@ func (f *FilterBuilder) Today(field string) *FilterBuilder {
 	return f.Between(field, start, end)
 }
 
-// FromStruct creates filters from struct fields
+// FromStruct creates filters from the non-zero fields of a struct. The
+// property name comes from the datastore tag, else the json tag, else the
+// field name passed through the NamingStrategy (see WithNaming and
+// SetNamingStrategy), lowercased by default. An op tag picks the operator,
+// "=" by default, as a symbol or a name:
+//
+//	type UserQuery struct {
+//		Status string   `datastore:"status"`
+//		MinAge int      `datastore:"age" op:">="`
+//		MaxAge int      `datastore:"age" op:"lt"`
+//		Roles  []string `datastore:"role" op:"in"`
+//	}
+//
+// Fields with a gostore tag, such as paging fields read by the repository,
+// fields of type QueryParams and unexported fields are skipped. An unknown op,
+// zero field or not, adds no filter and is reported by Err.
 func (f *FilterBuilder) FromStruct(s interface{}) *FilterBuilder {
 	v := reflect.ValueOf(s)
 	if v.Kind() == reflect.Ptr {
@@ -124,28 +143,63 @@ func (f *FilterBuilder) FromStruct(s interface{}) *FilterBuilder {
 		field := t.Field(i)
 		value := v.Field(i)
 
-		// Skip zero values
-		if isZeroValue(value) {
+		if !field.IsExported() || field.Tag.Get("gostore") != "" || indirectType(field.Type) == queryParamsType {
 			continue
 		}
 
-		// Get field name from tag
-		tag := field.Tag.Get("datastore")
-		if tag == "" || tag == "-" {
-			tag = field.Tag.Get("json")
+		op := field.Tag.Get("op")
+		operator, ok := ParseOperator(op)
+		if !ok {
+			if f.err == nil {
+				f.err = fmt.Errorf("field %s: unknown operator %q", field.Name, op)
+			}
+			continue
 		}
-		if tag == "" || tag == "-" {
-			tag = strings.ToLower(field.Name)
+
+		// Skip zero values
+		if isZeroValue(value) {
+			continue
 		}
+		f.filters = append(f.filters, FilterParam{
+			Field:    f.propertyName(field),
+			Operator: operator,
+			Value:    value.Interface(),
+		})
+	}
 
-		// Parse tag
-		tagParts := strings.Split(tag, ",")
-		fieldName := tagParts[0]
+	return f
+}
 
-		f.Equal(fieldName, value.Interface())
+var queryParamsType = reflect.TypeOf(QueryParams{})
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
+	return t
+}
 
-	return f
+// ParseOperator parses an operator symbol ("=", "!=", "<", "<=", ">", ">=",
+// "in") or name ("eq", "ne", "lt", "lte", "gt", "gte"), case-insensitively.
+// An empty string is Equal.
+func ParseOperator(s string) (FilterOperator, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "=", "==", "eq":
+		return Equal, true
+	case "!=", "ne":
+		return NotEqual, true
+	case "<", "lt":
+		return LessThan, true
+	case "<=", "lte":
+		return LessThanOrEqual, true
+	case ">", "gt":
+		return GreaterThan, true
+	case ">=", "gte":
+		return GreaterThanOrEqual, true
+	case "in":
+		return In, true
+	}
+	return "", false
 }
 
 // FromMap creates filters from map
@@ -192,6 +246,12 @@ func (f *FilterBuilder) Build() []FilterParam {
 	return f.filters
 }
 
+// Err returns the first unknown op met by FromStruct, whose field was left
+// out of the filters
+func (f *FilterBuilder) Err() error {
+	return f.err
+}
+
 // Helper function
 func isZeroValue(v reflect.Value) bool {
 	switch v.Kind() {
@@ -1,6 +1,8 @@
 package builder
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -21,3 +23,39 @@ func TestNewFilter(t *testing.T) {
 		}
 	})
 }
+
+func TestFromStructOperators(t *testing.T) {
+	type query struct {
+		Status string `datastore:"status"`
+		MinAge int    `datastore:"age" op:">="`
+		MaxAge int    `datastore:"age" op:"lt"`
+		Limit  int    `gostore:"limit"`
+		hidden string
+	}
+
+	filters := NewFilter().FromStruct(query{Status: "active", MinAge: 18, MaxAge: 65, Limit: 10, hidden: "x"}).Build()
+	want := []FilterParam{
+		{Field: "status", Operator: Equal, Value: "active"},
+		{Field: "age", Operator: GreaterThanOrEqual, Value: 18},
+		{Field: "age", Operator: LessThan, Value: 65},
+	}
+	if !reflect.DeepEqual(filters, want) {
+		t.Errorf("got %+v, want %+v", filters, want)
+	}
+}
+
+func TestFromStructUnknownOperator(t *testing.T) {
+	type query struct {
+		Status string `datastore:"status"`
+		MinAge int    `datastore:"age" op:"=>"`
+		Roles  []string
+	}
+
+	fb := NewFilter().FromStruct(query{Status: "active"})
+	if err := fb.Err(); err == nil || !strings.Contains(err.Error(), "MinAge") {
+		t.Errorf("expected the zero MinAge to be reported, got %v", err)
+	}
+	if filters := fb.Build(); len(filters) != 1 || filters[0].Field != "status" {
+		t.Errorf("expected only the status filter, got %+v", filters)
+	}
+}
@@ -0,0 +1,243 @@
+package builder
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// keyProperty is the pseudo-property filters and orders use for the key
+const keyProperty = "__key__"
+
+// Index is a composite index, as declared in index.yaml
+type Index struct {
+	Kind       string
+	Ancestor   bool
+	Properties []IndexProperty
+}
+
+// IndexProperty is a property of a composite index
+type IndexProperty struct {
+	Name      string
+	Direction OrderDirection
+}
+
+// String describes the index on one line, e.g. "users(status, age desc)"
+func (i *Index) String() string {
+	props := make([]string, len(i.Properties))
+	for j, p := range i.Properties {
+		props[j] = p.Name
+		if p.Direction == Descending {
+			props[j] += " desc"
+		}
+	}
+
+	s := i.Kind + "(" + strings.Join(props, ", ") + ")"
+	if i.Ancestor {
+		s = "ancestor " + s
+	}
+	return s
+}
+
+// IndexSpec returns the composite index the query built by b needs, and
+// false if Datastore's built-in single-property indexes serve it. Those serve
+// queries with only equality filters, and queries on a single property,
+// filtered by inequality or ordered, without an ancestor. Everything else,
+// such as several orders, an inequality with an order on another property or
+// a projection of several properties, needs a composite index: the equality
+// properties, then the inequality properties and orders, then the remaining
+// projected properties.
+func IndexSpec(b *Builder) (*Index, bool) {
+	if b.kind == "" {
+		return nil, false
+	}
+
+	var equality, inequality []string
+	for _, f := range b.params.Filters {
+		if f.Field == keyProperty {
+			continue
+		}
+		switch f.Operator {
+		case Equal, In:
+			if !slices.Contains(equality, f.Field) {
+				equality = append(equality, f.Field)
+			}
+		default:
+			if !slices.Contains(inequality, f.Field) {
+				inequality = append(inequality, f.Field)
+			}
+		}
+	}
+
+	index := &Index{Kind: b.kind, Ancestor: b.params.Ancestor != nil}
+	has := func(name string) bool {
+		return slices.ContainsFunc(index.Properties, func(p IndexProperty) bool { return p.Name == name })
+	}
+	add := func(name string, dir OrderDirection) {
+		if !has(name) {
+			index.Properties = append(index.Properties, IndexProperty{Name: name, Direction: dir})
+		}
+	}
+
+	for _, name := range equality {
+		add(name, Ascending)
+	}
+	ordered := len(index.Properties)
+
+	// Inequality properties sort first, in the direction they are ordered in
+	orders := b.params.Orders
+	for _, name := range inequality {
+		dir := Ascending
+		if i := slices.IndexFunc(orders, func(o OrderParam) bool { return o.Field == name }); i >= 0 {
+			dir = orders[i].Direction
+		}
+		add(name, dir)
+	}
+	for i, o := range orders {
+		// Results are ordered by key last anyway
+		if o.Field == keyProperty && o.Direction != Descending && i == len(orders)-1 {
+			continue
+		}
+		if o.Field == keyProperty || !slices.Contains(equality, o.Field) {
+			add(o.Field, o.Direction)
+		}
+	}
+	ordered = len(index.Properties) - ordered
+
+	for _, name := range b.params.Select {
+		add(name, Ascending)
+	}
+
+	switch {
+	case len(index.Properties) == 0:
+		return nil, false
+	case ordered == 0 && len(index.Properties) == len(equality):
+		// Equality filters alone are merged from the built-in indexes
+		return nil, false
+	case !index.Ancestor && len(index.Properties) == 1:
+		return nil, false
+	}
+	return index, true
+}
+
+// WriteIndexYAML writes specs to w in index.yaml syntax, skipping nil specs
+// and duplicates and sorting them by kind and properties so the output is
+// stable
+func WriteIndexYAML(w io.Writer, specs ...*Index) error {
+	seen := map[string]bool{}
+	var indexes []*Index
+	for _, spec := range specs {
+		if spec == nil || seen[spec.String()] {
+			continue
+		}
+		seen[spec.String()] = true
+		indexes = append(indexes, spec)
+	}
+	slices.SortFunc(indexes, func(a, b *Index) int {
+		if c := strings.Compare(a.Kind, b.Kind); c != 0 {
+			return c
+		}
+		return strings.Compare(a.String(), b.String())
+	})
+
+	var sb strings.Builder
+	sb.WriteString("indexes:\n")
+	for _, index := range indexes {
+		fmt.Fprintf(&sb, "\n- kind: %s\n", yamlString(index.Kind))
+		if index.Ancestor {
+			sb.WriteString("  ancestor: yes\n")
+		}
+		sb.WriteString("  properties:\n")
+		for _, p := range index.Properties {
+			fmt.Fprintf(&sb, "  - name: %s\n", yamlString(p.Name))
+			if p.Direction == Descending {
+				sb.WriteString("    direction: desc\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// yamlString quotes s unless it is a plain YAML scalar
+func yamlString(s string) string {
+	plain := s != ""
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		case (r >= '0' && r <= '9') || r == '.' || r == '-':
+			plain = plain && i > 0
+		default:
+			plain = false
+		}
+	}
+	if plain {
+		return s
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+// IndexRecorder collects the composite indexes of the queries built while it
+// is recording, see RecordIndexes
+type IndexRecorder struct {
+	mu      sync.Mutex
+	indexes []*Index
+}
+
+var indexRecorder atomic.Pointer[IndexRecorder]
+
+// RecordIndexes makes every Builder record the index its query needs (see
+// IndexSpec) when it is built, until Stop is called, so a test suite can
+// generate index.yaml from the queries it runs:
+//
+//	func TestMain(m *testing.M) {
+//		indexes := builder.RecordIndexes()
+//		code := m.Run()
+//		indexes.Stop()
+//		f, _ := os.Create("index.yaml")
+//		indexes.WriteYAML(f)
+//		f.Close()
+//		os.Exit(code)
+//	}
+//
+// Only one recorder is active at a time; starting one stops the previous.
+func RecordIndexes() *IndexRecorder {
+	r := &IndexRecorder{}
+	indexRecorder.Store(r)
+	return r
+}
+
+// Stop stops recording
+func (r *IndexRecorder) Stop() {
+	indexRecorder.CompareAndSwap(r, nil)
+}
+
+// Indexes returns the composite indexes recorded so far, without duplicates
+func (r *IndexRecorder) Indexes() []*Index {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.indexes)
+}
+
+// WriteYAML writes the recorded indexes to w with WriteIndexYAML
+func (r *IndexRecorder) WriteYAML(w io.Writer) error {
+	return WriteIndexYAML(w, r.Indexes()...)
+}
+
+// record adds the index b needs, if any
+func (r *IndexRecorder) record(b *Builder) {
+	index, ok := IndexSpec(b)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !slices.ContainsFunc(r.indexes, func(i *Index) bool { return i.String() == index.String() }) {
+		r.indexes = append(r.indexes, index)
+	}
+}
@@ -0,0 +1,121 @@
+package builder
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares got with testdata/name, rewriting it with -update
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("failed to update %s: %v", path, err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch, run with -update to accept\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestIndexSpec(t *testing.T) {
+	tests := []struct {
+		name string
+		b    *Builder
+		want string // empty when no composite index is needed
+	}{
+		{"whole kind", New().Kind("users"), ""},
+		{"equality filters", New().Kind("users").Where("status", "active").Where("role", "admin"), ""},
+		{"single inequality", New().Kind("users").Filter("age", GreaterThan, 18), ""},
+		{"single descending order", New().Kind("users").OrderDesc("created_at"), ""},
+		{"inequality ordered by itself", New().Kind("users").Filter("age", GreaterThan, 18).OrderDesc("age"), ""},
+		{"order on an equality property", New().Kind("users").Where("status", "active").OrderAsc("status"), ""},
+		{"ancestor with equality", New().Kind("posts").Ancestor("users", "u1").Where("draft", false), ""},
+		{"single projection", New().Kind("users").Select("email"), ""},
+		{"trailing key order", New().Kind("users").OrderAsc("name").OrderAsc("__key__"), ""},
+		{"kindless", New().OrderAsc("a").OrderAsc("b"), ""},
+
+		{"equality and order", New().Kind("users").Where("status", "active").OrderDesc("created_at"),
+			"users(status, created_at desc)"},
+		{"multiple orders", New().Kind("users").OrderAsc("last").OrderAsc("first"),
+			"users(last, first)"},
+		{"inequality and a different order", New().Kind("users").Filter("age", GreaterThanOrEqual, 18).OrderDesc("name"),
+			"users(age, name desc)"},
+		{"equality, inequality and orders", New().Kind("users").Where("status", "active").Filter("age", LessThan, 65).OrderDesc("age").OrderAsc("name"),
+			"users(status, age desc, name)"},
+		{"in counts as equality", New().Kind("users").Filter("role", In, []string{"a", "b"}).OrderAsc("name"),
+			"users(role, name)"},
+		{"ancestor and order", New().Kind("posts").Ancestor("users", "u1").OrderDesc("created_at"),
+			"ancestor posts(created_at desc)"},
+		{"projection", New().Kind("users").Select("status", "email").Distinct(),
+			"users(status, email)"},
+		{"filtered projection", New().Kind("users").Where("status", "active").Select("email"),
+			"users(status, email)"},
+		{"descending key order", New().Kind("users").Where("status", "active").OrderDesc("__key__"),
+			"users(status, __key__ desc)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, ok := IndexSpec(tt.b)
+			if ok != (tt.want != "") {
+				t.Fatalf("expected needed=%v, got %v (%v)", tt.want != "", ok, index)
+			}
+			if ok && index.String() != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, index)
+			}
+		})
+	}
+}
+
+func TestWriteIndexYAML(t *testing.T) {
+	var specs []*Index
+	for _, b := range []*Builder{
+		New().Kind("users").Where("status", "active").OrderDesc("created_at"),
+		New().Kind("users").Filter("age", GreaterThanOrEqual, 18).OrderDesc("name"),
+		New().Kind("posts").Ancestor("users", "u1").OrderDesc("created_at"),
+		New().Kind("users").Where("status", "inactive").OrderDesc("created_at"), // duplicate
+		New().Kind("users").Where("status", "active"),                           // no index
+		New().Kind("audit log").OrderAsc("at").OrderAsc("actor"),
+	} {
+		index, _ := IndexSpec(b)
+		specs = append(specs, index)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteIndexYAML(&buf, specs...); err != nil {
+		t.Fatalf("WriteIndexYAML: %v", err)
+	}
+	golden(t, "index.yaml", buf.Bytes())
+}
+
+func TestRecordIndexes(t *testing.T) {
+	rec := RecordIndexes()
+	New().Kind("users").Where("status", "active").OrderDesc("created_at").Build()
+	New().Kind("users").Where("status", "active").Build()
+	New().Kind("users").Where("status", "banned").OrderDesc("created_at").Build()
+	rec.Stop()
+	New().Kind("users").OrderAsc("a").OrderAsc("b").Build()
+
+	indexes := rec.Indexes()
+	if len(indexes) != 1 || indexes[0].String() != "users(status, created_at desc)" {
+		t.Errorf("expected only users(status, created_at desc), got %v", indexes)
+	}
+
+	var buf bytes.Buffer
+	if err := rec.WriteYAML(&buf); err != nil {
+		t.Fatalf("WriteYAML: %v", err)
+	}
+	golden(t, "recorded_index.yaml", buf.Bytes())
+}
@@ -0,0 +1,66 @@
+package builder
+
+import (
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrMissingIndex is returned by Execute, ExecuteWithCursor, First and the
+// Count, Sum and Avg methods when Datastore rejects the query for lack of a
+// composite index. It carries the index the query needs, as IndexSpec
+// derives it from the Builder's filters and orders, and its error message
+// includes the index.yaml snippet declaring it:
+//
+//	var missing *builder.ErrMissingIndex
+//	if errors.As(err, &missing) {
+//		log.Printf("add %v to index.yaml", missing.Index)
+//	}
+type ErrMissingIndex struct {
+	// Index is nil if IndexSpec finds the query served by the built-in
+	// indexes, where Datastore disagrees
+	Index *Index
+	Err   error
+}
+
+func (e *ErrMissingIndex) Error() string {
+	if e.Index == nil {
+		return "missing composite index: " + e.Err.Error()
+	}
+
+	var sb strings.Builder
+	sb.WriteString("missing composite index ")
+	sb.WriteString(e.Index.String())
+	sb.WriteString(": ")
+	sb.WriteString(e.Err.Error())
+	sb.WriteString("\nadd it to index.yaml:\n")
+	// Writing to a strings.Builder doesn't fail
+	_ = WriteIndexYAML(&sb, e.Index)
+	return sb.String()
+}
+
+// Unwrap returns the error Datastore returned
+func (e *ErrMissingIndex) Unwrap() error {
+	return e.Err
+}
+
+// missingIndex wraps err in an *ErrMissingIndex for b if it is Datastore's
+// FAILED_PRECONDITION "no matching index found" error, and returns other
+// errors unchanged
+func missingIndex(b *Builder, err error) error {
+	if err == nil || status.Code(err) != codes.FailedPrecondition {
+		return err
+	}
+	if !strings.Contains(strings.ToLower(err.Error()), "no matching index") {
+		return err
+	}
+	var missing *ErrMissingIndex
+	if errors.As(err, &missing) {
+		return err
+	}
+
+	index, _ := IndexSpec(b)
+	return &ErrMissingIndex{Index: index, Err: err}
+}
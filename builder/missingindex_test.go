@@ -0,0 +1,98 @@
+package builder_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMissingIndex(t *testing.T) {
+	ctx := context.Background()
+	noIndex := status.Error(codes.FailedPrecondition, "no matching index found. recommended index is: ...")
+
+	tests := []struct {
+		name string
+		b    *builder.Builder
+		want string
+		yaml string
+	}{
+		{
+			name: "Equality and order",
+			b:    builder.New().Kind("users").Where("status", "active").OrderDesc("created_at"),
+			want: "users(status, created_at desc)",
+			yaml: "- kind: users\n  properties:\n  - name: status\n  - name: created_at\n    direction: desc\n",
+		},
+		{
+			name: "Inequality and a different order",
+			b:    builder.New().Kind("users").Filter("age", builder.GreaterThanOrEqual, 18).OrderDesc("name"),
+			want: "users(age, name desc)",
+			yaml: "- kind: users\n  properties:\n  - name: age\n  - name: name\n    direction: desc\n",
+		},
+	}
+
+	check := func(t *testing.T, err error, want, yaml string) {
+		t.Helper()
+		var missing *builder.ErrMissingIndex
+		if !errors.As(err, &missing) {
+			t.Fatalf("expected an *ErrMissingIndex, got %v", err)
+		}
+		if missing.Index == nil || missing.Index.String() != want {
+			t.Errorf("expected index %s, got %v", want, missing.Index)
+		}
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("expected the status code to be kept, got %v", status.Code(err))
+		}
+		if !strings.Contains(err.Error(), yaml) {
+			t.Errorf("expected the message to hold the index.yaml snippet\n%s\ngot:\n%s", yaml, err)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := testutil.NewMockClient()
+
+			mock.FailNext(testutil.OpGetAll, noIndex, 1)
+			var users []testutil.TestUser
+			_, err := tt.b.Execute(ctx, mock, &users)
+			check(t, err, tt.want, tt.yaml)
+
+			mock.FailNext(testutil.OpRun, noIndex, 1)
+			var user testutil.TestUser
+			_, err = tt.b.ExecuteWithCursor(ctx, mock, &user)
+			check(t, err, tt.want, tt.yaml)
+
+			mock.FailNext(testutil.OpRun, noIndex, 1)
+			_, err = tt.b.Count(ctx, mock)
+			check(t, err, tt.want, tt.yaml)
+
+			mock.FailNext(testutil.OpRun, noIndex, 1)
+			_, err = tt.b.First(ctx, mock, &user)
+			check(t, err, tt.want, tt.yaml)
+
+			mock.FailNext(testutil.OpRunAggregationQuery, noIndex, 2)
+			_, err = tt.b.SumAggregate(ctx, mock, "score")
+			check(t, err, tt.want, tt.yaml)
+			_, err = tt.b.AvgAggregate(ctx, mock, "score")
+			check(t, err, tt.want, tt.yaml)
+		})
+	}
+
+	t.Run("Other errors are returned unchanged", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		precondition := status.Error(codes.FailedPrecondition, "transaction expired")
+		mock.FailNext(testutil.OpGetAll, precondition, 1)
+
+		var users []testutil.TestUser
+		_, err := tests[0].b.Execute(ctx, mock, &users)
+		var missing *builder.ErrMissingIndex
+		if err != precondition || errors.As(err, &missing) {
+			t.Errorf("expected the error unchanged, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package builder
+
+import (
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// NamingStrategy derives a property name from a Go field name, for fields
+// without a datastore or json tag
+type NamingStrategy func(goField string) string
+
+var namingStrategy atomic.Pointer[NamingStrategy]
+
+// SetNamingStrategy sets the NamingStrategy of FromStruct for every
+// FilterBuilder without its own, see FilterBuilder.WithNaming. A nil
+// strategy restores the default, Lowercase.
+func SetNamingStrategy(strategy NamingStrategy) {
+	if strategy == nil {
+		namingStrategy.Store(nil)
+		return
+	}
+	namingStrategy.Store(&strategy)
+}
+
+// currentNamingStrategy returns the strategy set with SetNamingStrategy
+func currentNamingStrategy() NamingStrategy {
+	if s := namingStrategy.Load(); s != nil {
+		return *s
+	}
+	return Lowercase
+}
+
+// Lowercase lowercases the field name, e.g. "CreatedAt" becomes "createdat".
+// It is the default NamingStrategy.
+func Lowercase(goField string) string {
+	return strings.ToLower(goField)
+}
+
+// AsIs keeps the field name, e.g. "CreatedAt" stays "CreatedAt", as the
+// datastore package names untagged properties
+func AsIs(goField string) string {
+	return goField
+}
+
+// SnakeCase converts the field name to snake_case, keeping initialisms
+// together, e.g. "CreatedAt" becomes "created_at" and "UserID" "user_id"
+func SnakeCase(goField string) string {
+	runes := []rune(goField)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// LowerCamel lowercases the leading word of the field name, e.g. "CreatedAt"
+// becomes "createdAt", "UserID" "userID" and "HTTPRequest" "httpRequest"
+func LowerCamel(goField string) string {
+	runes := []rune(goField)
+	for i, r := range runes {
+		if !unicode.IsUpper(r) {
+			break
+		}
+		// The last capital before a lowercase letter starts the next word
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(r)
+	}
+	return string(runes)
+}
+
+// WithNaming makes FromStruct name untagged fields with strategy instead of
+// the one set with SetNamingStrategy
+func (f *FilterBuilder) WithNaming(strategy NamingStrategy) *FilterBuilder {
+	f.naming = strategy
+	return f
+}
+
+// propertyName returns the property name of field: that of its datastore
+// tag, else of its json tag, else the field name passed through the naming
+// strategy
+func (f *FilterBuilder) propertyName(field reflect.StructField) string {
+	for _, key := range []string{"datastore", "json"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	naming := f.naming
+	if naming == nil {
+		naming = currentNamingStrategy()
+	}
+	return naming(field.Name)
+}
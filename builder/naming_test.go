@@ -0,0 +1,64 @@
+package builder
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNamingStrategies(t *testing.T) {
+	tests := []struct {
+		field, snake, camel string
+	}{
+		{"Name", "name", "name"},
+		{"CreatedAt", "created_at", "createdAt"},
+		{"LastLoginIP", "last_login_ip", "lastLoginIP"},
+		{"UserID", "user_id", "userID"},
+		{"ID", "id", "id"},
+		{"HTTPRequest", "http_request", "httpRequest"},
+	}
+	for _, tt := range tests {
+		if got := SnakeCase(tt.field); got != tt.snake {
+			t.Errorf("SnakeCase(%q) = %q, want %q", tt.field, got, tt.snake)
+		}
+		if got := LowerCamel(tt.field); got != tt.camel {
+			t.Errorf("LowerCamel(%q) = %q, want %q", tt.field, got, tt.camel)
+		}
+		if got := AsIs(tt.field); got != tt.field {
+			t.Errorf("AsIs(%q) = %q", tt.field, got)
+		}
+	}
+}
+
+func TestFromStructNaming(t *testing.T) {
+	type query struct {
+		CreatedAt string
+		UserID    int
+		Status    string `datastore:"state"`
+		Email     string `json:"mail,omitempty"`
+		Indexed   string `datastore:",noindex"`
+	}
+	q := query{CreatedAt: "today", UserID: 7, Status: "active", Email: "a@b.c", Indexed: "x"}
+
+	fields := func(fb *FilterBuilder) []string {
+		var names []string
+		for _, f := range fb.FromStruct(q).Build() {
+			names = append(names, f.Field)
+		}
+		return names
+	}
+	if got, want := fields(NewFilter()), []string{"createdat", "userid", "state", "mail", "indexed"}; !slices.Equal(got, want) {
+		t.Errorf("default: got %v, want %v", got, want)
+	}
+	if got, want := fields(NewFilter().WithNaming(SnakeCase)), []string{"created_at", "user_id", "state", "mail", "indexed"}; !slices.Equal(got, want) {
+		t.Errorf("snake_case: got %v, want %v", got, want)
+	}
+
+	SetNamingStrategy(LowerCamel)
+	defer SetNamingStrategy(nil)
+	if got, want := fields(NewFilter()), []string{"createdAt", "userID", "state", "mail", "indexed"}; !slices.Equal(got, want) {
+		t.Errorf("global lowerCamel: got %v, want %v", got, want)
+	}
+	if got, want := fields(NewFilter().WithNaming(AsIs)), []string{"CreatedAt", "UserID", "state", "mail", "Indexed"}; !slices.Equal(got, want) {
+		t.Errorf("builder strategy over global: got %v, want %v", got, want)
+	}
+}
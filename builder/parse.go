@@ -0,0 +1,582 @@
+package builder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/datastore"
+)
+
+// ParseError reports where a query given to Parse is malformed
+type ParseError struct {
+	// Pos is the 1-based position of the offending token, in characters
+	Pos int
+	// Expected describes what the parser was looking for
+	Expected string
+	// Found is the offending token, or "end of query"
+	Found string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse error at position %d: expected %s, found %s", e.Pos, e.Expected, e.Found)
+}
+
+// Parse builds a query from its text form, as written by String:
+//
+//	SELECT * FROM users WHERE status = "active" AND age >= 18 ORDER BY created_at DESC LIMIT 20
+//
+// The SELECT, FROM and WHERE clauses are optional, so a bare condition list
+// such as `status = "active" AND age >= 18 ORDER BY -created_at LIMIT 20`
+// parses too, leaving the kind to be set with Kind. Keywords are
+// case-insensitive and names that aren't plain identifiers or are keywords
+// go in backquotes. The grammar is:
+//
+//	query     = [SELECT selection] [FROM name [IN NAMESPACE string]]
+//	            [[WHERE] condition {AND condition}] [ORDER BY order {, order}]
+//	            [LIMIT int] [OFFSET int] [KEYS ONLY]
+//	selection = * | __key__ | [DISTINCT] name {, name}
+//	condition = name op value | name IN [ARRAY] (value {, value})
+//	          | __key__ HAS ANCESTOR KEY(name, value)
+//	order     = [+|-] name [ASC|DESC]
+//	op        = "=" | "!=" | "<>" | "<" | "<=" | ">" | ">="
+//	value     = string | int | float | TRUE | FALSE | NULL | timestamp
+//	          | KEY(name, value {, name, value})
+//
+// Strings are double-quoted with Go escapes, timestamps are unquoted RFC 3339
+// such as 2024-05-01T12:00:00Z, and KEY values list the path from the root,
+// each element a non-empty name or a positive ID. Integers are int64. Errors
+// are *ParseError.
+func Parse(q string) (*Builder, error) {
+	tokens, err := lex(q)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	return p.query()
+}
+
+// tokenKind classifies a token of the query language
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenName
+	// tokenQuoted is a backquoted name, which is never a keyword
+	tokenQuoted
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString, tokenQuoted:
+		return t.text
+	}
+	return strconv.Quote(t.text)
+}
+
+// keywords can't be used as plain names
+var keywords = map[string]bool{
+	"SELECT": true, "DISTINCT": true, "FROM": true, "IN": true, "NAMESPACE": true,
+	"WHERE": true, "AND": true, "OR": true, "ORDER": true, "BY": true, "ASC": true,
+	"DESC": true, "LIMIT": true, "OFFSET": true, "KEYS": true, "ONLY": true,
+	"TRUE": true, "FALSE": true, "NULL": true, "ARRAY": true, "HAS": true,
+	"ANCESTOR": true, "KEY": true,
+}
+
+func isNameStart(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isNamePart(r rune) bool {
+	return isNameStart(r) || r == '.' || (r >= '0' && r <= '9')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// lex splits q into tokens
+func lex(q string) ([]token, error) {
+	var tokens []token
+	pos := func(i int) int {
+		return utf8.RuneCountInString(q[:i]) + 1
+	}
+
+	for i := 0; i < len(q); {
+		r, size := utf8.DecodeRuneInString(q[i:])
+		start := i
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			i += size
+
+		case isNameStart(r):
+			for i < len(q) {
+				r, size := utf8.DecodeRuneInString(q[i:])
+				if !isNamePart(r) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, token{kind: tokenName, text: q[start:i], pos: pos(start)})
+
+		case r == '`':
+			end := strings.IndexByte(q[i+1:], '`')
+			if end < 0 {
+				return nil, &ParseError{Pos: pos(start), Expected: "closing `", Found: "end of query"}
+			}
+			i += end + 2
+			tokens = append(tokens, token{kind: tokenQuoted, text: q[start:i], value: q[start+1 : i-1], pos: pos(start)})
+
+		case r == '"':
+			i++
+			for i < len(q) && q[i] != '"' {
+				if q[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(q) {
+				return nil, &ParseError{Pos: pos(start), Expected: `closing "`, Found: "end of query"}
+			}
+			i++
+			s, err := strconv.Unquote(q[start:i])
+			if err != nil {
+				return nil, &ParseError{Pos: pos(start), Expected: "valid string", Found: q[start:i]}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: q[start:i], value: s, pos: pos(start)})
+
+		case r < utf8.RuneSelf && (isDigit(q[i]) || (q[i] == '-' && i+1 < len(q) && isDigit(q[i+1]))):
+			i++
+			for i < len(q) && (isDigit(q[i]) || strings.IndexByte("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ.:+-", q[i]) >= 0) {
+				i++
+			}
+			value, ok := parseNumber(q[start:i])
+			if !ok {
+				return nil, &ParseError{Pos: pos(start), Expected: "number or RFC 3339 timestamp", Found: strconv.Quote(q[start:i])}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: q[start:i], value: value, pos: pos(start)})
+
+		default:
+			for _, sym := range []string{"!=", "<>", "<=", ">=", "=", "<", ">", "(", ")", ",", "*", "+", "-"} {
+				if strings.HasPrefix(q[i:], sym) {
+					i += len(sym)
+					if sym == "<>" {
+						sym = "!="
+					}
+					tokens = append(tokens, token{kind: tokenSymbol, text: sym, pos: pos(start)})
+					break
+				}
+			}
+			if i == start {
+				return nil, &ParseError{Pos: pos(start), Expected: "name, value or operator", Found: strconv.Quote(string(r))}
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: utf8.RuneCountInString(q) + 1}), nil
+}
+
+// parseNumber parses an integer, float or RFC 3339 timestamp literal
+func parseNumber(s string) (interface{}, bool) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, true
+	}
+	if strings.Trim(s, "0123456789.eE+-") == "" {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, true
+		}
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+	return nil, false
+}
+
+// parser is a recursive descent parser over the tokens of a query
+type parser struct {
+	tokens []token
+	i      int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) errorf(expected string, args ...interface{}) error {
+	t := p.peek()
+	return &ParseError{Pos: t.pos, Expected: fmt.Sprintf(expected, args...), Found: t.String()}
+}
+
+// isKeyword reports whether the next token is the keyword kw
+func (p *parser) isKeyword(kw string) bool {
+	t := p.peek()
+	return t.kind == tokenName && strings.EqualFold(t.text, kw)
+}
+
+// keyword consumes the keyword kw if it is next
+func (p *parser) keyword(kw string) bool {
+	if p.isKeyword(kw) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if !p.keyword(kw) {
+		return p.errorf("%s", kw)
+	}
+	return nil
+}
+
+// symbol consumes the symbol s if it is next
+func (p *parser) symbol(s string) bool {
+	if t := p.peek(); t.kind == tokenSymbol && t.text == s {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectSymbol(s string) error {
+	if !p.symbol(s) {
+		return p.errorf("%q", s)
+	}
+	return nil
+}
+
+// name consumes a property or kind name
+func (p *parser) name(what string) (string, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenQuoted:
+		p.i++
+		return t.value.(string), nil
+	case t.kind == tokenName && !keywords[strings.ToUpper(t.text)]:
+		p.i++
+		return t.text, nil
+	}
+	return "", p.errorf("%s", what)
+}
+
+func (p *parser) query() (*Builder, error) {
+	b := New()
+
+	selected := p.keyword("SELECT")
+	if selected {
+		if err := p.selection(b); err != nil {
+			return nil, err
+		}
+	}
+	if p.keyword("FROM") {
+		kind, err := p.name("kind name")
+		if err != nil {
+			return nil, err
+		}
+		b.Kind(kind)
+		if p.keyword("IN") {
+			if err := p.expectKeyword("NAMESPACE"); err != nil {
+				return nil, err
+			}
+			t := p.peek()
+			if t.kind != tokenString {
+				return nil, p.errorf("namespace string")
+			}
+			p.i++
+			b.Namespace(t.value.(string))
+		}
+	} else if selected {
+		return nil, p.errorf("FROM")
+	}
+
+	where := p.keyword("WHERE")
+	if where || !p.isClause() {
+		if err := p.conditions(b); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.keyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		if err := p.orders(b); err != nil {
+			return nil, err
+		}
+	}
+	if p.keyword("LIMIT") {
+		n, err := p.count("LIMIT")
+		if err != nil {
+			return nil, err
+		}
+		b.Limit(n)
+	}
+	if p.keyword("OFFSET") {
+		n, err := p.count("OFFSET")
+		if err != nil {
+			return nil, err
+		}
+		b.Offset(n)
+	}
+	if p.keyword("KEYS") {
+		if err := p.expectKeyword("ONLY"); err != nil {
+			return nil, err
+		}
+		b.KeysOnly()
+	}
+
+	if p.peek().kind != tokenEOF {
+		return nil, p.errorf("end of query")
+	}
+	return b, nil
+}
+
+// isClause reports whether the next token starts a clause after the
+// conditions, or ends the query
+func (p *parser) isClause() bool {
+	return p.peek().kind == tokenEOF || p.isKeyword("ORDER") || p.isKeyword("LIMIT") ||
+		p.isKeyword("OFFSET") || p.isKeyword("KEYS")
+}
+
+func (p *parser) selection(b *Builder) error {
+	if p.symbol("*") {
+		return nil
+	}
+	if t := p.peek(); t.kind == tokenName && t.text == keyProperty {
+		p.i++
+		b.KeysOnly()
+		return nil
+	}
+
+	distinct := p.keyword("DISTINCT")
+	var fields []string
+	for {
+		field, err := p.name("property name or *")
+		if err != nil {
+			return err
+		}
+		fields = append(fields, field)
+		if !p.symbol(",") {
+			break
+		}
+	}
+	b.Select(fields...)
+	if distinct {
+		b.Distinct()
+	}
+	return nil
+}
+
+func (p *parser) conditions(b *Builder) error {
+	for {
+		if err := p.condition(b); err != nil {
+			return err
+		}
+		if p.isKeyword("OR") {
+			return p.errorf("AND (OR is not supported)")
+		}
+		if !p.keyword("AND") {
+			return nil
+		}
+	}
+}
+
+func (p *parser) condition(b *Builder) error {
+	if t := p.peek(); t.kind == tokenName && t.text == keyProperty && p.tokens[p.i+1].kind == tokenName &&
+		strings.EqualFold(p.tokens[p.i+1].text, "HAS") {
+		p.i += 2
+		if err := p.expectKeyword("ANCESTOR"); err != nil {
+			return err
+		}
+		if err := p.expectKeyword("KEY"); err != nil {
+			return err
+		}
+		if err := p.expectSymbol("("); err != nil {
+			return err
+		}
+		kind, err := p.name("ancestor kind name")
+		if err != nil {
+			return err
+		}
+		if err := p.expectSymbol(","); err != nil {
+			return err
+		}
+		id, err := p.id()
+		if err != nil {
+			return err
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return err
+		}
+		b.Ancestor(kind, id)
+		return nil
+	}
+
+	field, err := p.name("property name")
+	if err != nil {
+		return err
+	}
+
+	if p.keyword("IN") {
+		p.keyword("ARRAY")
+		if err := p.expectSymbol("("); err != nil {
+			return err
+		}
+		var values []interface{}
+		for {
+			v, err := p.value()
+			if err != nil {
+				return err
+			}
+			values = append(values, v)
+			if !p.symbol(",") {
+				break
+			}
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return err
+		}
+		b.Filter(field, In, values)
+		return nil
+	}
+
+	t := p.peek()
+	var op FilterOperator
+	if t.kind == tokenSymbol {
+		switch t.text {
+		case "=", "!=", "<", "<=", ">", ">=":
+			op, _ = ParseOperator(t.text)
+		}
+	}
+	if op == "" {
+		return p.errorf("operator (=, !=, <, <=, >, >= or IN)")
+	}
+	p.i++
+
+	v, err := p.value()
+	if err != nil {
+		return err
+	}
+	b.Filter(field, op, v)
+	return nil
+}
+
+// value consumes a literal value
+func (p *parser) value() (interface{}, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenString || t.kind == tokenNumber:
+		p.i++
+		return t.value, nil
+	case p.keyword("TRUE"):
+		return true, nil
+	case p.keyword("FALSE"):
+		return false, nil
+	case p.keyword("NULL"):
+		return nil, nil
+	case p.keyword("KEY"):
+		return p.key()
+	}
+	return nil, p.errorf("value")
+}
+
+// id consumes a key name or integer ID. Empty names and non-positive IDs
+// would make an incomplete key, which Datastore rejects in queries, so they
+// fail here with a position rather than at Execute.
+func (p *parser) id() (interface{}, error) {
+	t := p.peek()
+	if t.kind == tokenString {
+		if t.value == "" {
+			return nil, p.errorf("non-empty key name")
+		}
+		p.i++
+		return t.value, nil
+	}
+	if id, ok := t.value.(int64); ok {
+		if id <= 0 {
+			return nil, p.errorf("positive integer ID")
+		}
+		p.i++
+		return id, nil
+	}
+	return nil, p.errorf("key name string or integer ID")
+}
+
+// key consumes the path of a KEY value, after the keyword
+func (p *parser) key() (*datastore.Key, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	var key *datastore.Key
+	for {
+		kind, err := p.name("key kind name")
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol(","); err != nil {
+			return nil, err
+		}
+		id, err := p.id()
+		if err != nil {
+			return nil, err
+		}
+		if name, ok := id.(string); ok {
+			key = datastore.NameKey(kind, name, key)
+		} else {
+			key = datastore.IDKey(kind, id.(int64), key)
+		}
+		if !p.symbol(",") {
+			break
+		}
+	}
+	return key, p.expectSymbol(")")
+}
+
+func (p *parser) orders(b *Builder) error {
+	for {
+		dir := Ascending
+		if p.symbol("-") {
+			dir = Descending
+		} else {
+			p.symbol("+")
+		}
+		field, err := p.name("property name to order by")
+		if err != nil {
+			return err
+		}
+		switch {
+		case p.keyword("DESC"):
+			dir = Descending
+		case p.keyword("ASC"):
+			dir = Ascending
+		}
+		b.Order(field, dir)
+		if !p.symbol(",") {
+			return nil
+		}
+	}
+}
+
+// count consumes the non-negative integer of a LIMIT or OFFSET
+func (p *parser) count(clause string) (int, error) {
+	t := p.peek()
+	n, ok := t.value.(int64)
+	if t.kind != tokenNumber || !ok || n < 0 || n > 1<<31-1 {
+		return 0, p.errorf("non-negative integer after %s", clause)
+	}
+	p.i++
+	return int(n), nil
+}
@@ -0,0 +1,183 @@
+package builder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+func TestParse(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	parent := datastore.NameKey("users", "u1", nil)
+
+	tests := []struct {
+		name  string
+		query string
+		want  *Builder
+	}{
+		{
+			name:  "conditions only",
+			query: `status = "active" AND age >= 18 ORDER BY -created_at LIMIT 20`,
+			want:  New().Where("status", "active").Filter("age", GreaterThanOrEqual, int64(18)).OrderDesc("created_at").Limit(20),
+		},
+		{
+			name:  "full form",
+			query: `SELECT * FROM users WHERE status = "active" AND age >= 18 ORDER BY created_at DESC, name LIMIT 10 OFFSET 20`,
+			want: New().Kind("users").Where("status", "active").Filter("age", GreaterThanOrEqual, int64(18)).
+				OrderDesc("created_at").OrderAsc("name").Limit(10).Offset(20),
+		},
+		{
+			name:  "keywords in any case",
+			query: `select * from users where verified = TRUE and score < 9.5 order by +score asc limit 5`,
+			want:  New().Kind("users").Where("verified", true).Filter("score", LessThan, 9.5).OrderAsc("score").Limit(5),
+		},
+		{
+			name:  "every operator",
+			query: `a = 1 AND b != 2 AND c < 3 AND d <= 4 AND e > 5 AND f >= -6 AND g <> 7`,
+			want: New().Filter("a", Equal, int64(1)).Filter("b", NotEqual, int64(2)).Filter("c", LessThan, int64(3)).
+				Filter("d", LessThanOrEqual, int64(4)).Filter("e", GreaterThan, int64(5)).
+				Filter("f", GreaterThanOrEqual, int64(-6)).Filter("g", NotEqual, int64(7)),
+		},
+		{
+			name:  "literals",
+			query: `created_at > 2024-05-01T12:30:00Z AND deleted_at = NULL AND archived = false AND owner = KEY(users, "u1") AND note = "say \"hi\""`,
+			want: New().Filter("created_at", GreaterThan, created).Where("deleted_at", nil).Where("archived", false).
+				Where("owner", parent).Where("note", `say "hi"`),
+		},
+		{
+			name:  "in lists",
+			query: `role IN ("admin", "editor") AND tier IN ARRAY(1, 2)`,
+			want:  New().Filter("role", In, []interface{}{"admin", "editor"}).Filter("tier", In, []interface{}{int64(1), int64(2)}),
+		},
+		{
+			name:  "projection ancestor and namespace",
+			query: `SELECT DISTINCT title, author FROM posts IN NAMESPACE "tenant" WHERE __key__ HAS ANCESTOR KEY(users, 42)`,
+			want:  New().Kind("posts").Namespace("tenant").Select("title", "author").Distinct().Ancestor("users", int64(42)),
+		},
+		{
+			name:  "keys only",
+			query: `SELECT __key__ FROM users`,
+			want:  New().Kind("users").KeysOnly(),
+		},
+		{
+			name:  "keys only suffix",
+			query: `FROM users WHERE age > 3 KEYS ONLY`,
+			want:  New().Kind("users").Filter("age", GreaterThan, int64(3)).KeysOnly(),
+		},
+		{
+			name:  "quoted names",
+			query: "SELECT * FROM `audit log` WHERE `order` = 1 AND address.city = \"Paris\"",
+			want:  New().Kind("audit log").Where("order", int64(1)).Where("address.city", "Paris"),
+		},
+		{
+			name:  "empty",
+			query: "",
+			want:  New(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%s)\n got %s\nwant %s", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	key := datastore.IDKey("posts", 7, datastore.NameKey("users", "u1", nil))
+
+	tests := []*Builder{
+		New().Kind("users"),
+		New().Kind("users").Where("status", "active").Filter("age", GreaterThanOrEqual, 18).
+			Filter("deleted_at", Equal, nil).OrderDesc("created_at").OrderAsc("name").Limit(10).Offset(20),
+		New().Kind("posts").Namespace("tenant").Select("title").Distinct().Ancestor("users", "u1"),
+		New().Kind("users").KeysOnly(),
+		New().Kind("posts").Filter("user_id", In, []interface{}{"u1", "u2"}),
+		New().Kind("events").Filter("at", LessThan, time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)),
+		New().Kind("prices").Where("amount", 2.0).Where("ratio", 0.25).Where("big", 1e21),
+		New().Kind("comments").Where("post", key).Where("flag", true),
+		New().Kind("audit log").Where("select", "x").OrderDesc("from").Select("two words"),
+		New().Where("note", "tab\there \"quoted\" ünïcode"),
+	}
+
+	for _, b := range tests {
+		t.Run(b.String(), func(t *testing.T) {
+			first, err := Parse(b.String())
+			if err != nil {
+				t.Fatalf("Parse(%s): %v", b, err)
+			}
+			if first.String() != b.String() {
+				t.Errorf("expected %s, got %s", b, first)
+			}
+
+			second, err := Parse(first.String())
+			if err != nil {
+				t.Fatalf("Parse(%s): %v", first, err)
+			}
+			if !reflect.DeepEqual(first, second) {
+				t.Errorf("parsing again changed the query:\n%#v\n%#v", first.params, second.params)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query    string
+		pos      int
+		expected string
+	}{
+		{`status = `, 10, "value"},
+		{`status == "a"`, 9, "value"},
+		{`status "a"`, 8, "operator (=, !=, <, <=, >, >= or IN)"},
+		{`status = "a" OR age > 3`, 14, "AND (OR is not supported)"},
+		{`status = "a" AND`, 17, "property name"},
+		{`SELECT * WHERE a = 1`, 10, "FROM"},
+		{`SELECT FROM users`, 8, "property name or *"},
+		{`FROM users IN "ns"`, 15, "NAMESPACE"},
+		{`a = 1 ORDER created_at`, 13, "BY"},
+		{`a = 1 ORDER BY`, 15, "property name to order by"},
+		{`a = 1 LIMIT -3`, 13, "non-negative integer after LIMIT"},
+		{`a = 1 LIMIT 2.5`, 13, "non-negative integer after LIMIT"},
+		{`a = 1 LIMIT 5 garbage`, 15, "end of query"},
+		{`a = "unterminated`, 5, `closing "`},
+		{"a = 1 AND `b = 2", 11, "closing `"},
+		{`a = 12:30`, 5, "number or RFC 3339 timestamp"},
+		{`a = 1 ; b = 2`, 7, "name, value or operator"},
+		{`role IN ("a", "b"`, 18, `")"`},
+		{`__key__ HAS ANCESTOR KEY(users)`, 31, `","`},
+		{`owner = KEY(users, true)`, 20, "key name string or integer ID"},
+		{`owner = KEY(users, "")`, 20, "non-empty key name"},
+		{`owner = KEY(users, -4)`, 20, "positive integer ID"},
+		{`__key__ HAS ANCESTOR KEY(users, 0)`, 33, "positive integer ID"},
+		{`owner = KEY(users, "u1", posts, 0)`, 33, "positive integer ID"},
+		{`limit = 3`, 7, "non-negative integer after LIMIT"},
+		{"`ünïcode` = 1 AND x ? 2", 21, "name, value or operator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected a *ParseError, got %v", err)
+			}
+			if perr.Pos != tt.pos || perr.Expected != tt.expected {
+				t.Errorf("expected %q at %d, got %q at %d (%v)", tt.expected, tt.pos, perr.Expected, perr.Pos, err)
+			}
+			if !strings.Contains(err.Error(), "position") {
+				t.Errorf("expected the position in %q", err)
+			}
+		})
+	}
+}
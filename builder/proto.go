@@ -0,0 +1,267 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/AndroX7/gostore/proto/querypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var protoOperators = map[querypb.Operator]FilterOperator{
+	querypb.Operator_OPERATOR_EQUAL:                 Equal,
+	querypb.Operator_OPERATOR_LESS_THAN:             LessThan,
+	querypb.Operator_OPERATOR_LESS_THAN_OR_EQUAL:    LessThanOrEqual,
+	querypb.Operator_OPERATOR_GREATER_THAN:          GreaterThan,
+	querypb.Operator_OPERATOR_GREATER_THAN_OR_EQUAL: GreaterThanOrEqual,
+	querypb.Operator_OPERATOR_NOT_EQUAL:             NotEqual,
+	querypb.Operator_OPERATOR_IN:                    In,
+}
+
+var protoDirections = map[querypb.Direction]OrderDirection{
+	querypb.Direction_DIRECTION_ASCENDING:  Ascending,
+	querypb.Direction_DIRECTION_DESCENDING: Descending,
+}
+
+// FromProto converts query params received as protobuf. Integers become
+// int64, doubles float64, timestamps UTC time.Time, nulls nil and the list of
+// an In filter []interface{}. It fails on unspecified or unknown operators
+// and directions, missing filter and order fields, negative limits and
+// offsets, invalid cursors, lists outside In filters and ancestors without
+// an ID.
+func FromProto(pb *querypb.QueryParams) (*QueryParams, error) {
+	if pb == nil {
+		return nil, errors.New("query params must not be nil")
+	}
+	if pb.Limit < 0 || pb.Offset < 0 {
+		return nil, fmt.Errorf("limit and offset must not be negative, got %d and %d", pb.Limit, pb.Offset)
+	}
+	if err := ValidateCursor(pb.Cursor); err != nil {
+		return nil, err
+	}
+
+	params := &QueryParams{
+		Filters:     make([]FilterParam, 0, len(pb.Filters)),
+		Orders:      make([]OrderParam, 0, len(pb.Orders)),
+		Limit:       int(pb.Limit),
+		Offset:      int(pb.Offset),
+		Cursor:      pb.Cursor,
+		Select:      pb.Select,
+		Distinct:    pb.Distinct,
+		KeysOnly:    pb.KeysOnly,
+		Transaction: pb.Transaction,
+	}
+
+	for i, f := range pb.Filters {
+		op, ok := protoOperators[f.Operator]
+		if !ok {
+			return nil, fmt.Errorf("filter %d: unknown operator %v", i, f.Operator)
+		}
+		if f.Field == "" {
+			return nil, fmt.Errorf("filter %d: field must not be empty", i)
+		}
+
+		_, isList := f.Value.GetKind().(*querypb.Value_ListValue)
+		if isList != (op == In) {
+			return nil, fmt.Errorf("filter %d on %s: the in operator takes a list and other operators a single value", i, f.Field)
+		}
+		value, err := valueFromProto(f.Value, true)
+		if err != nil {
+			return nil, fmt.Errorf("filter %d on %s: %w", i, f.Field, err)
+		}
+		params.Filters = append(params.Filters, FilterParam{Field: f.Field, Operator: op, Value: value})
+	}
+
+	for i, o := range pb.Orders {
+		dir, ok := protoDirections[o.Direction]
+		if !ok {
+			return nil, fmt.Errorf("order %d: unknown direction %v", i, o.Direction)
+		}
+		if o.Field == "" {
+			return nil, fmt.Errorf("order %d: field must not be empty", i)
+		}
+		params.Orders = append(params.Orders, OrderParam{Field: o.Field, Direction: dir})
+	}
+
+	if a := pb.Ancestor; a != nil {
+		if a.Kind == "" {
+			return nil, errors.New("ancestor kind must not be empty")
+		}
+		var id interface{}
+		switch x := a.Id.(type) {
+		case *querypb.AncestorParam_Name:
+			id = x.Name
+		case *querypb.AncestorParam_IntId:
+			id = x.IntId
+		default:
+			return nil, fmt.Errorf("ancestor %s needs a name or an ID", a.Kind)
+		}
+		params.Ancestor = &AncestorParam{Kind: a.Kind, ID: id}
+	}
+	return params, nil
+}
+
+func valueFromProto(v *querypb.Value, list bool) (interface{}, error) {
+	switch x := v.GetKind().(type) {
+	case *querypb.Value_StringValue:
+		return x.StringValue, nil
+	case *querypb.Value_IntValue:
+		return x.IntValue, nil
+	case *querypb.Value_DoubleValue:
+		return x.DoubleValue, nil
+	case *querypb.Value_BoolValue:
+		return x.BoolValue, nil
+	case *querypb.Value_TimestampValue:
+		if err := x.TimestampValue.CheckValid(); err != nil {
+			return nil, err
+		}
+		return x.TimestampValue.AsTime(), nil
+	case *querypb.Value_NullValue:
+		return nil, nil
+	case *querypb.Value_ListValue:
+		if !list {
+			return nil, errors.New("lists can't be nested")
+		}
+		values := make([]interface{}, len(x.ListValue.GetValues()))
+		for i, e := range x.ListValue.GetValues() {
+			value, err := valueFromProto(e, false)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	}
+	return nil, errors.New("value must be set")
+}
+
+// ToProto converts params to protobuf for sending to another service. Filter
+// values can be strings, bools, integers, floats, time.Time, nil and, for In
+// filters, slices of those; other types fail, as do operators and directions
+// the protobuf form lacks.
+func ToProto(params *QueryParams) (*querypb.QueryParams, error) {
+	if params == nil {
+		return nil, errors.New("query params must not be nil")
+	}
+	if params.Limit > math.MaxInt32 || params.Offset > math.MaxInt32 {
+		return nil, fmt.Errorf("limit and offset must fit in 32 bits, got %d and %d", params.Limit, params.Offset)
+	}
+
+	pb := &querypb.QueryParams{
+		Limit:       int32(params.Limit),
+		Offset:      int32(params.Offset),
+		Cursor:      params.Cursor,
+		Select:      params.Select,
+		Distinct:    params.Distinct,
+		KeysOnly:    params.KeysOnly,
+		Transaction: params.Transaction,
+	}
+
+	for i, f := range params.Filters {
+		op, ok := protoOperator(f.Operator)
+		if !ok {
+			return nil, fmt.Errorf("filter %d on %s: unknown operator %q", i, f.Field, f.Operator)
+		}
+		value, err := valueToProto(f.Value, op == querypb.Operator_OPERATOR_IN)
+		if err != nil {
+			return nil, fmt.Errorf("filter %d on %s: %w", i, f.Field, err)
+		}
+		pb.Filters = append(pb.Filters, &querypb.FilterParam{Field: f.Field, Operator: op, Value: value})
+	}
+
+	for i, o := range params.Orders {
+		var dir querypb.Direction
+		for d, direction := range protoDirections {
+			if direction == o.Direction {
+				dir = d
+			}
+		}
+		if dir == querypb.Direction_DIRECTION_UNSPECIFIED {
+			return nil, fmt.Errorf("order %d on %s: unknown direction %q", i, o.Field, o.Direction)
+		}
+		pb.Orders = append(pb.Orders, &querypb.OrderParam{Field: o.Field, Direction: dir})
+	}
+
+	if a := params.Ancestor; a != nil {
+		pb.Ancestor = &querypb.AncestorParam{Kind: a.Kind}
+		switch id := a.ID.(type) {
+		case string:
+			pb.Ancestor.Id = &querypb.AncestorParam_Name{Name: id}
+		default:
+			n, ok := protoInt(reflect.ValueOf(a.ID))
+			if !ok {
+				return nil, fmt.Errorf("ancestor %s: ID must be a string or an integer, got %T", a.Kind, a.ID)
+			}
+			pb.Ancestor.Id = &querypb.AncestorParam_IntId{IntId: n}
+		}
+	}
+	return pb, nil
+}
+
+func protoOperator(op FilterOperator) (querypb.Operator, bool) {
+	for pb, operator := range protoOperators {
+		if operator == op {
+			return pb, true
+		}
+	}
+	return querypb.Operator_OPERATOR_UNSPECIFIED, false
+}
+
+// protoInt converts a value of any integer kind to int64
+func protoInt(v reflect.Value) (int64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > 1<<63-1 {
+			return 0, false
+		}
+		return int64(v.Uint()), true
+	}
+	return 0, false
+}
+
+// valueToProto converts a filter value, which must be a list if in is set
+func valueToProto(v interface{}, in bool) (*querypb.Value, error) {
+	rv := reflect.ValueOf(v)
+	isList := rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8
+	switch {
+	case isList && !in:
+		return nil, errors.New("lists need the in operator")
+	case in && !isList:
+		return nil, fmt.Errorf("the in operator needs a list, got %T", v)
+	case isList:
+		values := make([]*querypb.Value, rv.Len())
+		for i := range values {
+			value, err := valueToProto(rv.Index(i).Interface(), false)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return &querypb.Value{Kind: &querypb.Value_ListValue{ListValue: &querypb.ValueList{Values: values}}}, nil
+	}
+
+	switch x := v.(type) {
+	case nil:
+		return &querypb.Value{Kind: &querypb.Value_NullValue{NullValue: structpb.NullValue_NULL_VALUE}}, nil
+	case string:
+		return &querypb.Value{Kind: &querypb.Value_StringValue{StringValue: x}}, nil
+	case bool:
+		return &querypb.Value{Kind: &querypb.Value_BoolValue{BoolValue: x}}, nil
+	case float32:
+		return &querypb.Value{Kind: &querypb.Value_DoubleValue{DoubleValue: float64(x)}}, nil
+	case float64:
+		return &querypb.Value{Kind: &querypb.Value_DoubleValue{DoubleValue: x}}, nil
+	case time.Time:
+		return &querypb.Value{Kind: &querypb.Value_TimestampValue{TimestampValue: timestamppb.New(x)}}, nil
+	}
+	if n, ok := protoInt(rv); ok {
+		return &querypb.Value{Kind: &querypb.Value_IntValue{IntValue: n}}, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", v)
+}
@@ -0,0 +1,193 @@
+package builder
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AndroX7/gostore/proto/querypb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// viaProto converts params to protobuf, through the wire format, and back
+func viaProto(t *testing.T, params *QueryParams) *QueryParams {
+	t.Helper()
+	pb, err := ToProto(params)
+	if err != nil {
+		t.Fatalf("ToProto: %v", err)
+	}
+	data, err := proto.Marshal(pb)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var decoded querypb.QueryParams
+	if err := proto.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	got, err := FromProto(&decoded)
+	if err != nil {
+		t.Fatalf("FromProto: %v", err)
+	}
+	return got
+}
+
+func TestProtoValues(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.FixedZone("CEST", 2*60*60))
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{"string", "active", "active"},
+		{"empty string", "", ""},
+		{"int", 42, int64(42)},
+		{"int32", int32(-7), int64(-7)},
+		{"uint8", uint8(200), int64(200)},
+		{"int64", int64(1) << 62, int64(1) << 62},
+		{"float64", 9.5, 9.5},
+		{"float32", float32(0.5), 0.5},
+		{"bool", true, true},
+		{"false", false, false},
+		{"time", at, at.UTC()},
+		{"null", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := viaProto(t, &QueryParams{Filters: []FilterParam{{Field: "f", Operator: Equal, Value: tt.value}}})
+			if v := got.Filters[0].Value; !reflect.DeepEqual(v, tt.want) {
+				t.Errorf("expected %#v, got %#v", tt.want, v)
+			}
+		})
+	}
+
+	t.Run("in list", func(t *testing.T) {
+		value := []interface{}{"a", 1, 2.5, false, nil, at}
+		got := viaProto(t, &QueryParams{Filters: []FilterParam{{Field: "f", Operator: In, Value: value}}})
+		want := []interface{}{"a", int64(1), 2.5, false, nil, at.UTC()}
+		if v := got.Filters[0].Value; !reflect.DeepEqual(v, want) {
+			t.Errorf("expected %#v, got %#v", want, v)
+		}
+	})
+
+	t.Run("typed in list", func(t *testing.T) {
+		got := viaProto(t, &QueryParams{Filters: []FilterParam{{Field: "f", Operator: In, Value: []string{"a", "b"}}}})
+		if v := got.Filters[0].Value; !reflect.DeepEqual(v, []interface{}{"a", "b"}) {
+			t.Errorf("expected [a b], got %#v", v)
+		}
+	})
+}
+
+func TestProtoQuery(t *testing.T) {
+	cursor := base64.URLEncoding.EncodeToString([]byte("page 2"))
+	native := &QueryParams{
+		Filters: []FilterParam{
+			{Field: "status", Operator: Equal, Value: "active"},
+			{Field: "age", Operator: GreaterThanOrEqual, Value: int64(18)},
+			{Field: "age", Operator: LessThan, Value: int64(65)},
+			{Field: "score", Operator: LessThanOrEqual, Value: 9.5},
+			{Field: "verified", Operator: NotEqual, Value: false},
+			{Field: "created_at", Operator: GreaterThan, Value: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{Field: "role", Operator: In, Value: []interface{}{"admin", "editor"}},
+			{Field: "deleted_at", Operator: Equal, Value: nil},
+		},
+		Orders: []OrderParam{
+			{Field: "age", Direction: Ascending},
+			{Field: "created_at", Direction: Descending},
+		},
+		Limit:       20,
+		Offset:      40,
+		Cursor:      cursor,
+		Select:      []string{"status", "age"},
+		Distinct:    true,
+		KeysOnly:    true,
+		Ancestor:    &AncestorParam{Kind: "orgs", ID: int64(7)},
+		Transaction: true,
+	}
+
+	if got := viaProto(t, native); !reflect.DeepEqual(got, native) {
+		t.Errorf("round trip changed the params:\n got %+v\nwant %+v", got, native)
+	}
+
+	named := &QueryParams{Filters: []FilterParam{}, Orders: []OrderParam{}, Ancestor: &AncestorParam{Kind: "users", ID: "u1"}}
+	if got := viaProto(t, named); !reflect.DeepEqual(got, named) {
+		t.Errorf("expected %+v, got %+v", named, got)
+	}
+}
+
+func TestFromProtoErrors(t *testing.T) {
+	str := func(s string) *querypb.Value {
+		return &querypb.Value{Kind: &querypb.Value_StringValue{StringValue: s}}
+	}
+	list := func(values ...*querypb.Value) *querypb.Value {
+		return &querypb.Value{Kind: &querypb.Value_ListValue{ListValue: &querypb.ValueList{Values: values}}}
+	}
+	filter := func(op querypb.Operator, v *querypb.Value) *querypb.QueryParams {
+		return &querypb.QueryParams{Filters: []*querypb.FilterParam{{Field: "f", Operator: op, Value: v}}}
+	}
+	order := func(dir querypb.Direction) *querypb.QueryParams {
+		return &querypb.QueryParams{Orders: []*querypb.OrderParam{{Field: "f", Direction: dir}}}
+	}
+
+	tests := []struct {
+		name string
+		pb   *querypb.QueryParams
+		want string
+	}{
+		{"nil", nil, "must not be nil"},
+		{"unspecified operator", filter(querypb.Operator_OPERATOR_UNSPECIFIED, str("a")), "unknown operator"},
+		{"unknown operator", filter(querypb.Operator(42), str("a")), "unknown operator"},
+		{"unspecified direction", order(querypb.Direction_DIRECTION_UNSPECIFIED), "unknown direction"},
+		{"unknown direction", order(querypb.Direction(9)), "unknown direction"},
+		{"empty filter field", &querypb.QueryParams{Filters: []*querypb.FilterParam{{Operator: querypb.Operator_OPERATOR_EQUAL, Value: str("a")}}}, "field must not be empty"},
+		{"empty order field", &querypb.QueryParams{Orders: []*querypb.OrderParam{{Direction: querypb.Direction_DIRECTION_ASCENDING}}}, "field must not be empty"},
+		{"missing value", filter(querypb.Operator_OPERATOR_EQUAL, nil), "value must be set"},
+		{"list without in", filter(querypb.Operator_OPERATOR_EQUAL, list(str("a"))), "takes a list"},
+		{"in without list", filter(querypb.Operator_OPERATOR_IN, str("a")), "takes a list"},
+		{"nested list", filter(querypb.Operator_OPERATOR_IN, list(list(str("a")))), "can't be nested"},
+		{"invalid timestamp", filter(querypb.Operator_OPERATOR_EQUAL, &querypb.Value{Kind: &querypb.Value_TimestampValue{TimestampValue: &timestamppb.Timestamp{Nanos: -1}}}), "timestamp"},
+		{"negative limit", &querypb.QueryParams{Limit: -1}, "must not be negative"},
+		{"invalid cursor", &querypb.QueryParams{Cursor: "not a cursor!"}, "invalid cursor"},
+		{"ancestor without id", &querypb.QueryParams{Ancestor: &querypb.AncestorParam{Kind: "users"}}, "needs a name or an ID"},
+		{"ancestor without kind", &querypb.QueryParams{Ancestor: &querypb.AncestorParam{Id: &querypb.AncestorParam_IntId{IntId: 1}}}, "kind must not be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromProto(tt.pb)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestToProtoErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		params *QueryParams
+		want   string
+	}{
+		{"nil", nil, "must not be nil"},
+		{"unknown operator", &QueryParams{Filters: []FilterParam{{Field: "f", Operator: "~", Value: 1}}}, "unknown operator"},
+		{"unknown direction", &QueryParams{Orders: []OrderParam{{Field: "f", Direction: "sideways"}}}, "unknown direction"},
+		{"unsupported value", &QueryParams{Filters: []FilterParam{{Field: "f", Operator: Equal, Value: struct{}{}}}}, "unsupported value type"},
+		{"list without in", &QueryParams{Filters: []FilterParam{{Field: "f", Operator: Equal, Value: []string{"a"}}}}, "need the in operator"},
+		{"in without list", &QueryParams{Filters: []FilterParam{{Field: "f", Operator: In, Value: "a"}}}, "needs a list"},
+		{"uint overflow", &QueryParams{Filters: []FilterParam{{Field: "f", Operator: Equal, Value: uint64(1) << 63}}}, "unsupported value type"},
+		{"ancestor id", &QueryParams{Ancestor: &AncestorParam{Kind: "users", ID: 1.5}}, "must be a string or an integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ToProto(tt.params)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package builder
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+// QueryLogger is told about every query run by Execute, ExecuteWithCursor,
+// Count, CountUpTo, First, Rows and the aggregations with a context from
+// WithQueryLogger, such as the queries of an exec.Exec with
+// exec.WithQueryLogger
+type QueryLogger interface {
+	LogQuery(ctx context.Context, info QueryInfo)
+}
+
+// QueryInfo describes a query run
+type QueryInfo struct {
+	Kind string
+	// Query is the query as described by Builder.String
+	Query  string
+	Limit  int
+	Offset int
+	// Cursor reports whether the query started at a cursor
+	Cursor bool
+	// Returned is the number of entities read, or counted by Count and
+	// CountAggregate; 0 for sums and averages
+	Returned int
+	Duration time.Duration
+	Err      error
+}
+
+type queryLoggerKey struct{}
+
+// WithQueryLogger returns a copy of ctx that makes the queries run with it
+// report to l
+func WithQueryLogger(ctx context.Context, l QueryLogger) context.Context {
+	return context.WithValue(ctx, queryLoggerKey{}, l)
+}
+
+// logQuery reports the query of b that started at start to the QueryLogger
+// of ctx, if any
+func (b *Builder) logQuery(ctx context.Context, start time.Time, returned int, err error) {
+	l, _ := ctx.Value(queryLoggerKey{}).(QueryLogger)
+	if l == nil {
+		return
+	}
+
+	l.LogQuery(ctx, QueryInfo{
+		Kind:     b.kind,
+		Query:    b.String(),
+		Limit:    b.params.Limit,
+		Offset:   b.params.Offset,
+		Cursor:   b.params.Cursor != "",
+		Returned: returned,
+		Duration: time.Since(start),
+		Err:      err,
+	})
+}
+
+// QueryCounter is a QueryLogger tallying the queries run per kind, for tests
+// and metrics. The zero value is ready to use.
+type QueryCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// LogQuery counts a query of info.Kind
+func (c *QueryCounter) LogQuery(ctx context.Context, info QueryInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = map[string]int{}
+	}
+	c.counts[info.Kind]++
+}
+
+// Count returns the number of queries run on kind
+func (c *QueryCounter) Count(kind string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[kind]
+}
+
+// Counts returns the number of queries run per kind
+func (c *QueryCounter) Counts() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.counts)
+}
+
+// Reset forgets the queries counted so far
+func (c *QueryCounter) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts = nil
+}
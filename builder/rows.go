@@ -0,0 +1,155 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	contextKey "github.com/AndroX7/gostore/key"
+	"google.golang.org/api/iterator"
+)
+
+// errRowsClosed is returned by Scan and Cursor once Rows is closed
+var errRowsClosed = errors.New("rows are closed")
+
+// Rows iterates over the results of a query one entity at a time, in the
+// style of database/sql:
+//
+//	rows, err := builder.New().Kind("users").Rows(ctx, client)
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//	for rows.Next() {
+//		var u User
+//		if err := rows.Scan(&u); err != nil {
+//			return err
+//		}
+//	}
+//	return rows.Err()
+//
+// Entities are fetched in batches by the underlying iterator as Next
+// advances, so memory stays bounded however many match. A Rows is not safe
+// for concurrent use.
+type Rows struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	it     gostore.Iterator
+	key    *datastore.Key
+	props  datastore.PropertyList
+	err    error
+	closed bool
+	// query, start and read are logged once Rows is closed
+	query *Builder
+	start time.Time
+	read  int
+}
+
+// Rows runs the query and returns its results as Rows, which must be closed.
+// It fails only if the cursor set on b is invalid (see ValidateCursor) or
+// Validate fails; query errors are reported by Err once Next returns false.
+// Canceling ctx ends the iteration with ctx's error. The query is logged (see
+// QueryLogger) when Rows is closed.
+func (b *Builder) Rows(ctx context.Context, client gostore.Client) (*Rows, error) {
+	if err := ValidateCursor(b.params.Cursor); err != nil {
+		return nil, err
+	}
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &Rows{ctx: ctx, cancel: cancel, it: client.Run(ctx, b.Build()), query: b.Clone(), start: time.Now()}, nil
+}
+
+// Next advances to the next entity, returning false when there are no more,
+// an error occurred or Rows was closed. Rows closes itself when Next returns
+// false.
+func (r *Rows) Next() bool {
+	if r.closed {
+		return false
+	}
+	if err := r.ctx.Err(); err != nil {
+		r.err = err
+		r.Close()
+		return false
+	}
+
+	var props datastore.PropertyList
+	key, err := r.it.Next(&props)
+	if err == iterator.Done {
+		r.Close()
+		return false
+	}
+	if err != nil {
+		r.err = err
+		r.Close()
+		return false
+	}
+	r.key, r.props = key, props
+	r.read++
+	return true
+}
+
+// Scan loads the current entity into dest, a pointer to a struct or a
+// datastore.PropertyLoadSaver, and sets its ID field from the key (see
+// key.SetID). Like datastore's own loading, properties dest has no field for
+// produce a *datastore.ErrFieldMismatch after the rest are loaded.
+func (r *Rows) Scan(dest any) error {
+	if r.closed {
+		return errRowsClosed
+	}
+	if r.key == nil {
+		return errors.New("Scan called without a successful Next")
+	}
+
+	var err error
+	if pls, ok := dest.(datastore.PropertyLoadSaver); ok {
+		err = pls.Load(r.props)
+	} else {
+		err = datastore.LoadStruct(dest, r.props)
+	}
+	var mismatch *datastore.ErrFieldMismatch
+	if err != nil && !errors.As(err, &mismatch) {
+		return err
+	}
+	contextKey.SetID(dest, r.key)
+	return err
+}
+
+// Key returns the key of the current entity, or nil before the first Next
+func (r *Rows) Key() *datastore.Key {
+	return r.key
+}
+
+// Cursor returns a cursor just after the current entity, which resumes the
+// query from the next one when passed to Builder.Cursor
+func (r *Rows) Cursor() (string, error) {
+	if r.closed {
+		return "", errRowsClosed
+	}
+	cursor, err := r.it.Cursor()
+	if err != nil {
+		return "", err
+	}
+	return encodeCursor(cursor), nil
+}
+
+// Err returns the error that ended the iteration, if any. Reaching the end
+// of the results is not an error.
+func (r *Rows) Err() error {
+	return r.err
+}
+
+// Close stops the iteration, canceling any fetch in progress. It can be
+// called any number of times, and before the results are exhausted.
+func (r *Rows) Close() error {
+	if !r.closed {
+		r.closed = true
+		r.query.logQuery(r.ctx, r.start, r.read, r.err)
+		r.cancel()
+	}
+	return nil
+}
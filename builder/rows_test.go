@@ -0,0 +1,274 @@
+package builder_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+// mockUsers stores CreateTestUsers in a mock under their IDs
+func mockUsers(t *testing.T) (context.Context, *testutil.MockDatastoreClient) {
+	t.Helper()
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	testutil.NewSeeder().SeedKind(ctx, t, mock, "users", testutil.CreateTestUsers())
+	return ctx, mock
+}
+
+// runContext records the context queries are run with
+type runContext struct {
+	gostore.Client
+	ctx context.Context
+}
+
+func (c *runContext) Run(ctx context.Context, q *datastore.Query) gostore.Iterator {
+	c.ctx = ctx
+	return c.Client.Run(ctx, q)
+}
+
+func TestRows(t *testing.T) {
+	ctx, mock := mockUsers(t)
+
+	rows, err := builder.New().Kind("users").OrderDesc("age").Rows(ctx, mock)
+	if err != nil {
+		t.Fatalf("Rows: %v", err)
+	}
+	defer rows.Close()
+
+	var ages []int
+	for rows.Next() {
+		var u testutil.TestUser
+		if err := rows.Scan(&u); err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		if u.ID == "" || u.ID != rows.Key().Name {
+			t.Errorf("expected ID %q from the key, got %q", rows.Key().Name, u.ID)
+		}
+		ages = append(ages, u.Age)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+
+	want := []int{35, 30, 28, 25}
+	if len(ages) != len(want) {
+		t.Fatalf("expected ages %v, got %v", want, ages)
+	}
+	for i := range want {
+		if ages[i] != want[i] {
+			t.Fatalf("expected ages %v, got %v", want, ages)
+		}
+	}
+	if rows.Next() {
+		t.Error("expected Next to stay false after the end")
+	}
+}
+
+func TestRowsScanPropertyList(t *testing.T) {
+	ctx, mock := mockUsers(t)
+
+	rows, err := builder.New().Kind("users").Where("email", "john@example.com").Rows(ctx, mock)
+	if err != nil {
+		t.Fatalf("Rows: %v", err)
+	}
+	defer rows.Close()
+
+	if err := rows.Scan(&datastore.PropertyList{}); err == nil {
+		t.Error("expected Scan before Next to fail")
+	}
+	if !rows.Next() {
+		t.Fatalf("expected a row, got error %v", rows.Err())
+	}
+	var props datastore.PropertyList
+	if err := rows.Scan(&props); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(props) == 0 {
+		t.Error("expected properties")
+	}
+}
+
+func TestRowsCursorResumes(t *testing.T) {
+	ctx, mock := mockUsers(t)
+	b := builder.New().Kind("users").OrderAsc("age")
+
+	rows, err := b.Rows(ctx, mock)
+	if err != nil {
+		t.Fatalf("Rows: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if !rows.Next() {
+			t.Fatalf("expected row %d, got error %v", i, rows.Err())
+		}
+	}
+	cursor, err := rows.Cursor()
+	if err != nil {
+		t.Fatalf("Cursor: %v", err)
+	}
+	rows.Close()
+	if _, err := rows.Cursor(); err == nil {
+		t.Error("expected Cursor to fail after Close")
+	}
+
+	rest, err := b.Clone().Cursor(cursor).Rows(ctx, mock)
+	if err != nil {
+		t.Fatalf("Rows: %v", err)
+	}
+	defer rest.Close()
+	var ages []int
+	for rest.Next() {
+		var u testutil.TestUser
+		if err := rest.Scan(&u); err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		ages = append(ages, u.Age)
+	}
+	if len(ages) != 2 || ages[0] != 30 || ages[1] != 35 {
+		t.Errorf("expected to resume at age 30, got %v", ages)
+	}
+}
+
+func TestRowsCloseEarly(t *testing.T) {
+	ctx, mock := mockUsers(t)
+	client := &runContext{Client: mock}
+
+	rows, err := builder.New().Kind("users").Rows(ctx, client)
+	if err != nil {
+		t.Fatalf("Rows: %v", err)
+	}
+	if !rows.Next() {
+		t.Fatalf("expected a row, got error %v", rows.Err())
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	select {
+	case <-client.ctx.Done():
+	default:
+		t.Error("expected Close to cancel the query's context")
+	}
+	if rows.Next() {
+		t.Error("expected Next to return false after Close")
+	}
+	var u testutil.TestUser
+	if err := rows.Scan(&u); err == nil {
+		t.Error("expected Scan to fail after Close")
+	}
+	if err := rows.Err(); err != nil {
+		t.Errorf("expected no error after an early Close, got %v", err)
+	}
+	if err := rows.Close(); err != nil {
+		t.Errorf("expected a second Close to succeed, got %v", err)
+	}
+}
+
+func TestRowsErrors(t *testing.T) {
+	t.Run("mid-stream failure", func(t *testing.T) {
+		ctx, mock := mockUsers(t)
+		errBoom := errors.New("boom")
+
+		rows, err := builder.New().Kind("users").Rows(ctx, mock)
+		if err != nil {
+			t.Fatalf("Rows: %v", err)
+		}
+		defer rows.Close()
+
+		n := 0
+		for rows.Next() {
+			if n++; n == 2 {
+				mock.FailNext(testutil.OpNext, errBoom, 1)
+			}
+		}
+		if n != 2 || !errors.Is(rows.Err(), errBoom) {
+			t.Errorf("expected 2 rows then the injected error, got %d rows and %v", n, rows.Err())
+		}
+	})
+
+	t.Run("query failure", func(t *testing.T) {
+		ctx, mock := mockUsers(t)
+		errBoom := errors.New("boom")
+		mock.FailNext(testutil.OpRun, errBoom, 1)
+
+		rows, err := builder.New().Kind("users").Rows(ctx, mock)
+		if err != nil {
+			t.Fatalf("Rows: %v", err)
+		}
+		if rows.Next() || !errors.Is(rows.Err(), errBoom) {
+			t.Errorf("expected the injected error, got %v", rows.Err())
+		}
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		ctx, mock := mockUsers(t)
+		ctx, cancel := context.WithCancel(ctx)
+
+		rows, err := builder.New().Kind("users").Rows(ctx, mock)
+		if err != nil {
+			t.Fatalf("Rows: %v", err)
+		}
+		if !rows.Next() {
+			t.Fatalf("expected a row, got error %v", rows.Err())
+		}
+		cancel()
+		if rows.Next() || !errors.Is(rows.Err(), context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", rows.Err())
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		_, err := builder.New().Kind("users").Cursor("not a cursor!").Rows(context.Background(), testutil.NewMockClient())
+		if !errors.Is(err, builder.ErrInvalidCursor) {
+			t.Errorf("expected ErrInvalidCursor, got %v", err)
+		}
+	})
+}
+
+func TestInvalidAncestor(t *testing.T) {
+	ctx, mock := mockUsers(t)
+	b := builder.New().Kind("users").Ancestor("accounts", 1.5)
+
+	if err := b.Validate(); !errors.Is(err, builder.ErrInvalidAncestor) {
+		t.Fatalf("expected ErrInvalidAncestor from Validate, got %v", err)
+	}
+	var users []testutil.TestUser
+	if _, err := b.Execute(ctx, mock, &users); !errors.Is(err, builder.ErrInvalidAncestor) {
+		t.Errorf("expected Execute to fail with ErrInvalidAncestor, got %v", err)
+	}
+	if _, err := b.Count(ctx, mock); !errors.Is(err, builder.ErrInvalidAncestor) {
+		t.Errorf("expected Count to fail with ErrInvalidAncestor, got %v", err)
+	}
+	if _, err := b.Rows(ctx, mock); !errors.Is(err, builder.ErrInvalidAncestor) {
+		t.Errorf("expected Rows to fail with ErrInvalidAncestor, got %v", err)
+	}
+
+	// The built query fails rather than matching the whole kind
+	if keys, err := mock.GetAll(ctx, b.Build(), &users); err == nil {
+		t.Errorf("expected the built query to fail, got %d results", len(keys))
+	}
+}
+
+func TestRowsQueryLogger(t *testing.T) {
+	ctx, mock := mockUsers(t)
+	counter := &builder.QueryCounter{}
+	ctx = builder.WithQueryLogger(ctx, counter)
+
+	rows, err := builder.New().Kind("users").Rows(ctx, mock)
+	if err != nil {
+		t.Fatalf("Rows failed: %v", err)
+	}
+	rows.Next()
+	if counter.Count("users") != 0 {
+		t.Error("expected the query logged only once Rows is closed")
+	}
+	rows.Close()
+	rows.Close()
+	if n := counter.Count("users"); n != 1 {
+		t.Errorf("expected the query logged once, got %d", n)
+	}
+}
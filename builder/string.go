@@ -0,0 +1,163 @@
+package builder
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+// String describes the query in a GQL-like form for logs, e.g.
+// `SELECT * FROM users WHERE status = "active" ORDER BY age DESC LIMIT 10`.
+// Parse reads it back, except for the cursor, which is only noted as
+// START AT CURSOR, and values of types the query language lacks, such as
+// blobs and geopoints.
+func (b *Builder) String() string {
+	return b.format(false)
+}
+
+// Redacted describes the query like String with every filter value and the
+// ancestor ID replaced by "?"
+func (b *Builder) Redacted() string {
+	return b.format(true)
+}
+
+func (b *Builder) format(redact bool) string {
+	value := func(v interface{}) string {
+		switch {
+		case redact:
+			return "?"
+		case v == nil:
+			return "NULL"
+		}
+		return formatValue(v)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	switch {
+	case b.params.KeysOnly:
+		sb.WriteString("__key__")
+	case len(b.params.Select) > 0:
+		if b.params.Distinct {
+			sb.WriteString("DISTINCT ")
+		}
+		sb.WriteString(formatNames(b.params.Select))
+	default:
+		sb.WriteString("*")
+	}
+
+	sb.WriteString(" FROM ")
+	sb.WriteString(formatName(b.kind))
+	if b.namespace != "" {
+		fmt.Fprintf(&sb, " IN NAMESPACE %q", b.namespace)
+	}
+
+	var conds []string
+	if a := b.params.Ancestor; a != nil {
+		conds = append(conds, fmt.Sprintf("__key__ HAS ANCESTOR KEY(%s, %s)", formatName(a.Kind), value(a.ID)))
+	}
+	for _, f := range b.params.Filters {
+		if f.Operator == In && !redact {
+			var values []string
+			if v := reflect.ValueOf(f.Value); v.Kind() == reflect.Slice {
+				for i := 0; i < v.Len(); i++ {
+					values = append(values, value(v.Index(i).Interface()))
+				}
+			}
+			conds = append(conds, fmt.Sprintf("%s IN ARRAY(%s)", formatName(f.Field), strings.Join(values, ", ")))
+			continue
+		}
+		conds = append(conds, fmt.Sprintf("%s %s %s", formatName(f.Field), strings.ToUpper(string(f.Operator)), value(f.Value)))
+	}
+	if len(conds) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(conds, " AND "))
+	}
+
+	if len(b.params.Orders) > 0 {
+		orders := make([]string, len(b.params.Orders))
+		for i, o := range b.params.Orders {
+			orders[i] = formatName(o.Field)
+			if o.Direction == Descending {
+				orders[i] += " DESC"
+			}
+		}
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(strings.Join(orders, ", "))
+	}
+
+	if b.params.Limit > 0 {
+		fmt.Fprintf(&sb, " LIMIT %d", b.params.Limit)
+	}
+	if b.params.Offset > 0 {
+		fmt.Fprintf(&sb, " OFFSET %d", b.params.Offset)
+	}
+	if b.params.Cursor != "" {
+		sb.WriteString(" START AT CURSOR")
+	}
+	return sb.String()
+}
+
+// formatValue writes a filter value as a literal Parse reads back
+func formatValue(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return strconv.Quote(x)
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	case float32:
+		return formatFloat(float64(x), 32)
+	case float64:
+		return formatFloat(x, 64)
+	case *datastore.Key:
+		if x == nil {
+			return "NULL"
+		}
+		var path []string
+		for k := x; k != nil; k = k.Parent {
+			id := strconv.FormatInt(k.ID, 10)
+			if k.Name != "" || k.ID == 0 {
+				id = strconv.Quote(k.Name)
+			}
+			path = append([]string{formatName(k.Kind) + ", " + id}, path...)
+		}
+		return "KEY(" + strings.Join(path, ", ") + ")"
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// formatFloat writes f with a decimal point, so it doesn't read back as an
+// integer
+func formatFloat(f float64, bits int) string {
+	s := strconv.FormatFloat(f, 'g', -1, bits)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
+// formatName backquotes names that Parse would not read as a plain name
+func formatName(name string) string {
+	plain := name != "" && !keywords[strings.ToUpper(name)]
+	for i, r := range name {
+		if !isNamePart(r) || (i == 0 && !isNameStart(r)) {
+			plain = false
+		}
+	}
+	if plain {
+		return name
+	}
+	return "`" + name + "`"
+}
+
+func formatNames(names []string) string {
+	formatted := make([]string, len(names))
+	for i, name := range names {
+		formatted[i] = formatName(name)
+	}
+	return strings.Join(formatted, ", ")
+}
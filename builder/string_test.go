@@ -0,0 +1,55 @@
+package builder
+
+import "testing"
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name     string
+		b        *Builder
+		want     string
+		redacted string
+	}{
+		{
+			name:     "all entities",
+			b:        New().Kind("users"),
+			want:     "SELECT * FROM users",
+			redacted: "SELECT * FROM users",
+		},
+		{
+			name: "filters orders and paging",
+			b: New().Kind("users").Where("status", "active").Filter("age", GreaterThanOrEqual, 18).
+				Filter("deleted_at", Equal, nil).OrderDesc("created_at").OrderAsc("name").Limit(10).Offset(20),
+			want:     `SELECT * FROM users WHERE status = "active" AND age >= 18 AND deleted_at = NULL ORDER BY created_at DESC, name LIMIT 10 OFFSET 20`,
+			redacted: `SELECT * FROM users WHERE status = ? AND age >= ? AND deleted_at = ? ORDER BY created_at DESC, name LIMIT 10 OFFSET 20`,
+		},
+		{
+			name:     "projection ancestor and namespace",
+			b:        New().Kind("posts").Namespace("tenant").Select("title").Distinct().Ancestor("users", "u1"),
+			want:     `SELECT DISTINCT title FROM posts IN NAMESPACE "tenant" WHERE __key__ HAS ANCESTOR KEY(users, "u1")`,
+			redacted: `SELECT DISTINCT title FROM posts IN NAMESPACE "tenant" WHERE __key__ HAS ANCESTOR KEY(users, ?)`,
+		},
+		{
+			name:     "keys only with cursor",
+			b:        New().Kind("users").KeysOnly().Cursor("abc"),
+			want:     "SELECT __key__ FROM users START AT CURSOR",
+			redacted: "SELECT __key__ FROM users START AT CURSOR",
+		},
+		{
+			name:     "in filter",
+			b:        New().Kind("posts").Filter("user_id", In, []interface{}{"u1", "u2"}),
+			want:     `SELECT * FROM posts WHERE user_id IN ARRAY("u1", "u2")`,
+			redacted: "SELECT * FROM posts WHERE user_id IN ?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.b.String(); got != tt.want {
+				t.Errorf("String() = %s\nwant %s", got, tt.want)
+			}
+			if got := tt.b.Redacted(); got != tt.redacted {
+				t.Errorf("Redacted() = %s\nwant %s", got, tt.redacted)
+			}
+		})
+	}
+}
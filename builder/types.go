@@ -30,7 +30,7 @@ type OrderParam struct {
 // AncestorParam for ancestor queries
 type AncestorParam struct {
 	Kind string
-	ID   interface{} // string or int64
+	ID   interface{} // any type accepted by key.NormalizeID
 }
 
 // FilterOperator types
@@ -43,6 +43,8 @@ const (
 	GreaterThan        FilterOperator = ">"
 	GreaterThanOrEqual FilterOperator = ">="
 	NotEqual           FilterOperator = "!="
+	// In matches any value of a slice; Datastore allows at most 30 values
+	In FilterOperator = "in"
 )
 
 // OrderDirection types
@@ -58,6 +60,8 @@ type PaginationResult struct {
 	NextCursor string
 	HasMore    bool
 	Total      int
+	Page       int
+	PageSize   int
 }
 
 // Response wraps query results
@@ -0,0 +1,97 @@
+// Package gostore holds the client abstraction shared by the builder, exec and
+// repository packages.
+package gostore
+
+import (
+	"context"
+
+	"cloud.google.com/go/datastore"
+)
+
+// Client is the part of *datastore.Client gostore uses. Wrap adapts a
+// *datastore.Client; other implementations, such as testutil's mock, let code
+// built on gostore run without Datastore.
+type Client interface {
+	Get(ctx context.Context, key *datastore.Key, dst any) error
+	GetMulti(ctx context.Context, keys []*datastore.Key, dst any) error
+	Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error)
+	PutMulti(ctx context.Context, keys []*datastore.Key, src any) ([]*datastore.Key, error)
+	Delete(ctx context.Context, key *datastore.Key) error
+	DeleteMulti(ctx context.Context, keys []*datastore.Key) error
+	AllocateIDs(ctx context.Context, keys []*datastore.Key) ([]*datastore.Key, error)
+	GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error)
+	Run(ctx context.Context, q *datastore.Query) Iterator
+	RunAggregationQuery(ctx context.Context, aq *datastore.AggregationQuery) (datastore.AggregationResult, error)
+	RunInTransaction(ctx context.Context, f func(tx Transaction) error, opts ...datastore.TransactionOption) (*datastore.Commit, error)
+	Close() error
+}
+
+// Iterator is the result of a query run with Client.Run
+type Iterator interface {
+	Next(dst any) (*datastore.Key, error)
+	Cursor() (datastore.Cursor, error)
+}
+
+// Transaction is the part of *datastore.Transaction gostore uses
+type Transaction interface {
+	Get(key *datastore.Key, dst any) error
+	GetMulti(keys []*datastore.Key, dst any) error
+	Put(key *datastore.Key, src any) (*datastore.PendingKey, error)
+	PutMulti(keys []*datastore.Key, src any) ([]*datastore.PendingKey, error)
+	Delete(key *datastore.Key) error
+	DeleteMulti(keys []*datastore.Key) error
+}
+
+// datastoreClient adapts *datastore.Client to Client
+type datastoreClient struct {
+	*datastore.Client
+}
+
+// Wrap adapts c to Client. A nil c returns nil.
+func Wrap(c *datastore.Client) Client {
+	if c == nil {
+		return nil
+	}
+	return datastoreClient{c}
+}
+
+// Unwrap returns the *datastore.Client behind a Client created by Wrap
+func Unwrap(c Client) (*datastore.Client, bool) {
+	dc, ok := c.(datastoreClient)
+	return dc.Client, ok
+}
+
+// DatastoreTransaction returns tx as a *datastore.Transaction if it is one,
+// e.g. to attach it to a query
+func DatastoreTransaction(tx Transaction) (*datastore.Transaction, bool) {
+	dtx, ok := tx.(*datastore.Transaction)
+	return dtx, ok
+}
+
+func (c datastoreClient) Run(ctx context.Context, q *datastore.Query) Iterator {
+	return c.Client.Run(ctx, q)
+}
+
+func (c datastoreClient) RunInTransaction(ctx context.Context, f func(tx Transaction) error, opts ...datastore.TransactionOption) (*datastore.Commit, error) {
+	return c.Client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		return f(tx)
+	}, opts...)
+}
+
+// FromAny returns v as a Client, wrapping a *datastore.Client. It returns
+// false for nil and for values of other types.
+func FromAny(v any) (Client, bool) {
+	switch c := v.(type) {
+	case *datastore.Client:
+		if c == nil {
+			return nil, false
+		}
+		return Wrap(c), true
+	case Client:
+		if c == nil {
+			return nil, false
+		}
+		return c, true
+	}
+	return nil, false
+}
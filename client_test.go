@@ -0,0 +1,48 @@
+package gostore_test
+
+import (
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestFromAny(t *testing.T) {
+	mock := testutil.NewMockClient()
+
+	tests := []struct {
+		name string
+		v    any
+		ok   bool
+	}{
+		{"Mock client", mock, true},
+		{"Datastore client", &datastore.Client{}, true},
+		{"Nil datastore client", (*datastore.Client)(nil), false},
+		{"Nil", nil, false},
+		{"Other type", "client", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, ok := gostore.FromAny(tt.v)
+			if ok != tt.ok || (ok && client == nil) {
+				t.Errorf("expected ok=%v, got %v (%v)", tt.ok, ok, client)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if gostore.Wrap(nil) != nil {
+		t.Error("expected Wrap(nil) to be nil")
+	}
+
+	dc := &datastore.Client{}
+	if got, ok := gostore.Unwrap(gostore.Wrap(dc)); !ok || got != dc {
+		t.Errorf("expected Unwrap to return the wrapped client, got %v", got)
+	}
+	if _, ok := gostore.Unwrap(testutil.NewMockClient()); ok {
+		t.Error("expected Unwrap to fail for the mock")
+	}
+}
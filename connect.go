@@ -0,0 +1,43 @@
+package gostore
+
+import (
+	"context"
+
+	"cloud.google.com/go/datastore"
+)
+
+// ConnectOption configures the client Connect creates. The options of
+// Manager, such as WithDatabase, WithCredentialsFile and WithEndpoint, apply.
+type ConnectOption = ManagerOption
+
+// Connect creates a client for projectID and returns it along with a copy of
+// ctx carrying it under key.NOSQL_KEY, as key.WithClient would, so exec and
+// repository calls work on the returned context right away:
+//
+//	ctx, _, err := gostore.Connect(ctx, "my-project")
+//	if err != nil {
+//		return err
+//	}
+//	defer gostore.Close(ctx)
+//
+// An empty projectID is detected from the environment.
+func Connect(ctx context.Context, projectID string, opts ...ConnectOption) (context.Context, *datastore.Client, error) {
+	if projectID != "" {
+		opts = append([]ConnectOption{WithProject(projectID)}, opts...)
+	}
+	client, err := NewManager(opts...).newClient(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return context.WithValue(ctx, ClientKey, client), client, nil
+}
+
+// Close closes the client stored in ctx under key.NOSQL_KEY, such as the one
+// Connect created. It returns ErrClientNotInitialized if there is none.
+func Close(ctx context.Context) error {
+	client, ok := FromAny(ctx.Value(ClientKey))
+	if !ok {
+		return ErrClientNotInitialized
+	}
+	return client.Close()
+}
@@ -0,0 +1,75 @@
+package gostore_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/key"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestConnect(t *testing.T) {
+	t.Run("WithEndpoint", func(t *testing.T) {
+		f := &fakeDatastore{}
+		addr := startFakeDatastore(t, f)
+		t.Setenv(testutil.EmulatorHostEnv, "")
+
+		ctx, client, err := gostore.Connect(context.Background(), "test-project", gostore.WithEndpoint(addr))
+		if err != nil {
+			t.Fatalf("Connect failed: %v", err)
+		}
+		stored, err := key.ClientFromContext(ctx)
+		if err != nil {
+			t.Fatalf("expected the client in the context, got %v", err)
+		}
+		if dc, ok := gostore.Unwrap(stored); !ok || dc != client {
+			t.Errorf("expected the returned client in the context, got %v", stored)
+		}
+
+		var users []testutil.TestUser
+		if err := exec.NewExec().FindAll(ctx, "users", &users); err != nil || f.queries.Load() != 1 {
+			t.Errorf("expected the query to reach the endpoint, got %v after %d queries", err, f.queries.Load())
+		}
+		if err := gostore.Close(ctx); err != nil {
+			t.Errorf("Close failed: %v", err)
+		}
+	})
+
+	t.Run("Close without a client", func(t *testing.T) {
+		if err := gostore.Close(context.Background()); !errors.Is(err, key.ErrClientNotInitialized) {
+			t.Errorf("expected ErrClientNotInitialized, got %v", err)
+		}
+	})
+}
+
+func TestConnectEmulator(t *testing.T) {
+	host := testutil.RequireEmulator(t)
+	project := fmt.Sprintf("gostore-connect-%d", time.Now().UnixNano())
+
+	ctx, _, err := gostore.Connect(context.Background(), project, gostore.WithEndpoint(host))
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer gostore.Close(ctx)
+
+	h := exec.NewExec()
+	user := &testutil.TestUser{Name: "Ada", Age: 36}
+	if err := h.Create(ctx, "users", "ada", user); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	var got testutil.TestUser
+	if err := h.GetByID(ctx, "users", "ada", &got); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Name != "Ada" || got.Age != 36 {
+		t.Errorf("expected the created user, got %+v", got)
+	}
+	if err := h.Delete(ctx, "users", "ada"); err != nil {
+		t.Errorf("Delete failed: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package counter
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+// Counter is a sharded counter. Each increment touches one randomly chosen
+// shard so write throughput scales with the number of shards, and the value
+// is the sum of all shards.
+//
+// Shards are stored as entities of kind "<kind>Shard" under the parent key
+// (kind, name), so reading the value is a single ancestor query.
+type Counter struct {
+	kind   string
+	name   string
+	shards atomic.Int64
+}
+
+type shard struct {
+	Count int64 `datastore:"count,noindex"`
+}
+
+// NewCounter creates a counter with the given number of shards
+func NewCounter(kind, name string, shards int) *Counter {
+	if shards < 1 {
+		shards = 1
+	}
+
+	c := &Counter{
+		kind: kind,
+		name: name,
+	}
+	c.shards.Store(int64(shards))
+	return c
+}
+
+// Shards returns the current number of shards
+func (c *Counter) Shards() int {
+	return int(c.shards.Load())
+}
+
+// Incr adds delta to a random shard in a transaction
+func (c *Counter) Incr(ctx context.Context, delta int64) error {
+	client, err := clientFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	key := c.shardKey(rand.IntN(c.Shards()))
+
+	_, err = client.RunInTransaction(ctx, func(tx gostore.Transaction) error {
+		var s shard
+		if err := tx.Get(key, &s); err != nil && err != datastore.ErrNoSuchEntity {
+			return err
+		}
+		s.Count += delta
+		_, err := tx.Put(key, &s)
+		return err
+	})
+	return err
+}
+
+// Value returns the sum of all shards
+func (c *Counter) Value(ctx context.Context) (int64, error) {
+	client, err := clientFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := datastore.NewQuery(c.shardKind()).Ancestor(c.parentKey())
+
+	var shards []shard
+	if _, err := client.GetAll(ctx, query, &shards); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, s := range shards {
+		total += s.Count
+	}
+	return total, nil
+}
+
+// Resize increases the number of shards. Existing shards keep their counts,
+// so the value is unchanged; shrinking is not supported because increments
+// would no longer reach the dropped shards evenly.
+func (c *Counter) Resize(shards int) error {
+	current := c.Shards()
+	if shards < current {
+		return fmt.Errorf("cannot shrink counter from %d to %d shards", current, shards)
+	}
+
+	c.shards.Store(int64(shards))
+	return nil
+}
+
+func (c *Counter) parentKey() *datastore.Key {
+	return datastore.NameKey(c.kind, c.name, nil)
+}
+
+func (c *Counter) shardKind() string {
+	return c.kind + "Shard"
+}
+
+func (c *Counter) shardKey(i int) *datastore.Key {
+	return datastore.NameKey(c.shardKind(), fmt.Sprintf("shard-%d", i), c.parentKey())
+}
+
+func clientFromContext(ctx context.Context) (gostore.Client, error) {
+	return contextKey.ClientFromContext(ctx)
+}
@@ -0,0 +1,148 @@
+package counter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	contextKey "github.com/AndroX7/gostore/key"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	testutil.StopEmulator()
+	os.Exit(code)
+}
+
+func TestShardKeys(t *testing.T) {
+	t.Run("Shards live under a deterministic parent", func(t *testing.T) {
+		c := NewCounter("PageViews", "home", 4)
+
+		key := c.shardKey(2)
+		if key.Kind != "PageViewsShard" || key.Name != "shard-2" {
+			t.Errorf("unexpected shard key %v", key)
+		}
+		if !key.Parent.Equal(datastore.NameKey("PageViews", "home", nil)) {
+			t.Errorf("unexpected parent %v", key.Parent)
+		}
+		if !c.shardKey(2).Equal(key) {
+			t.Error("expected shard keys to be stable")
+		}
+	})
+
+	t.Run("Invalid shard count defaults to one", func(t *testing.T) {
+		if n := NewCounter("PageViews", "home", 0).Shards(); n != 1 {
+			t.Errorf("expected 1 shard, got %d", n)
+		}
+	})
+}
+
+func TestResize(t *testing.T) {
+	c := NewCounter("PageViews", "home", 4)
+
+	if err := c.Resize(8); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Shards() != 8 {
+		t.Errorf("expected 8 shards, got %d", c.Shards())
+	}
+
+	if err := c.Resize(2); err == nil {
+		t.Error("expected error when shrinking")
+	}
+}
+
+func TestIncrRetriesAbortedTransactions(t *testing.T) {
+	mock := testutil.NewMockClient()
+	ctx := context.WithValue(context.Background(), contextKey.NOSQL_KEY, mock)
+	c := NewCounter("PageViews", "home", 1)
+
+	if err := c.Incr(ctx, 2); err != nil {
+		t.Fatalf("Incr failed: %v", err)
+	}
+	mock.AbortNextTx(1)
+	mock.ResetOperations()
+	if err := c.Incr(ctx, 3); err != nil {
+		t.Fatalf("Incr failed: %v", err)
+	}
+
+	value, err := c.Value(ctx)
+	if err != nil {
+		t.Fatalf("Value failed: %v", err)
+	}
+	if value != 5 {
+		t.Errorf("expected value 5, got %d", value)
+	}
+	if txs := mock.OperationsFor(testutil.OpRunInTransaction); len(txs) != 1 || txs[0].Count != 2 {
+		t.Errorf("expected one transaction of 2 attempts, got %+v", txs)
+	}
+
+	mock.AbortNextTx(3)
+	if err := c.Incr(ctx, 1); !errors.Is(err, datastore.ErrConcurrentTransaction) {
+		t.Errorf("expected ErrConcurrentTransaction once attempts are exhausted, got %v", err)
+	}
+	if value, _ := c.Value(ctx); value != 5 {
+		t.Errorf("expected the aborted increment to be discarded, got %d", value)
+	}
+}
+
+func TestCounterEmulator(t *testing.T) {
+	client := testutil.StartEmulator(t)
+	ctx := context.WithValue(context.Background(), contextKey.NOSQL_KEY, client)
+
+	c := NewCounter("PageViews", "home", 5)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- c.Incr(ctx, 2)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Incr failed: %v", err)
+		}
+	}
+
+	value, err := c.Value(ctx)
+	if err != nil {
+		t.Fatalf("Value failed: %v", err)
+	}
+	if value != 100 {
+		t.Errorf("expected value 100, got %d", value)
+	}
+
+	if err := c.Resize(10); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	value, err = c.Value(ctx)
+	if err != nil {
+		t.Fatalf("Value failed: %v", err)
+	}
+	if value != 100 {
+		t.Errorf("expected resize to preserve value 100, got %d", value)
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := c.Incr(ctx, 1); err != nil {
+			t.Fatalf("Incr failed: %v", err)
+		}
+	}
+	value, err = c.Value(ctx)
+	if err != nil {
+		t.Fatalf("Value failed: %v", err)
+	}
+	if value != 110 {
+		t.Errorf("expected value 110, got %d", value)
+	}
+}
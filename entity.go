@@ -0,0 +1,130 @@
+package gostore
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"cloud.google.com/go/datastore"
+)
+
+// EntityKeyField is the map entry EntityToMap stores the key of a nested
+// entity under, as datastore does for a struct field tagged "__key__"
+const EntityKeyField = "__key__"
+
+// EntityToMap converts entity, a struct, a pointer to one or a
+// datastore.PropertyLoadSaver, to the properties Datastore would store for
+// it, keyed by name. It uses datastore.SaveStruct, so datastore tags apply:
+// renamed and skipped fields, omitempty and flattened structs, whose
+// properties are named like "Address.City". Values have the types Datastore
+// stores: int64 for all integers, []interface{} for slices other than
+// []byte, and nested maps for nested entities. Whether a property is indexed
+// is not kept.
+func EntityToMap(entity any) (map[string]any, error) {
+	var props []datastore.Property
+	var err error
+	if pls, ok := entity.(datastore.PropertyLoadSaver); ok {
+		props, err = pls.Save()
+	} else {
+		v := reflect.ValueOf(entity)
+		if v.Kind() == reflect.Struct {
+			// SaveStruct needs a pointer
+			p := reflect.New(v.Type())
+			p.Elem().Set(v)
+			v = p
+		}
+		if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return nil, fmt.Errorf("entity must be a struct or a non-nil pointer to one, got %T", entity)
+		}
+		props, err = datastore.SaveStruct(v.Interface())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return propertiesToMap(props), nil
+}
+
+// MapToEntity loads m, as returned by EntityToMap, into dest, a pointer to a
+// struct or a datastore.PropertyLoadSaver, with datastore.LoadStruct. Entries
+// without a matching field fail with a *datastore.ErrFieldMismatch, after
+// the others are loaded.
+func MapToEntity(m map[string]any, dest any) error {
+	if m == nil {
+		return errors.New("map must not be nil")
+	}
+
+	props := mapToProperties(m)
+	if pls, ok := dest.(datastore.PropertyLoadSaver); ok {
+		return pls.Load(props)
+	}
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("dest must be a non-nil pointer to a struct, got %T", dest)
+	}
+	return datastore.LoadStruct(dest, props)
+}
+
+func propertiesToMap(props []datastore.Property) map[string]any {
+	m := make(map[string]any, len(props))
+	for _, p := range props {
+		m[p.Name] = valueToMap(p.Value)
+	}
+	return m
+}
+
+// valueToMap converts nested entities in a property value to maps
+func valueToMap(v any) any {
+	switch x := v.(type) {
+	case *datastore.Entity:
+		if x == nil {
+			return nil
+		}
+		m := propertiesToMap(x.Properties)
+		if x.Key != nil {
+			m[EntityKeyField] = x.Key
+		}
+		return m
+	case []any:
+		values := make([]any, len(x))
+		for i, e := range x {
+			values[i] = valueToMap(e)
+		}
+		return values
+	}
+	return v
+}
+
+func mapToProperties(m map[string]any) []datastore.Property {
+	props := make([]datastore.Property, 0, len(m))
+	for name, value := range m {
+		props = append(props, datastore.Property{Name: name, Value: valueFromMap(value)})
+	}
+	return props
+}
+
+// valueFromMap converts nested maps in a value to entities
+func valueFromMap(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		if x == nil {
+			return (*datastore.Entity)(nil)
+		}
+		e := &datastore.Entity{}
+		for name, value := range x {
+			if key, ok := value.(*datastore.Key); ok && name == EntityKeyField {
+				e.Key = key
+				continue
+			}
+			e.Properties = append(e.Properties, datastore.Property{Name: name, Value: valueFromMap(value)})
+		}
+		return e
+	case []any:
+		values := make([]any, len(x))
+		for i, e := range x {
+			values[i] = valueFromMap(e)
+		}
+		return values
+	}
+	return v
+}
@@ -0,0 +1,141 @@
+package gostore_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type entityAddress struct {
+	Street string `datastore:"street"`
+	City   string `datastore:"city"`
+}
+
+type entityProfile struct {
+	Name     string          `datastore:"name"`
+	Bio      string          `datastore:"bio,noindex"`
+	Avatar   []byte          `datastore:"avatar"`
+	Tags     []string        `datastore:"tags"`
+	Home     entityAddress   `datastore:"home"`
+	Work     entityAddress   `datastore:"work,flatten"`
+	Previous []entityAddress `datastore:"previous"`
+	Secret   string          `datastore:"-"`
+	Nickname string          `datastore:"nickname,omitempty"`
+}
+
+func TestEntityToMap(t *testing.T) {
+	t.Run("TestUser round trip", func(t *testing.T) {
+		user := testutil.CreateTestUsers()[0]
+		user.ID = ""
+		user.CreatedAt = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+		m, err := gostore.EntityToMap(user)
+		if err != nil {
+			t.Fatalf("EntityToMap: %v", err)
+		}
+		want := map[string]any{
+			"email":      user.Email,
+			"name":       user.Name,
+			"age":        int64(user.Age),
+			"status":     user.Status,
+			"created_at": user.CreatedAt,
+		}
+		if !reflect.DeepEqual(m, want) {
+			t.Errorf("expected %v, got %v", want, m)
+		}
+
+		var got testutil.TestUser
+		if err := gostore.MapToEntity(m, &got); err != nil {
+			t.Fatalf("MapToEntity: %v", err)
+		}
+		if !reflect.DeepEqual(got, user) {
+			t.Errorf("expected %+v, got %+v", user, got)
+		}
+	})
+
+	t.Run("Nested, flattened and noindex fields", func(t *testing.T) {
+		profile := &entityProfile{
+			Name:     "Ann",
+			Bio:      "likes maps",
+			Avatar:   []byte{0x89, 'P', 'N', 'G'},
+			Tags:     []string{"a", "b"},
+			Home:     entityAddress{Street: "1 Main St", City: "Springfield"},
+			Work:     entityAddress{Street: "2 Side St", City: "Shelbyville"},
+			Previous: []entityAddress{{City: "Ogdenville"}, {City: "North Haverbrook"}},
+			Secret:   "not stored",
+		}
+
+		m, err := gostore.EntityToMap(profile)
+		if err != nil {
+			t.Fatalf("EntityToMap: %v", err)
+		}
+
+		if home, ok := m["home"].(map[string]any); !ok || home["city"] != "Springfield" {
+			t.Errorf("expected home as a nested map, got %#v", m["home"])
+		}
+		if m["work.city"] != "Shelbyville" {
+			t.Errorf("expected flattened work.city, got %v", m)
+		}
+		if previous, ok := m["previous"].([]any); !ok || len(previous) != 2 || previous[1].(map[string]any)["city"] != "North Haverbrook" {
+			t.Errorf("expected previous as a list of maps, got %#v", m["previous"])
+		}
+		if _, ok := m["avatar"].([]byte); !ok {
+			t.Errorf("expected avatar to stay []byte, got %T", m["avatar"])
+		}
+		if _, ok := m["Secret"]; ok {
+			t.Error("expected the skipped field to be left out")
+		}
+		if _, ok := m["nickname"]; ok {
+			t.Error("expected the empty omitempty field to be left out")
+		}
+
+		var got entityProfile
+		if err := gostore.MapToEntity(m, &got); err != nil {
+			t.Fatalf("MapToEntity: %v", err)
+		}
+		want := *profile
+		want.Secret = ""
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	})
+
+	t.Run("PropertyList", func(t *testing.T) {
+		props := datastore.PropertyList{{Name: "a", Value: int64(1)}}
+		m, err := gostore.EntityToMap(&props)
+		if err != nil || !reflect.DeepEqual(m, map[string]any{"a": int64(1)}) {
+			t.Fatalf("expected {a:1}, got %v (%v)", m, err)
+		}
+
+		var got datastore.PropertyList
+		if err := gostore.MapToEntity(m, &got); err != nil || !reflect.DeepEqual(got, props) {
+			t.Errorf("expected %v, got %v (%v)", props, got, err)
+		}
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		if _, err := gostore.EntityToMap("user"); err == nil {
+			t.Error("expected an error for a non-struct entity")
+		}
+		if _, err := gostore.EntityToMap((*testutil.TestUser)(nil)); err == nil {
+			t.Error("expected an error for a nil pointer")
+		}
+		if err := gostore.MapToEntity(map[string]any{}, testutil.TestUser{}); err == nil {
+			t.Error("expected an error for a non-pointer dest")
+		}
+	})
+
+	t.Run("Unknown entries", func(t *testing.T) {
+		var got testutil.TestUser
+		err := gostore.MapToEntity(map[string]any{"name": "A", "unknown": "x"}, &got)
+		var mismatch *datastore.ErrFieldMismatch
+		if !errors.As(err, &mismatch) || got.Name != "A" {
+			t.Errorf("expected a field mismatch after loading name, got %v and %+v", err, got)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package gostore
+
+import (
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+)
+
+// Error is a Datastore error annotated with the operation that failed, the
+// kind it ran on and, for operations on a single entity, its key. Errors of
+// batch operations are a datastore.MultiError of Errors, one per failed key.
+// errors.Is and errors.As see through it to Err.
+type Error struct {
+	// Op is the operation, e.g. "GetByID"
+	Op   string
+	Kind string
+	// Key is nil for queries and batches
+	Key *datastore.Key
+	Err error
+}
+
+// Error formats e as, e.g.,
+// "gostore: users.GetByID key=users/name=user123: datastore: no such entity"
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString("gostore: ")
+	if e.Kind != "" {
+		b.WriteString(e.Kind)
+		b.WriteByte('.')
+	}
+	b.WriteString(e.Op)
+	if e.Key != nil {
+		b.WriteString(" key=")
+		writeKeyPath(&b, e.Key)
+	}
+	b.WriteString(": ")
+	if e.Err != nil {
+		b.WriteString(e.Err.Error())
+	} else {
+		b.WriteString("<nil>")
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// writeKeyPath writes key with its ancestors as kind/name=x or kind/id=n
+// elements, root first
+func writeKeyPath(b *strings.Builder, key *datastore.Key) {
+	if key.Parent != nil {
+		writeKeyPath(b, key.Parent)
+		b.WriteByte('/')
+	}
+	b.WriteString(key.Kind)
+	switch {
+	case key.Name != "":
+		b.WriteString("/name=")
+		b.WriteString(key.Name)
+	case key.ID != 0:
+		b.WriteString("/id=")
+		b.WriteString(strconv.FormatInt(key.ID, 10))
+	}
+}
@@ -0,0 +1,54 @@
+package gostore
+
+import (
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+)
+
+func TestError(t *testing.T) {
+	parent := datastore.NameKey("tenants", "acme", nil)
+
+	tests := []struct {
+		name string
+		err  *Error
+		want string
+	}{
+		{
+			name: "Name key",
+			err:  &Error{Op: "GetByID", Kind: "users", Key: datastore.NameKey("users", "user123", nil), Err: datastore.ErrNoSuchEntity},
+			want: "gostore: users.GetByID key=users/name=user123: datastore: no such entity",
+		},
+		{
+			name: "ID key with a parent",
+			err:  &Error{Op: "Delete", Kind: "orders", Key: datastore.IDKey("orders", 42, parent), Err: errors.New("boom")},
+			want: "gostore: orders.Delete key=tenants/name=acme/orders/id=42: boom",
+		},
+		{
+			name: "Incomplete key",
+			err:  &Error{Op: "Create", Kind: "orders", Key: datastore.IncompleteKey("orders", nil), Err: errors.New("boom")},
+			want: "gostore: orders.Create key=orders: boom",
+		},
+		{
+			name: "Query",
+			err:  &Error{Op: "FindAll", Kind: "users", Err: errors.New("boom")},
+			want: "gostore: users.FindAll: boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("Unwrap", func(t *testing.T) {
+		var err error = &Error{Op: "GetByID", Kind: "users", Err: datastore.ErrNoSuchEntity}
+		if !errors.Is(err, datastore.ErrNoSuchEntity) {
+			t.Error("expected errors.Is to see through the Error")
+		}
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
 	"github.com/AndroX7/gostore/builder"
 	"github.com/AndroX7/gostore/repository"
 )
@@ -65,7 +66,7 @@ func main() {
 		Limit(10)
 
 	var users []User
-	pagination, err := b.Execute(ctx, client, &users)
+	pagination, err := b.Execute(ctx, gostore.Wrap(client), &users)
 	if err != nil {
 		log.Fatal(err)
 	}
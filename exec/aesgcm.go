@@ -0,0 +1,92 @@
+package exec
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrMalformedCiphertext is returned by AESGCM.Decrypt for a ciphertext too
+// short to have been returned by Encrypt
+var ErrMalformedCiphertext = errors.New("malformed ciphertext")
+
+// KeyProvider returns the AES key of version, of 16, 24 or 32 bytes for
+// AES-128, AES-192 or AES-256
+type KeyProvider func(version byte) ([]byte, error)
+
+// AESGCM is a Cipher using AES-GCM. A ciphertext starts with the version of
+// the key that encrypted it, followed by a random nonce, so keys can be
+// rotated by encrypting with a new version while the KeyProvider still
+// returns the old keys for the entities written with them.
+type AESGCM struct {
+	version byte
+	keys    KeyProvider
+	aeads   sync.Map // version -> cipher.AEAD
+}
+
+// NewAESGCM creates an AESGCM encrypting with the key of version and
+// decrypting with the key of each ciphertext's version. keys is called once
+// per version.
+func NewAESGCM(version byte, keys KeyProvider) *AESGCM {
+	return &AESGCM{version: version, keys: keys}
+}
+
+// Encrypt encrypts plaintext with the current key
+func (c *AESGCM) Encrypt(plaintext []byte) ([]byte, error) {
+	aead, err := c.aead(c.version)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := 1 + aead.NonceSize()
+	out := make([]byte, prefix, prefix+len(plaintext)+aead.Overhead())
+	out[0] = c.version
+	if _, err := rand.Read(out[1:]); err != nil {
+		return nil, err
+	}
+	// The version is authenticated with the ciphertext
+	return aead.Seal(out, out[1:], plaintext, out[:1]), nil
+}
+
+// Decrypt decrypts a ciphertext returned by Encrypt with the key of its
+// version
+func (c *AESGCM) Decrypt(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) == 0 {
+		return nil, ErrMalformedCiphertext
+	}
+	aead, err := c.aead(ciphertext[0])
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := 1 + aead.NonceSize()
+	if len(ciphertext) < prefix+aead.Overhead() {
+		return nil, ErrMalformedCiphertext
+	}
+	return aead.Open(nil, ciphertext[1:prefix], ciphertext[prefix:], ciphertext[:1])
+}
+
+// aead returns the AEAD of the key of version
+func (c *AESGCM) aead(version byte) (cipher.AEAD, error) {
+	if aead, ok := c.aeads.Load(version); ok {
+		return aead.(cipher.AEAD), nil
+	}
+
+	key, err := c.keys(version)
+	if err != nil {
+		return nil, fmt.Errorf("key version %d: %w", version, err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("key version %d: %w", version, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	c.aeads.Store(version, aead)
+	return aead, nil
+}
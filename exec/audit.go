@@ -0,0 +1,297 @@
+package exec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+// AuditKind is the kind DatastoreAudit writes entries to
+const AuditKind = "_gostore_audit"
+
+// ErrAuditFailed is matched by the error of a write whose audit entry could
+// not be recorded, with WithStrictAudit or WithTransactionalAudit
+var ErrAuditFailed = errors.New("audit failed")
+
+// AuditOperation is the kind of write an AuditEntry records
+type AuditOperation string
+
+const (
+	AuditCreate AuditOperation = "create"
+	AuditUpdate AuditOperation = "update"
+	AuditPatch  AuditOperation = "patch"
+	AuditDelete AuditOperation = "delete"
+)
+
+// AuditEntry records the write of one entity
+type AuditEntry struct {
+	Kind      string
+	Key       *datastore.Key
+	Operation AuditOperation
+	Timestamp time.Time
+	// Actor is read from the context (see WithAuditActor and
+	// WithAuditActorKey), empty if it carries none
+	Actor string
+	// Before is the entity as it was, as a datastore.PropertyList, for
+	// writes that read it anyway: patches, soft deletes, touches and
+	// UpdateWhere. Otherwise it is nil.
+	Before any
+	// After is the entity as written: the value passed to a create or
+	// update, or the patched datastore.PropertyList. It is nil for deletes.
+	After any
+}
+
+// AuditHook records the writes of an Exec
+type AuditHook interface {
+	Record(ctx context.Context, entry AuditEntry) error
+}
+
+// WithAudit records an entry with hook for every entity written by a
+// successful Create, Update, Patch or Delete, in their single, multi, by-key
+// and soft-delete forms, and for every entity of BulkCreate, BulkDelete,
+// BulkDeleteBuilder, BulkSoftDelete, UpdateWhere, Touch, ImportJSONL,
+// CopyKind and CreateIdempotent. Writes made with a TxExec are recorded once
+// Transaction commits, with keys allocated at commit resolved.
+//
+// Entries are recorded after the write succeeded, so a failing hook can't
+// undo it: the failure is logged with the Exec's logger and the write
+// succeeds, unless WithStrictAudit or WithTransactionalAudit is set. Dry
+// runs record nothing.
+func WithAudit(hook AuditHook) Option {
+	return func(h *Exec) {
+		h.audit = hook
+	}
+}
+
+// WithStrictAudit makes a write whose audit entry can't be recorded fail
+// with an error matching ErrAuditFailed. The write itself has already been
+// made.
+func WithStrictAudit() Option {
+	return func(h *Exec) {
+		h.auditStrict = true
+	}
+}
+
+// WithTransactionalAudit records the entries of writes made in a
+// transaction (Patch and the other writes that read first, and TxExec
+// writes) inside that transaction, with a context AuditTransaction returns
+// it from, so a hook like DatastoreAudit commits them along with the write.
+// A failing hook then fails and rolls back the transaction. Keys allocated
+// at commit are still incomplete in these entries.
+func WithTransactionalAudit() Option {
+	return func(h *Exec) {
+		h.auditTransactional = true
+	}
+}
+
+// WithAuditActorKey makes audit entries read their actor from ctx under key,
+// where an application's authentication middleware may store the user,
+// instead of from WithAuditActor. Values that are not strings are formatted
+// with fmt.
+func WithAuditActorKey(key any) Option {
+	return func(h *Exec) {
+		h.auditActorKey = key
+	}
+}
+
+// auditActorKey is the context key of WithAuditActor
+type auditActorKey struct{}
+
+// WithAuditActor returns a copy of ctx naming actor as the author of the
+// writes audited with it
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// auditTxKey is the context key of the transaction an entry is recorded in
+type auditTxKey struct{}
+
+// AuditTransaction returns the transaction an AuditHook is called inside of
+// with WithTransactionalAudit, so that it can write the entry as part of it
+func AuditTransaction(ctx context.Context) (gostore.Transaction, bool) {
+	tx, ok := ctx.Value(auditTxKey{}).(gostore.Transaction)
+	return tx, ok
+}
+
+func (h *Exec) auditActor(ctx context.Context) string {
+	var key any = auditActorKey{}
+	if h.auditActorKey != nil {
+		key = h.auditActorKey
+	}
+	switch actor := ctx.Value(key).(type) {
+	case nil:
+		return ""
+	case string:
+		return actor
+	default:
+		return fmt.Sprint(actor)
+	}
+}
+
+// auditBatch is the audit of one write of keys
+type auditBatch struct {
+	op   AuditOperation
+	keys []*datastore.Key
+	// before and after return the snapshots of keys[i], if set
+	before func(i int) any
+	after  func(i int) any
+}
+
+// auditEntities returns an after function over entities, a slice
+func auditEntities(entities any) func(i int) any {
+	v := reflect.ValueOf(entities)
+	return func(i int) any {
+		return v.Index(i).Interface()
+	}
+}
+
+// auditEntity returns an after function of a single entity
+func auditEntity(entity any) func(i int) any {
+	return func(int) any {
+		return entity
+	}
+}
+
+// auditProps returns a snapshot function over props
+func auditProps(props []datastore.PropertyList) func(i int) any {
+	if props == nil {
+		return nil
+	}
+	return func(i int) any {
+		return props[i]
+	}
+}
+
+// recordAudit records an entry for every key of b after a successful write.
+// Hook failures are logged and ignored unless the audit is strict.
+func (h *Exec) recordAudit(ctx context.Context, b auditBatch) error {
+	if h.audit == nil || h.dryRun {
+		return nil
+	}
+
+	at := now()
+	actor := h.auditActor(ctx)
+	for i, key := range b.keys {
+		entry := AuditEntry{Kind: key.Kind, Key: key, Operation: b.op, Timestamp: at, Actor: actor}
+		if b.before != nil {
+			entry.Before = b.before(i)
+		}
+		if b.after != nil {
+			entry.After = b.after(i)
+		}
+
+		err := h.audit.Record(ctx, entry)
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("%w: %s %v: %w", ErrAuditFailed, b.op, key, err)
+		if h.auditStrict {
+			return err
+		}
+		if h.logger != nil {
+			h.logger.WarnContext(ctx, "gostore audit failed", "kind", key.Kind, "error", err)
+		}
+	}
+	return nil
+}
+
+// recordAuditInTx records b inside tx with WithTransactionalAudit, failing
+// the transaction if the hook fails. It reports whether it recorded b, which
+// must otherwise be recorded once the transaction commits.
+func (h *Exec) recordAuditInTx(ctx context.Context, tx gostore.Transaction, b auditBatch) (bool, error) {
+	if h.audit == nil || !h.auditTransactional {
+		return false, nil
+	}
+
+	strict := *h
+	strict.auditStrict = true
+	return true, strict.recordAudit(context.WithValue(ctx, auditTxKey{}, tx), b)
+}
+
+// DatastoreAudit is an AuditHook writing each entry as an entity of
+// AuditKind with an allocated ID. Snapshots are stored as unindexed JSON.
+type DatastoreAudit struct {
+	client gostore.Client
+}
+
+// NewDatastoreAudit returns a DatastoreAudit writing with client, or with
+// the client stored in the context of each entry for a nil client
+func NewDatastoreAudit(client gostore.Client) *DatastoreAudit {
+	return &DatastoreAudit{client: client}
+}
+
+// auditRecord is the entity DatastoreAudit writes
+type auditRecord struct {
+	Kind      string         `datastore:"kind"`
+	Key       *datastore.Key `datastore:"key"`
+	Operation string         `datastore:"operation"`
+	Timestamp time.Time      `datastore:"timestamp"`
+	Actor     string         `datastore:"actor"`
+	Before    string         `datastore:"before,noindex,omitempty"`
+	After     string         `datastore:"after,noindex,omitempty"`
+}
+
+// Record writes entry, inside the transaction AuditTransaction returns if
+// there is one
+func (a *DatastoreAudit) Record(ctx context.Context, entry AuditEntry) error {
+	record := auditRecord{
+		Kind:      entry.Kind,
+		Key:       entry.Key,
+		Operation: string(entry.Operation),
+		Timestamp: entry.Timestamp,
+		Actor:     entry.Actor,
+	}
+	var err error
+	if record.Before, err = snapshotJSON(entry.Before); err != nil {
+		return err
+	}
+	if record.After, err = snapshotJSON(entry.After); err != nil {
+		return err
+	}
+
+	// Entries go to the namespace of the entity they record
+	key := datastore.IncompleteKey(AuditKind, nil)
+	if entry.Key != nil {
+		key.Namespace = entry.Key.Namespace
+	}
+
+	if tx, ok := AuditTransaction(ctx); ok {
+		_, err := tx.Put(key, &record)
+		return err
+	}
+
+	client := a.client
+	if client == nil {
+		if client, err = contextKey.ClientFromContext(ctx); err != nil {
+			return err
+		}
+	}
+	_, err = client.Put(ctx, key, &record)
+	return err
+}
+
+// snapshotJSON encodes a snapshot, with property lists as objects
+func snapshotJSON(v any) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	if props, ok := v.(datastore.PropertyList); ok {
+		m := make(map[string]any, len(props))
+		for _, p := range props {
+			m[p.Name] = p.Value
+		}
+		v = m
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("encode audit snapshot: %w", err)
+	}
+	return string(data), nil
+}
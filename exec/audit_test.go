@@ -0,0 +1,305 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+// recordingAudit is an AuditHook keeping every entry, failing with err if set
+type recordingAudit struct {
+	mu      sync.Mutex
+	entries []exec.AuditEntry
+	err     error
+}
+
+func (a *recordingAudit) Record(ctx context.Context, entry exec.AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err != nil {
+		return a.err
+	}
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+func (a *recordingAudit) take() []exec.AuditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entries := a.entries
+	a.entries = nil
+	return entries
+}
+
+func TestAudit(t *testing.T) {
+	ctx := context.Background()
+
+	newExec := func(opts ...exec.Option) (*exec.Exec, *recordingAudit, *testutil.MockDatastoreClient) {
+		audit := &recordingAudit{}
+		mock := testutil.NewMockClient()
+		opts = append([]exec.Option{exec.WithClient(mock), exec.WithAudit(audit)}, opts...)
+		return exec.NewExecWithOptions(opts...), audit, mock
+	}
+
+	expect := func(t *testing.T, entries []exec.AuditEntry, op exec.AuditOperation, names ...string) {
+		t.Helper()
+		if len(entries) != len(names) {
+			t.Fatalf("expected %d entries, got %d: %+v", len(names), len(entries), entries)
+		}
+		for i, entry := range entries {
+			if entry.Operation != op || entry.Kind != "users" || entry.Key == nil || entry.Key.Name != names[i] {
+				t.Errorf("expected %s of users/%s, got %s of %v", op, names[i], entry.Operation, entry.Key)
+			}
+			if entry.Timestamp.IsZero() {
+				t.Errorf("expected a timestamp on entry %d", i)
+			}
+		}
+	}
+
+	t.Run("Create, Update, Patch and Delete", func(t *testing.T) {
+		h, audit, _ := newExec()
+		ctx := exec.WithAuditActor(ctx, "admin@example.com")
+
+		user := &testutil.TestUser{Name: "John", Age: 30}
+		if err := h.Create(ctx, "users", "john", user); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		entries := audit.take()
+		expect(t, entries, exec.AuditCreate, "john")
+		if entries[0].After != user || entries[0].Before != nil {
+			t.Errorf("expected the created entity as After, got %+v", entries[0])
+		}
+		if entries[0].Actor != "admin@example.com" {
+			t.Errorf("expected actor from the context, got %q", entries[0].Actor)
+		}
+
+		user.Age = 31
+		if err := h.Update(ctx, "users", "john", user); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		expect(t, audit.take(), exec.AuditUpdate, "john")
+
+		if err := h.Patch(ctx, "users", "john", map[string]any{"age": 32}); err != nil {
+			t.Fatalf("Patch failed: %v", err)
+		}
+		entries = audit.take()
+		expect(t, entries, exec.AuditPatch, "john")
+		before, _ := entries[0].Before.(datastore.PropertyList)
+		after, _ := entries[0].After.(datastore.PropertyList)
+		if age := propValue(before, "age"); age != int64(31) {
+			t.Errorf("expected age 31 before the patch, got %v", age)
+		}
+		if age := propValue(after, "age"); age != int64(32) {
+			t.Errorf("expected age 32 after the patch, got %v", age)
+		}
+
+		if err := h.Delete(ctx, "users", "john"); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		expect(t, audit.take(), exec.AuditDelete, "john")
+	})
+
+	t.Run("One entry per item of multi and bulk writes", func(t *testing.T) {
+		h, audit, _ := newExec()
+
+		users := []testutil.TestUser{{Name: "A"}, {Name: "B"}, {Name: "C"}}
+		if err := h.CreateMulti(ctx, "users", []any{"a", "b", "c"}, users); err != nil {
+			t.Fatalf("CreateMulti failed: %v", err)
+		}
+		entries := audit.take()
+		expect(t, entries, exec.AuditCreate, "a", "b", "c")
+		if after, ok := entries[1].After.(testutil.TestUser); !ok || after.Name != "B" {
+			t.Errorf("expected the second user as After, got %+v", entries[1].After)
+		}
+
+		if err := h.DeleteMulti(ctx, "users", []any{"a", "b"}); err != nil {
+			t.Fatalf("DeleteMulti failed: %v", err)
+		}
+		expect(t, audit.take(), exec.AuditDelete, "a", "b")
+
+		n, err := h.BulkDelete(ctx, "users", nil)
+		if err != nil || n != 1 {
+			t.Fatalf("expected 1 deleted, got %d, %v", n, err)
+		}
+		expect(t, audit.take(), exec.AuditDelete, "c")
+	})
+
+	t.Run("Soft deletes are patches", func(t *testing.T) {
+		h, audit, _ := newExec(exec.WithSoftDelete(""))
+		if err := h.CreateMulti(ctx, "users", []any{"a", "b"}, []softUser{{Name: "A"}, {Name: "B"}}); err != nil {
+			t.Fatalf("CreateMulti failed: %v", err)
+		}
+		audit.take()
+
+		n, err := h.BulkSoftDelete(ctx, "users", nil)
+		if err != nil || n != 2 {
+			t.Fatalf("expected 2 soft deleted, got %d, %v", n, err)
+		}
+		entries := audit.take()
+		expect(t, entries, exec.AuditPatch, "a", "b")
+		if propValue(entries[0].After.(datastore.PropertyList), "deleted_at") == nil {
+			t.Errorf("expected deleted_at in the patched entity, got %+v", entries[0].After)
+		}
+	})
+
+	t.Run("Transaction writes are recorded after commit", func(t *testing.T) {
+		h, audit, _ := newExec()
+
+		_, err := h.Transaction(ctx, func(tx *exec.TxExec) error {
+			if err := tx.Create(ctx, "users", "tx", &testutil.TestUser{Name: "Tx"}); err != nil {
+				return err
+			}
+			if len(audit.take()) != 0 {
+				t.Error("expected nothing recorded before commit")
+			}
+			return tx.Patch(ctx, "users", "tx", map[string]any{"age": 40})
+		})
+		if err != nil {
+			t.Fatalf("Transaction failed: %v", err)
+		}
+		entries := audit.take()
+		if len(entries) != 2 || entries[0].Operation != exec.AuditCreate || entries[1].Operation != exec.AuditPatch {
+			t.Fatalf("expected a create and a patch, got %+v", entries)
+		}
+
+		_, err = h.Transaction(ctx, func(tx *exec.TxExec) error {
+			if err := tx.Delete(ctx, "users", "tx"); err != nil {
+				return err
+			}
+			return errors.New("rolled back")
+		})
+		if err == nil {
+			t.Fatal("expected the transaction to fail")
+		}
+		if entries := audit.take(); len(entries) != 0 {
+			t.Errorf("expected nothing recorded for a rolled back transaction, got %+v", entries)
+		}
+	})
+
+	t.Run("Actor from a context key", func(t *testing.T) {
+		type userKey struct{}
+		h, audit, _ := newExec(exec.WithAuditActorKey(userKey{}))
+
+		ctx := context.WithValue(ctx, userKey{}, 42)
+		if err := h.Create(ctx, "users", "john", &testutil.TestUser{}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if entries := audit.take(); len(entries) != 1 || entries[0].Actor != "42" {
+			t.Errorf("expected actor 42, got %+v", entries)
+		}
+	})
+
+	t.Run("Hook failures don't fail the write", func(t *testing.T) {
+		h, audit, mock := newExec()
+		audit.err = errors.New("audit store down")
+
+		if err := h.Create(ctx, "users", "john", &testutil.TestUser{}); err != nil {
+			t.Fatalf("expected the write to succeed, got %v", err)
+		}
+		if mock.Count("users") != 1 {
+			t.Error("expected the user to be written")
+		}
+	})
+
+	t.Run("Strict audit returns hook failures", func(t *testing.T) {
+		h, audit, mock := newExec(exec.WithStrictAudit())
+		audit.err = errors.New("audit store down")
+
+		err := h.Create(ctx, "users", "john", &testutil.TestUser{})
+		if !errors.Is(err, exec.ErrAuditFailed) || !errors.Is(err, audit.err) {
+			t.Fatalf("expected ErrAuditFailed wrapping the hook error, got %v", err)
+		}
+		if mock.Count("users") != 1 {
+			t.Error("expected the user to be written anyway")
+		}
+	})
+
+	t.Run("Transactional audit rolls back the write", func(t *testing.T) {
+		h, audit, mock := newExec(exec.WithTransactionalAudit())
+		if err := h.Create(ctx, "users", "john", &testutil.TestUser{Age: 30}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		audit.take()
+		audit.err = errors.New("audit store down")
+
+		err := h.Patch(ctx, "users", "john", map[string]any{"age": 31})
+		if !errors.Is(err, exec.ErrAuditFailed) {
+			t.Fatalf("expected ErrAuditFailed, got %v", err)
+		}
+
+		var user testutil.TestUser
+		if err := mock.Get(ctx, datastore.NameKey("users", "john", nil), &user); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if user.Age != 30 {
+			t.Errorf("expected the patch to be rolled back, got age %d", user.Age)
+		}
+	})
+
+	t.Run("Dry runs record nothing", func(t *testing.T) {
+		h, audit, _ := newExec(exec.WithDryRun())
+		if err := h.Create(ctx, "users", "john", &testutil.TestUser{}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if entries := audit.take(); len(entries) != 0 {
+			t.Errorf("expected no entries, got %+v", entries)
+		}
+	})
+}
+
+func TestDatastoreAudit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Writes entries beside the main write", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		h := exec.NewExecWithOptions(exec.WithClient(mock), exec.WithAudit(exec.NewDatastoreAudit(mock)))
+
+		ctx := exec.WithAuditActor(ctx, "admin")
+		if err := h.CreateMulti(ctx, "users", []any{"a", "b"}, []testutil.TestUser{{Name: "A"}, {Name: "B"}}); err != nil {
+			t.Fatalf("CreateMulti failed: %v", err)
+		}
+		if err := h.Patch(ctx, "users", "a", map[string]any{"age": 20}); err != nil {
+			t.Fatalf("Patch failed: %v", err)
+		}
+		if n := mock.Count(exec.AuditKind); n != 3 {
+			t.Errorf("expected 3 audit entities, got %d", n)
+		}
+	})
+
+	t.Run("Transactional entries commit with the write", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		h := exec.NewExecWithOptions(
+			exec.WithClient(mock),
+			exec.WithAudit(exec.NewDatastoreAudit(mock)),
+			exec.WithTransactionalAudit(),
+		)
+		if err := h.Create(ctx, "users", "a", &testutil.TestUser{}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		mock.ResetOperations()
+
+		if err := h.Patch(ctx, "users", "a", map[string]any{"age": 20}); err != nil {
+			t.Fatalf("Patch failed: %v", err)
+		}
+		if n := mock.Count(exec.AuditKind); n != 2 {
+			t.Errorf("expected 2 audit entities, got %d", n)
+		}
+		if puts := mock.OperationsFor(testutil.OpPut); len(puts) != 0 {
+			t.Errorf("expected the patch entry to be written in the transaction, got %d puts", len(puts))
+		}
+	})
+}
+
+func propValue(props datastore.PropertyList, name string) any {
+	for _, p := range props {
+		if p.Name == name {
+			return p.Value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package exec
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+type autoUUID struct{}
+
+// AutoUUID, passed as the ID of a write, stores the entity under a generated
+// name instead of a Datastore-allocated numeric ID. Names come from the
+// generator set with WithIDGenerator, or NewUUID by default.
+var AutoUUID = autoUUID{}
+
+// NewUUID returns a random (version 4) UUID string
+func NewUUID() string {
+	return uuid.NewString()
+}
+
+// WithIDGenerator makes writes with a nil ID store the entity under a name
+// produced by gen rather than a numeric ID; this also covers BulkCreate. A nil
+// gen uses NewUUID.
+func WithIDGenerator(gen func() string) Option {
+	return func(h *Exec) {
+		if gen == nil {
+			gen = NewUUID
+		}
+		h.generateID = gen
+	}
+}
+
+// resolveID replaces AutoUUID, and nil when a generator is configured, with
+// a generated name
+func (h *Exec) resolveID(id any) (any, error) {
+	switch id.(type) {
+	case autoUUID:
+	case nil:
+		if h.generateID == nil {
+			return nil, nil
+		}
+	default:
+		return id, nil
+	}
+
+	name := h.idGenerator()()
+	if name == "" {
+		return nil, errors.New("ID generator returned an empty name")
+	}
+	return name, nil
+}
+
+func (h *Exec) idGenerator() func() string {
+	if h.generateID != nil {
+		return h.generateID
+	}
+	return NewUUID
+}
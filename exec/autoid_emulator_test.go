@@ -0,0 +1,46 @@
+package exec_test
+
+import (
+	"testing"
+
+	"github.com/AndroX7/gostore/exec"
+)
+
+func TestCreateWithKeyAutoUUID(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	t.Run("Returned key carries the generated name", func(t *testing.T) {
+		key, err := h.CreateWithKey(ctx, "users", exec.AutoUUID, &stampedUser{Name: "John"})
+		if err != nil {
+			t.Fatalf("CreateWithKey failed: %v", err)
+		}
+		if key.Name == "" {
+			t.Fatalf("expected named key, got %v", key)
+		}
+
+		var got stampedUser
+		if err := h.GetByID(ctx, "users", key.Name, &got); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if got.Name != "John" {
+			t.Errorf("expected name 'John', got '%s'", got.Name)
+		}
+	})
+
+	t.Run("CreateMultiWithKeys names every entity", func(t *testing.T) {
+		users := []stampedUser{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+		keys, err := h.CreateMultiWithKeys(ctx, "users", []any{exec.AutoUUID, exec.AutoUUID, exec.AutoUUID}, users)
+		if err != nil {
+			t.Fatalf("CreateMultiWithKeys failed: %v", err)
+		}
+
+		seen := map[string]bool{}
+		for _, key := range keys {
+			if key.Name == "" || seen[key.Name] {
+				t.Errorf("expected unique named keys, got %v", keys)
+			}
+			seen[key.Name] = true
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package exec
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/google/uuid"
+)
+
+func TestAutoUUID(t *testing.T) {
+	t.Run("AutoUUID generates a UUID name", func(t *testing.T) {
+		key, err := NewExec().newKey("users", AutoUUID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := uuid.Parse(key.Name); err != nil {
+			t.Errorf("expected UUID name, got %q", key.Name)
+		}
+	})
+
+	t.Run("Nil ID stays incomplete without a generator", func(t *testing.T) {
+		key, err := NewExec().newKey("users", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !key.Incomplete() {
+			t.Errorf("expected incomplete key, got %v", key)
+		}
+	})
+
+	t.Run("WithIDGenerator names nil IDs", func(t *testing.T) {
+		h := NewExecWithOptions(WithIDGenerator(func() string { return "fixed" }))
+
+		keys, err := h.newKeys("users", []any{nil, AutoUUID, "given"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for i, want := range []string{"fixed", "fixed", "given"} {
+			if keys[i].Name != want {
+				t.Errorf("key %d: expected name %q, got %q", i, want, keys[i].Name)
+			}
+		}
+	})
+
+	t.Run("Empty generated name is an error", func(t *testing.T) {
+		h := NewExecWithOptions(WithIDGenerator(func() string { return "" }))
+
+		if _, err := h.newKey("users", nil); err == nil {
+			t.Error("expected error for empty generated name")
+		}
+	})
+}
+
+func TestBulkCreateGenerateID(t *testing.T) {
+	t.Run("Generates a unique name per entity", func(t *testing.T) {
+		var written []*datastore.Key
+		put := func(ctx context.Context, keys []*datastore.Key, src any) ([]*datastore.Key, error) {
+			written = append(written, keys...)
+			return keys, nil
+		}
+
+		opts := BulkOptions{GenerateID: NewUUID}
+		if err := bulkCreate(context.Background(), "users", bulkUsers(1000), 0, opts, put); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(written) != 1000 {
+			t.Fatalf("expected 1000 keys, got %d", len(written))
+		}
+		seen := make(map[string]bool, len(written))
+		for _, key := range written {
+			if key.Name == "" {
+				t.Fatalf("expected named key, got %v", key)
+			}
+			if seen[key.Name] {
+				t.Fatalf("duplicate name %q", key.Name)
+			}
+			seen[key.Name] = true
+		}
+	})
+}
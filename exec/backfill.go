@@ -0,0 +1,281 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/builder"
+	"google.golang.org/api/iterator"
+)
+
+// BackfillProgressKind is the kind Backfill persists the progress of a job
+// with a JobID in
+const BackfillProgressKind = "_gostore_backfill"
+
+// DefaultBackfillPageSize is the number of entities Backfill reads at a time
+const DefaultBackfillPageSize = 500
+
+// ErrBackfillAborted is matched by the error of a Backfill stopped by more
+// than BackfillOptions.MaxErrors failed entities
+var ErrBackfillAborted = errors.New("backfill aborted")
+
+// BackfillOptions configures Backfill
+type BackfillOptions struct {
+	// PageSize is the number of entities read at a time,
+	// DefaultBackfillPageSize if <= 0. Changed entities are written back in
+	// batches of at most MaxBatchSize.
+	PageSize int
+
+	// RateLimit, if > 0, paces writes to that many entities a second like
+	// WithWriteRateLimit, instead of the Exec's own limit
+	RateLimit float64
+
+	// DryRun runs the transform and counts the entities it changes without
+	// writing them or the progress, like an Exec with WithDryRun
+	DryRun bool
+
+	// MaxErrors is the number of entities that may fail, in the transform or
+	// when written, before the backfill is aborted with an error matching
+	// ErrBackfillAborted. At 0 the first failure aborts it; below 0 it never
+	// is.
+	MaxErrors int
+
+	// Cursor resumes the backfill after the page that returned it
+	Cursor string
+
+	// JobID, if set, persists the cursor and counts after every page in an
+	// entity of BackfillProgressKind named JobID, and resumes from it, so a
+	// job that crashed picks up where it left off when run again. A job
+	// that completed does nothing when run again with the same JobID.
+	JobID string
+
+	// OnPage is called after every page with the report so far
+	OnPage func(report BackfillReport)
+}
+
+// BackfillReport counts the entities a Backfill went through
+type BackfillReport struct {
+	// Scanned is the number of entities read
+	Scanned int
+	// Changed is the number of entities the transform changed and that
+	// were written back, or would be in a dry run
+	Changed int
+	// Failed is the number of entities whose transform or write failed
+	Failed int
+	// Errors holds the error of every failed entity
+	Errors []error
+	// Cursor points after the last page processed. It is empty once the
+	// backfill is complete.
+	Cursor string
+}
+
+// backfillProgress is the entity persisting the progress of a job
+type backfillProgress struct {
+	Kind      string    `datastore:"kind"`
+	Cursor    string    `datastore:"cursor,noindex"`
+	Scanned   int       `datastore:"scanned,noindex"`
+	Changed   int       `datastore:"changed,noindex"`
+	Failed    int       `datastore:"failed,noindex"`
+	Done      bool      `datastore:"done"`
+	UpdatedAt time.Time `datastore:"updated_at"`
+}
+
+// Backfill calls transform with every entity of kind matching filters, a
+// page at a time, and writes back the entities it reports as changed, e.g.
+// to set a new property on existing entities. It returns the report of this
+// run. Soft-deleted entities are skipped on an Exec with WithSoftDelete.
+//
+// Pages are read with cursors, so the job can be resumed with
+// BackfillOptions.Cursor or JobID after a crash. A page is only recorded as
+// done once its entities are written, so a crash in between runs the page
+// again: transform should report entities it already changed as unchanged.
+// Entities are written back as read, so changes made to them by others
+// between the read and the write are lost; transform should only run on
+// entities nothing else writes to at the time, or be rerun afterwards.
+//
+// Cancelling ctx stops the backfill after the page being written; the
+// report then holds the cursor to resume from.
+func (h *Exec) Backfill(ctx context.Context, kind string, filters map[string]any, transform func(key *datastore.Key, props *datastore.PropertyList) (changed bool, err error), opts BackfillOptions) (report *BackfillReport, err error) {
+	ctx, finish := h.startBulk(ctx, "Backfill", kind)
+	report = &BackfillReport{Cursor: opts.Cursor}
+	defer func() { finish(report.Changed, err) }()
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	if opts.RateLimit > 0 {
+		h = h.With(WithWriteRateLimit(opts.RateLimit))
+	}
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultBackfillPageSize
+	}
+	dryRun := opts.DryRun || h.dryRun
+
+	var progressKey *datastore.Key
+	var progress backfillProgress
+	if opts.JobID != "" && !dryRun {
+		progressKey = h.setNamespace(datastore.NameKey(BackfillProgressKind, opts.JobID, nil))
+		err := client.Get(ctx, progressKey, &progress)
+		switch {
+		case errors.Is(err, datastore.ErrNoSuchEntity):
+			progress = backfillProgress{Kind: kind, Cursor: opts.Cursor}
+		case err != nil:
+			return report, fmt.Errorf("load backfill progress: %w", err)
+		case progress.Kind != kind:
+			return report, fmt.Errorf("backfill job %s is of kind %q", opts.JobID, progress.Kind)
+		case progress.Done:
+			report.Cursor = ""
+			return report, nil
+		}
+		report.Cursor = progress.Cursor
+	}
+	// The counts of the runs before this one
+	base := progress
+
+	b := h.newBuilder(kind).Limit(pageSize)
+	fb := builder.NewFilter().FromMap(filters)
+	for _, filter := range fb.Build() {
+		b.Filter(filter.Field, filter.Operator, filter.Value)
+	}
+	h.applySoftDelete(b, queryOptions{})
+
+	o := op{name: "Backfill", kind: kind}
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		keys, entities, next, err := h.entitiesPage(ctx, o, client, b, report.Cursor)
+		if err != nil {
+			return report, err
+		}
+		report.Scanned += len(keys)
+
+		var changedKeys []*datastore.Key
+		var changed []datastore.PropertyList
+		for i, key := range keys {
+			ok, err := transform(key, &entities[i])
+			if err != nil {
+				report.fail(key, err)
+				continue
+			}
+			if ok {
+				changedKeys = append(changedKeys, key)
+				changed = append(changed, entities[i])
+			}
+		}
+
+		if dryRun {
+			report.Changed += len(changedKeys)
+		} else if err := h.backfillWrite(ctx, o, client, changedKeys, changed, report); err != nil {
+			return report, err
+		}
+		if opts.MaxErrors >= 0 && report.Failed > opts.MaxErrors {
+			return report, fmt.Errorf("%w: %d entities failed: %w", ErrBackfillAborted, report.Failed, errors.Join(report.Errors...))
+		}
+
+		done := len(keys) < pageSize
+		report.Cursor = next
+		if done {
+			report.Cursor = ""
+		}
+		if progressKey != nil {
+			progress.Cursor, progress.Done, progress.UpdatedAt = next, done, now()
+			progress.Scanned = base.Scanned + report.Scanned
+			progress.Changed = base.Changed + report.Changed
+			progress.Failed = base.Failed + report.Failed
+			if _, err := client.Put(ctx, progressKey, &progress); err != nil {
+				return report, fmt.Errorf("save backfill progress: %w", err)
+			}
+		}
+		if opts.OnPage != nil {
+			opts.OnPage(*report)
+		}
+		if done {
+			return report, nil
+		}
+	}
+}
+
+// fail records the failure of the entity stored under key
+func (r *BackfillReport) fail(key *datastore.Key, err error) {
+	r.Failed++
+	r.Errors = append(r.Errors, fmt.Errorf("%v: %w", key, err))
+}
+
+// backfillWrite writes entities under keys in batches, counting them in
+// report as changed or failed. Only a cancelled ctx is returned as an error.
+func (h *Exec) backfillWrite(ctx context.Context, o op, client gostore.Client, keys []*datastore.Key, entities []datastore.PropertyList, report *BackfillReport) error {
+	o.write = true
+	_, err := inBatches(ctx, len(keys), MaxBatchSize, h.paced(ctx, func(start, end int) error {
+		batch := keys[start:end]
+		err := h.run(ctx, o.on(batch...), func(ctx context.Context) error {
+			_, err := client.PutMulti(ctx, batch, entities[start:end])
+			return err
+		})
+
+		var me datastore.MultiError
+		switch {
+		case err == nil:
+			report.Changed += len(batch)
+		case errors.As(err, &me) && len(me) == len(batch):
+			for i, e := range me {
+				if e != nil {
+					report.fail(batch[i], e)
+				} else {
+					report.Changed++
+				}
+			}
+		case ctx.Err() != nil:
+			return err
+		default:
+			for _, key := range batch {
+				report.fail(key, err)
+			}
+		}
+		return nil
+	}))
+	return err
+}
+
+// entitiesPage runs b from cursor as part of o and returns the entities and
+// the cursor after them
+func (h *Exec) entitiesPage(ctx context.Context, o op, client gostore.Client, b *builder.Builder, cursor string) ([]*datastore.Key, []datastore.PropertyList, string, error) {
+	page := b.Clone().Cursor(cursor)
+	var keys []*datastore.Key
+	var entities []datastore.PropertyList
+	var next string
+
+	o.query = page
+	o.results = func() int { return len(keys) }
+	err := h.run(ctx, o, func(ctx context.Context) error {
+		keys, entities = nil, nil
+		it := client.Run(ctx, page.Build())
+		for {
+			var props datastore.PropertyList
+			key, err := it.Next(&props)
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			keys = append(keys, key)
+			entities = append(entities, props)
+		}
+		c, err := it.Cursor()
+		if err != nil {
+			return err
+		}
+		next = c.String()
+		return nil
+	})
+	return keys, entities, next, err
+}
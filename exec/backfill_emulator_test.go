@@ -0,0 +1,74 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestBackfillEmulator(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	ids := make([]any, 2000)
+	users := make([]testutil.TestUser, len(ids))
+	for i := range ids {
+		ids[i] = fmt.Sprintf("backfill-%04d", i)
+		users[i] = testutil.TestUser{Name: ids[i].(string), Age: 20 + i%40}
+	}
+	for start := 0; start < len(ids); start += exec.MaxBatchSize {
+		end := min(start+exec.MaxBatchSize, len(ids))
+		if err := h.CreateMulti(ctx, "backfill_users", ids[start:end], users[start:end]); err != nil {
+			t.Fatalf("CreateMulti failed: %v", err)
+		}
+	}
+
+	seen := map[string]int{}
+	transform := setTier(seen)
+
+	// The job crashes after 3 pages
+	crashCtx, crash := context.WithCancel(ctx)
+	opts := exec.BackfillOptions{
+		PageSize: 300,
+		JobID:    "backfill-tiers",
+		OnPage: func(r exec.BackfillReport) {
+			if r.Scanned >= 900 {
+				crash()
+			}
+		},
+	}
+	report, err := h.Backfill(crashCtx, "backfill_users", nil, transform, opts)
+	if !errors.Is(err, context.Canceled) || report.Changed != 900 {
+		t.Fatalf("expected a crash after 900, got %+v, %v", report, err)
+	}
+
+	opts.OnPage = nil
+	report, err = h.Backfill(ctx, "backfill_users", nil, transform, opts)
+	if err != nil || report.Scanned != 1100 || report.Changed != 1100 || report.Cursor != "" {
+		t.Fatalf("expected the other 1100 backfilled, got %+v, %v", report, err)
+	}
+
+	if len(seen) != 2000 {
+		t.Errorf("expected every user transformed, got %d", len(seen))
+	}
+	for name, n := range seen {
+		if n != 1 {
+			t.Errorf("expected %s transformed once, got %d", name, n)
+		}
+	}
+
+	var stored []datastore.PropertyList
+	if err := h.FindAll(ctx, "backfill_users", &stored); err != nil {
+		t.Fatalf("FindAll failed: %v", err)
+	}
+	for _, props := range stored {
+		if propValue(props, "tier") == nil {
+			t.Fatalf("expected every user to have a tier, got %v", props)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+// setTier is a Backfill transform setting a tier from the age, leaving
+// entities that have one alone
+func setTier(seen map[string]int) func(*datastore.Key, *datastore.PropertyList) (bool, error) {
+	return func(key *datastore.Key, props *datastore.PropertyList) (bool, error) {
+		seen[key.Name]++
+		var age int64
+		for _, p := range *props {
+			switch p.Name {
+			case "tier":
+				return false, nil
+			case "age":
+				age = p.Value.(int64)
+			}
+		}
+		tier := "junior"
+		if age >= 40 {
+			tier = "senior"
+		}
+		*props = append(*props, datastore.Property{Name: "tier", Value: tier})
+		return true, nil
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	ctx := context.Background()
+
+	seed := func(t *testing.T, n int) *testutil.MockDatastoreClient {
+		t.Helper()
+		mock := testutil.NewMockClient()
+		keys := make([]*datastore.Key, n)
+		users := make([]testutil.TestUser, n)
+		for i := range keys {
+			keys[i] = datastore.NameKey("users", fmt.Sprintf("u%04d", i), nil)
+			users[i] = testutil.TestUser{Name: keys[i].Name, Age: 20 + i%40}
+		}
+		if _, err := testutil.PutInBatches(ctx, mock, keys, users); err != nil {
+			t.Fatalf("PutInBatches failed: %v", err)
+		}
+		mock.ResetOperations()
+		return mock
+	}
+
+	tiers := func(t *testing.T, mock *testutil.MockDatastoreClient) int {
+		t.Helper()
+		var entities []datastore.PropertyList
+		if _, err := mock.GetAll(ctx, datastore.NewQuery("users"), &entities); err != nil {
+			t.Fatalf("GetAll failed: %v", err)
+		}
+		n := 0
+		for _, props := range entities {
+			for _, p := range props {
+				if p.Name == "tier" {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	t.Run("Writes back changed entities", func(t *testing.T) {
+		mock := seed(t, 1200)
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+		seen := map[string]int{}
+		report, err := h.Backfill(ctx, "users", map[string]any{"age >=": 40}, setTier(seen), exec.BackfillOptions{PageSize: 700})
+		if err != nil {
+			t.Fatalf("Backfill failed: %v", err)
+		}
+		if report.Scanned != 600 || report.Changed != 600 || report.Failed != 0 || report.Cursor != "" {
+			t.Errorf("expected 600 scanned and changed, got %+v", report)
+		}
+		if n := tiers(t, mock); n != 600 {
+			t.Errorf("expected 600 tiers, got %d", n)
+		}
+		for _, op := range mock.OperationsFor(testutil.OpPutMulti) {
+			if op.Count > exec.MaxBatchSize {
+				t.Errorf("expected batches of at most %d, got %d", exec.MaxBatchSize, op.Count)
+			}
+		}
+
+		// A second run finds nothing left to change
+		report, err = h.Backfill(ctx, "users", nil, setTier(seen), exec.BackfillOptions{})
+		if err != nil || report.Scanned != 1200 || report.Changed != 600 {
+			t.Errorf("expected the other 600 changed, got %+v, %v", report, err)
+		}
+	})
+
+	t.Run("Dry run writes nothing", func(t *testing.T) {
+		mock := seed(t, 100)
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+		report, err := h.Backfill(ctx, "users", nil, setTier(map[string]int{}), exec.BackfillOptions{PageSize: 30, DryRun: true, JobID: "dry"})
+		if err != nil || report.Changed != 100 {
+			t.Fatalf("expected 100 changed, got %+v, %v", report, err)
+		}
+		if n := tiers(t, mock); n != 0 {
+			t.Errorf("expected nothing written, got %d tiers", n)
+		}
+		if n := mock.Count(exec.BackfillProgressKind); n != 0 {
+			t.Errorf("expected no progress saved, got %d", n)
+		}
+	})
+
+	t.Run("Resumes a crashed job exactly once", func(t *testing.T) {
+		mock := seed(t, 1000)
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+		seen := map[string]int{}
+
+		crashCtx, crash := context.WithCancel(ctx)
+		opts := exec.BackfillOptions{
+			PageSize: 150,
+			JobID:    "tiers",
+			OnPage: func(r exec.BackfillReport) {
+				if r.Scanned >= 450 {
+					crash()
+				}
+			},
+		}
+		report, err := h.Backfill(crashCtx, "users", nil, setTier(seen), opts)
+		if !errors.Is(err, context.Canceled) || report.Changed != 450 || report.Cursor == "" {
+			t.Fatalf("expected a crash after 450, got %+v, %v", report, err)
+		}
+
+		opts.OnPage = nil
+		report, err = h.Backfill(ctx, "users", nil, setTier(seen), opts)
+		if err != nil || report.Scanned != 550 || report.Changed != 550 {
+			t.Fatalf("expected the other 550 backfilled, got %+v, %v", report, err)
+		}
+		if len(seen) != 1000 {
+			t.Errorf("expected every user transformed, got %d", len(seen))
+		}
+		for name, n := range seen {
+			if n != 1 {
+				t.Errorf("expected %s transformed once, got %d", name, n)
+			}
+		}
+
+		// A completed job does nothing
+		report, err = h.Backfill(ctx, "users", nil, setTier(seen), opts)
+		if err != nil || report.Scanned != 0 {
+			t.Errorf("expected a completed job to do nothing, got %+v, %v", report, err)
+		}
+	})
+
+	t.Run("MaxErrors aborts", func(t *testing.T) {
+		mock := seed(t, 100)
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+		bad := errors.New("bad entity")
+		transform := func(key *datastore.Key, props *datastore.PropertyList) (bool, error) {
+			if key.Name >= "u0050" && key.Name < "u0055" {
+				return false, bad
+			}
+			return true, nil
+		}
+
+		report, err := h.Backfill(ctx, "users", nil, transform, exec.BackfillOptions{PageSize: 20, MaxErrors: 2})
+		if !errors.Is(err, exec.ErrBackfillAborted) || !errors.Is(err, bad) {
+			t.Fatalf("expected ErrBackfillAborted, got %v", err)
+		}
+		if report.Failed != 5 || report.Scanned != 60 || report.Changed != 55 {
+			t.Errorf("expected to stop after the page with the errors, got %+v", report)
+		}
+
+		report, err = h.Backfill(ctx, "users", nil, transform, exec.BackfillOptions{PageSize: 20, MaxErrors: -1})
+		if err != nil || report.Failed != 5 || len(report.Errors) != 5 || report.Changed != 95 {
+			t.Errorf("expected every error reported, got %+v, %v", report, err)
+		}
+	})
+
+	t.Run("Write failures count as failed", func(t *testing.T) {
+		mock := seed(t, 100)
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+		mock.FailNext(testutil.OpPutMulti, errors.New("unavailable"), 1)
+		report, err := h.Backfill(ctx, "users", nil, setTier(map[string]int{}), exec.BackfillOptions{PageSize: 40, MaxErrors: -1})
+		if err != nil || report.Failed != 40 || report.Changed != 60 {
+			t.Errorf("expected the first page to fail, got %+v, %v", report, err)
+		}
+	})
+}
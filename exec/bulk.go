@@ -0,0 +1,164 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"cloud.google.com/go/datastore"
+)
+
+// MaxBatchSize is the largest number of entities Datastore accepts in a single
+// multi operation
+const MaxBatchSize = 500
+
+// BulkOptions configures BulkCreateWithOptions
+type BulkOptions struct {
+	// OnBatch is called after every batch, including failed ones. done is the
+	// number of entities processed so far (counting from the start of the
+	// slice), total is the slice length, keys are the keys written by the
+	// batch and err is the batch error, if any.
+	OnBatch func(done, total int, keys []*datastore.Key, err error)
+
+	// StartAt skips the first StartAt entities, so a failed run can be resumed
+	// from BulkError.Offset
+	StartAt int
+
+	// GenerateID, if set, names every entity with a generated ID (e.g.
+	// NewUUID) instead of letting Datastore allocate numeric IDs. It defaults
+	// to the generator set with WithIDGenerator.
+	GenerateID func() string
+}
+
+// BulkError reports the batch that stopped a bulk operation
+type BulkError struct {
+	// BatchIndex is the index of the failed batch within the run
+	BatchIndex int
+	// Offset is the index of the first entity of the failed batch; pass it as
+	// BulkOptions.StartAt to resume
+	Offset int
+	Err    error
+}
+
+func (e *BulkError) Error() string {
+	return fmt.Sprintf("bulk batch %d (offset %d) failed: %v", e.BatchIndex, e.Offset, e.Err)
+}
+
+func (e *BulkError) Unwrap() error {
+	return e.Err
+}
+
+// putMultiFunc matches datastore.Client.PutMulti
+type putMultiFunc func(ctx context.Context, keys []*datastore.Key, src any) ([]*datastore.Key, error)
+
+// BulkCreateWithOptions creates entities in batches with auto-generated IDs,
+// reporting progress through opts.OnBatch. On failure it returns a *BulkError
+// carrying the offset to resume from; if ctx is cancelled it stops before the
+// next batch with a *PartialError whose Completed is that offset.
+func (h *Exec) BulkCreateWithOptions(ctx context.Context, kind string, entities any, batchSize int, opts BulkOptions) (err error) {
+	ctx, finish := h.startBulk(ctx, "BulkCreate", kind)
+	defer func() {
+		n := 0
+		if v := reflect.ValueOf(entities); v.Kind() == reflect.Slice {
+			n = v.Len() - opts.StartAt
+		}
+		finish(n, err)
+	}()
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	put := h.batchPut(client.PutMulti)
+
+	if opts.GenerateID == nil {
+		opts.GenerateID = h.generateID
+	}
+
+	return bulkCreate(ctx, kind, entities, batchSize, opts, put)
+}
+
+// batchPut wraps put to stamp auto timestamps on each batch, put its keys in
+// the Exec's namespace, wait for the write rate limit and run it as a
+// BulkCreate operation
+func (h *Exec) batchPut(put putMultiFunc) putMultiFunc {
+	return func(ctx context.Context, keys []*datastore.Key, src any) ([]*datastore.Key, error) {
+		if err := h.waitWrites(ctx, len(keys)); err != nil {
+			return nil, err
+		}
+		batch := reflect.ValueOf(src)
+		for i := 0; i < batch.Len(); i++ {
+			if err := h.stampValue(batch.Index(i), true); err != nil {
+				return nil, err
+			}
+		}
+		for _, key := range keys {
+			h.setNamespace(key)
+		}
+
+		stored := keys
+		err := h.run(ctx, putOp("BulkCreate", keysKind(keys), keys...), func(ctx context.Context) error {
+			var err error
+			stored, err = put(ctx, keys, src)
+			return err
+		})
+		if err != nil {
+			return stored, err
+		}
+		return stored, h.recordAudit(ctx, auditBatch{op: AuditCreate, keys: stored, after: auditEntities(src)})
+	}
+}
+
+func bulkCreate(ctx context.Context, kind string, entities any, batchSize int, opts BulkOptions, put putMultiFunc) error {
+	v := reflect.ValueOf(entities)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("entities must be a slice")
+	}
+
+	if batchSize <= 0 || batchSize > MaxBatchSize {
+		batchSize = MaxBatchSize
+	}
+
+	total := v.Len()
+	if opts.StartAt < 0 || opts.StartAt > total {
+		return fmt.Errorf("start offset %d out of range [0, %d]", opts.StartAt, total)
+	}
+
+	batchIndex := 0
+	for i := opts.StartAt; i < total; i += batchSize {
+		if err := ctx.Err(); err != nil {
+			return &PartialError{Completed: i, Err: err}
+		}
+
+		end := i + batchSize
+		if end > total {
+			end = total
+		}
+
+		keys := make([]*datastore.Key, end-i)
+		for j := range keys {
+			if opts.GenerateID == nil {
+				keys[j] = datastore.IncompleteKey(kind, nil) // Auto-generate IDs
+				continue
+			}
+			name := opts.GenerateID()
+			if name == "" {
+				return fmt.Errorf("ID generator returned an empty name at index %d", i+j)
+			}
+			keys[j] = datastore.NameKey(kind, name, nil)
+		}
+
+		written, err := put(ctx, keys, v.Slice(i, end).Interface())
+		if opts.OnBatch != nil {
+			opts.OnBatch(end, total, written, err)
+		}
+		if err != nil {
+			return &BulkError{BatchIndex: batchIndex, Offset: i, Err: err}
+		}
+
+		batchIndex++
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type bulkEntity struct {
+	ID     string `datastore:"-"`
+	Status string `datastore:"status"`
+}
+
+// failingPut returns a putMultiFunc that records every entity it writes and
+// fails on the given call number (1-based) while fail is set
+func failingPut(stored *[]string, failOn int, fail *bool) putMultiFunc {
+	calls := 0
+	return func(ctx context.Context, keys []*datastore.Key, src any) ([]*datastore.Key, error) {
+		calls++
+		if *fail && calls == failOn {
+			return nil, errors.New("unavailable")
+		}
+
+		out := make([]*datastore.Key, len(keys))
+		for i, user := range src.([]bulkEntity) {
+			*stored = append(*stored, user.ID)
+			out[i] = datastore.IDKey(keys[i].Kind, int64(len(*stored)), nil)
+		}
+		return out, nil
+	}
+}
+
+func bulkUsers(n int) []bulkEntity {
+	users := make([]bulkEntity, n)
+	for i := range users {
+		users[i] = bulkEntity{ID: strconv.Itoa(i), Status: "active"}
+	}
+	return users
+}
+
+func TestBulkCreate(t *testing.T) {
+	t.Run("OnBatch reports progress for every batch", func(t *testing.T) {
+		var stored []string
+		fail := false
+		var done []int
+
+		opts := BulkOptions{
+			OnBatch: func(d, total int, keys []*datastore.Key, err error) {
+				if total != 25 {
+					t.Errorf("expected total 25, got %d", total)
+				}
+				if err != nil {
+					t.Errorf("unexpected batch error: %v", err)
+				}
+				done = append(done, d)
+			},
+		}
+
+		err := bulkCreate(context.Background(), "users", bulkUsers(25), 10, opts, failingPut(&stored, 0, &fail))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(done) != 3 || done[0] != 10 || done[1] != 20 || done[2] != 25 {
+			t.Errorf("expected progress [10 20 25], got %v", done)
+		}
+		if len(stored) != 25 {
+			t.Errorf("expected 25 stored entities, got %d", len(stored))
+		}
+	})
+
+	t.Run("Failure reports offset and resume completes the rest", func(t *testing.T) {
+		var stored []string
+		fail := true
+		var failedBatches int
+		users := bulkUsers(50)
+
+		opts := BulkOptions{
+			OnBatch: func(d, total int, keys []*datastore.Key, err error) {
+				if err != nil {
+					failedBatches++
+				}
+			},
+		}
+
+		err := bulkCreate(context.Background(), "users", users, 10, opts, failingPut(&stored, 3, &fail))
+
+		var bulkErr *BulkError
+		if !errors.As(err, &bulkErr) {
+			t.Fatalf("expected *BulkError, got %v", err)
+		}
+		if bulkErr.BatchIndex != 2 {
+			t.Errorf("expected batch index 2, got %d", bulkErr.BatchIndex)
+		}
+		if bulkErr.Offset != 20 {
+			t.Errorf("expected offset 20, got %d", bulkErr.Offset)
+		}
+		if failedBatches != 1 {
+			t.Errorf("expected OnBatch to see 1 failed batch, got %d", failedBatches)
+		}
+		if len(stored) != 20 {
+			t.Fatalf("expected 20 stored entities before failure, got %d", len(stored))
+		}
+
+		fail = false
+		opts.StartAt = bulkErr.Offset
+		if err := bulkCreate(context.Background(), "users", users, 10, opts, failingPut(&stored, 0, &fail)); err != nil {
+			t.Fatalf("unexpected error on resume: %v", err)
+		}
+
+		if len(stored) != len(users) {
+			t.Fatalf("expected %d stored entities after resume, got %d", len(users), len(stored))
+		}
+		for i, id := range stored {
+			if id != users[i].ID {
+				t.Errorf("entity %d: expected %s, got %s", i, users[i].ID, id)
+			}
+		}
+	})
+
+	t.Run("Out of range StartAt", func(t *testing.T) {
+		var stored []string
+		fail := false
+
+		err := bulkCreate(context.Background(), "users", bulkUsers(5), 10, BulkOptions{StartAt: 6}, failingPut(&stored, 0, &fail))
+		if err == nil {
+			t.Error("expected error for out of range StartAt")
+		}
+	})
+}
+
+func TestBulkCreateWithMock(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Batches are at most MaxBatchSize", func(t *testing.T) {
+		client := testutil.NewMockClient()
+		h := NewExecWithOptions(WithClient(client))
+
+		users := make([]testutil.TestUser, 1200)
+		if err := h.BulkCreate(ctx, "users", users, MaxBatchSize); err != nil {
+			t.Fatalf("BulkCreate failed: %v", err)
+		}
+
+		var sizes []int
+		for _, op := range client.OperationsFor(testutil.OpPutMulti) {
+			sizes = append(sizes, op.Count)
+		}
+		if !slices.Equal(sizes, []int{500, 500, 200}) {
+			t.Errorf("expected batches [500 500 200], got %v", sizes)
+		}
+		if n := client.Count("users"); n != 1200 {
+			t.Errorf("expected 1200 users, got %d", n)
+		}
+	})
+
+	t.Run("Dry run writes nothing", func(t *testing.T) {
+		client := testutil.NewMockClient()
+		h := NewExecWithOptions(WithClient(client), WithDryRun())
+
+		if err := h.BulkCreate(ctx, "users", make([]testutil.TestUser, 30), 10); err != nil {
+			t.Fatalf("BulkCreate failed: %v", err)
+		}
+		if err := h.Create(ctx, "users", "a", &testutil.TestUser{Name: "A"}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		_, err := h.Transaction(ctx, func(tx *TxExec) error {
+			return tx.Update(ctx, "users", "a", &testutil.TestUser{Name: "B"})
+		})
+		if err != nil {
+			t.Fatalf("Transaction failed: %v", err)
+		}
+
+		for _, op := range client.Operations() {
+			switch op.Method {
+			case testutil.OpPut, testutil.OpPutMulti, testutil.OpDelete, testutil.OpDeleteMulti:
+				t.Errorf("expected no writes, got %s of %d keys", op.Method, op.Count)
+			case testutil.OpRunInTransaction:
+				for _, inner := range op.Ops {
+					if inner.Method == testutil.OpCommit {
+						t.Errorf("expected the transaction to be rolled back, got a commit of %v", inner.Keys)
+					}
+				}
+			}
+		}
+	})
+}
+
+func TestBulkDeleteWithMock(t *testing.T) {
+	ctx := context.Background()
+	client := testutil.NewMockClient()
+	h := NewExecWithOptions(WithClient(client))
+
+	users := make([]testutil.TestUser, 600)
+	for i := range users {
+		users[i].Status = "inactive"
+	}
+	users[0].Status = "active"
+	if err := h.BulkCreate(ctx, "users", users, MaxBatchSize); err != nil {
+		t.Fatalf("BulkCreate failed: %v", err)
+	}
+	client.ResetOperations()
+
+	n, err := h.BulkDelete(ctx, "users", map[string]any{"status": "inactive"})
+	if err != nil {
+		t.Fatalf("BulkDelete failed: %v", err)
+	}
+	if n != 599 {
+		t.Errorf("expected 599 deleted, got %d", n)
+	}
+	if left := client.Count("users"); left != 1 {
+		t.Errorf("expected 1 user left, got %d", left)
+	}
+
+	var deleted []int
+	for _, op := range client.OperationsFor(testutil.OpDeleteMulti) {
+		deleted = append(deleted, op.Count)
+	}
+	if !slices.Equal(deleted, []int{500, 99}) {
+		t.Errorf("expected deletes of [500 99] keys, got %v", deleted)
+	}
+	var pages []int
+	for _, op := range client.OperationsFor(testutil.OpRun) {
+		pages = append(pages, op.Count)
+	}
+	if !slices.Equal(pages, []int{599, 0}) {
+		t.Errorf("expected a page of 599 keys and an empty one, got %v", pages)
+	}
+}
@@ -0,0 +1,186 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"cloud.google.com/go/datastore"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+// GetByKey retrieves the entity stored under key, keeping its parents and
+// namespace. A missing entity returns an error matching ErrNotFound.
+func (h *Exec) GetByKey(ctx context.Context, key *datastore.Key, dest any) error {
+	if err := h.checkKey(key, true); err != nil {
+		return err
+	}
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = h.run(ctx, op{name: "GetByKey", kind: key.Kind, results: one}.on(key), func(ctx context.Context) error {
+		return client.Get(ctx, key, dest)
+	})
+	if errors.Is(err, datastore.ErrNoSuchEntity) {
+		return fmt.Errorf("%w: %v", ErrNotFound, key)
+	}
+	if err != nil {
+		return err
+	}
+	contextKey.SetID(dest, key)
+	return nil
+}
+
+// GetMultiByKeys retrieves the entities stored under keys into dest, a slice
+// of the same length, in chunks like GetMulti
+func (h *Exec) GetMultiByKeys(ctx context.Context, keys []*datastore.Key, dest any) error {
+	if err := h.checkKeys(keys, true); err != nil {
+		return err
+	}
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := h.getMulti(ctx, op{name: "GetMultiByKeys", kind: keysKind(keys)}, client, keys, dest); err != nil {
+		return err
+	}
+	contextKey.SetIDs(dest, keys)
+	return nil
+}
+
+// PutByKey writes entity under key and returns the stored key, which is
+// complete even when key was incomplete. Auto timestamps are applied as for a
+// create, so the created property is only set when it is zero.
+func (h *Exec) PutByKey(ctx context.Context, key *datastore.Key, entity any) (*datastore.Key, error) {
+	if err := h.checkKey(key, false); err != nil {
+		return nil, err
+	}
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.stampTimestamps(entity, true); err != nil {
+		return nil, err
+	}
+
+	stored := key
+	err = h.run(ctx, putOp("PutByKey", key.Kind, key), func(ctx context.Context) error {
+		var err error
+		stored, err = client.Put(ctx, key, entity)
+		return err
+	})
+	if err != nil {
+		return stored, err
+	}
+	return stored, h.recordAudit(ctx, auditBatch{op: AuditCreate, keys: []*datastore.Key{stored}, after: auditEntity(entity)})
+}
+
+// PutMultiByKeys writes entities under keys and returns the stored keys
+func (h *Exec) PutMultiByKeys(ctx context.Context, keys []*datastore.Key, entities any) ([]*datastore.Key, error) {
+	if err := h.checkKeys(keys, false); err != nil {
+		return nil, err
+	}
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	v := reflect.ValueOf(entities)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("entities must be a slice")
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := h.stampValue(v.Index(i), true); err != nil {
+			return nil, fmt.Errorf("entity at index %d: %w", i, err)
+		}
+	}
+
+	stored := keys
+	err = h.run(ctx, putOp("PutMultiByKeys", keysKind(keys), keys...), func(ctx context.Context) error {
+		var err error
+		stored, err = client.PutMulti(ctx, keys, entities)
+		return err
+	})
+	if err != nil {
+		return stored, err
+	}
+	return stored, h.recordAudit(ctx, auditBatch{op: AuditCreate, keys: stored, after: auditEntities(entities)})
+}
+
+// DeleteByKey deletes the entity stored under key
+func (h *Exec) DeleteByKey(ctx context.Context, key *datastore.Key) error {
+	if err := h.checkKey(key, true); err != nil {
+		return err
+	}
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = h.run(ctx, op{name: "DeleteByKey", kind: key.Kind, write: true}.on(key), func(ctx context.Context) error {
+		return h.delete(ctx, client, key)
+	})
+	if err != nil {
+		return err
+	}
+	return h.recordAudit(ctx, auditBatch{op: AuditDelete, keys: []*datastore.Key{key}})
+}
+
+// DeleteMultiByKeys deletes the entities stored under keys
+func (h *Exec) DeleteMultiByKeys(ctx context.Context, keys []*datastore.Key) error {
+	if err := h.checkKeys(keys, true); err != nil {
+		return err
+	}
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = h.run(ctx, op{name: "DeleteMultiByKeys", kind: keysKind(keys), write: true}.on(keys...), func(ctx context.Context) error {
+		return h.deleteMulti(ctx, client, keys)
+	})
+	if err != nil {
+		return err
+	}
+	return h.recordAudit(ctx, auditBatch{op: AuditDelete, keys: keys})
+}
+
+// checkKey verifies key is usable by this Exec, and complete if it must
+// address a stored entity
+func (h *Exec) checkKey(key *datastore.Key, complete bool) error {
+	if key == nil {
+		return errors.New("key must not be nil")
+	}
+	if complete && key.Incomplete() {
+		return fmt.Errorf("key %v is incomplete", key)
+	}
+	return h.checkNamespace(key)
+}
+
+func (h *Exec) checkKeys(keys []*datastore.Key, complete bool) error {
+	for i, key := range keys {
+		if err := h.checkKey(key, complete); err != nil {
+			return fmt.Errorf("key at index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// keysKind returns the kind of keys for logging
+func keysKind(keys []*datastore.Key) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	return keys[0].Kind
+}
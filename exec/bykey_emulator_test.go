@@ -0,0 +1,83 @@
+package exec_test
+
+import (
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+)
+
+func TestByKey(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	parent := datastore.NameKey("users", "user1", nil)
+	parent.Namespace = "tenant-a"
+
+	t.Run("Parented namespaced keys round trip", func(t *testing.T) {
+		key := datastore.IncompleteKey("posts", parent)
+		key.Namespace = "tenant-a"
+
+		stored, err := h.PutByKey(ctx, key, &stampedUser{Name: "post"})
+		if err != nil {
+			t.Fatalf("PutByKey failed: %v", err)
+		}
+		if stored.Incomplete() || !stored.Parent.Equal(parent) || stored.Namespace != "tenant-a" {
+			t.Fatalf("expected complete key under %v in tenant-a, got %v", parent, stored)
+		}
+
+		decoded, err := exec.DecodeID(exec.EncodeID(stored))
+		if err != nil {
+			t.Fatalf("DecodeID failed: %v", err)
+		}
+
+		var got stampedUser
+		if err := h.GetByKey(ctx, decoded, &got); err != nil {
+			t.Fatalf("GetByKey failed: %v", err)
+		}
+		if got.Name != "post" {
+			t.Errorf("expected name 'post', got '%s'", got.Name)
+		}
+
+		// The same ID without the parent addresses a different entity
+		orphan := datastore.IDKey("posts", stored.ID, nil)
+		orphan.Namespace = "tenant-a"
+		if err := h.GetByKey(ctx, orphan, &got); !errors.Is(err, exec.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+
+		if err := h.DeleteByKey(ctx, stored); err != nil {
+			t.Fatalf("DeleteByKey failed: %v", err)
+		}
+		if err := h.GetByKey(ctx, stored, &got); !errors.Is(err, datastore.ErrNoSuchEntity) {
+			t.Errorf("expected ErrNoSuchEntity after delete, got %v", err)
+		}
+	})
+
+	t.Run("Multi variants keep every key", func(t *testing.T) {
+		keys := []*datastore.Key{
+			datastore.NameKey("posts", "a", parent),
+			datastore.NameKey("posts", "b", parent),
+		}
+		for _, key := range keys {
+			key.Namespace = "tenant-a"
+		}
+
+		if _, err := h.PutMultiByKeys(ctx, keys, []stampedUser{{Name: "a"}, {Name: "b"}}); err != nil {
+			t.Fatalf("PutMultiByKeys failed: %v", err)
+		}
+
+		got := make([]stampedUser, len(keys))
+		if err := h.GetMultiByKeys(ctx, keys, got); err != nil {
+			t.Fatalf("GetMultiByKeys failed: %v", err)
+		}
+		if got[0].Name != "a" || got[1].Name != "b" {
+			t.Errorf("unexpected entities %+v", got)
+		}
+
+		if err := h.DeleteMultiByKeys(ctx, keys); err != nil {
+			t.Fatalf("DeleteMultiByKeys failed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package exec
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+)
+
+func TestCheckKey(t *testing.T) {
+	t.Run("Rejects nil and incomplete keys", func(t *testing.T) {
+		if err := NewExec().checkKey(nil, true); err == nil {
+			t.Error("expected error for nil key")
+		}
+		if err := NewExec().checkKey(datastore.IncompleteKey("users", nil), true); err == nil {
+			t.Error("expected error for incomplete key")
+		}
+	})
+
+	t.Run("Multi keys report the failing index", func(t *testing.T) {
+		keys := []*datastore.Key{datastore.NameKey("users", "a", nil), nil}
+
+		err := NewExec().checkKeys(keys, true)
+		if err == nil || !strings.Contains(err.Error(), "index 1") {
+			t.Errorf("expected error at index 1, got %v", err)
+		}
+	})
+
+	t.Run("Key checks run before the client lookup", func(t *testing.T) {
+		err := NewExec().GetByKey(context.Background(), nil, &struct{}{})
+		if err == nil || !strings.Contains(err.Error(), "nil") {
+			t.Errorf("expected nil key error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,560 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+)
+
+// codecField is a field tagged gostore:"encrypt" or gostore:"compress",
+// whose value is encoded when written and decoded when read
+type codecField struct {
+	// name is the name of its property
+	name string
+	// bytes is set for a []byte field, decoded as []byte rather than string
+	bytes    bool
+	encrypt  bool
+	compress bool
+}
+
+type codecFieldsResult struct {
+	fields []codecField
+	err    error
+}
+
+// codecFieldsByType caches codecFieldsOf by struct type
+var codecFieldsByType sync.Map
+
+var byteSliceType = reflect.TypeOf([]byte(nil))
+
+// codecFieldsOf returns the encrypted and compressed fields of t, a struct or
+// pointer to struct. Other types have none.
+func codecFieldsOf(t reflect.Type) ([]codecField, error) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	if r, ok := codecFieldsByType.Load(t); ok {
+		r := r.(codecFieldsResult)
+		return r.fields, r.err
+	}
+
+	var r codecFieldsResult
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tags := strings.Split(f.Tag.Get("gostore"), ",")
+		field := codecField{encrypt: slices.Contains(tags, "encrypt"), compress: slices.Contains(tags, "compress")}
+		if !f.IsExported() || (!field.encrypt && !field.compress) {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("datastore"), ",")
+		if name == "-" {
+			continue
+		}
+		field.name = name
+		if name == "" {
+			field.name = f.Name
+		}
+		switch {
+		case f.Type.Kind() == reflect.String:
+		case f.Type == byteSliceType:
+			field.bytes = true
+		default:
+			r.err = fmt.Errorf("gostore: encrypted or compressed field %s.%s is a %s, not a string or []byte", t.Name(), f.Name, f.Type)
+			continue
+		}
+		r.fields = append(r.fields, field)
+	}
+
+	codecFieldsByType.Store(t, r)
+	return r.fields, r.err
+}
+
+// findCodecField returns the field of fields with property name
+func findCodecField(fields []codecField, name string) (codecField, bool) {
+	for _, f := range fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return codecField{}, false
+}
+
+// hasCodec reports whether the Exec encrypts or compresses fields
+func (h *Exec) hasCodec() bool {
+	return h.cipher != nil || h.compressor != nil
+}
+
+// kindCodecFields returns the fields declared for kind with EncryptKind,
+// which its history kind shares
+func (h *Exec) kindCodecFields(kind string) []codecField {
+	if fields, ok := h.codecKinds[kind]; ok {
+		return fields
+	}
+	for k, historyKind := range h.history {
+		if historyKind == kind {
+			return h.codecKinds[k]
+		}
+	}
+	return nil
+}
+
+// encodeEntity returns src, to be stored under key, as properties with its
+// fields compressed and encrypted. It returns false if src has none.
+func (h *Exec) encodeEntity(key *datastore.Key, src any) (datastore.PropertyList, bool, error) {
+	var fields []codecField
+	var props datastore.PropertyList
+	switch e := src.(type) {
+	case datastore.PropertyList:
+		fields, props = h.kindCodecFields(key.Kind), e
+	case *datastore.PropertyList:
+		fields, props = h.kindCodecFields(key.Kind), *e
+	default:
+		var err error
+		if fields, err = codecFieldsOf(reflect.TypeOf(src)); err != nil {
+			return nil, false, err
+		}
+		if len(fields) > 0 {
+			if props, err = saveEntity(src); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil, false, nil
+	}
+
+	encoded := make(datastore.PropertyList, len(props))
+	for i, p := range props {
+		encoded[i] = p
+		f, ok := findCodecField(fields, p.Name)
+		if !ok || p.Value == nil {
+			continue
+		}
+
+		var value []byte
+		switch v := p.Value.(type) {
+		case string:
+			value = []byte(v)
+		case []byte:
+			value = v
+		default:
+			return nil, false, fmt.Errorf("property %s of %v is a %T, not a string or []byte", p.Name, key, p.Value)
+		}
+
+		changed := false
+		if f.compress && h.compressor != nil {
+			compressed, ok, err := compress(h.compressor, value)
+			if err != nil {
+				return nil, false, fmt.Errorf("compress property %s of %v: %w", p.Name, key, err)
+			}
+			if ok {
+				value, changed = compressed, true
+			}
+		}
+		if f.encrypt && h.cipher != nil {
+			ciphertext, err := h.cipher.Encrypt(value)
+			if err != nil {
+				return nil, false, fmt.Errorf("encrypt property %s of %v: %w", p.Name, key, err)
+			}
+			value, changed = ciphertext, true
+		}
+		if changed {
+			encoded[i] = datastore.Property{Name: p.Name, Value: value, NoIndex: true}
+		}
+	}
+	return encoded, true, nil
+}
+
+// encodeEntities encodes src, a slice of entities to be stored under keys,
+// like encodeEntity. It returns src itself if none has fields to encode.
+func (h *Exec) encodeEntities(keys []*datastore.Key, src any) (any, error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() != reflect.Slice || v.Len() != len(keys) {
+		// Left to the client to reject
+		return src, nil
+	}
+
+	entities := make([]datastore.PropertyList, v.Len())
+	encoded := false
+	for i := range entities {
+		props, ok, err := h.encodeEntity(keys[i], entityAt(v, i))
+		if err != nil {
+			return nil, err
+		}
+		entities[i], encoded = props, encoded || ok
+	}
+	if !encoded {
+		return src, nil
+	}
+
+	// The client takes a slice of one type
+	for i, props := range entities {
+		if props != nil {
+			continue
+		}
+		var err error
+		if entities[i], err = saveEntity(entityAt(v, i)); err != nil {
+			return nil, err
+		}
+	}
+	return entities, nil
+}
+
+// entityAt returns the entity at i in the slice v, as a pointer if it is a
+// struct
+func entityAt(v reflect.Value, i int) any {
+	e := v.Index(i)
+	if e.Kind() == reflect.Struct {
+		return e.Addr().Interface()
+	}
+	return e.Interface()
+}
+
+// saveEntity returns the properties of src, a struct, pointer to struct or
+// datastore.PropertyLoadSaver
+func saveEntity(src any) (datastore.PropertyList, error) {
+	switch e := src.(type) {
+	case datastore.PropertyList:
+		return e, nil
+	case datastore.PropertyLoadSaver:
+		return e.Save()
+	}
+	v := reflect.ValueOf(src)
+	if v.Kind() == reflect.Struct {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		src = ptr.Interface()
+	}
+	return datastore.SaveStruct(src)
+}
+
+// loadEntity loads props into dst, a pointer to struct or
+// datastore.PropertyLoadSaver
+func loadEntity(dst any, props datastore.PropertyList) error {
+	if pls, ok := dst.(datastore.PropertyLoadSaver); ok {
+		return pls.Load(props)
+	}
+	return datastore.LoadStruct(dst, props)
+}
+
+// decodeProps decrypts and decompresses in place the properties of props
+// that are fields
+func (h *Exec) decodeProps(key *datastore.Key, props datastore.PropertyList, fields []codecField) error {
+	for i, p := range props {
+		f, ok := findCodecField(fields, p.Name)
+		value, isBytes := p.Value.([]byte)
+		if !ok || !isBytes {
+			continue
+		}
+
+		if f.encrypt && h.cipher != nil {
+			plaintext, err := h.cipher.Decrypt(value)
+			if err != nil {
+				return fmt.Errorf("decrypt property %s of %v: %w", p.Name, key, err)
+			}
+			value = plaintext
+		}
+		if f.compress {
+			decompressed, err := h.decompress(value)
+			if err != nil {
+				return fmt.Errorf("decompress property %s of %v: %w", p.Name, key, err)
+			}
+			value = decompressed
+		}
+
+		if f.bytes {
+			props[i].Value = value
+		} else {
+			props[i].Value = string(value)
+		}
+	}
+	return nil
+}
+
+// decodeEntity reads an entity into dst with get, decoding its encrypted
+// and compressed fields. get loads the entity into the value it is given and
+// returns its key.
+func (h *Exec) decodeEntity(dst any, get func(dst any) (*datastore.Key, error)) (*datastore.Key, error) {
+	if props, ok := dst.(*datastore.PropertyList); ok {
+		key, err := get(props)
+		if err != nil || key == nil {
+			return key, err
+		}
+		return key, h.decodeProps(key, *props, h.kindCodecFields(key.Kind))
+	}
+
+	fields, err := codecFieldsOf(reflect.TypeOf(dst))
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return get(dst)
+	}
+
+	var props datastore.PropertyList
+	key, err := get(&props)
+	if err != nil {
+		return key, err
+	}
+	if err := h.decodeProps(key, props, fields); err != nil {
+		return key, err
+	}
+	return key, loadEntity(dst, props)
+}
+
+// decodeEntities reads the entities of keys into dst, a slice, with
+// getMulti, decoding their fields. Failures are returned in a
+// datastore.MultiError, like GetMulti does.
+func (h *Exec) decodeEntities(keys []*datastore.Key, dst any, getMulti func(dst any) error) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Slice || v.Len() != len(keys) {
+		return getMulti(dst)
+	}
+
+	elem := v.Type().Elem()
+	var entities []datastore.PropertyList
+	switch {
+	case elem == reflect.TypeOf(datastore.PropertyList(nil)):
+		entities = dst.([]datastore.PropertyList)
+	case elem.Kind() != reflect.Interface:
+		fields, err := codecFieldsOf(elem)
+		if err != nil {
+			return err
+		}
+		if len(fields) == 0 {
+			return getMulti(dst)
+		}
+		entities = make([]datastore.PropertyList, len(keys))
+	default:
+		entities = make([]datastore.PropertyList, len(keys))
+	}
+
+	err := getMulti(entities)
+	me := make(datastore.MultiError, len(keys))
+	if err != nil && !errors.As(err, &me) {
+		return err
+	}
+
+	failed := false
+	for i, key := range keys {
+		if me[i] == nil {
+			me[i] = h.decodeInto(v.Index(i), key, entities[i])
+		}
+		failed = failed || me[i] != nil
+	}
+	if !failed {
+		return nil
+	}
+	return me
+}
+
+// decodeInto decodes props, the entity of key, into e, an addressable
+// entity. For a datastore.PropertyList e, props is e's own and is decoded
+// in place.
+func (h *Exec) decodeInto(e reflect.Value, key *datastore.Key, props datastore.PropertyList) error {
+	if e.Type() == reflect.TypeOf(datastore.PropertyList(nil)) {
+		return h.decodeProps(key, props, h.kindCodecFields(key.Kind))
+	}
+	if e.Kind() == reflect.Ptr && e.IsNil() {
+		e.Set(reflect.New(e.Type().Elem()))
+	}
+
+	var dst any
+	if e.Kind() == reflect.Struct {
+		dst = e.Addr().Interface()
+	} else {
+		dst = e.Interface()
+	}
+	_, err := h.decodeEntity(dst, func(dst any) (*datastore.Key, error) {
+		if p, ok := dst.(*datastore.PropertyList); ok {
+			*p = props
+			return key, nil
+		}
+		return key, loadEntity(dst, props)
+	})
+	return err
+}
+
+// codecClient encodes and decodes the entities written and read through its
+// Client, see WithCipher and WithCompression
+type codecClient struct {
+	gostore.Client
+	h *Exec
+}
+
+func (c codecClient) Get(ctx context.Context, key *datastore.Key, dst any) error {
+	_, err := c.h.decodeEntity(dst, func(dst any) (*datastore.Key, error) {
+		return key, c.Client.Get(ctx, key, dst)
+	})
+	return err
+}
+
+func (c codecClient) GetMulti(ctx context.Context, keys []*datastore.Key, dst any) error {
+	return c.h.decodeEntities(keys, dst, func(dst any) error {
+		return c.Client.GetMulti(ctx, keys, dst)
+	})
+}
+
+func (c codecClient) Put(ctx context.Context, key *datastore.Key, src any) (*datastore.Key, error) {
+	props, ok, err := c.h.encodeEntity(key, src)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		src = &props
+	}
+	return c.Client.Put(ctx, key, src)
+}
+
+func (c codecClient) PutMulti(ctx context.Context, keys []*datastore.Key, src any) ([]*datastore.Key, error) {
+	src, err := c.h.encodeEntities(keys, src)
+	if err != nil {
+		return nil, err
+	}
+	return c.Client.PutMulti(ctx, keys, src)
+}
+
+func (c codecClient) GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error) {
+	v := reflect.ValueOf(dst)
+	if dst == nil || v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return c.Client.GetAll(ctx, q, dst)
+	}
+
+	if lists, ok := dst.(*[]datastore.PropertyList); ok {
+		start := len(*lists)
+		keys, err := c.Client.GetAll(ctx, q, dst)
+		if err != nil {
+			return keys, err
+		}
+		for i, key := range keys {
+			if err := c.h.decodeProps(key, (*lists)[start+i], c.h.kindCodecFields(key.Kind)); err != nil {
+				return keys, err
+			}
+		}
+		return keys, nil
+	}
+
+	slice := v.Elem()
+	elem := slice.Type().Elem()
+	fields, err := codecFieldsOf(elem)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return c.Client.GetAll(ctx, q, dst)
+	}
+
+	var entities []datastore.PropertyList
+	keys, err := c.Client.GetAll(ctx, q, &entities)
+	if err != nil {
+		return keys, err
+	}
+
+	// Like datastore, load every entity despite mismatched fields and
+	// return the first mismatch
+	var mismatch error
+	for i, key := range keys {
+		e := reflect.New(elem).Elem()
+		if err := c.h.decodeInto(e, key, entities[i]); err != nil {
+			var fm *datastore.ErrFieldMismatch
+			if !errors.As(err, &fm) {
+				return keys, err
+			}
+			if mismatch == nil {
+				mismatch = err
+			}
+		}
+		slice = reflect.Append(slice, e)
+	}
+	v.Elem().Set(slice)
+	return keys, mismatch
+}
+
+func (c codecClient) Run(ctx context.Context, q *datastore.Query) gostore.Iterator {
+	return codecIterator{Iterator: c.Client.Run(ctx, q), h: c.h}
+}
+
+func (c codecClient) RunInTransaction(ctx context.Context, f func(tx gostore.Transaction) error, opts ...datastore.TransactionOption) (*datastore.Commit, error) {
+	return c.Client.RunInTransaction(ctx, func(tx gostore.Transaction) error {
+		return f(codecTx{Transaction: tx, h: c.h})
+	}, opts...)
+}
+
+// codecIterator decodes the entities of an Iterator
+type codecIterator struct {
+	gostore.Iterator
+	h *Exec
+}
+
+func (it codecIterator) Next(dst any) (*datastore.Key, error) {
+	if dst == nil {
+		return it.Iterator.Next(dst)
+	}
+	return it.h.decodeEntity(dst, it.Iterator.Next)
+}
+
+// codecTx encodes and decodes the entities written and read in a
+// Transaction
+type codecTx struct {
+	gostore.Transaction
+	h *Exec
+}
+
+func (t codecTx) Get(key *datastore.Key, dst any) error {
+	_, err := t.h.decodeEntity(dst, func(dst any) (*datastore.Key, error) {
+		return key, t.Transaction.Get(key, dst)
+	})
+	return err
+}
+
+func (t codecTx) GetMulti(keys []*datastore.Key, dst any) error {
+	return t.h.decodeEntities(keys, dst, func(dst any) error {
+		return t.Transaction.GetMulti(keys, dst)
+	})
+}
+
+func (t codecTx) Put(key *datastore.Key, src any) (*datastore.PendingKey, error) {
+	props, ok, err := t.h.encodeEntity(key, src)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		src = &props
+	}
+	return t.Transaction.Put(key, src)
+}
+
+func (t codecTx) PutMulti(keys []*datastore.Key, src any) ([]*datastore.PendingKey, error) {
+	src, err := t.h.encodeEntities(keys, src)
+	if err != nil {
+		return nil, err
+	}
+	return t.Transaction.PutMulti(keys, src)
+}
+
+// withCodec returns client encoding and decoding entities if the Exec has a
+// Cipher or Compressor
+func (h *Exec) withCodec(client gostore.Client) gostore.Client {
+	if !h.hasCodec() {
+		return client
+	}
+	return codecClient{Client: client, h: h}
+}
+
+// datastoreTx returns the *datastore.Transaction behind tx, if any
+func datastoreTx(tx gostore.Transaction) (*datastore.Transaction, bool) {
+	if ct, ok := tx.(codecTx); ok {
+		tx = ct.Transaction
+	}
+	return gostore.DatastoreTransaction(tx)
+}
@@ -0,0 +1,58 @@
+package exec_test
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type tenantMember struct {
+	ID       string `datastore:"-" gostore:"id"`
+	TenantID string `datastore:"tenant_id" gostore:"keypart=1"`
+	Email    string `datastore:"email" gostore:"keypart=2"`
+	Role     string `datastore:"role"`
+}
+
+func TestCompositeKeys(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+	key, err := h.CreateWithKey(ctx, "members", nil, &tenantMember{TenantID: "acme|eu", Email: "bob@example.com", Role: "admin"})
+	if err != nil {
+		t.Fatalf("CreateWithKey failed: %v", err)
+	}
+	if key.Name != `acme\|eu|bob@example.com` {
+		t.Errorf("expected the key named after the key parts, got %v", key)
+	}
+
+	var got tenantMember
+	if err := h.GetByCompositeID(ctx, "members", &got, "acme|eu", "bob@example.com"); err != nil {
+		t.Fatalf("GetByCompositeID failed: %v", err)
+	}
+	if got.Role != "admin" || got.ID != key.Name {
+		t.Errorf("expected the tenantMember with its ID set, got %+v", got)
+	}
+
+	members := []tenantMember{{TenantID: "acme", Email: "a"}, {TenantID: "acme", Email: "b"}}
+	if err := h.CreateMulti(ctx, "members", []any{nil, "explicit"}, members); err != nil {
+		t.Fatalf("CreateMulti failed: %v", err)
+	}
+	for _, name := range []string{"acme|a", "explicit"} {
+		var m tenantMember
+		if err := mock.Get(ctx, datastore.NameKey("members", name, nil), &m); err != nil {
+			t.Errorf("expected a tenantMember named %q: %v", name, err)
+		}
+	}
+
+	// Writing the same key parts again updates the entity
+	if err := h.Create(ctx, "members", nil, &tenantMember{TenantID: "acme|eu", Email: "bob@example.com", Role: "viewer"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if n := mock.Count("members"); n != 3 {
+		t.Errorf("expected 3 members, got %d", n)
+	}
+}
@@ -0,0 +1,136 @@
+package exec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Compressor compresses the fields tagged gostore:"compress" of an Exec with
+// WithCompression
+type Compressor interface {
+	// ID identifies the algorithm in the header of the values it
+	// compresses. IDs below 16 are reserved for gostore.
+	ID() byte
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// GzipID is the ID of Gzip
+const GzipID byte = 1
+
+// ErrUnknownCompressor is returned when reading a value compressed by a
+// Compressor the Exec doesn't have
+var ErrUnknownCompressor = errors.New("unknown compressor")
+
+// compressedHeader starts compressed values, followed by the ID of their
+// Compressor
+var compressedHeader = []byte{0xff, 0xc5}
+
+// WithCompression compresses with c the struct fields tagged
+// gostore:"compress", which must be strings or []byte, before every write of
+// the Exec and of its TxExecs, and decompresses them when entities are read
+// into structs, e.g. to keep large JSON blobs under the entity size limit.
+// A compressed value is stored as an unindexed []byte property starting with
+// a header naming its Compressor; values that would not shrink are stored as
+// they are. Fields tagged both gostore:"compress" and gostore:"encrypt" are
+// compressed before they are encrypted.
+//
+// Values without the header, as stored before compression was enabled, are
+// read as they are and compressed when next written. Values compressed by a
+// Compressor given in an earlier WithCompression, or by Gzip, stay readable.
+// Like with WithCipher, entities handled as datastore.PropertyList only have
+// the fields of kinds declared with EncryptKind compressed.
+func WithCompression(c Compressor) Option {
+	return func(h *Exec) {
+		// Copy the map, which Execs created by With share
+		compressors := make(map[byte]Compressor, len(h.compressors)+1)
+		for id, c := range h.compressors {
+			compressors[id] = c
+		}
+		compressors[c.ID()] = c
+		h.compressor, h.compressors = c, compressors
+	}
+}
+
+// Gzip is a Compressor using gzip at Level, or gzip.DefaultCompression if 0
+type Gzip struct {
+	Level int
+}
+
+// ID returns GzipID
+func (Gzip) ID() byte {
+	return GzipID
+}
+
+// Compress compresses data with gzip
+func (g Gzip) Compress(data []byte) ([]byte, error) {
+	level := g.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses data compressed with gzip
+func (Gzip) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// compress returns value compressed with c behind the header, or false if
+// it would not shrink
+func compress(c Compressor, value []byte) ([]byte, bool, error) {
+	compressed, err := c.Compress(value)
+	if err != nil {
+		return nil, false, err
+	}
+
+	size := len(compressedHeader) + 1 + len(compressed)
+	// A value starting like a compressed one is compressed anyway, not to
+	// be mistaken for one when read
+	if size >= len(value) && !bytes.HasPrefix(value, compressedHeader) {
+		return nil, false, nil
+	}
+
+	out := make([]byte, 0, size)
+	out = append(out, compressedHeader...)
+	out = append(out, c.ID())
+	return append(out, compressed...), true, nil
+}
+
+// decompress returns value decompressed by the Compressor of its header, or
+// value itself if it has none
+func (h *Exec) decompress(value []byte) ([]byte, error) {
+	if len(value) <= len(compressedHeader) || !bytes.HasPrefix(value, compressedHeader) {
+		return value, nil
+	}
+
+	id := value[len(compressedHeader)]
+	c, ok := h.compressors[id]
+	if !ok && id == GzipID {
+		c, ok = Gzip{}, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w %d", ErrUnknownCompressor, id)
+	}
+	return c.Decompress(value[len(compressedHeader)+1:])
+}
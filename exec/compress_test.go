@@ -0,0 +1,171 @@
+package exec_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type document struct {
+	Title string `datastore:"title"`
+	Body  string `datastore:"body,noindex" gostore:"compress"`
+	Raw   []byte `datastore:"raw" gostore:"compress"`
+}
+
+type secretDocument struct {
+	Body string `datastore:"body,noindex" gostore:"compress,encrypt"`
+}
+
+// largeJSON returns a JSON blob of about n bytes
+func largeJSON(n int) string {
+	var b strings.Builder
+	b.WriteString("[")
+	for b.Len() < n {
+		b.WriteString(`{"id":12345,"status":"active","tags":["a","b","c"]},`)
+	}
+	b.WriteString("{}]")
+	return b.String()
+}
+
+// propsSize is the size of the string and []byte values of props
+func propsSize(props datastore.PropertyList) int {
+	n := 0
+	for _, p := range props {
+		switch v := p.Value.(type) {
+		case string:
+			n += len(v)
+		case []byte:
+			n += len(v)
+		}
+	}
+	return n
+}
+
+func storedDocument(t *testing.T, mock *testutil.MockDatastoreClient, id string) datastore.PropertyList {
+	t.Helper()
+	var props datastore.PropertyList
+	if err := mock.Get(context.Background(), datastore.NameKey("documents", id, nil), &props); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	return props
+}
+
+func TestCompression(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Large payloads round trip smaller", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		h := exec.NewExecWithOptions(exec.WithClient(mock), exec.WithCompression(exec.Gzip{}))
+
+		want := document{Title: "report", Body: largeJSON(300 << 10), Raw: []byte(largeJSON(100 << 10))}
+		if err := h.Create(ctx, "documents", "report", &want); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		plain, err := datastore.SaveStruct(&want)
+		if err != nil {
+			t.Fatalf("SaveStruct failed: %v", err)
+		}
+		stored := storedDocument(t, mock, "report")
+		if got, orig := propsSize(stored), propsSize(plain); got*10 > orig {
+			t.Errorf("expected the entity at least 10 times smaller, got %d bytes for %d", got, orig)
+		}
+		if _, ok := propValue(stored, "body").([]byte); !ok {
+			t.Errorf("expected body stored as []byte, got %T", propValue(stored, "body"))
+		}
+
+		var got document
+		if err := h.GetByID(ctx, "documents", "report", &got); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if got.Title != want.Title || got.Body != want.Body || !bytes.Equal(got.Raw, want.Raw) {
+			t.Error("expected the payloads to round trip")
+		}
+
+		var all []*document
+		if err := h.FindAll(ctx, "documents", &all); err != nil || len(all) != 1 || all[0].Body != want.Body {
+			t.Errorf("expected query results decompressed, got %v", err)
+		}
+	})
+
+	t.Run("Small values are stored as they are", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		h := exec.NewExecWithOptions(exec.WithClient(mock), exec.WithCompression(exec.Gzip{}))
+
+		if err := h.Create(ctx, "documents", "small", &document{Body: "hi"}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if body := propValue(storedDocument(t, mock, "small"), "body"); body != "hi" {
+			t.Errorf("expected a short body stored uncompressed, got %v", body)
+		}
+		var got document
+		if err := h.GetByID(ctx, "documents", "small", &got); err != nil || got.Body != "hi" {
+			t.Errorf("expected the short body read back, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("Legacy values pass through", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		legacy := document{Title: "old", Body: largeJSON(10 << 10), Raw: []byte(largeJSON(10 << 10))}
+		if _, err := mock.Put(ctx, datastore.NameKey("documents", "old", nil), &legacy); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+
+		h := exec.NewExecWithOptions(exec.WithClient(mock), exec.WithCompression(exec.Gzip{}))
+		var got document
+		if err := h.GetByID(ctx, "documents", "old", &got); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if got.Body != legacy.Body || !bytes.Equal(got.Raw, legacy.Raw) {
+			t.Error("expected the uncompressed values unchanged")
+		}
+
+		if err := h.Update(ctx, "documents", "old", &got); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if stored := storedDocument(t, mock, "old"); propsSize(stored) >= len(legacy.Body) {
+			t.Error("expected the values compressed once written again")
+		}
+	})
+
+	t.Run("Compression comes before encryption", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		h := exec.NewExecWithOptions(exec.WithClient(mock),
+			exec.WithCompression(exec.Gzip{}), exec.WithCipher(exec.NewAESGCM(1, testKeys(1))))
+
+		want := secretDocument{Body: largeJSON(50 << 10)}
+		if err := h.Create(ctx, "documents", "secret", &want); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		// Ciphertext doesn't compress, so it is small only if compressed first
+		body, ok := propValue(storedDocument(t, mock, "secret"), "body").([]byte)
+		if !ok || len(body)*10 > len(want.Body) {
+			t.Errorf("expected a small ciphertext, got %d bytes", len(body))
+		}
+
+		var got secretDocument
+		if err := h.GetByID(ctx, "documents", "secret", &got); err != nil || got.Body != want.Body {
+			t.Errorf("expected the body to round trip, got %v", err)
+		}
+	})
+
+	t.Run("Unknown compressors fail", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		props := datastore.PropertyList{{Name: "body", Value: []byte{0xff, 0xc5, 99, 1, 2}, NoIndex: true}}
+		if _, err := mock.Put(ctx, datastore.NameKey("documents", "odd", nil), &props); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+
+		h := exec.NewExecWithOptions(exec.WithClient(mock), exec.WithCompression(exec.Gzip{}))
+		var got document
+		if err := h.GetByID(ctx, "documents", "odd", &got); !errors.Is(err, exec.ErrUnknownCompressor) {
+			t.Errorf("expected ErrUnknownCompressor, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/builder"
+	"google.golang.org/api/iterator"
+)
+
+// CopyOptions configures CopyKind
+type CopyOptions struct {
+	// Filters restricts the copy to matching source entities
+	Filters map[string]any
+	// Transform is applied to every entity before it is written; the key is
+	// the destination key
+	Transform func(key *datastore.Key, entity *datastore.PropertyList) error
+	// DropParents writes root keys instead of keeping the source parents
+	DropParents bool
+	// DeleteSource deletes each source page once it is copied, turning the
+	// copy into a move
+	DeleteSource bool
+	// DryRun counts what would be copied without writing anything
+	DryRun bool
+	// PageSize is the number of entities read and written per page, at most
+	// MaxBatchSize
+	PageSize int
+	// Cursor resumes a copy from CopyError.Cursor
+	Cursor string
+	// OnPage is called after every copied page with the running total and the
+	// cursor to resume after that page
+	OnPage func(copied int, cursor string)
+}
+
+// CopyError reports where an interrupted CopyKind can be resumed
+type CopyError struct {
+	// Copied is the number of entities copied before the failure
+	Copied int
+	// Cursor is the progress token to pass as CopyOptions.Cursor
+	Cursor string
+	Err    error
+}
+
+func (e *CopyError) Error() string {
+	return fmt.Sprintf("copy stopped after %d entities: %v", e.Copied, e.Err)
+}
+
+func (e *CopyError) Unwrap() error {
+	return e.Err
+}
+
+// CopyKind copies every entity of srcKind to dstKind, keeping names, IDs,
+// parents and namespaces. Source keys are read keys-only a page at a time and
+// each page is loaded and written in one batch, so memory stays bounded. On
+// failure the returned error is a *CopyError carrying a resume cursor.
+func (h *Exec) CopyKind(ctx context.Context, srcKind, dstKind string, opts CopyOptions) (int, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if srcKind == dstKind {
+		return 0, fmt.Errorf("source and destination kind are both %q", srcKind)
+	}
+	if _, err := datastore.DecodeCursor(opts.Cursor); err != nil {
+		return 0, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	pageSize := opts.PageSize
+	if pageSize <= 0 || pageSize > MaxBatchSize {
+		pageSize = MaxBatchSize
+	}
+
+	copied := 0
+	cursor := opts.Cursor
+	fail := func(err error) (int, error) {
+		return copied, &CopyError{Copied: copied, Cursor: cursor, Err: err}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return fail(err)
+		}
+
+		b := h.newBuilder(srcKind).KeysOnly().Limit(pageSize).Cursor(cursor)
+
+		fb := builder.NewFilter().FromMap(opts.Filters)
+		for _, filter := range fb.Build() {
+			b.Filter(filter.Field, filter.Operator, filter.Value)
+		}
+
+		it := client.Run(ctx, b.Build())
+		var keys []*datastore.Key
+		for {
+			key, err := it.Next(nil)
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return fail(err)
+			}
+			keys = append(keys, key)
+		}
+		if len(keys) == 0 {
+			return copied, nil
+		}
+
+		next, err := it.Cursor()
+		if err != nil {
+			return fail(err)
+		}
+
+		if !opts.DryRun {
+			if err := h.waitWrites(ctx, len(keys)); err != nil {
+				return fail(err)
+			}
+			var dstKeys []*datastore.Key
+			var entities []datastore.PropertyList
+			err := h.run(ctx, op{name: "CopyKind", kind: dstKind, write: true}, func(ctx context.Context) error {
+				var err error
+				dstKeys, entities, err = copyPage(ctx, client, keys, dstKind, opts)
+				return err
+			})
+			if err != nil {
+				return fail(err)
+			}
+			if err := h.recordAudit(ctx, auditBatch{op: AuditCreate, keys: dstKeys, after: auditProps(entities)}); err != nil {
+				return fail(err)
+			}
+			if opts.DeleteSource {
+				if err := h.recordAudit(ctx, auditBatch{op: AuditDelete, keys: keys}); err != nil {
+					return fail(err)
+				}
+			}
+		}
+
+		copied += len(keys)
+		cursor = next.String()
+		if opts.OnPage != nil {
+			opts.OnPage(copied, cursor)
+		}
+
+		if len(keys) < pageSize {
+			return copied, nil
+		}
+	}
+}
+
+// copyPage copies the entities of keys and returns the keys and entities
+// written
+func copyPage(ctx context.Context, client gostore.Client, keys []*datastore.Key, dstKind string, opts CopyOptions) ([]*datastore.Key, []datastore.PropertyList, error) {
+	entities := make([]datastore.PropertyList, len(keys))
+	if err := client.GetMulti(ctx, keys, entities); err != nil {
+		return nil, nil, err
+	}
+
+	dstKeys := make([]*datastore.Key, len(keys))
+	for i, key := range keys {
+		dstKeys[i] = rekind(key, dstKind, opts.DropParents)
+
+		if opts.Transform != nil {
+			if err := opts.Transform(dstKeys[i], &entities[i]); err != nil {
+				return nil, nil, fmt.Errorf("transform %v: %w", key, err)
+			}
+		}
+	}
+
+	if _, err := client.PutMulti(ctx, dstKeys, entities); err != nil {
+		return nil, nil, err
+	}
+
+	if opts.DeleteSource {
+		if err := client.DeleteMulti(ctx, keys); err != nil {
+			return nil, nil, err
+		}
+	}
+	return dstKeys, entities, nil
+}
+
+// rekind returns a copy of key with a different kind
+func rekind(key *datastore.Key, kind string, dropParent bool) *datastore.Key {
+	out := *key
+	out.Kind = kind
+	if dropParent {
+		out.Parent = nil
+	}
+	return &out
+}
@@ -0,0 +1,115 @@
+package exec_test
+
+import (
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+)
+
+type copyItem struct {
+	N      int64  `datastore:"n"`
+	Status string `datastore:"status"`
+	Note   string `datastore:"note"`
+}
+
+func TestCopyKind(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	items := make([]copyItem, 1200)
+	for i := range items {
+		items[i] = copyItem{N: int64(i), Status: "active"}
+		if i%4 == 0 {
+			items[i].Status = "archived"
+		}
+	}
+	if err := h.BulkCreate(ctx, "items", items, 500); err != nil {
+		t.Fatalf("failed to seed items: %v", err)
+	}
+
+	t.Run("Copies every entity", func(t *testing.T) {
+		copied, err := h.CopyKind(ctx, "items", "items_copy", exec.CopyOptions{})
+		if err != nil {
+			t.Fatalf("CopyKind failed: %v", err)
+		}
+		if copied != 1200 {
+			t.Errorf("expected 1200 copied, got %d", copied)
+		}
+
+		n, err := h.Count(ctx, "items_copy", nil)
+		if err != nil {
+			t.Fatalf("Count failed: %v", err)
+		}
+		if n != 1200 {
+			t.Errorf("expected 1200 entities in destination, got %d", n)
+		}
+	})
+
+	t.Run("Filtered copy with transform", func(t *testing.T) {
+		copied, err := h.CopyKind(ctx, "items", "items_archived", exec.CopyOptions{
+			Filters: map[string]any{"status": "archived"},
+			Transform: func(key *datastore.Key, entity *datastore.PropertyList) error {
+				*entity = append(*entity, datastore.Property{Name: "note", Value: "copied"})
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("CopyKind failed: %v", err)
+		}
+		if copied != 300 {
+			t.Errorf("expected 300 copied, got %d", copied)
+		}
+
+		var got []copyItem
+		if err := h.FindAll(ctx, "items_archived", &got); err != nil {
+			t.Fatalf("FindAll failed: %v", err)
+		}
+		for _, item := range got {
+			if item.Status != "archived" || item.Note != "copied" {
+				t.Fatalf("unexpected entity %+v", item)
+			}
+		}
+	})
+
+	t.Run("Dry run writes nothing", func(t *testing.T) {
+		copied, err := h.CopyKind(ctx, "items", "items_dry", exec.CopyOptions{DryRun: true})
+		if err != nil {
+			t.Fatalf("CopyKind failed: %v", err)
+		}
+		if copied != 1200 {
+			t.Errorf("expected 1200 counted, got %d", copied)
+		}
+
+		n, err := h.Count(ctx, "items_dry", nil)
+		if err != nil {
+			t.Fatalf("Count failed: %v", err)
+		}
+		if n != 0 {
+			t.Errorf("expected empty destination, got %d", n)
+		}
+	})
+
+	t.Run("Resume from a page cursor", func(t *testing.T) {
+		var firstPage string
+		_, err := h.CopyKind(ctx, "items", "items_resume", exec.CopyOptions{
+			PageSize: 500,
+			OnPage: func(copied int, cursor string) {
+				if firstPage == "" {
+					firstPage = cursor
+				}
+			},
+		})
+		if err != nil {
+			t.Fatalf("CopyKind failed: %v", err)
+		}
+
+		copied, err := h.CopyKind(ctx, "items", "items_resume", exec.CopyOptions{Cursor: firstPage})
+		if err != nil {
+			t.Fatalf("CopyKind resume failed: %v", err)
+		}
+		if copied != 700 {
+			t.Errorf("expected resume to copy the remaining 700, got %d", copied)
+		}
+	})
+}
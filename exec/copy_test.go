@@ -0,0 +1,35 @@
+package exec
+
+import (
+	"testing"
+
+	"cloud.google.com/go/datastore"
+)
+
+func TestRekind(t *testing.T) {
+	parent := datastore.NameKey("tenants", "acme", nil)
+	key := datastore.IDKey("users", 42, parent)
+	key.Namespace = "prod"
+
+	t.Run("Keeps identity, parent and namespace", func(t *testing.T) {
+		out := rekind(key, "users_v2", false)
+
+		if out.Kind != "users_v2" || out.ID != 42 || out.Namespace != "prod" {
+			t.Errorf("unexpected key %v", out)
+		}
+		if !out.Parent.Equal(parent) {
+			t.Errorf("expected parent %v, got %v", parent, out.Parent)
+		}
+		if key.Kind != "users" {
+			t.Error("source key must not be modified")
+		}
+	})
+
+	t.Run("Drops the parent on request", func(t *testing.T) {
+		out := rekind(key, "users_v2", true)
+
+		if out.Parent != nil {
+			t.Errorf("expected root key, got parent %v", out.Parent)
+		}
+	})
+}
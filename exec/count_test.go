@@ -0,0 +1,91 @@
+package exec_test
+
+import (
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/exec"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+func TestCountUpTo(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	users := make([]rankedUser, 150)
+	for i := range users {
+		users[i] = rankedUser{Name: fmt.Sprint(i), Score: i}
+	}
+	if err := h.BulkCreate(ctx, "users", users, 0); err != nil {
+		t.Fatalf("BulkCreate failed: %v", err)
+	}
+
+	t.Run("Stops at the cap", func(t *testing.T) {
+		count, err := h.CountUpTo(ctx, "users", nil, 100)
+		if err != nil {
+			t.Fatalf("CountUpTo failed: %v", err)
+		}
+		if count != 100 {
+			t.Errorf("expected 100, got %d", count)
+		}
+	})
+
+	t.Run("Cap above the total counts everything", func(t *testing.T) {
+		count, err := h.CountUpTo(ctx, "users", nil, 1000)
+		if err != nil {
+			t.Fatalf("CountUpTo failed: %v", err)
+		}
+		if count != 150 {
+			t.Errorf("expected 150, got %d", count)
+		}
+	})
+
+	t.Run("Filters apply before the cap", func(t *testing.T) {
+		filters := []builder.FilterParam{{Field: "score", Operator: builder.GreaterThanOrEqual, Value: 140}}
+		count, err := h.CountUpTo(ctx, "users", filters, 100)
+		if err != nil {
+			t.Fatalf("CountUpTo failed: %v", err)
+		}
+		if count != 10 {
+			t.Errorf("expected 10, got %d", count)
+		}
+	})
+}
+
+// BenchmarkCount compares the memory of counting by streaming keys with
+// fetching them all via GetAll, on a 100k-entity kind
+func BenchmarkCount(b *testing.B) {
+	ctx := emulatorContext(b)
+	h := exec.NewExec()
+
+	users := make([]rankedUser, 100000)
+	for i := range users {
+		users[i] = rankedUser{Name: fmt.Sprint(i), Score: i}
+	}
+	if err := h.BulkCreate(ctx, "users", users, 0); err != nil {
+		b.Fatalf("BulkCreate failed: %v", err)
+	}
+	client := ctx.Value(contextKey.NOSQL_KEY).(*datastore.Client)
+
+	b.Run("Stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := h.Count(ctx, "users", nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("GetAll", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			keys, err := client.GetAll(ctx, datastore.NewQuery("users").KeysOnly(), nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			_ = len(keys)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+	"google.golang.org/api/iterator"
+)
+
+// CountByField counts entities matching filters grouped by the value of
+// field, using a single streaming projection query tallied client side. The
+// field must be indexed, and combining it with filters on other properties
+// needs a composite index.
+//
+// Values are turned into map keys with formatValue: strings as-is, integers
+// and floats in their shortest decimal form, booleans as "true"/"false",
+// times as RFC 3339 in UTC, keys in their encoded form and null as "null".
+func (h *Exec) CountByField(ctx context.Context, kind, field string, filters map[string]any) (map[string]int64, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b := h.newBuilder(kind).Select(field)
+
+	fb := builder.NewFilter().FromMap(filters)
+	for _, filter := range fb.Build() {
+		b.Filter(filter.Field, filter.Operator, filter.Value)
+	}
+	h.applySoftDelete(b, queryOptions{})
+
+	var counts map[string]int64
+	err = h.run(ctx, op{name: "CountByField", kind: kind, query: b}, func(ctx context.Context) error {
+		// A retry starts the tally over
+		counts = make(map[string]int64)
+		it := client.Run(ctx, b.Build())
+		for {
+			var entity datastore.PropertyList
+			_, err := it.Next(&entity)
+			if err == iterator.Done {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			for _, p := range entity {
+				if p.Name == field {
+					counts[formatValue(p.Value)]++
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("count %s by %s: %w", kind, field, err)
+	}
+
+	return counts, nil
+}
+
+// formatValue renders a property value as a string, see CountByField
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case *datastore.Key:
+		return v.Encode()
+	case datastore.GeoPoint:
+		return fmt.Sprintf("%g,%g", v.Lat, v.Lng)
+	default:
+		return fmt.Sprint(v)
+	}
+}
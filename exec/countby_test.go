@@ -0,0 +1,32 @@
+package exec
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"nil", nil, "null"},
+		{"string", "active", "active"},
+		{"int64", int64(42), "42"},
+		{"float64", 2.5, "2.5"},
+		{"bool", true, "true"},
+		{"time", time.Date(2024, 5, 1, 10, 0, 0, 0, time.FixedZone("WIB", 7*3600)), "2024-05-01T03:00:00Z"},
+		{"geopoint", datastore.GeoPoint{Lat: -6.2, Lng: 106.8}, "-6.2,106.8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatValue(tt.value); got != tt.want {
+				t.Errorf("expected '%s', got '%s'", tt.want, got)
+			}
+		})
+	}
+}
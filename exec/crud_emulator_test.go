@@ -0,0 +1,98 @@
+package exec_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestCRUD(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+	users := testutil.CreateTestUsers()
+
+	t.Run("Create and get", func(t *testing.T) {
+		if err := h.Create(ctx, "users", users[0].ID, &users[0]); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		var got testutil.TestUser
+		if err := h.GetByID(ctx, "users", users[0].ID, &got); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if got.ID != users[0].ID || got.Email != users[0].Email || !got.CreatedAt.Equal(users[0].CreatedAt.Truncate(time.Microsecond)) {
+			t.Errorf("expected %+v, got %+v", users[0], got)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		updated := users[0]
+		updated.Name = "John Updated"
+		if err := h.Update(ctx, "users", updated.ID, &updated); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+
+		var got testutil.TestUser
+		if err := h.GetByID(ctx, "users", updated.ID, &got); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if got.Name != "John Updated" {
+			t.Errorf("expected updated name, got %q", got.Name)
+		}
+	})
+
+	t.Run("Multi", func(t *testing.T) {
+		rest := users[1:]
+		ids := make([]any, len(rest))
+		for i, u := range rest {
+			ids[i] = u.ID
+		}
+		if err := h.CreateMulti(ctx, "users", ids, rest); err != nil {
+			t.Fatalf("CreateMulti failed: %v", err)
+		}
+
+		got := make([]testutil.TestUser, len(ids))
+		if err := h.GetMulti(ctx, "users", ids, got); err != nil {
+			t.Fatalf("GetMulti failed: %v", err)
+		}
+		for i, u := range got {
+			if u.ID != rest[i].ID || u.Email != rest[i].Email {
+				t.Errorf("index %d: expected %s, got %+v", i, rest[i].ID, u)
+			}
+		}
+
+		if err := h.DeleteMulti(ctx, "users", ids); err != nil {
+			t.Fatalf("DeleteMulti failed: %v", err)
+		}
+		err := h.GetMulti(ctx, "users", ids, got)
+		var merr datastore.MultiError
+		if !errors.As(err, &merr) || !errors.Is(merr[0], datastore.ErrNoSuchEntity) {
+			t.Errorf("expected ErrNoSuchEntity for every user, got %v", err)
+		}
+	})
+
+	t.Run("Exists and delete", func(t *testing.T) {
+		ok, err := h.Exists(ctx, "users", users[0].ID)
+		if err != nil || !ok {
+			t.Fatalf("expected %s to exist, got %v, %v", users[0].ID, ok, err)
+		}
+
+		if err := h.Delete(ctx, "users", users[0].ID); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+
+		ok, err = h.Exists(ctx, "users", users[0].ID)
+		if err != nil || ok {
+			t.Errorf("expected %s to be gone, got %v, %v", users[0].ID, ok, err)
+		}
+
+		var got testutil.TestUser
+		if err := h.GetByID(ctx, "users", users[0].ID, &got); !errors.Is(err, datastore.ErrNoSuchEntity) {
+			t.Errorf("expected ErrNoSuchEntity, got %v", err)
+		}
+	})
+}
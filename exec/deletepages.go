@@ -0,0 +1,194 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/builder"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/api/iterator"
+)
+
+// DefaultDeletePageSize is the number of keys BulkDelete fetches at a time
+const DefaultDeletePageSize = 5000
+
+// deletePages deletes the entities matched by b, a keys-only query, one page
+// of opts.PageSize keys at a time (see BulkDeleteOptions.PageSize). Pages are
+// read with cursors and deleted by opts.Workers workers while the next ones
+// are fetched. Because deleting entities can move a cursor past others on
+// some backends, a pass that reaches the end is followed by another from the
+// start, once the pages in flight are deleted, until one finds nothing.
+func (h *Exec) deletePages(ctx context.Context, o op, client gostore.Client, b *builder.Builder, opts BulkDeleteOptions) (int, error) {
+	b = b.Clone().KeysOnly().Limit(opts.PageSize)
+	workers := max(opts.Workers, 1)
+
+	// mu guards the counts and serializes the callbacks
+	var mu sync.Mutex
+	fetched, deleted, pages := 0, 0, 0
+
+	fetch := func(ctx context.Context, cursor string) ([]*datastore.Key, string, error) {
+		return h.keysPage(ctx, o, client, b, cursor)
+	}
+
+	w := o
+	w.write = true
+	deletePage := func(ctx context.Context, keys []*datastore.Key) error {
+		_, err := inBatches(ctx, len(keys), MaxBatchSize, h.paced(ctx, func(start, end int) error {
+			batch := keys[start:end]
+			if opts.BeforeBatch != nil {
+				mu.Lock()
+				err := opts.BeforeBatch(batch)
+				mu.Unlock()
+				if err != nil {
+					return err
+				}
+			}
+
+			err := h.run(ctx, w.on(batch...), func(ctx context.Context) error {
+				return client.DeleteMulti(ctx, batch)
+			})
+			if err == nil {
+				err = h.recordAudit(ctx, auditBatch{op: AuditDelete, keys: batch})
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				deleted += len(batch)
+			}
+			if opts.OnBatch != nil {
+				opts.OnBatch(deleted, fetched, batch, err)
+			}
+			return err
+		}))
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		pages++
+		if opts.OnPage != nil {
+			opts.OnPage(pages, deleted)
+		}
+		return nil
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	queue := make(chan []*datastore.Key)
+	var inFlight sync.WaitGroup
+	if workers > 1 {
+		for range workers {
+			g.Go(func() error {
+				for keys := range queue {
+					err := deletePage(gctx, keys)
+					inFlight.Done()
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}
+	}
+
+	produce := func() error {
+		cursor := ""
+		for {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			keys, next, err := fetch(gctx, cursor)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			fetched += len(keys)
+			mu.Unlock()
+
+			if len(keys) > 0 {
+				if workers == 1 {
+					if err := deletePage(gctx, keys); err != nil {
+						return err
+					}
+				} else {
+					inFlight.Add(1)
+					select {
+					case queue <- keys:
+					case <-gctx.Done():
+						inFlight.Done()
+						return gctx.Err()
+					}
+				}
+			}
+
+			if len(keys) == opts.PageSize {
+				cursor = next
+				continue
+			}
+			// The end of a pass: done if it started at the beginning and
+			// found nothing, otherwise start over once in-flight pages are
+			// deleted
+			if cursor == "" && len(keys) == 0 {
+				return nil
+			}
+			inFlight.Wait()
+			cursor = ""
+		}
+	}
+
+	err := produce()
+	close(queue)
+	if gerr := g.Wait(); gerr != nil {
+		err = gerr
+	}
+
+	mu.Lock()
+	n := deleted
+	mu.Unlock()
+	var partial *PartialError
+	switch {
+	case err == nil:
+		return n, nil
+	case ctx.Err() != nil:
+		return n, &PartialError{Completed: n, Err: ctx.Err()}
+	case errors.As(err, &partial):
+		return n, &PartialError{Completed: n, Err: partial.Err}
+	}
+	return n, err
+}
+
+// keysPage runs b, a keys-only query, from cursor as part of o and returns
+// the keys and the cursor after them
+func (h *Exec) keysPage(ctx context.Context, o op, client gostore.Client, b *builder.Builder, cursor string) ([]*datastore.Key, string, error) {
+	page := b.Clone().Cursor(cursor)
+	var keys []*datastore.Key
+	var next string
+
+	o.query = page
+	o.results = func() int { return len(keys) }
+	err := h.run(ctx, o, func(ctx context.Context) error {
+		keys = nil
+		it := client.Run(ctx, page.Build())
+		for {
+			key, err := it.Next(nil)
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			keys = append(keys, key)
+		}
+		c, err := it.Cursor()
+		if err != nil {
+			return err
+		}
+		next = c.String()
+		return nil
+	})
+	return keys, next, err
+}
@@ -0,0 +1,33 @@
+package exec_test
+
+import (
+	"testing"
+
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestBulkDeletePagesEmulator(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	if err := h.BulkCreate(ctx, "sessions", make([]testutil.TestUser, 10000), 0); err != nil {
+		t.Fatalf("BulkCreate failed: %v", err)
+	}
+
+	pages := 0
+	n, err := h.BulkDeleteWithOptions(ctx, "sessions", nil, exec.BulkDeleteOptions{
+		PageSize: 300,
+		Workers:  4,
+		OnPage:   func(p, deleted int) { pages = p },
+	})
+	if err != nil || n != 10000 {
+		t.Fatalf("expected 10000 deleted, got %d, %v", n, err)
+	}
+	if pages < 34 {
+		t.Errorf("expected at least 34 pages, got %d", pages)
+	}
+	if left, err := h.Count(ctx, "sessions", nil); err != nil || left != 0 {
+		t.Errorf("expected no sessions left, got %d, %v", left, err)
+	}
+}
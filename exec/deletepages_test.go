@@ -0,0 +1,157 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestBulkDeletePages(t *testing.T) {
+	ctx := context.Background()
+
+	seed := func(t *testing.T, n int) *testutil.MockDatastoreClient {
+		t.Helper()
+		mock := testutil.NewMockClient()
+		keys := make([]*datastore.Key, n)
+		for i := range keys {
+			keys[i] = datastore.NameKey("sessions", fmt.Sprintf("s%05d", i), nil)
+		}
+		if _, err := testutil.PutInBatches(ctx, mock, keys, make([]testutil.TestUser, n)); err != nil {
+			t.Fatalf("PutInBatches failed: %v", err)
+		}
+		mock.ResetOperations()
+		return mock
+	}
+
+	t.Run("Keeps at most a page of keys", func(t *testing.T) {
+		mock := seed(t, 1050)
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+		n, err := h.BulkDeleteWithOptions(ctx, "sessions", nil, exec.BulkDeleteOptions{PageSize: 100})
+		if err != nil || n != 1050 {
+			t.Fatalf("expected 1050 deleted, got %d, %v", n, err)
+		}
+		if left := mock.Count("sessions"); left != 0 {
+			t.Errorf("expected no sessions left, got %d", left)
+		}
+
+		// Every page is fetched only once the one before is deleted
+		held := 0
+		for _, op := range mock.Operations() {
+			switch op.Method {
+			case testutil.OpRun:
+				if held != 0 {
+					t.Fatalf("a page was fetched with %d keys of the last still undeleted", held)
+				}
+				if op.Count > 100 {
+					t.Fatalf("expected pages of at most 100 keys, got %d", op.Count)
+				}
+				held = op.Count
+			case testutil.OpDeleteMulti:
+				held -= op.Count
+			}
+		}
+	})
+
+	t.Run("Workers", func(t *testing.T) {
+		mock := seed(t, 2345)
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+		pages := 0
+		n, err := h.BulkDeleteWithOptions(ctx, "sessions", nil, exec.BulkDeleteOptions{
+			PageSize: 200,
+			Workers:  4,
+			OnPage: func(p, deleted int) {
+				pages++
+				if p != pages {
+					t.Errorf("expected page %d, got %d", pages, p)
+				}
+			},
+		})
+		if err != nil || n != 2345 {
+			t.Fatalf("expected 2345 deleted, got %d, %v", n, err)
+		}
+		if left := mock.Count("sessions"); left != 0 {
+			t.Errorf("expected no sessions left, got %d", left)
+		}
+		deleted := 0
+		for _, op := range mock.OperationsFor(testutil.OpDeleteMulti) {
+			deleted += op.Count
+		}
+		if deleted != 2345 {
+			t.Errorf("expected every key deleted once, got %d deletes", deleted)
+		}
+	})
+
+	t.Run("Progress", func(t *testing.T) {
+		mock := seed(t, 1200)
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+		var progress [][2]int
+		_, err := h.BulkDeleteWithOptions(ctx, "sessions", nil, exec.BulkDeleteOptions{
+			PageSize: 700,
+			OnPage: func(pages, deleted int) {
+				progress = append(progress, [2]int{pages, deleted})
+			},
+		})
+		if err != nil {
+			t.Fatalf("BulkDeleteWithOptions failed: %v", err)
+		}
+		if len(progress) != 2 || progress[0] != [2]int{1, 700} || progress[1] != [2]int{2, 1200} {
+			t.Errorf("expected progress [[1 700] [2 1200]], got %v", progress)
+		}
+	})
+
+	t.Run("Cancellation", func(t *testing.T) {
+		for _, workers := range []int{1, 3} {
+			t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+				mock := seed(t, 1000)
+				h := exec.NewExecWithOptions(exec.WithClient(mock))
+				ctx, cancel := context.WithCancel(ctx)
+				defer cancel()
+
+				n, err := h.BulkDeleteWithOptions(ctx, "sessions", nil, exec.BulkDeleteOptions{
+					PageSize: 100,
+					Workers:  workers,
+					OnPage: func(pages, deleted int) {
+						if pages == 2 {
+							cancel()
+						}
+					},
+				})
+
+				var partial *exec.PartialError
+				if !errors.As(err, &partial) || !errors.Is(err, context.Canceled) {
+					t.Fatalf("expected a cancelled *PartialError, got %v", err)
+				}
+				left := mock.Count("sessions")
+				if partial.Completed != n || n+left != 1000 {
+					t.Errorf("expected the count to match what was deleted, got %d with %d left", n, left)
+				}
+				if n < 200 || n > 100*(workers+1) {
+					t.Errorf("expected the run to stop soon after 2 pages, got %d deleted", n)
+				}
+			})
+		}
+	})
+
+	t.Run("Delete errors", func(t *testing.T) {
+		mock := seed(t, 500)
+		errBoom := errors.New("boom")
+		mock.FailEveryN(testutil.OpDeleteMulti, 3, errBoom)
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+		n, err := h.BulkDeleteWithOptions(ctx, "sessions", nil, exec.BulkDeleteOptions{PageSize: 100, Workers: 2})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("expected the delete error, got %v", err)
+		}
+		if n+mock.Count("sessions") != 500 {
+			t.Errorf("expected the count to match what was deleted, got %d with %d left", n, mock.Count("sessions"))
+		}
+	})
+}
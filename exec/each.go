@@ -0,0 +1,97 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+	"google.golang.org/api/iterator"
+)
+
+// defaultPageSize is used by paging helpers when no page size is given
+const defaultPageSize = 100
+
+// cursorIterator is the part of datastore.Iterator used by cursor paging
+type cursorIterator interface {
+	Next(dst any) (*datastore.Key, error)
+	Cursor() (datastore.Cursor, error)
+}
+
+// FindEach calls fn for every entity matching filters, fetching pageSize
+// entities per query and starting after startCursor when it is not empty.
+//
+// The returned cursor points after the last entity fn accepted, also when an
+// error is returned, so a job can persist it and resume with it later. If fn
+// returns ErrStop the iteration ends cleanly; the entity it was called with
+// counts as processed.
+func (h *Exec) FindEach(ctx context.Context, kind string, filters map[string]any, startCursor string, pageSize int, fn func(key *datastore.Key, entity datastore.PropertyList) error) (string, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return startCursor, err
+	}
+
+	if _, err := datastore.DecodeCursor(startCursor); err != nil {
+		return startCursor, fmt.Errorf("invalid start cursor: %w", err)
+	}
+
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	run := func(cursor string) cursorIterator {
+		b := h.newBuilder(kind).Limit(pageSize).Cursor(cursor)
+
+		fb := builder.NewFilter().FromMap(filters)
+		for _, filter := range fb.Build() {
+			b.Filter(filter.Field, filter.Operator, filter.Value)
+		}
+		h.applySoftDelete(b, queryOptions{})
+
+		return client.Run(ctx, b.Build())
+	}
+
+	return eachPage(ctx, startCursor, pageSize, run, fn)
+}
+
+func eachPage(ctx context.Context, cursor string, pageSize int, run func(cursor string) cursorIterator, fn func(key *datastore.Key, entity datastore.PropertyList) error) (string, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return cursor, err
+		}
+
+		it := run(cursor)
+		count := 0
+		for {
+			var entity datastore.PropertyList
+			key, err := it.Next(&entity)
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return cursor, err
+			}
+			count++
+
+			fnErr := fn(key, entity)
+			if fnErr != nil && !errors.Is(fnErr, ErrStop) {
+				return cursor, fnErr
+			}
+
+			next, err := it.Cursor()
+			if err != nil {
+				return cursor, err
+			}
+			cursor = next.String()
+
+			if fnErr != nil {
+				return cursor, nil
+			}
+		}
+
+		if count < pageSize {
+			return cursor, nil
+		}
+	}
+}
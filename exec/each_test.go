@@ -0,0 +1,131 @@
+package exec
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"google.golang.org/api/iterator"
+)
+
+// offsetIterator serves entities [pos, end) of a numbered kind, using the
+// absolute offset as its cursor
+type offsetIterator struct {
+	pos, end int
+}
+
+func (it *offsetIterator) Next(dst any) (*datastore.Key, error) {
+	if it.pos >= it.end {
+		return nil, iterator.Done
+	}
+	it.pos++
+	*dst.(*datastore.PropertyList) = datastore.PropertyList{{Name: "n", Value: int64(it.pos)}}
+	return datastore.IDKey("items", int64(it.pos), nil), nil
+}
+
+func (it *offsetIterator) Cursor() (datastore.Cursor, error) {
+	return datastore.DecodeCursor(base64.URLEncoding.EncodeToString([]byte(strconv.Itoa(it.pos))))
+}
+
+// offsetRun pages through total entities pageSize at a time
+func offsetRun(t *testing.T, total, pageSize int, queries *int) func(cursor string) cursorIterator {
+	return func(cursor string) cursorIterator {
+		*queries++
+		start := 0
+		if cursor != "" {
+			raw, err := base64.RawURLEncoding.DecodeString(cursor)
+			if err != nil {
+				t.Fatalf("bad cursor %q: %v", cursor, err)
+			}
+			start, _ = strconv.Atoi(string(raw))
+		}
+
+		end := start + pageSize
+		if end > total {
+			end = total
+		}
+		return &offsetIterator{pos: start, end: end}
+	}
+}
+
+func TestEachPage(t *testing.T) {
+	t.Run("Visits every entity", func(t *testing.T) {
+		queries := 0
+		var seen []int64
+
+		_, err := eachPage(context.Background(), "", 10, offsetRun(t, 25, 10, &queries), func(key *datastore.Key, entity datastore.PropertyList) error {
+			seen = append(seen, key.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(seen) != 25 {
+			t.Errorf("expected 25 entities, got %d", len(seen))
+		}
+		if queries != 3 {
+			t.Errorf("expected 3 page queries, got %d", queries)
+		}
+	})
+
+	t.Run("ErrStop ends cleanly after the current entity", func(t *testing.T) {
+		queries := 0
+		count := 0
+
+		cursor, err := eachPage(context.Background(), "", 10, offsetRun(t, 25, 10, &queries), func(key *datastore.Key, entity datastore.PropertyList) error {
+			count++
+			if key.ID == 12 {
+				return ErrStop
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error on ErrStop, got %v", err)
+		}
+
+		if count != 12 {
+			t.Errorf("expected 12 visited entities, got %d", count)
+		}
+		if raw, _ := base64.RawURLEncoding.DecodeString(cursor); string(raw) != "12" {
+			t.Errorf("expected cursor after entity 12, got %q", raw)
+		}
+	})
+
+	t.Run("Resume from the returned cursor covers the rest exactly once", func(t *testing.T) {
+		queries := 0
+		seen := make(map[int64]int)
+		failure := errors.New("job crashed")
+
+		cursor, err := eachPage(context.Background(), "", 10, offsetRun(t, 25, 10, &queries), func(key *datastore.Key, entity datastore.PropertyList) error {
+			if key.ID == 17 {
+				return failure
+			}
+			seen[key.ID]++
+			return nil
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("expected callback error, got %v", err)
+		}
+
+		_, err = eachPage(context.Background(), cursor, 10, offsetRun(t, 25, 10, &queries), func(key *datastore.Key, entity datastore.PropertyList) error {
+			seen[key.ID]++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error on resume: %v", err)
+		}
+
+		if len(seen) != 25 {
+			t.Errorf("expected 25 distinct entities, got %d", len(seen))
+		}
+		for id, n := range seen {
+			if n != 1 {
+				t.Errorf("entity %d visited %d times", id, n)
+			}
+		}
+	})
+}
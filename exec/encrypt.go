@@ -0,0 +1,95 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/AndroX7/gostore/builder"
+)
+
+// Cipher encrypts the fields tagged gostore:"encrypt" of an Exec with
+// WithCipher. Encrypt should return a different ciphertext every time, and
+// Decrypt must accept every ciphertext Encrypt returned, including under keys
+// since rotated, as AESGCM does.
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// ErrEncryptedQuery is returned by a query filtering or ordering on an
+// encrypted property, whose ciphertext Datastore doesn't index
+var ErrEncryptedQuery = errors.New("cannot query on an encrypted property")
+
+// WithCipher encrypts with c the struct fields tagged gostore:"encrypt",
+// which must be strings or []byte, before every write of the Exec and of its
+// TxExecs, and decrypts them when entities are read into structs. The
+// ciphertext is stored as an unindexed []byte property under the field's
+// property name.
+//
+// Entities handled as datastore.PropertyList, as by Patch, Backfill and
+// History, carry no tags: only the kinds declared with EncryptKind have
+// their properties encrypted there, and queries checked for filters on
+// encrypted properties. Strings stored before encryption was enabled are
+// read as they are and encrypted when next written. Audit hooks see
+// plaintext.
+func WithCipher(c Cipher) Option {
+	return func(h *Exec) {
+		h.cipher = c
+	}
+}
+
+// EncryptKind declares prototype, a struct or pointer to struct, as the type
+// of the entities of kind on an Exec with WithCipher, so that its encrypted
+// fields are also encrypted when entities of kind are handled as
+// datastore.PropertyList, and queries on kind filtering or ordering on them
+// fail with ErrEncryptedQuery. Its fields tagged gostore:"compress" are
+// declared alike for WithCompression. It panics if prototype is not a struct
+// or has an encrypted or compressed field of another type than string or
+// []byte.
+func EncryptKind(kind string, prototype any) Option {
+	t := reflect.TypeOf(prototype)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("gostore: EncryptKind(%q) needs a struct, not %T", kind, prototype))
+	}
+	fields, err := codecFieldsOf(t)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(h *Exec) {
+		// Copy the map, which Execs created by With share
+		kinds := make(map[string][]codecField, len(h.codecKinds)+1)
+		for k, v := range h.codecKinds {
+			kinds[k] = v
+		}
+		kinds[kind] = fields
+		h.codecKinds = kinds
+	}
+}
+
+// checkEncryptedQuery returns an error matching ErrEncryptedQuery if b
+// filters or orders on an encrypted property of its kind
+func (h *Exec) checkEncryptedQuery(b *builder.Builder) error {
+	if b == nil || h.cipher == nil {
+		return nil
+	}
+	fields := h.kindCodecFields(b.GetKind())
+	if len(fields) == 0 {
+		return nil
+	}
+	for _, f := range b.GetFilters() {
+		if field, ok := findCodecField(fields, f.Field); ok && field.encrypt {
+			return fmt.Errorf("%w: %s.%s", ErrEncryptedQuery, b.GetKind(), f.Field)
+		}
+	}
+	for _, o := range b.GetOrders() {
+		if field, ok := findCodecField(fields, o.Field); ok && field.encrypt {
+			return fmt.Errorf("%w: %s.%s", ErrEncryptedQuery, b.GetKind(), o.Field)
+		}
+	}
+	return nil
+}
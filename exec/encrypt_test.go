@@ -0,0 +1,215 @@
+package exec_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type secretUser struct {
+	Name  string `datastore:"name"`
+	SSN   string `datastore:"ssn" gostore:"encrypt"`
+	Token []byte `datastore:"token" gostore:"encrypt"`
+}
+
+// testKeys returns a KeyProvider with a distinct AES-256 key per version
+func testKeys(versions ...byte) exec.KeyProvider {
+	return func(version byte) ([]byte, error) {
+		for _, v := range versions {
+			if v == version {
+				return bytes.Repeat([]byte{version}, 32), nil
+			}
+		}
+		return nil, fmt.Errorf("no key %d", version)
+	}
+}
+
+// storedProps reads the entity of users stored under id, as written
+func storedProps(t *testing.T, mock *testutil.MockDatastoreClient, id string) datastore.PropertyList {
+	t.Helper()
+	var props datastore.PropertyList
+	if err := mock.Get(context.Background(), datastore.NameKey("users", id, nil), &props); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	return props
+}
+
+func TestEncryption(t *testing.T) {
+	ctx := context.Background()
+
+	newExec := func(mock *testutil.MockDatastoreClient, c exec.Cipher) *exec.Exec {
+		return exec.NewExecWithOptions(exec.WithClient(mock), exec.WithCipher(c), exec.EncryptKind("users", secretUser{}))
+	}
+
+	t.Run("Round trip preserves plaintext", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		h := newExec(mock, exec.NewAESGCM(1, testKeys(1)))
+
+		want := secretUser{Name: "John", SSN: "123-45-6789", Token: []byte("secret")}
+		if err := h.Create(ctx, "users", "john", &want); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if err := h.CreateMulti(ctx, "users", []any{"jane"}, []secretUser{{Name: "Jane", SSN: "987"}}); err != nil {
+			t.Fatalf("CreateMulti failed: %v", err)
+		}
+
+		stored := storedProps(t, mock, "john")
+		if v, ok := propValue(stored, "ssn").([]byte); !ok || bytes.Contains(v, []byte(want.SSN)) {
+			t.Errorf("expected ssn stored encrypted, got %v", propValue(stored, "ssn"))
+		}
+		for _, p := range stored {
+			if p.Name == "ssn" && !p.NoIndex {
+				t.Error("expected ssn unindexed")
+			}
+		}
+		if name := propValue(stored, "name"); name != "John" {
+			t.Errorf("expected name stored in clear, got %v", name)
+		}
+
+		var got secretUser
+		if err := h.GetByID(ctx, "users", "john", &got); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if got.SSN != want.SSN || string(got.Token) != "secret" || got.Name != "John" {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+
+		multi := make([]*secretUser, 2)
+		if err := h.GetMulti(ctx, "users", []any{"jane", "john"}, multi); err != nil {
+			t.Fatalf("GetMulti failed: %v", err)
+		}
+		if multi[0].SSN != "987" || multi[1].SSN != want.SSN {
+			t.Errorf("expected decrypted entities, got %+v, %+v", multi[0], multi[1])
+		}
+
+		var all []secretUser
+		if err := h.FindAll(ctx, "users", &all); err != nil {
+			t.Fatalf("FindAll failed: %v", err)
+		}
+		if len(all) != 2 || all[0].SSN != "987" || all[1].SSN != want.SSN {
+			t.Errorf("expected decrypted query results, got %+v", all)
+		}
+
+		_, err := h.Transaction(ctx, func(tx *exec.TxExec) error {
+			var u secretUser
+			if err := tx.GetByID(ctx, "users", "john", &u); err != nil {
+				return err
+			}
+			u.SSN = "111"
+			return tx.Update(ctx, "users", "john", &u)
+		})
+		if err != nil {
+			t.Fatalf("Transaction failed: %v", err)
+		}
+		if err := h.GetByID(ctx, "users", "john", &got); err != nil || got.SSN != "111" {
+			t.Errorf("expected the transactional update decrypted, got %+v, %v", got, err)
+		}
+		if _, ok := propValue(storedProps(t, mock, "john"), "ssn").([]byte); !ok {
+			t.Error("expected the transactional update stored encrypted")
+		}
+	})
+
+	t.Run("Ciphertext differs per write", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		h := newExec(mock, exec.NewAESGCM(1, testKeys(1)))
+
+		user := secretUser{Name: "John", SSN: "123"}
+		if err := h.Create(ctx, "users", "john", &user); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		first := propValue(storedProps(t, mock, "john"), "ssn").([]byte)
+		if err := h.Update(ctx, "users", "john", &user); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		second := propValue(storedProps(t, mock, "john"), "ssn").([]byte)
+		if bytes.Equal(first, second) {
+			t.Error("expected a fresh nonce for every write")
+		}
+	})
+
+	t.Run("Rotation decrypts old versions", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		if err := newExec(mock, exec.NewAESGCM(1, testKeys(1))).Create(ctx, "users", "john", &secretUser{SSN: "old"}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		h := newExec(mock, exec.NewAESGCM(2, testKeys(1, 2)))
+		if err := h.Create(ctx, "users", "jane", &secretUser{SSN: "new"}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if v := propValue(storedProps(t, mock, "jane"), "ssn").([]byte); v[0] != 2 {
+			t.Errorf("expected the new write prefixed with version 2, got %d", v[0])
+		}
+
+		var old, current secretUser
+		if err := h.GetByID(ctx, "users", "john", &old); err != nil || old.SSN != "old" {
+			t.Errorf("expected the version 1 entity decrypted, got %+v, %v", old, err)
+		}
+		if err := h.GetByID(ctx, "users", "jane", &current); err != nil || current.SSN != "new" {
+			t.Errorf("expected the version 2 entity decrypted, got %+v, %v", current, err)
+		}
+
+		retired := newExec(mock, exec.NewAESGCM(2, testKeys(2)))
+		if err := retired.GetByID(ctx, "users", "john", &old); err == nil {
+			t.Error("expected an error without the key of version 1")
+		}
+	})
+
+	t.Run("Patch encrypts declared kinds", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		h := newExec(mock, exec.NewAESGCM(1, testKeys(1)))
+		if err := h.Create(ctx, "users", "john", &secretUser{Name: "John", SSN: "123"}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		if err := h.Patch(ctx, "users", "john", map[string]any{"ssn": "456"}); err != nil {
+			t.Fatalf("Patch failed: %v", err)
+		}
+		if _, ok := propValue(storedProps(t, mock, "john"), "ssn").([]byte); !ok {
+			t.Error("expected the patched ssn stored encrypted")
+		}
+		var got secretUser
+		if err := h.GetByID(ctx, "users", "john", &got); err != nil || got.SSN != "456" || got.Name != "John" {
+			t.Errorf("expected the patched entity decrypted, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("Queries on encrypted properties fail", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		h := newExec(mock, exec.NewAESGCM(1, testKeys(1)))
+
+		var users []secretUser
+		err := h.FindWhere(ctx, "users", map[string]any{"ssn": "123"}, &users)
+		if !errors.Is(err, exec.ErrEncryptedQuery) {
+			t.Errorf("expected ErrEncryptedQuery, got %v", err)
+		}
+		if _, err := h.Count(ctx, "users", nil, exec.Scope(func(b *builder.Builder) { b.OrderAsc("token") })); !errors.Is(err, exec.ErrEncryptedQuery) {
+			t.Errorf("expected ErrEncryptedQuery ordering on token, got %v", err)
+		}
+		if err := h.FindWhere(ctx, "users", map[string]any{"name": "John"}, &users); err != nil {
+			t.Errorf("expected filters on other properties to run, got %v", err)
+		}
+	})
+
+	t.Run("Corrupt ciphertext fails", func(t *testing.T) {
+		c := exec.NewAESGCM(1, testKeys(1))
+		ciphertext, err := c.Encrypt([]byte("x"))
+		if err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+		ciphertext[len(ciphertext)-1] ^= 1
+		if _, err := c.Decrypt(ciphertext); err == nil {
+			t.Error("expected tampered ciphertext to fail")
+		}
+		if _, err := c.Decrypt([]byte{1}); !errors.Is(err, exec.ErrMalformedCiphertext) {
+			t.Errorf("expected ErrMalformedCiphertext, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+)
+
+// ErrStop can be returned from an iteration callback to end the iteration
+// early without an error
+var ErrStop = errors.New("stop iteration")
+
+// ErrNotFound is returned when no entity matches a lookup or query. It also
+// matches datastore.ErrNoSuchEntity under errors.Is, so existing checks for
+// that sentinel keep working.
+var ErrNotFound error = notFoundError{}
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string {
+	return "entity not found"
+}
+
+func (notFoundError) Is(target error) bool {
+	return target == datastore.ErrNoSuchEntity
+}
+
+// checkDest verifies dest can be decoded into
+func checkDest(dest any) error {
+	if dest == nil {
+		return errors.New("dest must be a non-nil pointer, got nil")
+	}
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("dest must be a non-nil pointer, got %T", dest)
+	}
+	return nil
+}
+
+// checkEntities verifies entities is a slice with one element per ID and
+// returns it
+func checkEntities(ids []any, entities any) (reflect.Value, error) {
+	v := reflect.ValueOf(entities)
+	if v.Kind() != reflect.Slice {
+		return v, fmt.Errorf("entities must be a slice, got %T", entities)
+	}
+	if v.Len() != len(ids) {
+		return v, fmt.Errorf("ids and entities must have the same length, got %d ids and %d entities", len(ids), v.Len())
+	}
+	return v, nil
+}
+
+// opError wraps err, returned by the Datastore calls of o, in a
+// *gostore.Error naming o and its key. The errors of a datastore.MultiError
+// of o's keys are wrapped each with the key they belong to. Errors already
+// wrapped, by a nested operation, are returned unchanged.
+func opError(o op, err error) error {
+	if err == nil {
+		return nil
+	}
+	var ge *gostore.Error
+	if errors.As(err, &ge) {
+		return err
+	}
+
+	if me, ok := err.(datastore.MultiError); ok && len(me) == len(o.targets) {
+		out := make(datastore.MultiError, len(me))
+		for i, e := range me {
+			if e != nil && !errors.As(e, &ge) {
+				e = &gostore.Error{Op: o.name, Kind: o.kind, Key: o.targets[i], Err: e}
+			}
+			out[i] = e
+		}
+		return out
+	}
+
+	e := &gostore.Error{Op: o.name, Kind: o.kind, Err: err}
+	if len(o.targets) == 1 {
+		e.Key = o.targets[0]
+	}
+	return e
+}
@@ -0,0 +1,209 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrNotFound(t *testing.T) {
+	t.Run("Matches itself and ErrNoSuchEntity", func(t *testing.T) {
+		err := errors.Join(errors.New("context"), ErrNotFound)
+
+		if !errors.Is(err, ErrNotFound) {
+			t.Error("expected errors.Is to match ErrNotFound")
+		}
+		if !errors.Is(err, datastore.ErrNoSuchEntity) {
+			t.Error("expected errors.Is to match datastore.ErrNoSuchEntity")
+		}
+	})
+}
+
+func TestFindOneDest(t *testing.T) {
+	h := NewExec()
+
+	tests := []struct {
+		name string
+		dest any
+	}{
+		{"nil", nil},
+		{"non-pointer", bulkEntity{}},
+		{"nil pointer", (*bulkEntity)(nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := h.FindOne(context.Background(), "users", nil, tt.dest)
+			if err == nil || err.Error() == "database is not initialized" {
+				t.Errorf("expected dest error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateMultiChecksEntities(t *testing.T) {
+	ctx := context.Background()
+	h := NewExecWithOptions(WithClient(testutil.NewMockClient()))
+	users := []testutil.TestUser{{Name: "A"}, {Name: "B"}}
+
+	tests := []struct {
+		name     string
+		ids      []any
+		entities any
+	}{
+		{"more ids than entities", []any{"a", "b", "c"}, users},
+		{"more entities than ids", []any{"a"}, users},
+		{"nil entities slice", []any{"a"}, []testutil.TestUser(nil)},
+		{"nil entities", []any{"a"}, nil},
+		{"entities not a slice", []any{"a"}, users[0]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := h.CreateMulti(ctx, "users", tt.ids, tt.entities); err == nil {
+				t.Error("expected CreateMulti to fail")
+			}
+			if err := h.UpdateMulti(ctx, "users", tt.ids, tt.entities); err == nil {
+				t.Error("expected UpdateMulti to fail")
+			}
+		})
+	}
+}
+
+func TestCreateMultiIndexesErrors(t *testing.T) {
+	errTooLarge := errors.New("entity too large")
+	client := testutil.NewMockClient()
+	client.FailKey(datastore.NameKey("users", "b", nil), errTooLarge)
+	h := NewExecWithOptions(WithClient(client))
+
+	users := []testutil.TestUser{{Name: "A"}, {Name: "B"}, {Name: "C"}}
+	err := h.CreateMulti(context.Background(), "users", []any{"a", "b", "c"}, users)
+
+	var me datastore.MultiError
+	if !errors.As(err, &me) || len(me) != 3 {
+		t.Fatalf("expected a MultiError of 3, got %v", err)
+	}
+	if me[0] != nil || me[2] != nil {
+		t.Errorf("expected only index 1 to fail, got %v", me)
+	}
+	var ge *gostore.Error
+	if !errors.Is(me[1], errTooLarge) || !errors.As(me[1], &ge) || ge.Key.Name != "b" {
+		t.Errorf("expected index 1 error to name its key, got %v", me[1])
+	}
+	if client.Count("users") != 0 {
+		t.Errorf("expected nothing to be written, got %d users", client.Count("users"))
+	}
+}
+
+func TestOperationErrors(t *testing.T) {
+	ctx := context.Background()
+	client := testutil.NewMockClient()
+	h := NewExecWithOptions(WithClient(client))
+
+	if err := h.Create(ctx, "users", "a", &testutil.TestUser{Name: "A"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	t.Run("GetByID", func(t *testing.T) {
+		var user testutil.TestUser
+		err := h.GetByID(ctx, "users", "user123", &user)
+
+		const want = "gostore: users.GetByID key=users/name=user123: datastore: no such entity"
+		if err == nil || err.Error() != want {
+			t.Fatalf("expected %q, got %v", want, err)
+		}
+		if !errors.Is(err, datastore.ErrNoSuchEntity) {
+			t.Error("expected errors.Is to match datastore.ErrNoSuchEntity")
+		}
+		var ge *gostore.Error
+		if !errors.As(err, &ge) || ge.Op != "GetByID" || ge.Kind != "users" || ge.Key.Name != "user123" {
+			t.Errorf("expected a *gostore.Error for users/user123, got %#v", ge)
+		}
+
+		exists, err := h.Exists(ctx, "users", "user123")
+		if err != nil || exists {
+			t.Errorf("expected Exists to report a missing entity, got %v, %v", exists, err)
+		}
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		unavailable := status.Error(codes.Unavailable, "unavailable")
+		client.FailNext(testutil.OpPut, unavailable, 1)
+
+		err := h.Create(ctx, "users", int64(5), &testutil.TestUser{Name: "B"})
+		if !strings.HasPrefix(fmt.Sprint(err), "gostore: users.Create key=users/id=5: ") {
+			t.Errorf("expected the error to name users.Create and its key, got %v", err)
+		}
+		if status.Code(err) != codes.Unavailable {
+			t.Errorf("expected the status code to be kept, got %v", status.Code(err))
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		errDenied := errors.New("denied")
+		client.FailNext(testutil.OpDelete, errDenied, 1)
+
+		err := h.Delete(ctx, "users", "a")
+		var ge *gostore.Error
+		if !errors.Is(err, errDenied) || !errors.As(err, &ge) || ge.Op != "Delete" || ge.Key.Name != "a" {
+			t.Errorf("expected a *gostore.Error for users.Delete of a, got %v", err)
+		}
+	})
+
+	t.Run("MultiError", func(t *testing.T) {
+		users := make([]testutil.TestUser, 2)
+		err := h.GetMulti(ctx, "users", []any{"a", "missing"}, users)
+
+		var me datastore.MultiError
+		if !errors.As(err, &me) || len(me) != 2 || me[0] != nil {
+			t.Fatalf("expected a MultiError failing at index 1, got %v", err)
+		}
+		const want = "gostore: users.GetMulti key=users/name=missing: datastore: no such entity"
+		if me[1].Error() != want || !errors.Is(me[1], datastore.ErrNoSuchEntity) {
+			t.Errorf("expected %q, got %v", want, me[1])
+		}
+	})
+}
+
+func TestRetryWithMock(t *testing.T) {
+	ctx := context.Background()
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	client := testutil.NewMockClient()
+	h := NewExecWithOptions(WithClient(client), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+
+	if err := h.Create(ctx, "users", "a", &testutil.TestUser{Name: "A"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	t.Run("Unavailable twice then success", func(t *testing.T) {
+		client.FailNext(testutil.OpGet, unavailable, 2)
+
+		var user testutil.TestUser
+		if err := h.GetByID(ctx, "users", "a", &user); err != nil {
+			t.Fatalf("expected the third attempt to succeed, got %v", err)
+		}
+		if user.Name != "A" {
+			t.Errorf("expected user A, got %+v", user)
+		}
+	})
+
+	t.Run("Unavailable on every attempt", func(t *testing.T) {
+		client.FailNext(testutil.OpGet, unavailable, 3)
+
+		var user testutil.TestUser
+		if err := h.GetByID(ctx, "users", "a", &user); status.Code(err) != codes.Unavailable {
+			t.Fatalf("expected Unavailable after 3 attempts, got %v", err)
+		}
+		if err := h.GetByID(ctx, "users", "a", &user); err != nil {
+			t.Errorf("expected the injected failures to be used up, got %v", err)
+		}
+	})
+}
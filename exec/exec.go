@@ -4,15 +4,52 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"reflect"
+	"log/slog"
+	"time"
 
 	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
 	"github.com/AndroX7/gostore/builder"
 	contextKey "github.com/AndroX7/gostore/key"
 )
 
 // Exec provides utility functions for Datastore operations
 type Exec struct {
+	softDelete     bool
+	deletedAtField string
+	createdAtField string
+	updatedAtField string
+	generateID     func() string
+
+	jsonNumberNames bool
+	namespace       string
+	opTimeout       time.Duration
+	retryPolicy     *RetryPolicy
+	logger          *slog.Logger
+	slowThreshold   time.Duration
+	redactLogs      bool
+	queryLogger     builder.QueryLogger
+	dryRun          bool
+	client          gostore.Client
+	clientName      string
+	tracer          Tracer
+	metrics         Metrics
+	writeRate       float64
+	writeBurst      int
+	writeLimiter    *writeLimiter
+	parallelism     int
+
+	audit              AuditHook
+	auditActorKey      any
+	auditStrict        bool
+	auditTransactional bool
+
+	history map[string]string
+
+	cipher      Cipher
+	compressor  Compressor
+	compressors map[byte]Compressor
+	codecKinds  map[string][]codecField
 }
 
 // NewExec creates a new helper instance
@@ -20,192 +57,296 @@ func NewExec() *Exec {
 	return &Exec{}
 }
 
+// clientFor returns the client set with WithClient, or else the one stored
+// in ctx under NOSQL_KEY, which may be a *datastore.Client or a
+// gostore.Client, or under the name set with On. Without either it returns
+// key.ErrClientNotInitialized. With WithCipher, the client encrypts and
+// decrypts the entities going through it.
+func (h *Exec) clientFor(ctx context.Context) (gostore.Client, error) {
+	if h.client != nil {
+		return h.withCodec(h.client), nil
+	}
+	client, err := contextKey.NamedClientFromContext(ctx, h.clientName)
+	if err != nil {
+		return nil, err
+	}
+	return h.withCodec(client), nil
+}
+
 // GetByID retrieves entity by ID
 func (h *Exec) GetByID(ctx context.Context, kind string, id any, dest any) error {
-	var key *datastore.Key
-
-	var client *datastore.Client
-	ref := ctx.Value(contextKey.NOSQL_KEY)
-	if tmp, ok := ref.(*datastore.Client); ok && tmp != nil {
-		client = tmp
-	} else {
-		err := errors.New("database is not initialized")
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, err := h.idKey(kind, id)
+	if err != nil {
 		return err
 	}
 
-	switch v := id.(type) {
-	case string:
-		key = datastore.NameKey(kind, v, nil)
-	case int64:
-		key = datastore.IDKey(kind, v, nil)
-	default:
-		return fmt.Errorf("invalid ID type: %T", id)
+	err = h.run(ctx, op{name: "GetByID", kind: kind, results: one}.on(key), func(ctx context.Context) error {
+		return client.Get(ctx, key, dest)
+	})
+	if err != nil {
+		return err
 	}
+	contextKey.SetID(dest, key)
+	return nil
+}
 
-	return client.Get(ctx, key, dest)
+// GetByCompositeID retrieves the entity named after parts with
+// key.Composite, e.g. one created from fields tagged gostore:"keypart=N"
+func (h *Exec) GetByCompositeID(ctx context.Context, kind string, dest any, parts ...string) error {
+	return h.GetByID(ctx, kind, contextKey.Composite(parts...), dest)
 }
 
-// GetMulti retrieves multiple entities by IDs
+// GetMulti retrieves multiple entities by IDs. Over MaxBatchSize IDs are
+// looked up in chunks, concurrently with WithParallelism.
 func (h *Exec) GetMulti(ctx context.Context, kind string, ids []any, dest any) error {
-	var client *datastore.Client
-	ref := ctx.Value(contextKey.NOSQL_KEY)
-	if tmp, ok := ref.(*datastore.Client); ok && tmp != nil {
-		client = tmp
-	} else {
-		err := errors.New("database is not initialized")
+	client, err := h.clientFor(ctx)
+	if err != nil {
 		return err
 	}
 
-	keys := make([]*datastore.Key, len(ids))
-	for i, id := range ids {
-		switch v := id.(type) {
-		case string:
-			keys[i] = datastore.NameKey(kind, v, nil)
-		case int64:
-			keys[i] = datastore.IDKey(kind, v, nil)
-		default:
-			return fmt.Errorf("invalid ID type at index %d: %T", i, id)
-		}
+	keys, err := h.idKeys(kind, ids)
+	if err != nil {
+		return err
 	}
 
-	return client.GetMulti(ctx, keys, dest)
+	if err := h.getMulti(ctx, op{name: "GetMulti", kind: kind}, client, keys, dest); err != nil {
+		return err
+	}
+	contextKey.SetIDs(dest, keys)
+	return nil
 }
 
-// Create creates a new entity
+// Create creates a new entity. A nil id names it after the fields of entity
+// tagged gostore:"keypart=N" (see key.CompositeID), if it has any.
 func (h *Exec) Create(ctx context.Context, kind string, id any, entity any) error {
+	_, err := h.put(ctx, kind, id, entity, true)
+	return err
+}
 
-	var client *datastore.Client
-	ref := ctx.Value(contextKey.NOSQL_KEY)
-	if tmp, ok := ref.(*datastore.Client); ok && tmp != nil {
-		client = tmp
-	} else {
-		err := errors.New("database is not initialized")
-		return err
+// CreateWithKey creates a new entity and returns its key, which carries the
+// ID allocated or generated for a nil or AutoUUID id
+func (h *Exec) CreateWithKey(ctx context.Context, kind string, id any, entity any) (*datastore.Key, error) {
+	return h.put(ctx, kind, id, entity, true)
+}
+
+// put writes entity, stamping auto timestamps for a create or an update
+func (h *Exec) put(ctx context.Context, kind string, id any, entity any, create bool) (*datastore.Key, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	var key *datastore.Key
+	// A nil ID is derived from the entity's key parts, or auto-generated
+	key, err := h.entityKey(kind, id, entity)
+	if err != nil {
+		return nil, err
+	}
 
-	switch v := id.(type) {
-	case string:
-		key = datastore.NameKey(kind, v, nil)
-	case int64:
-		key = datastore.IDKey(kind, v, nil)
-	case nil:
-		// Auto-generate ID
-		key = datastore.IncompleteKey(kind, nil)
-	default:
-		return fmt.Errorf("invalid ID type: %T", id)
+	if err := h.stampTimestamps(entity, create); err != nil {
+		return nil, err
 	}
 
-	_, err := client.Put(ctx, key, entity)
-	return err
+	stored := key
+	err = h.run(ctx, putOp(putName(create), kind, key), func(ctx context.Context) error {
+		if !create && h.hasHistory(key) {
+			_, err := h.withHistory(ctx, client, []*datastore.Key{key}, func(tx gostore.Transaction) error {
+				_, err := tx.Put(key, entity)
+				return err
+			})
+			return err
+		}
+
+		var err error
+		stored, err = client.Put(ctx, key, entity)
+		return err
+	})
+	if err != nil {
+		return stored, err
+	}
+	return stored, h.recordAudit(ctx, auditBatch{op: auditPut(create), keys: []*datastore.Key{stored}, after: auditEntity(entity)})
+}
+
+// auditPut returns the audit operation of a create or an update
+func auditPut(create bool) AuditOperation {
+	if create {
+		return AuditCreate
+	}
+	return AuditUpdate
 }
 
 // CreateMulti creates multiple entities
 func (h *Exec) CreateMulti(ctx context.Context, kind string, ids []any, entities any) error {
+	_, err := h.putMulti(ctx, kind, ids, entities, true)
+	return err
+}
 
-	var client *datastore.Client
-	ref := ctx.Value(contextKey.NOSQL_KEY)
-	if tmp, ok := ref.(*datastore.Client); ok && tmp != nil {
-		client = tmp
-	} else {
-		err := errors.New("database is not initialized")
-		return err
+// CreateMultiWithKeys creates multiple entities and returns their keys in
+// order
+func (h *Exec) CreateMultiWithKeys(ctx context.Context, kind string, ids []any, entities any) ([]*datastore.Key, error) {
+	return h.putMulti(ctx, kind, ids, entities, true)
+}
+
+// putMulti writes entities, stamping auto timestamps for a create or an update
+func (h *Exec) putMulti(ctx context.Context, kind string, ids []any, entities any, create bool) ([]*datastore.Key, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	v := reflect.ValueOf(entities)
-	if v.Kind() != reflect.Slice {
-		return fmt.Errorf("entities must be a slice")
+	v, err := checkEntities(ids, entities)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := h.entityKeys(kind, ids, v)
+	if err != nil {
+		return nil, err
 	}
 
-	keys := make([]*datastore.Key, len(ids))
-	for i, id := range ids {
-		switch v := id.(type) {
-		case string:
-			keys[i] = datastore.NameKey(kind, v, nil)
-		case int64:
-			keys[i] = datastore.IDKey(kind, v, nil)
-		case nil:
-			keys[i] = datastore.IncompleteKey(kind, nil)
-		default:
-			return fmt.Errorf("invalid ID type at index %d: %T", i, id)
+	for i := 0; i < v.Len(); i++ {
+		if err := h.stampValue(v.Index(i), create); err != nil {
+			return nil, fmt.Errorf("entity at index %d: %w", i, err)
 		}
 	}
 
-	_, err := client.PutMulti(ctx, keys, entities)
-	return err
+	stored := keys
+	err = h.run(ctx, putOp(putName(create)+"Multi", kind, keys...), func(ctx context.Context) error {
+		if !create && h.hasHistory(keys...) {
+			return h.putMultiWithHistory(ctx, client, keys, entities, &stored)
+		}
+
+		var err error
+		stored, err = client.PutMulti(ctx, keys, entities)
+		return err
+	})
+	if err != nil {
+		return stored, err
+	}
+	return stored, h.recordAudit(ctx, auditBatch{op: auditPut(create), keys: stored, after: auditEntities(entities)})
+}
+
+// putMultiWithHistory writes entities under keys in a transaction keeping
+// their history, storing the written keys in stored
+func (h *Exec) putMultiWithHistory(ctx context.Context, client gostore.Client, keys []*datastore.Key, entities any, stored *[]*datastore.Key) error {
+	var pending []*datastore.PendingKey
+	commit, err := h.withHistory(ctx, client, keys, func(tx gostore.Transaction) error {
+		var err error
+		pending, err = tx.PutMulti(keys, entities)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	*stored = make([]*datastore.Key, len(keys))
+	for i, key := range keys {
+		if key.Incomplete() {
+			key = commit.Key(pending[i])
+		}
+		(*stored)[i] = key
+	}
+	return nil
 }
 
 // Update updates an existing entity
 func (h *Exec) Update(ctx context.Context, kind string, id any, entity any) error {
-	return h.Create(ctx, kind, id, entity) // Put works for both create and update
+	_, err := h.put(ctx, kind, id, entity, false) // Put works for both create and update
+	return err
+}
+
+// UpdateWithKey updates an existing entity and returns its key
+func (h *Exec) UpdateWithKey(ctx context.Context, kind string, id any, entity any) (*datastore.Key, error) {
+	return h.put(ctx, kind, id, entity, false)
 }
 
 // UpdateMulti updates multiple entities
 func (h *Exec) UpdateMulti(ctx context.Context, kind string, ids []any, entities any) error {
-	return h.CreateMulti(ctx, kind, ids, entities)
+	_, err := h.putMulti(ctx, kind, ids, entities, false)
+	return err
+}
+
+// UpdateMultiWithKeys updates multiple entities and returns their keys in
+// order
+func (h *Exec) UpdateMultiWithKeys(ctx context.Context, kind string, ids []any, entities any) ([]*datastore.Key, error) {
+	return h.putMulti(ctx, kind, ids, entities, false)
 }
 
 // Delete deletes an entity
 func (h *Exec) Delete(ctx context.Context, kind string, id any) error {
-
-	var client *datastore.Client
-	ref := ctx.Value(contextKey.NOSQL_KEY)
-	if tmp, ok := ref.(*datastore.Client); ok && tmp != nil {
-		client = tmp
-	} else {
-		err := errors.New("database is not initialized")
+	client, err := h.clientFor(ctx)
+	if err != nil {
 		return err
 	}
 
-	var key *datastore.Key
-
-	switch v := id.(type) {
-	case string:
-		key = datastore.NameKey(kind, v, nil)
-	case int64:
-		key = datastore.IDKey(kind, v, nil)
-	default:
-		return fmt.Errorf("invalid ID type: %T", id)
+	key, err := h.idKey(kind, id)
+	if err != nil {
+		return err
 	}
 
-	return client.Delete(ctx, key)
+	err = h.run(ctx, op{name: "Delete", kind: kind, write: true}.on(key), func(ctx context.Context) error {
+		return h.delete(ctx, client, key)
+	})
+	if err != nil {
+		return err
+	}
+	return h.recordAudit(ctx, auditBatch{op: AuditDelete, keys: []*datastore.Key{key}})
 }
 
 // DeleteMulti deletes multiple entities
 func (h *Exec) DeleteMulti(ctx context.Context, kind string, ids []any) error {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return err
+	}
 
-	var client *datastore.Client
-	ref := ctx.Value(contextKey.NOSQL_KEY)
-	if tmp, ok := ref.(*datastore.Client); ok && tmp != nil {
-		client = tmp
-	} else {
-		err := errors.New("database is not initialized")
+	keys, err := h.idKeys(kind, ids)
+	if err != nil {
 		return err
 	}
 
-	keys := make([]*datastore.Key, len(ids))
+	err = h.run(ctx, op{name: "DeleteMulti", kind: kind, write: true}.on(keys...), func(ctx context.Context) error {
+		return h.deleteMulti(ctx, client, keys)
+	})
+	if err != nil {
+		return err
+	}
+	return h.recordAudit(ctx, auditBatch{op: AuditDelete, keys: keys})
+}
 
-	for i, id := range ids {
-		switch v := id.(type) {
-		case string:
-			keys[i] = datastore.NameKey(kind, v, nil)
-		case int64:
-			keys[i] = datastore.IDKey(kind, v, nil)
-		default:
-			return fmt.Errorf("invalid ID type at index %d: %T", i, id)
-		}
+// delete deletes key, in a transaction keeping its history if its kind has
+// one
+func (h *Exec) delete(ctx context.Context, client gostore.Client, key *datastore.Key) error {
+	if !h.hasHistory(key) {
+		return client.Delete(ctx, key)
 	}
+	_, err := h.withHistory(ctx, client, []*datastore.Key{key}, func(tx gostore.Transaction) error {
+		return tx.Delete(key)
+	})
+	return err
+}
 
-	return client.DeleteMulti(ctx, keys)
+// deleteMulti deletes keys like delete
+func (h *Exec) deleteMulti(ctx context.Context, client gostore.Client, keys []*datastore.Key) error {
+	if !h.hasHistory(keys...) {
+		return client.DeleteMulti(ctx, keys)
+	}
+	_, err := h.withHistory(ctx, client, keys, func(tx gostore.Transaction) error {
+		return tx.DeleteMulti(keys)
+	})
+	return err
 }
 
 // Exists checks if entity exists
 func (h *Exec) Exists(ctx context.Context, kind string, id any) (bool, error) {
-	var entity map[string]any
+	var entity datastore.PropertyList
 	err := h.GetByID(ctx, kind, id, &entity)
 
-	if err == datastore.ErrNoSuchEntity {
+	if errors.Is(err, datastore.ErrNoSuchEntity) {
 		return false, nil
 	}
 	if err != nil {
@@ -216,193 +357,262 @@ func (h *Exec) Exists(ctx context.Context, kind string, id any) (bool, error) {
 }
 
 // Count counts entities matching query
-func (h *Exec) Count(ctx context.Context, kind string, filters []builder.FilterParam) (int, error) {
-
-	var client *datastore.Client
-	ref := ctx.Value(contextKey.NOSQL_KEY)
-	if tmp, ok := ref.(*datastore.Client); ok && tmp != nil {
-		client = tmp
-	} else {
-		err := errors.New("database is not initialized")
+func (h *Exec) Count(ctx context.Context, kind string, filters []builder.FilterParam, opts ...QueryOption) (int, error) {
+	return h.CountUpTo(ctx, kind, filters, 0, opts...)
+}
+
+// CountUpTo counts entities matching query, stopping once limit is reached.
+// A limit <= 0 counts every match.
+func (h *Exec) CountUpTo(ctx context.Context, kind string, filters []builder.FilterParam, limit int, opts ...QueryOption) (int, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
 		return 0, err
 	}
 
-	b := builder.New().Kind(kind)
+	b := h.newBuilder(kind)
 
 	for _, filter := range filters {
 		b.Filter(filter.Field, filter.Operator, filter.Value)
 	}
+	h.applyQueryOptions(b, newQueryOptions(opts))
 
-	return b.Count(ctx, client)
+	var count int
+	err = h.run(ctx, op{name: "Count", kind: kind, query: b, results: func() int { return count }}, func(ctx context.Context) error {
+		var err error
+		count, err = b.CountUpTo(ctx, client, limit)
+		return err
+	})
+	return count, err
 }
 
 // FindAll retrieves all entities of a kind
-func (h *Exec) FindAll(ctx context.Context, kind string, dest any) error {
-
-	var client *datastore.Client
-	ref := ctx.Value(contextKey.NOSQL_KEY)
-	if tmp, ok := ref.(*datastore.Client); ok && tmp != nil {
-		client = tmp
-	} else {
-		err := errors.New("database is not initialized")
+func (h *Exec) FindAll(ctx context.Context, kind string, dest any, opts ...QueryOption) error {
+	client, err := h.clientFor(ctx)
+	if err != nil {
 		return err
 	}
 
-	query := datastore.NewQuery(kind)
-	_, err := client.GetAll(ctx, query, dest)
-	return err
+	b := h.newBuilder(kind)
+	h.applyQueryOptions(b, newQueryOptions(opts))
+
+	var result *builder.PaginationResult
+	return h.run(ctx, op{name: "FindAll", kind: kind, query: b, results: func() int { return result.Total }}, func(ctx context.Context) error {
+		var err error
+		result, err = b.Execute(ctx, client, dest)
+		return err
+	})
 }
 
 // FindWhere retrieves entities matching filters
-func (h *Exec) FindWhere(ctx context.Context, kind string, filters map[string]any, dest any) error {
-
-	var client *datastore.Client
-	ref := ctx.Value(contextKey.NOSQL_KEY)
-	if tmp, ok := ref.(*datastore.Client); ok && tmp != nil {
-		client = tmp
-	} else {
-		err := errors.New("database is not initialized")
+func (h *Exec) FindWhere(ctx context.Context, kind string, filters map[string]any, dest any, opts ...QueryOption) error {
+	client, err := h.clientFor(ctx)
+	if err != nil {
 		return err
 	}
 
-	b := builder.New().Kind(kind)
+	b := h.newBuilder(kind)
 
 	fb := builder.NewFilter().FromMap(filters)
 	for _, filter := range fb.Build() {
 		b.Filter(filter.Field, filter.Operator, filter.Value)
 	}
+	h.applyQueryOptions(b, newQueryOptions(opts))
 
-	_, err := b.Execute(ctx, client, dest)
-	return err
+	var result *builder.PaginationResult
+	return h.run(ctx, op{name: "FindWhere", kind: kind, query: b, results: func() int { return result.Total }}, func(ctx context.Context) error {
+		var err error
+		result, err = b.Execute(ctx, client, dest)
+		return err
+	})
 }
 
 // FindOne retrieves first entity matching filters
-func (h *Exec) FindOne(ctx context.Context, kind string, filters map[string]any, dest any) error {
-
-	var client *datastore.Client
-	ref := ctx.Value(contextKey.NOSQL_KEY)
-	if tmp, ok := ref.(*datastore.Client); ok && tmp != nil {
-		client = tmp
-	} else {
-		err := errors.New("database is not initialized")
+func (h *Exec) FindOne(ctx context.Context, kind string, filters map[string]any, dest any, opts ...QueryOption) error {
+	if err := checkDest(dest); err != nil {
+		return err
+	}
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
 		return err
 	}
 
-	b := builder.New().Kind(kind).Limit(1)
+	b := h.newBuilder(kind).Limit(1)
 
 	fb := builder.NewFilter().FromMap(filters)
 	for _, filter := range fb.Build() {
 		b.Filter(filter.Field, filter.Operator, filter.Value)
 	}
+	h.applyQueryOptions(b, newQueryOptions(opts))
 
-	query := b.Build()
-	it := client.Run(ctx, query)
-
-	_, err := it.Next(dest)
-	return err
+	err = h.run(ctx, op{name: "FindOne", kind: kind, query: b, results: one}, func(ctx context.Context) error {
+		_, err := b.First(ctx, client, dest)
+		return err
+	})
+	if errors.Is(err, datastore.ErrNoSuchEntity) {
+		return fmt.Errorf("%w: no %s matching %v", ErrNotFound, kind, filters)
+	}
+	if err != nil {
+		return fmt.Errorf("find one %s matching %v: %w", kind, filters, err)
+	}
+	return nil
 }
 
-// Paginate retrieves paginated results
-func (h *Exec) Paginate(ctx context.Context, kind string, filters map[string]any, page, pageSize int, dest any) (*builder.PaginationResult, error) {
-
-	var client *datastore.Client
-	ref := ctx.Value(contextKey.NOSQL_KEY)
-	if tmp, ok := ref.(*datastore.Client); ok && tmp != nil {
-		client = tmp
-	} else {
-		err := errors.New("database is not initialized")
+// Paginate retrieves one page of results. Total is the number of entities
+// matching filters, counted with an aggregation query unless SkipTotal is
+// given, in which case it is the page length and HasMore only reports whether
+// the page is full.
+func (h *Exec) Paginate(ctx context.Context, kind string, filters map[string]any, page, pageSize int, dest any, opts ...QueryOption) (*builder.PaginationResult, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
 		return nil, err
 	}
 
+	if page < 1 {
+		page = 1
+	}
 	offset := (page - 1) * pageSize
+	o := newQueryOptions(opts)
 
-	b := builder.New().Kind(kind).Limit(pageSize).Offset(offset)
+	b := h.newBuilder(kind).Limit(pageSize).Offset(offset)
+	countBuilder := h.newBuilder(kind)
 
 	fb := builder.NewFilter().FromMap(filters)
 	for _, filter := range fb.Build() {
 		b.Filter(filter.Field, filter.Operator, filter.Value)
+		countBuilder.Filter(filter.Field, filter.Operator, filter.Value)
 	}
+	h.applyQueryOptions(b, o)
+	h.applyQueryOptions(countBuilder, o)
 
-	return b.Execute(ctx, client, dest)
-}
+	var result *builder.PaginationResult
+	err = h.run(ctx, op{name: "Paginate", kind: kind, query: b, results: func() int { return result.Total }}, func(ctx context.Context) error {
+		var err error
+		result, err = b.Execute(ctx, client, dest)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Page = page
+	result.PageSize = pageSize
 
-// Transaction executes operations in a transaction
-func (h *Exec) Transaction(ctx context.Context, fn func(tx *datastore.Transaction) error) error {
+	if o.skipTotal {
+		return result, nil
+	}
 
-	var client *datastore.Client
-	ref := ctx.Value(contextKey.NOSQL_KEY)
-	if tmp, ok := ref.(*datastore.Client); ok && tmp != nil {
-		client = tmp
-	} else {
-		err := errors.New("database is not initialized")
+	var total int
+	err = h.run(ctx, op{name: "Paginate", kind: kind, query: countBuilder, results: func() int { return total }}, func(ctx context.Context) error {
+		var err error
+		total, err = countAggregate(ctx, client, countBuilder)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	_, err := client.RunInTransaction(ctx, fn)
-	return err
+	result.HasMore = offset+result.Total < total
+	result.Total = total
+
+	return result, nil
 }
 
 // BulkCreate creates entities in batches
 func (h *Exec) BulkCreate(ctx context.Context, kind string, entities any, batchSize int) error {
+	return h.BulkCreateWithOptions(ctx, kind, entities, batchSize, BulkOptions{})
+}
 
-	v := reflect.ValueOf(entities)
-	if v.Kind() != reflect.Slice {
-		return fmt.Errorf("entities must be a slice")
-	}
-
-	total := v.Len()
-	for i := 0; i < total; i += batchSize {
-		end := i + batchSize
-		if end > total {
-			end = total
-		}
-
-		batch := v.Slice(i, end).Interface()
-		ids := make([]any, end-i)
-		for j := range ids {
-			ids[j] = nil // Auto-generate IDs
-		}
-
-		if err := h.CreateMulti(ctx, kind, ids, batch); err != nil {
-			return err
-		}
-	}
+// BulkDelete deletes entities matching query in batches of MaxBatchSize,
+// fetching their keys a page of DefaultDeletePageSize at a time. Cancelling
+// ctx stops it between batches with a *PartialError.
+func (h *Exec) BulkDelete(ctx context.Context, kind string, filters map[string]any) (int, error) {
+	return h.BulkDeleteWithOptions(ctx, kind, filters, BulkDeleteOptions{PageSize: DefaultDeletePageSize})
+}
 
-	return nil
+// BulkDeleteOptions configures BulkDeleteWithOptions
+type BulkDeleteOptions struct {
+	// BeforeBatch is called with the keys of every batch before it is
+	// deleted. Returning an error stops the run before that batch; the error
+	// is returned along with the number already deleted.
+	BeforeBatch func(keys []*datastore.Key) error
+
+	// OnBatch is called after every batch, including failed ones, like
+	// BulkOptions.OnBatch. With PageSize set, total is the number of keys
+	// fetched so far, as the full count isn't known up front.
+	OnBatch func(done, total int, keys []*datastore.Key, err error)
+
+	// PageSize, if set, fetches the keys to delete a page of at most
+	// PageSize at a time instead of all at once, so memory holds at most
+	// Workers+1 pages of keys however many entities match
+	PageSize int
+
+	// Workers is the number of pages deleted concurrently while the next
+	// ones are fetched, with PageSize set. At most 1, each page is deleted
+	// before the next is fetched.
+	Workers int
+
+	// OnPage is called after every page is deleted, with PageSize set, with
+	// the number of pages and of entities deleted so far. The callbacks are
+	// never called concurrently, even with several Workers.
+	OnPage func(pages, deleted int)
+
+	// Scope, if set, is applied to the query selecting the entities to
+	// delete, like the Scope query option
+	Scope func(b *builder.Builder)
 }
 
-// BulkDelete deletes entities matching query
-func (h *Exec) BulkDelete(ctx context.Context, kind string, filters map[string]any) (int, error) {
+// BulkDeleteWithOptions deletes entities matching filters like BulkDelete,
+// reporting each batch through opts
+func (h *Exec) BulkDeleteWithOptions(ctx context.Context, kind string, filters map[string]any, opts BulkDeleteOptions) (n int, err error) {
+	ctx, finish := h.startBulk(ctx, "BulkDelete", kind)
+	defer func() { finish(n, err) }()
 
-	var client *datastore.Client
-	ref := ctx.Value(contextKey.NOSQL_KEY)
-	if tmp, ok := ref.(*datastore.Client); ok && tmp != nil {
-		client = tmp
-	} else {
-		err := errors.New("database is not initialized")
+	client, err := h.clientFor(ctx)
+	if err != nil {
 		return 0, err
 	}
 
-	b := builder.New().Kind(kind).KeysOnly()
+	b := h.newBuilder(kind).KeysOnly()
 
 	fb := builder.NewFilter().FromMap(filters)
 	for _, filter := range fb.Build() {
 		b.Filter(filter.Field, filter.Operator, filter.Value)
 	}
+	if opts.Scope != nil {
+		opts.Scope(b)
+	}
+
+	if opts.PageSize > 0 {
+		return h.deletePages(ctx, op{name: "BulkDelete", kind: kind}, client, b, opts)
+	}
 
-	query := b.Build()
-	keys, err := client.GetAll(ctx, query, nil)
+	keys, err := h.getKeys(ctx, op{name: "BulkDelete", kind: kind}, client, b)
 	if err != nil {
 		return 0, err
 	}
 
-	if len(keys) == 0 {
-		return 0, nil
+	if opts.BeforeBatch == nil && opts.OnBatch == nil {
+		return h.deleteKeys(ctx, op{name: "BulkDelete", kind: kind, write: true}, client, keys)
 	}
 
-	if err := client.DeleteMulti(ctx, keys); err != nil {
-		return 0, err
-	}
+	o := op{name: "BulkDelete", kind: kind, write: true}
+	return inBatches(ctx, len(keys), MaxBatchSize, h.paced(ctx, func(start, end int) error {
+		batch := keys[start:end]
+		if opts.BeforeBatch != nil {
+			if err := opts.BeforeBatch(batch); err != nil {
+				return err
+			}
+		}
 
-	return len(keys), nil
+		err := h.run(ctx, o.on(batch...), func(ctx context.Context) error {
+			return client.DeleteMulti(ctx, batch)
+		})
+		if err == nil {
+			err = h.recordAudit(ctx, auditBatch{op: AuditDelete, keys: batch})
+		}
+		if opts.OnBatch != nil {
+			opts.OnBatch(end, len(keys), batch, err)
+		}
+		return err
+	}))
 }
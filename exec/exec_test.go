@@ -0,0 +1,26 @@
+package exec_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	contextKey "github.com/AndroX7/gostore/key"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	testutil.StopEmulator()
+	os.Exit(code)
+}
+
+// emulatorContext returns a context carrying a client connected to the
+// Datastore emulator, using a fresh project so tests don't share data. The
+// test is skipped when there is no emulator (see testutil.RequireEmulator).
+func emulatorContext(t testing.TB) context.Context {
+	t.Helper()
+
+	client := testutil.StartEmulator(t)
+	return context.WithValue(context.Background(), contextKey.NOSQL_KEY, client)
+}
@@ -0,0 +1,136 @@
+package exec
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+// ExportKeyField is the JSON field ExportJSONL stores each entity's key in
+const ExportKeyField = "__key__"
+
+// ExportOptions configures ExportJSONL
+type ExportOptions struct {
+	// Fields restricts the export to these properties with a projection
+	// query, which only returns entities storing all of them indexed
+	Fields []string
+	// PageSize is the number of entities fetched per query, 100 by default
+	PageSize int
+	// OnProgress is called after every page is written with the running
+	// total
+	OnProgress func(exported int)
+}
+
+// ExportJSONL writes the entities of kind matching filters to w as JSON
+// Lines, one object per entity with its key path (see key.FormatPath) under
+// ExportKeyField, which ImportJSONL reads back. Times are written in RFC 3339
+// format and blobs in base64. Entities are read a page at a time and each
+// page is flushed to w before the next is fetched, so memory stays bounded.
+// It returns the number of entities written.
+func (h *Exec) ExportJSONL(ctx context.Context, kind string, filters map[string]any, w io.Writer, opts ExportOptions) (int, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	run := func(cursor string) cursorIterator {
+		b := h.newBuilder(kind).Limit(pageSize).Cursor(cursor)
+		if len(opts.Fields) > 0 {
+			b.Select(opts.Fields...)
+		}
+
+		fb := builder.NewFilter().FromMap(filters)
+		for _, filter := range fb.Build() {
+			b.Filter(filter.Field, filter.Operator, filter.Value)
+		}
+		h.applySoftDelete(b, queryOptions{})
+
+		return client.Run(ctx, b.Build())
+	}
+
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	exported := 0
+	flush := func() error {
+		if err := buf.Flush(); err != nil {
+			return err
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(exported)
+		}
+		return nil
+	}
+
+	_, err = eachPage(ctx, "", pageSize, run, func(key *datastore.Key, entity datastore.PropertyList) error {
+		line := exportProperties(entity)
+		line[ExportKeyField] = contextKey.FormatPath(key)
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+
+		exported++
+		if exported%pageSize == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		buf.Flush()
+		return exported, err
+	}
+	if exported%pageSize != 0 {
+		return exported, flush()
+	}
+	return exported, nil
+}
+
+func exportProperties(props []datastore.Property) map[string]any {
+	out := make(map[string]any, len(props)+1)
+	for _, p := range props {
+		out[p.Name] = exportValue(p.Value)
+	}
+	return out
+}
+
+// exportValue converts a property value to the form it is exported in.
+// []byte is left to encoding/json, which writes it in base64.
+func exportValue(v any) any {
+	switch x := v.(type) {
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	case *datastore.Key:
+		if x == nil {
+			return nil
+		}
+		return contextKey.FormatPath(x)
+	case *datastore.Entity:
+		if x == nil {
+			return nil
+		}
+		nested := exportProperties(x.Properties)
+		if x.Key != nil {
+			nested[ExportKeyField] = contextKey.FormatPath(x.Key)
+		}
+		return nested
+	case []any:
+		values := make([]any, len(x))
+		for i, e := range x {
+			values[i] = exportValue(e)
+		}
+		return values
+	}
+	return v
+}
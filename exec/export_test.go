@@ -0,0 +1,129 @@
+package exec_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestExportJSONL(t *testing.T) {
+	ctx := context.Background()
+	client := testutil.NewMockClient()
+	h := exec.NewExecWithOptions(exec.WithClient(client))
+
+	created := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	users := make([]testutil.TestUser, 2000)
+	for i := range users {
+		status := "active"
+		if i%4 == 0 {
+			status = "inactive"
+		}
+		users[i] = testutil.TestUser{
+			ID:        fmt.Sprintf("user%04d", i),
+			Email:     fmt.Sprintf("user%04d@example.com", i),
+			Name:      fmt.Sprintf("User %d", i),
+			Age:       20 + i%50,
+			Status:    status,
+			CreatedAt: created.Add(time.Duration(i) * time.Minute),
+		}
+	}
+	testutil.NewSeeder().SeedKind(ctx, t, client, "users", users)
+
+	t.Run("Every entity on its own line", func(t *testing.T) {
+		var buf bytes.Buffer
+		var progress []int
+		n, err := h.ExportJSONL(ctx, "users", nil, &buf, exec.ExportOptions{
+			PageSize:   300,
+			OnProgress: func(exported int) { progress = append(progress, exported) },
+		})
+		if err != nil {
+			t.Fatalf("ExportJSONL failed: %v", err)
+		}
+		if n != 2000 {
+			t.Errorf("expected 2000 exported, got %d", n)
+		}
+		if len(progress) != 7 || progress[0] != 300 || progress[6] != 2000 {
+			t.Errorf("expected progress every 300 up to 2000, got %v", progress)
+		}
+
+		lines := 0
+		var sample map[string]any
+		scanner := bufio.NewScanner(&buf)
+		for scanner.Scan() {
+			var line map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatalf("line %d is not JSON: %v", lines+1, err)
+			}
+			if _, ok := line[exec.ExportKeyField].(string); !ok {
+				t.Fatalf("line %d has no key: %s", lines+1, scanner.Text())
+			}
+			if line[exec.ExportKeyField] == "users/user1234" {
+				sample = line
+			}
+			lines++
+		}
+		if lines != 2000 {
+			t.Errorf("expected 2000 lines, got %d", lines)
+		}
+
+		want := map[string]any{
+			exec.ExportKeyField: "users/user1234",
+			"email":             "user1234@example.com",
+			"name":              "User 1234",
+			"age":               float64(54),
+			"status":            "active",
+			"created_at":        "2024-03-02T09:04:00Z",
+		}
+		if len(sample) != len(want) {
+			t.Errorf("expected fields %v, got %v", want, sample)
+		}
+		for field, value := range want {
+			if sample[field] != value {
+				t.Errorf("field %s: expected %v, got %v", field, value, sample[field])
+			}
+		}
+	})
+
+	t.Run("Filters and projection", func(t *testing.T) {
+		var buf bytes.Buffer
+		n, err := h.ExportJSONL(ctx, "users", map[string]any{"status": "inactive"}, &buf, exec.ExportOptions{
+			Fields: []string{"email"},
+		})
+		if err != nil {
+			t.Fatalf("ExportJSONL failed: %v", err)
+		}
+		if n != 500 {
+			t.Errorf("expected 500 inactive users, got %d", n)
+		}
+
+		first, _, _ := strings.Cut(buf.String(), "\n")
+		if want := `{"__key__":"users/user0000","email":"user0000@example.com"}`; first != want {
+			t.Errorf("expected %s, got %s", want, first)
+		}
+	})
+
+	t.Run("Blobs are base64", func(t *testing.T) {
+		type file struct {
+			Data []byte `datastore:"data,noindex"`
+		}
+		if err := h.Create(ctx, "files", "a", &file{Data: []byte("hello")}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		var buf bytes.Buffer
+		if _, err := h.ExportJSONL(ctx, "files", nil, &buf, exec.ExportOptions{}); err != nil {
+			t.Fatalf("ExportJSONL failed: %v", err)
+		}
+		if want := `{"__key__":"files/a","data":"aGVsbG8="}` + "\n"; buf.String() != want {
+			t.Errorf("expected %q, got %q", want, buf.String())
+		}
+	})
+}
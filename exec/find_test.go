@@ -0,0 +1,119 @@
+package exec_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestFindOne(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	users := testutil.CreateTestUsers()
+	ids := make([]any, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	if err := h.CreateMulti(ctx, "users", ids, users); err != nil {
+		t.Fatalf("failed to seed users: %v", err)
+	}
+
+	t.Run("Match", func(t *testing.T) {
+		var user testutil.TestUser
+		if err := h.FindOne(ctx, "users", map[string]any{"email": "jane@example.com"}, &user); err != nil {
+			t.Fatalf("FindOne failed: %v", err)
+		}
+		if user.Name != "Jane Smith" {
+			t.Errorf("expected 'Jane Smith', got '%s'", user.Name)
+		}
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		var user testutil.TestUser
+		err := h.FindOne(ctx, "users", map[string]any{"email": "nobody@example.com"}, &user)
+		if !errors.Is(err, exec.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestPaginate(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	users := make([]testutil.TestUser, 25)
+	for i := range users {
+		users[i] = testutil.TestUser{Name: "user", Age: i, Status: "active"}
+	}
+	if err := h.BulkCreate(ctx, "users", users, 10); err != nil {
+		t.Fatalf("failed to seed users: %v", err)
+	}
+
+	tests := []struct {
+		page     int
+		returned int
+		hasMore  bool
+	}{
+		{1, 10, true},
+		{2, 10, true},
+		{3, 5, false},
+	}
+
+	for _, tt := range tests {
+		var got []testutil.TestUser
+		pg, err := h.Paginate(ctx, "users", map[string]any{"status": "active"}, tt.page, 10, &got)
+		if err != nil {
+			t.Fatalf("page %d: Paginate failed: %v", tt.page, err)
+		}
+
+		if len(got) != tt.returned {
+			t.Errorf("page %d: expected %d users, got %d", tt.page, tt.returned, len(got))
+		}
+		if pg.Total != 25 {
+			t.Errorf("page %d: expected total 25, got %d", tt.page, pg.Total)
+		}
+		if pg.HasMore != tt.hasMore {
+			t.Errorf("page %d: expected HasMore %v, got %v", tt.page, tt.hasMore, pg.HasMore)
+		}
+		if pg.Page != tt.page || pg.PageSize != 10 {
+			t.Errorf("page %d: expected page %d size 10, got %d size %d", tt.page, tt.page, pg.Page, pg.PageSize)
+		}
+	}
+
+	t.Run("SkipTotal", func(t *testing.T) {
+		var got []testutil.TestUser
+		pg, err := h.Paginate(ctx, "users", nil, 1, 10, &got, exec.SkipTotal())
+		if err != nil {
+			t.Fatalf("Paginate failed: %v", err)
+		}
+		if pg.Total != 10 {
+			t.Errorf("expected total to be the page length 10, got %d", pg.Total)
+		}
+	})
+}
+
+func TestCountByField(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	users := testutil.CreateTestUsers()
+	ids := make([]any, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	if err := h.CreateMulti(ctx, "users", ids, users); err != nil {
+		t.Fatalf("failed to seed users: %v", err)
+	}
+
+	counts, err := h.CountByField(ctx, "users", "status", nil)
+	if err != nil {
+		t.Fatalf("CountByField failed: %v", err)
+	}
+
+	if len(counts) != 2 || counts["active"] != 3 || counts["inactive"] != 1 {
+		t.Errorf("expected {active:3 inactive:1}, got %v", counts)
+	}
+}
@@ -0,0 +1,41 @@
+package exec
+
+import (
+	"context"
+)
+
+// Get retrieves the entity with the given ID as a *T
+func Get[T any](ctx context.Context, e *Exec, kind string, id any) (*T, error) {
+	var dest T
+	if err := e.GetByID(ctx, kind, id, &dest); err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
+
+// GetMultiT retrieves the entities with the given IDs, in the same order
+func GetMultiT[T any](ctx context.Context, e *Exec, kind string, ids []any) ([]T, error) {
+	dest := make([]T, len(ids))
+	if err := e.GetMulti(ctx, kind, ids, dest); err != nil {
+		return nil, err
+	}
+	return dest, nil
+}
+
+// Find retrieves the entities matching filters as a []T
+func Find[T any](ctx context.Context, e *Exec, kind string, filters map[string]any, opts ...QueryOption) ([]T, error) {
+	var dest []T
+	if err := e.FindWhere(ctx, kind, filters, &dest, opts...); err != nil {
+		return nil, err
+	}
+	return dest, nil
+}
+
+// FindOneT retrieves the first entity matching filters as a *T
+func FindOneT[T any](ctx context.Context, e *Exec, kind string, filters map[string]any, opts ...QueryOption) (*T, error) {
+	var dest T
+	if err := e.FindOne(ctx, kind, filters, &dest, opts...); err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
@@ -0,0 +1,97 @@
+package exec_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+// ExampleGet contrasts the typed helpers with the any-based methods: no
+// destination bookkeeping, and the result type is checked at compile time.
+func ExampleGet() {
+	ctx := context.Background()
+	e := exec.NewExec()
+
+	// Before
+	var before testutil.TestUser
+	if err := e.GetByID(ctx, "users", "user1", &before); err != nil {
+		return
+	}
+
+	// After
+	user, err := exec.Get[testutil.TestUser](ctx, e, "users", "user1")
+	if err != nil {
+		return
+	}
+	fmt.Println(user.Name)
+}
+
+func ExampleFind() {
+	ctx := context.Background()
+	e := exec.NewExec()
+
+	users, err := exec.Find[testutil.TestUser](ctx, e, "users", map[string]any{"status": "active"})
+	if err != nil {
+		return
+	}
+	for _, user := range users {
+		fmt.Println(user.Email)
+	}
+}
+
+func TestGenericHelpers(t *testing.T) {
+	ctx := emulatorContext(t)
+	e := exec.NewExec()
+
+	users := testutil.CreateTestUsers()
+	ids := make([]any, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	if err := e.CreateMulti(ctx, "users", ids, users); err != nil {
+		t.Fatalf("failed to seed users: %v", err)
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		user, err := exec.Get[testutil.TestUser](ctx, e, "users", "user1")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if user.Email != "john@example.com" {
+			t.Errorf("expected email 'john@example.com', got '%s'", user.Email)
+		}
+	})
+
+	t.Run("GetMultiT keeps input order", func(t *testing.T) {
+		got, err := exec.GetMultiT[testutil.TestUser](ctx, e, "users", []any{"user3", "user1"})
+		if err != nil {
+			t.Fatalf("GetMultiT failed: %v", err)
+		}
+		if len(got) != 2 || got[0].Name != "Bob Wilson" || got[1].Name != "John Doe" {
+			t.Errorf("unexpected users: %+v", got)
+		}
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		got, err := exec.Find[testutil.TestUser](ctx, e, "users", map[string]any{"status": "active"})
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if len(got) != 3 {
+			t.Errorf("expected 3 active users, got %d", len(got))
+		}
+	})
+
+	t.Run("FindOneT", func(t *testing.T) {
+		user, err := exec.FindOneT[testutil.TestUser](ctx, e, "users", map[string]any{"email": "bob@example.com"})
+		if err != nil {
+			t.Fatalf("FindOneT failed: %v", err)
+		}
+		if user.Name != "Bob Wilson" {
+			t.Errorf("expected 'Bob Wilson', got '%s'", user.Name)
+		}
+	})
+}
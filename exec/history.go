@@ -0,0 +1,272 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+)
+
+// HistorySuffix is appended to a kind to name its history kind when
+// EnableHistory is given none
+const HistorySuffix = "_history"
+
+// EnableHistory makes Update, Patch and Delete on kind, in their single,
+// multi, by-key and TxExec forms, and the writes built on Patch (Touch,
+// SoftDelete, BulkSoftDelete and UpdateWhere), first copy the entity as
+// stored into historyKind, in the same transaction as the write. Copies are
+// keyed by the entity's key as parent and an increasing version ID, so
+// History reads them back with an ancestor query. An empty historyKind uses
+// kind + HistorySuffix.
+//
+// Keeping the copy doubles the mutations of a write, so UpdateWhere and
+// BulkSoftDelete use batches of half of MaxBatchSize on kind. Bulk deletes
+// don't keep history.
+func EnableHistory(kind, historyKind string) Option {
+	if historyKind == "" {
+		historyKind = kind + HistorySuffix
+	}
+	return func(h *Exec) {
+		// Copy the map, which Execs created by With share
+		history := make(map[string]string, len(h.history)+1)
+		for k, v := range h.history {
+			history[k] = v
+		}
+		history[kind] = historyKind
+		h.history = history
+	}
+}
+
+// HistoryEntry is a past version of an entity
+type HistoryEntry struct {
+	// Version identifies the version for RestoreVersion. Versions of an
+	// entity increase with time.
+	Version int64
+	// Key is the key of the copy in the history kind
+	Key *datastore.Key
+	// Timestamp is when the version was replaced or deleted
+	Timestamp time.Time
+	// Entity is the entity as it was
+	Entity datastore.PropertyList
+}
+
+// Load decodes the entity of e into dst, a pointer to a struct or a
+// datastore.PropertyLoadSaver
+func (e HistoryEntry) Load(dst any) error {
+	if pls, ok := dst.(datastore.PropertyLoadSaver); ok {
+		return pls.Load(e.Entity)
+	}
+	return datastore.LoadStruct(dst, e.Entity)
+}
+
+// History returns the past versions of the entity of kind stored under id,
+// newest first. A limit <= 0 returns every version. It fails for a kind
+// without EnableHistory.
+func (h *Exec) History(ctx context.Context, kind string, id any, limit int) ([]HistoryEntry, error) {
+	historyKind, err := h.historyKind(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := h.idKey(kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	q := datastore.NewQuery(historyKind).Namespace(key.Namespace).Ancestor(key).Order("-__key__")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	var entities []datastore.PropertyList
+	var keys []*datastore.Key
+	err = h.run(ctx, op{name: "History", kind: historyKind, results: func() int { return len(keys) }}, func(ctx context.Context) error {
+		var err error
+		keys, err = client.GetAll(ctx, q, &entities)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]HistoryEntry, len(keys))
+	for i, k := range keys {
+		entries[i] = HistoryEntry{
+			Version:   k.ID,
+			Key:       k,
+			Timestamp: time.UnixMicro(k.ID).UTC(),
+			Entity:    entities[i],
+		}
+	}
+	return entries, nil
+}
+
+// RestoreVersion transactionally writes version of the entity of kind
+// stored under id, as returned by History, back over the entity, which is
+// recreated if it was deleted. The entity it replaces is kept in the history
+// like for an Update, so a restore can be undone. An unknown version returns
+// an error matching ErrNotFound.
+func (h *Exec) RestoreVersion(ctx context.Context, kind string, id any, version int64) error {
+	historyKind, err := h.historyKind(kind)
+	if err != nil {
+		return err
+	}
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, err := h.idKey(kind, id)
+	if err != nil {
+		return err
+	}
+
+	versionKey := datastore.IDKey(historyKind, version, key)
+	versionKey.Namespace = key.Namespace
+
+	var restored datastore.PropertyList
+	err = h.run(ctx, op{name: "RestoreVersion", kind: kind, write: true}.on(key), func(ctx context.Context) error {
+		_, err := client.RunInTransaction(ctx, func(tx gostore.Transaction) error {
+			restored = nil
+			if err := tx.Get(versionKey, &restored); err != nil {
+				return err
+			}
+			if err := h.saveHistory(tx, []*datastore.Key{key}); err != nil {
+				return err
+			}
+			_, err := tx.Put(key, &restored)
+			return err
+		})
+		return err
+	})
+	if errors.Is(err, datastore.ErrNoSuchEntity) {
+		return fmt.Errorf("%w: version %d of %v", ErrNotFound, version, key)
+	}
+	if err != nil {
+		return err
+	}
+	return h.recordAudit(ctx, auditBatch{op: AuditUpdate, keys: []*datastore.Key{key}, after: auditEntity(restored)})
+}
+
+// historyKind returns the history kind of kind
+func (h *Exec) historyKind(kind string) (string, error) {
+	historyKind, ok := h.history[kind]
+	if !ok {
+		return "", fmt.Errorf("history is not enabled for kind %q", kind)
+	}
+	return historyKind, nil
+}
+
+// hasHistory reports whether any of keys is of a kind with history
+func (h *Exec) hasHistory(keys ...*datastore.Key) bool {
+	for _, key := range keys {
+		if _, ok := h.history[key.Kind]; ok && !key.Incomplete() {
+			return true
+		}
+	}
+	return false
+}
+
+// batchSize returns size, halved for a kind with history, whose writes
+// take twice the mutations
+func (h *Exec) batchSize(kind string, size int) int {
+	if _, ok := h.history[kind]; ok {
+		return size / 2
+	}
+	return size
+}
+
+// withHistory runs write in a transaction that first copies the entities
+// stored under keys into their history kind
+func (h *Exec) withHistory(ctx context.Context, client gostore.Client, keys []*datastore.Key, write func(tx gostore.Transaction) error) (*datastore.Commit, error) {
+	return client.RunInTransaction(ctx, func(tx gostore.Transaction) error {
+		if err := h.saveHistory(tx, keys); err != nil {
+			return err
+		}
+		return write(tx)
+	})
+}
+
+// saveHistory copies the entities stored under keys into their history kind
+// inside tx. Keys of kinds without history and missing entities are skipped.
+func (h *Exec) saveHistory(tx gostore.Transaction, keys []*datastore.Key) error {
+	var tracked []*datastore.Key
+	for _, key := range keys {
+		if h.hasHistory(key) {
+			tracked = append(tracked, key)
+		}
+	}
+	if len(tracked) == 0 {
+		return nil
+	}
+
+	entities := make([]datastore.PropertyList, len(tracked))
+	err := tx.GetMulti(tracked, entities)
+	var me datastore.MultiError
+	if err != nil && !errors.As(err, &me) {
+		return err
+	}
+
+	found := make([]bool, len(tracked))
+	for i := range tracked {
+		if me != nil && me[i] != nil {
+			if !errors.Is(me[i], datastore.ErrNoSuchEntity) {
+				return me[i]
+			}
+			continue
+		}
+		found[i] = true
+	}
+	return h.putHistory(tx, tracked, entities, found)
+}
+
+// putHistory writes the entities stored under keys, as read in tx, to their
+// history kind. Keys of kinds without history, and those not found if found
+// is set, are skipped.
+func (h *Exec) putHistory(tx gostore.Transaction, keys []*datastore.Key, entities []datastore.PropertyList, found []bool) error {
+	var versionKeys []*datastore.Key
+	var versions []datastore.PropertyList
+	for i, key := range keys {
+		if !h.hasHistory(key) || (found != nil && !found[i]) {
+			continue
+		}
+		versionKey := datastore.IDKey(h.history[key.Kind], nextVersion(), key)
+		versionKey.Namespace = key.Namespace
+		versionKeys = append(versionKeys, versionKey)
+		versions = append(versions, entities[i])
+	}
+	if len(versionKeys) == 0 {
+		return nil
+	}
+	_, err := tx.PutMulti(versionKeys, versions)
+	return err
+}
+
+// lastVersion is the last version handed out by nextVersion
+var lastVersion atomic.Int64
+
+// nextVersion returns the current time in microseconds, or one more than the
+// last version if the clock hasn't moved past it, so that versions written
+// by this process are unique and increasing
+func nextVersion() int64 {
+	for {
+		last := lastVersion.Load()
+		v := now().UnixMicro()
+		if v <= last {
+			v = last + 1
+		}
+		if lastVersion.CompareAndSwap(last, v) {
+			return v
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+
+	newExec := func(t *testing.T) (*exec.Exec, *testutil.MockDatastoreClient) {
+		t.Helper()
+		mock := testutil.NewMockClient()
+		h := exec.NewExecWithOptions(exec.WithClient(mock), exec.EnableHistory("users", ""))
+		if err := h.Create(ctx, "users", "john", &testutil.TestUser{Name: "John", Age: 30}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		return h, mock
+	}
+
+	ages := func(t *testing.T, entries []exec.HistoryEntry) []int {
+		t.Helper()
+		out := make([]int, len(entries))
+		for i, entry := range entries {
+			var user testutil.TestUser
+			if err := entry.Load(&user); err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			out[i] = user.Age
+		}
+		return out
+	}
+
+	t.Run("Updates keep every version newest first", func(t *testing.T) {
+		h, mock := newExec(t)
+
+		if len(mustHistory(t, h, 0)) != 0 {
+			t.Fatal("expected no history after a create")
+		}
+		for age := 31; age <= 33; age++ {
+			if err := h.Update(ctx, "users", "john", &testutil.TestUser{Name: "John", Age: age}); err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+		}
+
+		entries := mustHistory(t, h, 0)
+		if got := ages(t, entries); len(got) != 3 || got[0] != 32 || got[1] != 31 || got[2] != 30 {
+			t.Fatalf("expected versions aged 32, 31, 30, got %v", got)
+		}
+		for i := 1; i < len(entries); i++ {
+			if entries[i].Version >= entries[i-1].Version || entries[i].Timestamp.After(entries[i-1].Timestamp) {
+				t.Errorf("expected versions newest first, got %d before %d", entries[i-1].Version, entries[i].Version)
+			}
+		}
+		if parent := entries[0].Key.Parent; parent == nil || parent.Name != "john" || entries[0].Key.Kind != "users"+exec.HistorySuffix {
+			t.Errorf("expected a history key under users/john, got %v", entries[0].Key)
+		}
+		if n := mock.Count("users" + exec.HistorySuffix); n != 3 {
+			t.Errorf("expected 3 history entities, got %d", n)
+		}
+
+		if got := ages(t, mustHistory(t, h, 2)); len(got) != 2 || got[0] != 32 {
+			t.Errorf("expected the 2 newest versions, got %v", got)
+		}
+	})
+
+	t.Run("Patch and Delete keep the version they replace", func(t *testing.T) {
+		h, mock := newExec(t)
+
+		if err := h.Patch(ctx, "users", "john", map[string]any{"age": 40}); err != nil {
+			t.Fatalf("Patch failed: %v", err)
+		}
+		if err := h.Delete(ctx, "users", "john"); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if mock.Count("users") != 0 {
+			t.Fatal("expected the user to be deleted")
+		}
+
+		if got := ages(t, mustHistory(t, h, 0)); len(got) != 2 || got[0] != 40 || got[1] != 30 {
+			t.Errorf("expected the deleted and the patched version, got %v", got)
+		}
+	})
+
+	t.Run("Transactions keep history", func(t *testing.T) {
+		h, _ := newExec(t)
+
+		_, err := h.Transaction(ctx, func(tx *exec.TxExec) error {
+			if err := tx.Update(ctx, "users", "john", &testutil.TestUser{Name: "John", Age: 31}); err != nil {
+				return err
+			}
+			return tx.Patch(ctx, "users", "john", map[string]any{"age": 32})
+		})
+		if err != nil {
+			t.Fatalf("Transaction failed: %v", err)
+		}
+
+		// The patch reads the update made earlier in the transaction only
+		// once it commits, so both copies are of the version before it
+		if got := ages(t, mustHistory(t, h, 0)); len(got) != 2 {
+			t.Errorf("expected 2 versions, got %v", got)
+		}
+	})
+
+	t.Run("Restore brings back the chosen version", func(t *testing.T) {
+		h, _ := newExec(t)
+		for age := 31; age <= 33; age++ {
+			if err := h.Update(ctx, "users", "john", &testutil.TestUser{Name: "John", Age: age}); err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+		}
+		entries := mustHistory(t, h, 0)
+
+		// Aged 31
+		if err := h.RestoreVersion(ctx, "users", "john", entries[1].Version); err != nil {
+			t.Fatalf("RestoreVersion failed: %v", err)
+		}
+
+		var user testutil.TestUser
+		if err := h.GetByID(ctx, "users", "john", &user); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if user.Age != 31 {
+			t.Errorf("expected the restored user aged 31, got %d", user.Age)
+		}
+
+		if got := ages(t, mustHistory(t, h, 1)); len(got) != 1 || got[0] != 33 {
+			t.Errorf("expected the replaced version aged 33 kept, got %v", got)
+		}
+	})
+
+	t.Run("Restore after delete", func(t *testing.T) {
+		h, _ := newExec(t)
+		if err := h.Delete(ctx, "users", "john"); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+
+		entries := mustHistory(t, h, 1)
+		if err := h.RestoreVersion(ctx, "users", "john", entries[0].Version); err != nil {
+			t.Fatalf("RestoreVersion failed: %v", err)
+		}
+		if exists, err := h.Exists(ctx, "users", "john"); err != nil || !exists {
+			t.Errorf("expected the user to be recreated, got %v, %v", exists, err)
+		}
+	})
+
+	t.Run("Unknown version", func(t *testing.T) {
+		h, _ := newExec(t)
+		if err := h.RestoreVersion(ctx, "users", "john", 1); !errors.Is(err, exec.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Kinds without history", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		h := exec.NewExecWithOptions(exec.WithClient(mock), exec.EnableHistory("users", "user_versions"))
+
+		if err := h.Update(ctx, "orders", "o1", &testutil.TestUser{}); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if ops := mock.OperationsFor(testutil.OpRunInTransaction); len(ops) != 0 {
+			t.Errorf("expected no transaction for a kind without history, got %d", len(ops))
+		}
+		if _, err := h.History(ctx, "orders", "o1", 0); err == nil {
+			t.Error("expected an error for a kind without history")
+		}
+
+		if err := h.Update(ctx, "users", "u1", &testutil.TestUser{}); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if err := h.Update(ctx, "users", "u1", &testutil.TestUser{Age: 1}); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if n := mock.Count("user_versions"); n != 1 {
+			t.Errorf("expected 1 version in user_versions, got %d", n)
+		}
+	})
+}
+
+func mustHistory(t *testing.T, h *exec.Exec, limit int) []exec.HistoryEntry {
+	t.Helper()
+	entries, err := h.History(context.Background(), "users", "john", limit)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Key == nil || entry.Entity == nil {
+			t.Fatalf("expected a key and an entity, got %+v", entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,130 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/builder"
+)
+
+// IdempotencyKind is the kind holding the records written by CreateIdempotent.
+// A record is named after its idempotency key, under a parent key of the same
+// kind named after the kind of the entity created, so kinds never share
+// records.
+const IdempotencyKind = "_gostore_idempotency"
+
+type idempotencyRecord struct {
+	Key       *datastore.Key `datastore:"key,noindex"`
+	CreatedAt time.Time      `datastore:"created_at"`
+}
+
+// CreateIdempotent creates entity with an auto-generated ID unless a previous
+// call already did so for the same kind and idempotencyKey. The entity and a
+// record in IdempotencyKind are written in one transaction; a repeated call
+// returns the key created the first time and created=false.
+func (h *Exec) CreateIdempotent(ctx context.Context, kind string, idempotencyKey string, entity any) (*datastore.Key, bool, error) {
+	if idempotencyKey == "" {
+		return nil, false, errors.New("idempotency key is required")
+	}
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The ID is allocated up front because the record must reference the
+	// entity key inside the same transaction
+	incomplete, err := h.newKey(kind, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var entityKey *datastore.Key
+	err = h.run(ctx, op{name: "CreateIdempotent", kind: kind}, func(ctx context.Context) error {
+		allocated, err := client.AllocateIDs(ctx, []*datastore.Key{incomplete})
+		if err != nil {
+			return err
+		}
+		entityKey = allocated[0]
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := h.stampTimestamps(entity, true); err != nil {
+		return nil, false, err
+	}
+
+	recordKey := h.idempotencyRecordKey(kind, idempotencyKey)
+
+	// Left as is when the write is skipped by a dry run
+	key, created := entityKey, true
+	err = h.run(ctx, op{name: "CreateIdempotent", kind: kind, write: true}, func(ctx context.Context) error {
+		_, err := client.RunInTransaction(ctx, func(tx gostore.Transaction) error {
+			var record idempotencyRecord
+			err := tx.Get(recordKey, &record)
+			if err == nil {
+				key, created = record.Key, false
+				return nil
+			}
+			if !errors.Is(err, datastore.ErrNoSuchEntity) {
+				return err
+			}
+
+			if _, err := tx.Put(entityKey, entity); err != nil {
+				return err
+			}
+			record = idempotencyRecord{Key: entityKey, CreatedAt: now()}
+			if _, err := tx.Put(recordKey, &record); err != nil {
+				return err
+			}
+
+			key, created = entityKey, true
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("idempotent create %s: %w", kind, err)
+	}
+
+	if created {
+		if err := h.recordAudit(ctx, auditBatch{op: AuditCreate, keys: []*datastore.Key{key}, after: auditEntity(entity)}); err != nil {
+			return key, created, err
+		}
+	}
+	return key, created, nil
+}
+
+// idempotencyRecordKey returns the key of the record of idempotencyKey for
+// kind
+func (h *Exec) idempotencyRecordKey(kind, idempotencyKey string) *datastore.Key {
+	parent := datastore.NameKey(IdempotencyKind, kind, nil)
+	return h.setNamespace(datastore.NameKey(IdempotencyKind, idempotencyKey, parent))
+}
+
+// PurgeIdempotencyRecords deletes idempotency records older than olderThan,
+// after which the same idempotency keys create new entities again
+func (h *Exec) PurgeIdempotencyRecords(ctx context.Context, olderThan time.Duration) (int, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	b := h.newBuilder(IdempotencyKind).KeysOnly().
+		Filter("created_at", builder.LessThan, time.Now().Add(-olderThan))
+
+	o := op{name: "PurgeIdempotencyRecords", kind: IdempotencyKind}
+	keys, err := h.getKeys(ctx, o, client, b)
+	if err != nil {
+		return 0, err
+	}
+
+	o.write = true
+	return h.deleteKeys(ctx, o, client, keys)
+}
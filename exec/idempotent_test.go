@@ -0,0 +1,107 @@
+package exec_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type order struct {
+	Item string `datastore:"item"`
+}
+
+func TestCreateIdempotent(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	t.Run("Same key creates one entity", func(t *testing.T) {
+		first, created, err := h.CreateIdempotent(ctx, "orders", "req-1", &order{Item: "book"})
+		if err != nil {
+			t.Fatalf("CreateIdempotent failed: %v", err)
+		}
+		if !created {
+			t.Error("expected the first call to create")
+		}
+
+		second, created, err := h.CreateIdempotent(ctx, "orders", "req-1", &order{Item: "book"})
+		if err != nil {
+			t.Fatalf("CreateIdempotent failed: %v", err)
+		}
+		if created {
+			t.Error("expected the retry not to create")
+		}
+		if !second.Equal(first) {
+			t.Errorf("expected key %v, got %v", first, second)
+		}
+
+		n, err := h.Count(ctx, "orders", nil)
+		if err != nil {
+			t.Fatalf("Count failed: %v", err)
+		}
+		if n != 1 {
+			t.Errorf("expected 1 order, got %d", n)
+		}
+	})
+
+	t.Run("Different keys create two entities", func(t *testing.T) {
+		if _, _, err := h.CreateIdempotent(ctx, "orders", "req-2", &order{Item: "pen"}); err != nil {
+			t.Fatalf("CreateIdempotent failed: %v", err)
+		}
+
+		n, err := h.Count(ctx, "orders", nil)
+		if err != nil {
+			t.Fatalf("Count failed: %v", err)
+		}
+		if n != 2 {
+			t.Errorf("expected 2 orders, got %d", n)
+		}
+	})
+
+	t.Run("Purge expires records", func(t *testing.T) {
+		purged, err := h.PurgeIdempotencyRecords(ctx, -time.Minute)
+		if err != nil {
+			t.Fatalf("PurgeIdempotencyRecords failed: %v", err)
+		}
+		if purged != 2 {
+			t.Errorf("expected 2 purged records, got %d", purged)
+		}
+
+		_, created, err := h.CreateIdempotent(ctx, "orders", "req-1", &order{Item: "book"})
+		if err != nil {
+			t.Fatalf("CreateIdempotent failed: %v", err)
+		}
+		if !created {
+			t.Error("expected a purged key to create again")
+		}
+	})
+}
+
+func TestCreateIdempotentScopesKeysByKind(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+	// Joined with a separator, both pairs would read "a/b/c"
+	first, created, err := h.CreateIdempotent(ctx, "a/b", "c", &order{Item: "book"})
+	if err != nil || !created {
+		t.Fatalf("expected the first call to create, got %v, %v", created, err)
+	}
+	second, created, err := h.CreateIdempotent(ctx, "a", "b/c", &order{Item: "pen"})
+	if err != nil || !created {
+		t.Fatalf("expected another kind to create, got %v, %v", created, err)
+	}
+	if second.Kind != "a" || first.Kind != "a/b" {
+		t.Errorf("expected an entity of each kind, got %v and %v", first, second)
+	}
+
+	again, created, err := h.CreateIdempotent(ctx, "a", "b/c", &order{Item: "pen"})
+	if err != nil || created || !again.Equal(second) {
+		t.Errorf("expected the retry to return %v, got %v, %v, %v", second, again, created, err)
+	}
+	if n := mock.Count(exec.IdempotencyKind); n != 2 {
+		t.Errorf("expected 2 records, got %d", n)
+	}
+}
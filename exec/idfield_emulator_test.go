@@ -0,0 +1,111 @@
+package exec_test
+
+import (
+	"testing"
+
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type counterEntity struct {
+	ID    int64 `datastore:"-"`
+	Value int   `datastore:"value"`
+}
+
+func TestReadsPopulateID(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	users := testutil.CreateTestUsers()
+	ids := make([]any, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	if err := h.CreateMulti(ctx, "users", ids, users); err != nil {
+		t.Fatalf("CreateMulti failed: %v", err)
+	}
+
+	counterKeys, err := h.CreateMultiWithKeys(ctx, "counters", []any{nil, nil}, []counterEntity{{Value: 1}, {Value: 2}})
+	if err != nil {
+		t.Fatalf("CreateMultiWithKeys failed: %v", err)
+	}
+
+	t.Run("GetByID", func(t *testing.T) {
+		var user testutil.TestUser
+		if err := h.GetByID(ctx, "users", "user2", &user); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if user.ID != "user2" {
+			t.Errorf("expected user2, got %q", user.ID)
+		}
+
+		var counter counterEntity
+		if err := h.GetByID(ctx, "counters", counterKeys[0].ID, &counter); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if counter.ID != counterKeys[0].ID {
+			t.Errorf("expected %d, got %d", counterKeys[0].ID, counter.ID)
+		}
+	})
+
+	t.Run("GetMulti", func(t *testing.T) {
+		got := make([]testutil.TestUser, 2)
+		if err := h.GetMulti(ctx, "users", []any{"user3", "user1"}, got); err != nil {
+			t.Fatalf("GetMulti failed: %v", err)
+		}
+		if got[0].ID != "user3" || got[1].ID != "user1" {
+			t.Errorf("expected user3 and user1, got %q and %q", got[0].ID, got[1].ID)
+		}
+
+		counters := make([]*counterEntity, 2)
+		for i := range counters {
+			counters[i] = &counterEntity{}
+		}
+		if err := h.GetMultiByKeys(ctx, counterKeys, counters); err != nil {
+			t.Fatalf("GetMultiByKeys failed: %v", err)
+		}
+		for i, c := range counters {
+			if c.ID != counterKeys[i].ID {
+				t.Errorf("index %d: expected %d, got %d", i, counterKeys[i].ID, c.ID)
+			}
+		}
+	})
+
+	t.Run("Queries", func(t *testing.T) {
+		var all []testutil.TestUser
+		if err := h.FindAll(ctx, "users", &all); err != nil {
+			t.Fatalf("FindAll failed: %v", err)
+		}
+		for _, u := range all {
+			if u.ID == "" {
+				t.Errorf("expected ID to be set for %s", u.Email)
+			}
+		}
+
+		var active []testutil.TestUser
+		if err := h.FindWhere(ctx, "users", map[string]any{"email": "jane@example.com"}, &active); err != nil {
+			t.Fatalf("FindWhere failed: %v", err)
+		}
+		if len(active) != 1 || active[0].ID != "user2" {
+			t.Errorf("expected user2, got %+v", active)
+		}
+
+		var one testutil.TestUser
+		if err := h.FindOne(ctx, "users", map[string]any{"email": "bob@example.com"}, &one); err != nil {
+			t.Fatalf("FindOne failed: %v", err)
+		}
+		if one.ID != "user3" {
+			t.Errorf("expected user3, got %q", one.ID)
+		}
+
+		var counters []counterEntity
+		if err := h.FindAll(ctx, "counters", &counters); err != nil {
+			t.Fatalf("FindAll failed: %v", err)
+		}
+		for _, c := range counters {
+			if c.ID == 0 {
+				t.Errorf("expected ID to be set for counter %d", c.Value)
+			}
+		}
+	})
+}
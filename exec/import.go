@@ -0,0 +1,320 @@
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+// ImportOptions configures ImportJSONL
+type ImportOptions struct {
+	// IDField names the field holding the name or integer ID of objects
+	// without an ExportKeyField. It is not stored as a property. Objects
+	// with neither get an allocated ID.
+	IDField string
+	// TimeFields names the properties holding RFC 3339 times; a line where
+	// one does not parse is malformed. When nil, every string that parses as
+	// an RFC 3339 time becomes a time.
+	TimeFields []string
+	// BatchSize is the number of entities written per PutMulti, at most
+	// MaxBatchSize
+	BatchSize int
+	// StopOnError makes the first malformed line end the import, after the
+	// lines before it are written, instead of being skipped and reported in
+	// the *ImportError returned at the end
+	StopOnError bool
+	// OnBatch is called after every written batch with the running total
+	OnBatch func(imported int)
+}
+
+// ImportError reports the lines ImportJSONL skipped because they could not
+// be decoded into entities
+type ImportError struct {
+	Lines []LineError
+}
+
+// LineError is a malformed line of a JSON Lines import
+type LineError struct {
+	// Line is the 1-based line number
+	Line int
+	Err  error
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e LineError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ImportError) Error() string {
+	if len(e.Lines) == 1 {
+		return "skipped 1 malformed line: " + e.Lines[0].Error()
+	}
+	return fmt.Sprintf("skipped %d malformed lines, first %v", len(e.Lines), e.Lines[0])
+}
+
+func (e *ImportError) Unwrap() []error {
+	errs := make([]error, len(e.Lines))
+	for i, l := range e.Lines {
+		errs[i] = l
+	}
+	return errs
+}
+
+// ImportJSONL writes the entities read from r, JSON Lines as written by
+// ExportJSONL, to kind. Keys are rebuilt from ExportKeyField with the kind
+// replaced, so an export can be imported into another kind; parents are kept.
+// Numbers become int64 when integral and float64 otherwise, nested objects
+// entities, and strings times as set by opts.TimeFields. Entities are
+// written in batches with ctx checked between them. Malformed lines are
+// skipped and reported in an *ImportError, unless opts.StopOnError is set.
+// It returns the number of entities written.
+func (h *Exec) ImportJSONL(ctx context.Context, kind string, r io.Reader, opts ImportOptions) (int, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 || batchSize > MaxBatchSize {
+		batchSize = MaxBatchSize
+	}
+
+	imported := 0
+	var keys []*datastore.Key
+	var entities []datastore.PropertyList
+	write := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return &PartialError{Completed: imported, Err: err}
+		}
+		if err := h.waitWrites(ctx, len(keys)); err != nil {
+			return &PartialError{Completed: imported, Err: err}
+		}
+
+		stored := keys
+		err := h.run(ctx, putOp("ImportJSONL", kind, keys...), func(ctx context.Context) error {
+			var err error
+			stored, err = client.PutMulti(ctx, keys, entities)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		imported += len(keys)
+		written := entities
+		keys, entities = nil, nil
+		if opts.OnBatch != nil {
+			opts.OnBatch(imported)
+		}
+		return h.recordAudit(ctx, auditBatch{op: AuditCreate, keys: stored, after: auditEntities(written)})
+	}
+
+	var skipped []LineError
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return imported, readErr
+		}
+
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			key, props, err := h.importLine(kind, data, opts)
+			if err != nil {
+				skipped = append(skipped, LineError{Line: line, Err: err})
+				if opts.StopOnError {
+					if err := write(); err != nil {
+						return imported, err
+					}
+					return imported, &ImportError{Lines: skipped}
+				}
+			} else {
+				keys = append(keys, key)
+				entities = append(entities, props)
+			}
+		}
+
+		if len(keys) == batchSize || (readErr == io.EOF && len(keys) > 0) {
+			if err := write(); err != nil {
+				return imported, err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	if len(skipped) > 0 {
+		return imported, &ImportError{Lines: skipped}
+	}
+	return imported, nil
+}
+
+// importLine decodes one JSON object into the key and properties of an
+// entity of kind
+func (h *Exec) importLine(kind string, data []byte, opts ImportOptions) (*datastore.Key, datastore.PropertyList, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var object map[string]any
+	if err := dec.Decode(&object); err != nil {
+		return nil, nil, err
+	}
+	if object == nil {
+		return nil, nil, errors.New("expected a JSON object")
+	}
+	if dec.More() {
+		return nil, nil, errors.New("unexpected data after the JSON object")
+	}
+
+	key, err := h.importKey(kind, object, opts.IDField)
+	if err != nil {
+		return nil, nil, err
+	}
+	delete(object, ExportKeyField)
+	if opts.IDField != "" {
+		delete(object, opts.IDField)
+	}
+
+	hint := func(string) timeHint { return timeMaybe }
+	if opts.TimeFields != nil {
+		hint = func(name string) timeHint {
+			if slices.Contains(opts.TimeFields, name) {
+				return timeAlways
+			}
+			return timeNever
+		}
+	}
+	props, err := importProperties(object, hint)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, props, nil
+}
+
+// importKey builds the key of an imported object from its ExportKeyField or
+// IDField, or an incomplete key if it has neither
+func (h *Exec) importKey(kind string, object map[string]any, idField string) (*datastore.Key, error) {
+	if path, ok := object[ExportKeyField]; ok {
+		s, ok := path.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a key path string, got %v", ExportKeyField, path)
+		}
+		key, err := contextKey.ParsePath(s)
+		if err != nil {
+			return nil, err
+		}
+		return h.setNamespace(rekind(key, kind, false)), nil
+	}
+
+	if id, ok := object[idField]; ok && idField != "" {
+		key, err := h.idKey(kind, id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", idField, err)
+		}
+		return key, nil
+	}
+	return h.newKey(kind, nil)
+}
+
+// timeHint tells importValue which strings are times
+type timeHint int
+
+const (
+	timeNever timeHint = iota
+	// timeMaybe converts strings that parse as RFC 3339
+	timeMaybe
+	// timeAlways requires strings to parse as RFC 3339
+	timeAlways
+)
+
+// importProperties converts a decoded JSON object to properties, sorted by
+// name, with hint telling which of them hold times
+func importProperties(object map[string]any, hint func(name string) timeHint) (datastore.PropertyList, error) {
+	props := make(datastore.PropertyList, 0, len(object))
+	for name, value := range object {
+		v, err := importValue(value, hint(name))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		props = append(props, datastore.Property{Name: name, Value: v})
+	}
+	slices.SortFunc(props, func(a, b datastore.Property) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return props, nil
+}
+
+// importValue converts a decoded JSON value to a property value
+func importValue(v any, hint timeHint) (any, error) {
+	switch x := v.(type) {
+	case json.Number:
+		if n, err := x.Int64(); err == nil {
+			return n, nil
+		}
+		return x.Float64()
+	case string:
+		if hint == timeNever {
+			return x, nil
+		}
+		t, err := time.Parse(time.RFC3339Nano, x)
+		if err != nil && hint == timeAlways {
+			return nil, fmt.Errorf("expected an RFC 3339 time: %w", err)
+		}
+		if err != nil {
+			return x, nil
+		}
+		return t, nil
+	case []any:
+		values := make([]any, len(x))
+		for i, e := range x {
+			if _, nested := e.([]any); nested {
+				return nil, errors.New("arrays cannot contain arrays")
+			}
+			value, err := importValue(e, hint)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	case map[string]any:
+		entity := &datastore.Entity{}
+		if path, ok := x[ExportKeyField].(string); ok {
+			key, err := contextKey.ParsePath(path)
+			if err != nil {
+				return nil, err
+			}
+			entity.Key = key
+			delete(x, ExportKeyField)
+		}
+
+		// Time fields name top-level properties, so only heuristics
+		// reach into nested entities
+		nested := timeNever
+		if hint == timeMaybe {
+			nested = timeMaybe
+		}
+		props, err := importProperties(x, func(string) timeHint { return nested })
+		if err != nil {
+			return nil, err
+		}
+		entity.Properties = props
+		return entity, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,202 @@
+package exec_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestImportJSONL(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Round trip through export", func(t *testing.T) {
+		client := testutil.NewMockClient()
+		h := exec.NewExecWithOptions(exec.WithClient(client))
+
+		created := time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.UTC)
+		users := make([]testutil.TestUser, 1200)
+		for i := range users {
+			users[i] = testutil.TestUser{
+				ID:        fmt.Sprintf("user%04d", i),
+				Email:     fmt.Sprintf("user%04d@example.com", i),
+				Name:      fmt.Sprintf("User %d", i),
+				Age:       20 + i%50,
+				Status:    "active",
+				CreatedAt: created.Add(time.Duration(i) * time.Minute),
+			}
+		}
+		testutil.NewSeeder().SeedKind(ctx, t, client, "users", users)
+
+		var buf bytes.Buffer
+		if _, err := h.ExportJSONL(ctx, "users", nil, &buf, exec.ExportOptions{}); err != nil {
+			t.Fatalf("ExportJSONL failed: %v", err)
+		}
+
+		var batches []int
+		n, err := h.ImportJSONL(ctx, "users_copy", &buf, exec.ImportOptions{
+			OnBatch: func(imported int) { batches = append(batches, imported) },
+		})
+		if err != nil {
+			t.Fatalf("ImportJSONL failed: %v", err)
+		}
+		if n != 1200 {
+			t.Errorf("expected 1200 imported, got %d", n)
+		}
+		if len(batches) != 3 || batches[0] != 500 || batches[2] != 1200 {
+			t.Errorf("expected batches of at most 500, got %v", batches)
+		}
+
+		for _, u := range users {
+			var src, dst datastore.PropertyList
+			if err := client.Get(ctx, datastore.NameKey("users", u.ID, nil), &src); err != nil {
+				t.Fatalf("Get %s: %v", u.ID, err)
+			}
+			if err := client.Get(ctx, datastore.NameKey("users_copy", u.ID, nil), &dst); err != nil {
+				t.Fatalf("Get copy of %s: %v", u.ID, err)
+			}
+			if !sameProperties(src, dst) {
+				t.Fatalf("%s: expected %v, got %v", u.ID, src, dst)
+			}
+		}
+	})
+
+	t.Run("Keys from an ID field or allocated", func(t *testing.T) {
+		client := testutil.NewMockClient()
+		h := exec.NewExecWithOptions(exec.WithClient(client))
+
+		input := `{"id":"a","name":"A"}
+{"id":42,"name":"B"}
+{"name":"C"}
+`
+		n, err := h.ImportJSONL(ctx, "users", strings.NewReader(input), exec.ImportOptions{IDField: "id"})
+		if err != nil || n != 3 {
+			t.Fatalf("expected 3 imported, got %d (%v)", n, err)
+		}
+
+		var a testutil.TestUser
+		if err := client.Get(ctx, datastore.NameKey("users", "a", nil), &a); err != nil || a.Name != "A" {
+			t.Errorf("expected A under name a, got %+v (%v)", a, err)
+		}
+		var b datastore.PropertyList
+		if err := client.Get(ctx, datastore.IDKey("users", 42, nil), &b); err != nil || len(b) != 1 {
+			t.Errorf("expected B under ID 42 without the id property, got %v (%v)", b, err)
+		}
+		if n := client.Count("users"); n != 3 {
+			t.Errorf("expected 3 users, got %d", n)
+		}
+	})
+
+	t.Run("Time hints", func(t *testing.T) {
+		client := testutil.NewMockClient()
+		h := exec.NewExecWithOptions(exec.WithClient(client))
+
+		input := `{"__key__":"events/a","at":"2024-03-01T12:00:00Z","label":"2024-03-01T12:00:00Z"}`
+		if _, err := h.ImportJSONL(ctx, "events", strings.NewReader(input), exec.ImportOptions{TimeFields: []string{"at"}}); err != nil {
+			t.Fatalf("ImportJSONL failed: %v", err)
+		}
+
+		var props datastore.PropertyList
+		if err := client.Get(ctx, datastore.NameKey("events", "a", nil), &props); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		for _, p := range props {
+			_, isTime := p.Value.(time.Time)
+			if want := p.Name == "at"; isTime != want {
+				t.Errorf("%s: expected time %v, got %T", p.Name, want, p.Value)
+			}
+		}
+	})
+
+	t.Run("Malformed lines are collected", func(t *testing.T) {
+		client := testutil.NewMockClient()
+		h := exec.NewExecWithOptions(exec.WithClient(client))
+
+		input := `{"__key__":"users/a","name":"A"}
+not json
+{"__key__":"users/b","name":"B"}
+{"__key__":"users/c/d","name":"D"}
+[1, 2]
+`
+		n, err := h.ImportJSONL(ctx, "users", strings.NewReader(input), exec.ImportOptions{})
+		var importErr *exec.ImportError
+		if !errors.As(err, &importErr) {
+			t.Fatalf("expected *exec.ImportError, got %v", err)
+		}
+		var lines []int
+		for _, l := range importErr.Lines {
+			lines = append(lines, l.Line)
+		}
+		if fmt.Sprint(lines) != "[2 4 5]" {
+			t.Errorf("expected lines [2 4 5], got %v", lines)
+		}
+		if n != 2 || client.Count("users") != 2 {
+			t.Errorf("expected the 2 valid lines imported, got %d", n)
+		}
+	})
+
+	t.Run("StopOnError", func(t *testing.T) {
+		client := testutil.NewMockClient()
+		h := exec.NewExecWithOptions(exec.WithClient(client))
+
+		input := "{\"name\":\"A\"}\n{\"name\":\n{\"name\":\"C\"}\n"
+		n, err := h.ImportJSONL(ctx, "users", strings.NewReader(input), exec.ImportOptions{StopOnError: true})
+		var importErr *exec.ImportError
+		if !errors.As(err, &importErr) || len(importErr.Lines) != 1 || importErr.Lines[0].Line != 2 {
+			t.Fatalf("expected an *exec.ImportError for line 2, got %v", err)
+		}
+		if n != 1 || client.Count("users") != 1 {
+			t.Errorf("expected only the first line imported, got %d", n)
+		}
+	})
+
+	t.Run("Cancelled context stops between batches", func(t *testing.T) {
+		h := exec.NewExecWithOptions(exec.WithClient(testutil.NewMockClient()))
+
+		ctx, cancel := context.WithCancel(ctx)
+		var input strings.Builder
+		for i := 0; i < 20; i++ {
+			fmt.Fprintf(&input, "{\"__key__\":\"users/u%d\"}\n", i)
+		}
+		n, err := h.ImportJSONL(ctx, "users", strings.NewReader(input.String()), exec.ImportOptions{
+			BatchSize: 10,
+			OnBatch:   func(int) { cancel() },
+		})
+		var partial *exec.PartialError
+		if !errors.As(err, &partial) || n != 10 {
+			t.Errorf("expected a *exec.PartialError after 10, got %d (%v)", n, err)
+		}
+	})
+}
+
+// sameProperties reports whether a and b hold the same values, in any order
+func sameProperties(a, b datastore.PropertyList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	values := make(map[string]any, len(a))
+	for _, p := range a {
+		values[p.Name] = p.Value
+	}
+	for _, p := range b {
+		want, ok := values[p.Name]
+		if !ok {
+			return false
+		}
+		if t, isTime := want.(time.Time); isTime {
+			if got, ok := p.Value.(time.Time); !ok || !got.Equal(t) {
+				return false
+			}
+		} else if want != p.Value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,211 @@
+package exec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+// KindSchema describes the properties found on the entities of a kind, as
+// returned by IntrospectKind
+type KindSchema struct {
+	Kind string `json:"kind"`
+	// Sampled is the number of entities read
+	Sampled int `json:"sampled"`
+	// Properties are sorted by name
+	Properties []PropertySchema `json:"properties"`
+}
+
+// PropertySchema describes one property of a KindSchema
+type PropertySchema struct {
+	Name string `json:"name"`
+	// Types are the value types seen, sorted: "array" for lists, whose
+	// elements add their own types, "blob", "bool", "entity", "float",
+	// "geopoint", "int", "key", "null", "string" and "time"
+	Types []string `json:"types,omitempty"`
+	// Count is the number of sampled entities storing the property
+	Count int `json:"count"`
+	// Nullable is set if a sampled entity stores null or lacks the property
+	Nullable bool `json:"nullable"`
+	// Indexed and Unindexed report whether values were seen indexed and
+	// excluded from indexes
+	Indexed   bool `json:"indexed"`
+	Unindexed bool `json:"unindexed"`
+	// Representations are the index representations Datastore's
+	// __property__ metadata lists, such as "STRING" or "INT64"
+	Representations []string `json:"representations,omitempty"`
+}
+
+// propertyMetadata is an entity of the __property__ metadata kind
+type propertyMetadata struct {
+	Representations []string `datastore:"property_representation"`
+}
+
+// IntrospectKind samples up to sampleSize entities of kind, 100 if
+// sampleSize <= 0, and reports the union of their properties with the types
+// seen. Properties Datastore's __property__ metadata lists as indexed are
+// added with their representations, so indexed properties missing from the
+// sample still show up with a Count of 0. When the metadata can't be
+// queried only the sample is used.
+func (h *Exec) IntrospectKind(ctx context.Context, kind string, sampleSize int) (*KindSchema, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if sampleSize <= 0 {
+		sampleSize = defaultPageSize
+	}
+	pageSize := min(sampleSize, MaxBatchSize)
+
+	schema := &KindSchema{Kind: kind}
+	found := map[string]*PropertySchema{}
+	property := func(name string) *PropertySchema {
+		p, ok := found[name]
+		if !ok {
+			p = &PropertySchema{Name: name}
+			found[name] = p
+		}
+		return p
+	}
+
+	run := func(cursor string) cursorIterator {
+		return client.Run(ctx, h.newBuilder(kind).Limit(pageSize).Cursor(cursor).Build())
+	}
+	_, err = eachPage(ctx, "", pageSize, run, func(key *datastore.Key, entity datastore.PropertyList) error {
+		schema.Sampled++
+		seen := map[string]bool{}
+		for _, prop := range entity {
+			p := property(prop.Name)
+			if !seen[prop.Name] {
+				seen[prop.Name] = true
+				p.Count++
+			}
+			if prop.NoIndex {
+				p.Unindexed = true
+			} else {
+				p.Indexed = true
+			}
+			addValueTypes(p, prop.Value)
+		}
+
+		if schema.Sampled == sampleSize {
+			return ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata []propertyMetadata
+	keys, err := client.GetAll(ctx, h.newBuilder("__property__").Ancestor("__kind__", kind).Build(), &metadata)
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err == nil {
+		for i, key := range keys {
+			p := property(key.Name)
+			p.Indexed = true
+			p.Representations = metadata[i].Representations
+		}
+	}
+
+	for _, p := range found {
+		p.Nullable = p.Nullable || p.Count < schema.Sampled
+		slices.Sort(p.Types)
+		schema.Properties = append(schema.Properties, *p)
+	}
+	slices.SortFunc(schema.Properties, func(a, b PropertySchema) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return schema, nil
+}
+
+// addValueTypes records the type of v, and of its elements for an array
+func addValueTypes(p *PropertySchema, v any) {
+	add := func(t string) {
+		if !slices.Contains(p.Types, t) {
+			p.Types = append(p.Types, t)
+		}
+	}
+
+	switch x := v.(type) {
+	case nil:
+		add("null")
+		p.Nullable = true
+	case []any:
+		add("array")
+		for _, e := range x {
+			addValueTypes(p, e)
+		}
+	default:
+		add(valueType(v))
+	}
+}
+
+func valueType(v any) string {
+	switch v.(type) {
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case bool:
+		return "bool"
+	case string:
+		return "string"
+	case []byte:
+		return "blob"
+	case time.Time:
+		return "time"
+	case *datastore.Key:
+		return "key"
+	case datastore.GeoPoint:
+		return "geopoint"
+	case *datastore.Entity:
+		return "entity"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// String renders the schema as a table, one property per line
+func (s *KindSchema) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %d entities sampled\n", s.Kind, s.Sampled)
+
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROPERTY\tTYPES\tPRESENT\tNULLABLE\tINDEXED")
+	for _, p := range s.Properties {
+		indexed := "no"
+		switch {
+		case p.Indexed && p.Unindexed:
+			indexed = "mixed"
+		case p.Indexed:
+			indexed = "yes"
+		}
+		types := strings.Join(p.Types, ", ")
+		if types == "" {
+			types = strings.ToLower(strings.Join(p.Representations, ", "))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\t%s\n", p.Name, types, p.Count, s.Sampled, yesNo(p.Nullable), indexed)
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// JSON renders the schema as indented JSON
+func (s *KindSchema) JSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
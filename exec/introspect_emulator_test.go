@@ -0,0 +1,46 @@
+package exec_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/AndroX7/gostore/exec"
+)
+
+func TestIntrospectKindEmulator(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	if err := h.Create(ctx, "users", "a", &introspectedUser{Name: "a", Age: 30, Bio: "hi"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := h.Create(ctx, "users", "b", &introspectedAdmin{introspectedUser{Name: "b", Age: 40}, 3}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	schema, err := h.IntrospectKind(ctx, "users", 10)
+	if err != nil {
+		t.Fatalf("IntrospectKind failed: %v", err)
+	}
+	if schema.Sampled != 2 {
+		t.Errorf("expected 2 sampled, got %d", schema.Sampled)
+	}
+
+	for _, p := range schema.Properties {
+		switch p.Name {
+		case "level":
+			if p.Count != 1 || !p.Nullable {
+				t.Errorf("expected level on 1 of 2 entities, got %+v", p)
+			}
+		case "bio":
+			if !p.Unindexed {
+				t.Errorf("expected bio to be unindexed, got %+v", p)
+			}
+		case "name":
+			// Filled in from __property__ where the emulator supports it
+			if p.Representations != nil && !slices.Contains(p.Representations, "STRING") {
+				t.Errorf("expected name to be represented as a STRING, got %v", p.Representations)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package exec_test
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type introspectedUser struct {
+	Name     string    `datastore:"name"`
+	Age      any       `datastore:"age"`
+	Tags     []string  `datastore:"tags"`
+	Bio      string    `datastore:"bio,noindex"`
+	JoinedAt time.Time `datastore:"joined_at"`
+}
+
+type introspectedAdmin struct {
+	introspectedUser
+	Level int `datastore:"level"`
+}
+
+func TestIntrospectKind(t *testing.T) {
+	ctx := context.Background()
+	client := testutil.NewMockClient()
+	h := exec.NewExecWithOptions(exec.WithClient(client))
+
+	joined := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, u := range []introspectedUser{
+		{Name: "a", Age: 30, Tags: []string{"x"}, Bio: "hi", JoinedAt: joined},
+		{Name: "b", Age: 31.5, Bio: "hello", JoinedAt: joined},
+		{Name: "c", Age: nil, Bio: "hey", JoinedAt: joined},
+	} {
+		key := datastore.IDKey("users", int64(i+1), nil)
+		if _, err := client.Put(ctx, key, &u); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	admin := introspectedAdmin{introspectedUser{Name: "d", Age: 40, JoinedAt: joined}, 3}
+	if _, err := client.Put(ctx, datastore.IDKey("users", 4, nil), &admin); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	schema, err := h.IntrospectKind(ctx, "users", 10)
+	if err != nil {
+		t.Fatalf("IntrospectKind failed: %v", err)
+	}
+	if schema.Sampled != 4 {
+		t.Errorf("expected 4 sampled, got %d", schema.Sampled)
+	}
+
+	props := map[string]exec.PropertySchema{}
+	var names []string
+	for _, p := range schema.Properties {
+		props[p.Name] = p
+		names = append(names, p.Name)
+	}
+	if want := []string{"age", "bio", "joined_at", "level", "name", "tags"}; !slices.Equal(names, want) {
+		t.Fatalf("expected properties %v, got %v", want, names)
+	}
+
+	if p := props["age"]; !slices.Equal(p.Types, []string{"float", "int", "null"}) || !p.Nullable || p.Count != 4 {
+		t.Errorf("expected age to be a nullable int or float on 4 entities, got %+v", p)
+	}
+	if p := props["level"]; !slices.Equal(p.Types, []string{"int"}) || !p.Nullable || p.Count != 1 {
+		t.Errorf("expected level to be an int on 1 entity, got %+v", p)
+	}
+	if p := props["name"]; p.Nullable || !p.Indexed || p.Unindexed {
+		t.Errorf("expected name to be indexed and always present, got %+v", p)
+	}
+	if p := props["bio"]; !p.Unindexed || !slices.Equal(p.Types, []string{"string"}) {
+		t.Errorf("expected bio to be an unindexed string, got %+v", p)
+	}
+	if p := props["tags"]; !slices.Contains(p.Types, "array") || !slices.Contains(p.Types, "string") {
+		t.Errorf("expected tags to be an array of strings, got %+v", p)
+	}
+	if p := props["joined_at"]; !slices.Equal(p.Types, []string{"time"}) {
+		t.Errorf("expected joined_at to be a time, got %+v", p)
+	}
+
+	t.Run("Sample size", func(t *testing.T) {
+		schema, err := h.IntrospectKind(ctx, "users", 2)
+		if err != nil {
+			t.Fatalf("IntrospectKind failed: %v", err)
+		}
+		if schema.Sampled != 2 {
+			t.Errorf("expected 2 sampled, got %d", schema.Sampled)
+		}
+	})
+
+	t.Run("Rendering", func(t *testing.T) {
+		text := schema.String()
+		if !strings.HasPrefix(text, "users: 4 entities sampled\n") || !strings.Contains(text, "level") {
+			t.Errorf("unexpected text rendering:\n%s", text)
+		}
+
+		data, err := schema.JSON()
+		if err != nil {
+			t.Fatalf("JSON failed: %v", err)
+		}
+		var decoded exec.KindSchema
+		if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Properties) != 6 {
+			t.Errorf("expected 6 properties in JSON, got %s (%v)", data, err)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package exec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"cloud.google.com/go/datastore"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+// EncodedID is an ID given as an encoded key string (see EncodeID). Passing
+// it where an ID is expected addresses the full encoded key, including its
+// parents and namespace, while a plain string is always a key name.
+type EncodedID string
+
+// KeyPath is an ID given as a key path such as "users/123/posts/abc" (see
+// key.ParsePath). Like EncodedID it addresses the full key including parents;
+// its last kind must match the kind of the call.
+type KeyPath string
+
+// EncodeID returns the web-safe encoded form of a complete key
+func EncodeID(key *datastore.Key) string {
+	if key == nil {
+		return ""
+	}
+	return key.Encode()
+}
+
+// DecodeID parses a string produced by EncodeID
+func DecodeID(s string) (*datastore.Key, error) {
+	if s == "" {
+		return nil, errors.New("encoded key is empty")
+	}
+
+	key, err := datastore.DecodeKey(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encoded key: %w", err)
+	}
+	if key.Incomplete() {
+		return nil, errors.New("encoded key is incomplete")
+	}
+	return key, nil
+}
+
+// Key returns the complete key Exec uses for kind and id, in its namespace.
+// id may be anything accepted by GetByID.
+func (h *Exec) Key(kind string, id any) (*datastore.Key, error) {
+	return h.idKey(kind, id)
+}
+
+// Keys returns the keys Exec uses for kind and ids, in order
+func (h *Exec) Keys(kind string, ids []any) ([]*datastore.Key, error) {
+	return h.idKeys(kind, ids)
+}
+
+// idKey builds a complete key from an EncodedID, a KeyPath or any ID accepted
+// by key.NormalizeID
+func (h *Exec) idKey(kind string, id any) (*datastore.Key, error) {
+	switch v := id.(type) {
+	case EncodedID:
+		key, err := DecodeID(string(v))
+		if err != nil {
+			return nil, err
+		}
+		if err := h.checkNamespace(key); err != nil {
+			return nil, err
+		}
+		return checkKeyKind(key, kind)
+	case KeyPath:
+		key, err := contextKey.ParsePath(string(v))
+		if err != nil {
+			return nil, err
+		}
+		return checkKeyKind(h.setNamespace(key), kind)
+	}
+
+	normalized, err := contextKey.NormalizeID(id)
+	if errors.Is(err, contextKey.ErrNonNumericID) && h.jsonNumberNames {
+		normalized, err = string(id.(json.Number)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := normalized.(type) {
+	case string:
+		return h.setNamespace(datastore.NameKey(kind, v, nil)), nil
+	default:
+		return h.setNamespace(datastore.IDKey(kind, v.(int64), nil)), nil
+	}
+}
+
+// checkKeyKind returns key if it is of the expected kind
+func checkKeyKind(key *datastore.Key, kind string) (*datastore.Key, error) {
+	if key.Kind != kind {
+		return nil, fmt.Errorf("key is of kind %q, expected %q", key.Kind, kind)
+	}
+	return key, nil
+}
+
+// newKey builds a key for a write, generating a name for AutoUUID and
+// returning an incomplete key for a nil ID
+func (h *Exec) newKey(kind string, id any) (*datastore.Key, error) {
+	id, err := h.resolveID(id)
+	if err != nil {
+		return nil, err
+	}
+	if id == nil {
+		return h.setNamespace(datastore.IncompleteKey(kind, nil)), nil
+	}
+	return h.idKey(kind, id)
+}
+
+// entityKey builds the key of a write of entity like newKey, naming it after
+// the key parts of entity (see key.CompositeID) if id is nil
+func (h *Exec) entityKey(kind string, id, entity any) (*datastore.Key, error) {
+	if id == nil {
+		name, ok, err := contextKey.CompositeID(entity)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			id = name
+		}
+	}
+	return h.newKey(kind, id)
+}
+
+// entityKeys builds the keys of a multi write of the entities in v like
+// entityKey
+func (h *Exec) entityKeys(kind string, ids []any, v reflect.Value) ([]*datastore.Key, error) {
+	keys := make([]*datastore.Key, len(ids))
+	for i, id := range ids {
+		key, err := h.entityKey(kind, id, v.Index(i).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("ID at index %d: %w", i, err)
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+// idKeys builds complete keys for ids
+func (h *Exec) idKeys(kind string, ids []any) ([]*datastore.Key, error) {
+	keys := make([]*datastore.Key, len(ids))
+	for i, id := range ids {
+		key, err := h.idKey(kind, id)
+		if err != nil {
+			return nil, fmt.Errorf("ID at index %d: %w", i, err)
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+// newKeys builds keys for a multi write, with incomplete keys for nil IDs
+func (h *Exec) newKeys(kind string, ids []any) ([]*datastore.Key, error) {
+	keys := make([]*datastore.Key, len(ids))
+	for i, id := range ids {
+		key, err := h.newKey(kind, id)
+		if err != nil {
+			return nil, fmt.Errorf("ID at index %d: %w", i, err)
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
@@ -0,0 +1,187 @@
+package exec
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+)
+
+func TestEncodeID(t *testing.T) {
+	t.Run("Round trips keys with parents and namespaces", func(t *testing.T) {
+		parent := datastore.NameKey("users", "user123", nil)
+		parent.Namespace = "tenant-a"
+		child := datastore.IDKey("posts", 42, parent)
+		child.Namespace = "tenant-a"
+
+		keys := []*datastore.Key{
+			datastore.NameKey("users", "user123", nil),
+			datastore.IDKey("users", 7, nil),
+			parent,
+			child,
+		}
+
+		for _, key := range keys {
+			decoded, err := DecodeID(EncodeID(key))
+			if err != nil {
+				t.Fatalf("unexpected error decoding %v: %v", key, err)
+			}
+			if !decoded.Equal(key) {
+				t.Errorf("expected %v, got %v", key, decoded)
+			}
+		}
+	})
+
+	t.Run("Nil key encodes to empty string", func(t *testing.T) {
+		if s := EncodeID(nil); s != "" {
+			t.Errorf("expected empty string, got %q", s)
+		}
+	})
+}
+
+func TestDecodeID(t *testing.T) {
+	t.Run("Rejects empty string", func(t *testing.T) {
+		if _, err := DecodeID(""); err == nil {
+			t.Error("expected error for empty string")
+		}
+	})
+
+	t.Run("Rejects garbage", func(t *testing.T) {
+		if _, err := DecodeID("not a key!"); err == nil {
+			t.Error("expected error for invalid encoded key")
+		}
+	})
+
+	t.Run("Rejects incomplete keys", func(t *testing.T) {
+		if _, err := DecodeID(EncodeID(datastore.IncompleteKey("users", nil))); err == nil {
+			t.Error("expected error for incomplete key")
+		}
+	})
+}
+
+func TestIDKey(t *testing.T) {
+	t.Run("Plain string is always a name", func(t *testing.T) {
+		encoded := EncodeID(datastore.IDKey("users", 7, nil))
+
+		key, err := NewExec().idKey("users", encoded)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if key.Name != encoded || key.ID != 0 {
+			t.Errorf("expected name key %q, got %v", encoded, key)
+		}
+	})
+
+	t.Run("EncodedID is decoded", func(t *testing.T) {
+		parent := datastore.NameKey("users", "user123", nil)
+		want := datastore.IDKey("posts", 42, parent)
+
+		key, err := NewExec().idKey("posts", EncodedID(EncodeID(want)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !key.Equal(want) {
+			t.Errorf("expected %v, got %v", want, key)
+		}
+	})
+
+	t.Run("EncodedID of another kind is rejected", func(t *testing.T) {
+		encoded := EncodedID(EncodeID(datastore.IDKey("users", 7, nil)))
+
+		_, err := NewExec().idKey("posts", encoded)
+		if err == nil || !strings.Contains(err.Error(), "kind") {
+			t.Errorf("expected kind mismatch error, got %v", err)
+		}
+	})
+
+	t.Run("Multi keys report the failing index", func(t *testing.T) {
+		_, err := NewExec().idKeys("users", []any{"a", int64(1), 3.5})
+		if err == nil || !strings.Contains(err.Error(), "index 2") {
+			t.Errorf("expected error at index 2, got %v", err)
+		}
+	})
+
+	t.Run("Nil IDs are incomplete only for writes", func(t *testing.T) {
+		keys, err := NewExec().newKeys("users", []any{nil, "a"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !keys[0].Incomplete() || keys[1].Name != "a" {
+			t.Errorf("unexpected keys %v", keys)
+		}
+
+		if _, err := NewExec().idKey("users", nil); err == nil {
+			t.Error("expected error for nil ID on read")
+		}
+	})
+}
+
+func TestIDKeyNumericTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		id   any
+	}{
+		{"int", 42},
+		{"int32", int32(42)},
+		{"uint", uint(42)},
+		{"uint32", uint32(42)},
+		{"uint64", uint64(42)},
+		{"json.Number", json.Number("42")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := NewExec().idKey("users", tt.id)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if key.ID != 42 {
+				t.Errorf("expected ID 42, got %v", key)
+			}
+		})
+	}
+
+	t.Run("Rejects overflow and floats", func(t *testing.T) {
+		for _, id := range []any{uint64(math.MaxUint64), 42.0} {
+			if _, err := NewExec().idKey("users", id); err == nil {
+				t.Errorf("expected error for %v", id)
+			}
+		}
+	})
+
+	t.Run("Non-numeric json.Number needs WithJSONNumberNames", func(t *testing.T) {
+		if _, err := NewExec().idKey("users", json.Number("abc")); err == nil {
+			t.Error("expected error without WithJSONNumberNames")
+		}
+
+		key, err := NewExecWithOptions(WithJSONNumberNames()).idKey("users", json.Number("abc"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if key.Name != "abc" {
+			t.Errorf("expected name key 'abc', got %v", key)
+		}
+	})
+}
+
+func TestIDKeyPath(t *testing.T) {
+	t.Run("KeyPath keeps parents", func(t *testing.T) {
+		key, err := NewExec().idKey("posts", KeyPath("users/123/posts/abc"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := datastore.NameKey("posts", "abc", datastore.IDKey("users", 123, nil))
+		if !key.Equal(want) {
+			t.Errorf("expected %v, got %v", want, key)
+		}
+	})
+
+	t.Run("KeyPath of another kind is rejected", func(t *testing.T) {
+		if _, err := NewExec().idKey("users", KeyPath("users/123/posts/abc")); err == nil {
+			t.Error("expected kind mismatch error")
+		}
+	})
+}
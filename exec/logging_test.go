@@ -0,0 +1,59 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestWithLogging(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client := testutil.NewMockClient()
+	client.SetLatency(testutil.OpGet, 20*time.Millisecond)
+	h := NewExecWithOptions(WithClient(client), WithLogging(logger, 10*time.Millisecond, RedactValues()))
+
+	if err := h.Create(ctx, "users", "u1", &testutil.TestUser{Name: "A"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	var user testutil.TestUser
+	if err := h.GetByID(ctx, "users", "u1", &user); err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], "level=DEBUG") || !strings.Contains(lines[0], "op=Create") {
+		t.Errorf("expected fast Create at debug level, got %s", lines[0])
+	}
+	for _, want := range []string{"level=WARN", "gostore slow operation", "op=GetByID", "keys=1", "results=1", "threshold=10ms"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("slow GetByID log lacks %q: %s", want, lines[1])
+		}
+	}
+
+	t.Run("queries are logged redacted", func(t *testing.T) {
+		buf.Reset()
+		var users []testutil.TestUser
+		if err := h.FindWhere(ctx, "users", map[string]any{"email": "secret@example.com"}, &users); err != nil {
+			t.Fatalf("FindWhere: %v", err)
+		}
+
+		out := buf.String()
+		if !strings.Contains(out, `query="SELECT * FROM users WHERE email = ?"`) || !strings.Contains(out, "results=") {
+			t.Errorf("expected redacted query and results, got %s", out)
+		}
+		if strings.Contains(out, "secret@example.com") {
+			t.Errorf("filter value leaked into the log: %s", out)
+		}
+	})
+}
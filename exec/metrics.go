@@ -0,0 +1,52 @@
+package exec
+
+import (
+	"context"
+	"time"
+)
+
+// Metrics records the Datastore calls made by an Exec. Package
+// prometheusgostore implements it with Prometheus.
+//
+// Every call is observed once, after its last attempt, under its Exec
+// method, e.g. "GetByID". The calls making up a bulk operation are observed
+// per batch with a ".batch" suffix, e.g. "BulkCreate.batch", and the bulk
+// operation as a whole under its own name.
+type Metrics interface {
+	// ObserveOperation records a call on kind taking d, including retries,
+	// and failing with err if it is not nil
+	ObserveOperation(kind, op string, d time.Duration, err error)
+	// AddEntities records n entities read or written by a successful call
+	AddEntities(kind, op string, n int)
+}
+
+// WithMetrics records the operations of the Exec with m
+func WithMetrics(m Metrics) Option {
+	return func(h *Exec) {
+		h.metrics = m
+	}
+}
+
+// observe records o with the Exec's metrics, if any
+func (h *Exec) observe(ctx context.Context, o op, d time.Duration, err error) {
+	if h.metrics == nil {
+		return
+	}
+
+	name := o.name
+	if ctx.Value(bulkKey{}) != nil {
+		name += ".batch"
+	}
+	h.metrics.ObserveOperation(o.kind, name, d, err)
+	if err != nil {
+		return
+	}
+
+	n := o.keys
+	if o.results != nil {
+		n = o.results()
+	}
+	if n > 0 {
+		h.metrics.AddEntities(o.kind, name, n)
+	}
+}
@@ -0,0 +1,80 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AndroX7/gostore/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recordingMetrics records observations as "kind op err" and entity counts
+// by "kind op"
+type recordingMetrics struct {
+	mu       sync.Mutex
+	ops      []string
+	entities map[string]int
+}
+
+func (m *recordingMetrics) ObserveOperation(kind, op string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ops = append(m.ops, fmt.Sprintf("%s %s %v", kind, op, err != nil))
+}
+
+func (m *recordingMetrics) AddEntities(kind, op string, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entities == nil {
+		m.entities = make(map[string]int)
+	}
+	m.entities[kind+" "+op] += n
+}
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Observes a retried call once", func(t *testing.T) {
+		m := &recordingMetrics{}
+		h := NewExecWithOptions(WithMetrics(m), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+
+		calls := 0
+		unavailable := status.Error(codes.Unavailable, "unavailable")
+		if err := h.run(ctx, op{name: "GetByID", kind: "users", keys: 1}, flaky(1, unavailable, &calls)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(m.ops) != 1 || m.ops[0] != "users GetByID false" {
+			t.Errorf("expected one successful GetByID, got %v", m.ops)
+		}
+		if m.entities["users GetByID"] != 1 {
+			t.Errorf("expected 1 entity, got %v", m.entities)
+		}
+	})
+
+	t.Run("Observes bulk batches", func(t *testing.T) {
+		m := &recordingMetrics{}
+		h := NewExecWithOptions(WithClient(testutil.NewMockClient()), WithMetrics(m))
+
+		users := make([]testutil.TestUser, 25)
+		if err := h.BulkCreate(ctx, "users", users, 10); err != nil {
+			t.Fatalf("BulkCreate: %v", err)
+		}
+
+		batches := 0
+		for _, o := range m.ops {
+			if o == "users BulkCreate.batch false" {
+				batches++
+			}
+		}
+		if batches != 3 || m.ops[len(m.ops)-1] != "users BulkCreate false" {
+			t.Errorf("expected 3 batches then the bulk operation, got %v", m.ops)
+		}
+		if m.entities["users BulkCreate.batch"] != 25 {
+			t.Errorf("expected 25 entities, got %v", m.entities)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	contextKey "github.com/AndroX7/gostore/key"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestNamedClients(t *testing.T) {
+	operational, analytics := testutil.NewMockClient(), testutil.NewMockClient()
+	ctx := context.WithValue(context.Background(), contextKey.NOSQL_KEY, operational)
+	ctx = contextKey.WithNamedClient(ctx, "analytics", analytics)
+
+	h := exec.NewExec()
+	if err := h.Create(ctx, "users", "u1", &testutil.TestUser{Name: "Ada"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := h.On("analytics").Create(ctx, "events", "e1", &testutil.TestUser{Name: "signup"}); err != nil {
+		t.Fatalf("Create on analytics: %v", err)
+	}
+
+	if operational.Count("users") != 1 || operational.Count("events") != 0 {
+		t.Errorf("expected only the user in the default client, got %d users and %d events",
+			operational.Count("users"), operational.Count("events"))
+	}
+	if analytics.Count("events") != 1 || analytics.Count("users") != 0 {
+		t.Errorf("expected only the event in the analytics client, got %d users and %d events",
+			analytics.Count("users"), analytics.Count("events"))
+	}
+
+	var event testutil.TestUser
+	if err := h.On("analytics").GetByID(ctx, "events", "e1", &event); err != nil || event.Name != "signup" {
+		t.Errorf("expected the event from analytics, got %+v, %v", event, err)
+	}
+	if err := h.GetByID(ctx, "events", "e1", &event); !errors.Is(err, datastore.ErrNoSuchEntity) {
+		t.Errorf("expected the default client not to have the event, got %v", err)
+	}
+
+	configured := exec.NewExecWithOptions(exec.WithClientName("analytics"))
+	if n, err := configured.Count(ctx, "events", nil); err != nil || n != 1 {
+		t.Errorf("expected WithClientName to count 1 event, got %d, %v", n, err)
+	}
+	if n, err := configured.On("").Count(ctx, "users", nil); err != nil || n != 1 {
+		t.Errorf("expected On(\"\") to use the default client, got %d, %v", n, err)
+	}
+
+	_, err := h.On("billing").Count(ctx, "invoices", nil)
+	if !errors.Is(err, contextKey.ErrClientNotInitialized) || !strings.Contains(err.Error(), `"billing"`) {
+		t.Errorf("expected ErrClientNotInitialized naming billing, got %v", err)
+	}
+
+	pinned := exec.NewExecWithOptions(exec.WithClient(operational))
+	if n, err := pinned.On("analytics").Count(ctx, "users", nil); err != nil || n != 1 {
+		t.Errorf("expected WithClient to take precedence, got %d, %v", n, err)
+	}
+}
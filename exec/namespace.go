@@ -0,0 +1,57 @@
+package exec
+
+import (
+	"fmt"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+)
+
+// WithNamespace makes the Exec build every key and query in namespace. Keys
+// passed in whole (EncodedID, GetByKey and friends) must already be in it.
+func WithNamespace(namespace string) Option {
+	return func(h *Exec) {
+		h.namespace = namespace
+	}
+}
+
+// Namespace returns the namespace set with WithNamespace
+func (h *Exec) Namespace() string {
+	return h.namespace
+}
+
+// newBuilder starts a query on kind in the Exec's namespace
+func (h *Exec) newBuilder(kind string) *builder.Builder {
+	return builder.New().Kind(kind).Namespace(h.namespace)
+}
+
+// scopeBuilder returns b set to the Exec's namespace, copying it if needed. A
+// builder already set to another namespace is rejected.
+func (h *Exec) scopeBuilder(b *builder.Builder) (*builder.Builder, error) {
+	switch b.GetNamespace() {
+	case h.namespace:
+		return b, nil
+	case "":
+		return b.Clone().Namespace(h.namespace), nil
+	default:
+		return nil, fmt.Errorf("query namespace %q does not match %q", b.GetNamespace(), h.namespace)
+	}
+}
+
+// checkNamespace rejects a key outside the Exec's namespace
+func (h *Exec) checkNamespace(key *datastore.Key) error {
+	for k := key; k != nil; k = k.Parent {
+		if k.Namespace != h.namespace {
+			return fmt.Errorf("key %v is in namespace %q, expected %q", key, k.Namespace, h.namespace)
+		}
+	}
+	return nil
+}
+
+// setNamespace puts key and its parents in the Exec's namespace
+func (h *Exec) setNamespace(key *datastore.Key) *datastore.Key {
+	for k := key; k != nil; k = k.Parent {
+		k.Namespace = h.namespace
+	}
+	return key
+}
@@ -0,0 +1,212 @@
+package exec
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+)
+
+// op describes a Datastore call made through Exec.run
+type op struct {
+	// name is the Exec method, e.g. "GetByID"
+	name string
+	kind string
+	// write calls are skipped in dry-run mode
+	write bool
+	// once calls are never retried, e.g. writes that allocate IDs
+	once bool
+	// keys is the number of keys read or written, 0 for queries
+	keys int
+	// targets are the keys read or written, when known, named in errors
+	targets []*datastore.Key
+	// results, if set, returns the number of results of a successful read
+	results func() int
+	// query is the query run, if any
+	query *builder.Builder
+}
+
+// on returns o reading or writing keys
+func (o op) on(keys ...*datastore.Key) op {
+	o.keys = len(keys)
+	o.targets = keys
+	return o
+}
+
+// one reports a single result
+func one() int { return 1 }
+
+// WithLogger logs every Datastore call made by the Exec: successful calls at
+// debug level and failed attempts at warn level, with the operation, kind,
+// attempt and duration
+func WithLogger(l *slog.Logger) Option {
+	return func(h *Exec) {
+		h.logger = l
+	}
+}
+
+// LogOption configures WithLogging
+type LogOption func(*Exec)
+
+// RedactValues logs queries with their filter values replaced by "?"
+func RedactValues() LogOption {
+	return func(h *Exec) {
+		h.redactLogs = true
+	}
+}
+
+// WithLogging logs every Datastore call made by the Exec like WithLogger,
+// with the query run (see builder.Builder.String), the number of keys and
+// results, and the error. Successful calls taking longer than slowThreshold
+// are logged at warn level; a threshold <= 0 disables this.
+func WithLogging(l *slog.Logger, slowThreshold time.Duration, opts ...LogOption) Option {
+	return func(h *Exec) {
+		h.logger = l
+		h.slowThreshold = slowThreshold
+		for _, opt := range opts {
+			opt(h)
+		}
+	}
+}
+
+// WithQueryLogger reports to l the queries the Exec runs through the builder,
+// as by FindAll, FindWhere, FindOne, Paginate and Count, with their result
+// counts and durations (see builder.QueryLogger)
+func WithQueryLogger(l builder.QueryLogger) Option {
+	return func(h *Exec) {
+		h.queryLogger = l
+	}
+}
+
+// WithDryRun makes the Exec log and skip every write while still running
+// reads. Write methods that return keys return the keys that would have been
+// written, which are incomplete for auto-allocated IDs. Transactions run
+// their callback and are then rolled back, returning a nil Commit.
+func WithDryRun() Option {
+	return func(h *Exec) {
+		h.dryRun = true
+	}
+}
+
+// run executes fn for o, applying the operation timeout to each attempt and
+// the dry-run, tracing, metrics, retry and logging options. A query on
+// encrypted properties fails without running. Errors are returned as a
+// *gostore.Error, or a datastore.MultiError of them, naming o.
+func (h *Exec) run(ctx context.Context, o op, fn func(ctx context.Context) error) (err error) {
+	if err := h.checkEncryptedQuery(o.query); err != nil {
+		return err
+	}
+	if o.query != nil {
+		if err := o.query.Validate(); err != nil {
+			return err
+		}
+	}
+	defer func() {
+		err = opError(o, err)
+	}()
+	if h.queryLogger != nil {
+		ctx = builder.WithQueryLogger(ctx, h.queryLogger)
+	}
+
+	if o.write && h.dryRun {
+		if h.logger != nil {
+			h.logger.InfoContext(ctx, "gostore dry run: skipped write", "op", o.name, "kind", o.kind)
+		}
+		return nil
+	}
+
+	if h.tracer != nil {
+		var span Span
+		ctx, span = h.tracer.Start(ctx, Operation{
+			Name:  o.name,
+			Kind:  o.kind,
+			Keys:  o.keys,
+			Batch: ctx.Value(bulkKey{}) != nil,
+		})
+		defer func() {
+			if err == nil && o.results != nil {
+				span.SetResults(o.results())
+			}
+			span.End(err)
+		}()
+	}
+
+	if h.metrics != nil {
+		defer func(start time.Time) {
+			h.observe(ctx, o, time.Since(start), err)
+		}(time.Now())
+	}
+
+	attempts := 1
+	if !o.once {
+		attempts = h.retryPolicy.attempts()
+	}
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		opCtx, cancel := h.opContext(ctx)
+		err := fn(opCtx)
+		cancel()
+		h.logOp(ctx, o, attempt, time.Since(start), err)
+
+		if err == nil || attempt >= attempts || ctx.Err() != nil || !h.retryPolicy.retryable(err) {
+			return err
+		}
+		if sleep(ctx, h.retryPolicy.backoff(attempt)) != nil {
+			return err
+		}
+	}
+}
+
+func (h *Exec) logOp(ctx context.Context, o op, attempt int, d time.Duration, err error) {
+	if h.logger == nil {
+		return
+	}
+
+	attrs := []any{"op", o.name, "kind", o.kind, "attempt", attempt, "duration", d}
+	if o.query != nil {
+		query := o.query.String()
+		if h.redactLogs {
+			query = o.query.Redacted()
+		}
+		attrs = append(attrs, "query", query)
+	}
+	if o.keys > 0 {
+		attrs = append(attrs, "keys", o.keys)
+	}
+
+	if err != nil {
+		h.logger.WarnContext(ctx, "gostore operation failed", append(attrs, "error", err)...)
+		return
+	}
+	if o.results != nil {
+		attrs = append(attrs, "results", o.results())
+	}
+	if h.slowThreshold > 0 && d > h.slowThreshold {
+		h.logger.WarnContext(ctx, "gostore slow operation", append(attrs, "threshold", h.slowThreshold)...)
+		return
+	}
+	h.logger.DebugContext(ctx, "gostore operation", attrs...)
+}
+
+// putOp describes a Put of keys. Puts that let Datastore allocate IDs are
+// not retried.
+func putOp(name, kind string, keys ...*datastore.Key) op {
+	o := op{name: name, kind: kind, write: true}.on(keys...)
+	for _, key := range keys {
+		if key.Incomplete() {
+			o.once = true
+		}
+	}
+	return o
+}
+
+// putName names the operation writing entities for a create or an update
+func putName(create bool) string {
+	if create {
+		return "Create"
+	}
+	return "Update"
+}
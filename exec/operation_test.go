@@ -0,0 +1,163 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// flaky returns a call that fails with err for the first n calls
+func flaky(n int, err error, calls *int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		*calls++
+		if *calls <= n {
+			return err
+		}
+		return nil
+	}
+}
+
+func TestRun(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	t.Run("Retries transient errors", func(t *testing.T) {
+		h := NewExecWithOptions(WithRetryPolicy(policy))
+
+		calls := 0
+		err := h.run(context.Background(), op{name: "GetByID"}, flaky(2, unavailable, &calls))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("expected 3 calls, got %d", calls)
+		}
+	})
+
+	t.Run("Gives up after MaxAttempts", func(t *testing.T) {
+		h := NewExecWithOptions(WithRetryPolicy(policy))
+
+		calls := 0
+		err := h.run(context.Background(), op{name: "GetByID"}, flaky(5, unavailable, &calls))
+		if status.Code(err) != codes.Unavailable || calls != 3 {
+			t.Errorf("expected Unavailable after 3 calls, got %v after %d", err, calls)
+		}
+	})
+
+	t.Run("Does not retry permanent errors or once operations", func(t *testing.T) {
+		h := NewExecWithOptions(WithRetryPolicy(policy))
+
+		calls := 0
+		h.run(context.Background(), op{name: "GetByID"}, flaky(5, datastore.ErrNoSuchEntity, &calls))
+		if calls != 1 {
+			t.Errorf("expected 1 call for a permanent error, got %d", calls)
+		}
+
+		calls = 0
+		h.run(context.Background(), op{name: "Create", write: true, once: true}, flaky(5, unavailable, &calls))
+		if calls != 1 {
+			t.Errorf("expected 1 call for a once operation, got %d", calls)
+		}
+	})
+
+	t.Run("Dry run skips writes but runs reads", func(t *testing.T) {
+		h := NewExecWithOptions(WithDryRun())
+
+		calls := 0
+		if err := h.run(context.Background(), op{name: "Delete", write: true}, flaky(0, nil, &calls)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls != 0 {
+			t.Errorf("expected write to be skipped, got %d calls", calls)
+		}
+
+		h.run(context.Background(), op{name: "GetByID"}, flaky(0, nil, &calls))
+		if calls != 1 {
+			t.Errorf("expected read to run, got %d calls", calls)
+		}
+	})
+
+	t.Run("Logger records operations and failures", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		h := NewExecWithOptions(WithLogger(logger))
+
+		h.run(context.Background(), op{name: "GetByID", kind: "users"}, flaky(0, nil, new(int)))
+		h.run(context.Background(), op{name: "Delete", kind: "users"}, flaky(1, errors.New("boom"), new(int)))
+
+		out := buf.String()
+		if !strings.Contains(out, "op=GetByID kind=users") {
+			t.Errorf("expected GetByID to be logged, got %q", out)
+		}
+		if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "error=boom") {
+			t.Errorf("expected failed Delete to be logged at warn, got %q", out)
+		}
+	})
+
+	t.Run("Exec instances keep their own options", func(t *testing.T) {
+		ctx := context.Background()
+		dry := NewExecWithOptions(WithDryRun(), WithNamespace("tenant-a"))
+		live := NewExec()
+
+		calls := 0
+		dry.run(ctx, op{name: "Delete", write: true}, flaky(0, nil, &calls))
+		live.run(ctx, op{name: "Delete", write: true}, flaky(0, nil, &calls))
+		if calls != 1 {
+			t.Errorf("expected only the live Exec to write, got %d calls", calls)
+		}
+
+		dryKey, _ := dry.idKey("users", "a")
+		liveKey, _ := live.idKey("users", "a")
+		if dryKey.Namespace != "tenant-a" || liveKey.Namespace != "" {
+			t.Errorf("expected namespaces 'tenant-a' and '', got %q and %q", dryKey.Namespace, liveKey.Namespace)
+		}
+	})
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+
+	for attempt, want := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 300 * time.Millisecond,
+		4: 300 * time.Millisecond,
+	} {
+		if got := p.backoff(attempt); got != want {
+			t.Errorf("attempt %d: expected %v, got %v", attempt, want, got)
+		}
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	h := NewExecWithOptions(WithNamespace("tenant-a"))
+
+	t.Run("Keys and queries use the namespace", func(t *testing.T) {
+		key, err := h.newKey("users", nil)
+		if err != nil || key.Namespace != "tenant-a" {
+			t.Errorf("expected key in tenant-a, got %v (%v)", key, err)
+		}
+		if ns := h.newBuilder("users").GetNamespace(); ns != "tenant-a" {
+			t.Errorf("expected builder in tenant-a, got %q", ns)
+		}
+	})
+
+	t.Run("Keys from other namespaces are rejected", func(t *testing.T) {
+		other := datastore.NameKey("users", "a", nil)
+
+		if _, err := h.idKey("users", EncodedID(EncodeID(other))); err == nil {
+			t.Error("expected error for encoded key in the default namespace")
+		}
+		if err := h.checkKey(other, true); err == nil {
+			t.Error("expected error for key in the default namespace")
+		}
+	})
+}
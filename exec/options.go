@@ -0,0 +1,128 @@
+package exec
+
+import (
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/builder"
+)
+
+// DefaultDeletedAtField is the property used to mark soft-deleted entities
+const DefaultDeletedAtField = "deleted_at"
+
+// Option configures an Exec
+type Option func(*Exec)
+
+// NewExecWithOptions creates a new helper instance configured with opts
+func NewExecWithOptions(opts ...Option) *Exec {
+	h := NewExec()
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// With returns a copy of the Exec with opts applied on top of its options
+func (h *Exec) With(opts ...Option) *Exec {
+	c := *h
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
+}
+
+// WithSoftDelete makes FindAll, FindWhere, FindOne, Paginate and Count skip
+// entities whose field property is set. An empty field uses
+// DefaultDeletedAtField.
+//
+// Datastore only matches "field = nil" when the property is stored with a
+// null value, so soft-deletable entities must always carry it (e.g. a nil
+// *time.Time field).
+func WithSoftDelete(field string) Option {
+	return func(h *Exec) {
+		if field != "" {
+			h.deletedAtField = field
+		}
+		h.softDelete = true
+	}
+}
+
+// WithJSONNumberNames makes a non-integer json.Number ID address a key by
+// name instead of failing
+func WithJSONNumberNames() Option {
+	return func(h *Exec) {
+		h.jsonNumberNames = true
+	}
+}
+
+// WithClient makes the Exec use client instead of the client stored in the
+// context
+func WithClient(client gostore.Client) Option {
+	return func(h *Exec) {
+		h.client = client
+	}
+}
+
+// WithClientName makes the Exec use the client stored in the context under
+// name by key.WithNamedClient instead of the default one. A client set with
+// WithClient takes precedence.
+func WithClientName(name string) Option {
+	return func(h *Exec) {
+		h.clientName = name
+	}
+}
+
+// On returns a copy of the Exec that uses the client named name in the
+// context, e.g. h.On("analytics").GetByID(ctx, "events", id, &e), see
+// WithClientName
+func (h *Exec) On(name string) *Exec {
+	return h.With(WithClientName(name))
+}
+
+// QueryOption configures a single read call
+type QueryOption func(*queryOptions)
+
+type queryOptions struct {
+	includeDeleted bool
+	skipTotal      bool
+	scopes         []func(b *builder.Builder)
+}
+
+// IncludeDeleted controls whether soft-deleted entities are returned by reads
+// on an Exec configured with WithSoftDelete
+func IncludeDeleted(include bool) QueryOption {
+	return func(o *queryOptions) {
+		o.includeDeleted = include
+	}
+}
+
+// SkipTotal makes Paginate skip the count query it runs to fill Total
+func SkipTotal() QueryOption {
+	return func(o *queryOptions) {
+		o.skipTotal = true
+	}
+}
+
+// Scope applies fn to the query of a read before it runs, e.g. to restrict it
+// to one tenant. Scopes add to the call's filters; they are applied in the
+// order given.
+func Scope(fn func(b *builder.Builder)) QueryOption {
+	return func(o *queryOptions) {
+		o.scopes = append(o.scopes, fn)
+	}
+}
+
+func newQueryOptions(opts []QueryOption) queryOptions {
+	var o queryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// applyQueryOptions runs the scopes of o on b, then excludes soft-deleted
+// entities as applySoftDelete does
+func (h *Exec) applyQueryOptions(b *builder.Builder, o queryOptions) {
+	for _, scope := range o.scopes {
+		scope(b)
+	}
+	h.applySoftDelete(b, o)
+}
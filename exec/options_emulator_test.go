@@ -0,0 +1,70 @@
+package exec_test
+
+import (
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+)
+
+type tenantUser struct {
+	Name string `datastore:"name"`
+}
+
+func TestExecOptionsAreIndependent(t *testing.T) {
+	ctx := emulatorContext(t)
+
+	tenantA := exec.NewExecWithOptions(exec.WithNamespace("tenant-a"))
+	tenantB := exec.NewExecWithOptions(exec.WithNamespace("tenant-b"))
+	dry := exec.NewExecWithOptions(exec.WithNamespace("tenant-a"), exec.WithDryRun())
+
+	if err := tenantA.Create(ctx, "users", "user1", &tenantUser{Name: "A"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := tenantB.Create(ctx, "users", "user1", &tenantUser{Name: "B"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	t.Run("Namespaces are isolated", func(t *testing.T) {
+		var a, b tenantUser
+		if err := tenantA.GetByID(ctx, "users", "user1", &a); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if err := tenantB.GetByID(ctx, "users", "user1", &b); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if a.Name != "A" || b.Name != "B" {
+			t.Errorf("expected A and B, got %s and %s", a.Name, b.Name)
+		}
+
+		count, err := exec.NewExec().Count(ctx, "users", nil)
+		if err != nil {
+			t.Fatalf("Count failed: %v", err)
+		}
+		if count != 0 {
+			t.Errorf("expected 0 users in the default namespace, got %d", count)
+		}
+	})
+
+	t.Run("Dry run reads but does not write", func(t *testing.T) {
+		var user tenantUser
+		if err := dry.GetByID(ctx, "users", "user1", &user); err != nil || user.Name != "A" {
+			t.Fatalf("expected dry run to read A, got %q (%v)", user.Name, err)
+		}
+
+		if err := dry.Delete(ctx, "users", "user1"); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if err := dry.Create(ctx, "users", "user2", &tenantUser{Name: "C"}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		if err := tenantA.GetByID(ctx, "users", "user1", &user); err != nil {
+			t.Errorf("expected user1 to survive a dry-run delete, got %v", err)
+		}
+		if err := tenantA.GetByID(ctx, "users", "user2", &user); !errors.Is(err, datastore.ErrNoSuchEntity) {
+			t.Errorf("expected ErrNoSuchEntity for a dry-run create, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"golang.org/x/sync/errgroup"
+)
+
+// WithParallelism makes GetMulti and GetMultiByKeys look up keys in chunks
+// of MaxBatchSize with up to n lookups in flight, instead of one chunk at a
+// time. Each chunk fills its own range of dest, so results line up with the
+// keys whatever order the lookups finish in. n <= 1 looks chunks up one
+// after another.
+func WithParallelism(n int) Option {
+	return func(h *Exec) {
+		h.parallelism = n
+	}
+}
+
+// getMulti looks up keys into dest as part of o, in chunks of MaxBatchSize
+// run with the Exec's parallelism. Missing entities and other per-key errors
+// are returned as one datastore.MultiError indexed like keys; any other
+// error stops the lookups still pending and is returned as is.
+func (h *Exec) getMulti(ctx context.Context, o op, client gostore.Client, keys []*datastore.Key, dest any) error {
+	if len(keys) <= MaxBatchSize {
+		o = o.on(keys...)
+		o.results = func() int { return len(keys) }
+		return h.run(ctx, o, func(ctx context.Context) error {
+			return client.GetMulti(ctx, keys, dest)
+		})
+	}
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Slice || v.Len() != len(keys) {
+		return fmt.Errorf("dest must be a slice of length %d, got %T", len(keys), dest)
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(h.parallelism, 1))
+
+	merged := make(datastore.MultiError, len(keys))
+	for start := 0; start < len(keys); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(keys))
+		chunk := o.on(keys[start:end]...)
+		chunk.results = func() int { return end - start }
+
+		g.Go(func() error {
+			err := h.run(ctx, chunk, func(ctx context.Context) error {
+				return client.GetMulti(ctx, keys[start:end], v.Slice(start, end).Interface())
+			})
+
+			var me datastore.MultiError
+			if !errors.As(err, &me) {
+				return err
+			}
+			// Chunks own disjoint ranges of merged
+			copy(merged[start:end], me)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	for _, err := range merged {
+		if err != nil {
+			return merged
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package exec_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+// BenchmarkGetMultiParallel compares looking up 5,000 entities one chunk at
+// a time with 8 chunks in flight
+func BenchmarkGetMultiParallel(b *testing.B) {
+	ctx := emulatorContext(b)
+
+	ids := make([]any, 5000)
+	users := make([]testutil.TestUser, len(ids))
+	for i := range ids {
+		ids[i] = fmt.Sprintf("user-%04d", i)
+		users[i] = testutil.TestUser{Name: ids[i].(string), Age: i}
+	}
+	// Datastore takes at most MaxBatchSize entities per write
+	for start := 0; start < len(ids); start += exec.MaxBatchSize {
+		end := min(start+exec.MaxBatchSize, len(ids))
+		if err := exec.NewExec().CreateMulti(ctx, "users", ids[start:end], users[start:end]); err != nil {
+			b.Fatalf("CreateMulti failed: %v", err)
+		}
+	}
+
+	for _, parallelism := range []int{1, 8} {
+		h := exec.NewExecWithOptions(exec.WithParallelism(parallelism))
+		b.Run(fmt.Sprintf("Parallelism %d", parallelism), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				dest := make([]testutil.TestUser, len(ids))
+				if err := h.GetMulti(ctx, "users", ids, dest); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
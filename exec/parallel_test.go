@@ -0,0 +1,138 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+// slowFirstChunks delays each GetMulti by less than the one before, so
+// parallel chunks finish in reverse order
+type slowFirstChunks struct {
+	*testutil.MockDatastoreClient
+	calls    atomic.Int32
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (c *slowFirstChunks) GetMulti(ctx context.Context, keys []*datastore.Key, dst any) error {
+	n := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	call := c.calls.Add(1)
+	time.Sleep(time.Duration(10-call) * 5 * time.Millisecond)
+	return c.MockDatastoreClient.GetMulti(ctx, keys, dst)
+}
+
+func TestGetMultiParallel(t *testing.T) {
+	ctx := context.Background()
+
+	// 2,600 IDs in 6 chunks; every 7th was never stored
+	const n = 2600
+	ids := make([]any, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("user-%04d", i)
+	}
+	seed := func(t *testing.T) *testutil.MockDatastoreClient {
+		t.Helper()
+		mock := testutil.NewMockClient()
+		var keys []*datastore.Key
+		var users []testutil.TestUser
+		for i := range ids {
+			if i%7 == 3 {
+				continue
+			}
+			keys = append(keys, datastore.NameKey("users", ids[i].(string), nil))
+			users = append(users, testutil.TestUser{Name: ids[i].(string), Age: i})
+		}
+		if _, err := testutil.PutInBatches(ctx, mock, keys, users); err != nil {
+			t.Fatalf("PutInBatches failed: %v", err)
+		}
+		return mock
+	}
+
+	for _, parallelism := range []int{0, 1, 4, 8} {
+		t.Run(fmt.Sprintf("Parallelism %d", parallelism), func(t *testing.T) {
+			client := &slowFirstChunks{MockDatastoreClient: seed(t)}
+			h := exec.NewExecWithOptions(exec.WithClient(client), exec.WithParallelism(parallelism))
+
+			users := make([]testutil.TestUser, n)
+			err := h.GetMulti(ctx, "users", ids, users)
+
+			var me datastore.MultiError
+			if !errors.As(err, &me) || len(me) != n {
+				t.Fatalf("expected a MultiError of %d, got %v", n, err)
+			}
+			for i := range ids {
+				missing := i%7 == 3
+				if missing != errors.Is(me[i], datastore.ErrNoSuchEntity) {
+					t.Fatalf("index %d: expected missing=%v, got %v", i, missing, me[i])
+				}
+				if !missing && (users[i].Name != ids[i] || users[i].Age != i) {
+					t.Fatalf("index %d: expected %s, got %+v", i, ids[i], users[i])
+				}
+			}
+
+			want := int32(min(max(parallelism, 1), 6))
+			if got := client.peak.Load(); got != want {
+				t.Errorf("expected %d lookups in flight at most, got %d", want, got)
+			}
+		})
+	}
+
+	t.Run("By keys into pointers", func(t *testing.T) {
+		mock := seed(t)
+		h := exec.NewExecWithOptions(exec.WithClient(mock), exec.WithParallelism(8))
+
+		keys := make([]*datastore.Key, 0, n)
+		for i := range ids {
+			if i%7 != 3 {
+				keys = append(keys, datastore.NameKey("users", ids[i].(string), nil))
+			}
+		}
+		users := make([]*testutil.TestUser, len(keys))
+		for i := range users {
+			users[i] = &testutil.TestUser{}
+		}
+		if err := h.GetMultiByKeys(ctx, keys, users); err != nil {
+			t.Fatalf("GetMultiByKeys failed: %v", err)
+		}
+		for i, user := range users {
+			if user == nil || user.Name != keys[i].Name {
+				t.Fatalf("index %d: expected %s, got %+v", i, keys[i].Name, user)
+			}
+		}
+	})
+
+	t.Run("Call errors stop the lookup", func(t *testing.T) {
+		mock := seed(t)
+		errBoom := errors.New("boom")
+		mock.FailNext(testutil.OpGetMulti, errBoom, 1)
+		h := exec.NewExecWithOptions(exec.WithClient(mock), exec.WithParallelism(4))
+
+		err := h.GetMulti(ctx, "users", ids, make([]testutil.TestUser, n))
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected the call error, got %v", err)
+		}
+	})
+
+	t.Run("Dest length must match", func(t *testing.T) {
+		h := exec.NewExecWithOptions(exec.WithClient(seed(t)), exec.WithParallelism(4))
+		if err := h.GetMulti(ctx, "users", ids, make([]testutil.TestUser, 10)); err == nil {
+			t.Error("expected an error for a short dest")
+		}
+	})
+}
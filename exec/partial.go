@@ -0,0 +1,41 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+)
+
+// PartialError reports a bulk operation that stopped part way, typically
+// because its context was cancelled between batches
+type PartialError struct {
+	// Completed is the number of items processed before the operation stopped
+	Completed int
+	Err       error
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("stopped after %d items: %v", e.Completed, e.Err)
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// inBatches calls fn for consecutive [start, end) ranges of at most size out
+// of n items, checking ctx before each batch. It returns the number of items
+// in the batches that succeeded.
+func inBatches(ctx context.Context, n, size int, fn func(start, end int) error) (int, error) {
+	done := 0
+	for start := 0; start < n; start += size {
+		if err := ctx.Err(); err != nil {
+			return done, &PartialError{Completed: done, Err: err}
+		}
+
+		end := min(start+size, n)
+		if err := fn(start, end); err != nil {
+			return done, err
+		}
+		done = end
+	}
+	return done, nil
+}
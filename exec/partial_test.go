@@ -0,0 +1,92 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+)
+
+func TestBulkCreateCancellation(t *testing.T) {
+	t.Run("Stops after the batch that saw the cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var stored []string
+		fail := false
+		inner := failingPut(&stored, 0, &fail)
+		batches := 0
+		put := func(ctx context.Context, keys []*datastore.Key, src any) ([]*datastore.Key, error) {
+			batches++
+			if batches == 2 {
+				cancel()
+			}
+			return inner(ctx, keys, src)
+		}
+
+		err := bulkCreate(ctx, "users", bulkUsers(60), 10, BulkOptions{}, put)
+
+		var partial *PartialError
+		if !errors.As(err, &partial) {
+			t.Fatalf("expected *PartialError, got %v", err)
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if partial.Completed != 20 {
+			t.Errorf("expected 20 completed, got %d", partial.Completed)
+		}
+		if batches != 2 || len(stored) != 20 {
+			t.Errorf("expected 2 batches with 20 entities, got %d batches with %d", batches, len(stored))
+		}
+	})
+}
+
+func TestInBatches(t *testing.T) {
+	t.Run("Checks the context before every batch", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var ranges [][2]int
+		done, err := inBatches(ctx, 60, 10, func(start, end int) error {
+			ranges = append(ranges, [2]int{start, end})
+			if len(ranges) == 2 {
+				cancel()
+			}
+			return nil
+		})
+
+		var partial *PartialError
+		if !errors.As(err, &partial) || partial.Completed != 20 {
+			t.Fatalf("expected *PartialError with 20 completed, got %v", err)
+		}
+		if done != 20 || len(ranges) != 2 {
+			t.Errorf("expected 2 batches and 20 done, got %v and %d", ranges, done)
+		}
+	})
+
+	t.Run("Batch errors are returned unwrapped", func(t *testing.T) {
+		boom := errors.New("boom")
+		done, err := inBatches(context.Background(), 25, 10, func(start, end int) error {
+			if start == 10 {
+				return boom
+			}
+			return nil
+		})
+		if err != boom || done != 10 {
+			t.Errorf("expected boom after 10, got %v after %d", err, done)
+		}
+	})
+
+	t.Run("Last batch is short", func(t *testing.T) {
+		var last [2]int
+		done, err := inBatches(context.Background(), 25, 10, func(start, end int) error {
+			last = [2]int{start, end}
+			return nil
+		})
+		if err != nil || done != 25 || last != [2]int{20, 25} {
+			t.Errorf("expected 25 done ending with [20 25], got %d, %v, %v", done, last, err)
+		}
+	})
+}
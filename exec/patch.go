@@ -0,0 +1,204 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+)
+
+// Patch sets the given properties on an existing entity without touching the
+// others. Keys of changes may be dotted paths into nested entities
+// ("address.city"). The read and write happen in one transaction.
+func (h *Exec) Patch(ctx context.Context, kind string, id any, changes map[string]any) error {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, err := h.idKey(kind, id)
+	if err != nil {
+		return err
+	}
+
+	changes = h.withUpdatedAt(changes)
+
+	var pending *auditBatch
+	err = h.run(ctx, op{name: "Patch", kind: kind, write: true}.on(key), func(ctx context.Context) error {
+		var err error
+		pending, err = h.patchTx(ctx, client, []*datastore.Key{key}, changes)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return h.recordPending(ctx, pending)
+}
+
+// patchTx patches keys in a transaction of its own. With an audit hook it
+// returns the audit of the patch, unless it was recorded in the
+// transaction.
+func (h *Exec) patchTx(ctx context.Context, client gostore.Client, keys []*datastore.Key, changes map[string]any) (*auditBatch, error) {
+	var pending *auditBatch
+	_, err := client.RunInTransaction(ctx, func(tx gostore.Transaction) error {
+		pending = nil
+		before, after, err := h.patchEntities(tx, keys, changes, h.audit != nil)
+		if err != nil || h.audit == nil {
+			return err
+		}
+
+		b := auditBatch{op: AuditPatch, keys: keys, before: auditProps(before), after: auditProps(after)}
+		recorded, err := h.recordAuditInTx(ctx, tx, b)
+		if !recorded {
+			pending = &b
+		}
+		return err
+	})
+	return pending, err
+}
+
+// recordPending records b, if any, once its transaction committed
+func (h *Exec) recordPending(ctx context.Context, b *auditBatch) error {
+	if b == nil {
+		return nil
+	}
+	return h.recordAudit(ctx, *b)
+}
+
+// patchEntities loads keys, applies changes to each entity and writes them
+// back, keeping the history of kinds with one. It returns the entities as
+// they were and as written if snapshot is set.
+func (h *Exec) patchEntities(tx gostore.Transaction, keys []*datastore.Key, changes map[string]any, snapshot bool) (before, after []datastore.PropertyList, err error) {
+	entities := make([]datastore.PropertyList, len(keys))
+	if err := tx.GetMulti(keys, entities); err != nil {
+		if me, ok := err.(datastore.MultiError); ok && len(keys) == 1 {
+			return nil, nil, me[0]
+		}
+		return nil, nil, err
+	}
+
+	history := h.hasHistory(keys...)
+	if snapshot || history {
+		before = make([]datastore.PropertyList, len(entities))
+		for i, props := range entities {
+			before[i] = cloneProps(props)
+		}
+	}
+	if history {
+		if err := h.putHistory(tx, keys, before, nil); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for i := range entities {
+		if err := applyChanges(&entities[i], changes); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if _, err := tx.PutMulti(keys, entities); err != nil {
+		return nil, nil, err
+	}
+	if !snapshot {
+		return nil, nil, nil
+	}
+	return before, entities, nil
+}
+
+// cloneProps copies props deeply enough for applyChanges, which modifies
+// nested entities in place, to leave the copy alone
+func cloneProps(props datastore.PropertyList) datastore.PropertyList {
+	out := make(datastore.PropertyList, len(props))
+	copy(out, props)
+	for i, p := range out {
+		if entity, ok := p.Value.(*datastore.Entity); ok && entity != nil {
+			nested := *entity
+			nested.Properties = cloneProps(entity.Properties)
+			out[i].Value = &nested
+		}
+	}
+	return out
+}
+
+// applyChanges sets every change on props, creating nested entities for
+// dotted paths that don't exist yet
+func applyChanges(props *datastore.PropertyList, changes map[string]any) error {
+	for path, value := range changes {
+		if path == "" {
+			return fmt.Errorf("empty property path")
+		}
+		if err := setProperty(props, path, normalizeValue(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setProperty(props *datastore.PropertyList, path string, value any) error {
+	// Flattened struct fields are stored under their full dotted name
+	for i := range *props {
+		if (*props)[i].Name == path {
+			(*props)[i].Value = value
+			return nil
+		}
+	}
+
+	name, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		*props = append(*props, datastore.Property{Name: name, Value: value})
+		return nil
+	}
+
+	for i := range *props {
+		if (*props)[i].Name != name {
+			continue
+		}
+
+		entity, ok := (*props)[i].Value.(*datastore.Entity)
+		if !ok {
+			return fmt.Errorf("property %q is not an entity", name)
+		}
+		list := datastore.PropertyList(entity.Properties)
+		if err := setProperty(&list, rest, value); err != nil {
+			return err
+		}
+		entity.Properties = list
+		return nil
+	}
+
+	var list datastore.PropertyList
+	if err := setProperty(&list, rest, value); err != nil {
+		return err
+	}
+	*props = append(*props, datastore.Property{
+		Name:  name,
+		Value: &datastore.Entity{Properties: list},
+	})
+	return nil
+}
+
+// normalizeValue converts Go values that Datastore can't store directly into
+// their property equivalents
+func normalizeValue(value any) any {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	}
+	return value
+}
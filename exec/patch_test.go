@@ -0,0 +1,88 @@
+package exec
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+)
+
+func TestApplyChanges(t *testing.T) {
+	t.Run("Replaces existing and appends new properties", func(t *testing.T) {
+		props := datastore.PropertyList{
+			{Name: "name", Value: "John"},
+			{Name: "bio", Value: "long text", NoIndex: true},
+		}
+
+		err := applyChanges(&props, map[string]any{"name": "Jane", "age": 30})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(props) != 3 {
+			t.Fatalf("expected 3 properties, got %d", len(props))
+		}
+		if props[0].Value != "Jane" {
+			t.Errorf("expected name 'Jane', got '%v'", props[0].Value)
+		}
+		if !props[1].NoIndex || props[1].Value != "long text" {
+			t.Errorf("expected bio to be untouched, got %+v", props[1])
+		}
+		if props[2].Value != int64(30) {
+			t.Errorf("expected age to be stored as int64 30, got %T %v", props[2].Value, props[2].Value)
+		}
+	})
+
+	t.Run("Dotted paths reach into nested entities", func(t *testing.T) {
+		props := datastore.PropertyList{
+			{Name: "address", Value: &datastore.Entity{Properties: []datastore.Property{
+				{Name: "city", Value: "Jakarta"},
+				{Name: "zip", Value: "10110"},
+			}}},
+		}
+
+		err := applyChanges(&props, map[string]any{"address.city": "Bandung", "meta.source": "import"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		address := props[0].Value.(*datastore.Entity)
+		if address.Properties[0].Value != "Bandung" {
+			t.Errorf("expected city 'Bandung', got '%v'", address.Properties[0].Value)
+		}
+		if address.Properties[1].Value != "10110" {
+			t.Errorf("expected zip to be untouched, got '%v'", address.Properties[1].Value)
+		}
+
+		meta, ok := props[1].Value.(*datastore.Entity)
+		if !ok || meta.Properties[0].Name != "source" {
+			t.Errorf("expected nested meta entity to be created, got %+v", props[1])
+		}
+	})
+
+	t.Run("Flattened properties match their full name", func(t *testing.T) {
+		props := datastore.PropertyList{{Name: "address.city", Value: "Jakarta"}}
+
+		if err := applyChanges(&props, map[string]any{"address.city": "Bandung"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(props) != 1 || props[0].Value != "Bandung" {
+			t.Errorf("expected flattened property to be replaced, got %+v", props)
+		}
+	})
+
+	t.Run("Path through a non-entity property", func(t *testing.T) {
+		props := datastore.PropertyList{{Name: "address", Value: "Jakarta"}}
+
+		if err := applyChanges(&props, map[string]any{"address.city": "Bandung"}); err == nil {
+			t.Error("expected error when traversing a scalar property")
+		}
+	})
+}
+
+func TestUpdateWhereRequiresChanges(t *testing.T) {
+	h := NewExec()
+	if _, err := h.UpdateWhere(context.Background(), "users", nil, nil); err == nil {
+		t.Error("expected an error without changes")
+	}
+}
@@ -0,0 +1,55 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/AndroX7/gostore/builder"
+	contextKey "github.com/AndroX7/gostore/key"
+	"google.golang.org/api/iterator"
+)
+
+// GetProjection retrieves only the given fields of an entity, using a
+// projection query on its key. Fields left out of the projection keep their
+// zero values in dest.
+//
+// Datastore serves projections from indexes, so every projected field must be
+// indexed (not tagged noindex); an entity whose projected fields are not
+// indexed is reported as ErrNotFound.
+func (h *Exec) GetProjection(ctx context.Context, kind string, id any, fields []string, dest any) error {
+	if err := checkDest(dest); err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return errors.New("at least one projection field is required")
+	}
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, err := h.idKey(kind, id)
+	if err != nil {
+		return err
+	}
+
+	b := h.newBuilder(kind).
+		Filter("__key__", builder.Equal, key).
+		Select(fields...).
+		Limit(1)
+
+	err = h.run(ctx, op{name: "GetProjection", kind: kind}, func(ctx context.Context) error {
+		_, err := client.Run(ctx, b.Build()).Next(dest)
+		return err
+	})
+	if errors.Is(err, iterator.Done) {
+		return fmt.Errorf("%w: %s %v", ErrNotFound, kind, key)
+	}
+	if err != nil {
+		return err
+	}
+	contextKey.SetID(dest, key)
+	return nil
+}
@@ -0,0 +1,93 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestGetProjection(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	users := testutil.CreateTestUsers()
+	if err := h.Create(ctx, "users", users[0].ID, &users[0]); err != nil {
+		t.Fatalf("failed to seed user: %v", err)
+	}
+
+	t.Run("Projected fields match a full Get", func(t *testing.T) {
+		var full testutil.TestUser
+		if err := h.GetByID(ctx, "users", "user1", &full); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+
+		var projected testutil.TestUser
+		if err := h.GetProjection(ctx, "users", "user1", []string{"status", "created_at"}, &projected); err != nil {
+			t.Fatalf("GetProjection failed: %v", err)
+		}
+
+		if projected.Status != full.Status {
+			t.Errorf("expected status '%s', got '%s'", full.Status, projected.Status)
+		}
+		if !projected.CreatedAt.Equal(full.CreatedAt) {
+			t.Errorf("expected created_at %v, got %v", full.CreatedAt, projected.CreatedAt)
+		}
+		if projected.Email != "" || projected.Name != "" {
+			t.Errorf("expected unprojected fields to stay empty, got %+v", projected)
+		}
+	})
+
+	t.Run("Missing entity", func(t *testing.T) {
+		var projected testutil.TestUser
+		err := h.GetProjection(ctx, "users", "nobody", []string{"status"}, &projected)
+		if !errors.Is(err, exec.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestGetProjectionWithMock(t *testing.T) {
+	ctx := context.Background()
+	h := exec.NewExecWithOptions(exec.WithClient(testutil.NewMockClient()))
+
+	user := testutil.CreateTestUsers()[0]
+	if err := h.Create(ctx, "users", user.ID, &user); err != nil {
+		t.Fatalf("failed to seed user: %v", err)
+	}
+
+	t.Run("Only projected fields are loaded", func(t *testing.T) {
+		var projected testutil.TestUser
+		if err := h.GetProjection(ctx, "users", user.ID, []string{"status", "created_at"}, &projected); err != nil {
+			t.Fatalf("GetProjection failed: %v", err)
+		}
+		if projected.Status != user.Status || !projected.CreatedAt.Equal(user.CreatedAt) {
+			t.Errorf("expected status %q and created_at %v, got %+v", user.Status, user.CreatedAt, projected)
+		}
+		if projected.Email != "" || projected.Name != "" || projected.Age != 0 {
+			t.Errorf("expected unprojected fields to stay empty, got %+v", projected)
+		}
+	})
+
+	t.Run("Property missing from the entity", func(t *testing.T) {
+		var projected testutil.TestUser
+		err := h.GetProjection(ctx, "users", user.ID, []string{"nickname"}, &projected)
+		if !errors.Is(err, exec.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Property missing from the destination", func(t *testing.T) {
+		var projected struct {
+			Name string `datastore:"name"`
+		}
+		err := h.GetProjection(ctx, "users", user.ID, []string{"email"}, &projected)
+		var mismatch *datastore.ErrFieldMismatch
+		if !errors.As(err, &mismatch) {
+			t.Errorf("expected *datastore.ErrFieldMismatch, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndroX7/gostore/builder"
+)
+
+// PurgeOptions configures PurgeOlderThan
+type PurgeOptions struct {
+	// PageSize is the number of keys fetched at a time, DefaultDeletePageSize
+	// if <= 0. Each page is deleted in batches of at most MaxBatchSize.
+	PageSize int
+
+	// MaxDelete, if > 0, stops the purge once that many entities are
+	// deleted, as a safety cap on a misconfigured field or duration
+	MaxDelete int
+
+	// RateLimit, if > 0, paces deletes to that many entities a second like
+	// WithWriteRateLimit, instead of the Exec's own limit
+	RateLimit float64
+
+	// DryRun counts the stale entities, up to MaxDelete, without deleting
+	// them, like an Exec with WithDryRun
+	DryRun bool
+
+	// Cursor resumes a purge from the cursor an earlier one returned, with
+	// the cutoff time of that purge
+	Cursor string
+
+	// OnPage is called after every page with the number of entities deleted
+	// so far
+	OnPage func(deleted int)
+}
+
+// PurgeOlderThan deletes the entities of kind whose timeField property is
+// more than olderThan in the past, such as expired sessions or tokens, and
+// returns the number deleted. Stale keys are queried a page at a time,
+// oldest first, which needs a single-property index on timeField.
+//
+// The returned cursor is empty once no stale entity is left. When MaxDelete
+// stops the purge early, passing it as opts.Cursor resumes the purge with
+// the same cutoff, so a scheduler can spread a large backlog over several
+// runs. Pages already deleted stay deleted
+// when a later one fails or ctx is cancelled; the error is then a
+// *PartialError with the number deleted.
+func (h *Exec) PurgeOlderThan(ctx context.Context, kind, timeField string, olderThan time.Duration, opts PurgeOptions) (n int, cursor string, err error) {
+	ctx, finish := h.startBulk(ctx, "PurgeOlderThan", kind)
+	defer func() { finish(n, err) }()
+
+	if timeField == "" {
+		return 0, "", errors.New("time field is required")
+	}
+	if olderThan <= 0 {
+		return 0, "", errors.New("olderThan must be positive")
+	}
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if opts.RateLimit > 0 {
+		h = h.With(WithWriteRateLimit(opts.RateLimit))
+	}
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultDeletePageSize
+	}
+	dryRun := opts.DryRun || h.dryRun
+
+	cutoff := now().Add(-olderThan)
+	var start string
+	if opts.Cursor != "" {
+		if cutoff, start, err = decodePurgeCursor(opts.Cursor); err != nil {
+			return 0, "", err
+		}
+	}
+
+	b := h.newBuilder(kind).KeysOnly().
+		Filter(timeField, builder.LessThan, cutoff).
+		OrderAsc(timeField)
+
+	o := op{name: "PurgeOlderThan", kind: kind}
+	cursor = opts.Cursor
+	from := start
+	for {
+		limit := pageSize
+		if opts.MaxDelete > 0 {
+			limit = min(limit, opts.MaxDelete-n)
+		}
+
+		keys, next, err := h.keysPage(ctx, o, client, b.Clone().Limit(limit), from)
+		if err != nil {
+			return n, cursor, partial(n, err)
+		}
+
+		if dryRun {
+			n += len(keys)
+		} else {
+			deleted, err := h.deleteKeys(ctx, o, client, keys)
+			n += deleted
+			if err != nil {
+				return n, cursor, partial(n, err)
+			}
+		}
+		// Deleted entities drop out of the query, so while deleting every
+		// page is read from where the purge started
+		if dryRun {
+			from = next
+		}
+		cursor = encodePurgeCursor(cutoff, from)
+		if opts.OnPage != nil {
+			opts.OnPage(n)
+		}
+
+		if len(keys) < limit {
+			return n, "", nil
+		}
+		if opts.MaxDelete > 0 && n >= opts.MaxDelete {
+			return n, cursor, nil
+		}
+	}
+}
+
+// partial returns err as a *PartialError of n completed, replacing the
+// count of one reporting the progress of a single page
+func partial(n int, err error) error {
+	var pe *PartialError
+	if errors.As(err, &pe) {
+		err = pe.Err
+	} else if n == 0 {
+		return err
+	}
+	return &PartialError{Completed: n, Err: err}
+}
+
+// encodePurgeCursor returns the cursor of a purge with cutoff, resuming its
+// query from cursor
+func encodePurgeCursor(cutoff time.Time, cursor string) string {
+	return strconv.FormatInt(cutoff.UnixMicro(), 10) + "." + cursor
+}
+
+// decodePurgeCursor returns the cutoff and query cursor of a purge cursor
+func decodePurgeCursor(s string) (time.Time, string, error) {
+	micros, cursor, ok := strings.Cut(s, ".")
+	us, err := strconv.ParseInt(micros, 10, 64)
+	if !ok || err != nil {
+		return time.Time{}, "", fmt.Errorf("invalid purge cursor %q", s)
+	}
+	return time.UnixMicro(us).UTC(), cursor, nil
+}
@@ -0,0 +1,160 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type session struct {
+	Token     string    `datastore:"token"`
+	CreatedAt time.Time `datastore:"created_at"`
+}
+
+func TestPurgeOlderThan(t *testing.T) {
+	ctx := context.Background()
+
+	// seed stores one session per hour of age, from 0 to hours-1 hours old
+	seed := func(t *testing.T, hours int) *testutil.MockDatastoreClient {
+		t.Helper()
+		mock := testutil.NewMockClient()
+		now := time.Now().UTC()
+		keys := make([]*datastore.Key, hours)
+		sessions := make([]session, hours)
+		for i := range keys {
+			keys[i] = datastore.NameKey("sessions", fmt.Sprintf("s%03d", i), nil)
+			sessions[i] = session{Token: keys[i].Name, CreatedAt: now.Add(-time.Duration(i)*time.Hour - time.Minute)}
+		}
+		if _, err := mock.PutMulti(ctx, keys, sessions); err != nil {
+			t.Fatalf("PutMulti failed: %v", err)
+		}
+		mock.ResetOperations()
+		return mock
+	}
+
+	remaining := func(t *testing.T, mock *testutil.MockDatastoreClient) []session {
+		t.Helper()
+		var left []session
+		if _, err := mock.GetAll(ctx, datastore.NewQuery("sessions"), &left); err != nil {
+			t.Fatalf("GetAll failed: %v", err)
+		}
+		return left
+	}
+
+	t.Run("Deletes only the stale entities", func(t *testing.T) {
+		mock := seed(t, 72)
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+		pages := 0
+		n, cursor, err := h.PurgeOlderThan(ctx, "sessions", "created_at", 24*time.Hour, exec.PurgeOptions{
+			PageSize: 10,
+			OnPage:   func(int) { pages++ },
+		})
+		if err != nil || n != 48 {
+			t.Fatalf("expected 48 purged, got %d, %v", n, err)
+		}
+		if cursor != "" {
+			t.Errorf("expected no cursor once done, got %q", cursor)
+		}
+		if pages != 5 {
+			t.Errorf("expected 5 pages, got %d", pages)
+		}
+
+		left := remaining(t, mock)
+		if len(left) != 24 {
+			t.Fatalf("expected 24 sessions left, got %d", len(left))
+		}
+		cutoff := time.Now().Add(-24 * time.Hour)
+		for _, s := range left {
+			if s.CreatedAt.Before(cutoff) {
+				t.Errorf("expected stale session %s to be purged", s.Token)
+			}
+		}
+
+		for _, op := range mock.OperationsFor(testutil.OpDeleteMulti) {
+			if op.Count > exec.MaxBatchSize {
+				t.Errorf("expected batches of at most %d, got %d", exec.MaxBatchSize, op.Count)
+			}
+		}
+	})
+
+	t.Run("MaxDelete stops early with a cursor to resume", func(t *testing.T) {
+		mock := seed(t, 72)
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+		opts := exec.PurgeOptions{PageSize: 10, MaxDelete: 25}
+		n, cursor, err := h.PurgeOlderThan(ctx, "sessions", "created_at", 24*time.Hour, opts)
+		if err != nil || n != 25 {
+			t.Fatalf("expected 25 purged, got %d, %v", n, err)
+		}
+		if cursor == "" {
+			t.Fatal("expected a cursor to resume from")
+		}
+		if left := len(remaining(t, mock)); left != 47 {
+			t.Errorf("expected 47 sessions left, got %d", left)
+		}
+
+		opts.Cursor = cursor
+		n, cursor, err = h.PurgeOlderThan(ctx, "sessions", "created_at", 24*time.Hour, opts)
+		if err != nil || n != 23 || cursor != "" {
+			t.Fatalf("expected the other 23 purged, got %d, %q, %v", n, cursor, err)
+		}
+		if left := len(remaining(t, mock)); left != 24 {
+			t.Errorf("expected 24 sessions left, got %d", left)
+		}
+	})
+
+	t.Run("Dry run counts without deleting", func(t *testing.T) {
+		mock := seed(t, 72)
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+		n, _, err := h.PurgeOlderThan(ctx, "sessions", "created_at", 48*time.Hour, exec.PurgeOptions{PageSize: 7, DryRun: true})
+		if err != nil || n != 24 {
+			t.Fatalf("expected 24 to purge, got %d, %v", n, err)
+		}
+		if mock.Count("sessions") != 72 {
+			t.Error("expected nothing deleted")
+		}
+		if ops := mock.OperationsFor(testutil.OpDeleteMulti); len(ops) != 0 {
+			t.Errorf("expected no deletes, got %d", len(ops))
+		}
+
+		dry := exec.NewExecWithOptions(exec.WithClient(mock), exec.WithDryRun())
+		if n, _, err := dry.PurgeOlderThan(ctx, "sessions", "created_at", 48*time.Hour, exec.PurgeOptions{MaxDelete: 10}); err != nil || n != 10 {
+			t.Errorf("expected 10 to purge with WithDryRun, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("Failures report progress", func(t *testing.T) {
+		mock := seed(t, 72)
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+		boom := errors.New("boom")
+		mock.FailEveryN(testutil.OpDeleteMulti, 3, boom)
+		n, _, err := h.PurgeOlderThan(ctx, "sessions", "created_at", 24*time.Hour, exec.PurgeOptions{PageSize: 10})
+
+		var partial *exec.PartialError
+		if !errors.As(err, &partial) || !errors.Is(err, boom) {
+			t.Fatalf("expected a PartialError wrapping boom, got %v", err)
+		}
+		if n != 20 || partial.Completed != 20 {
+			t.Errorf("expected 20 purged, got %d (%d)", n, partial.Completed)
+		}
+	})
+
+	t.Run("Rejects bad arguments", func(t *testing.T) {
+		h := exec.NewExecWithOptions(exec.WithClient(testutil.NewMockClient()))
+		if _, _, err := h.PurgeOlderThan(ctx, "sessions", "", time.Hour, exec.PurgeOptions{}); err == nil {
+			t.Error("expected an error without a time field")
+		}
+		if _, _, err := h.PurgeOlderThan(ctx, "sessions", "created_at", 0, exec.PurgeOptions{}); err == nil {
+			t.Error("expected an error for a zero duration")
+		}
+	})
+}
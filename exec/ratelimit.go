@@ -0,0 +1,104 @@
+package exec
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// WithWriteRateLimit paces the batches of bulk writes (BulkCreate,
+// BulkDelete, BulkDeleteBuilder, BulkSoftDelete, TouchMulti, UpdateWhere,
+// CopyKind and ImportJSONL) to at most entitiesPerSecond entities a second,
+// so large imports don't get throttled with RESOURCE_EXHAUSTED. Each batch
+// waits for its entities before it is written; cancelling ctx while waiting
+// stops the operation before that batch with the error it reports progress
+// in (a *PartialError, *BulkError or *CopyError). A rate <= 0 removes the
+// limit. Copies made with With share the limit.
+func WithWriteRateLimit(entitiesPerSecond float64) Option {
+	return func(h *Exec) {
+		h.writeRate = entitiesPerSecond
+		h.writeLimiter = newWriteLimiter(h.writeRate, h.writeBurst, time.Now, sleep)
+	}
+}
+
+// WithBurst lets up to n entities be written at once without waiting when
+// WithWriteRateLimit is set, e.g. at the start of a run. It defaults to 1,
+// which spreads writes evenly.
+func WithBurst(n int) Option {
+	return func(h *Exec) {
+		h.writeBurst = n
+		h.writeLimiter = newWriteLimiter(h.writeRate, h.writeBurst, time.Now, sleep)
+	}
+}
+
+// writeLimiter is a token bucket of entities written
+type writeLimiter struct {
+	limiter *rate.Limiter
+	now     func() time.Time
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+// newWriteLimiter returns a limiter of perSecond entities with the given
+// burst, telling time with now and waiting with sleep, or nil for a
+// perSecond <= 0
+func newWriteLimiter(perSecond float64, burst int, now func() time.Time, sleep func(context.Context, time.Duration) error) *writeLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &writeLimiter{limiter: rate.NewLimiter(rate.Limit(perSecond), burst), now: now, sleep: sleep}
+}
+
+// wait blocks until n entities may be written or ctx is done. Batches larger
+// than the burst are reserved a burst at a time.
+func (l *writeLimiter) wait(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := l.now()
+	var reservations []*rate.Reservation
+	var delay time.Duration
+	for n > 0 {
+		tokens := min(n, l.limiter.Burst())
+		r := l.limiter.ReserveN(now, tokens)
+		reservations = append(reservations, r)
+		delay = r.DelayFrom(now)
+		n -= tokens
+	}
+
+	if err := l.sleep(ctx, delay); err != nil {
+		// Give back what this batch won't use, latest reservation first
+		for i := len(reservations) - 1; i >= 0; i-- {
+			reservations[i].CancelAt(l.now())
+		}
+		return err
+	}
+	return nil
+}
+
+// waitWrites waits for the write rate limit, if any, to allow n entities
+func (h *Exec) waitWrites(ctx context.Context, n int) error {
+	if h.writeLimiter == nil {
+		return nil
+	}
+	return h.writeLimiter.wait(ctx, n)
+}
+
+// paced wraps fn, the batch function of a bulk write run with inBatches, to
+// wait for the write rate limit before each batch. Cancelling ctx while
+// waiting returns a *PartialError, as inBatches does between batches.
+func (h *Exec) paced(ctx context.Context, fn func(start, end int) error) func(start, end int) error {
+	if h.writeLimiter == nil {
+		return fn
+	}
+	return func(start, end int) error {
+		if err := h.writeLimiter.wait(ctx, end-start); err != nil {
+			return &PartialError{Completed: start, Err: err}
+		}
+		return fn(start, end)
+	}
+}
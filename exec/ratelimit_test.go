@@ -0,0 +1,141 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/testutil"
+	"golang.org/x/time/rate"
+)
+
+// fakeClock tells simulated time, which sleeping advances
+type fakeClock struct {
+	mu    sync.Mutex
+	t     time.Time
+	slept time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+	c.slept += d
+	return nil
+}
+
+func TestWriteRateLimit(t *testing.T) {
+	ctx := context.Background()
+
+	pacing := []struct {
+		name      string
+		perSecond float64
+		burst     int
+		batchSize int
+		want      time.Duration
+	}{
+		{"Evenly spread", 250, 0, 100, 3996 * time.Millisecond},
+		{"Batches larger than the burst", 250, 0, 500, 3996 * time.Millisecond},
+		{"Burst at the start", 250, 250, 100, 3 * time.Second},
+	}
+	for _, tt := range pacing {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			h := NewExec()
+			h.writeLimiter = newWriteLimiter(tt.perSecond, tt.burst, clock.now, clock.sleep)
+
+			var stored []string
+			fail := false
+			err := bulkCreate(ctx, "users", bulkUsers(1000), tt.batchSize, BulkOptions{}, h.batchPut(failingPut(&stored, 0, &fail)))
+			if err != nil {
+				t.Fatalf("bulkCreate failed: %v", err)
+			}
+			if len(stored) != 1000 {
+				t.Errorf("expected 1000 entities, got %d", len(stored))
+			}
+			if clock.slept != tt.want {
+				t.Errorf("expected %v of waiting, got %v", tt.want, clock.slept)
+			}
+		})
+	}
+
+	t.Run("Paces BulkDelete", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		users := make([]testutil.TestUser, 1000)
+		keys := make([]*datastore.Key, len(users))
+		for i := range keys {
+			keys[i] = datastore.IDKey("users", int64(i+1), nil)
+		}
+		if _, err := testutil.PutInBatches(ctx, mock, keys, users); err != nil {
+			t.Fatalf("PutInBatches failed: %v", err)
+		}
+
+		clock := newFakeClock()
+		h := NewExecWithOptions(WithClient(mock))
+		h.writeLimiter = newWriteLimiter(500, 0, clock.now, clock.sleep)
+
+		n, err := h.BulkDelete(ctx, "users", nil)
+		if err != nil || n != 1000 {
+			t.Fatalf("expected 1000 deleted, got %d, %v", n, err)
+		}
+		if clock.slept != 1998*time.Millisecond {
+			t.Errorf("expected 1.998s of waiting, got %v", clock.slept)
+		}
+	})
+
+	t.Run("Cancellation interrupts a wait", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		h := NewExecWithOptions(WithWriteRateLimit(1))
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		start := time.Now()
+		batches := 0
+		done, err := inBatches(ctx, 30, 10, h.paced(ctx, func(start, end int) error {
+			batches++
+			return nil
+		}))
+
+		var partial *PartialError
+		if !errors.As(err, &partial) || !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected a cancelled *PartialError, got %v", err)
+		}
+		if partial.Completed != 0 || done != 0 || batches != 0 {
+			t.Errorf("expected no batch to run, got %d done in %d batches", partial.Completed, batches)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected the wait to end on cancel, took %v", elapsed)
+		}
+		if tokens := h.writeLimiter.limiter.Tokens(); tokens < -1 {
+			t.Errorf("expected the cancelled reservation to be given back, got %v tokens", tokens)
+		}
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		h := NewExecWithOptions(WithBurst(50), WithWriteRateLimit(100))
+		if l := h.writeLimiter.limiter; l.Limit() != rate.Limit(100) || l.Burst() != 50 {
+			t.Errorf("expected 100/s with a burst of 50, got %v/s and %d", l.Limit(), l.Burst())
+		}
+		if h.With(WithWriteRateLimit(0)).writeLimiter != nil {
+			t.Error("expected a rate of 0 to remove the limit")
+		}
+		if NewExec().waitWrites(ctx, 1000) != nil {
+			t.Error("expected no limit by default")
+		}
+	})
+}
@@ -0,0 +1,252 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/builder"
+	contextKey "github.com/AndroX7/gostore/key"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RunBuilder runs a prebuilt query with the client from the context, for
+// queries that need ordering, ancestors, projections or inequality filters.
+// The builder is run as given, in the Exec's namespace; the soft-delete filter
+// is not added.
+func (h *Exec) RunBuilder(ctx context.Context, b *builder.Builder, dest any) (*builder.PaginationResult, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = h.scopeBuilder(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *builder.PaginationResult
+	err = h.run(ctx, op{name: "RunBuilder", kind: b.GetKind(), query: b, results: func() int { return result.Total }}, func(ctx context.Context) error {
+		var err error
+		result, err = b.Execute(ctx, client, dest)
+		return err
+	})
+	return result, err
+}
+
+// RunBuilderOne loads the first result of a prebuilt query into dest and
+// returns its key. A query without results returns an error matching
+// ErrNotFound.
+func (h *Exec) RunBuilderOne(ctx context.Context, b *builder.Builder, dest any) (*datastore.Key, error) {
+	if err := checkDest(dest); err != nil {
+		return nil, err
+	}
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = h.scopeBuilder(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var key *datastore.Key
+	err = h.run(ctx, op{name: "RunBuilderOne", kind: b.GetKind(), query: b, results: one}, func(ctx context.Context) error {
+		var err error
+		key, err = b.First(ctx, client, dest)
+		return err
+	})
+	if errors.Is(err, datastore.ErrNoSuchEntity) {
+		return nil, ErrNotFound
+	}
+	return key, err
+}
+
+// RunBuilderPage runs a prebuilt query like RunBuilder, appending its results
+// to dest, a pointer to a slice, and returns the cursor after the last
+// result. Set a limit and pass the cursor to the builder's Cursor to fetch
+// the next page; the cursor is empty if there were no results.
+func (h *Exec) RunBuilderPage(ctx context.Context, b *builder.Builder, dest any) (string, error) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return "", fmt.Errorf("dest must be a pointer to a slice, got %T", dest)
+	}
+	slice := v.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	b, err = h.scopeBuilder(b)
+	if err != nil {
+		return "", err
+	}
+
+	start := slice.Len()
+	var cursor string
+	err = h.run(ctx, op{name: "RunBuilderPage", kind: b.GetKind(), query: b, results: func() int { return slice.Len() - start }}, func(ctx context.Context) error {
+		// Drop the results of a failed attempt
+		slice.SetLen(start)
+		cursor = ""
+
+		it := client.Run(ctx, b.Build())
+		for {
+			e := reflect.New(elemType)
+			key, err := it.Next(e.Interface())
+			if err == iterator.Done {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			contextKey.SetID(e.Interface(), key)
+
+			if isPtr {
+				slice.Set(reflect.Append(slice, e))
+			} else {
+				slice.Set(reflect.Append(slice, e.Elem()))
+			}
+
+			next, err := it.Cursor()
+			if err != nil {
+				return err
+			}
+			cursor = next.String()
+		}
+	})
+	return cursor, err
+}
+
+// CountBuilder counts the results of a prebuilt query
+func (h *Exec) CountBuilder(ctx context.Context, b *builder.Builder) (int, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	b, err = h.scopeBuilder(b)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = h.run(ctx, op{name: "CountBuilder", kind: b.GetKind(), query: b, results: func() int { return count }}, func(ctx context.Context) error {
+		var err error
+		count, err = countAggregate(ctx, client, b)
+		return err
+	})
+	return count, err
+}
+
+// SumBuilder sums field over the results of a prebuilt query with an
+// aggregation query
+func (h *Exec) SumBuilder(ctx context.Context, b *builder.Builder, field string) (float64, error) {
+	return h.aggregateBuilder(ctx, "SumBuilder", b, func(ctx context.Context, client gostore.Client, b *builder.Builder) (float64, error) {
+		return b.SumAggregate(ctx, client, field)
+	})
+}
+
+// AvgBuilder averages field over the results of a prebuilt query with an
+// aggregation query, returning 0 if there are none
+func (h *Exec) AvgBuilder(ctx context.Context, b *builder.Builder, field string) (float64, error) {
+	return h.aggregateBuilder(ctx, "AvgBuilder", b, func(ctx context.Context, client gostore.Client, b *builder.Builder) (float64, error) {
+		return b.AvgAggregate(ctx, client, field)
+	})
+}
+
+func (h *Exec) aggregateBuilder(ctx context.Context, name string, b *builder.Builder, aggregate func(context.Context, gostore.Client, *builder.Builder) (float64, error)) (float64, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	b, err = h.scopeBuilder(b)
+	if err != nil {
+		return 0, err
+	}
+
+	var result float64
+	err = h.run(ctx, op{name: name, kind: b.GetKind(), query: b, results: one}, func(ctx context.Context) error {
+		var err error
+		result, err = aggregate(ctx, client, b)
+		return err
+	})
+	return result, err
+}
+
+// BulkDeleteBuilder deletes every entity matched by a prebuilt query, in
+// batches of MaxBatchSize, and returns the number deleted. Cancelling ctx
+// stops it between batches with a *PartialError.
+func (h *Exec) BulkDeleteBuilder(ctx context.Context, b *builder.Builder) (n int, err error) {
+	ctx, finish := h.startBulk(ctx, "BulkDeleteBuilder", b.GetKind())
+	defer func() { finish(n, err) }()
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	b, err = h.scopeBuilder(b)
+	if err != nil {
+		return 0, err
+	}
+
+	o := op{name: "BulkDeleteBuilder", kind: b.GetKind()}
+	keys, err := h.getKeys(ctx, o, client, b.Clone().KeysOnly())
+	if err != nil {
+		return 0, err
+	}
+
+	o.write = true
+	return h.deleteKeys(ctx, o, client, keys)
+}
+
+// getKeys runs a keys-only query as part of o
+func (h *Exec) getKeys(ctx context.Context, o op, client gostore.Client, b *builder.Builder) ([]*datastore.Key, error) {
+	var keys []*datastore.Key
+	o.query = b
+	o.results = func() int { return len(keys) }
+	err := h.run(ctx, o, func(ctx context.Context) error {
+		var err error
+		keys, err = client.GetAll(ctx, b.Build(), nil)
+		return err
+	})
+	return keys, err
+}
+
+// deleteKeys deletes keys in batches of MaxBatchSize as part of o and returns
+// the number deleted
+func (h *Exec) deleteKeys(ctx context.Context, o op, client gostore.Client, keys []*datastore.Key) (int, error) {
+	return inBatches(ctx, len(keys), MaxBatchSize, h.paced(ctx, func(start, end int) error {
+		err := h.run(ctx, o.on(keys[start:end]...), func(ctx context.Context) error {
+			return client.DeleteMulti(ctx, keys[start:end])
+		})
+		if err != nil {
+			return err
+		}
+		return h.recordAudit(ctx, auditBatch{op: AuditDelete, keys: keys[start:end]})
+	}))
+}
+
+// countAggregate counts with an aggregation query, falling back to a keys-only
+// count where aggregations are unsupported
+func countAggregate(ctx context.Context, client gostore.Client, b *builder.Builder) (int, error) {
+	total, err := b.CountAggregate(ctx, client)
+	if status.Code(err) == codes.Unimplemented {
+		return b.Count(ctx, client)
+	}
+	return total, err
+}
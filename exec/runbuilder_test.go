@@ -0,0 +1,87 @@
+package exec_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/exec"
+)
+
+type rankedUser struct {
+	Name  string `datastore:"name"`
+	Score int    `datastore:"score"`
+}
+
+func TestRunBuilder(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	users := []rankedUser{{"a", 10}, {"b", 30}, {"c", 20}, {"d", 40}}
+	ids := []any{"a", "b", "c", "d"}
+	if err := h.CreateMulti(ctx, "users", ids, users); err != nil {
+		t.Fatalf("CreateMulti failed: %v", err)
+	}
+
+	t.Run("Runs order and limit through the context client", func(t *testing.T) {
+		var got []rankedUser
+		b := builder.New().Kind("users").OrderDesc("score").Limit(2)
+
+		result, err := h.RunBuilder(ctx, b, &got)
+		if err != nil {
+			t.Fatalf("RunBuilder failed: %v", err)
+		}
+		if result.Total != 2 || !result.HasMore {
+			t.Errorf("expected 2 results with more, got %+v", result)
+		}
+		if len(got) != 2 || got[0].Name != "d" || got[1].Name != "b" {
+			t.Errorf("expected [d b], got %+v", got)
+		}
+	})
+
+	t.Run("RunBuilderOne returns the first result", func(t *testing.T) {
+		var got rankedUser
+		b := builder.New().Kind("users").OrderAsc("score")
+
+		key, err := h.RunBuilderOne(ctx, b, &got)
+		if err != nil {
+			t.Fatalf("RunBuilderOne failed: %v", err)
+		}
+		if got.Name != "a" || key.Name != "a" {
+			t.Errorf("expected user a, got %+v with key %v", got, key)
+		}
+
+		_, err = h.RunBuilderOne(ctx, builder.New().Kind("users").Filter("score", builder.GreaterThan, 100), &got)
+		if !errors.Is(err, exec.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Count and bulk delete take a builder", func(t *testing.T) {
+		b := builder.New().Kind("users").Filter("score", builder.GreaterThanOrEqual, 30)
+
+		count, err := h.CountBuilder(ctx, b)
+		if err != nil {
+			t.Fatalf("CountBuilder failed: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("expected count 2, got %d", count)
+		}
+
+		deleted, err := h.BulkDeleteBuilder(ctx, b)
+		if err != nil {
+			t.Fatalf("BulkDeleteBuilder failed: %v", err)
+		}
+		if deleted != 2 {
+			t.Errorf("expected 2 deleted, got %d", deleted)
+		}
+
+		remaining, err := h.Count(ctx, "users", nil)
+		if err != nil {
+			t.Fatalf("Count failed: %v", err)
+		}
+		if remaining != 2 {
+			t.Errorf("expected 2 remaining, got %d", remaining)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package exec
+
+import (
+	"context"
+
+	"github.com/AndroX7/gostore/builder"
+)
+
+// SoftDelete marks an entity as deleted by setting its deleted-at property
+// to the current time
+func (h *Exec) SoftDelete(ctx context.Context, kind string, id any) error {
+	return h.Patch(ctx, kind, id, map[string]any{
+		h.deletedAt(): now(),
+	})
+}
+
+// Restore clears the deleted-at marker set by SoftDelete
+func (h *Exec) Restore(ctx context.Context, kind string, id any) error {
+	return h.Patch(ctx, kind, id, map[string]any{
+		h.deletedAt(): nil,
+	})
+}
+
+// BulkSoftDelete soft-deletes entities matching filters, patching them in
+// transactional batches of at most MaxBatchSize
+func (h *Exec) BulkSoftDelete(ctx context.Context, kind string, filters map[string]any) (n int, err error) {
+	ctx, finish := h.startBulk(ctx, "BulkSoftDelete", kind)
+	defer func() { finish(n, err) }()
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	b := h.newBuilder(kind).KeysOnly()
+
+	fb := builder.NewFilter().FromMap(filters)
+	for _, filter := range fb.Build() {
+		b.Filter(filter.Field, filter.Operator, filter.Value)
+	}
+	h.applySoftDelete(b, queryOptions{})
+
+	o := op{name: "BulkSoftDelete", kind: kind}
+	keys, err := h.getKeys(ctx, o, client, b)
+	if err != nil {
+		return 0, err
+	}
+
+	changes := h.withUpdatedAt(map[string]any{h.deletedAt(): now()})
+
+	o.write = true
+	return inBatches(ctx, len(keys), h.batchSize(kind, MaxBatchSize), h.paced(ctx, func(start, end int) error {
+		var pending *auditBatch
+		err := h.run(ctx, o.on(keys[start:end]...), func(ctx context.Context) error {
+			var err error
+			pending, err = h.patchTx(ctx, client, keys[start:end], changes)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return h.recordPending(ctx, pending)
+	}))
+}
+
+func (h *Exec) deletedAt() string {
+	if h.deletedAtField == "" {
+		return DefaultDeletedAtField
+	}
+	return h.deletedAtField
+}
+
+// applySoftDelete excludes soft-deleted entities from b unless soft delete is
+// disabled or the caller asked for them
+func (h *Exec) applySoftDelete(b *builder.Builder, o queryOptions) {
+	if h.softDelete && !o.includeDeleted {
+		b.Filter(h.deletedAt(), builder.Equal, nil)
+	}
+}
@@ -0,0 +1,110 @@
+package exec_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AndroX7/gostore/exec"
+)
+
+type softUser struct {
+	Name      string     `datastore:"name"`
+	Status    string     `datastore:"status"`
+	DeletedAt *time.Time `datastore:"deleted_at"`
+}
+
+func TestSoftDelete(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExecWithOptions(exec.WithSoftDelete(""))
+
+	users := []softUser{
+		{Name: "John", Status: "active"},
+		{Name: "Jane", Status: "active"},
+		{Name: "Bob", Status: "inactive"},
+	}
+	ids := []any{"user1", "user2", "user3"}
+	if err := h.CreateMulti(ctx, "users", ids, users); err != nil {
+		t.Fatalf("failed to seed users: %v", err)
+	}
+
+	t.Run("Soft-deleted entities disappear from default reads", func(t *testing.T) {
+		if err := h.SoftDelete(ctx, "users", "user1"); err != nil {
+			t.Fatalf("SoftDelete failed: %v", err)
+		}
+
+		var all []softUser
+		if err := h.FindAll(ctx, "users", &all); err != nil {
+			t.Fatalf("FindAll failed: %v", err)
+		}
+		if len(all) != 2 {
+			t.Errorf("expected 2 users, got %d", len(all))
+		}
+
+		var active []softUser
+		if err := h.FindWhere(ctx, "users", map[string]any{"status": "active"}, &active); err != nil {
+			t.Fatalf("FindWhere failed: %v", err)
+		}
+		if len(active) != 1 || active[0].Name != "Jane" {
+			t.Errorf("expected only Jane, got %+v", active)
+		}
+
+		var page []softUser
+		pg, err := h.Paginate(ctx, "users", nil, 1, 10, &page)
+		if err != nil {
+			t.Fatalf("Paginate failed: %v", err)
+		}
+		if len(page) != 2 {
+			t.Errorf("expected 2 users on page, got %d (%+v)", len(page), pg)
+		}
+	})
+
+	t.Run("IncludeDeleted returns soft-deleted entities", func(t *testing.T) {
+		var all []softUser
+		if err := h.FindAll(ctx, "users", &all, exec.IncludeDeleted(true)); err != nil {
+			t.Fatalf("FindAll failed: %v", err)
+		}
+		if len(all) != 3 {
+			t.Errorf("expected 3 users, got %d", len(all))
+		}
+	})
+
+	t.Run("Restore clears the marker", func(t *testing.T) {
+		if err := h.Restore(ctx, "users", "user1"); err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+
+		var user softUser
+		if err := h.GetByID(ctx, "users", "user1", &user); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if user.DeletedAt != nil {
+			t.Errorf("expected deleted_at to be cleared, got %v", user.DeletedAt)
+		}
+
+		var all []softUser
+		if err := h.FindAll(ctx, "users", &all); err != nil {
+			t.Fatalf("FindAll failed: %v", err)
+		}
+		if len(all) != 3 {
+			t.Errorf("expected 3 users after restore, got %d", len(all))
+		}
+	})
+
+	t.Run("BulkSoftDelete marks matching entities", func(t *testing.T) {
+		n, err := h.BulkSoftDelete(ctx, "users", map[string]any{"status": "active"})
+		if err != nil {
+			t.Fatalf("BulkSoftDelete failed: %v", err)
+		}
+		if n != 2 {
+			t.Errorf("expected 2 soft-deleted users, got %d", n)
+		}
+
+		var all []softUser
+		if err := h.FindAll(ctx, "users", &all); err != nil {
+			t.Fatalf("FindAll failed: %v", err)
+		}
+		if len(all) != 1 || all[0].Name != "Bob" {
+			t.Errorf("expected only Bob, got %+v", all)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+)
+
+// ErrStatsUnavailable is returned by KindStats and AllKindStats when
+// Datastore has no statistics, as on the emulator or for a kind created
+// since they were last computed
+var ErrStatsUnavailable = errors.New("datastore statistics unavailable")
+
+// KindStats are the statistics Datastore keeps for a kind in its
+// __Stat_Kind__ entities. They are recomputed every day or two, so counts
+// are approximate.
+type KindStats struct {
+	Kind string
+	// Count is the number of entities
+	Count int64
+	// Bytes is the storage used by the entities and their indexes
+	Bytes int64
+	// EntityBytes is the storage used by the entities alone
+	EntityBytes int64
+	// IndexBytes is the storage used by built-in and composite indexes
+	IndexBytes int64
+	// Timestamp is when the statistics were computed
+	Timestamp time.Time
+}
+
+// KindStats returns the statistics of kind in the Exec's namespace. It is
+// much cheaper than an exact Count on a large kind, but is not up to date.
+// Without statistics for kind it returns an error matching
+// ErrStatsUnavailable.
+func (h *Exec) KindStats(ctx context.Context, kind string) (*KindStats, error) {
+	stats, err := h.kindStats(ctx, "KindStats", h.statsBuilder().Filter("kind_name", builder.Equal, kind))
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return nil, fmt.Errorf("%w for kind %s", ErrStatsUnavailable, kind)
+	}
+	return &stats[0], nil
+}
+
+// AllKindStats returns the statistics of every kind in the Exec's namespace,
+// sorted by kind, like KindStats
+func (h *Exec) AllKindStats(ctx context.Context) ([]KindStats, error) {
+	stats, err := h.kindStats(ctx, "AllKindStats", h.statsBuilder())
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return nil, ErrStatsUnavailable
+	}
+	return stats, nil
+}
+
+// statsBuilder returns a query on the kind statistics of the Exec's
+// namespace, which are kept apart from those of the default namespace
+func (h *Exec) statsBuilder() *builder.Builder {
+	if h.namespace != "" {
+		return h.newBuilder("__Stat_Ns_Kind__")
+	}
+	return h.newBuilder("__Stat_Kind__")
+}
+
+// kindStats runs b and decodes the latest statistics of each kind, sorted
+// by kind
+func (h *Exec) kindStats(ctx context.Context, name string, b *builder.Builder) ([]KindStats, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entities []datastore.PropertyList
+	err = h.run(ctx, op{name: name, kind: b.GetKind(), query: b, results: func() int { return len(entities) }}, func(ctx context.Context) error {
+		entities = nil
+		_, err := client.GetAll(ctx, b.Build(), &entities)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Stats being recomputed may briefly exist in two versions
+	latest := map[string]KindStats{}
+	for _, props := range entities {
+		s := decodeKindStats(props)
+		if prev, ok := latest[s.Kind]; !ok || s.Timestamp.After(prev.Timestamp) {
+			latest[s.Kind] = s
+		}
+	}
+
+	stats := make([]KindStats, 0, len(latest))
+	for _, s := range latest {
+		stats = append(stats, s)
+	}
+	slices.SortFunc(stats, func(a, b KindStats) int {
+		return strings.Compare(a.Kind, b.Kind)
+	})
+	return stats, nil
+}
+
+// decodeKindStats reads the properties of a __Stat_Kind__ entity, ignoring
+// those KindStats doesn't expose
+func decodeKindStats(props datastore.PropertyList) KindStats {
+	var s KindStats
+	for _, p := range props {
+		switch v := p.Value.(type) {
+		case string:
+			if p.Name == "kind_name" {
+				s.Kind = v
+			}
+		case time.Time:
+			if p.Name == "timestamp" {
+				s.Timestamp = v
+			}
+		case int64:
+			switch p.Name {
+			case "count":
+				s.Count = v
+			case "bytes":
+				s.Bytes = v
+			case "entity_bytes":
+				s.EntityBytes = v
+			case "builtin_index_bytes", "composite_index_bytes":
+				s.IndexBytes += v
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,119 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+// putKindStats seeds a statistics entity like those Datastore computes
+func putKindStats(t *testing.T, mock *testutil.MockDatastoreClient, statKind, namespace, kind string, count int64, at time.Time) {
+	t.Helper()
+	key := datastore.NameKey(statKind, kind, nil)
+	key.Namespace = namespace
+	props := datastore.PropertyList{
+		{Name: "kind_name", Value: kind},
+		{Name: "count", Value: count},
+		{Name: "bytes", Value: count * 150},
+		{Name: "entity_bytes", Value: count * 100},
+		{Name: "builtin_index_bytes", Value: count * 40},
+		{Name: "builtin_index_count", Value: count * 4},
+		{Name: "composite_index_bytes", Value: count * 10},
+		{Name: "composite_index_count", Value: count},
+		{Name: "timestamp", Value: at},
+	}
+	if _, err := mock.Put(context.Background(), key, &props); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+}
+
+func TestKindStats(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC)
+
+	mock := testutil.NewMockClient()
+	putKindStats(t, mock, "__Stat_Kind__", "", "users", 1200, at)
+	putKindStats(t, mock, "__Stat_Kind__", "", "orders", 50000, at)
+	putKindStats(t, mock, "__Stat_Ns_Kind__", "tenant-a", "users", 30, at)
+	h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+	t.Run("Decodes the statistics of a kind", func(t *testing.T) {
+		stats, err := h.KindStats(ctx, "users")
+		if err != nil {
+			t.Fatalf("KindStats failed: %v", err)
+		}
+		want := exec.KindStats{Kind: "users", Count: 1200, Bytes: 180000, EntityBytes: 120000, IndexBytes: 60000, Timestamp: at}
+		if *stats != want {
+			t.Errorf("expected %+v, got %+v", want, *stats)
+		}
+	})
+
+	t.Run("All kinds sorted", func(t *testing.T) {
+		stats, err := h.AllKindStats(ctx)
+		if err != nil {
+			t.Fatalf("AllKindStats failed: %v", err)
+		}
+		if len(stats) != 2 || stats[0].Kind != "orders" || stats[1].Kind != "users" {
+			t.Errorf("expected orders and users, got %+v", stats)
+		}
+	})
+
+	t.Run("Namespaces have their own statistics", func(t *testing.T) {
+		stats, err := h.With(exec.WithNamespace("tenant-a")).KindStats(ctx, "users")
+		if err != nil || stats.Count != 30 {
+			t.Errorf("expected 30 users in tenant-a, got %+v, %v", stats, err)
+		}
+	})
+
+	t.Run("Latest statistics win", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		putKindStats(t, mock, "__Stat_Kind__", "", "users", 1000, at.Add(-24*time.Hour))
+		key := datastore.NameKey("__Stat_Kind__", "users-new", nil)
+		props := datastore.PropertyList{
+			{Name: "kind_name", Value: "users"},
+			{Name: "count", Value: int64(1100)},
+			{Name: "timestamp", Value: at},
+		}
+		if _, err := mock.Put(ctx, key, &props); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+
+		stats, err := exec.NewExecWithOptions(exec.WithClient(mock)).KindStats(ctx, "users")
+		if err != nil || stats.Count != 1100 {
+			t.Errorf("expected the latest count 1100, got %+v, %v", stats, err)
+		}
+	})
+
+	t.Run("Missing statistics", func(t *testing.T) {
+		if _, err := h.KindStats(ctx, "sessions"); !errors.Is(err, exec.ErrStatsUnavailable) {
+			t.Errorf("expected ErrStatsUnavailable for a kind without statistics, got %v", err)
+		}
+
+		empty := exec.NewExecWithOptions(exec.WithClient(testutil.NewMockClient()))
+		if _, err := empty.AllKindStats(ctx); !errors.Is(err, exec.ErrStatsUnavailable) {
+			t.Errorf("expected ErrStatsUnavailable without statistics, got %v", err)
+		}
+	})
+}
+
+func TestKindStatsEmulator(t *testing.T) {
+	ctx := emulatorContext(t)
+
+	stats, err := exec.NewExec().AllKindStats(ctx)
+	if errors.Is(err, exec.ErrStatsUnavailable) {
+		t.Skip("the emulator keeps no statistics")
+	}
+	if err != nil {
+		t.Fatalf("AllKindStats failed: %v", err)
+	}
+	for _, s := range stats {
+		if s.Kind == "" || s.Timestamp.IsZero() {
+			t.Errorf("expected a kind and timestamp, got %+v", s)
+		}
+	}
+}
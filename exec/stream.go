@@ -0,0 +1,89 @@
+package exec
+
+import (
+	"context"
+
+	"cloud.google.com/go/datastore"
+	contextKey "github.com/AndroX7/gostore/key"
+	"google.golang.org/api/iterator"
+)
+
+// StreamItem is a single result delivered by FindAllStream
+type StreamItem struct {
+	Key    *datastore.Key
+	Entity any
+	Err    error
+}
+
+// nextFunc matches datastore.Iterator.Next
+type nextFunc func(dest any) (*datastore.Key, error)
+
+// FindAllStream streams every entity of a kind over a channel instead of
+// buffering them in a slice. newDest must return a fresh pointer to decode
+// each entity into; its ID field is set from the entity's key.
+//
+// The channel is unbuffered, so memory stays bounded by what the consumer
+// holds. The consumer must either drain the channel or cancel ctx; otherwise
+// the producing goroutine blocks forever. The channel is closed when the kind
+// is exhausted, after an item carrying an error, or once ctx is cancelled.
+// The query runs as one operation, traced, measured and logged like the
+// others, but never retried, since its first results may already have been
+// delivered.
+func (h *Exec) FindAllStream(ctx context.Context, kind string, newDest func() any, opts ...QueryOption) (<-chan StreamItem, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b := h.newBuilder(kind)
+	h.applyQueryOptions(b, newQueryOptions(opts))
+
+	items := make(chan StreamItem)
+	go func() {
+		defer close(items)
+
+		sent := 0
+		o := op{name: "FindAllStream", kind: kind, once: true, query: b, results: func() int { return sent }}
+		err := h.run(ctx, o, func(ctx context.Context) error {
+			var err error
+			sent, err = stream(ctx, client.Run(ctx, b.Build()).Next, newDest, items)
+			return err
+		})
+		if err != nil && ctx.Err() == nil {
+			select {
+			case items <- StreamItem{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return items, nil
+}
+
+// stream sends the entities read with next to items until next is exhausted,
+// returning the number sent. It stops at the first error of next, which it
+// returns without sending, or once ctx is cancelled.
+func stream(ctx context.Context, next nextFunc, newDest func() any, items chan<- StreamItem) (int, error) {
+	sent := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+
+		dest := newDest()
+		key, err := next(dest)
+		if err == iterator.Done {
+			return sent, nil
+		}
+		if err != nil {
+			return sent, err
+		}
+		contextKey.SetID(dest, key)
+
+		select {
+		case items <- StreamItem{Key: key, Entity: dest}:
+			sent++
+		case <-ctx.Done():
+			return sent, ctx.Err()
+		}
+	}
+}
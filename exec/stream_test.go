@@ -0,0 +1,174 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/testutil"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// sliceNext serves n entities like an iterator, counting calls
+func sliceNext(n int, calls *int64) nextFunc {
+	return func(dest any) (*datastore.Key, error) {
+		i := atomic.AddInt64(calls, 1)
+		if i > int64(n) {
+			return nil, iterator.Done
+		}
+		*dest.(*bulkEntity) = bulkEntity{Status: "active"}
+		return datastore.IDKey("users", i, nil), nil
+	}
+}
+
+func newBulkEntity() any {
+	return &bulkEntity{}
+}
+
+// runStream runs stream in a goroutine, closing the channel when it returns
+func runStream(ctx context.Context, next nextFunc) <-chan StreamItem {
+	items := make(chan StreamItem)
+	go func() {
+		defer close(items)
+		stream(ctx, next, newBulkEntity, items)
+	}()
+	return items
+}
+
+func TestStream(t *testing.T) {
+	t.Run("Delivers every entity and closes", func(t *testing.T) {
+		var calls int64
+		items := runStream(context.Background(), sliceNext(5000, &calls))
+
+		count := 0
+		for item := range items {
+			if item.Err != nil {
+				t.Fatalf("unexpected error: %v", item.Err)
+			}
+			if item.Entity.(*bulkEntity).Status != "active" {
+				t.Fatalf("unexpected entity: %+v", item.Entity)
+			}
+			count++
+		}
+
+		if count != 5000 {
+			t.Errorf("expected 5000 items, got %d", count)
+		}
+	})
+
+	t.Run("Cancel halfway stops the producer", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var calls int64
+		items := runStream(ctx, sliceNext(5000, &calls))
+
+		for i := 0; i < 2500; i++ {
+			<-items
+		}
+		cancel()
+
+		done := make(chan struct{})
+		go func() {
+			for range items {
+			}
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("producer goroutine did not exit after cancel")
+		}
+
+		if n := atomic.LoadInt64(&calls); n > 2502 {
+			t.Errorf("expected producer to stop near 2500 entities, it read %d", n)
+		}
+	})
+
+	t.Run("Iterator error stops the stream", func(t *testing.T) {
+		failure := errors.New("unavailable")
+		calls := 0
+		next := func(dest any) (*datastore.Key, error) {
+			calls++
+			if calls == 3 {
+				return nil, failure
+			}
+			return datastore.IDKey("users", int64(calls), nil), nil
+		}
+
+		items := make(chan StreamItem, 3)
+		sent, err := stream(context.Background(), next, newBulkEntity, items)
+		if sent != 2 || len(items) != 2 {
+			t.Errorf("expected 2 items, got %d sent and %d received", sent, len(items))
+		}
+		if !errors.Is(err, failure) {
+			t.Errorf("expected the iterator error, got %v", err)
+		}
+	})
+}
+
+func TestFindAllStream(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Sets IDs", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		testutil.NewSeeder().SeedKind(ctx, t, mock, "users", []bulkEntity{{ID: "a", Status: "active"}, {ID: "b", Status: "active"}})
+		h := NewExecWithOptions(WithClient(mock))
+
+		items, err := h.FindAllStream(ctx, "users", newBulkEntity)
+		if err != nil {
+			t.Fatalf("FindAllStream failed: %v", err)
+		}
+		var ids []string
+		for item := range items {
+			if item.Err != nil {
+				t.Fatalf("unexpected error: %v", item.Err)
+			}
+			ids = append(ids, item.Entity.(*bulkEntity).ID)
+		}
+		if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+			t.Errorf("expected IDs a and b, got %v", ids)
+		}
+	})
+
+	t.Run("Errors go through the operation", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		testutil.NewSeeder().SeedKind(ctx, t, mock, "users", []bulkEntity{{ID: "a"}, {ID: "b"}})
+		failure := status.Error(codes.Unavailable, "unavailable")
+		mock.FailNext(testutil.OpNext, failure, 1)
+
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		h := NewExecWithOptions(WithClient(mock), WithLogger(logger), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+
+		items, err := h.FindAllStream(ctx, "users", newBulkEntity)
+		if err != nil {
+			t.Fatalf("FindAllStream failed: %v", err)
+		}
+		var got []StreamItem
+		for item := range items {
+			got = append(got, item)
+		}
+
+		if len(got) != 1 {
+			t.Fatalf("expected only the error item, without a retry, got %d items", len(got))
+		}
+		var opErr *gostore.Error
+		if !errors.As(got[0].Err, &opErr) || opErr.Op != "FindAllStream" || !errors.Is(got[0].Err, failure) {
+			t.Errorf("expected a FindAllStream *gostore.Error wrapping the failure, got %v", got[0].Err)
+		}
+		if !strings.Contains(buf.String(), "op=FindAllStream") {
+			t.Errorf("expected the stream to be logged, got %q", buf.String())
+		}
+	})
+}
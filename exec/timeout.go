@@ -0,0 +1,25 @@
+package exec
+
+import (
+	"context"
+	"time"
+)
+
+// WithOperationTimeout bounds every Datastore call made by the Exec to d,
+// without extending a tighter deadline already set on the caller's context.
+// It applies to each attempt when retrying and, in bulk operations, to each
+// batch rather than to the whole job. FindAllStream is bounded as a whole,
+// including the time the consumer takes to receive; FindEach is not bounded.
+func WithOperationTimeout(d time.Duration) Option {
+	return func(h *Exec) {
+		h.opTimeout = d
+	}
+}
+
+// opContext derives the context for a single operation or batch
+func (h *Exec) opContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if h.opTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, h.opTimeout)
+}
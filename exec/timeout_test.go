@@ -0,0 +1,126 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+// slowPut returns a putMultiFunc whose calls take delay, or fail with the
+// context error if the context ends first
+func slowPut(delay func(call int) time.Duration) putMultiFunc {
+	calls := 0
+	return func(ctx context.Context, keys []*datastore.Key, src any) ([]*datastore.Key, error) {
+		calls++
+		select {
+		case <-time.After(delay(calls)):
+			return keys, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func TestOperationTimeout(t *testing.T) {
+	t.Run("Slow batch fails with DeadlineExceeded", func(t *testing.T) {
+		h := NewExecWithOptions(WithOperationTimeout(20 * time.Millisecond))
+		ctx := context.Background()
+
+		put := slowPut(func(call int) time.Duration {
+			if call == 3 {
+				return time.Second
+			}
+			return 0
+		})
+
+		err := bulkCreate(ctx, "users", bulkUsers(50), 10, BulkOptions{}, h.batchPut(put))
+
+		var bulkErr *BulkError
+		if !errors.As(err, &bulkErr) {
+			t.Fatalf("expected *BulkError, got %v", err)
+		}
+		if bulkErr.BatchIndex != 2 {
+			t.Errorf("expected batch 2 to fail, got %d", bulkErr.BatchIndex)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected DeadlineExceeded, got %v", err)
+		}
+		if ctx.Err() != nil {
+			t.Errorf("expected caller context to stay valid, got %v", ctx.Err())
+		}
+	})
+
+	t.Run("Timeout applies per batch, not to the whole job", func(t *testing.T) {
+		h := NewExecWithOptions(WithOperationTimeout(50 * time.Millisecond))
+
+		// Five batches of 20ms each exceed the timeout in total but not
+		// individually
+		put := slowPut(func(int) time.Duration { return 20 * time.Millisecond })
+
+		if err := bulkCreate(context.Background(), "users", bulkUsers(50), 10, BulkOptions{}, h.batchPut(put)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Tighter caller deadline is kept", func(t *testing.T) {
+		h := NewExecWithOptions(WithOperationTimeout(time.Hour))
+
+		parent, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		want, _ := parent.Deadline()
+
+		ctx, cancelOp := h.opContext(parent)
+		defer cancelOp()
+
+		got, ok := ctx.Deadline()
+		if !ok || !got.Equal(want) {
+			t.Errorf("expected deadline %v, got %v", want, got)
+		}
+	})
+
+	t.Run("No timeout leaves the context untouched", func(t *testing.T) {
+		ctx, cancel := NewExec().opContext(context.Background())
+		defer cancel()
+
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("expected no deadline")
+		}
+	})
+}
+
+func TestLatencyWithMock(t *testing.T) {
+	client := testutil.NewMockClient()
+	client.SetLatency(testutil.OpGet, 50*time.Millisecond)
+	h := NewExecWithOptions(WithClient(client))
+
+	if err := h.Create(context.Background(), "users", "a", &testutil.TestUser{Name: "A"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	t.Run("Caller deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		var user testutil.TestUser
+		if err := h.GetByID(ctx, "users", "a", &user); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected DeadlineExceeded, got %v", err)
+		}
+		if d := time.Since(start); d >= 50*time.Millisecond {
+			t.Errorf("expected the deadline to interrupt the call, took %v", d)
+		}
+	})
+
+	t.Run("Operation timeout", func(t *testing.T) {
+		h := NewExecWithOptions(WithClient(client), WithOperationTimeout(10*time.Millisecond))
+
+		var user testutil.TestUser
+		if err := h.GetByID(context.Background(), "users", "a", &user); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected DeadlineExceeded, got %v", err)
+		}
+	})
+}
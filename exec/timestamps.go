@@ -0,0 +1,176 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+var (
+	timeType         = reflect.TypeOf(time.Time{})
+	propertyListType = reflect.TypeOf(datastore.PropertyList{})
+)
+
+// WithAutoTimestamps makes Create, CreateMulti and BulkCreate set the created
+// property when it is zero, and every write (including Update, UpdateMulti and
+// Patch) set the updated property to the current time. Either name may be
+// empty to disable that half. Struct entities are matched by datastore tag or
+// field name and must be passed by pointer (or inside a slice); PropertyList
+// entities are updated in place.
+func WithAutoTimestamps(created, updated string) Option {
+	return func(h *Exec) {
+		h.createdAtField = created
+		h.updatedAtField = updated
+	}
+}
+
+// now returns the current time at the precision Datastore stores
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// stampTimestamps applies auto timestamps to an entity passed to Put
+func (h *Exec) stampTimestamps(entity any, create bool) error {
+	return h.stampValue(reflect.ValueOf(entity), create)
+}
+
+func (h *Exec) stampValue(v reflect.Value, create bool) error {
+	if h.createdAtField == "" && h.updatedAtField == "" {
+		return nil
+	}
+
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	t := now()
+
+	if v.Type() == propertyListType {
+		if !v.CanAddr() {
+			return errors.New("entity must be addressable to set timestamps")
+		}
+		props := v.Addr().Interface().(*datastore.PropertyList)
+		if create && h.createdAtField != "" {
+			setPropertyIfZero(props, h.createdAtField, t)
+		}
+		if h.updatedAtField != "" {
+			setPropertyValue(props, h.updatedAtField, t)
+		}
+		return nil
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	if !v.CanSet() {
+		return errors.New("entity must be a pointer to set timestamps")
+	}
+
+	if create && h.createdAtField != "" {
+		if err := setTimeField(v, h.createdAtField, t, false); err != nil {
+			return err
+		}
+	}
+	if h.updatedAtField != "" {
+		if err := setTimeField(v, h.updatedAtField, t, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withUpdatedAt returns changes plus the updated timestamp, leaving the
+// caller's map untouched
+func (h *Exec) withUpdatedAt(changes map[string]any) map[string]any {
+	if h.updatedAtField == "" {
+		return changes
+	}
+
+	out := make(map[string]any, len(changes)+1)
+	for k, v := range changes {
+		out[k] = v
+	}
+	if _, ok := out[h.updatedAtField]; !ok {
+		out[h.updatedAtField] = now()
+	}
+	return out
+}
+
+// setTimeField sets the time.Time or *time.Time field stored under property
+// name; without overwrite a non-zero value is kept
+func setTimeField(v reflect.Value, name string, t time.Time, overwrite bool) error {
+	field, ok := fieldByProperty(v, name)
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case field.Type() == timeType:
+		if overwrite || field.Interface().(time.Time).IsZero() {
+			field.Set(reflect.ValueOf(t))
+		}
+	case field.Type() == reflect.PointerTo(timeType):
+		if overwrite || field.IsNil() || field.Elem().Interface().(time.Time).IsZero() {
+			field.Set(reflect.ValueOf(&t))
+		}
+	default:
+		return fmt.Errorf("timestamp field %q must be time.Time or *time.Time, got %s", name, field.Type())
+	}
+	return nil
+}
+
+// fieldByProperty finds the exported struct field saved under property name
+func fieldByProperty(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldName := field.Name
+		if tag := field.Tag.Get("datastore"); tag != "" {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				fieldName = tagName
+			}
+		}
+
+		if fieldName == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func setPropertyIfZero(props *datastore.PropertyList, name string, value time.Time) {
+	for _, p := range *props {
+		if p.Name != name {
+			continue
+		}
+		if existing, ok := p.Value.(time.Time); ok && !existing.IsZero() {
+			return
+		}
+	}
+	setPropertyValue(props, name, value)
+}
+
+func setPropertyValue(props *datastore.PropertyList, name string, value any) {
+	for i := range *props {
+		if (*props)[i].Name == name {
+			(*props)[i].Value = value
+			return
+		}
+	}
+	*props = append(*props, datastore.Property{Name: name, Value: value})
+}
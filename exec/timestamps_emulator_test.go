@@ -0,0 +1,89 @@
+package exec_test
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+)
+
+type stampedUser struct {
+	Name      string    `datastore:"name"`
+	CreatedAt time.Time `datastore:"created_at"`
+	UpdatedAt time.Time `datastore:"updated_at"`
+}
+
+func TestAutoTimestampsRoundTrip(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExecWithOptions(exec.WithAutoTimestamps("created_at", "updated_at"))
+
+	t.Run("Update leaves CreatedAt unchanged", func(t *testing.T) {
+		if err := h.Create(ctx, "users", "user1", &stampedUser{Name: "John"}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		var created stampedUser
+		if err := h.GetByID(ctx, "users", "user1", &created); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if created.CreatedAt.IsZero() {
+			t.Fatal("expected created_at to be stored")
+		}
+
+		time.Sleep(time.Millisecond)
+		created.Name = "Johnny"
+		if err := h.Update(ctx, "users", "user1", &created); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+
+		var updated stampedUser
+		if err := h.GetByID(ctx, "users", "user1", &updated); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if !updated.CreatedAt.Equal(created.CreatedAt) {
+			t.Errorf("expected created_at %v, got %v", created.CreatedAt, updated.CreatedAt)
+		}
+		if !updated.UpdatedAt.After(updated.CreatedAt) {
+			t.Errorf("expected updated_at after created_at, got %v <= %v", updated.UpdatedAt, updated.CreatedAt)
+		}
+	})
+
+	t.Run("PropertyList entities", func(t *testing.T) {
+		props := datastore.PropertyList{{Name: "name", Value: "Jane"}}
+		if err := h.Create(ctx, "users", "user2", &props); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		var stored stampedUser
+		if err := h.GetByID(ctx, "users", "user2", &stored); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if stored.CreatedAt.IsZero() || stored.UpdatedAt.IsZero() {
+			t.Errorf("expected timestamps to be stored, got %+v", stored)
+		}
+	})
+
+	t.Run("Patch only touches UpdatedAt", func(t *testing.T) {
+		var before stampedUser
+		if err := h.GetByID(ctx, "users", "user1", &before); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+
+		time.Sleep(time.Millisecond)
+		if err := h.Patch(ctx, "users", "user1", map[string]any{"name": "John"}); err != nil {
+			t.Fatalf("Patch failed: %v", err)
+		}
+
+		var after stampedUser
+		if err := h.GetByID(ctx, "users", "user1", &after); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if !after.CreatedAt.Equal(before.CreatedAt) {
+			t.Errorf("expected created_at %v, got %v", before.CreatedAt, after.CreatedAt)
+		}
+		if !after.UpdatedAt.After(before.UpdatedAt) {
+			t.Errorf("expected updated_at to move forward, got %v", after.UpdatedAt)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package exec
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+type stampedUser struct {
+	Name      string     `datastore:"name"`
+	CreatedAt time.Time  `datastore:"created_at"`
+	UpdatedAt *time.Time `datastore:"updated_at"`
+}
+
+func TestAutoTimestamps(t *testing.T) {
+	h := NewExecWithOptions(WithAutoTimestamps("created_at", "updated_at"))
+
+	t.Run("Create sets both timestamps on a struct", func(t *testing.T) {
+		user := &stampedUser{Name: "John"}
+
+		if err := h.stampTimestamps(user, true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if user.CreatedAt.IsZero() {
+			t.Error("expected created_at to be set")
+		}
+		if user.UpdatedAt == nil || !user.UpdatedAt.Equal(user.CreatedAt) {
+			t.Errorf("expected updated_at to equal created_at, got %v", user.UpdatedAt)
+		}
+	})
+
+	t.Run("Create keeps an explicit CreatedAt", func(t *testing.T) {
+		created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		user := &stampedUser{Name: "John", CreatedAt: created}
+
+		if err := h.stampTimestamps(user, true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !user.CreatedAt.Equal(created) {
+			t.Errorf("expected created_at %v, got %v", created, user.CreatedAt)
+		}
+		if user.UpdatedAt == nil {
+			t.Error("expected updated_at to be set")
+		}
+	})
+
+	t.Run("Update only touches UpdatedAt", func(t *testing.T) {
+		old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		user := &stampedUser{Name: "John", UpdatedAt: &old}
+
+		if err := h.stampTimestamps(user, false); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !user.CreatedAt.IsZero() {
+			t.Errorf("expected created_at to stay zero, got %v", user.CreatedAt)
+		}
+		if user.UpdatedAt == nil || !user.UpdatedAt.After(old) {
+			t.Errorf("expected updated_at to move forward, got %v", user.UpdatedAt)
+		}
+	})
+
+	t.Run("Slice elements are stamped in place", func(t *testing.T) {
+		users := []stampedUser{{Name: "John"}, {Name: "Jane"}}
+
+		for i := range users {
+			if err := h.stampTimestamps(&users[i], true); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		for i, user := range users {
+			if user.CreatedAt.IsZero() || user.UpdatedAt == nil {
+				t.Errorf("user %d: expected timestamps to be set, got %+v", i, user)
+			}
+		}
+	})
+
+	t.Run("PropertyList entities", func(t *testing.T) {
+		created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		props := datastore.PropertyList{
+			{Name: "name", Value: "John"},
+			{Name: "created_at", Value: created},
+		}
+
+		if err := h.stampTimestamps(&props, true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(props) != 3 {
+			t.Fatalf("expected 3 properties, got %d", len(props))
+		}
+		if props[1].Value != created {
+			t.Errorf("expected created_at to be kept, got %v", props[1].Value)
+		}
+		if _, ok := props[2].Value.(time.Time); props[2].Name != "updated_at" || !ok {
+			t.Errorf("expected updated_at time property, got %+v", props[2])
+		}
+	})
+
+	t.Run("Struct passed by value", func(t *testing.T) {
+		if err := h.stampTimestamps(stampedUser{}, true); err == nil {
+			t.Error("expected error for non-pointer struct")
+		}
+	})
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		user := &stampedUser{Name: "John"}
+
+		if err := NewExec().stampTimestamps(user, true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !user.CreatedAt.IsZero() || user.UpdatedAt != nil {
+			t.Errorf("expected no timestamps, got %+v", user)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/datastore"
+)
+
+// TouchBatchSize is the number of entities TouchMulti updates per transaction
+const TouchBatchSize = 25
+
+// Touch transactionally sets the timestamp property field of an existing
+// entity to the current UTC time, leaving every other property as stored. An
+// empty field uses the updated property set with WithAutoTimestamps. A missing
+// entity returns an error matching ErrNotFound.
+func (h *Exec) Touch(ctx context.Context, kind string, id any, field string) error {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	field, err = h.touchField(field)
+	if err != nil {
+		return err
+	}
+
+	key, err := h.idKey(kind, id)
+	if err != nil {
+		return err
+	}
+
+	var pending *auditBatch
+	err = h.run(ctx, op{name: "Touch", kind: kind, write: true}.on(key), func(ctx context.Context) error {
+		var err error
+		pending, err = h.patchTx(ctx, client, []*datastore.Key{key}, map[string]any{field: now()})
+		return err
+	})
+	if err != nil {
+		return notFoundKeys(err, []*datastore.Key{key})
+	}
+	return h.recordPending(ctx, pending)
+}
+
+// TouchMulti touches every entity in ids like Touch, in one transaction per
+// TouchBatchSize entities, and returns the number touched. Cancelling ctx
+// stops it between batches with a *PartialError.
+func (h *Exec) TouchMulti(ctx context.Context, kind string, ids []any, field string) (n int, err error) {
+	ctx, finish := h.startBulk(ctx, "TouchMulti", kind)
+	defer func() { finish(n, err) }()
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	field, err = h.touchField(field)
+	if err != nil {
+		return 0, err
+	}
+
+	keys, err := h.idKeys(kind, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	return inBatches(ctx, len(keys), TouchBatchSize, h.paced(ctx, func(start, end int) error {
+		batch := keys[start:end]
+		var pending *auditBatch
+		err := h.run(ctx, op{name: "TouchMulti", kind: kind, write: true, keys: len(batch)}, func(ctx context.Context) error {
+			var err error
+			pending, err = h.patchTx(ctx, client, batch, map[string]any{field: now()})
+			return err
+		})
+		if err != nil {
+			return notFoundKeys(err, batch)
+		}
+		return h.recordPending(ctx, pending)
+	}))
+}
+
+func (h *Exec) touchField(field string) (string, error) {
+	if field != "" {
+		return field, nil
+	}
+	if h.updatedAtField == "" {
+		return "", errors.New("touch field is required without WithAutoTimestamps")
+	}
+	return h.updatedAtField, nil
+}
+
+// notFoundKeys maps missing-entity errors from a lookup of keys to ErrNotFound
+func notFoundKeys(err error, keys []*datastore.Key) error {
+	if errors.Is(err, datastore.ErrNoSuchEntity) {
+		return fmt.Errorf("%w: %v", ErrNotFound, keys[0])
+	}
+
+	var me datastore.MultiError
+	if errors.As(err, &me) {
+		for i, e := range me {
+			if errors.Is(e, datastore.ErrNoSuchEntity) {
+				return fmt.Errorf("%w: %v", ErrNotFound, keys[i])
+			}
+		}
+	}
+	return err
+}
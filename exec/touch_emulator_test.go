@@ -0,0 +1,104 @@
+package exec_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+)
+
+type activeUser struct {
+	Name     string    `datastore:"name"`
+	Bio      string    `datastore:"bio,noindex"`
+	Tags     []string  `datastore:"tags"`
+	LastSeen time.Time `datastore:"last_seen"`
+}
+
+// withoutProperty returns props minus the named property
+func withoutProperty(props datastore.PropertyList, name string) datastore.PropertyList {
+	var out datastore.PropertyList
+	for _, p := range props {
+		if p.Name != name {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func TestTouch(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	seen := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	user := activeUser{Name: "John", Bio: "hello", Tags: []string{"a", "b"}, LastSeen: seen}
+	if err := h.Create(ctx, "users", "user1", &user); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	t.Run("Only the timestamp property changes", func(t *testing.T) {
+		var before datastore.PropertyList
+		if err := h.GetByID(ctx, "users", "user1", &before); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+
+		if err := h.Touch(ctx, "users", "user1", "last_seen"); err != nil {
+			t.Fatalf("Touch failed: %v", err)
+		}
+
+		var after datastore.PropertyList
+		if err := h.GetByID(ctx, "users", "user1", &after); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+
+		if !reflect.DeepEqual(withoutProperty(before, "last_seen"), withoutProperty(after, "last_seen")) {
+			t.Errorf("expected other properties unchanged, got %+v then %+v", before, after)
+		}
+
+		var got activeUser
+		if err := h.GetByID(ctx, "users", "user1", &got); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if !got.LastSeen.After(seen) {
+			t.Errorf("expected last_seen after %v, got %v", seen, got.LastSeen)
+		}
+	})
+
+	t.Run("Missing entity returns ErrNotFound", func(t *testing.T) {
+		if err := h.Touch(ctx, "users", "missing", "last_seen"); !errors.Is(err, exec.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("TouchMulti touches every entity", func(t *testing.T) {
+		ids := make([]any, 30)
+		users := make([]activeUser, 30)
+		for i := range ids {
+			ids[i] = int64(i + 1)
+			users[i] = activeUser{Name: "u", LastSeen: seen}
+		}
+		if err := h.CreateMulti(ctx, "users", ids, users); err != nil {
+			t.Fatalf("CreateMulti failed: %v", err)
+		}
+
+		touched, err := h.TouchMulti(ctx, "users", ids, "last_seen")
+		if err != nil {
+			t.Fatalf("TouchMulti failed: %v", err)
+		}
+		if touched != 30 {
+			t.Errorf("expected 30 touched, got %d", touched)
+		}
+
+		got := make([]activeUser, 30)
+		if err := h.GetMulti(ctx, "users", ids, got); err != nil {
+			t.Fatalf("GetMulti failed: %v", err)
+		}
+		for i, u := range got {
+			if !u.LastSeen.After(seen) {
+				t.Errorf("user %d: expected last_seen to be bumped, got %v", i, u.LastSeen)
+			}
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package exec
+
+import (
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+)
+
+func TestTouchField(t *testing.T) {
+	t.Run("Explicit field wins", func(t *testing.T) {
+		h := NewExecWithOptions(WithAutoTimestamps("created_at", "updated_at"))
+
+		field, err := h.touchField("last_seen")
+		if err != nil || field != "last_seen" {
+			t.Errorf("expected last_seen, got %q (%v)", field, err)
+		}
+	})
+
+	t.Run("Empty field falls back to the updated property", func(t *testing.T) {
+		h := NewExecWithOptions(WithAutoTimestamps("", "updated_at"))
+
+		field, err := h.touchField("")
+		if err != nil || field != "updated_at" {
+			t.Errorf("expected updated_at, got %q (%v)", field, err)
+		}
+
+		if _, err := NewExec().touchField(""); err == nil {
+			t.Error("expected error without a field or auto timestamps")
+		}
+	})
+}
+
+func TestNotFoundKeys(t *testing.T) {
+	keys := []*datastore.Key{datastore.NameKey("users", "a", nil), datastore.NameKey("users", "b", nil)}
+
+	t.Run("Maps a missing entity in a multi error", func(t *testing.T) {
+		err := notFoundKeys(datastore.MultiError{nil, datastore.ErrNoSuchEntity}, keys)
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Leaves other errors alone", func(t *testing.T) {
+		boom := errors.New("boom")
+		if err := notFoundKeys(boom, keys); err != boom {
+			t.Errorf("expected boom, got %v", err)
+		}
+	})
+}
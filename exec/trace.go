@@ -0,0 +1,72 @@
+package exec
+
+import (
+	"context"
+	"time"
+)
+
+// Operation describes a Datastore call made by an Exec, as passed to a Tracer
+type Operation struct {
+	// Name is the Exec method, e.g. "GetByID"
+	Name string
+	Kind string
+	// Keys is the number of keys read or written, 0 for queries
+	Keys int
+	// Batch is set on the calls making up a bulk operation, which are traced
+	// inside the span of the operation as a whole
+	Batch bool
+}
+
+// Span is started by a Tracer for an Operation
+type Span interface {
+	// SetResults records the number of entities a read returned, or the
+	// number a count or bulk operation reported
+	SetResults(n int)
+	// End ends the span with the error of the operation, if any
+	End(err error)
+}
+
+// Tracer starts a Span for every Datastore call made by an Exec, and for every
+// bulk operation as a whole. Package otelgostore implements it with
+// OpenTelemetry.
+type Tracer interface {
+	Start(ctx context.Context, op Operation) (context.Context, Span)
+}
+
+// WithTracer traces the operations of the Exec with t
+func WithTracer(t Tracer) Option {
+	return func(h *Exec) {
+		h.tracer = t
+	}
+}
+
+// bulkKey marks the context of a bulk operation, whose calls are batches
+type bulkKey struct{}
+
+// startBulk starts the span of a bulk operation, which makes one call per
+// batch with the returned context. The returned function ends the span with
+// the number the operation reports and its error, and records the operation
+// as a whole with the Exec's metrics.
+func (h *Exec) startBulk(ctx context.Context, name, kind string) (context.Context, func(n int, err error)) {
+	if h.tracer == nil && h.metrics == nil {
+		return ctx, func(int, error) {}
+	}
+
+	start := time.Now()
+	var span Span
+	if h.tracer != nil {
+		ctx, span = h.tracer.Start(ctx, Operation{Name: name, Kind: kind})
+	}
+	return context.WithValue(ctx, bulkKey{}, true), func(n int, err error) {
+		if h.metrics != nil {
+			h.metrics.ObserveOperation(kind, name, time.Since(start), err)
+		}
+		if span == nil {
+			return
+		}
+		if err == nil {
+			span.SetResults(n)
+		}
+		span.End(err)
+	}
+}
@@ -0,0 +1,183 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+)
+
+// TxOption configures Transaction
+type TxOption func(*txSettings)
+
+type txSettings struct {
+	options []datastore.TransactionOption
+	onRetry func(attempt int, err error)
+	repanic bool
+}
+
+// MaxAttempts sets how many times the transaction is attempted before giving
+// up (the datastore default is 3)
+func MaxAttempts(n int) TxOption {
+	return func(s *txSettings) {
+		s.options = append(s.options, datastore.MaxAttempts(n))
+	}
+}
+
+// ReadOnly runs the transaction in read-only mode
+func ReadOnly() TxOption {
+	return func(s *txSettings) {
+		s.options = append(s.options, datastore.ReadOnly)
+	}
+}
+
+// OnRetry registers a callback invoked before every retried attempt. attempt
+// is the number of the attempt about to start (2 for the first retry) and err
+// is what failed the previous one: the callback error, or
+// datastore.ErrConcurrentTransaction when the commit was aborted.
+func OnRetry(fn func(attempt int, err error)) TxOption {
+	return func(s *txSettings) {
+		s.onRetry = fn
+	}
+}
+
+// WithRepanic makes Transaction panic again with the original value after
+// rolling back a transaction whose callback panicked, instead of returning a
+// *PanicError
+func WithRepanic() TxOption {
+	return func(s *txSettings) {
+		s.repanic = true
+	}
+}
+
+// PanicError is returned by Transaction when its callback panics. The
+// transaction is rolled back.
+type PanicError struct {
+	// Value is the value the callback panicked with
+	Value any
+	// Stack is the stack trace of the panicking goroutine
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("gostore: panic in transaction: %v", e.Value)
+}
+
+// Unwrap returns Value if it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Transaction executes operations in a transaction and returns its commit,
+// which resolves the pending keys of Puts made inside fn. The callback
+// receives a TxExec bound to the transaction and to this Exec's options; the
+// raw transaction is available from its Tx method. When every attempt
+// is aborted by contention the returned error wraps
+// datastore.ErrConcurrentTransaction and reports the attempt count. A panic
+// in fn rolls the transaction back and is returned as a *PanicError, or
+// raised again with WithRepanic.
+func (h *Exec) Transaction(ctx context.Context, fn func(tx *TxExec) error, opts ...TxOption) (*datastore.Commit, error) {
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var s txSettings
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	attempt := 0
+	var lastErr error
+	var audits []txAudit
+	var committed []func()
+	var panicked *PanicError
+	run := func(tx gostore.Transaction) (err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				panicked = &PanicError{Value: v, Stack: debug.Stack()}
+				lastErr, err = panicked, panicked
+			}
+		}()
+
+		attempt++
+		if attempt > 1 && s.onRetry != nil {
+			prev := lastErr
+			if prev == nil {
+				prev = datastore.ErrConcurrentTransaction
+			}
+			s.onRetry(attempt, prev)
+		}
+
+		audits, committed = nil, nil
+		txe := h.InTx(tx)
+		txe.audits = &audits
+		txe.committed = &committed
+		lastErr = fn(txe)
+		if lastErr == nil && h.dryRun {
+			return errDryRun
+		}
+		return lastErr
+	}
+
+	// Contention is retried by RunInTransaction itself
+	var commit *datastore.Commit
+	err = h.run(ctx, op{name: "Transaction", once: true}, func(ctx context.Context) error {
+		var err error
+		commit, err = client.RunInTransaction(ctx, run, s.options...)
+		return err
+	})
+	if panicked != nil {
+		if s.repanic {
+			panic(panicked.Value)
+		}
+		return nil, panicked
+	}
+	if errors.Is(err, errDryRun) {
+		return nil, nil
+	}
+	if errors.Is(err, datastore.ErrConcurrentTransaction) {
+		return nil, fmt.Errorf("transaction aborted after %d attempts: %w", attempt, err)
+	}
+	if err != nil {
+		return commit, err
+	}
+	for _, fn := range committed {
+		fn()
+	}
+	return commit, h.recordCommitted(ctx, commit, audits)
+}
+
+// recordCommitted records the audits of a committed transaction, with the
+// keys allocated at commit resolved
+func (h *Exec) recordCommitted(ctx context.Context, commit *datastore.Commit, audits []txAudit) error {
+	for _, a := range audits {
+		b := a.batch
+		b.keys = append([]*datastore.Key(nil), b.keys...)
+		for i, key := range b.keys {
+			if key.Incomplete() && i < len(a.pending) && a.pending[i] != nil && commit != nil {
+				b.keys[i] = commit.Key(a.pending[i])
+			}
+		}
+		if err := h.recordAudit(ctx, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InTx returns a TxExec that runs operations inside tx, an open transaction,
+// with this Exec's options
+func (h *Exec) InTx(tx gostore.Transaction) *TxExec {
+	if _, ok := tx.(codecTx); !ok && h.hasCodec() {
+		tx = codecTx{Transaction: tx, h: h}
+	}
+	return &TxExec{tx: tx, h: h}
+}
+
+// errDryRun rolls back a transaction run in dry-run mode
+var errDryRun = errors.New("dry run")
@@ -0,0 +1,233 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/exec"
+	contextKey "github.com/AndroX7/gostore/key"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type counter struct {
+	Value int64 `datastore:"value"`
+}
+
+func TestTransaction(t *testing.T) {
+	ctx := emulatorContext(t)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	h := exec.NewExec()
+	client := ctx.Value(contextKey.NOSQL_KEY).(*datastore.Client)
+	key := datastore.NameKey("counters", "hits", nil)
+
+	if _, err := client.Put(ctx, key, &counter{}); err != nil {
+		t.Fatalf("failed to seed counter: %v", err)
+	}
+
+	increment := func(tx *exec.TxExec) error {
+		var c counter
+		if err := tx.GetByID(ctx, "counters", "hits", &c); err != nil {
+			return err
+		}
+		c.Value++
+		return tx.Update(ctx, "counters", "hits", &c)
+	}
+
+	t.Run("Contention retry fires OnRetry", func(t *testing.T) {
+		var retries []int
+		interfered := false
+
+		_, err := h.Transaction(ctx, func(tx *exec.TxExec) error {
+			if err := increment(tx); err != nil {
+				return err
+			}
+			if !interfered {
+				// A second transaction commits to the same entity first,
+				// so this attempt is aborted at commit time
+				interfered = true
+				if _, err := h.Transaction(ctx, increment); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, exec.MaxAttempts(5), exec.OnRetry(func(attempt int, err error) {
+			retries = append(retries, attempt)
+		}))
+		if err != nil {
+			t.Fatalf("Transaction failed: %v", err)
+		}
+
+		if len(retries) == 0 || retries[0] != 2 {
+			t.Errorf("expected OnRetry for attempt 2, got %v", retries)
+		}
+
+		var c counter
+		if err := client.Get(ctx, key, &c); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if c.Value != 2 {
+			t.Errorf("expected counter 2, got %d", c.Value)
+		}
+	})
+
+	t.Run("Commit resolves pending keys", func(t *testing.T) {
+		var pending *datastore.PendingKey
+		commit, err := h.Transaction(ctx, func(tx *exec.TxExec) error {
+			var err error
+			pending, err = tx.Tx().Put(datastore.IncompleteKey("counters", nil), &counter{Value: 1})
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Transaction failed: %v", err)
+		}
+
+		if k := commit.Key(pending); k == nil || k.Incomplete() {
+			t.Errorf("expected a resolved key, got %v", k)
+		}
+	})
+
+	t.Run("Callback error rolls back", func(t *testing.T) {
+		failure := errors.New("rollback")
+		_, err := h.Transaction(ctx, func(tx *exec.TxExec) error {
+			if err := increment(tx); err != nil {
+				return err
+			}
+			return failure
+		}, exec.MaxAttempts(1))
+		if !errors.Is(err, failure) {
+			t.Fatalf("expected callback error, got %v", err)
+		}
+
+		var c counter
+		if err := client.Get(ctx, key, &c); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if c.Value != 2 {
+			t.Errorf("expected counter to stay 2, got %d", c.Value)
+		}
+	})
+}
+
+type account struct {
+	Balance int64 `datastore:"balance"`
+}
+
+func TestTxExec(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	if err := h.CreateMulti(ctx, "accounts", []any{"alice", "bob"}, []account{{Balance: 100}, {Balance: 50}}); err != nil {
+		t.Fatalf("failed to seed accounts: %v", err)
+	}
+
+	transfer := func(tx *exec.TxExec, amount int64) error {
+		accounts := make([]account, 2)
+		if err := tx.GetMulti(ctx, "accounts", []any{"alice", "bob"}, accounts); err != nil {
+			return err
+		}
+		if err := tx.Patch(ctx, "accounts", "alice", map[string]any{"balance": accounts[0].Balance - amount}); err != nil {
+			return err
+		}
+		accounts[1].Balance += amount
+		return tx.Update(ctx, "accounts", "bob", &accounts[1])
+	}
+
+	balances := func(t *testing.T) (int64, int64) {
+		t.Helper()
+		accounts := make([]account, 2)
+		if err := h.GetMulti(ctx, "accounts", []any{"alice", "bob"}, accounts); err != nil {
+			t.Fatalf("GetMulti failed: %v", err)
+		}
+		return accounts[0].Balance, accounts[1].Balance
+	}
+
+	t.Run("Transfer moves the value atomically", func(t *testing.T) {
+		_, err := h.Transaction(ctx, func(tx *exec.TxExec) error {
+			return transfer(tx, 30)
+		})
+		if err != nil {
+			t.Fatalf("Transaction failed: %v", err)
+		}
+
+		alice, bob := balances(t)
+		if alice != 70 || bob != 80 {
+			t.Errorf("expected balances 70/80, got %d/%d", alice, bob)
+		}
+	})
+
+	t.Run("Error rolls back both entities", func(t *testing.T) {
+		failure := errors.New("insufficient funds")
+		_, err := h.Transaction(ctx, func(tx *exec.TxExec) error {
+			if err := transfer(tx, 500); err != nil {
+				return err
+			}
+			return failure
+		}, exec.MaxAttempts(1))
+		if !errors.Is(err, failure) {
+			t.Fatalf("expected callback error, got %v", err)
+		}
+
+		alice, bob := balances(t)
+		if alice != 70 || bob != 80 {
+			t.Errorf("expected balances to stay 70/80, got %d/%d", alice, bob)
+		}
+	})
+}
+
+func TestAfterCommit(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+	t.Run("Runs once after the commit", func(t *testing.T) {
+		mock.AbortNextTx(1)
+		calls, attempts := 0, 0
+		_, err := h.Transaction(ctx, func(tx *exec.TxExec) error {
+			attempts++
+			tx.AfterCommit(func() {
+				if mock.Count("users") != 1 {
+					t.Error("expected the write committed before the callback")
+				}
+				calls++
+			})
+			return tx.Create(ctx, "users", "john", &testutil.TestUser{Name: "John"})
+		})
+		if err != nil {
+			t.Fatalf("Transaction failed: %v", err)
+		}
+		if attempts != 2 || calls != 1 {
+			t.Errorf("expected 2 attempts and 1 call, got %d and %d", attempts, calls)
+		}
+	})
+
+	t.Run("Not run on rollback", func(t *testing.T) {
+		called := false
+		_, err := h.Transaction(ctx, func(tx *exec.TxExec) error {
+			tx.AfterCommit(func() { called = true })
+			return errors.New("rollback")
+		})
+		if err == nil || called {
+			t.Errorf("expected a rollback without the callback, got %v, called=%v", err, called)
+		}
+	})
+
+	t.Run("Runs right away on a transaction of the caller's", func(t *testing.T) {
+		called := false
+		_, err := mock.RunInTransaction(ctx, func(tx gostore.Transaction) error {
+			h.InTx(tx).AfterCommit(func() { called = true })
+			if !called {
+				t.Error("expected the callback called right away")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("RunInTransaction failed: %v", err)
+		}
+	})
+}
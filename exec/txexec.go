@@ -0,0 +1,339 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/builder"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+// TxExec runs Exec-style operations inside an open transaction. Writes are
+// buffered by the transaction and applied when it commits, so keys of
+// entities created with a nil ID are only known from the commit.
+type TxExec struct {
+	tx gostore.Transaction
+	h  *Exec
+	// audits collects the audits of writes for Transaction to record once
+	// it commits; nil for a TxExec over a transaction of the caller's
+	audits *[]txAudit
+	// committed collects the AfterCommit callbacks for Transaction to call
+	// once it commits; nil for a TxExec over a transaction of the caller's
+	committed *[]func()
+}
+
+// txAudit is the audit of a write made in a transaction, with the pending
+// keys of its incomplete keys
+type txAudit struct {
+	batch   auditBatch
+	pending []*datastore.PendingKey
+}
+
+// NewTxExec wraps an open transaction, usually a *datastore.Transaction
+func NewTxExec(tx gostore.Transaction) *TxExec {
+	return &TxExec{tx: tx, h: NewExec()}
+}
+
+// Tx returns the underlying *datastore.Transaction, or nil if the transaction
+// comes from another gostore.Client implementation
+func (t *TxExec) Tx() *datastore.Transaction {
+	dtx, _ := datastoreTx(t.tx)
+	return dtx
+}
+
+// Transaction returns the underlying transaction
+func (t *TxExec) Transaction() gostore.Transaction {
+	return t.tx
+}
+
+// AfterCommit calls fn once the transaction commits, if it was started by
+// Transaction; callbacks of attempts retried on contention are dropped with
+// their writes. For a TxExec over a transaction of the caller's, who commits
+// it, fn is called right away.
+func (t *TxExec) AfterCommit(fn func()) {
+	if t.committed == nil {
+		fn()
+		return
+	}
+	*t.committed = append(*t.committed, fn)
+}
+
+// GetByID retrieves entity by ID
+func (t *TxExec) GetByID(ctx context.Context, kind string, id any, dest any) error {
+	key, err := t.h.idKey(kind, id)
+	if err != nil {
+		return err
+	}
+
+	if err := t.tx.Get(key, dest); err != nil {
+		return opError(op{name: "GetByID", kind: kind}.on(key), err)
+	}
+	contextKey.SetID(dest, key)
+	return nil
+}
+
+// GetMulti retrieves multiple entities by IDs
+func (t *TxExec) GetMulti(ctx context.Context, kind string, ids []any, dest any) error {
+	keys, err := t.h.idKeys(kind, ids)
+	if err != nil {
+		return err
+	}
+
+	if err := t.tx.GetMulti(keys, dest); err != nil {
+		return opError(op{name: "GetMulti", kind: kind}.on(keys...), err)
+	}
+	contextKey.SetIDs(dest, keys)
+	return nil
+}
+
+// GetByKey retrieves the entity stored under key. A missing entity returns
+// an error matching ErrNotFound.
+func (t *TxExec) GetByKey(ctx context.Context, key *datastore.Key, dest any) error {
+	if err := t.h.checkKey(key, true); err != nil {
+		return err
+	}
+
+	err := t.tx.Get(key, dest)
+	if errors.Is(err, datastore.ErrNoSuchEntity) {
+		return fmt.Errorf("%w: %v", ErrNotFound, key)
+	}
+	if err != nil {
+		return opError(op{name: "GetByKey", kind: key.Kind}.on(key), err)
+	}
+	contextKey.SetID(dest, key)
+	return nil
+}
+
+// Exists checks if an entity exists
+func (t *TxExec) Exists(ctx context.Context, kind string, id any) (bool, error) {
+	var entity datastore.PropertyList
+	err := t.GetByID(ctx, kind, id, &entity)
+	if errors.Is(err, datastore.ErrNoSuchEntity) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Create creates a new entity
+func (t *TxExec) Create(ctx context.Context, kind string, id any, entity any) error {
+	_, err := t.put(ctx, kind, id, entity, true)
+	return err
+}
+
+// CreateWithKey creates a new entity and returns its key, which stays
+// incomplete until commit for a nil id
+func (t *TxExec) CreateWithKey(ctx context.Context, kind string, id any, entity any) (*datastore.Key, error) {
+	return t.put(ctx, kind, id, entity, true)
+}
+
+// CreateMulti creates multiple entities
+func (t *TxExec) CreateMulti(ctx context.Context, kind string, ids []any, entities any) error {
+	_, err := t.putMulti(ctx, kind, ids, entities, true)
+	return err
+}
+
+// CreateMultiWithKeys creates multiple entities and returns their keys in
+// order, incomplete until commit for nil IDs
+func (t *TxExec) CreateMultiWithKeys(ctx context.Context, kind string, ids []any, entities any) ([]*datastore.Key, error) {
+	return t.putMulti(ctx, kind, ids, entities, true)
+}
+
+// Update updates an existing entity
+func (t *TxExec) Update(ctx context.Context, kind string, id any, entity any) error {
+	_, err := t.put(ctx, kind, id, entity, false)
+	return err
+}
+
+// UpdateWithKey updates an existing entity and returns its key
+func (t *TxExec) UpdateWithKey(ctx context.Context, kind string, id any, entity any) (*datastore.Key, error) {
+	return t.put(ctx, kind, id, entity, false)
+}
+
+// UpdateMulti updates multiple entities
+func (t *TxExec) UpdateMulti(ctx context.Context, kind string, ids []any, entities any) error {
+	_, err := t.putMulti(ctx, kind, ids, entities, false)
+	return err
+}
+
+// UpdateMultiWithKeys updates multiple entities and returns their keys in
+// order
+func (t *TxExec) UpdateMultiWithKeys(ctx context.Context, kind string, ids []any, entities any) ([]*datastore.Key, error) {
+	return t.putMulti(ctx, kind, ids, entities, false)
+}
+
+func (t *TxExec) put(ctx context.Context, kind string, id any, entity any, create bool) (*datastore.Key, error) {
+	key, err := t.h.entityKey(kind, id, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.h.stampTimestamps(entity, create); err != nil {
+		return nil, err
+	}
+
+	if !create {
+		if err := t.h.saveHistory(t.tx, []*datastore.Key{key}); err != nil {
+			return nil, err
+		}
+	}
+
+	pending, err := t.tx.Put(key, entity)
+	if err != nil {
+		return nil, opError(putOp(putName(create), kind, key), err)
+	}
+	b := auditBatch{op: auditPut(create), keys: []*datastore.Key{key}, after: auditEntity(entity)}
+	return key, t.audit(ctx, b, []*datastore.PendingKey{pending})
+}
+
+func (t *TxExec) putMulti(ctx context.Context, kind string, ids []any, entities any, create bool) ([]*datastore.Key, error) {
+	v, err := checkEntities(ids, entities)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := t.h.entityKeys(kind, ids, v)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if err := t.h.stampValue(v.Index(i), create); err != nil {
+			return nil, fmt.Errorf("entity at index %d: %w", i, err)
+		}
+	}
+
+	if !create {
+		if err := t.h.saveHistory(t.tx, keys); err != nil {
+			return nil, err
+		}
+	}
+
+	pending, err := t.tx.PutMulti(keys, entities)
+	if err != nil {
+		return nil, opError(putOp(putName(create)+"Multi", kind, keys...), err)
+	}
+	b := auditBatch{op: auditPut(create), keys: keys, after: auditEntities(entities)}
+	return keys, t.audit(ctx, b, pending)
+}
+
+// Delete deletes an entity
+func (t *TxExec) Delete(ctx context.Context, kind string, id any) error {
+	key, err := t.h.idKey(kind, id)
+	if err != nil {
+		return err
+	}
+
+	if err := t.h.saveHistory(t.tx, []*datastore.Key{key}); err != nil {
+		return err
+	}
+	if err := t.tx.Delete(key); err != nil {
+		return opError(op{name: "Delete", kind: kind}.on(key), err)
+	}
+	return t.audit(ctx, auditBatch{op: AuditDelete, keys: []*datastore.Key{key}}, nil)
+}
+
+// DeleteByKey deletes the entity stored under key
+func (t *TxExec) DeleteByKey(ctx context.Context, key *datastore.Key) error {
+	if err := t.h.checkKey(key, true); err != nil {
+		return err
+	}
+
+	if err := t.h.saveHistory(t.tx, []*datastore.Key{key}); err != nil {
+		return err
+	}
+	if err := t.tx.Delete(key); err != nil {
+		return opError(op{name: "DeleteByKey", kind: key.Kind}.on(key), err)
+	}
+	return t.audit(ctx, auditBatch{op: AuditDelete, keys: []*datastore.Key{key}}, nil)
+}
+
+// DeleteMulti deletes multiple entities
+func (t *TxExec) DeleteMulti(ctx context.Context, kind string, ids []any) error {
+	keys, err := t.h.idKeys(kind, ids)
+	if err != nil {
+		return err
+	}
+
+	if err := t.h.saveHistory(t.tx, keys); err != nil {
+		return err
+	}
+	if err := t.tx.DeleteMulti(keys); err != nil {
+		return opError(op{name: "DeleteMulti", kind: kind}.on(keys...), err)
+	}
+	return t.audit(ctx, auditBatch{op: AuditDelete, keys: keys}, nil)
+}
+
+// Patch sets the given properties on an existing entity without touching the
+// others
+func (t *TxExec) Patch(ctx context.Context, kind string, id any, changes map[string]any) error {
+	key, err := t.h.idKey(kind, id)
+	if err != nil {
+		return err
+	}
+
+	keys := []*datastore.Key{key}
+	before, after, err := t.h.patchEntities(t.tx, keys, t.h.withUpdatedAt(changes), t.h.audit != nil)
+	if err != nil {
+		return opError(op{name: "Patch", kind: kind}.on(key), err)
+	}
+	return t.audit(ctx, auditBatch{op: AuditPatch, keys: keys, before: auditProps(before), after: auditProps(after)}, nil)
+}
+
+// audit records b for a write made in the transaction: inside it with
+// WithTransactionalAudit, once Transaction commits otherwise, or right away
+// for a TxExec over a transaction of the caller's, who commits it
+func (t *TxExec) audit(ctx context.Context, b auditBatch, pending []*datastore.PendingKey) error {
+	if t.h.audit == nil {
+		return nil
+	}
+	if recorded, err := t.h.recordAuditInTx(ctx, t.tx, b); recorded {
+		return err
+	}
+	if t.audits == nil {
+		return t.h.recordAudit(ctx, b)
+	}
+	*t.audits = append(*t.audits, txAudit{batch: b, pending: pending})
+	return nil
+}
+
+// FindWhere retrieves entities matching filters as part of the transaction,
+// using the client stored in ctx. Datastore mode databases without
+// non-ancestor transactional query support reject such queries. Transactions
+// of other gostore.Client implementations run the query outside the
+// transaction.
+func (t *TxExec) FindWhere(ctx context.Context, kind string, filters map[string]any, dest any, opts ...QueryOption) error {
+	client, err := t.h.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	b := t.h.newBuilder(kind)
+
+	fb := builder.NewFilter().FromMap(filters)
+	for _, filter := range fb.Build() {
+		b.Filter(filter.Field, filter.Operator, filter.Value)
+	}
+	t.h.applyQueryOptions(b, newQueryOptions(opts))
+
+	if err := t.h.checkEncryptedQuery(b); err != nil {
+		return err
+	}
+
+	q := b.Build()
+	if dtx, ok := datastoreTx(t.tx); ok {
+		q = q.Transaction(dtx)
+	}
+
+	keys, err := client.GetAll(ctx, q, dest)
+	if err != nil {
+		return opError(op{name: "FindWhere", kind: kind, query: b}, err)
+	}
+	contextKey.SetIDs(dest, keys)
+	return nil
+}
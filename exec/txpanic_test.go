@@ -0,0 +1,76 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestTransactionPanic(t *testing.T) {
+	ctx := context.Background()
+
+	panicking := func(value any) func(tx *exec.TxExec) error {
+		return func(tx *exec.TxExec) error {
+			if err := tx.Create(ctx, "users", "john", &testutil.TestUser{Name: "John"}); err != nil {
+				return err
+			}
+			panic(value)
+		}
+	}
+
+	t.Run("Returns a PanicError and commits nothing", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+		commit, err := h.Transaction(ctx, panicking("boom"))
+		if commit != nil {
+			t.Error("expected no commit")
+		}
+		var pe *exec.PanicError
+		if !errors.As(err, &pe) || pe.Value != "boom" {
+			t.Fatalf("expected a PanicError for boom, got %v", err)
+		}
+		if err.Error() != "gostore: panic in transaction: boom" {
+			t.Errorf("unexpected message %q", err.Error())
+		}
+		if !strings.Contains(string(pe.Stack), "TestTransactionPanic") {
+			t.Errorf("expected the stack of the panic, got:\n%s", pe.Stack)
+		}
+		if n := mock.Count("users"); n != 0 {
+			t.Errorf("expected nothing committed, got %d users", n)
+		}
+		if n := len(mock.OperationsFor(testutil.OpRunInTransaction)); n != 1 {
+			t.Errorf("expected a single attempt, got %d", n)
+		}
+	})
+
+	t.Run("Panics with an error unwrap to it", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		h := exec.NewExecWithOptions(exec.WithClient(testutil.NewMockClient()))
+
+		if _, err := h.Transaction(ctx, panicking(errBoom)); !errors.Is(err, errBoom) {
+			t.Errorf("expected the error to match the panic value, got %v", err)
+		}
+	})
+
+	t.Run("WithRepanic", func(t *testing.T) {
+		type panicValue struct{ code int }
+		mock := testutil.NewMockClient()
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+
+		defer func() {
+			if v := recover(); v != (panicValue{code: 7}) {
+				t.Errorf("expected the original panic value, got %v", v)
+			}
+			if n := mock.Count("users"); n != 0 {
+				t.Errorf("expected nothing committed, got %d users", n)
+			}
+		}()
+		h.Transaction(ctx, panicking(panicValue{code: 7}), exec.WithRepanic())
+		t.Error("expected Transaction to panic")
+	})
+}
@@ -0,0 +1,91 @@
+package exec
+
+import (
+	"context"
+	"errors"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+)
+
+// UpdateWhereOptions configures UpdateWhereWithOptions
+type UpdateWhereOptions struct {
+	// OnBatch is called after every batch, including failed ones, like
+	// BulkOptions.OnBatch
+	OnBatch func(done, total int, keys []*datastore.Key, err error)
+
+	// Scope, if set, is applied to the query selecting the entities to
+	// update, like the Scope query option
+	Scope func(b *builder.Builder)
+}
+
+// UpdateWhere sets the properties in changes on every entity matching
+// filters, like Patch, and returns the number updated. Entities are selected
+// with a keys-only query, then patched in transactional batches of at most
+// MaxBatchSize, so properties outside changes are never overwritten. On an Exec
+// with WithSoftDelete, soft-deleted entities are left alone.
+//
+// Batches already written stay written when a later one fails or ctx is
+// cancelled; the error is then a *PartialError with the number updated.
+func (h *Exec) UpdateWhere(ctx context.Context, kind string, filters, changes map[string]any) (int, error) {
+	return h.UpdateWhereWithOptions(ctx, kind, filters, changes, UpdateWhereOptions{})
+}
+
+// UpdateWhereWithOptions updates entities matching filters like UpdateWhere,
+// reporting each batch through opts
+func (h *Exec) UpdateWhereWithOptions(ctx context.Context, kind string, filters, changes map[string]any, opts UpdateWhereOptions) (n int, err error) {
+	ctx, finish := h.startBulk(ctx, "UpdateWhere", kind)
+	defer func() { finish(n, err) }()
+
+	if len(changes) == 0 {
+		return 0, errors.New("at least one change is required")
+	}
+
+	client, err := h.clientFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	b := h.newBuilder(kind).KeysOnly()
+
+	fb := builder.NewFilter().FromMap(filters)
+	for _, filter := range fb.Build() {
+		b.Filter(filter.Field, filter.Operator, filter.Value)
+	}
+	if opts.Scope != nil {
+		opts.Scope(b)
+	}
+	h.applySoftDelete(b, queryOptions{})
+
+	o := op{name: "UpdateWhere", kind: kind}
+	keys, err := h.getKeys(ctx, o, client, b)
+	if err != nil {
+		return 0, err
+	}
+
+	changes = h.withUpdatedAt(changes)
+
+	o.write = true
+	n, err = inBatches(ctx, len(keys), h.batchSize(kind, MaxBatchSize), h.paced(ctx, func(start, end int) error {
+		batch := keys[start:end]
+		var pending *auditBatch
+		err := h.run(ctx, o.on(batch...), func(ctx context.Context) error {
+			var err error
+			pending, err = h.patchTx(ctx, client, batch, changes)
+			return err
+		})
+		if err == nil {
+			err = h.recordPending(ctx, pending)
+		}
+		if opts.OnBatch != nil {
+			opts.OnBatch(end, len(keys), batch, err)
+		}
+		return err
+	}))
+
+	var partial *PartialError
+	if err != nil && n > 0 && !errors.As(err, &partial) {
+		err = &PartialError{Completed: n, Err: err}
+	}
+	return n, err
+}
@@ -0,0 +1,83 @@
+package exec_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/exec"
+)
+
+type member struct {
+	Name      string    `datastore:"name"`
+	Status    string    `datastore:"status"`
+	LastLogin time.Time `datastore:"last_login"`
+	Address   address   `datastore:"address"`
+}
+
+type address struct {
+	City string `datastore:"city"`
+	Zip  string `datastore:"zip"`
+}
+
+func TestUpdateWhere(t *testing.T) {
+	ctx := emulatorContext(t)
+	h := exec.NewExec()
+
+	cutoff := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	members := make([]member, 1200)
+	for i := range members {
+		members[i] = member{Name: "member", Status: "active", Address: address{City: "Jakarta", Zip: "10110"}}
+		if i < 800 {
+			members[i].LastLogin = cutoff.AddDate(0, 0, -1-i%30)
+		} else {
+			members[i].LastLogin = cutoff.AddDate(0, 0, 1+i%30)
+		}
+	}
+	if err := h.BulkCreate(ctx, "members", members, 500); err != nil {
+		t.Fatalf("BulkCreate failed: %v", err)
+	}
+
+	n, err := h.UpdateWhere(ctx, "members",
+		map[string]any{"last_login <": cutoff},
+		map[string]any{"status": "archived", "address.city": "Bandung"})
+	if err != nil {
+		t.Fatalf("UpdateWhere failed: %v", err)
+	}
+	if n != 800 {
+		t.Errorf("expected 800 updated, got %d", n)
+	}
+
+	var archived []member
+	if err := h.FindWhere(ctx, "members", map[string]any{"status": "archived"}, &archived); err != nil {
+		t.Fatalf("FindWhere failed: %v", err)
+	}
+	if len(archived) != 800 {
+		t.Fatalf("expected 800 archived, got %d", len(archived))
+	}
+	for _, m := range archived[:10] {
+		if m.Name != "member" || m.Address.Zip != "10110" || m.Address.City != "Bandung" || !m.LastLogin.Before(cutoff) {
+			t.Errorf("unexpected archived member %+v", m)
+		}
+	}
+
+	count, err := h.Count(ctx, "members", []builder.FilterParam{{Field: "status", Operator: builder.Equal, Value: "active"}})
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if count != 400 {
+		t.Errorf("expected 400 members left active, got %d", count)
+	}
+
+	t.Run("Cancelled context stops between batches", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := h.UpdateWhere(cancelled, "members", nil, map[string]any{"status": "gone"})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+}
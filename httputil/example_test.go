@@ -0,0 +1,60 @@
+package httputil_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/AndroX7/gostore/httputil"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type Product struct {
+	ID    string `datastore:"-" json:"id"`
+	Name  string `datastore:"name" json:"name"`
+	Price int    `datastore:"price" json:"price"`
+}
+
+// listHandler serves a page of a repository's entities, filtered, ordered
+// and paged by the query string
+func listHandler(repo *repository.BaseRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params, err := httputil.ParseQueryRequest(r, httputil.WithMaxLimit(50))
+		if err != nil {
+			httputil.WriteError(w, err)
+			return
+		}
+
+		var products []Product
+		page, err := repo.QueryWithCursor(r.Context(), params, &products)
+		if err != nil {
+			httputil.WriteError(w, err)
+			return
+		}
+		httputil.WritePage(w, products, page)
+	}
+}
+
+func ExampleWritePage() {
+	ctx := context.Background()
+	repo := repository.NewBaseRepositoryWithClient(testutil.NewMockClient(), "products")
+	for i, name := range []string{"lamp", "desk", "chair"} {
+		product := Product{Name: name, Price: 10 * (i + 1)}
+		repo.Create(ctx, name, &product)
+	}
+
+	handler := listHandler(repo)
+	for _, url := range []string{
+		"/products?price[gte]=20&order_by=-price",
+		"/products?limit=abc",
+	} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, url, nil))
+		fmt.Print(w.Code, " ", w.Body)
+	}
+	// Output:
+	// 200 {"data":[{"id":"chair","name":"chair","price":30},{"id":"desk","name":"desk","price":20}],"next_cursor":"","has_more":false,"total":2}
+	// 400 {"error":"invalid query parameter: limit must be a non-negative integer, got \"abc\""}
+}
@@ -0,0 +1,309 @@
+// Package httputil is the HTTP glue between gostore queries and JSON APIs:
+// ParseQueryRequest turns a request's query string into builder.QueryParams
+// and WritePage and WriteError write the results, or the error, as JSON.
+//
+//	func listUsers(w http.ResponseWriter, r *http.Request) {
+//		params, err := httputil.ParseQueryRequest(r, httputil.WithMaxLimit(50))
+//		if err != nil {
+//			httputil.WriteError(w, err)
+//			return
+//		}
+//		var users []*User
+//		page, err := repo.QueryWithCursor(r.Context(), params, &users)
+//		if err != nil {
+//			httputil.WriteError(w, err)
+//			return
+//		}
+//		httputil.WritePage(w, users, page)
+//	}
+package httputil
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndroX7/gostore/builder"
+)
+
+// ErrInvalidParam is matched by the errors ParseQueryRequest returns for a
+// malformed query string. WriteError answers them with 400 Bad Request.
+var ErrInvalidParam = errors.New("invalid query parameter")
+
+// Limits used when no option sets them
+const (
+	DefaultLimit    = 20
+	DefaultMaxLimit = 100
+)
+
+// Option configures ParseQueryRequest
+type Option func(*config)
+
+type config struct {
+	defaultLimit int
+	maxLimit     int
+	fields       []string
+}
+
+// WithDefaultLimit sets the limit of requests without one
+func WithDefaultLimit(n int) Option {
+	return func(c *config) {
+		c.defaultLimit = n
+	}
+}
+
+// WithMaxLimit sets the largest limit a request can ask for; larger limits
+// are lowered to it. 0 removes the cap.
+func WithMaxLimit(n int) Option {
+	return func(c *config) {
+		c.maxLimit = n
+	}
+}
+
+// WithFields restricts the properties requests can filter, order and select
+// by. Other properties are rejected with ErrInvalidParam. By default every
+// property is allowed.
+func WithFields(fields ...string) Option {
+	return func(c *config) {
+		c.fields = append(c.fields, fields...)
+	}
+}
+
+// ParseQueryRequest parses the query string of r with ParseQueryValues
+func ParseQueryRequest(r *http.Request, opts ...Option) (*builder.QueryParams, error) {
+	return ParseQueryValues(r.URL.Query(), opts...)
+}
+
+// ParseQueryValues parses query string values into query params:
+//
+//   - limit and offset take non-negative integers. The limit defaults to
+//     DefaultLimit and is capped at DefaultMaxLimit, which a limit of 0 asks
+//     for, see WithDefaultLimit and WithMaxLimit.
+//   - cursor takes the NextCursor of the previous page and must be valid (see
+//     builder.ValidateCursor)
+//   - order_by takes a field, "-field" or "field desc" for descending order,
+//     or several of those comma-separated or repeated
+//   - select takes comma-separated fields to project, and distinct=true
+//     removes duplicate projections
+//   - any other key filters by a property: "status=active" for equality,
+//     "age[gte]=18" with an operator name of builder.ParseOperator, and
+//     "role[in]=admin,editor" or a repeated "role=admin&role=editor" for In
+//
+// Filter values are typed the way they read: integers become int64, other
+// numbers float64, true and false bool, null nil and RFC 3339 times
+// time.Time. Everything else, and anything in double quotes such as "\"42\"",
+// stays a string. Filters are sorted by field so the query is the same
+// whatever the parameter order.
+func ParseQueryValues(values url.Values, opts ...Option) (*builder.QueryParams, error) {
+	c := config{defaultLimit: DefaultLimit, maxLimit: DefaultMaxLimit}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	allowed := func(field string) error {
+		if len(c.fields) > 0 && !slices.Contains(c.fields, field) {
+			return invalid("unknown field %q", field)
+		}
+		return nil
+	}
+
+	params := &builder.QueryParams{Limit: c.defaultLimit}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		vals := values[key]
+		switch key {
+		case "limit":
+			n, err := paramInt(key, vals)
+			if err != nil {
+				return nil, err
+			}
+			params.Limit = n
+		case "offset":
+			n, err := paramInt(key, vals)
+			if err != nil {
+				return nil, err
+			}
+			params.Offset = n
+		case "cursor":
+			cursor := vals[len(vals)-1]
+			if err := builder.ValidateCursor(cursor); err != nil {
+				return nil, err
+			}
+			params.Cursor = cursor
+		case "order_by":
+			for _, spec := range splitList(vals) {
+				order, err := parseOrder(spec)
+				if err != nil {
+					return nil, err
+				}
+				if err := allowed(order.Field); err != nil {
+					return nil, err
+				}
+				params.Orders = append(params.Orders, order)
+			}
+		case "select":
+			for _, field := range splitList(vals) {
+				if err := allowed(field); err != nil {
+					return nil, err
+				}
+				params.Select = append(params.Select, field)
+			}
+		case "distinct":
+			distinct, err := strconv.ParseBool(vals[len(vals)-1])
+			if err != nil {
+				return nil, invalid("distinct must be true or false, got %q", vals[len(vals)-1])
+			}
+			params.Distinct = distinct
+		default:
+			filter, err := parseFilter(key, vals)
+			if err != nil {
+				return nil, err
+			}
+			if err := allowed(filter.Field); err != nil {
+				return nil, err
+			}
+			params.Filters = append(params.Filters, filter)
+		}
+	}
+
+	if params.Distinct && len(params.Select) == 0 {
+		return nil, invalid("distinct needs select")
+	}
+	if c.maxLimit > 0 && (params.Limit > c.maxLimit || params.Limit == 0) {
+		params.Limit = c.maxLimit
+	}
+	return params, nil
+}
+
+// invalid returns an error matching ErrInvalidParam
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidParam, fmt.Sprintf(format, args...))
+}
+
+// paramInt parses the last value of a limit or offset param
+func paramInt(key string, vals []string) (int, error) {
+	s := strings.TrimSpace(vals[len(vals)-1])
+	n, err := strconv.ParseInt(s, 10, 0)
+	if err != nil || n < 0 || n > math.MaxInt32 {
+		return 0, invalid("%s must be a non-negative integer, got %q", key, s)
+	}
+	return int(n), nil
+}
+
+// splitList splits comma-separated values, dropping empty entries
+func splitList(vals []string) []string {
+	var list []string
+	for _, v := range vals {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+	}
+	return list
+}
+
+// parseOrder parses an order_by entry
+func parseOrder(spec string) (builder.OrderParam, error) {
+	fields := strings.Fields(spec)
+	switch {
+	case len(fields) == 1 && strings.HasPrefix(fields[0], "-") && len(fields[0]) > 1:
+		return builder.OrderParam{Field: fields[0][1:], Direction: builder.Descending}, nil
+	case len(fields) == 1 && !strings.HasPrefix(fields[0], "-"):
+		return builder.OrderParam{Field: fields[0], Direction: builder.Ascending}, nil
+	case len(fields) == 2 && strings.EqualFold(fields[1], "desc"):
+		return builder.OrderParam{Field: fields[0], Direction: builder.Descending}, nil
+	case len(fields) == 2 && strings.EqualFold(fields[1], "asc"):
+		return builder.OrderParam{Field: fields[0], Direction: builder.Ascending}, nil
+	}
+	return builder.OrderParam{}, invalid("invalid order %q", spec)
+}
+
+// parseFilter parses a "field" or "field[op]" param
+func parseFilter(key string, vals []string) (builder.FilterParam, error) {
+	field, op := key, ""
+	if i := strings.IndexByte(key, '['); i >= 0 && strings.HasSuffix(key, "]") {
+		field, op = key[:i], key[i+1:len(key)-1]
+	}
+	operator, ok := builder.ParseOperator(op)
+	if !ok || field == "" {
+		return builder.FilterParam{}, invalid("invalid filter %q", key)
+	}
+
+	if operator == builder.In || (operator == builder.Equal && len(vals) > 1) {
+		var list []interface{}
+		for _, s := range splitList(vals) {
+			list = append(list, parseValue(s))
+		}
+		if len(list) == 0 {
+			return builder.FilterParam{}, invalid("%s needs at least one value", key)
+		}
+		return builder.FilterParam{Field: field, Operator: builder.In, Value: list}, nil
+	}
+	if len(vals) > 1 {
+		return builder.FilterParam{}, invalid("%s is given %d times", key, len(vals))
+	}
+	return builder.FilterParam{Field: field, Operator: operator, Value: parseValue(vals[0])}, nil
+}
+
+// parseValue types a filter value, see ParseQueryValues
+func parseValue(s string) interface{} {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+		return s[1 : len(s)-1]
+	}
+
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if !numeric(s) {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t
+		}
+		return s
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+// numeric reports whether s reads as a plain decimal number. Leading zeros,
+// as in zip codes, keep a value a string.
+func numeric(s string) bool {
+	digits := strings.TrimPrefix(s, "-")
+	if digits == "" || (len(digits) > 1 && digits[0] == '0' && digits[1] != '.') {
+		return false
+	}
+	dot := false
+	for i, r := range digits {
+		switch {
+		case r >= '0' && r <= '9':
+		case r == '.' && !dot && i > 0 && i < len(digits)-1:
+			dot = true
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package httputil
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AndroX7/gostore/builder"
+)
+
+func TestParseQueryValues(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		query string
+		opts  []Option
+		want  *builder.QueryParams
+	}{
+		{"empty", "", nil, &builder.QueryParams{Limit: DefaultLimit}},
+		{"paging", "limit=10&offset=30", nil, &builder.QueryParams{Limit: 10, Offset: 30}},
+		{"limit capped", "limit=500", nil, &builder.QueryParams{Limit: DefaultMaxLimit}},
+		{"zero limit is the cap", "limit=0", []Option{WithMaxLimit(40)}, &builder.QueryParams{Limit: 40}},
+		{"no cap", "limit=500", []Option{WithMaxLimit(0)}, &builder.QueryParams{Limit: 500}},
+		{"default limit", "", []Option{WithDefaultLimit(5)}, &builder.QueryParams{Limit: 5}},
+		{"orders", "order_by=-age,name&order_by=email+desc", nil, &builder.QueryParams{
+			Limit: DefaultLimit,
+			Orders: []builder.OrderParam{
+				{Field: "age", Direction: builder.Descending},
+				{Field: "name", Direction: builder.Ascending},
+				{Field: "email", Direction: builder.Descending},
+			},
+		}},
+		{"projection", "select=status,age&distinct=true", nil, &builder.QueryParams{
+			Limit: DefaultLimit, Select: []string{"status", "age"}, Distinct: true,
+		}},
+		{"typed filters", "status=active&age[gte]=18&score[lt]=9.5&verified=true&deleted_at=null&created_at[gt]=2024-05-01T12:00:00Z", nil, &builder.QueryParams{
+			Limit: DefaultLimit,
+			Filters: []builder.FilterParam{
+				{Field: "age", Operator: builder.GreaterThanOrEqual, Value: int64(18)},
+				{Field: "created_at", Operator: builder.GreaterThan, Value: created},
+				{Field: "deleted_at", Operator: builder.Equal, Value: nil},
+				{Field: "score", Operator: builder.LessThan, Value: 9.5},
+				{Field: "status", Operator: builder.Equal, Value: "active"},
+				{Field: "verified", Operator: builder.Equal, Value: true},
+			},
+		}},
+		{"strings that look like other types", `zip=01234&code="42"&name=1.2.3`, nil, &builder.QueryParams{
+			Limit: DefaultLimit,
+			Filters: []builder.FilterParam{
+				{Field: "code", Operator: builder.Equal, Value: "42"},
+				{Field: "name", Operator: builder.Equal, Value: "1.2.3"},
+				{Field: "zip", Operator: builder.Equal, Value: "01234"},
+			},
+		}},
+		{"in", "role[in]=admin,editor&tier=1&tier=2", nil, &builder.QueryParams{
+			Limit: DefaultLimit,
+			Filters: []builder.FilterParam{
+				{Field: "role", Operator: builder.In, Value: []interface{}{"admin", "editor"}},
+				{Field: "tier", Operator: builder.In, Value: []interface{}{int64(1), int64(2)}},
+			},
+		}},
+		{"allowed fields", "status=active&order_by=age", []Option{WithFields("status", "age")}, &builder.QueryParams{
+			Limit:   DefaultLimit,
+			Filters: []builder.FilterParam{{Field: "status", Operator: builder.Equal, Value: "active"}},
+			Orders:  []builder.OrderParam{{Field: "age", Direction: builder.Ascending}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseQueryValues(values, tt.opts...)
+			if err != nil {
+				t.Fatalf("ParseQueryValues: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseQueryValuesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		opts  []Option
+	}{
+		{"limit not a number", "limit=ten", nil},
+		{"negative offset", "offset=-1", nil},
+		{"bad order", "order_by=age+sideways", nil},
+		{"bare dash order", "order_by=-", nil},
+		{"unknown operator", "age[about]=3", nil},
+		{"missing field", "[gt]=3", nil},
+		{"repeated inequality", "age[gt]=1&age[gt]=2", nil},
+		{"empty in", "role[in]=", nil},
+		{"bad distinct", "select=a&distinct=maybe", nil},
+		{"distinct without select", "distinct=true", nil},
+		{"unknown filter field", "password=x", []Option{WithFields("status")}},
+		{"unknown order field", "order_by=password", []Option{WithFields("status")}},
+		{"unknown select field", "select=password", []Option{WithFields("status")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ParseQueryValues(values, tt.opts...); !errors.Is(err, ErrInvalidParam) {
+				t.Errorf("expected ErrInvalidParam, got %v", err)
+			}
+		})
+	}
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		_, err := ParseQueryValues(url.Values{"cursor": {"not a cursor!"}})
+		if !errors.Is(err, builder.ErrInvalidCursor) {
+			t.Errorf("expected ErrInvalidCursor, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/exec"
+)
+
+// Page is the JSON envelope WritePage writes. Every field is always present,
+// with data an empty array rather than null for no results.
+type Page struct {
+	Data       any    `json:"data"`
+	NextCursor string `json:"next_cursor"`
+	HasMore    bool   `json:"has_more"`
+	Total      int    `json:"total"`
+}
+
+// ErrorResponse is the JSON body WriteError writes
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// WritePage writes data, usually the slice a query filled, and the
+// pagination p returned with it as a Page with status 200. A nil p writes a
+// page without a next cursor, totalling the length of data.
+func WritePage(w http.ResponseWriter, data any, p *builder.PaginationResult) {
+	page := Page{Data: data}
+	v := reflect.ValueOf(data)
+	switch {
+	case data == nil:
+		page.Data = []any{}
+	case v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Slice:
+		v = v.Elem()
+		page.Data = v.Interface()
+	}
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		page.Data = []any{}
+	}
+
+	if p != nil {
+		page.NextCursor = p.NextCursor
+		page.HasMore = p.HasMore
+		page.Total = p.Total
+	} else if v.Kind() == reflect.Slice {
+		page.Total = v.Len()
+	}
+	WriteJSON(w, http.StatusOK, page)
+}
+
+// WriteError writes err as an ErrorResponse with the status StatusCode maps
+// it to. The messages of server errors are not exposed; they read
+// "internal server error".
+func WriteError(w http.ResponseWriter, err error) {
+	status := StatusCode(err)
+	msg := err.Error()
+	if status >= 500 {
+		msg = http.StatusText(status)
+		if status == http.StatusInternalServerError {
+			msg = "internal server error"
+		}
+	}
+	WriteJSON(w, status, ErrorResponse{Error: msg})
+}
+
+// StatusCode maps an error of a gostore call to an HTTP status:
+// exec.ErrNotFound (and datastore.ErrNoSuchEntity) is 404,
+// ErrInvalidParam and builder.ErrInvalidCursor 400, an expired deadline 504
+// and anything else 500
+func StatusCode(err error) int {
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, exec.ErrNotFound), errors.Is(err, datastore.ErrNoSuchEntity):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidParam), errors.Is(err, builder.ErrInvalidCursor):
+		return http.StatusBadRequest
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// WriteJSON writes v as JSON with status. If v can't be encoded, a 500
+// ErrorResponse is written instead.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		body, _ = json.Marshal(ErrorResponse{Error: "internal server error"})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(body, '\n'))
+}
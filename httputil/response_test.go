@@ -0,0 +1,194 @@
+package httputil_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/httputil"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+// page is httputil.Page with the data decoded as products
+type page struct {
+	Data       []Product `json:"data"`
+	NextCursor string    `json:"next_cursor"`
+	HasMore    bool      `json:"has_more"`
+	Total      int       `json:"total"`
+}
+
+func newProductServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	ctx := context.Background()
+	repo := repository.NewBaseRepositoryWithClient(testutil.NewMockClient(), "products")
+	for i := 0; i < 25; i++ {
+		product := Product{Name: fmt.Sprintf("product %02d", i), Price: i}
+		if err := repo.Create(ctx, fmt.Sprintf("p%02d", i), &product); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /products", listHandler(repo))
+	mux.HandleFunc("GET /products/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var product Product
+		if err := repo.GetByID(r.Context(), r.PathValue("id"), &product); err != nil {
+			httputil.WriteError(w, err)
+			return
+		}
+		httputil.WriteJSON(w, http.StatusOK, product)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, url string, out any) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected a JSON response, got %q", ct)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("decoding the response of %s: %v", url, err)
+	}
+	return resp.StatusCode
+}
+
+func TestListHandler(t *testing.T) {
+	srv := newProductServer(t)
+
+	t.Run("pages through with cursors", func(t *testing.T) {
+		var prices []int
+		query := url.Values{"price[gte]": {"5"}, "order_by": {"price"}, "limit": {"8"}}
+		for pages := 0; ; pages++ {
+			if pages > 3 {
+				t.Fatal("paging did not stop")
+			}
+			var p page
+			if status := get(t, srv.URL+"/products?"+query.Encode(), &p); status != http.StatusOK {
+				t.Fatalf("expected 200, got %d", status)
+			}
+			if p.Total != len(p.Data) {
+				t.Errorf("expected total %d, got %d", len(p.Data), p.Total)
+			}
+			for _, product := range p.Data {
+				prices = append(prices, product.Price)
+			}
+			if !p.HasMore {
+				if p.NextCursor != "" {
+					t.Errorf("expected no cursor on the last page, got %q", p.NextCursor)
+				}
+				break
+			}
+			query.Set("cursor", p.NextCursor)
+		}
+
+		if len(prices) != 20 {
+			t.Fatalf("expected 20 products, got %v", prices)
+		}
+		for i, price := range prices {
+			if price != i+5 {
+				t.Fatalf("expected prices 5 to 24 in order, got %v", prices)
+			}
+		}
+	})
+
+	t.Run("empty page", func(t *testing.T) {
+		var body map[string]any
+		if status := get(t, srv.URL+"/products?price[gt]=100", &body); status != http.StatusOK {
+			t.Fatalf("expected 200, got %d", status)
+		}
+		data, ok := body["data"].([]any)
+		if !ok || len(data) != 0 {
+			t.Errorf("expected an empty data array, got %v", body["data"])
+		}
+		for _, key := range []string{"next_cursor", "has_more", "total"} {
+			if _, ok := body[key]; !ok {
+				t.Errorf("expected %s in %v", key, body)
+			}
+		}
+	})
+
+	t.Run("bad requests", func(t *testing.T) {
+		for _, query := range []string{"limit=-3", "cursor=garbage!", "price[near]=3"} {
+			var body httputil.ErrorResponse
+			if status := get(t, srv.URL+"/products?"+query, &body); status != http.StatusBadRequest {
+				t.Errorf("%s: expected 400, got %d", query, status)
+			}
+			if body.Error == "" {
+				t.Errorf("%s: expected an error message", query)
+			}
+		}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		var product Product
+		if status := get(t, srv.URL+"/products/p07", &product); status != http.StatusOK || product.Price != 7 {
+			t.Errorf("expected 200 and product p07, got %d %+v", status, product)
+		}
+
+		var body httputil.ErrorResponse
+		if status := get(t, srv.URL+"/products/missing", &body); status != http.StatusNotFound {
+			t.Errorf("expected 404, got %d %+v", status, body)
+		}
+	})
+}
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, http.StatusOK},
+		{exec.ErrNotFound, http.StatusNotFound},
+		{fmt.Errorf("users 1: %w", datastore.ErrNoSuchEntity), http.StatusNotFound},
+		{httputil.ErrInvalidParam, http.StatusBadRequest},
+		{builder.ValidateCursor("!"), http.StatusBadRequest},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := httputil.StatusCode(tt.err); got != tt.want {
+			t.Errorf("StatusCode(%v): expected %d, got %d", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestWriteErrorHidesServerErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	httputil.WriteError(w, errors.New("dial tcp 10.0.0.1: connection refused"))
+
+	var body httputil.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusInternalServerError || body.Error != "internal server error" {
+		t.Errorf("expected a 500 without details, got %d %q", w.Code, body.Error)
+	}
+}
+
+func TestWritePageWithoutPagination(t *testing.T) {
+	w := httptest.NewRecorder()
+	httputil.WritePage(w, &[]string{"a", "b"}, nil)
+
+	want := `{"data":["a","b"],"next_cursor":"","has_more":false,"total":2}` + "\n"
+	if w.Code != http.StatusOK || w.Body.String() != want {
+		t.Errorf("expected 200 %s, got %d %s", want, w.Code, w.Body)
+	}
+}
@@ -0,0 +1,173 @@
+package key
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidComposite is returned by SplitComposite for a string Composite
+// can't have produced
+var ErrInvalidComposite = errors.New("invalid composite key")
+
+const (
+	compositeSep    = '|'
+	compositeEscape = '\\'
+)
+
+// Composite joins parts into one key name, e.g. a tenant ID and an email,
+// with "|" between them. A "|" or "\" inside a part is escaped with a "\", so
+// SplitComposite returns the parts as given whatever they contain. No parts
+// give the same name as one empty part.
+func Composite(parts ...string) string {
+	var b strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			b.WriteByte(compositeSep)
+		}
+		for j := 0; j < len(part); j++ {
+			if c := part[j]; c == compositeSep || c == compositeEscape {
+				b.WriteByte(compositeEscape)
+			}
+			b.WriteByte(part[j])
+		}
+	}
+	return b.String()
+}
+
+// SplitComposite returns the parts of a name made by Composite. It returns
+// an error matching ErrInvalidComposite if s has a "\" escaping anything but
+// "|" or "\".
+func SplitComposite(s string) ([]string, error) {
+	var parts []string
+	var part strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case compositeEscape:
+			if i+1 == len(s) || (s[i+1] != compositeSep && s[i+1] != compositeEscape) {
+				return nil, fmt.Errorf("%w: %q has a stray %q at %d", ErrInvalidComposite, s, compositeEscape, i)
+			}
+			i++
+			part.WriteByte(s[i])
+		case compositeSep:
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteByte(c)
+		}
+	}
+	return append(parts, part.String()), nil
+}
+
+// keyPart is a field tagged gostore:"keypart=N"
+type keyPart struct {
+	index int
+	n     int
+}
+
+type keyPartsResult struct {
+	parts []keyPart
+	err   error
+}
+
+// keyPartFields caches the key parts of each struct type
+var keyPartFields sync.Map
+
+// CompositeID returns the Composite of the fields of the struct src is or
+// points to tagged gostore:"keypart=N", in the order of their N, e.g.
+//
+//	type Member struct {
+//		TenantID string `datastore:"tenant_id" gostore:"keypart=1"`
+//		Email    string `datastore:"email" gostore:"keypart=2"`
+//	}
+//
+// Key parts are strings or integers, written in decimal. ok is false if src
+// has none.
+func CompositeID(src any) (id string, ok bool, err error) {
+	v := reflect.ValueOf(src)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", false, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", false, nil
+	}
+
+	fields, err := keyParts(v.Type())
+	if err != nil || len(fields) == 0 {
+		return "", false, err
+	}
+
+	parts := make([]string, len(fields))
+	for i, p := range fields {
+		f := v.Field(p.index)
+		switch {
+		case f.Kind() == reflect.String:
+			parts[i] = f.String()
+		case f.CanInt():
+			parts[i] = strconv.FormatInt(f.Int(), 10)
+		default:
+			parts[i] = strconv.FormatUint(f.Uint(), 10)
+		}
+	}
+	return Composite(parts...), true, nil
+}
+
+// keyParts returns the key parts of struct type t in order
+func keyParts(t reflect.Type) ([]keyPart, error) {
+	if r, ok := keyPartFields.Load(t); ok {
+		r := r.(keyPartsResult)
+		return r.parts, r.err
+	}
+
+	var r keyPartsResult
+	for i := 0; i < t.NumField() && r.err == nil; i++ {
+		f := t.Field(i)
+		for _, opt := range strings.Split(f.Tag.Get("gostore"), ",") {
+			s, ok := strings.CutPrefix(opt, "keypart=")
+			if !ok {
+				continue
+			}
+			n, err := strconv.Atoi(s)
+			switch {
+			case err != nil || n < 1:
+				r.err = fmt.Errorf("field %s.%s: invalid keypart %q", t.Name(), f.Name, s)
+			case !f.IsExported():
+				r.err = fmt.Errorf("field %s.%s: keypart on an unexported field", t.Name(), f.Name)
+			case f.Type.Kind() != reflect.String && !isInteger(f.Type.Kind()):
+				r.err = fmt.Errorf("field %s.%s: keypart on a %s, not a string or integer", t.Name(), f.Name, f.Type)
+			}
+			r.parts = append(r.parts, keyPart{index: i, n: n})
+		}
+	}
+	if r.err == nil {
+		slices.SortFunc(r.parts, func(a, b keyPart) int { return cmp.Compare(a.n, b.n) })
+		for i := 1; i < len(r.parts); i++ {
+			if r.parts[i].n == r.parts[i-1].n {
+				r.err = fmt.Errorf("%s has two fields with keypart=%d", t.Name(), r.parts[i].n)
+			}
+		}
+	}
+	if r.err != nil {
+		r.parts = nil
+	}
+
+	keyPartFields.Store(t, r)
+	return r.parts, r.err
+}
+
+func isInteger(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
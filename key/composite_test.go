@@ -0,0 +1,82 @@
+package key
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestComposite(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts []string
+		want  string
+	}{
+		{"plain parts", []string{"acme", "bob@example.com"}, "acme|bob@example.com"},
+		{"separator in a part", []string{"a|b", "c"}, `a\|b|c`},
+		{"escape in a part", []string{`a\`, `|\b`}, `a\\|\|\\b`},
+		{"empty parts", []string{"", "x", ""}, "|x|"},
+		{"single part", []string{"solo"}, "solo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Composite(tt.parts...)
+			if got != tt.want {
+				t.Errorf("Composite(%q) = %q, want %q", tt.parts, got, tt.want)
+			}
+			parts, err := SplitComposite(got)
+			if err != nil || !slices.Equal(parts, tt.parts) {
+				t.Errorf("SplitComposite(%q) = %q, %v, want %q", got, parts, err, tt.parts)
+			}
+			if again := Composite(parts...); again != got {
+				t.Errorf("expected a stable round trip, got %q then %q", got, again)
+			}
+		})
+	}
+
+	t.Run("Distinct parts give distinct names", func(t *testing.T) {
+		if Composite("a|b", "c") == Composite("a", "b|c") {
+			t.Error("expected escaping to keep part boundaries")
+		}
+	})
+
+	t.Run("Stray escapes are invalid", func(t *testing.T) {
+		for _, s := range []string{`a\`, `a\b`} {
+			if _, err := SplitComposite(s); !errors.Is(err, ErrInvalidComposite) {
+				t.Errorf("SplitComposite(%q): expected ErrInvalidComposite, got %v", s, err)
+			}
+		}
+	})
+}
+
+func TestCompositeID(t *testing.T) {
+	type member struct {
+		Email    string `datastore:"email" gostore:"keypart=2"`
+		TenantID int64  `datastore:"tenant_id" gostore:"keypart=1"`
+		Name     string `datastore:"name"`
+	}
+
+	id, ok, err := CompositeID(&member{Email: "a|b@example.com", TenantID: 42})
+	if err != nil || !ok || id != `42|a\|b@example.com` {
+		t.Errorf("got %q, %v, %v", id, ok, err)
+	}
+
+	if _, ok, err := CompositeID(namedEntity{}); ok || err != nil {
+		t.Errorf("expected no composite ID without key parts, got %v, %v", ok, err)
+	}
+
+	type duplicate struct {
+		A string `gostore:"keypart=1"`
+		B string `gostore:"keypart=1"`
+	}
+	if _, _, err := CompositeID(duplicate{}); err == nil {
+		t.Error("expected an error for duplicate key parts")
+	}
+
+	type unsupported struct {
+		A float64 `gostore:"keypart=1"`
+	}
+	if _, _, err := CompositeID(unsupported{}); err == nil {
+		t.Error("expected an error for a float key part")
+	}
+}
@@ -0,0 +1,60 @@
+package key
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AndroX7/gostore"
+)
+
+// ErrClientNotInitialized is returned when a context carries no client under
+// NOSQL_KEY, or none under the name asked for
+var ErrClientNotInitialized = gostore.ErrClientNotInitialized
+
+// WithClient returns a copy of ctx carrying c under NOSQL_KEY, where
+// ClientFromContext and exec look for it. c may be a *datastore.Client or a
+// gostore.Client.
+func WithClient(ctx context.Context, c any) context.Context {
+	return context.WithValue(ctx, NOSQL_KEY, c)
+}
+
+// ClientFromContext returns the client stored in ctx under NOSQL_KEY, which
+// may be a *datastore.Client or a gostore.Client
+func ClientFromContext(ctx context.Context) (gostore.Client, error) {
+	if client, ok := gostore.FromAny(ctx.Value(NOSQL_KEY)); ok {
+		return client, nil
+	}
+	return nil, ErrClientNotInitialized
+}
+
+// namedClientsKey is the context key of the clients stored by
+// WithNamedClient
+type namedClientsKey struct{}
+
+// WithNamedClient returns a copy of ctx carrying c under name, for services
+// that talk to several projects. c may be a *datastore.Client or a
+// gostore.Client. The clients already named in ctx are kept, and the default
+// client under NOSQL_KEY is left alone.
+func WithNamedClient(ctx context.Context, name string, c any) context.Context {
+	parent, _ := ctx.Value(namedClientsKey{}).(map[string]any)
+	clients := make(map[string]any, len(parent)+1)
+	for n, client := range parent {
+		clients[n] = client
+	}
+	clients[name] = c
+	return context.WithValue(ctx, namedClientsKey{}, clients)
+}
+
+// NamedClientFromContext returns the client stored in ctx under name by
+// WithNamedClient, or an error matching ErrClientNotInitialized that mentions
+// name. An empty name returns the default client, like ClientFromContext.
+func NamedClientFromContext(ctx context.Context, name string) (gostore.Client, error) {
+	if name == "" {
+		return ClientFromContext(ctx)
+	}
+	clients, _ := ctx.Value(namedClientsKey{}).(map[string]any)
+	if client, ok := gostore.FromAny(clients[name]); ok {
+		return client, nil
+	}
+	return nil, fmt.Errorf("%w: no client named %q", ErrClientNotInitialized, name)
+}
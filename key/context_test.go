@@ -0,0 +1,54 @@
+package key_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/AndroX7/gostore"
+	contextKey "github.com/AndroX7/gostore/key"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestNamedClients(t *testing.T) {
+	operational, analytics := testutil.NewMockClient(), testutil.NewMockClient()
+	def := testutil.NewMockClient()
+
+	ctx := contextKey.WithClient(context.Background(), def)
+	ctx = contextKey.WithNamedClient(ctx, "operational", operational)
+	withBoth := contextKey.WithNamedClient(ctx, "analytics", analytics)
+
+	lookups := []struct {
+		ctx  context.Context
+		name string
+		want gostore.Client
+	}{
+		{withBoth, "operational", operational},
+		{withBoth, "analytics", analytics},
+		{withBoth, "", def},
+		{ctx, "operational", operational},
+	}
+	for _, l := range lookups {
+		got, err := contextKey.NamedClientFromContext(l.ctx, l.name)
+		if err != nil || got != l.want {
+			t.Errorf("contextKey.NamedClientFromContext(%q): expected %p, got %p, %v", l.name, l.want, got, err)
+		}
+	}
+	if got, err := contextKey.ClientFromContext(withBoth); err != nil || got != def {
+		t.Errorf("expected the default client to be kept, got %v, %v", got, err)
+	}
+
+	// Adding a name must not leak into the parent context
+	_, err := contextKey.NamedClientFromContext(ctx, "analytics")
+	if !errors.Is(err, contextKey.ErrClientNotInitialized) || !strings.Contains(err.Error(), `"analytics"`) {
+		t.Errorf("expected contextKey.ErrClientNotInitialized naming analytics, got %v", err)
+	}
+
+	if _, err := contextKey.NamedClientFromContext(context.Background(), ""); !errors.Is(err, contextKey.ErrClientNotInitialized) {
+		t.Errorf("expected contextKey.ErrClientNotInitialized without a default client, got %v", err)
+	}
+	if _, err := contextKey.NamedClientFromContext(contextKey.WithNamedClient(ctx, "nil", nil), "nil"); !errors.Is(err, contextKey.ErrClientNotInitialized) {
+		t.Errorf("expected contextKey.ErrClientNotInitialized for a nil client, got %v", err)
+	}
+}
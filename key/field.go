@@ -0,0 +1,147 @@
+package key
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/datastore"
+)
+
+// idFields caches the index of the ID field of each struct type, or -1
+var idFields sync.Map
+
+var keyType = reflect.TypeOf((*datastore.Key)(nil))
+
+// SetID copies k into the ID field of the struct dest points to. The ID field
+// is the one whose gostore tag includes "id" (`gostore:"id"`), or else a field
+// named ID tagged `datastore:"-"`. A string field gets the key's name, or its
+// numeric ID in decimal; an integer field gets the numeric ID and a
+// *datastore.Key field the key itself. Types implementing datastore.KeyLoader,
+// which load their own key, and destinations without an ID field are left
+// alone.
+func SetID(dest any, k *datastore.Key) {
+	if k == nil {
+		return
+	}
+	if _, ok := dest.(datastore.KeyLoader); ok {
+		return
+	}
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return
+	}
+	setID(v.Elem(), k)
+}
+
+// SetIDs copies keys into the ID fields of the elements of dest, a slice or a
+// pointer to a slice of structs or struct pointers, aligned by index
+func SetIDs(dest any, keys []*datastore.Key) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice {
+		return
+	}
+
+	for i := 0; i < v.Len() && i < len(keys); i++ {
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				continue
+			}
+			elem = elem.Elem()
+		}
+		if keys[i] == nil || !elem.CanAddr() {
+			continue
+		}
+		if _, ok := elem.Addr().Interface().(datastore.KeyLoader); ok {
+			continue
+		}
+		setID(elem, keys[i])
+	}
+}
+
+// ID returns the value of the ID field (see SetID) of the struct src is or
+// points to. ok is false if src is not a struct or has no ID field.
+func ID(src any) (id any, ok bool) {
+	v := reflect.ValueOf(src)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	i := idField(v.Type())
+	if i < 0 {
+		return nil, false
+	}
+	return v.Field(i).Interface(), true
+}
+
+func setID(v reflect.Value, k *datastore.Key) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	i := idField(v.Type())
+	if i < 0 {
+		return
+	}
+
+	f := v.Field(i)
+	switch {
+	case f.Type() == keyType:
+		f.Set(reflect.ValueOf(k))
+	case f.Kind() == reflect.String:
+		if k.Name != "" {
+			f.SetString(k.Name)
+		} else {
+			f.SetString(strconv.FormatInt(k.ID, 10))
+		}
+	case f.CanInt():
+		if k.Name == "" && !f.OverflowInt(k.ID) {
+			f.SetInt(k.ID)
+		}
+	case f.CanUint():
+		if k.Name == "" && k.ID >= 0 && !f.OverflowUint(uint64(k.ID)) {
+			f.SetUint(uint64(k.ID))
+		}
+	}
+}
+
+// idField returns the index of the ID field of struct type t, or -1
+func idField(t reflect.Type) int {
+	if i, ok := idFields.Load(t); ok {
+		return i.(int)
+	}
+
+	index := -1
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if slices.Contains(strings.Split(f.Tag.Get("gostore"), ","), "id") {
+			index = i
+			break
+		}
+		if index < 0 && f.Name == "ID" && f.Tag.Get("datastore") == "-" {
+			index = i
+		}
+	}
+
+	idFields.Store(t, index)
+	return index
+}
@@ -0,0 +1,160 @@
+package key
+
+import (
+	"testing"
+
+	"cloud.google.com/go/datastore"
+)
+
+type namedEntity struct {
+	ID   string `datastore:"-"`
+	Name string `datastore:"name"`
+}
+
+type numberedEntity struct {
+	ID   int64 `datastore:"-"`
+	Name string
+}
+
+type taggedEntity struct {
+	ID  string         `datastore:"-"`
+	Ref *datastore.Key `datastore:"-" gostore:"id"`
+}
+
+type storedIDEntity struct {
+	ID string `datastore:"id"`
+}
+
+type keyLoaderEntity struct {
+	ID     string `datastore:"-"`
+	loaded *datastore.Key
+}
+
+func (e *keyLoaderEntity) Load(ps []datastore.Property) error { return nil }
+
+func (e *keyLoaderEntity) Save() ([]datastore.Property, error) { return nil, nil }
+
+func (e *keyLoaderEntity) LoadKey(k *datastore.Key) error {
+	e.loaded = k
+	return nil
+}
+
+func TestSetID(t *testing.T) {
+	t.Run("Name key into string field", func(t *testing.T) {
+		var e namedEntity
+		SetID(&e, datastore.NameKey("users", "abc", nil))
+		if e.ID != "abc" {
+			t.Errorf("expected abc, got %q", e.ID)
+		}
+	})
+
+	t.Run("ID key into string field", func(t *testing.T) {
+		var e namedEntity
+		SetID(&e, datastore.IDKey("users", 42, nil))
+		if e.ID != "42" {
+			t.Errorf("expected 42, got %q", e.ID)
+		}
+	})
+
+	t.Run("ID key into int64 field", func(t *testing.T) {
+		var e numberedEntity
+		SetID(&e, datastore.IDKey("users", 42, nil))
+		if e.ID != 42 {
+			t.Errorf("expected 42, got %d", e.ID)
+		}
+
+		e = numberedEntity{}
+		SetID(&e, datastore.NameKey("users", "abc", nil))
+		if e.ID != 0 {
+			t.Errorf("expected name key to leave int field alone, got %d", e.ID)
+		}
+	})
+
+	t.Run("gostore tag takes precedence", func(t *testing.T) {
+		var e taggedEntity
+		k := datastore.NameKey("users", "abc", nil)
+		SetID(&e, k)
+		if e.Ref != k || e.ID != "" {
+			t.Errorf("expected only Ref to be set, got %+v", e)
+		}
+	})
+
+	t.Run("Stored ID field is left alone", func(t *testing.T) {
+		e := storedIDEntity{ID: "stored"}
+		SetID(&e, datastore.NameKey("users", "abc", nil))
+		if e.ID != "stored" {
+			t.Errorf("expected stored, got %q", e.ID)
+		}
+	})
+
+	t.Run("KeyLoader is left alone", func(t *testing.T) {
+		var e keyLoaderEntity
+		SetID(&e, datastore.NameKey("users", "abc", nil))
+		if e.ID != "" {
+			t.Errorf("expected KeyLoader to be skipped, got %q", e.ID)
+		}
+	})
+
+	t.Run("Non-struct destinations are ignored", func(t *testing.T) {
+		var m map[string]any
+		SetID(&m, datastore.NameKey("users", "abc", nil))
+		SetID(namedEntity{}, datastore.NameKey("users", "abc", nil))
+	})
+}
+
+func TestSetIDs(t *testing.T) {
+	keys := []*datastore.Key{
+		datastore.NameKey("users", "a", nil),
+		datastore.IDKey("users", 2, nil),
+	}
+
+	t.Run("Slice of structs", func(t *testing.T) {
+		dest := make([]namedEntity, 2)
+		SetIDs(dest, keys)
+		if dest[0].ID != "a" || dest[1].ID != "2" {
+			t.Errorf("expected a and 2, got %q and %q", dest[0].ID, dest[1].ID)
+		}
+	})
+
+	t.Run("Pointer to slice of pointers", func(t *testing.T) {
+		dest := []*numberedEntity{{}, nil}
+		SetIDs(&dest, keys)
+		if dest[0].ID != 0 {
+			t.Errorf("expected name key to leave int field alone, got %d", dest[0].ID)
+		}
+
+		dest = []*numberedEntity{{}, {}}
+		SetIDs(&dest, keys)
+		if dest[1].ID != 2 {
+			t.Errorf("expected 2, got %d", dest[1].ID)
+		}
+	})
+}
+
+func TestID(t *testing.T) {
+	ref := datastore.NameKey("users", "u1", nil)
+
+	tests := []struct {
+		name   string
+		src    any
+		want   any
+		wantOK bool
+	}{
+		{"string field", &namedEntity{ID: "u1"}, "u1", true},
+		{"struct value", numberedEntity{ID: 7}, int64(7), true},
+		{"zero ID", &numberedEntity{}, int64(0), true},
+		{"gostore tag", &taggedEntity{ID: "ignored", Ref: ref}, ref, true},
+		{"no ID field", &storedIDEntity{ID: "u1"}, nil, false},
+		{"not a struct", new(string), nil, false},
+		{"nil pointer", (*namedEntity)(nil), nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ID(tt.src)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("ID() = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package key
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ErrNonNumericID is returned by NormalizeID for a json.Number that is not an
+// integer
+var ErrNonNumericID = errors.New("json.Number ID is not an integer")
+
+// NormalizeID converts an entity ID to the string name or int64 ID Datastore
+// keys are built from. Any signed or unsigned integer type is accepted as long
+// as it fits in an int64, and a json.Number is parsed as an int64.
+func NormalizeID(id any) (any, error) {
+	switch v := id.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case uint:
+		return uintID(uint64(v))
+	case uint64:
+		return uintID(v)
+	case uint32:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint8:
+		return int64(v), nil
+	case json.Number:
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			return nil, fmt.Errorf("ID %s overflows int64", string(v))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrNonNumericID, string(v))
+		}
+		return n, nil
+	default:
+		return nil, fmt.Errorf("invalid ID type: %T", id)
+	}
+}
+
+func uintID(v uint64) (any, error) {
+	if v > math.MaxInt64 {
+		return nil, fmt.Errorf("ID %d overflows int64", v)
+	}
+	return int64(v), nil
+}
@@ -0,0 +1,70 @@
+package key
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestNormalizeID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   any
+		want any
+	}{
+		{"string", "user1", "user1"},
+		{"int64", int64(42), int64(42)},
+		{"int", 42, int64(42)},
+		{"int32", int32(42), int64(42)},
+		{"int16", int16(42), int64(42)},
+		{"int8", int8(42), int64(42)},
+		{"uint", uint(42), int64(42)},
+		{"uint64", uint64(42), int64(42)},
+		{"uint32", uint32(math.MaxUint32), int64(math.MaxUint32)},
+		{"uint16", uint16(42), int64(42)},
+		{"uint8", uint8(42), int64(42)},
+		{"uint64 max int64", uint64(math.MaxInt64), int64(math.MaxInt64)},
+		{"json.Number", json.Number("42"), int64(42)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeID(tt.id)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %v (%T), got %v (%T)", tt.want, tt.want, got, got)
+			}
+		})
+	}
+
+	rejected := []struct {
+		name string
+		id   any
+	}{
+		{"uint64 overflow", uint64(math.MaxInt64) + 1},
+		{"uint overflow", uint(math.MaxUint64)},
+		{"float64", 42.0},
+		{"float32", float32(42)},
+		{"json.Number float", json.Number("4.2")},
+		{"json.Number overflow", json.Number("9223372036854775808")},
+		{"nil", nil},
+	}
+
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NormalizeID(tt.id); err == nil {
+				t.Errorf("expected error for %v", tt.id)
+			}
+		})
+	}
+
+	t.Run("Non-numeric json.Number", func(t *testing.T) {
+		_, err := NormalizeID(json.Number("abc"))
+		if !errors.Is(err, ErrNonNumericID) {
+			t.Errorf("expected ErrNonNumericID, got %v", err)
+		}
+	})
+}
@@ -1,5 +1,9 @@
 package key
 
-type Key int
+import "github.com/AndroX7/gostore"
 
-const NOSQL_KEY Key = 1970
+// Key is the type of NOSQL_KEY. It aliases gostore.ContextKey so that
+// gostore.Connect and gostore.Manager can store clients under NOSQL_KEY too.
+type Key = gostore.ContextKey
+
+const NOSQL_KEY = gostore.ClientKey
@@ -0,0 +1,104 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+)
+
+// ParsePath parses a key path of alternating kind and identifier segments,
+// such as "users/123/posts/abc", into a parent-chained key. Identifiers made
+// only of digits become numeric IDs and everything else a name. Segments may
+// be percent-encoded, so "%2F" is a "/" within a name and an escaped digit
+// (e.g. "%3123") marks an all-digit name. The namespace is left empty.
+func ParsePath(path string) (*datastore.Key, error) {
+	if path == "" {
+		return nil, errors.New("key path is empty")
+	}
+
+	segments := strings.Split(path, "/")
+	if len(segments)%2 != 0 {
+		return nil, fmt.Errorf("key path %q must have an even number of segments", path)
+	}
+
+	var key *datastore.Key
+	for i := 0; i < len(segments); i += 2 {
+		kind, err := url.PathUnescape(segments[i])
+		if err != nil {
+			return nil, fmt.Errorf("key path %q: invalid kind %q: %w", path, segments[i], err)
+		}
+		if kind == "" {
+			return nil, fmt.Errorf("key path %q: empty kind at segment %d", path, i)
+		}
+
+		raw := segments[i+1]
+		if isDigits(raw) {
+			id, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || id == 0 {
+				return nil, fmt.Errorf("key path %q: invalid ID %q", path, raw)
+			}
+			key = datastore.IDKey(kind, id, key)
+			continue
+		}
+
+		name, err := url.PathUnescape(raw)
+		if err != nil {
+			return nil, fmt.Errorf("key path %q: invalid name %q: %w", path, raw, err)
+		}
+		if name == "" {
+			return nil, fmt.Errorf("key path %q: empty identifier at segment %d", path, i+1)
+		}
+		key = datastore.NameKey(kind, name, key)
+	}
+
+	return key, nil
+}
+
+// FormatPath returns the path of a complete key in the form read by
+// ParsePath. The namespace is not included.
+func FormatPath(k *datastore.Key) string {
+	var segments []string
+	for ; k != nil; k = k.Parent {
+		id := strconv.FormatInt(k.ID, 10)
+		if k.Name != "" || k.ID == 0 {
+			id = escapeName(k.Name)
+		}
+		segments = append(segments, id, escapeSegment(k.Kind))
+	}
+
+	for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+		segments[i], segments[j] = segments[j], segments[i]
+	}
+	return strings.Join(segments, "/")
+}
+
+// escapeSegment escapes the characters ParsePath treats specially
+func escapeSegment(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	return strings.ReplaceAll(s, "/", "%2F")
+}
+
+// escapeName escapes a name, also escaping the first digit of an all-digit
+// name so it is not read back as a numeric ID
+func escapeName(name string) string {
+	if isDigits(name) {
+		return fmt.Sprintf("%%%X", name[0]) + name[1:]
+	}
+	return escapeSegment(name)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package key
+
+import (
+	"testing"
+
+	"cloud.google.com/go/datastore"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want *datastore.Key
+	}{
+		{
+			"Two levels",
+			"users/123/posts/abc",
+			datastore.NameKey("posts", "abc", datastore.IDKey("users", 123, nil)),
+		},
+		{
+			"Three levels",
+			"orgs/acme/users/123/posts/456",
+			datastore.IDKey("posts", 456, datastore.IDKey("users", 123, datastore.NameKey("orgs", "acme", nil))),
+		},
+		{
+			"Name containing a slash",
+			"files/a%2Fb.txt",
+			datastore.NameKey("files", "a/b.txt", nil),
+		},
+		{
+			"All-digit name",
+			"users/%3123",
+			datastore.NameKey("users", "123", nil),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePath(tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+
+			if path := FormatPath(got); path != tt.path {
+				t.Errorf("expected path %q, got %q", tt.path, path)
+			}
+		})
+	}
+
+	invalid := []string{"", "users", "users/123/posts", "users/", "/123", "users/0", "users/%zz"}
+	for _, path := range invalid {
+		t.Run("Rejects "+path, func(t *testing.T) {
+			if _, err := ParsePath(path); err == nil {
+				t.Errorf("expected error for %q", path)
+			}
+		})
+	}
+}
+
+func TestFormatPath(t *testing.T) {
+	t.Run("Round trips names that need escaping", func(t *testing.T) {
+		names := []string{"a/b", "100%", "007", "x%2Fy", "users/123"}
+		for _, name := range names {
+			k := datastore.NameKey("items", name, datastore.IDKey("users", 7, nil))
+
+			got, err := ParsePath(FormatPath(k))
+			if err != nil {
+				t.Fatalf("unexpected error for %q: %v", name, err)
+			}
+			if !got.Equal(k) {
+				t.Errorf("expected %v, got %v", k, got)
+			}
+		}
+	})
+}
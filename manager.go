@@ -0,0 +1,190 @@
+package gostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"cloud.google.com/go/datastore"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ContextKey is the type of ClientKey. It is declared here so that
+// Manager.Context can store its client where key.ClientFromContext looks for
+// it.
+type ContextKey int
+
+// ClientKey is the context key clients are stored under, which the key
+// package exports as NOSQL_KEY
+const ClientKey ContextKey = 1970
+
+// DefaultHealthKind is the kind Manager.Healthy queries unless
+// WithHealthKind sets another. It lists the kinds in the namespace, so it
+// works against any database.
+const DefaultHealthKind = "__kind__"
+
+// ErrClientNotInitialized is returned when a context carries no client. The
+// key package returns it too.
+var ErrClientNotInitialized = errors.New("database is not initialized")
+
+// ErrManagerClosed is returned by a Manager once Close was called
+var ErrManagerClosed = errors.New("client manager is closed")
+
+// Manager creates a *datastore.Client on first use and shares it between
+// callers. It is safe for concurrent use.
+type Manager struct {
+	projectID  string
+	databaseID string
+	namespace  string
+	healthKind string
+	clientOpts []option.ClientOption
+
+	mu     sync.Mutex
+	client *datastore.Client
+	closed bool
+}
+
+// ManagerOption configures a Manager
+type ManagerOption func(*Manager)
+
+// WithProject sets the project ID. By default it is detected from the
+// environment (see datastore.DetectProjectID).
+func WithProject(id string) ManagerOption {
+	return func(m *Manager) {
+		m.projectID = id
+	}
+}
+
+// WithDatabase sets the database ID, which defaults to the default database
+func WithDatabase(id string) ManagerOption {
+	return func(m *Manager) {
+		m.databaseID = id
+	}
+}
+
+// WithNamespace sets the namespace health checks run in. The client itself
+// is not bound to a namespace; pass Namespace to exec.WithNamespace.
+func WithNamespace(ns string) ManagerOption {
+	return func(m *Manager) {
+		m.namespace = ns
+	}
+}
+
+// WithCredentialsFile authenticates with the service account key file at
+// path instead of the application default credentials
+func WithCredentialsFile(path string) ManagerOption {
+	return WithClientOptions(option.WithAuthCredentialsFile(option.ServiceAccount, path))
+}
+
+// WithEndpoint connects to addr without authentication or TLS, as to a
+// Datastore emulator. DATASTORE_EMULATOR_HOST, when set, takes precedence.
+func WithEndpoint(addr string) ManagerOption {
+	return WithClientOptions(
+		option.WithEndpoint(addr),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+}
+
+// WithClientOptions adds options passed to datastore.NewClientWithDatabase
+func WithClientOptions(opts ...option.ClientOption) ManagerOption {
+	return func(m *Manager) {
+		m.clientOpts = append(m.clientOpts, opts...)
+	}
+}
+
+// WithHealthKind sets the kind Healthy queries, e.g. a sentinel kind the
+// service account is allowed to read
+func WithHealthKind(kind string) ManagerOption {
+	return func(m *Manager) {
+		m.healthKind = kind
+	}
+}
+
+// NewManager returns a Manager configured by opts. No client is created
+// until the first call to Client.
+func NewManager(opts ...ManagerOption) *Manager {
+	m := &Manager{
+		projectID:  datastore.DetectProjectID,
+		healthKind: DefaultHealthKind,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Client returns the managed client, creating it on the first call. If
+// creating it fails, the error is returned and the next call tries again.
+func (m *Manager) Client(ctx context.Context) (*datastore.Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, ErrManagerClosed
+	}
+	if m.client == nil {
+		client, err := m.newClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.client = client
+	}
+	return m.client, nil
+}
+
+func (m *Manager) newClient(ctx context.Context) (*datastore.Client, error) {
+	client, err := datastore.NewClientWithDatabase(ctx, m.projectID, m.databaseID, m.clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create datastore client: %w", err)
+	}
+	return client, nil
+}
+
+// Namespace returns the namespace set with WithNamespace
+func (m *Manager) Namespace() string {
+	return m.namespace
+}
+
+// Healthy runs a keys-only query for at most one entity of the health kind
+// and returns its error, if any. It creates the client if needed.
+func (m *Manager) Healthy(ctx context.Context) error {
+	client, err := m.Client(ctx)
+	if err != nil {
+		return err
+	}
+	q := datastore.NewQuery(m.healthKind).Namespace(m.namespace).KeysOnly().Limit(1)
+	if _, err := client.GetAll(ctx, q, nil); err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	return nil
+}
+
+// Context returns a copy of ctx carrying the managed client under
+// key.NOSQL_KEY, creating the client if needed
+func (m *Manager) Context(ctx context.Context) (context.Context, error) {
+	client, err := m.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, ClientKey, client), nil
+}
+
+// Close closes the client, if one was created. Later calls to Close do
+// nothing and return nil; other methods return ErrManagerClosed.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+	if m.client == nil {
+		return nil
+	}
+	return m.client.Close()
+}
@@ -0,0 +1,170 @@
+package gostore_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"cloud.google.com/go/datastore/apiv1/datastorepb"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/key"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeDatastore answers queries with no results, or with err if set
+type fakeDatastore struct {
+	datastorepb.UnimplementedDatastoreServer
+	err     error
+	queries atomic.Int32
+	kind    atomic.Value
+}
+
+func (f *fakeDatastore) RunQuery(ctx context.Context, req *datastorepb.RunQueryRequest) (*datastorepb.RunQueryResponse, error) {
+	f.queries.Add(1)
+	if kinds := req.GetQuery().GetKind(); len(kinds) > 0 {
+		f.kind.Store(kinds[0].GetName())
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &datastorepb.RunQueryResponse{
+		Batch: &datastorepb.QueryResultBatch{
+			EntityResultType: datastorepb.EntityResult_KEY_ONLY,
+			MoreResults:      datastorepb.QueryResultBatch_NO_MORE_RESULTS,
+		},
+	}, nil
+}
+
+// startFakeDatastore serves f and points new clients at it the way the
+// emulator does, returning its address
+func startFakeDatastore(t *testing.T, f *fakeDatastore) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	datastorepb.RegisterDatastoreServer(srv, f)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	t.Setenv("DATASTORE_EMULATOR_HOST", lis.Addr().String())
+	return lis.Addr().String()
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Lazy single init", func(t *testing.T) {
+		startFakeDatastore(t, &fakeDatastore{})
+		m := gostore.NewManager(gostore.WithProject("test-project"))
+		defer m.Close()
+
+		clients := make([]*datastore.Client, 20)
+		var wg sync.WaitGroup
+		for i := range clients {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				client, err := m.Client(ctx)
+				if err != nil {
+					t.Errorf("Client failed: %v", err)
+				}
+				clients[i] = client
+			}()
+		}
+		wg.Wait()
+
+		for i, client := range clients {
+			if client == nil || client != clients[0] {
+				t.Fatalf("expected every caller to get the same client, caller %d got %p", i, client)
+			}
+		}
+	})
+
+	t.Run("Healthy", func(t *testing.T) {
+		f := &fakeDatastore{}
+		startFakeDatastore(t, f)
+		m := gostore.NewManager(gostore.WithProject("test-project"))
+		defer m.Close()
+
+		if err := m.Healthy(ctx); err != nil {
+			t.Fatalf("expected a healthy manager, got %v", err)
+		}
+		if f.queries.Load() != 1 || f.kind.Load() != gostore.DefaultHealthKind {
+			t.Errorf("expected one query of %s, got %d of %v", gostore.DefaultHealthKind, f.queries.Load(), f.kind.Load())
+		}
+	})
+
+	t.Run("Healthy with a sentinel kind", func(t *testing.T) {
+		f := &fakeDatastore{}
+		startFakeDatastore(t, f)
+		m := gostore.NewManager(gostore.WithProject("test-project"), gostore.WithHealthKind("healthcheck"))
+		defer m.Close()
+
+		if err := m.Healthy(ctx); err != nil {
+			t.Fatalf("expected a healthy manager, got %v", err)
+		}
+		if f.kind.Load() != "healthcheck" {
+			t.Errorf("expected the sentinel kind to be queried, got %v", f.kind.Load())
+		}
+	})
+
+	t.Run("Unhealthy", func(t *testing.T) {
+		startFakeDatastore(t, &fakeDatastore{err: status.Error(codes.PermissionDenied, "denied")})
+		m := gostore.NewManager(gostore.WithProject("test-project"))
+		defer m.Close()
+
+		err := m.Healthy(ctx)
+		if status.Code(errors.Unwrap(err)) != codes.PermissionDenied {
+			t.Errorf("expected the query error, got %v", err)
+		}
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		startFakeDatastore(t, &fakeDatastore{})
+		m := gostore.NewManager(gostore.WithProject("test-project"))
+		defer m.Close()
+
+		ctx, err := m.Context(ctx)
+		if err != nil {
+			t.Fatalf("Context failed: %v", err)
+		}
+		client, err := key.ClientFromContext(ctx)
+		if err != nil {
+			t.Fatalf("expected the client in the context, got %v", err)
+		}
+		want, _ := m.Client(ctx)
+		if dc, ok := gostore.Unwrap(client); !ok || dc != want {
+			t.Errorf("expected the managed client, got %v", client)
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		startFakeDatastore(t, &fakeDatastore{})
+		m := gostore.NewManager(gostore.WithProject("test-project"))
+		if _, err := m.Client(ctx); err != nil {
+			t.Fatalf("Client failed: %v", err)
+		}
+
+		for i := 0; i < 3; i++ {
+			if err := m.Close(); err != nil {
+				t.Errorf("Close %d failed: %v", i+1, err)
+			}
+		}
+		if _, err := m.Client(ctx); !errors.Is(err, gostore.ErrManagerClosed) {
+			t.Errorf("expected ErrManagerClosed, got %v", err)
+		}
+		if err := m.Healthy(ctx); !errors.Is(err, gostore.ErrManagerClosed) {
+			t.Errorf("expected ErrManagerClosed, got %v", err)
+		}
+		if err := gostore.NewManager().Close(); err != nil {
+			t.Errorf("expected closing an unused manager to succeed, got %v", err)
+		}
+	})
+}
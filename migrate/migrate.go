@@ -0,0 +1,238 @@
+// Package migrate runs one-off data migrations, such as renaming a property
+// or backfilling a new field, once per database, recording the migrations
+// applied in the Kind kind.
+//
+//	func init() {
+//		migrate.Register("2024-05-01-backfill-status", func(ctx context.Context, e *exec.Exec) error {
+//			_, err := e.UpdateWhere(ctx, "users", map[string]any{"status": nil}, map[string]any{"status": "active"})
+//			return err
+//		})
+//	}
+//
+//	applied, err := migrate.Run(ctx, gostore.Wrap(client))
+//
+// Migrations run in registration order and should be safe to run again, as
+// one that fails partway is run from the start on the next Run.
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/exec"
+)
+
+// Kind is the kind recording the state of migrations, one entity per
+// migration named by its ID
+const Kind = "_gostore_migrations"
+
+var (
+	// ErrRegistered is returned when registering a migration ID twice
+	ErrRegistered = errors.New("migration already registered")
+
+	// ErrFailed is matched by the error of a Run stopped by a failing
+	// migration
+	ErrFailed = errors.New("migration failed")
+)
+
+// Func applies a migration with e, an Exec over the client of the Run
+type Func func(ctx context.Context, e *exec.Exec) error
+
+// State is the state of a migration
+type State string
+
+const (
+	// StatePending migrations have not been applied yet
+	StatePending State = "pending"
+	// StateApplied migrations completed and are skipped by Run
+	StateApplied State = "applied"
+	// StateFailed migrations returned an error the last time they ran, and
+	// run again on the next Run
+	StateFailed State = "failed"
+)
+
+// Migration is the state of a migration, as listed by Status
+type Migration struct {
+	ID    string
+	State State
+	// Registered is false for a migration recorded in Kind that is not
+	// registered, e.g. one removed from the code after it was applied
+	Registered bool
+	// At is when the migration was applied, or last failed
+	At time.Time
+	// Duration is how long the migration ran, when applied or failed
+	Duration time.Duration
+	// Error is the error of a failed migration
+	Error string
+}
+
+// Applied is a migration applied by Run
+type Applied struct {
+	ID       string
+	At       time.Time
+	Duration time.Duration
+}
+
+// record is the entity recording a migration in Kind
+type record struct {
+	State    string        `datastore:"state"`
+	At       time.Time     `datastore:"at"`
+	Duration time.Duration `datastore:"duration,noindex"`
+	Error    string        `datastore:"error,noindex"`
+}
+
+type migration struct {
+	id string
+	up Func
+}
+
+// Migrator holds migrations in registration order. It is safe for
+// concurrent use, but Run must not run concurrently against one database.
+type Migrator struct {
+	mu         sync.Mutex
+	migrations []migration
+}
+
+// New creates an empty Migrator
+func New() *Migrator {
+	return &Migrator{}
+}
+
+// Register adds the migration id applied by up after those already
+// registered. Registering an ID twice returns an error matching
+// ErrRegistered.
+func (m *Migrator) Register(id string, up Func) error {
+	if id == "" {
+		return errors.New("migration ID is required")
+	}
+	if up == nil {
+		return fmt.Errorf("nil migration %s", id)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, mig := range m.migrations {
+		if mig.id == id {
+			return fmt.Errorf("%w: %s", ErrRegistered, id)
+		}
+	}
+	m.migrations = append(m.migrations, migration{id: id, up: up})
+	return nil
+}
+
+// Run applies the pending migrations with client in registration order,
+// recording each in Kind, and returns those it applied. It stops at the
+// first migration that fails, which is recorded as StateFailed, with an error
+// matching ErrFailed that wraps the migration's; the migrations after it stay
+// pending.
+func (m *Migrator) Run(ctx context.Context, client gostore.Client) ([]Applied, error) {
+	records, err := load(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	e := exec.NewExecWithOptions(exec.WithClient(client))
+	var applied []Applied
+	for _, mig := range m.registered() {
+		if r, ok := records[mig.id]; ok && State(r.State) == StateApplied {
+			continue
+		}
+
+		start := time.Now()
+		err := mig.up(ctx, e)
+		r := record{State: string(StateApplied), At: time.Now().UTC(), Duration: time.Since(start)}
+		if err != nil {
+			r.State, r.Error = string(StateFailed), err.Error()
+		}
+
+		if _, putErr := client.Put(ctx, key(mig.id), &r); putErr != nil {
+			return applied, fmt.Errorf("record migration %s: %w", mig.id, errors.Join(putErr, err))
+		}
+		if err != nil {
+			return applied, fmt.Errorf("%w: %s: %w", ErrFailed, mig.id, err)
+		}
+		applied = append(applied, Applied{ID: mig.id, At: r.At, Duration: r.Duration})
+	}
+	return applied, nil
+}
+
+// Status lists the registered migrations in registration order with their
+// state in the database of client, followed by the migrations recorded there
+// that are not registered, sorted by ID
+func (m *Migrator) Status(ctx context.Context, client gostore.Client) ([]Migration, error) {
+	records, err := load(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Migration
+	for _, mig := range m.registered() {
+		status := Migration{ID: mig.id, State: StatePending, Registered: true}
+		if r, ok := records[mig.id]; ok {
+			status.State, status.At, status.Duration, status.Error = State(r.State), r.At, r.Duration, r.Error
+			delete(records, mig.id)
+		}
+		list = append(list, status)
+	}
+
+	unknown := make([]Migration, 0, len(records))
+	for id, r := range records {
+		unknown = append(unknown, Migration{ID: id, State: State(r.State), At: r.At, Duration: r.Duration, Error: r.Error})
+	}
+	slices.SortFunc(unknown, func(a, b Migration) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return append(list, unknown...), nil
+}
+
+// registered returns a copy of the migrations registered so far
+func (m *Migrator) registered() []migration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.migrations)
+}
+
+// load returns the records of Kind by migration ID
+func load(ctx context.Context, client gostore.Client) (map[string]record, error) {
+	var records []record
+	keys, err := client.GetAll(ctx, datastore.NewQuery(Kind), &records)
+	if err != nil {
+		return nil, fmt.Errorf("load migrations: %w", err)
+	}
+
+	byID := make(map[string]record, len(keys))
+	for i, k := range keys {
+		byID[k.Name] = records[i]
+	}
+	return byID, nil
+}
+
+func key(id string) *datastore.Key {
+	return datastore.NameKey(Kind, id, nil)
+}
+
+// DefaultMigrator is the Migrator used by the package-level Register, Run
+// and Status
+var DefaultMigrator = New()
+
+// Register adds a migration to DefaultMigrator
+func Register(id string, up Func) error {
+	return DefaultMigrator.Register(id, up)
+}
+
+// Run applies the pending migrations of DefaultMigrator
+func Run(ctx context.Context, client gostore.Client) ([]Applied, error) {
+	return DefaultMigrator.Run(ctx, client)
+}
+
+// Status lists the migrations of DefaultMigrator with their state
+func Status(ctx context.Context, client gostore.Client) ([]Migration, error) {
+	return DefaultMigrator.Status(ctx, client)
+}
@@ -0,0 +1,168 @@
+package migrate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/migrate"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Running twice applies each migration once", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		m := migrate.New()
+
+		var runs []string
+		for _, id := range []string{"001-create", "002-backfill"} {
+			err := m.Register(id, func(ctx context.Context, e *exec.Exec) error {
+				runs = append(runs, id)
+				return e.Create(ctx, "users", id, &testutil.TestUser{Name: id})
+			})
+			if err != nil {
+				t.Fatalf("Register failed: %v", err)
+			}
+		}
+
+		applied, err := m.Run(ctx, mock)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if len(applied) != 2 || applied[0].ID != "001-create" || applied[1].ID != "002-backfill" {
+			t.Fatalf("expected both migrations applied in order, got %+v", applied)
+		}
+		if applied[0].At.IsZero() {
+			t.Error("expected the time applied")
+		}
+
+		applied, err = m.Run(ctx, mock)
+		if err != nil || len(applied) != 0 {
+			t.Fatalf("expected nothing applied the second time, got %+v, %v", applied, err)
+		}
+		if len(runs) != 2 || mock.Count("users") != 2 {
+			t.Errorf("expected each migration to run once, got %v", runs)
+		}
+		if n := mock.Count(migrate.Kind); n != 2 {
+			t.Errorf("expected 2 migrations recorded, got %d", n)
+		}
+	})
+
+	t.Run("A failing migration blocks the later ones", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		m := migrate.New()
+
+		boom := errors.New("boom")
+		fail := true
+		var ran []string
+		register := func(id string, up func() error) {
+			t.Helper()
+			err := m.Register(id, func(ctx context.Context, e *exec.Exec) error {
+				ran = append(ran, id)
+				return up()
+			})
+			if err != nil {
+				t.Fatalf("Register failed: %v", err)
+			}
+		}
+		register("001", func() error { return nil })
+		register("002", func() error {
+			if fail {
+				return boom
+			}
+			return nil
+		})
+		register("003", func() error { return nil })
+
+		applied, err := m.Run(ctx, mock)
+		if !errors.Is(err, migrate.ErrFailed) || !errors.Is(err, boom) {
+			t.Fatalf("expected ErrFailed wrapping boom, got %v", err)
+		}
+		if len(applied) != 1 || applied[0].ID != "001" {
+			t.Errorf("expected only 001 applied, got %+v", applied)
+		}
+		if len(ran) != 2 {
+			t.Errorf("expected 003 not to run, got %v", ran)
+		}
+
+		status, err := m.Status(ctx, mock)
+		if err != nil {
+			t.Fatalf("Status failed: %v", err)
+		}
+		want := []migrate.State{migrate.StateApplied, migrate.StateFailed, migrate.StatePending}
+		for i, s := range status {
+			if s.State != want[i] {
+				t.Errorf("expected %s to be %s, got %s", s.ID, want[i], s.State)
+			}
+		}
+		if status[1].Error != "boom" {
+			t.Errorf("expected the failure recorded, got %q", status[1].Error)
+		}
+
+		fail = false
+		ran = nil
+		applied, err = m.Run(ctx, mock)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if len(applied) != 2 || applied[0].ID != "002" || applied[1].ID != "003" {
+			t.Errorf("expected 002 and 003 applied, got %+v", applied)
+		}
+		if len(ran) != 2 {
+			t.Errorf("expected 001 to be skipped, got %v", ran)
+		}
+	})
+
+	t.Run("Recording failures stop the run", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		m := migrate.New()
+		if err := m.Register("001", func(ctx context.Context, e *exec.Exec) error { return nil }); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+
+		unavailable := errors.New("unavailable")
+		mock.FailNext(testutil.OpPut, unavailable, 1)
+		if _, err := m.Run(ctx, mock); !errors.Is(err, unavailable) {
+			t.Errorf("expected the Put error, got %v", err)
+		}
+	})
+}
+
+func TestStatus(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	m := migrate.New()
+
+	for _, id := range []string{"b", "a"} {
+		if err := m.Register(id, func(ctx context.Context, e *exec.Exec) error { return nil }); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+	if err := m.Register("a", func(ctx context.Context, e *exec.Exec) error { return nil }); !errors.Is(err, migrate.ErrRegistered) {
+		t.Errorf("expected ErrRegistered, got %v", err)
+	}
+
+	if _, err := mock.Put(ctx, datastore.NameKey(migrate.Kind, "removed", nil), &struct {
+		State string `datastore:"state"`
+	}{State: string(migrate.StateApplied)}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	status, err := m.Status(ctx, mock)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(status) != 3 {
+		t.Fatalf("expected 3 migrations, got %+v", status)
+	}
+	if status[0].ID != "b" || status[1].ID != "a" || status[0].State != migrate.StatePending || !status[0].Registered {
+		t.Errorf("expected b and a pending in registration order, got %+v", status[:2])
+	}
+	if status[2].ID != "removed" || status[2].Registered || status[2].State != migrate.StateApplied {
+		t.Errorf("expected the unregistered migration last, got %+v", status[2])
+	}
+}
@@ -0,0 +1,111 @@
+// Package otelgostore traces gostore operations with OpenTelemetry. It is a
+// separate module so that programs not importing it do not depend on the
+// OpenTelemetry SDK.
+//
+//	h := exec.NewExecWithOptions(otelgostore.WithTracing())
+//	repo := repository.NewBaseRepository(client, "users", otelgostore.WithTracing())
+//
+// Every Datastore call gets a span named "gostore.<kind>.<operation>", e.g.
+// "gostore.users.GetByID", as a child of the span in the call's context. Bulk
+// operations get one span for the whole run with a child span per batch.
+package otelgostore
+
+import (
+	"context"
+
+	"github.com/AndroX7/gostore/exec"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the tracer
+const ScopeName = "github.com/AndroX7/gostore/otelgostore"
+
+// Span attributes
+const (
+	KindKey      = attribute.Key("gostore.kind")
+	OperationKey = attribute.Key("gostore.operation")
+	KeysKey      = attribute.Key("gostore.keys")
+	ResultsKey   = attribute.Key("gostore.results")
+	BatchKey     = attribute.Key("gostore.batch")
+)
+
+// Option configures the tracer
+type Option func(*config)
+
+type config struct {
+	provider trace.TracerProvider
+}
+
+// WithTracerProvider sets the provider spans are created with. The default
+// is the global provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = tp
+	}
+}
+
+// NewTracer returns an exec.Tracer creating OpenTelemetry spans
+func NewTracer(opts ...Option) exec.Tracer {
+	c := config{provider: otel.GetTracerProvider()}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &tracer{tracer: c.provider.Tracer(ScopeName)}
+}
+
+// WithTracing is an exec option tracing every operation of the Exec, or of a
+// repository when passed to repository.NewBaseRepository or
+// repository.WithExecOptions
+func WithTracing(opts ...Option) exec.Option {
+	return exec.WithTracer(NewTracer(opts...))
+}
+
+type tracer struct {
+	tracer trace.Tracer
+}
+
+func (t *tracer) Start(ctx context.Context, op exec.Operation) (context.Context, exec.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "datastore"),
+		KindKey.String(op.Kind),
+		OperationKey.String(op.Name),
+	}
+	if op.Keys > 0 {
+		attrs = append(attrs, KeysKey.Int(op.Keys))
+	}
+	if op.Batch {
+		attrs = append(attrs, BatchKey.Bool(true))
+	}
+
+	ctx, s := t.tracer.Start(ctx, SpanName(op),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	return ctx, span{s}
+}
+
+// SpanName returns the name of the span for op
+func SpanName(op exec.Operation) string {
+	if op.Kind == "" {
+		return "gostore." + op.Name
+	}
+	return "gostore." + op.Kind + "." + op.Name
+}
+
+type span struct {
+	trace.Span
+}
+
+func (s span) SetResults(n int) {
+	s.SetAttributes(ResultsKey.Int(n))
+}
+
+func (s span) End(err error) {
+	if err != nil {
+		s.RecordError(err)
+		s.SetStatus(codes.Error, err.Error())
+	}
+	s.Span.End()
+}
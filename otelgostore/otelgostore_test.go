@@ -0,0 +1,107 @@
+package otelgostore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/otelgostore"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newRepo(t *testing.T) (*repository.BaseRepository, *tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	repo := repository.NewBaseRepositoryWithClient(testutil.NewMockClient(), "users",
+		repository.WithExecOptions(otelgostore.WithTracing(otelgostore.WithTracerProvider(tp))))
+	return repo, recorder, tp
+}
+
+func attrs(s sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value)
+	for _, kv := range s.Attributes() {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestSpans(t *testing.T) {
+	repo, recorder, tp := newRepo(t)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	if err := repo.Create(ctx, "u1", &testutil.TestUser{Name: "A"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	users := make([]testutil.TestUser, 1)
+	if err := repo.GetMulti(ctx, []interface{}{"u1"}, users); err != nil {
+		t.Fatalf("GetMulti: %v", err)
+	}
+	var missing testutil.TestUser
+	if err := repo.GetByID(ctx, "missing", &missing); err == nil {
+		t.Fatal("expected GetByID to fail")
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+
+	for i, want := range []string{"gostore.users.Create", "gostore.users.GetMulti", "gostore.users.GetByID"} {
+		s := spans[i]
+		if s.Name() != want {
+			t.Errorf("span %d: expected %s, got %s", i, want, s.Name())
+		}
+		if s.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%s: not a child of the request span", s.Name())
+		}
+		a := attrs(s)
+		if a[otelgostore.KindKey].AsString() != "users" || a[otelgostore.KeysKey].AsInt64() != 1 {
+			t.Errorf("%s: unexpected attributes %v", s.Name(), s.Attributes())
+		}
+	}
+
+	if got := attrs(spans[1])[otelgostore.ResultsKey].AsInt64(); got != 1 {
+		t.Errorf("GetMulti: expected 1 result, got %d", got)
+	}
+	if spans[0].Status().Code != codes.Unset {
+		t.Errorf("Create: unexpected status %v", spans[0].Status())
+	}
+	if spans[2].Status().Code != codes.Error {
+		t.Errorf("GetByID: expected error status, got %v", spans[2].Status())
+	}
+}
+
+func TestBulkSpans(t *testing.T) {
+	repo, recorder, _ := newRepo(t)
+
+	users := make([]testutil.TestUser, 5)
+	if err := repo.BulkCreateWithOptions(context.Background(), users, 2, exec.BulkOptions{}); err != nil {
+		t.Fatalf("BulkCreate: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("expected 3 batch spans and a parent, got %d", len(spans))
+	}
+
+	parent := spans[3]
+	if parent.Name() != "gostore.users.BulkCreate" || attrs(parent)[otelgostore.ResultsKey].AsInt64() != 5 {
+		t.Errorf("unexpected parent span %s %v", parent.Name(), parent.Attributes())
+	}
+	for _, s := range spans[:3] {
+		if s.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("batch span %s is not a child of the bulk span", s.Name())
+		}
+		if !attrs(s)[otelgostore.BatchKey].AsBool() {
+			t.Errorf("batch span %s lacks %s", s.Name(), otelgostore.BatchKey)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Package prometheusgostore records gostore operations as Prometheus metrics.
+// It is a separate module so that programs not importing it do not depend on
+// the Prometheus client.
+//
+//	m := prometheusgostore.New()
+//	prometheus.MustRegister(m)
+//	h := exec.NewExecWithOptions(exec.WithMetrics(m))
+//	repo := repository.NewBaseRepositoryWithOptions(client, "users", repository.WithMetrics(m))
+//
+// Every metric is labeled with the Datastore kind and the operation, as
+// described by exec.Metrics:
+//
+//   - gostore_operation_duration_seconds, a histogram of call durations
+//   - gostore_operations_total, the number of calls
+//   - gostore_operation_errors_total, the number of failed calls
+//   - gostore_entities_total, the number of entities read or written
+package prometheusgostore
+
+import (
+	"time"
+
+	"github.com/AndroX7/gostore/exec"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metric labels
+const (
+	KindLabel      = "kind"
+	OperationLabel = "operation"
+)
+
+// Option configures the metrics
+type Option func(*config)
+
+type config struct {
+	namespace   string
+	buckets     []float64
+	constLabels prometheus.Labels
+}
+
+// WithNamespace sets the prefix of the metric names. The default is
+// "gostore".
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithBuckets sets the buckets of the duration histogram, in seconds. The
+// default is prometheus.DefBuckets.
+func WithBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// WithConstLabels adds labels with fixed values to every metric, e.g. the
+// Datastore database
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// Metrics is an exec.Metrics recording Prometheus metrics. It is a
+// prometheus.Collector, to be registered before use.
+type Metrics struct {
+	duration   *prometheus.HistogramVec
+	operations *prometheus.CounterVec
+	errors     *prometheus.CounterVec
+	entities   *prometheus.CounterVec
+}
+
+var _ exec.Metrics = (*Metrics)(nil)
+
+// New returns unregistered metrics
+func New(opts ...Option) *Metrics {
+	c := config{namespace: "gostore", buckets: prometheus.DefBuckets}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	labels := []string{KindLabel, OperationLabel}
+	return &Metrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   c.namespace,
+			Name:        "operation_duration_seconds",
+			Help:        "Duration of gostore Datastore calls, including retries.",
+			Buckets:     c.buckets,
+			ConstLabels: c.constLabels,
+		}, labels),
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Name:        "operations_total",
+			Help:        "Number of gostore Datastore calls.",
+			ConstLabels: c.constLabels,
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Name:        "operation_errors_total",
+			Help:        "Number of failed gostore Datastore calls.",
+			ConstLabels: c.constLabels,
+		}, labels),
+		entities: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Name:        "entities_total",
+			Help:        "Number of entities read or written by gostore Datastore calls.",
+			ConstLabels: c.constLabels,
+		}, labels),
+	}
+}
+
+// ObserveOperation implements exec.Metrics
+func (m *Metrics) ObserveOperation(kind, op string, d time.Duration, err error) {
+	m.duration.WithLabelValues(kind, op).Observe(d.Seconds())
+	m.operations.WithLabelValues(kind, op).Inc()
+	if err != nil {
+		m.errors.WithLabelValues(kind, op).Inc()
+	}
+}
+
+// AddEntities implements exec.Metrics
+func (m *Metrics) AddEntities(kind, op string, n int) {
+	m.entities.WithLabelValues(kind, op).Add(float64(n))
+}
+
+// Describe implements prometheus.Collector
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.duration.Describe(ch)
+	m.operations.Describe(ch)
+	m.errors.Describe(ch)
+	m.entities.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.duration.Collect(ch)
+	m.operations.Collect(ch)
+	m.errors.Collect(ch)
+	m.entities.Collect(ch)
+}
@@ -0,0 +1,54 @@
+package prometheusgostore_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/prometheusgostore"
+	"github.com/AndroX7/gostore/testutil"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	m := prometheusgostore.New()
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(m)
+
+	h := exec.NewExecWithOptions(exec.WithClient(testutil.NewMockClient()), exec.WithMetrics(m))
+
+	user := testutil.TestUser{Email: "john@example.com"}
+	if err := h.Create(ctx, "users", "user1", &user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	var got testutil.TestUser
+	if err := h.GetByID(ctx, "users", "missing", &got); err == nil {
+		t.Fatal("expected an error for a missing entity")
+	}
+
+	expected := `
+# HELP gostore_operations_total Number of gostore Datastore calls.
+# TYPE gostore_operations_total counter
+gostore_operations_total{kind="users",operation="Create"} 1
+gostore_operations_total{kind="users",operation="GetByID"} 1
+# HELP gostore_operation_errors_total Number of failed gostore Datastore calls.
+# TYPE gostore_operation_errors_total counter
+gostore_operation_errors_total{kind="users",operation="GetByID"} 1
+# HELP gostore_entities_total Number of entities read or written by gostore Datastore calls.
+# TYPE gostore_entities_total counter
+gostore_entities_total{kind="users",operation="Create"} 1
+`
+	err := promtest.GatherAndCompare(reg, strings.NewReader(expected),
+		"gostore_operations_total", "gostore_operation_errors_total", "gostore_entities_total")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if n := promtest.CollectAndCount(m, "gostore_operation_duration_seconds"); n != 2 {
+		t.Errorf("expected 2 duration series, got %d", n)
+	}
+}
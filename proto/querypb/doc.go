@@ -0,0 +1,6 @@
+// Package querypb holds the Go code generated from proto/query.proto, the
+// protobuf form of builder.QueryParams. Convert with builder.FromProto and
+// builder.ToProto.
+package querypb
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=module=github.com/AndroX7/gostore ../../proto/query.proto
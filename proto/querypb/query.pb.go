@@ -0,0 +1,810 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: proto/query.proto
+
+package querypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Operator mirrors builder.FilterOperator. OPERATOR_UNSPECIFIED is invalid.
+type Operator int32
+
+const (
+	Operator_OPERATOR_UNSPECIFIED           Operator = 0
+	Operator_OPERATOR_EQUAL                 Operator = 1
+	Operator_OPERATOR_LESS_THAN             Operator = 2
+	Operator_OPERATOR_LESS_THAN_OR_EQUAL    Operator = 3
+	Operator_OPERATOR_GREATER_THAN          Operator = 4
+	Operator_OPERATOR_GREATER_THAN_OR_EQUAL Operator = 5
+	Operator_OPERATOR_NOT_EQUAL             Operator = 6
+	Operator_OPERATOR_IN                    Operator = 7
+)
+
+// Enum value maps for Operator.
+var (
+	Operator_name = map[int32]string{
+		0: "OPERATOR_UNSPECIFIED",
+		1: "OPERATOR_EQUAL",
+		2: "OPERATOR_LESS_THAN",
+		3: "OPERATOR_LESS_THAN_OR_EQUAL",
+		4: "OPERATOR_GREATER_THAN",
+		5: "OPERATOR_GREATER_THAN_OR_EQUAL",
+		6: "OPERATOR_NOT_EQUAL",
+		7: "OPERATOR_IN",
+	}
+	Operator_value = map[string]int32{
+		"OPERATOR_UNSPECIFIED":           0,
+		"OPERATOR_EQUAL":                 1,
+		"OPERATOR_LESS_THAN":             2,
+		"OPERATOR_LESS_THAN_OR_EQUAL":    3,
+		"OPERATOR_GREATER_THAN":          4,
+		"OPERATOR_GREATER_THAN_OR_EQUAL": 5,
+		"OPERATOR_NOT_EQUAL":             6,
+		"OPERATOR_IN":                    7,
+	}
+)
+
+func (x Operator) Enum() *Operator {
+	p := new(Operator)
+	*p = x
+	return p
+}
+
+func (x Operator) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Operator) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_query_proto_enumTypes[0].Descriptor()
+}
+
+func (Operator) Type() protoreflect.EnumType {
+	return &file_proto_query_proto_enumTypes[0]
+}
+
+func (x Operator) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Operator.Descriptor instead.
+func (Operator) EnumDescriptor() ([]byte, []int) {
+	return file_proto_query_proto_rawDescGZIP(), []int{0}
+}
+
+// Direction mirrors builder.OrderDirection. DIRECTION_UNSPECIFIED is invalid.
+type Direction int32
+
+const (
+	Direction_DIRECTION_UNSPECIFIED Direction = 0
+	Direction_DIRECTION_ASCENDING   Direction = 1
+	Direction_DIRECTION_DESCENDING  Direction = 2
+)
+
+// Enum value maps for Direction.
+var (
+	Direction_name = map[int32]string{
+		0: "DIRECTION_UNSPECIFIED",
+		1: "DIRECTION_ASCENDING",
+		2: "DIRECTION_DESCENDING",
+	}
+	Direction_value = map[string]int32{
+		"DIRECTION_UNSPECIFIED": 0,
+		"DIRECTION_ASCENDING":   1,
+		"DIRECTION_DESCENDING":  2,
+	}
+)
+
+func (x Direction) Enum() *Direction {
+	p := new(Direction)
+	*p = x
+	return p
+}
+
+func (x Direction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Direction) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_query_proto_enumTypes[1].Descriptor()
+}
+
+func (Direction) Type() protoreflect.EnumType {
+	return &file_proto_query_proto_enumTypes[1]
+}
+
+func (x Direction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Direction.Descriptor instead.
+func (Direction) EnumDescriptor() ([]byte, []int) {
+	return file_proto_query_proto_rawDescGZIP(), []int{1}
+}
+
+// QueryParams mirrors builder.QueryParams
+type QueryParams struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filters       []*FilterParam         `protobuf:"bytes,1,rep,name=filters,proto3" json:"filters,omitempty"`
+	Orders        []*OrderParam          `protobuf:"bytes,2,rep,name=orders,proto3" json:"orders,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	Cursor        string                 `protobuf:"bytes,5,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Select        []string               `protobuf:"bytes,6,rep,name=select,proto3" json:"select,omitempty"`
+	Distinct      bool                   `protobuf:"varint,7,opt,name=distinct,proto3" json:"distinct,omitempty"`
+	KeysOnly      bool                   `protobuf:"varint,8,opt,name=keys_only,json=keysOnly,proto3" json:"keys_only,omitempty"`
+	Ancestor      *AncestorParam         `protobuf:"bytes,9,opt,name=ancestor,proto3" json:"ancestor,omitempty"`
+	Transaction   bool                   `protobuf:"varint,10,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryParams) Reset() {
+	*x = QueryParams{}
+	mi := &file_proto_query_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryParams) ProtoMessage() {}
+
+func (x *QueryParams) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_query_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryParams.ProtoReflect.Descriptor instead.
+func (*QueryParams) Descriptor() ([]byte, []int) {
+	return file_proto_query_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *QueryParams) GetFilters() []*FilterParam {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+func (x *QueryParams) GetOrders() []*OrderParam {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *QueryParams) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *QueryParams) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *QueryParams) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *QueryParams) GetSelect() []string {
+	if x != nil {
+		return x.Select
+	}
+	return nil
+}
+
+func (x *QueryParams) GetDistinct() bool {
+	if x != nil {
+		return x.Distinct
+	}
+	return false
+}
+
+func (x *QueryParams) GetKeysOnly() bool {
+	if x != nil {
+		return x.KeysOnly
+	}
+	return false
+}
+
+func (x *QueryParams) GetAncestor() *AncestorParam {
+	if x != nil {
+		return x.Ancestor
+	}
+	return nil
+}
+
+func (x *QueryParams) GetTransaction() bool {
+	if x != nil {
+		return x.Transaction
+	}
+	return false
+}
+
+// FilterParam mirrors builder.FilterParam
+type FilterParam struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Field    string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Operator Operator               `protobuf:"varint,2,opt,name=operator,proto3,enum=gostore.Operator" json:"operator,omitempty"`
+	// value is a list for OPERATOR_IN and a single value otherwise
+	Value         *Value `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FilterParam) Reset() {
+	*x = FilterParam{}
+	mi := &file_proto_query_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FilterParam) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FilterParam) ProtoMessage() {}
+
+func (x *FilterParam) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_query_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FilterParam.ProtoReflect.Descriptor instead.
+func (*FilterParam) Descriptor() ([]byte, []int) {
+	return file_proto_query_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FilterParam) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *FilterParam) GetOperator() Operator {
+	if x != nil {
+		return x.Operator
+	}
+	return Operator_OPERATOR_UNSPECIFIED
+}
+
+func (x *FilterParam) GetValue() *Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+// Value is a filter value
+type Value struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Kind:
+	//
+	//	*Value_StringValue
+	//	*Value_IntValue
+	//	*Value_DoubleValue
+	//	*Value_BoolValue
+	//	*Value_TimestampValue
+	//	*Value_NullValue
+	//	*Value_ListValue
+	Kind          isValue_Kind `protobuf_oneof:"kind"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Value) Reset() {
+	*x = Value{}
+	mi := &file_proto_query_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Value) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Value) ProtoMessage() {}
+
+func (x *Value) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_query_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Value.ProtoReflect.Descriptor instead.
+func (*Value) Descriptor() ([]byte, []int) {
+	return file_proto_query_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Value) GetKind() isValue_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return nil
+}
+
+func (x *Value) GetStringValue() string {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_StringValue); ok {
+			return x.StringValue
+		}
+	}
+	return ""
+}
+
+func (x *Value) GetIntValue() int64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_IntValue); ok {
+			return x.IntValue
+		}
+	}
+	return 0
+}
+
+func (x *Value) GetDoubleValue() float64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_DoubleValue); ok {
+			return x.DoubleValue
+		}
+	}
+	return 0
+}
+
+func (x *Value) GetBoolValue() bool {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_BoolValue); ok {
+			return x.BoolValue
+		}
+	}
+	return false
+}
+
+func (x *Value) GetTimestampValue() *timestamppb.Timestamp {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_TimestampValue); ok {
+			return x.TimestampValue
+		}
+	}
+	return nil
+}
+
+func (x *Value) GetNullValue() structpb.NullValue {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_NullValue); ok {
+			return x.NullValue
+		}
+	}
+	return structpb.NullValue(0)
+}
+
+func (x *Value) GetListValue() *ValueList {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_ListValue); ok {
+			return x.ListValue
+		}
+	}
+	return nil
+}
+
+type isValue_Kind interface {
+	isValue_Kind()
+}
+
+type Value_StringValue struct {
+	StringValue string `protobuf:"bytes,1,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+type Value_IntValue struct {
+	IntValue int64 `protobuf:"varint,2,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+type Value_DoubleValue struct {
+	DoubleValue float64 `protobuf:"fixed64,3,opt,name=double_value,json=doubleValue,proto3,oneof"`
+}
+
+type Value_BoolValue struct {
+	BoolValue bool `protobuf:"varint,4,opt,name=bool_value,json=boolValue,proto3,oneof"`
+}
+
+type Value_TimestampValue struct {
+	TimestampValue *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=timestamp_value,json=timestampValue,proto3,oneof"`
+}
+
+type Value_NullValue struct {
+	NullValue structpb.NullValue `protobuf:"varint,6,opt,name=null_value,json=nullValue,proto3,enum=google.protobuf.NullValue,oneof"`
+}
+
+type Value_ListValue struct {
+	ListValue *ValueList `protobuf:"bytes,7,opt,name=list_value,json=listValue,proto3,oneof"`
+}
+
+func (*Value_StringValue) isValue_Kind() {}
+
+func (*Value_IntValue) isValue_Kind() {}
+
+func (*Value_DoubleValue) isValue_Kind() {}
+
+func (*Value_BoolValue) isValue_Kind() {}
+
+func (*Value_TimestampValue) isValue_Kind() {}
+
+func (*Value_NullValue) isValue_Kind() {}
+
+func (*Value_ListValue) isValue_Kind() {}
+
+// ValueList is the value of an IN filter. Lists can't be nested.
+type ValueList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []*Value               `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValueList) Reset() {
+	*x = ValueList{}
+	mi := &file_proto_query_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValueList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValueList) ProtoMessage() {}
+
+func (x *ValueList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_query_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValueList.ProtoReflect.Descriptor instead.
+func (*ValueList) Descriptor() ([]byte, []int) {
+	return file_proto_query_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ValueList) GetValues() []*Value {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// OrderParam mirrors builder.OrderParam
+type OrderParam struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Direction     Direction              `protobuf:"varint,2,opt,name=direction,proto3,enum=gostore.Direction" json:"direction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderParam) Reset() {
+	*x = OrderParam{}
+	mi := &file_proto_query_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderParam) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderParam) ProtoMessage() {}
+
+func (x *OrderParam) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_query_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderParam.ProtoReflect.Descriptor instead.
+func (*OrderParam) Descriptor() ([]byte, []int) {
+	return file_proto_query_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *OrderParam) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *OrderParam) GetDirection() Direction {
+	if x != nil {
+		return x.Direction
+	}
+	return Direction_DIRECTION_UNSPECIFIED
+}
+
+// AncestorParam mirrors builder.AncestorParam
+type AncestorParam struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Kind  string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	// Types that are valid to be assigned to Id:
+	//
+	//	*AncestorParam_Name
+	//	*AncestorParam_IntId
+	Id            isAncestorParam_Id `protobuf_oneof:"id"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AncestorParam) Reset() {
+	*x = AncestorParam{}
+	mi := &file_proto_query_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AncestorParam) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AncestorParam) ProtoMessage() {}
+
+func (x *AncestorParam) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_query_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AncestorParam.ProtoReflect.Descriptor instead.
+func (*AncestorParam) Descriptor() ([]byte, []int) {
+	return file_proto_query_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AncestorParam) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *AncestorParam) GetId() isAncestorParam_Id {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *AncestorParam) GetName() string {
+	if x != nil {
+		if x, ok := x.Id.(*AncestorParam_Name); ok {
+			return x.Name
+		}
+	}
+	return ""
+}
+
+func (x *AncestorParam) GetIntId() int64 {
+	if x != nil {
+		if x, ok := x.Id.(*AncestorParam_IntId); ok {
+			return x.IntId
+		}
+	}
+	return 0
+}
+
+type isAncestorParam_Id interface {
+	isAncestorParam_Id()
+}
+
+type AncestorParam_Name struct {
+	Name string `protobuf:"bytes,2,opt,name=name,proto3,oneof"`
+}
+
+type AncestorParam_IntId struct {
+	IntId int64 `protobuf:"varint,3,opt,name=int_id,json=intId,proto3,oneof"`
+}
+
+func (*AncestorParam_Name) isAncestorParam_Id() {}
+
+func (*AncestorParam_IntId) isAncestorParam_Id() {}
+
+var File_proto_query_proto protoreflect.FileDescriptor
+
+const file_proto_query_proto_rawDesc = "" +
+	"\n" +
+	"\x11proto/query.proto\x12\agostore\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd7\x02\n" +
+	"\vQueryParams\x12.\n" +
+	"\afilters\x18\x01 \x03(\v2\x14.gostore.FilterParamR\afilters\x12+\n" +
+	"\x06orders\x18\x02 \x03(\v2\x13.gostore.OrderParamR\x06orders\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x05R\x06offset\x12\x16\n" +
+	"\x06cursor\x18\x05 \x01(\tR\x06cursor\x12\x16\n" +
+	"\x06select\x18\x06 \x03(\tR\x06select\x12\x1a\n" +
+	"\bdistinct\x18\a \x01(\bR\bdistinct\x12\x1b\n" +
+	"\tkeys_only\x18\b \x01(\bR\bkeysOnly\x122\n" +
+	"\bancestor\x18\t \x01(\v2\x16.gostore.AncestorParamR\bancestor\x12 \n" +
+	"\vtransaction\x18\n" +
+	" \x01(\bR\vtransaction\"x\n" +
+	"\vFilterParam\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12-\n" +
+	"\boperator\x18\x02 \x01(\x0e2\x11.gostore.OperatorR\boperator\x12$\n" +
+	"\x05value\x18\x03 \x01(\v2\x0e.gostore.ValueR\x05value\"\xd2\x02\n" +
+	"\x05Value\x12#\n" +
+	"\fstring_value\x18\x01 \x01(\tH\x00R\vstringValue\x12\x1d\n" +
+	"\tint_value\x18\x02 \x01(\x03H\x00R\bintValue\x12#\n" +
+	"\fdouble_value\x18\x03 \x01(\x01H\x00R\vdoubleValue\x12\x1f\n" +
+	"\n" +
+	"bool_value\x18\x04 \x01(\bH\x00R\tboolValue\x12E\n" +
+	"\x0ftimestamp_value\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampH\x00R\x0etimestampValue\x12;\n" +
+	"\n" +
+	"null_value\x18\x06 \x01(\x0e2\x1a.google.protobuf.NullValueH\x00R\tnullValue\x123\n" +
+	"\n" +
+	"list_value\x18\a \x01(\v2\x12.gostore.ValueListH\x00R\tlistValueB\x06\n" +
+	"\x04kind\"3\n" +
+	"\tValueList\x12&\n" +
+	"\x06values\x18\x01 \x03(\v2\x0e.gostore.ValueR\x06values\"T\n" +
+	"\n" +
+	"OrderParam\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x120\n" +
+	"\tdirection\x18\x02 \x01(\x0e2\x12.gostore.DirectionR\tdirection\"X\n" +
+	"\rAncestorParam\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x14\n" +
+	"\x04name\x18\x02 \x01(\tH\x00R\x04name\x12\x17\n" +
+	"\x06int_id\x18\x03 \x01(\x03H\x00R\x05intIdB\x04\n" +
+	"\x02id*\xd9\x01\n" +
+	"\bOperator\x12\x18\n" +
+	"\x14OPERATOR_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eOPERATOR_EQUAL\x10\x01\x12\x16\n" +
+	"\x12OPERATOR_LESS_THAN\x10\x02\x12\x1f\n" +
+	"\x1bOPERATOR_LESS_THAN_OR_EQUAL\x10\x03\x12\x19\n" +
+	"\x15OPERATOR_GREATER_THAN\x10\x04\x12\"\n" +
+	"\x1eOPERATOR_GREATER_THAN_OR_EQUAL\x10\x05\x12\x16\n" +
+	"\x12OPERATOR_NOT_EQUAL\x10\x06\x12\x0f\n" +
+	"\vOPERATOR_IN\x10\a*Y\n" +
+	"\tDirection\x12\x19\n" +
+	"\x15DIRECTION_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13DIRECTION_ASCENDING\x10\x01\x12\x18\n" +
+	"\x14DIRECTION_DESCENDING\x10\x02B*Z(github.com/AndroX7/gostore/proto/querypbb\x06proto3"
+
+var (
+	file_proto_query_proto_rawDescOnce sync.Once
+	file_proto_query_proto_rawDescData []byte
+)
+
+func file_proto_query_proto_rawDescGZIP() []byte {
+	file_proto_query_proto_rawDescOnce.Do(func() {
+		file_proto_query_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_query_proto_rawDesc), len(file_proto_query_proto_rawDesc)))
+	})
+	return file_proto_query_proto_rawDescData
+}
+
+var file_proto_query_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_proto_query_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_proto_query_proto_goTypes = []any{
+	(Operator)(0),                 // 0: gostore.Operator
+	(Direction)(0),                // 1: gostore.Direction
+	(*QueryParams)(nil),           // 2: gostore.QueryParams
+	(*FilterParam)(nil),           // 3: gostore.FilterParam
+	(*Value)(nil),                 // 4: gostore.Value
+	(*ValueList)(nil),             // 5: gostore.ValueList
+	(*OrderParam)(nil),            // 6: gostore.OrderParam
+	(*AncestorParam)(nil),         // 7: gostore.AncestorParam
+	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
+	(structpb.NullValue)(0),       // 9: google.protobuf.NullValue
+}
+var file_proto_query_proto_depIdxs = []int32{
+	3,  // 0: gostore.QueryParams.filters:type_name -> gostore.FilterParam
+	6,  // 1: gostore.QueryParams.orders:type_name -> gostore.OrderParam
+	7,  // 2: gostore.QueryParams.ancestor:type_name -> gostore.AncestorParam
+	0,  // 3: gostore.FilterParam.operator:type_name -> gostore.Operator
+	4,  // 4: gostore.FilterParam.value:type_name -> gostore.Value
+	8,  // 5: gostore.Value.timestamp_value:type_name -> google.protobuf.Timestamp
+	9,  // 6: gostore.Value.null_value:type_name -> google.protobuf.NullValue
+	5,  // 7: gostore.Value.list_value:type_name -> gostore.ValueList
+	4,  // 8: gostore.ValueList.values:type_name -> gostore.Value
+	1,  // 9: gostore.OrderParam.direction:type_name -> gostore.Direction
+	10, // [10:10] is the sub-list for method output_type
+	10, // [10:10] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_proto_query_proto_init() }
+func file_proto_query_proto_init() {
+	if File_proto_query_proto != nil {
+		return
+	}
+	file_proto_query_proto_msgTypes[2].OneofWrappers = []any{
+		(*Value_StringValue)(nil),
+		(*Value_IntValue)(nil),
+		(*Value_DoubleValue)(nil),
+		(*Value_BoolValue)(nil),
+		(*Value_TimestampValue)(nil),
+		(*Value_NullValue)(nil),
+		(*Value_ListValue)(nil),
+	}
+	file_proto_query_proto_msgTypes[5].OneofWrappers = []any{
+		(*AncestorParam_Name)(nil),
+		(*AncestorParam_IntId)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_query_proto_rawDesc), len(file_proto_query_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_query_proto_goTypes,
+		DependencyIndexes: file_proto_query_proto_depIdxs,
+		EnumInfos:         file_proto_query_proto_enumTypes,
+		MessageInfos:      file_proto_query_proto_msgTypes,
+	}.Build()
+	File_proto_query_proto = out.File
+	file_proto_query_proto_goTypes = nil
+	file_proto_query_proto_depIdxs = nil
+}
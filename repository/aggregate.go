@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/exec"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CountWhere is Count returning an int64. It counts the entities matching
+// filters with an aggregation query, except that with WithStatsCount the count
+// of an unfiltered kind comes from its statistics.
+func (r *BaseRepository) CountWhere(ctx context.Context, filters interface{}) (int64, error) {
+	n, err := r.Count(ctx, filters)
+	return int64(n), err
+}
+
+// Sum sums field over the entities matching filters, which take the shapes
+// Count accepts. Entities without field, or storing it noindex, are left out.
+func (r *BaseRepository) Sum(ctx context.Context, field string, filters interface{}) (float64, error) {
+	return r.aggregate(ctx, "Sum", field, filters, r.executor.SumBuilder)
+}
+
+// Avg averages field over the entities matching filters, which take the
+// shapes Count accepts. Entities without field, or storing it noindex, are
+// left out; Avg returns 0 if none are left.
+func (r *BaseRepository) Avg(ctx context.Context, field string, filters interface{}) (float64, error) {
+	return r.aggregate(ctx, "Avg", field, filters, r.executor.AvgBuilder)
+}
+
+// MinMax returns the smallest (lo) and largest (hi) values of field among
+// the entities matching filters, which take the shapes Count accepts, as
+// Datastore stores them (e.g. int64 for an int field). Datastore has no min
+// or max aggregation, so it runs two queries ordered by field with a limit of
+// 1. Entities without field, or storing it noindex, are left out; if none are
+// left, it returns an error matching exec.ErrNotFound.
+func (r *BaseRepository) MinMax(ctx context.Context, field string, filters interface{}) (lo, hi interface{}, err error) {
+	if err := r.notInTx("MinMax"); err != nil {
+		return nil, nil, err
+	}
+	if field == "" {
+		return nil, nil, errors.New("a field to aggregate is required")
+	}
+
+	b, err := r.newBuilder()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := applyFilters(b, filters); err != nil {
+		return nil, nil, err
+	}
+
+	lo, err = r.first(ctx, "MinMax", field, b.Clone().OrderAsc(field))
+	if err != nil {
+		return nil, nil, err
+	}
+	hi, err = r.first(ctx, "MinMax", field, b.Clone().OrderDesc(field))
+	if err != nil {
+		return nil, nil, err
+	}
+	return lo, hi, nil
+}
+
+// first returns field of the first result of b
+func (r *BaseRepository) first(ctx context.Context, method, field string, b *builder.Builder) (interface{}, error) {
+	var rows []datastore.PropertyList
+	if _, err := r.executor.RunBuilder(ctx, b.Limit(1), &rows); err != nil {
+		return nil, r.aggregateError(method, field, err)
+	}
+	if len(rows) == 0 {
+		return nil, exec.ErrNotFound
+	}
+	for _, p := range rows[0] {
+		if p.Name == field {
+			return p.Value, nil
+		}
+	}
+	return nil, exec.ErrNotFound
+}
+
+func (r *BaseRepository) aggregate(ctx context.Context, method, field string, filters interface{}, run func(context.Context, *builder.Builder, string) (float64, error)) (float64, error) {
+	if err := r.notInTx(method); err != nil {
+		return 0, err
+	}
+	if field == "" {
+		return 0, errors.New("a field to aggregate is required")
+	}
+
+	b, err := r.newBuilder()
+	if err != nil {
+		return 0, err
+	}
+	if err := applyFilters(b, filters); err != nil {
+		return 0, err
+	}
+
+	result, err := run(ctx, b, field)
+	if err != nil {
+		return 0, r.aggregateError(method, field, err)
+	}
+	return result, nil
+}
+
+// aggregateError explains the index an aggregation over field is missing
+func (r *BaseRepository) aggregateError(method, field string, err error) error {
+	if status.Code(err) == codes.FailedPrecondition {
+		return fmt.Errorf("%s %s.%s: %s must be indexed and filtered queries over it may need a composite index: %w",
+			method, r.kind, field, field, err)
+	}
+	return err
+}
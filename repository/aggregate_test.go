@@ -0,0 +1,97 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestAggregates(t *testing.T) {
+	ctx, client := emulatorClient(t)
+
+	users := testutil.CreateTestUsers()
+	repo := repository.NewTyped[testutil.TestUser](client, "users")
+	var sum, active, activeSum float64
+	lo, hi := users[0].Age, users[0].Age
+	for i := range users {
+		if _, err := repo.Save(ctx, &users[i]); err != nil {
+			t.Fatalf("Save %s: %v", users[i].ID, err)
+		}
+		sum += float64(users[i].Age)
+		if users[i].Status == "active" {
+			active++
+			activeSum += float64(users[i].Age)
+		}
+		lo, hi = min(lo, users[i].Age), max(hi, users[i].Age)
+	}
+
+	count, err := repo.CountWhere(ctx, map[string]interface{}{"status": "active"})
+	if err != nil {
+		t.Fatalf("CountWhere: %v", err)
+	}
+	if float64(count) != active {
+		t.Errorf("CountWhere: got %d, want %v", count, active)
+	}
+
+	got, err := repo.Sum(ctx, "age", nil)
+	if err != nil {
+		t.Fatalf("Sum: %v", err)
+	}
+	if got != sum {
+		t.Errorf("Sum: got %v, want %v", got, sum)
+	}
+
+	got, err = repo.Avg(ctx, "age", nil)
+	if err != nil {
+		t.Fatalf("Avg: %v", err)
+	}
+	if want := sum / float64(len(users)); got != want {
+		t.Errorf("Avg: got %v, want %v", got, want)
+	}
+
+	got, err = repo.Avg(ctx, "age", map[string]interface{}{"status": "active"})
+	if err != nil {
+		t.Fatalf("Avg active: %v", err)
+	}
+	if want := activeSum / active; got != want {
+		t.Errorf("Avg active: got %v, want %v", got, want)
+	}
+
+	gotLo, gotHi, err := repo.MinMax(ctx, "age", nil)
+	if err != nil {
+		t.Fatalf("MinMax: %v", err)
+	}
+	if gotLo != int64(lo) || gotHi != int64(hi) {
+		t.Errorf("MinMax: got %v, %v, want %d, %d", gotLo, gotHi, lo, hi)
+	}
+}
+
+func TestUnknownOperator(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	testutil.NewSeeder().SeedKind(ctx, t, mock, "users", testutil.CreateTestUsers())
+	repo := repository.NewBaseRepositoryWithClient(mock, "users")
+
+	// A typo must fail rather than count or read the whole kind
+	filters := struct {
+		Age int `datastore:"age" op:"=>"`
+	}{Age: 30}
+
+	if n, err := repo.Count(ctx, filters); err == nil {
+		t.Errorf("expected Count to fail, got %d", n)
+	}
+	if n, err := repo.CountWhere(ctx, filters); err == nil {
+		t.Errorf("expected CountWhere to fail, got %d", n)
+	}
+	if sum, err := repo.Sum(ctx, "age", filters); err == nil {
+		t.Errorf("expected Sum to fail, got %v", sum)
+	}
+	if _, _, err := repo.MinMax(ctx, "age", filters); err == nil {
+		t.Error("expected MinMax to fail")
+	}
+	if results, _, err := repo.Query(ctx, filters); err == nil {
+		t.Errorf("expected Query to fail, got %d results", len(results))
+	}
+}
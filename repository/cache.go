@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/gob"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+// Cache stores serialized entities for the read-through cache of a repository
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, val []byte, ttl time.Duration)
+	Delete(key string)
+}
+
+// WithCache makes GetByID read through c, keeping entities for ttl (no
+// expiry if ttl <= 0). Writes made through the repository invalidate the
+// entities they touch, whether or not they succeed, since a failed call may
+// still have been applied; writes made elsewhere are only seen once the entry
+// expires. Repositories with WithCipher don't cache.
+func WithCache(c Cache, ttl time.Duration) Option {
+	return func(o *options) {
+		o.cache = c
+		o.cacheTTL = ttl
+	}
+}
+
+func init() {
+	// Concrete types found in datastore.Property values
+	gob.Register(time.Time{})
+	gob.Register(&datastore.Key{})
+	gob.Register(datastore.GeoPoint{})
+	gob.Register(&datastore.Entity{})
+	gob.Register([]interface{}{})
+}
+
+// cacheKey identifies an entity by namespace and key path
+func cacheKey(key *datastore.Key) string {
+	return "gostore:" + key.Namespace + ":" + contextKey.FormatPath(key)
+}
+
+// cacheGet loads the cached entity for key into dest
+func (r *BaseRepository) cacheGet(key *datastore.Key, dest interface{}) bool {
+	data, ok := r.cache.Get(cacheKey(key))
+	if !ok {
+		return false
+	}
+
+	var props []datastore.Property
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&props); err != nil {
+		r.cache.Delete(cacheKey(key))
+		return false
+	}
+
+	if err := loadProps(dest, props); err != nil {
+		return false
+	}
+
+	contextKey.SetID(dest, key)
+	return true
+}
+
+// loadProps loads props into dest, a PropertyLoadSaver or struct pointer
+func loadProps(dest interface{}, props []datastore.Property) error {
+	if pls, ok := dest.(datastore.PropertyLoadSaver); ok {
+		return pls.Load(props)
+	}
+	return datastore.LoadStruct(dest, props)
+}
+
+// saveProps returns the properties of src, a PropertyLoadSaver or struct
+// pointer
+func saveProps(src interface{}) ([]datastore.Property, error) {
+	if pls, ok := src.(datastore.PropertyLoadSaver); ok {
+		return pls.Save()
+	}
+	return datastore.SaveStruct(src)
+}
+
+// cacheSet stores src under key. Entities that cannot be encoded are not
+// cached.
+func (r *BaseRepository) cacheSet(key *datastore.Key, src interface{}) {
+	props, err := saveProps(src)
+	if err != nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(props); err != nil {
+		return
+	}
+	r.cache.Set(cacheKey(key), buf.Bytes(), r.cacheTTL)
+}
+
+// invalidate removes keys from the cache, once the transaction commits on a
+// view bound by inTx
+func (r *BaseRepository) invalidate(keys ...*datastore.Key) {
+	if r.cache == nil {
+		return
+	}
+	if r.txCommit != nil {
+		keys = append([]*datastore.Key(nil), keys...)
+		r.txCommit.AfterCommit(func() { r.drop(keys) })
+		return
+	}
+	r.drop(keys)
+}
+
+// drop deletes keys from the cache
+func (r *BaseRepository) drop(keys []*datastore.Key) {
+	for _, key := range keys {
+		if key != nil && !key.Incomplete() {
+			r.cache.Delete(cacheKey(key))
+		}
+	}
+}
+
+// invalidateIDs removes the entities with the given IDs from the cache
+func (r *BaseRepository) invalidateIDs(ids ...interface{}) {
+	if r.cache == nil {
+		return
+	}
+	for _, id := range ids {
+		if key, err := r.executor.Key(r.kind, id); err == nil {
+			r.invalidate(key)
+		}
+	}
+}
+
+// LRUCache is an in-memory Cache holding at most a fixed number of entries,
+// evicting the least recently used. It is safe for concurrent use.
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+	now      func() time.Time
+}
+
+type lruEntry struct {
+	key     string
+	val     []byte
+	expires time.Time
+}
+
+// NewLRUCache creates an LRUCache holding up to capacity entries
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &LRUCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// Get returns the value stored under key unless it is missing or expired
+func (c *LRUCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*lruEntry)
+	if !e.expires.IsZero() && !c.now().Before(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(el)
+	return e.val, true
+}
+
+// Set stores val under key for ttl, or without expiry if ttl <= 0
+func (c *LRUCache) Set(key string, val []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.now().Add(ttl)
+	}
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*lruEntry)
+		e.val, e.expires = val, expires
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, val: val, expires: expires})
+	if c.ll.Len() > c.capacity {
+		c.remove(c.ll.Back())
+	}
+}
+
+// Delete removes key
+func (c *LRUCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Len returns the number of entries, including expired ones not yet evicted
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *LRUCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*lruEntry).key)
+}
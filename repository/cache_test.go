@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+// The context in these tests carries no client, so any read that reaches
+// exec fails and a successful GetByID must have been served from the cache.
+
+func cachedRepo(ttl time.Duration) (*BaseRepository, *LRUCache, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewLRUCache(10)
+	cache.now = func() time.Time { return now }
+
+	repo := NewBaseRepositoryWithOptions(nil, "users", WithCache(cache, ttl))
+	repo.cacheSet(datastore.NameKey("users", "user1", nil), &testutil.TestUser{Name: "John", Age: 30})
+	return repo, cache, &now
+}
+
+type keypartUser struct {
+	Tenant string `datastore:"tenant" gostore:"keypart=1"`
+	Email  string `datastore:"email" gostore:"keypart=2"`
+	Name   string `datastore:"name"`
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Hit avoids the client", func(t *testing.T) {
+		repo, _, _ := cachedRepo(time.Minute)
+
+		var user testutil.TestUser
+		if err := repo.GetByID(ctx, "user1", &user); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if user.Name != "John" || user.Age != 30 || user.ID != "user1" {
+			t.Errorf("expected cached John, got %+v", user)
+		}
+	})
+
+	t.Run("Miss falls back to the client", func(t *testing.T) {
+		repo, _, _ := cachedRepo(time.Minute)
+
+		var user testutil.TestUser
+		if err := repo.GetByID(ctx, "user2", &user); err == nil {
+			t.Error("expected the uncached read to reach the client")
+		}
+	})
+
+	t.Run("Writes invalidate", func(t *testing.T) {
+		writes := map[string]func(r *BaseRepository) error{
+			"Update": func(r *BaseRepository) error { return r.Update(ctx, "user1", &testutil.TestUser{}) },
+			"Delete": func(r *BaseRepository) error { return r.Delete(ctx, "user1") },
+			"Patch":  func(r *BaseRepository) error { return r.Patch(ctx, "user1", map[string]interface{}{"age": 31}) },
+			"CreateMulti": func(r *BaseRepository) error {
+				return r.CreateMulti(ctx, []interface{}{"user1"}, []testutil.TestUser{{}})
+			},
+		}
+
+		for name, write := range writes {
+			t.Run(name, func(t *testing.T) {
+				repo, cache, _ := cachedRepo(time.Minute)
+				write(repo)
+
+				if cache.Len() != 0 {
+					t.Errorf("expected the entry to be invalidated, %d left", cache.Len())
+				}
+			})
+		}
+	})
+
+	t.Run("Transaction writes invalidate at commit", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		key := datastore.NameKey("users", "user1", nil)
+		if _, err := mock.Put(ctx, key, &testutil.TestUser{Name: "John"}); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		cache := NewLRUCache(10)
+		repo := NewBaseRepositoryWithClient(mock, "users", WithCache(cache, time.Minute))
+
+		update := func(fail bool) (int, error) {
+			repo.cacheSet(key, &testutil.TestUser{Name: "John"})
+			attempts := 0
+			_, err := repo.executor.Transaction(ctx, func(tx *exec.TxExec) error {
+				attempts++
+				if err := repo.inTx(tx).Update(ctx, "user1", &testutil.TestUser{Name: "Jane"}); err != nil {
+					return err
+				}
+				if cache.Len() != 1 {
+					t.Errorf("attempt %d: expected the entry kept until commit", attempts)
+				}
+				if fail {
+					return errors.New("rollback")
+				}
+				return nil
+			})
+			return attempts, err
+		}
+
+		if _, err := update(true); err == nil || cache.Len() != 1 {
+			t.Errorf("expected a rolled back write to keep the entry, got %v with %d entries", err, cache.Len())
+		}
+
+		mock.AbortNextTx(1)
+		attempts, err := update(false)
+		if err != nil || attempts != 2 {
+			t.Fatalf("expected the retried transaction to commit on attempt 2, got %v after %d", err, attempts)
+		}
+		if cache.Len() != 0 {
+			t.Errorf("expected the entry invalidated after commit, %d left", cache.Len())
+		}
+	})
+
+	t.Run("Keypart creates invalidate the key written", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		repo := NewBaseRepositoryWithClient(mock, "users", WithCache(NewLRUCache(10), time.Minute))
+		creates := map[string]func(user *keypartUser) error{
+			"CreateWithKey": func(user *keypartUser) error {
+				_, err := repo.CreateWithKey(ctx, nil, user)
+				return err
+			},
+			"CreateMultiWithKeys": func(user *keypartUser) error {
+				_, err := repo.CreateMultiWithKeys(ctx, []interface{}{nil}, []*keypartUser{user})
+				return err
+			},
+		}
+
+		for name, create := range creates {
+			t.Run(name, func(t *testing.T) {
+				if err := create(&keypartUser{Tenant: "acme", Email: "bob", Name: "Bob"}); err != nil {
+					t.Fatalf("create failed: %v", err)
+				}
+				var got keypartUser
+				if err := repo.GetByID(ctx, "acme|bob", &got); err != nil || got.Name != "Bob" {
+					t.Fatalf("expected Bob, got %+v, %v", got, err)
+				}
+
+				if err := create(&keypartUser{Tenant: "acme", Email: "bob", Name: "Robert"}); err != nil {
+					t.Fatalf("create failed: %v", err)
+				}
+				if err := repo.GetByID(ctx, "acme|bob", &got); err != nil || got.Name != "Robert" {
+					t.Errorf("expected the overwrite to be read, got %+v, %v", got, err)
+				}
+			})
+		}
+	})
+
+	t.Run("Expired entries fall back to the client", func(t *testing.T) {
+		repo, _, now := cachedRepo(time.Minute)
+		*now = now.Add(time.Minute)
+
+		var user testutil.TestUser
+		if err := repo.GetByID(ctx, "user1", &user); err == nil {
+			t.Error("expected the expired read to reach the client")
+		}
+	})
+
+	t.Run("Keys include the namespace", func(t *testing.T) {
+		a := cacheKey(datastore.NameKey("users", "user1", nil))
+		k := datastore.NameKey("users", "user1", nil)
+		k.Namespace = "tenant-a"
+		if b := cacheKey(k); a == b {
+			t.Errorf("expected different cache keys, got %q for both", a)
+		}
+	})
+}
+
+func TestLRUCache(t *testing.T) {
+	c := NewLRUCache(2)
+	c.Set("a", []byte("1"), 0)
+	c.Set("b", []byte("2"), 0)
+	c.Get("a")
+	c.Set("c", []byte("3"), 0)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted as least recently used")
+	}
+	if v, ok := c.Get("a"); !ok || string(v) != "1" {
+		t.Errorf("expected a=1, got %q (%v)", v, ok)
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", c.Len())
+	}
+}
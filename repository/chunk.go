@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/exec"
+)
+
+// Chunk calls fn with the entities matching filters, size at a time, paging
+// with cursors so only one page is held in memory. Pages hold the same values
+// as Query results and are never reused, so fn may keep them. Returning
+// exec.ErrStop from fn ends the iteration without an error; any other error
+// ends it and is returned. ctx is checked between pages.
+func (r *BaseRepository) Chunk(ctx context.Context, filters map[string]interface{}, size int, fn func(page []interface{}) error) error {
+	if r.entityType == nil {
+		return r.chunk(ctx, filters, size, reflect.TypeOf([]rawEntity(nil)), func(page reflect.Value) error {
+			results := make([]interface{}, page.Len())
+			for i, e := range page.Interface().([]rawEntity) {
+				results[i] = map[string]interface{}(e)
+			}
+			return fn(results)
+		})
+	}
+
+	return r.chunk(ctx, filters, size, reflect.SliceOf(reflect.PointerTo(r.entityType)), func(page reflect.Value) error {
+		results := make([]interface{}, page.Len())
+		for i := range results {
+			results[i] = page.Index(i).Interface()
+		}
+		return fn(results)
+	})
+}
+
+// chunk runs the paging of Chunk, decoding every page into a new slice of
+// pageType
+func (r *BaseRepository) chunk(ctx context.Context, filters map[string]interface{}, size int, pageType reflect.Type, fn func(page reflect.Value) error) error {
+	if err := r.notInTx("Chunk"); err != nil {
+		return err
+	}
+	if size <= 0 {
+		return fmt.Errorf("chunk size must be positive, got %d", size)
+	}
+
+	b, err := r.newBuilder()
+	if err != nil {
+		return err
+	}
+	for _, filter := range builder.NewFilter().FromMap(filters).Build() {
+		b.Filter(filter.Field, filter.Operator, filter.Value)
+	}
+	r.defaults.applyOrders(b)
+	b.Limit(size)
+
+	cursor := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		page := reflect.New(pageType)
+		next, err := r.executor.RunBuilderPage(ctx, b.Clone().Cursor(cursor), page.Interface())
+		if err != nil {
+			return err
+		}
+
+		n := page.Elem().Len()
+		if n == 0 {
+			return nil
+		}
+		if err := fn(page.Elem()); err != nil {
+			if errors.Is(err, exec.ErrStop) {
+				return nil
+			}
+			return err
+		}
+		if n < size {
+			return nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,70 @@
+package repository_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestChunk(t *testing.T) {
+	ctx, client := emulatorClient(t)
+	repo := repository.NewTyped[testutil.TestUser](client, "users")
+
+	users := make([]testutil.TestUser, 2500)
+	for i := range users {
+		users[i] = testutil.TestUser{Email: fmt.Sprintf("user%d@example.com", i), Status: "active"}
+	}
+	if err := repo.Base().BulkCreate(ctx, users, 500); err != nil {
+		t.Fatalf("BulkCreate: %v", err)
+	}
+
+	t.Run("pages", func(t *testing.T) {
+		var sizes []int
+		var pages [][]testutil.TestUser
+		err := repo.Chunk(ctx, map[string]any{"status": "active"}, 1000, func(page []testutil.TestUser) error {
+			sizes = append(sizes, len(page))
+			pages = append(pages, page)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Chunk: %v", err)
+		}
+		if fmt.Sprint(sizes) != "[1000 1000 500]" {
+			t.Errorf("expected pages of [1000 1000 500], got %v", sizes)
+		}
+
+		// Retained pages stay intact and don't overlap
+		seen := make(map[string]bool)
+		for _, page := range pages {
+			for _, u := range page {
+				if u.ID == "" || seen[u.ID] {
+					t.Fatalf("missing or repeated ID %q", u.ID)
+				}
+				seen[u.ID] = true
+			}
+		}
+	})
+
+	t.Run("early stop", func(t *testing.T) {
+		calls := 0
+		err := repo.Base().Chunk(ctx, nil, 1000, func(page []interface{}) error {
+			calls++
+			return exec.ErrStop
+		})
+		if err != nil || calls != 1 {
+			t.Errorf("expected one page and no error, got %d pages and %v", calls, err)
+		}
+
+		errBoom := errors.New("boom")
+		err = repo.Chunk(ctx, nil, 1000, func(page []testutil.TestUser) error {
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected callback error, got %v", err)
+		}
+	})
+}
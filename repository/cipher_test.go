@@ -0,0 +1,92 @@
+package repository_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type account struct {
+	Email string `datastore:"email"`
+	Token string `datastore:"token" gostore:"encrypt"`
+}
+
+func TestWithCipher(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	c := exec.NewAESGCM(1, func(version byte) ([]byte, error) {
+		return bytes.Repeat([]byte{version}, 16), nil
+	})
+	repo := repository.NewBaseRepositoryWithClient(mock, "accounts", repository.WithCipher(c), repository.WithPrototype(account{}))
+
+	if err := repo.Create(ctx, "a", &account{Email: "a@example.com", Token: "t0"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := repo.Patch(ctx, "a", map[string]interface{}{"token": "t1"}); err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+
+	var got account
+	if err := repo.GetByID(ctx, "a", &got); err != nil || got.Token != "t1" {
+		t.Errorf("expected the patched token decrypted, got %+v, %v", got, err)
+	}
+
+	var raw datastore.PropertyList
+	if err := mock.Get(ctx, datastore.NameKey("accounts", "a", nil), &raw); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	for _, p := range raw {
+		if _, ok := p.Value.([]byte); p.Name == "token" && !ok {
+			t.Errorf("expected the token stored encrypted, got %v", p.Value)
+		}
+	}
+
+	var found []account
+	if err := repo.FindWhere(ctx, map[string]interface{}{"token": "t1"}, &found); !errors.Is(err, exec.ErrEncryptedQuery) {
+		t.Errorf("expected ErrEncryptedQuery, got %v", err)
+	}
+}
+
+// recordingCache is a Cache keeping every value set
+type recordingCache struct {
+	*repository.LRUCache
+	set [][]byte
+}
+
+func (c *recordingCache) Set(key string, val []byte, ttl time.Duration) {
+	c.set = append(c.set, val)
+	c.LRUCache.Set(key, val, ttl)
+}
+
+func TestWithCipherAndCache(t *testing.T) {
+	ctx := context.Background()
+	c := exec.NewAESGCM(1, func(version byte) ([]byte, error) {
+		return bytes.Repeat([]byte{version}, 16), nil
+	})
+	cache := &recordingCache{LRUCache: repository.NewLRUCache(10)}
+	repo := repository.NewBaseRepositoryWithClient(testutil.NewMockClient(), "accounts",
+		repository.WithCipher(c), repository.WithCache(cache, time.Minute))
+
+	if err := repo.Create(ctx, "a", &account{Email: "a@example.com", Token: "secret-token"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for range 2 {
+		var got account
+		if err := repo.GetByID(ctx, "a", &got); err != nil || got.Token != "secret-token" {
+			t.Fatalf("expected the token decrypted, got %+v, %v", got, err)
+		}
+	}
+
+	for _, val := range cache.set {
+		if bytes.Contains(val, []byte("secret-token")) {
+			t.Fatal("expected no plaintext token in the cache")
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	contextKey "github.com/AndroX7/gostore/key"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestNewBaseRepositoryFromContext(t *testing.T) {
+	mock := testutil.NewMockClient()
+	ctx := context.WithValue(context.Background(), contextKey.NOSQL_KEY, mock)
+
+	t.Run("binds the context's client", func(t *testing.T) {
+		repo, err := repository.NewBaseRepositoryFromContext(ctx, "users")
+		if err != nil {
+			t.Fatalf("NewBaseRepositoryFromContext: %v", err)
+		}
+		if repo.Client() != mock {
+			t.Error("expected the repository to use the context's client")
+		}
+
+		// The client is bound, so later calls don't need it in their context
+		user := testutil.CreateTestUsers()[0]
+		if err := repo.Create(context.Background(), user.ID, &user); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if mock.Count("users") != 1 {
+			t.Errorf("expected 1 user stored, got %d", mock.Count("users"))
+		}
+	})
+
+	t.Run("fails without a client", func(t *testing.T) {
+		_, err := repository.NewBaseRepositoryFromContext(context.Background(), "users")
+		if !errors.Is(err, contextKey.ErrClientNotInitialized) {
+			t.Errorf("expected ErrClientNotInitialized, got %v", err)
+		}
+	})
+}
+
+func TestNewLazyBaseRepository(t *testing.T) {
+	mock := testutil.NewMockClient()
+	ctx := context.WithValue(context.Background(), contextKey.NOSQL_KEY, mock)
+	repo := repository.NewLazyBaseRepository("users")
+
+	user := testutil.CreateTestUsers()[0]
+	if err := repo.Create(ctx, user.ID, &user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	var got testutil.TestUser
+	if err := repo.GetByID(ctx, user.ID, &got); err != nil || got.Email != user.Email {
+		t.Errorf("GetByID: expected %s, got %+v (%v)", user.Email, got, err)
+	}
+
+	if err := repo.GetByID(context.Background(), user.ID, &got); !errors.Is(err, contextKey.ErrClientNotInitialized) {
+		t.Errorf("GetByID: expected ErrClientNotInitialized, got %v", err)
+	}
+	if _, err := repo.Exists(context.Background(), user.ID); !errors.Is(err, contextKey.ErrClientNotInitialized) {
+		t.Errorf("Exists: expected ErrClientNotInitialized, got %v", err)
+	}
+	if repo.GetClient() != nil {
+		t.Error("expected no bound client")
+	}
+}
@@ -0,0 +1,53 @@
+package repository
+
+import "github.com/AndroX7/gostore/builder"
+
+// queryDefaults holds the filters and orders a repository adds to its queries
+type queryDefaults struct {
+	filters []builder.FilterParam
+	orders  []builder.OrderParam
+}
+
+// WithDefaultFilter adds a filter to every query of the repository, ahead of
+// the caller's filters, unless IgnoreDefaults is used. Counts, BulkDelete and
+// Preload apply it too.
+func WithDefaultFilter(field string, op builder.FilterOperator, value any) Option {
+	return func(o *options) {
+		o.defaultFilters = append(o.defaultFilters, builder.FilterParam{Field: field, Operator: op, Value: value})
+	}
+}
+
+// WithDefaultOrder orders the results of Query, QueryTyped, FindAll,
+// FindWhere, FindOne and Paginate by field unless the caller orders them
+// itself or IgnoreDefaults is used. Several default orders apply in the order
+// given. As with any order, Datastore requires the first one to be on the
+// property of an inequality filter, if the query has one.
+func WithDefaultOrder(field string, dir builder.OrderDirection) Option {
+	return func(o *options) {
+		o.defaultOrders = append(o.defaultOrders, builder.OrderParam{Field: field, Direction: dir})
+	}
+}
+
+// IgnoreDefaults returns a view of the repository without its default filters
+// and orders. Scopes still apply; chain Unscoped to drop them too.
+func (r *BaseRepository) IgnoreDefaults() *BaseRepository {
+	view := *r
+	view.defaults = queryDefaults{}
+	return &view
+}
+
+func (d queryDefaults) applyFilters(b *builder.Builder) {
+	for _, f := range d.filters {
+		b.Filter(f.Field, f.Operator, f.Value)
+	}
+}
+
+// applyOrders adds the default orders to b unless it is already ordered
+func (d queryDefaults) applyOrders(b *builder.Builder) {
+	if len(b.GetOrders()) > 0 {
+		return
+	}
+	for _, o := range d.orders {
+		b.Order(o.Field, o.Direction)
+	}
+}
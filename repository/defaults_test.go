@@ -0,0 +1,79 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestQueryDefaults(t *testing.T) {
+	ctx, client := emulatorClient(t)
+	repo := repository.NewBaseRepositoryWithOptions(client, "posts",
+		repository.WithDefaultFilter("published", builder.Equal, true),
+		repository.WithDefaultOrder("created_at", builder.Descending),
+	)
+
+	for _, p := range testutil.CreateTestPosts() {
+		if err := repo.Create(ctx, p.ID, &p); err != nil {
+			t.Fatalf("Create %s: %v", p.ID, err)
+		}
+	}
+
+	ids := func(posts []testutil.TestPost) []string {
+		var ids []string
+		for _, p := range posts {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	t.Run("defaults apply", func(t *testing.T) {
+		var all []testutil.TestPost
+		if err := repo.FindAll(ctx, &all); err != nil {
+			t.Fatalf("FindAll: %v", err)
+		}
+		if got := ids(all); len(got) != 2 || got[0] != "post2" || got[1] != "post1" {
+			t.Errorf("FindAll: expected [post2 post1], got %v", got)
+		}
+
+		var page []testutil.TestPost
+		result, err := repo.Paginate(ctx, map[string]interface{}{}, 1, 1, &page)
+		if err != nil {
+			t.Fatalf("Paginate: %v", err)
+		}
+		if result.Total != 2 || len(page) != 1 || page[0].ID != "post2" {
+			t.Errorf("Paginate: expected post2 of 2, got %v of %d", ids(page), result.Total)
+		}
+
+		n, err := repo.Count(ctx, map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("Count: %v", err)
+		}
+		if n != 2 {
+			t.Errorf("Count: expected 2, got %d", n)
+		}
+	})
+
+	t.Run("caller orders override the default order", func(t *testing.T) {
+		var posts []testutil.TestPost
+		params := builder.QueryParams{Orders: []builder.OrderParam{{Field: "created_at", Direction: builder.Ascending}}}
+		if _, err := repo.QueryTyped(ctx, params, &posts); err != nil {
+			t.Fatalf("QueryTyped: %v", err)
+		}
+		if got := ids(posts); len(got) != 2 || got[0] != "post1" {
+			t.Errorf("expected [post1 post2], got %v", got)
+		}
+	})
+
+	t.Run("IgnoreDefaults bypasses filters and orders", func(t *testing.T) {
+		var all []testutil.TestPost
+		if err := repo.IgnoreDefaults().FindAll(ctx, &all); err != nil {
+			t.Fatalf("FindAll: %v", err)
+		}
+		if len(all) != 3 {
+			t.Errorf("expected 3 posts, got %v", ids(all))
+		}
+	})
+}
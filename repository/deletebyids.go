@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+)
+
+// DeleteByIDsOptions configures DeleteByIDsWithOptions
+type DeleteByIDsOptions struct {
+	// Strict looks each batch up before deleting it and reports the IDs
+	// without an entity as missing instead of counting them as deleted
+	Strict bool
+}
+
+// DeleteByIDs deletes the entities with the given IDs in batches of
+// exec.MaxBatchSize. Unlike DeleteMulti it carries on past a failed batch:
+// IDs that cannot be turned into keys, are vetoed by a before delete hook or
+// belong to a failed batch are returned in missingOrFailed, and the rest are
+// counted in deleted. Datastore deletes are idempotent, so IDs without an
+// entity count as deleted; see DeleteByIDsWithOptions to report them. err is
+// only set when the call stops early: on a cancelled ctx or a failed Strict
+// lookup, with an *exec.PartialError, or when an after delete hook fails.
+func (r *BaseRepository) DeleteByIDs(ctx context.Context, ids []interface{}) (deleted int, missingOrFailed []interface{}, err error) {
+	return r.DeleteByIDsWithOptions(ctx, ids, DeleteByIDsOptions{})
+}
+
+// DeleteByIDsWithOptions is DeleteByIDs, optionally reporting IDs without an
+// entity as missing (see DeleteByIDsOptions.Strict)
+func (r *BaseRepository) DeleteByIDsWithOptions(ctx context.Context, ids []interface{}, opts DeleteByIDsOptions) (deleted int, missingOrFailed []interface{}, err error) {
+	if err := r.notInTx("DeleteByIDs"); err != nil {
+		return 0, nil, err
+	}
+
+	for start := 0; start < len(ids); start += exec.MaxBatchSize {
+		if err := ctx.Err(); err != nil {
+			return deleted, missingOrFailed, &exec.PartialError{Completed: start, Err: err}
+		}
+
+		batch := ids[start:min(start+exec.MaxBatchSize, len(ids))]
+		var keys []*datastore.Key
+		var keyIDs []interface{}
+		for _, id := range batch {
+			key, err := r.executor.Key(r.kind, id)
+			if err == nil {
+				err = r.hooks.fire(ctx, beforeDelete, r.kind, id, nil)
+			}
+			if err != nil {
+				missingOrFailed = append(missingOrFailed, id)
+				continue
+			}
+			keys = append(keys, key)
+			keyIDs = append(keyIDs, id)
+		}
+
+		if opts.Strict && len(keys) > 0 {
+			found, err := r.found(ctx, keys)
+			if err != nil {
+				return deleted, missingOrFailed, &exec.PartialError{Completed: start, Err: err}
+			}
+			var existing []*datastore.Key
+			var existingIDs []interface{}
+			for i, ok := range found {
+				if ok {
+					existing = append(existing, keys[i])
+					existingIDs = append(existingIDs, keyIDs[i])
+				} else {
+					missingOrFailed = append(missingOrFailed, keyIDs[i])
+				}
+			}
+			keys, keyIDs = existing, existingIDs
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		err := r.executor.DeleteMultiByKeys(ctx, keys)
+		r.invalidate(keys...)
+
+		var done []*datastore.Key
+		var multi datastore.MultiError
+		switch {
+		case err == nil:
+			done = keys
+		case errors.As(err, &multi) && len(multi) == len(keys):
+			for i, e := range multi {
+				if e == nil {
+					done = append(done, keys[i])
+				} else {
+					missingOrFailed = append(missingOrFailed, keyIDs[i])
+				}
+			}
+		default:
+			missingOrFailed = append(missingOrFailed, keyIDs...)
+		}
+
+		deleted += len(done)
+		if err := r.hooks.fireKeys(ctx, afterDelete, r.kind, done, nil); err != nil {
+			return deleted, missingOrFailed, err
+		}
+	}
+	return deleted, missingOrFailed, nil
+}
+
+// found reports which of keys have an entity. A key whose own lookup fails
+// is reported as not found, and a failed lookup of the whole batch as an
+// error.
+func (r *BaseRepository) found(ctx context.Context, keys []*datastore.Key) ([]bool, error) {
+	found := make([]bool, len(keys))
+	dest := make([]datastore.PropertyList, len(keys))
+	err := r.executor.GetMultiByKeys(ctx, keys, dest)
+
+	var multi datastore.MultiError
+	switch {
+	case err == nil:
+		for i := range found {
+			found[i] = true
+		}
+	case errors.As(err, &multi) && len(multi) == len(keys):
+		for i, e := range multi {
+			found[i] = e == nil
+		}
+	default:
+		return nil, err
+	}
+	return found, nil
+}
@@ -0,0 +1,125 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestDeleteByIDs(t *testing.T) {
+	ctx := context.Background()
+
+	// 1,200 IDs, every tenth of which has no entity
+	setup := func(t *testing.T) (*testutil.MockDatastoreClient, *repository.BaseRepository, []interface{}) {
+		mock := testutil.NewMockClient()
+		repo := repository.NewBaseRepositoryWithClient(mock, "users")
+
+		ids := make([]interface{}, 1200)
+		var users []testutil.TestUser
+		for i := range ids {
+			id := fmt.Sprintf("user-%04d", i)
+			ids[i] = id
+			if i%10 != 0 {
+				users = append(users, testutil.TestUser{ID: id, Status: "active"})
+			}
+		}
+		if err := repo.BulkUpsert(ctx, users, 0); err != nil {
+			t.Fatalf("BulkUpsert: %v", err)
+		}
+		return mock, repo, ids
+	}
+
+	t.Run("lenient", func(t *testing.T) {
+		mock, repo, ids := setup(t)
+
+		var before, after atomic.Int32
+		repo.BeforeDelete(func(ctx context.Context, kind string, id, entity any) error {
+			before.Add(1)
+			return nil
+		})
+		repo.AfterDelete(func(ctx context.Context, kind string, id, entity any) error {
+			after.Add(1)
+			return nil
+		})
+
+		deleted, failed, err := repo.DeleteByIDs(ctx, ids)
+		if err != nil {
+			t.Fatalf("DeleteByIDs: %v", err)
+		}
+		if deleted != 1200 || len(failed) != 0 {
+			t.Errorf("expected 1200 deleted and none failed, got %d and %v", deleted, failed)
+		}
+		if before.Load() != 1200 || after.Load() != 1200 {
+			t.Errorf("expected 1200 hook calls each, got %d before and %d after", before.Load(), after.Load())
+		}
+		if n := mock.Count("users"); n != 0 {
+			t.Errorf("expected no entities left, got %d", n)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		mock, repo, ids := setup(t)
+
+		deleted, missing, err := repo.DeleteByIDsWithOptions(ctx, ids, repository.DeleteByIDsOptions{Strict: true})
+		if err != nil {
+			t.Fatalf("DeleteByIDsWithOptions: %v", err)
+		}
+		if deleted != 1080 || len(missing) != 120 {
+			t.Fatalf("expected 1080 deleted and 120 missing, got %d and %d", deleted, len(missing))
+		}
+		for i, id := range missing {
+			if want := fmt.Sprintf("user-%04d", i*10); id != want {
+				t.Errorf("missing[%d]: got %v, want %s", i, id, want)
+			}
+		}
+		if n := mock.Count("users"); n != 0 {
+			t.Errorf("expected no entities left, got %d", n)
+		}
+	})
+
+	t.Run("strict lookup failure", func(t *testing.T) {
+		mock, repo, ids := setup(t)
+		errUnavailable := errors.New("unavailable")
+		mock.FailNext(testutil.OpGetMulti, errUnavailable, 1)
+
+		deleted, missing, err := repo.DeleteByIDsWithOptions(ctx, ids, repository.DeleteByIDsOptions{Strict: true})
+		var partial *exec.PartialError
+		if !errors.Is(err, errUnavailable) || !errors.As(err, &partial) || partial.Completed != 0 {
+			t.Fatalf("expected a PartialError for the failed lookup, got %v", err)
+		}
+		if deleted != 0 || len(missing) != 0 {
+			t.Errorf("expected nothing deleted or reported missing, got %d and %d", deleted, len(missing))
+		}
+		if n := mock.Count("users"); n != 1080 {
+			t.Errorf("expected every entity left, got %d", n)
+		}
+	})
+
+	t.Run("veto", func(t *testing.T) {
+		mock, repo, ids := setup(t)
+
+		repo.BeforeDelete(func(ctx context.Context, kind string, id, entity any) error {
+			if id == "user-0001" {
+				return errors.New("protected")
+			}
+			return nil
+		})
+
+		deleted, failed, err := repo.DeleteByIDs(ctx, ids)
+		if err != nil {
+			t.Fatalf("DeleteByIDs: %v", err)
+		}
+		if deleted != 1199 || len(failed) != 1 || failed[0] != "user-0001" {
+			t.Errorf("expected only user-0001 to fail, got %d deleted and %v", deleted, failed)
+		}
+		if n := mock.Count("users"); n != 1 {
+			t.Errorf("expected the vetoed entity left, got %d entities", n)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+// FirstOrCreate loads the first entity matching filters into dest, or, if
+// there is none, creates defaults (a pointer to a struct) and copies it into
+// dest. created reports which happened. defaults is stored under its ID field
+// like Save does: a zero ID gets a generated or allocated one, written back
+// into defaults and dest.
+//
+// The query and the create are separate operations, so two concurrent callers
+// can both miss and create two entities. Use FirstOrCreateByID where the
+// entity can be keyed on something deterministic, such as an email address.
+func (r *BaseRepository) FirstOrCreate(ctx context.Context, filters map[string]interface{}, defaults interface{}, dest interface{}) (created bool, err error) {
+	err = r.FindOne(ctx, filters, dest)
+	if !errors.Is(err, exec.ErrNotFound) {
+		return false, err
+	}
+
+	id, _, err := saveID(defaults)
+	if err != nil {
+		return false, err
+	}
+	key, err := r.CreateWithKey(ctx, id, defaults)
+	if err != nil {
+		return false, err
+	}
+	contextKey.SetID(defaults, key)
+	return true, copyEntity(dest, defaults, key)
+}
+
+// FirstOrCreateByID loads the entity stored under id into dest, or, if there
+// is none, creates defaults under id and copies it into dest. The read and the
+// create run in one transaction, so of several concurrent callers exactly one
+// creates the entity and the others load it. On a WithTx view it runs in that
+// transaction.
+func (r *BaseRepository) FirstOrCreateByID(ctx context.Context, id interface{}, defaults interface{}, dest interface{}) (created bool, err error) {
+	if r.tx != nil {
+		return r.firstOrCreateByID(ctx, id, defaults, dest)
+	}
+
+	_, err = r.executor.Transaction(ctx, func(tx *exec.TxExec) error {
+		var err error
+		created, err = r.inTx(tx).firstOrCreateByID(ctx, id, defaults, dest)
+		return err
+	})
+	return created, err
+}
+
+func (r *BaseRepository) firstOrCreateByID(ctx context.Context, id interface{}, defaults interface{}, dest interface{}) (bool, error) {
+	err := r.GetByID(ctx, id, dest)
+	if !errors.Is(err, exec.ErrNotFound) && !errors.Is(err, datastore.ErrNoSuchEntity) {
+		return false, err
+	}
+
+	key, err := r.CreateWithKey(ctx, id, defaults)
+	if err != nil {
+		return false, err
+	}
+	contextKey.SetID(defaults, key)
+	return true, copyEntity(dest, defaults, key)
+}
+
+// copyEntity copies src, stored under key, into dest through its properties
+func copyEntity(dest, src interface{}, key *datastore.Key) error {
+	if dest == src {
+		return nil
+	}
+
+	props, err := saveProps(src)
+	if err != nil {
+		return err
+	}
+	if err := loadProps(dest, props); err != nil {
+		return err
+	}
+	contextKey.SetID(dest, key)
+	return nil
+}
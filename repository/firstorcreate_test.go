@@ -0,0 +1,73 @@
+package repository_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestFirstOrCreate(t *testing.T) {
+	ctx, client := emulatorClient(t)
+	repo := repository.NewBaseRepository(client, "users")
+
+	existing := testutil.CreateTestUsers()[0]
+	if err := repo.Create(ctx, existing.ID, &existing); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	t.Run("hit", func(t *testing.T) {
+		var got testutil.TestUser
+		created, err := repo.FirstOrCreate(ctx, map[string]interface{}{"email": existing.Email}, &testutil.TestUser{Name: "Other"}, &got)
+		if err != nil {
+			t.Fatalf("FirstOrCreate: %v", err)
+		}
+		if created || got.ID != existing.ID || got.Name != existing.Name {
+			t.Errorf("expected existing user, got created=%v %+v", created, got)
+		}
+	})
+
+	t.Run("miss then create", func(t *testing.T) {
+		defaults := testutil.TestUser{Email: "new@example.com", Name: "New"}
+		var got testutil.TestUser
+		created, err := repo.FirstOrCreate(ctx, map[string]interface{}{"email": defaults.Email}, &defaults, &got)
+		if err != nil {
+			t.Fatalf("FirstOrCreate: %v", err)
+		}
+		if !created || got.ID == "" || got.ID != defaults.ID || got.Name != "New" {
+			t.Errorf("expected new user with generated ID, got created=%v %+v", created, got)
+		}
+
+		created, err = repo.FirstOrCreate(ctx, map[string]interface{}{"email": defaults.Email}, &testutil.TestUser{}, &got)
+		if err != nil || created {
+			t.Errorf("expected second call to find the user, got created=%v err=%v", created, err)
+		}
+	})
+
+	t.Run("by ID under concurrent callers", func(t *testing.T) {
+		const callers = 2
+		var wg sync.WaitGroup
+		results := make([]bool, callers)
+		for i := range callers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var got testutil.TestUser
+				created, err := repo.FirstOrCreateByID(ctx, "racer@example.com", &testutil.TestUser{Email: "racer@example.com", Age: i}, &got)
+				if err != nil {
+					t.Errorf("FirstOrCreateByID: %v", err)
+				}
+				if got.ID != "racer@example.com" {
+					t.Errorf("expected user racer@example.com, got %+v", got)
+				}
+				results[i] = created
+			}()
+		}
+		wg.Wait()
+
+		if results[0] == results[1] {
+			t.Errorf("expected exactly one caller to create, got %v", results)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+
+	"cloud.google.com/go/datastore"
+)
+
+// Hook is called around repository writes. Before hooks receive the id passed
+// to the call and can veto the write by returning an error; after hooks
+// receive the final *datastore.Key as id and their error is returned to the
+// caller, although the write has already happened. entity is nil for deletes.
+//
+// Multi variants call hooks once per entity: every before hook runs before the
+// write, so a veto writes nothing. BulkCreate does the same, then calls after
+// hooks per entity as each batch is stored. BulkDelete only learns its keys
+// from a query, so it calls before hooks per key ahead of each batch and a
+// veto stops it with earlier batches already deleted. DeleteByIDs calls them
+// per ID, and a veto only skips the vetoed ID.
+type Hook func(ctx context.Context, kind string, id any, entity any) error
+
+type hookEvent int
+
+const (
+	beforeCreate hookEvent = iota
+	afterCreate
+	beforeUpdate
+	afterUpdate
+	beforeDelete
+	afterDelete
+	numHookEvents
+)
+
+// hooks holds the hooks registered on a repository
+type hooks struct {
+	mu     sync.RWMutex
+	events [numHookEvents][]Hook
+}
+
+func (h *hooks) add(event hookEvent, hook Hook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events[event] = append(h.events[event], hook)
+}
+
+func (h *hooks) has(event hookEvent) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.events[event]) > 0
+}
+
+// fire calls the hooks for event in registration order, stopping at the first
+// error
+func (h *hooks) fire(ctx context.Context, event hookEvent, kind string, id any, entity any) error {
+	h.mu.RLock()
+	hs := h.events[event]
+	h.mu.RUnlock()
+
+	for _, hook := range hs {
+		if err := hook(ctx, kind, id, entity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fireEach calls the hooks for event for every element of entities, passing
+// ids[i] (or nil) and a pointer to the element so hooks can modify it
+func (h *hooks) fireEach(ctx context.Context, event hookEvent, kind string, ids []any, entities any) error {
+	if !h.has(event) {
+		return nil
+	}
+
+	v := reflect.ValueOf(entities)
+	if v.Kind() != reflect.Slice {
+		return errors.New("entities must be a slice")
+	}
+	for i := 0; i < v.Len(); i++ {
+		var id any
+		if i < len(ids) {
+			id = ids[i]
+		}
+		if err := h.fire(ctx, event, kind, id, elem(v, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fireKeys calls the hooks for event once per key, passing the matching
+// element of entities if given
+func (h *hooks) fireKeys(ctx context.Context, event hookEvent, kind string, keys []*datastore.Key, entities any) error {
+	if !h.has(event) {
+		return nil
+	}
+
+	var v reflect.Value
+	if entities != nil {
+		v = reflect.ValueOf(entities)
+	}
+	for i, key := range keys {
+		var entity any
+		if v.IsValid() && i < v.Len() {
+			entity = elem(v, i)
+		}
+		if err := h.fire(ctx, event, kind, key, entity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// elem returns element i of slice v as a pointer, unless it already is one
+func elem(v reflect.Value, i int) any {
+	e := v.Index(i)
+	if e.Kind() == reflect.Ptr || e.Kind() == reflect.Interface || !e.CanAddr() {
+		return e.Interface()
+	}
+	return e.Addr().Interface()
+}
+
+// BeforeCreate registers a hook called before every create
+func (r *BaseRepository) BeforeCreate(hook Hook) {
+	r.hooks.add(beforeCreate, hook)
+}
+
+// AfterCreate registers a hook called after every create
+func (r *BaseRepository) AfterCreate(hook Hook) {
+	r.hooks.add(afterCreate, hook)
+}
+
+// BeforeUpdate registers a hook called before every update
+func (r *BaseRepository) BeforeUpdate(hook Hook) {
+	r.hooks.add(beforeUpdate, hook)
+}
+
+// AfterUpdate registers a hook called after every update
+func (r *BaseRepository) AfterUpdate(hook Hook) {
+	r.hooks.add(afterUpdate, hook)
+}
+
+// BeforeDelete registers a hook called before every delete
+func (r *BaseRepository) BeforeDelete(hook Hook) {
+	r.hooks.add(beforeDelete, hook)
+}
+
+// AfterDelete registers a hook called after every delete
+func (r *BaseRepository) AfterDelete(hook Hook) {
+	r.hooks.add(afterDelete, hook)
+}
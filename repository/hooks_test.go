@@ -0,0 +1,196 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+// hookCounter counts hook invocations by name
+type hookCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *hookCounter) hook(name string) repository.Hook {
+	return func(ctx context.Context, kind string, id any, entity any) error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.counts == nil {
+			c.counts = map[string]int{}
+		}
+		c.counts[name]++
+		return nil
+	}
+}
+
+func (c *hookCounter) get(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[name]
+}
+
+func countingRepo(client *datastore.Client) (*repository.BaseRepository, *hookCounter) {
+	repo := repository.NewBaseRepository(client, "users")
+	c := &hookCounter{}
+	repo.BeforeCreate(c.hook("beforeCreate"))
+	repo.AfterCreate(c.hook("afterCreate"))
+	repo.BeforeUpdate(c.hook("beforeUpdate"))
+	repo.AfterUpdate(c.hook("afterUpdate"))
+	repo.BeforeDelete(c.hook("beforeDelete"))
+	repo.AfterDelete(c.hook("afterDelete"))
+	return repo, c
+}
+
+func TestHooks(t *testing.T) {
+	ctx, client := emulatorClient(t)
+	repo, c := countingRepo(client)
+
+	var afterKeys []*datastore.Key
+	repo.AfterCreate(func(ctx context.Context, kind string, id any, entity any) error {
+		afterKeys = append(afterKeys, id.(*datastore.Key))
+		return nil
+	})
+
+	users := testutil.CreateTestUsers()
+	if err := repo.Create(ctx, "user1", &users[0]); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := repo.Update(ctx, "user1", &users[0]); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := repo.CreateMulti(ctx, []any{"user2", "user3"}, users[1:3]); err != nil {
+		t.Fatalf("CreateMulti failed: %v", err)
+	}
+	if err := repo.BulkCreate(ctx, make([]testutil.TestUser, 5), 2); err != nil {
+		t.Fatalf("BulkCreate failed: %v", err)
+	}
+	if err := repo.Delete(ctx, "user1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	for name, want := range map[string]int{
+		"beforeCreate": 8,
+		"afterCreate":  8,
+		"beforeUpdate": 1,
+		"afterUpdate":  1,
+		"beforeDelete": 1,
+		"afterDelete":  1,
+	} {
+		if got := c.get(name); got != want {
+			t.Errorf("%s: expected %d calls, got %d", name, want, got)
+		}
+	}
+
+	if len(afterKeys) != 8 || afterKeys[0].Name != "user1" {
+		t.Fatalf("expected 8 keys starting with user1, got %v", afterKeys)
+	}
+	for _, key := range afterKeys[3:] {
+		if key.Incomplete() {
+			t.Errorf("expected bulk-created keys to be complete, got %v", key)
+		}
+	}
+}
+
+func TestHookVeto(t *testing.T) {
+	errVeto := errors.New("veto")
+	veto := func(ctx context.Context, kind string, id any, entity any) error {
+		return errVeto
+	}
+
+	t.Run("Before hooks stop the write", func(t *testing.T) {
+		// The context carries no client, so reaching exec would fail with
+		// a different error
+		ctx := context.Background()
+		repo := repository.NewBaseRepository(nil, "users")
+		repo.BeforeCreate(veto)
+		repo.BeforeUpdate(veto)
+		repo.BeforeDelete(veto)
+
+		user := &testutil.TestUser{}
+		checks := map[string]error{
+			"Create":      repo.Create(ctx, "user1", user),
+			"CreateMulti": repo.CreateMulti(ctx, []any{"user1"}, []testutil.TestUser{*user}),
+			"BulkCreate":  repo.BulkCreate(ctx, []testutil.TestUser{*user}, 10),
+			"Update":      repo.Update(ctx, "user1", user),
+			"Delete":      repo.Delete(ctx, "user1"),
+			"DeleteMulti": repo.DeleteMulti(ctx, []any{"user1"}),
+		}
+		for name, err := range checks {
+			if !errors.Is(err, errVeto) {
+				t.Errorf("%s: expected veto error, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("A vetoed item writes nothing", func(t *testing.T) {
+		ctx, client := emulatorClient(t)
+		repo := repository.NewBaseRepository(client, "users")
+		repo.BeforeCreate(func(ctx context.Context, kind string, id any, entity any) error {
+			if entity.(*testutil.TestUser).Status == "banned" {
+				return errVeto
+			}
+			return nil
+		})
+
+		users := []testutil.TestUser{{Status: "active"}, {Status: "banned"}}
+		if err := repo.BulkCreate(ctx, users, 1); !errors.Is(err, errVeto) {
+			t.Fatalf("expected veto error, got %v", err)
+		}
+
+		count, err := exec.NewExec().Count(ctx, "users", nil)
+		if err != nil {
+			t.Fatalf("Count failed: %v", err)
+		}
+		if count != 0 {
+			t.Errorf("expected nothing written, got %d", count)
+		}
+	})
+}
+
+func TestBulkCreateResume(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	errInvalid, errVetoed := errors.New("invalid"), errors.New("vetoed")
+	repo := repository.NewBaseRepositoryWithClient(mock, "users", repository.WithValidator(func(entity any) error {
+		if entity.(*testutil.TestUser).Name == "" {
+			return errInvalid
+		}
+		return nil
+	}))
+
+	var before []string
+	repo.BeforeCreate(func(ctx context.Context, kind string, id any, entity any) error {
+		user := entity.(*testutil.TestUser)
+		if user.Status == "banned" {
+			return errVetoed
+		}
+		before = append(before, user.Name)
+		return nil
+	})
+
+	// The first entity was stored by the run being resumed; it would now
+	// fail both the validator and the hook
+	users := []testutil.TestUser{{Status: "banned"}, {Name: "B"}, {Name: "C"}}
+	if err := repo.BulkCreateWithOptions(ctx, users, 10, exec.BulkOptions{StartAt: 1}); err != nil {
+		t.Fatalf("BulkCreateWithOptions failed: %v", err)
+	}
+	if len(before) != 2 || before[0] != "B" || before[1] != "C" {
+		t.Errorf("expected the hooks to fire for B and C only, got %v", before)
+	}
+	if n := mock.Count("users"); n != 2 {
+		t.Errorf("expected 2 users written, got %d", n)
+	}
+
+	err := repo.BulkCreateWithOptions(ctx, []testutil.TestUser{{Name: "D"}, {}}, 10, exec.BulkOptions{StartAt: 1})
+	if !errors.Is(err, errInvalid) || !strings.Contains(err.Error(), "index 1") {
+		t.Errorf("expected the invalid entity reported at its index in entities, got %v", err)
+	}
+}
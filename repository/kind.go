@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/builder"
+)
+
+// KindNamer derives a kind name from a Go type name
+type KindNamer func(typeName string) string
+
+// Kinder is implemented by entity types that name their own kind
+type Kinder interface {
+	Kind() string
+}
+
+// WithKindNamer sets how For derives a kind from the type name when the type
+// neither implements Kinder nor carries a kind tag. The default is
+// PluralSnakeCase.
+func WithKindNamer(namer KindNamer) Option {
+	return func(o *options) {
+		o.namer = namer
+	}
+}
+
+// For creates a typed repository whose kind is derived from T: the result of
+// a Kind method on T or *T, else a `gostore:"kind=..."` tag on any field of T,
+// else the type name passed through the kind namer. Use NewTyped to give the
+// kind explicitly.
+func For[T any](client *datastore.Client, opts ...Option) *Typed[T] {
+	o := newOptions(append([]Option{WithPrototype(new(T))}, opts...))
+	return NewTypedFrom[T](newBaseRepository(gostore.Wrap(client), kindOf[T](o.namer), o))
+}
+
+// kindOf derives the kind of T
+func kindOf[T any](namer KindNamer) string {
+	var zero T
+	if k, ok := any(zero).(Kinder); ok {
+		return k.Kind()
+	}
+	if k, ok := any(&zero).(Kinder); ok {
+		return k.Kind()
+	}
+
+	t := reflect.TypeOf(&zero).Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			for _, opt := range strings.Split(t.Field(i).Tag.Get("gostore"), ",") {
+				if kind, ok := strings.CutPrefix(opt, "kind="); ok && kind != "" {
+					return kind
+				}
+			}
+		}
+	}
+
+	return namer(t.Name())
+}
+
+// PluralSnakeCase turns a type name into a lowercase, snake_case plural, e.g.
+// "User" into "users" and "BlogCategory" into "blog_categories"
+func PluralSnakeCase(typeName string) string {
+	return pluralize(builder.SnakeCase(typeName))
+}
+
+// pluralize applies the regular English plural rules
+func pluralize(s string) string {
+	switch {
+	case s == "":
+		return s
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "z"),
+		strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	default:
+		return s + "s"
+	}
+}
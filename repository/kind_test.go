@@ -0,0 +1,81 @@
+package repository_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type BlogCategory struct {
+	Name string `datastore:"name"`
+}
+
+type HTTPRequest struct {
+	Path string `datastore:"path"`
+}
+
+type Person struct {
+	Name string `datastore:"name"`
+}
+
+func (Person) Kind() string { return "people" }
+
+type Setting struct {
+	ID    string `datastore:"-" gostore:"id,kind=tenant_settings"`
+	Value string `datastore:"value"`
+}
+
+func TestFor(t *testing.T) {
+	tests := []struct {
+		name string
+		kind string
+		want string
+	}{
+		{"Default naming", repository.For[testutil.TestUser](nil).GetKind(), "test_users"},
+		{"Multi-word -y", repository.For[BlogCategory](nil).GetKind(), "blog_categories"},
+		{"Initialism", repository.For[HTTPRequest](nil).GetKind(), "http_requests"},
+		{"Kind method", repository.For[Person](nil).GetKind(), "people"},
+		{"Kind tag", repository.For[Setting](nil).GetKind(), "tenant_settings"},
+		{
+			"Custom namer",
+			repository.For[BlogCategory](nil, repository.WithKindNamer(strings.ToLower)).GetKind(),
+			"blogcategory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.kind != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, tt.kind)
+			}
+		})
+	}
+}
+
+func TestForQueriesDerivedKind(t *testing.T) {
+	ctx, client := emulatorClient(t)
+
+	repo := repository.For[Setting](client)
+	if _, err := repo.Create(ctx, "theme", &Setting{Value: "dark"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	var settings []Setting
+	if err := exec.NewExec().FindAll(ctx, "tenant_settings", &settings); err != nil {
+		t.Fatalf("FindAll failed: %v", err)
+	}
+	if len(settings) != 1 || settings[0].ID != "theme" {
+		t.Errorf("expected the theme setting under tenant_settings, got %+v", settings)
+	}
+
+	got, _, err := repo.List(ctx, nil)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Value != "dark" {
+		t.Errorf("expected List to read tenant_settings, got %+v", got)
+	}
+}
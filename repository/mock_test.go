@@ -0,0 +1,213 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestBaseRepositoryWithMock(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	repo := repository.NewBaseRepositoryWithClient(mock, "users")
+
+	users := testutil.CreateTestUsers()
+
+	t.Run("Create and get", func(t *testing.T) {
+		if err := repo.Create(ctx, "user1", &users[0]); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		var got testutil.TestUser
+		if err := repo.GetByID(ctx, "user1", &got); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if got.ID != "user1" || got.Email != users[0].Email || !got.CreatedAt.Equal(users[0].CreatedAt) {
+			t.Errorf("expected %+v, got %+v", users[0], got)
+		}
+	})
+
+	t.Run("Create with allocated ID", func(t *testing.T) {
+		key, err := repo.CreateWithKey(ctx, nil, &users[1])
+		if err != nil {
+			t.Fatalf("CreateWithKey failed: %v", err)
+		}
+		if key.Incomplete() {
+			t.Fatal("expected an allocated ID")
+		}
+
+		exists, err := repo.Exists(ctx, key.ID)
+		if err != nil || !exists {
+			t.Errorf("expected entity %d to exist, got %v (%v)", key.ID, exists, err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		updated := users[0]
+		updated.Status = "inactive"
+		if err := repo.Update(ctx, "user1", &updated); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+
+		var got testutil.TestUser
+		if err := repo.GetByID(ctx, "user1", &got); err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if got.Status != "inactive" {
+			t.Errorf("expected inactive, got %s", got.Status)
+		}
+	})
+
+	t.Run("Multi", func(t *testing.T) {
+		if err := repo.CreateMulti(ctx, []interface{}{"user3", "user4"}, users[2:]); err != nil {
+			t.Fatalf("CreateMulti failed: %v", err)
+		}
+
+		got := make([]testutil.TestUser, 2)
+		if err := repo.GetMulti(ctx, []interface{}{"user4", "user3"}, got); err != nil {
+			t.Fatalf("GetMulti failed: %v", err)
+		}
+		if got[0].Name != "Alice Brown" || got[1].Name != "Bob Wilson" {
+			t.Errorf("expected Alice Brown and Bob Wilson, got %s and %s", got[0].Name, got[1].Name)
+		}
+
+		err := repo.GetMulti(ctx, []interface{}{"user3", "missing"}, got)
+		var multi datastore.MultiError
+		if !errors.As(err, &multi) || multi[0] != nil || !errors.Is(multi[1], datastore.ErrNoSuchEntity) {
+			t.Errorf("expected MultiError for the missing entity, got %v", err)
+		}
+	})
+
+	t.Run("FindAll and Count", func(t *testing.T) {
+		var all []testutil.TestUser
+		if err := repo.FindAll(ctx, &all); err != nil {
+			t.Fatalf("FindAll failed: %v", err)
+		}
+		if len(all) != 4 {
+			t.Fatalf("expected 4 users, got %d", len(all))
+		}
+		// Numeric IDs sort before names
+		if all[0].Email != users[1].Email || all[1].ID != "user1" || all[3].ID != "user4" {
+			t.Errorf("expected users in key order, got %+v", all)
+		}
+
+		n, err := repo.Count(ctx, nil)
+		if err != nil || n != 4 {
+			t.Errorf("expected 4 users, got %d (%v)", n, err)
+		}
+	})
+
+	t.Run("FirstOrCreateByID", func(t *testing.T) {
+		var got testutil.TestUser
+		created, err := repo.FirstOrCreateByID(ctx, "user5", &testutil.TestUser{Name: "Eve"}, &got)
+		if err != nil || !created || got.ID != "user5" {
+			t.Fatalf("expected user5 to be created, got %+v, %v (%v)", got, created, err)
+		}
+		created, err = repo.FirstOrCreateByID(ctx, "user5", &testutil.TestUser{Name: "Other"}, &got)
+		if err != nil || created || got.Name != "Eve" {
+			t.Errorf("expected user5 to be loaded, got %+v, %v (%v)", got, created, err)
+		}
+		if err := repo.Delete(ctx, "user5"); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := repo.Delete(ctx, "user1"); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+
+		var got testutil.TestUser
+		if err := repo.GetByID(ctx, "user1", &got); !errors.Is(err, datastore.ErrNoSuchEntity) {
+			t.Errorf("expected ErrNoSuchEntity, got %v", err)
+		}
+		if n := mock.Count("users"); n != 3 {
+			t.Errorf("expected 3 users left, got %d", n)
+		}
+	})
+
+	t.Run("Typed", func(t *testing.T) {
+		typed := repository.NewTypedFrom[testutil.TestUser](repo)
+
+		user, err := typed.Get(ctx, "user3")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if user.Email != "bob@example.com" {
+			t.Errorf("expected bob@example.com, got %s", user.Email)
+		}
+	})
+}
+
+func TestCreateMultiLengthMismatch(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewBaseRepositoryWithClient(testutil.NewMockClient(), "users")
+	repo.BeforeCreate(func(ctx context.Context, kind string, id any, entity any) error {
+		t.Error("hook called despite mismatched lengths")
+		return nil
+	})
+
+	users := testutil.CreateTestUsers()
+	if err := repo.CreateMulti(ctx, []interface{}{"user1"}, users); err == nil {
+		t.Error("expected an error for more entities than ids")
+	}
+	if err := repo.UpdateMulti(ctx, []interface{}{"user1", "user2"}, []testutil.TestUser(nil)); err == nil {
+		t.Error("expected an error for a nil entities slice")
+	}
+}
+
+func TestQueryWithCursorInvalidCursor(t *testing.T) {
+	repo := repository.NewBaseRepositoryWithClient(testutil.NewMockClient(), "users")
+
+	var dest []testutil.TestUser
+	_, err := repo.QueryWithCursor(context.Background(), &builder.QueryParams{Limit: 2, Cursor: "not a cursor!"}, &dest)
+	if !errors.Is(err, builder.ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestQueryWithCursorWithMock(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewTypedFrom[testutil.TestUser](repository.NewBaseRepositoryWithClient(testutil.NewMockClient(), "users"))
+
+	for i := 0; i < 25; i++ {
+		user := testutil.TestUser{ID: fmt.Sprintf("user%02d", i), Age: i}
+		if _, err := repo.Save(ctx, &user); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	params := &builder.QueryParams{Limit: 10, Orders: []builder.OrderParam{{Field: "age", Direction: builder.Ascending}}}
+	var ages []int
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("paging did not stop")
+		}
+		page, pagination, err := repo.QueryWithCursor(ctx, params)
+		if err != nil {
+			t.Fatalf("QueryWithCursor: %v", err)
+		}
+		for _, u := range page {
+			ages = append(ages, u.Age)
+		}
+		if !pagination.HasMore {
+			break
+		}
+		params.Cursor = pagination.NextCursor
+	}
+
+	for i, age := range ages {
+		if age != i {
+			t.Fatalf("expected ages 0 to 24 once each in order, got %v", ages)
+		}
+	}
+	if len(ages) != 25 {
+		t.Errorf("expected 25 users, got %d", len(ages))
+	}
+}
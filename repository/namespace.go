@@ -0,0 +1,21 @@
+package repository
+
+import "github.com/AndroX7/gostore/exec"
+
+// Namespace returns a view of the repository whose keys and queries are all
+// in namespace ("" being the default namespace). Keys passed in whole, as to
+// GetByKey, must already be in it. Hooks, scopes and the cache are shared
+// with the repository; cache entries are kept apart by namespace.
+func (r *BaseRepository) Namespace(namespace string) *BaseRepository {
+	view := *r
+	view.executor = r.executor.With(exec.WithNamespace(namespace))
+	if r.tx != nil {
+		view.tx = view.executor.InTx(r.tx.Transaction())
+	}
+	return &view
+}
+
+// GetNamespace returns the namespace of the repository's keys and queries
+func (r *BaseRepository) GetNamespace() string {
+	return r.executor.Namespace()
+}
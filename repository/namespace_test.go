@@ -0,0 +1,115 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestNamespace(t *testing.T) {
+	ctx, client := emulatorClient(t)
+	base := repository.NewBaseRepository(client, "users")
+	tenantA := base.Namespace("tenant-a")
+	tenantB := repository.NewBaseRepositoryWithNamespace(client, "users", "tenant-b")
+
+	userA := testutil.TestUser{Name: "A", Status: "active"}
+	userB := testutil.TestUser{Name: "B", Status: "active"}
+	if err := tenantA.Create(ctx, "same", &userA); err != nil {
+		t.Fatalf("Create in tenant-a: %v", err)
+	}
+	if err := tenantB.Create(ctx, "same", &userB); err != nil {
+		t.Fatalf("Create in tenant-b: %v", err)
+	}
+	if err := tenantB.Create(ctx, "other", &userB); err != nil {
+		t.Fatalf("Create in tenant-b: %v", err)
+	}
+
+	t.Run("reads", func(t *testing.T) {
+		var got testutil.TestUser
+		if err := tenantA.GetByID(ctx, "same", &got); err != nil || got.Name != "A" {
+			t.Errorf("tenant-a: got %+v, %v", got, err)
+		}
+
+		users := make([]testutil.TestUser, 2)
+		if err := tenantB.GetMulti(ctx, []interface{}{"same", "other"}, users); err != nil {
+			t.Fatalf("GetMulti: %v", err)
+		}
+		if users[0].Name != "B" {
+			t.Errorf("tenant-b: got %+v", users[0])
+		}
+
+		if err := base.GetByID(ctx, "same", &got); !errors.Is(err, datastore.ErrNoSuchEntity) {
+			t.Errorf("default namespace: expected ErrNoSuchEntity, got %v", err)
+		}
+		if exists, _ := tenantA.Exists(ctx, "other"); exists {
+			t.Error("tenant-a sees tenant-b's entity")
+		}
+	})
+
+	t.Run("queries and counts", func(t *testing.T) {
+		for _, tc := range []struct {
+			repo *repository.BaseRepository
+			want int
+		}{{base, 0}, {tenantA, 1}, {tenantB, 2}} {
+			n, err := tc.repo.Count(ctx, map[string]interface{}{"status": "active"})
+			if err != nil {
+				t.Fatalf("Count: %v", err)
+			}
+			var found []testutil.TestUser
+			if err := tc.repo.FindWhere(ctx, map[string]interface{}{"status": "active"}, &found); err != nil {
+				t.Fatalf("FindWhere: %v", err)
+			}
+			results, _, err := tc.repo.Query(ctx, map[string]interface{}{"status": "active"})
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			if n != tc.want || len(found) != tc.want || len(results) != tc.want {
+				t.Errorf("namespace %q: expected %d, got Count=%d FindWhere=%d Query=%d",
+					tc.repo.GetNamespace(), tc.want, n, len(found), len(results))
+			}
+		}
+	})
+
+	t.Run("deletes", func(t *testing.T) {
+		if err := tenantA.Delete(ctx, "same"); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if exists, _ := tenantB.Exists(ctx, "same"); !exists {
+			t.Error("tenant-a delete removed tenant-b's entity")
+		}
+
+		n, err := tenantB.BulkDelete(ctx, map[string]interface{}{"status": "active"})
+		if err != nil {
+			t.Fatalf("BulkDelete: %v", err)
+		}
+		if n != 2 {
+			t.Errorf("expected 2 deleted in tenant-b, got %d", n)
+		}
+	})
+}
+
+func TestNamespaceKeys(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	repo := repository.NewBaseRepositoryWithClient(mock, "users").Namespace("tenant-a")
+
+	key, err := repo.CreateWithKey(ctx, "u1", &testutil.TestUser{Name: "A"})
+	if err != nil {
+		t.Fatalf("CreateWithKey: %v", err)
+	}
+	if key.Namespace != "tenant-a" {
+		t.Errorf("expected key in tenant-a, got %q", key.Namespace)
+	}
+
+	var got testutil.TestUser
+	if err := repo.Namespace("").GetByID(ctx, "u1", &got); !errors.Is(err, datastore.ErrNoSuchEntity) {
+		t.Errorf("default namespace view: expected ErrNoSuchEntity, got %v", err)
+	}
+	if err := repo.GetByKey(ctx, datastore.NameKey("users", "u1", nil), &got); err == nil {
+		t.Error("GetByKey: expected a key outside the namespace to be rejected")
+	}
+}
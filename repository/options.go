@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/exec"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+// Option configures a repository
+type Option func(*options)
+
+type options struct {
+	namer    KindNamer
+	execOpts []exec.Option
+	cache    Cache
+	cacheTTL time.Duration
+	scopes   []string
+	proto    reflect.Type
+
+	statsCount bool
+	cipher     exec.Cipher
+	compressor exec.Compressor
+	naming     builder.NamingStrategy
+
+	validators []func(entity any) error
+
+	defaultFilters []builder.FilterParam
+	defaultOrders  []builder.OrderParam
+}
+
+func newOptions(opts []Option) options {
+	o := options{namer: PluralSnakeCase}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithExecOptions configures the underlying exec.Exec
+func WithExecOptions(opts ...exec.Option) Option {
+	return func(o *options) {
+		o.execOpts = append(o.execOpts, opts...)
+	}
+}
+
+// WithMetrics records the Datastore calls of the repository with m, like
+// exec.WithMetrics
+func WithMetrics(m exec.Metrics) Option {
+	return WithExecOptions(exec.WithMetrics(m))
+}
+
+// WithAudit records the writes of the repository with hook, like
+// exec.WithAudit
+func WithAudit(hook exec.AuditHook) Option {
+	return WithExecOptions(exec.WithAudit(hook))
+}
+
+// WithQueryLogger reports the queries of the repository to l, like
+// exec.WithQueryLogger
+func WithQueryLogger(l builder.QueryLogger) Option {
+	return WithExecOptions(exec.WithQueryLogger(l))
+}
+
+// WithStatsCount makes Count without filters or scopes return the
+// approximate count of exec.KindStats, which is free however large the kind,
+// falling back to an exact count where Datastore has no statistics yet
+func WithStatsCount() Option {
+	return func(o *options) {
+		o.statsCount = true
+	}
+}
+
+// WithCipher encrypts the fields of entities tagged gostore:"encrypt" with c,
+// like exec.WithCipher. With WithPrototype, the prototype is declared with
+// exec.EncryptKind, so that Patch encrypts them too and queries on them fail.
+// GetByID bypasses the cache of WithCache, which would hold the entities
+// decrypted.
+func WithCipher(c exec.Cipher) Option {
+	return func(o *options) {
+		o.cipher = c
+	}
+}
+
+// WithCompression compresses the fields of entities tagged
+// gostore:"compress" with c, like exec.WithCompression, declaring the
+// prototype of WithPrototype like WithCipher
+func WithCompression(c exec.Compressor) Option {
+	return func(o *options) {
+		o.compressor = c
+	}
+}
+
+// WithNamingStrategy names the untagged fields of struct params with
+// strategy, instead of the one set with builder.SetNamingStrategy
+func WithNamingStrategy(strategy builder.NamingStrategy) Option {
+	return func(o *options) {
+		o.naming = strategy
+	}
+}
+
+// WithPrototype makes Query decode results into proto's type, a struct or
+// pointer to struct, instead of maps
+func WithPrototype(proto interface{}) Option {
+	return func(o *options) {
+		t := reflect.TypeOf(proto)
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		o.proto = t
+	}
+}
+
+// NewBaseRepositoryWithOptions creates a base repository configured by
+// repository options
+func NewBaseRepositoryWithOptions(client *datastore.Client, kind string, opts ...Option) *BaseRepository {
+	return newBaseRepository(gostore.Wrap(client), kind, newOptions(opts))
+}
+
+// NewBaseRepositoryWithClient creates a base repository over any
+// gostore.Client, such as testutil's mock
+func NewBaseRepositoryWithClient(client gostore.Client, kind string, opts ...Option) *BaseRepository {
+	return newBaseRepository(client, kind, newOptions(opts))
+}
+
+// NewBaseRepositoryFromContext creates a base repository over the client
+// stored in ctx under key.NOSQL_KEY, the one exec uses, returning
+// key.ErrClientNotInitialized if there is none
+func NewBaseRepositoryFromContext(ctx context.Context, kind string, opts ...Option) (*BaseRepository, error) {
+	client, err := contextKey.ClientFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newBaseRepository(client, kind, newOptions(opts)), nil
+}
+
+// NewLazyBaseRepository creates a base repository without a client. Each call
+// uses the client stored in its ctx under key.NOSQL_KEY and fails with
+// key.ErrClientNotInitialized if there is none. GetClient and Client return
+// nil.
+func NewLazyBaseRepository(kind string, opts ...Option) *BaseRepository {
+	return newBaseRepository(nil, kind, newOptions(opts))
+}
+
+// NewBaseRepositoryWithNamespace creates a base repository whose keys and
+// queries are all in namespace
+func NewBaseRepositoryWithNamespace(client *datastore.Client, kind, namespace string, opts ...Option) *BaseRepository {
+	return NewBaseRepositoryWithOptions(client, kind, append(opts, WithExecOptions(exec.WithNamespace(namespace)))...)
+}
+
+func newBaseRepository(client gostore.Client, kind string, o options) *BaseRepository {
+	execOpts := o.execOpts
+	if client != nil {
+		execOpts = append([]exec.Option{exec.WithClient(client)}, execOpts...)
+	}
+	if o.cipher != nil {
+		execOpts = append(execOpts, exec.WithCipher(o.cipher))
+	}
+	if o.compressor != nil {
+		execOpts = append(execOpts, exec.WithCompression(o.compressor))
+	}
+	if (o.cipher != nil || o.compressor != nil) && o.proto != nil {
+		execOpts = append(execOpts, exec.EncryptKind(kind, reflect.New(o.proto).Interface()))
+	}
+
+	return &BaseRepository{
+		client:     client,
+		kind:       kind,
+		executor:   exec.NewExecWithOptions(execOpts...),
+		hooks:      &hooks{},
+		scopes:     &scopes{},
+		active:     o.scopes,
+		cache:      o.cache,
+		cacheTTL:   o.cacheTTL,
+		entityType: o.proto,
+		validators: o.validators,
+		statsCount: o.statsCount,
+		naming:     o.naming,
+		encrypted:  o.cipher != nil,
+		defaults: queryDefaults{
+			filters: o.defaultFilters,
+			orders:  o.defaultOrders,
+		},
+	}
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/AndroX7/gostore/builder"
+)
+
+func TestApplyMapParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]interface{}
+		want    string
+		wantErr bool
+	}{
+		{"ascending order", map[string]interface{}{"order_by": "name"}, "SELECT * FROM users ORDER BY name", false},
+		{"dash for descending", map[string]interface{}{"order_by": "-created_at"}, "SELECT * FROM users ORDER BY created_at DESC", false},
+		{"desc suffix", map[string]interface{}{"order_by": "created_at DESC"}, "SELECT * FROM users ORDER BY created_at DESC", false},
+		{"comma-separated orders", map[string]interface{}{"order_by": "status, -age,name asc"}, "SELECT * FROM users ORDER BY status, age DESC, name", false},
+		{"string slice orders", map[string]interface{}{"order_by": []string{"-age", "name"}}, "SELECT * FROM users ORDER BY age DESC, name", false},
+		{"JSON array orders", map[string]interface{}{"order_by": []interface{}{"age desc"}}, "SELECT * FROM users ORDER BY age DESC", false},
+		{"int limit", map[string]interface{}{"limit": 10}, "SELECT * FROM users LIMIT 10", false},
+		{"float64 limit", map[string]interface{}{"limit": float64(10)}, "SELECT * FROM users LIMIT 10", false},
+		{"int64 offset", map[string]interface{}{"offset": int64(20)}, "SELECT * FROM users OFFSET 20", false},
+		{"json.Number limit", map[string]interface{}{"limit": json.Number("5")}, "SELECT * FROM users LIMIT 5", false},
+		{"string offset", map[string]interface{}{"offset": "15"}, "SELECT * FROM users OFFSET 15", false},
+		{"filters stay filters", map[string]interface{}{"status": "active"}, `SELECT * FROM users WHERE status = "active"`, false},
+		{"fractional limit", map[string]interface{}{"limit": 2.5}, "", true},
+		{"non-numeric string limit", map[string]interface{}{"limit": "ten"}, "", true},
+		{"unknown limit type", map[string]interface{}{"limit": true}, "", true},
+		{"invalid json.Number offset", map[string]interface{}{"offset": json.Number("1e3x")}, "", true},
+		{"unknown order type", map[string]interface{}{"order_by": 3}, "", true},
+		{"malformed order", map[string]interface{}{"order_by": "age sideways"}, "", true},
+		{"empty order", map[string]interface{}{"order_by": "name,"}, "", true},
+	}
+
+	r := &BaseRepository{kind: "users"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := builder.New().Kind("users")
+			err := r.applyMapParams(b, tt.params)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %s", b)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+type userSearch struct {
+	Status  string   `json:"status"`
+	MinAge  int      `datastore:"age" op:">="`
+	MaxAge  int      `datastore:"age" op:"lt"`
+	Roles   []string `datastore:"role" op:"in"`
+	Sort    string   `json:"sort" gostore:"order"`
+	PerPage float64  `json:"per_page" gostore:"limit"`
+	Skip    int      `json:"skip" gostore:"offset"`
+	Page    string   `json:"page" gostore:"cursor"`
+	builder.QueryParams
+}
+
+func TestApplyStructParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "filters and a range",
+			params: userSearch{Status: "active", MinAge: 18, MaxAge: 65},
+			want:   `SELECT * FROM users WHERE status = "active" AND age >= 18 AND age < 65`,
+		},
+		{
+			name:   "in filter",
+			params: &userSearch{Roles: []string{"admin", "editor"}},
+			want:   `SELECT * FROM users WHERE role IN ARRAY("admin", "editor")`,
+		},
+		{
+			name:   "ordering and paging",
+			params: userSearch{MinAge: 18, Sort: "-age,name", PerPage: 20, Skip: 40},
+			want:   "SELECT * FROM users WHERE age >= 18 ORDER BY age DESC, name LIMIT 20 OFFSET 40",
+		},
+		{
+			name: "tagged fields take precedence over QueryParams",
+			params: userSearch{PerPage: 5, QueryParams: builder.QueryParams{
+				Limit:   100,
+				Offset:  10,
+				Orders:  []builder.OrderParam{{Field: "created_at", Direction: builder.Descending}},
+				Filters: []builder.FilterParam{{Field: "verified", Operator: builder.Equal, Value: true}},
+			}},
+			want: "SELECT * FROM users WHERE verified = true ORDER BY created_at DESC LIMIT 5 OFFSET 10",
+		},
+		{
+			name:   "cursor",
+			params: userSearch{Page: "abc"},
+			want:   "SELECT * FROM users START AT CURSOR",
+		},
+		{
+			name:    "fractional limit",
+			params:  userSearch{PerPage: 2.5},
+			wantErr: true,
+		},
+		{
+			name: "unknown operator",
+			params: struct {
+				Age int `op:"~"`
+			}{Age: 1},
+			wantErr: true,
+		},
+		{
+			name: "unknown gostore tag",
+			params: struct {
+				Size int `gostore:"size"`
+			}{Size: 1},
+			wantErr: true,
+		},
+	}
+
+	r := &BaseRepository{kind: "users"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := builder.New().Kind("users")
+			err := r.applyStructParams(b, tt.params)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %s", b)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructParamsNaming(t *testing.T) {
+	params := struct {
+		LastName string
+		Age      int `datastore:"age_years"`
+	}{LastName: "Doe", Age: 30}
+
+	r := &BaseRepository{kind: "users", naming: builder.SnakeCase}
+	b := builder.New().Kind("users")
+	if err := r.applyStructParams(b, params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := b.String(), "SELECT * FROM users WHERE last_name = \"Doe\" AND age_years = 30"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Pluck loads the values of one property of the entities matching filters
+// into dest, a pointer to a slice of the property's Go type, e.g. *[]string
+// for a string property. It runs a projection query, so the property must be
+// indexed: entities storing it noindex are left out, and a query whose
+// filters need a missing composite index fails with an explanation.
+//
+// An entity storing an array has one value per element in the results.
+func (r *BaseRepository) Pluck(ctx context.Context, field string, filters map[string]any, dest any) error {
+	return r.pluck(ctx, "Pluck", field, filters, dest, false)
+}
+
+// PluckDistinct is like Pluck but returns every value once
+func (r *BaseRepository) PluckDistinct(ctx context.Context, field string, filters map[string]any, dest any) error {
+	return r.pluck(ctx, "PluckDistinct", field, filters, dest, true)
+}
+
+func (r *BaseRepository) pluck(ctx context.Context, method, field string, filters map[string]any, dest any, distinct bool) error {
+	if err := r.notInTx(method); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dest must be a pointer to a slice, got %T", dest)
+	}
+	if field == "" {
+		return errors.New("a field to pluck is required")
+	}
+
+	b, err := r.newBuilder()
+	if err != nil {
+		return err
+	}
+	for _, filter := range builder.NewFilter().FromMap(filters).Build() {
+		b.Filter(filter.Field, filter.Operator, filter.Value)
+	}
+	b.Select(field)
+	if distinct {
+		b.Distinct()
+	}
+
+	var rows []datastore.PropertyList
+	if _, err := r.executor.RunBuilder(ctx, b, &rows); err != nil {
+		if status.Code(err) == codes.FailedPrecondition {
+			return fmt.Errorf("%s %s.%s: projection queries are served from indexes, so %s must be indexed and filtered projections may need a composite index: %w",
+				method, r.kind, field, field, err)
+		}
+		return err
+	}
+
+	slice := v.Elem()
+	values := reflect.MakeSlice(slice.Type(), 0, len(rows))
+	for _, props := range rows {
+		for _, p := range props {
+			if p.Name != field {
+				continue
+			}
+			value, err := pluckValue(p.Value, slice.Type().Elem())
+			if err != nil {
+				return fmt.Errorf("%s %s.%s: %w", method, r.kind, field, err)
+			}
+			values = reflect.Append(values, value)
+		}
+	}
+	slice.Set(values)
+	return nil
+}
+
+// pluckValue converts a projected property value to t
+func pluckValue(value any, t reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(t), nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type().AssignableTo(t):
+		return v, nil
+	case t == timeType && v.Kind() == reflect.Int64:
+		// Projections return timestamps as microseconds
+		return reflect.ValueOf(time.UnixMicro(v.Int())), nil
+	case isNumber(v.Kind()) && isNumber(t.Kind()), v.Kind() == t.Kind():
+		return v.Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot load %T value into %s", value, t)
+}
+
+func isNumber(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package repository_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestPluck(t *testing.T) {
+	ctx, client := emulatorClient(t)
+	repo := repository.NewBaseRepository(client, "users")
+	for _, u := range testutil.CreateTestUsers() {
+		if err := repo.Create(ctx, u.ID, &u); err != nil {
+			t.Fatalf("Create %s: %v", u.ID, err)
+		}
+	}
+
+	t.Run("strings with filters", func(t *testing.T) {
+		var emails []string
+		if err := repo.Pluck(ctx, "email", map[string]any{"status": "active"}, &emails); err != nil {
+			t.Fatalf("Pluck: %v", err)
+		}
+		slices.Sort(emails)
+		want := []string{"alice@example.com", "jane@example.com", "john@example.com"}
+		if !slices.Equal(emails, want) {
+			t.Errorf("expected %v, got %v", want, emails)
+		}
+	})
+
+	t.Run("int64s without filters", func(t *testing.T) {
+		var ages []int64
+		if err := repo.Pluck(ctx, "age", nil, &ages); err != nil {
+			t.Fatalf("Pluck: %v", err)
+		}
+		slices.Sort(ages)
+		if want := []int64{25, 28, 30, 35}; !slices.Equal(ages, want) {
+			t.Errorf("expected %v, got %v", want, ages)
+		}
+	})
+
+	t.Run("distinct", func(t *testing.T) {
+		var statuses []string
+		if err := repo.PluckDistinct(ctx, "status", nil, &statuses); err != nil {
+			t.Fatalf("PluckDistinct: %v", err)
+		}
+		slices.Sort(statuses)
+		if want := []string{"active", "inactive"}; !slices.Equal(statuses, want) {
+			t.Errorf("expected %v, got %v", want, statuses)
+		}
+	})
+}
+
+func TestPluckWithMock(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewBaseRepositoryWithClient(testutil.NewMockClient(), "users")
+	users := testutil.CreateTestUsers()
+	for _, u := range users {
+		if err := repo.Create(ctx, u.ID, &u); err != nil {
+			t.Fatalf("Create %s: %v", u.ID, err)
+		}
+	}
+
+	t.Run("only the projected values", func(t *testing.T) {
+		var emails []string
+		if err := repo.Pluck(ctx, "email", map[string]any{"status": "active"}, &emails); err != nil {
+			t.Fatalf("Pluck: %v", err)
+		}
+		slices.Sort(emails)
+		want := []string{"alice@example.com", "jane@example.com", "john@example.com"}
+		if !slices.Equal(emails, want) {
+			t.Errorf("expected %v, got %v", want, emails)
+		}
+	})
+
+	t.Run("timestamps", func(t *testing.T) {
+		var created []time.Time
+		if err := repo.Pluck(ctx, "created_at", map[string]any{"email": "john@example.com"}, &created); err != nil {
+			t.Fatalf("Pluck: %v", err)
+		}
+		if len(created) != 1 || !created[0].Equal(users[0].CreatedAt) {
+			t.Errorf("expected [%v], got %v", users[0].CreatedAt, created)
+		}
+	})
+
+	t.Run("distinct", func(t *testing.T) {
+		var statuses []string
+		if err := repo.PluckDistinct(ctx, "status", nil, &statuses); err != nil {
+			t.Fatalf("PluckDistinct: %v", err)
+		}
+		slices.Sort(statuses)
+		if want := []string{"active", "inactive"}; !slices.Equal(statuses, want) {
+			t.Errorf("expected %v, got %v", want, statuses)
+		}
+	})
+
+	t.Run("arrays give one value per element", func(t *testing.T) {
+		type post struct {
+			Tags []string `datastore:"tags"`
+		}
+		posts := repository.NewBaseRepositoryWithClient(repo.Client(), "posts")
+		if err := posts.Create(ctx, "a", &post{Tags: []string{"go", "mock"}}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if err := posts.Create(ctx, "b", &post{Tags: []string{"go"}}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		var tags []string
+		if err := posts.Pluck(ctx, "tags", nil, &tags); err != nil {
+			t.Fatalf("Pluck: %v", err)
+		}
+		slices.Sort(tags)
+		if want := []string{"go", "go", "mock"}; !slices.Equal(tags, want) {
+			t.Errorf("expected %v, got %v", want, tags)
+		}
+
+		if err := posts.PluckDistinct(ctx, "tags", nil, &tags); err != nil {
+			t.Fatalf("PluckDistinct: %v", err)
+		}
+		slices.Sort(tags)
+		if want := []string{"go", "mock"}; !slices.Equal(tags, want) {
+			t.Errorf("expected %v, got %v", want, tags)
+		}
+	})
+
+	t.Run("noindex properties are left out", func(t *testing.T) {
+		type note struct {
+			Title string `datastore:"title"`
+			Body  string `datastore:"body,noindex"`
+		}
+		notes := repository.NewBaseRepositoryWithClient(repo.Client(), "notes")
+		if err := notes.Create(ctx, "a", &note{Title: "t", Body: "b"}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		var bodies []string
+		if err := notes.Pluck(ctx, "body", nil, &bodies); err != nil {
+			t.Fatalf("Pluck: %v", err)
+		}
+		if len(bodies) != 0 {
+			t.Errorf("expected no values for a noindex property, got %v", bodies)
+		}
+	})
+}
+
+func TestPluckRejectsInvalidDest(t *testing.T) {
+	repo := repository.NewBaseRepositoryWithClient(testutil.NewMockClient(), "users")
+
+	var emails []string
+	for _, dest := range []any{emails, (*[]string)(nil), new(string)} {
+		if err := repo.Pluck(context.Background(), "email", nil, dest); err == nil {
+			t.Errorf("expected an error for %T", dest)
+		}
+	}
+}
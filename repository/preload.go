@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+// PreloadChunkSize is the number of parent IDs per IN query of Preload
+const PreloadChunkSize = 10
+
+// Preload loads the children of parents from this repository's kind in a few
+// IN queries instead of one query per parent. parents is a slice, or pointer
+// to a slice, of structs or struct pointers. Each parent's ID is read from
+// its parentIDField, or from its ID field (see key.SetID) when parentIDField
+// is empty. Children are matched on their childFKField property and assigned,
+// in query order, to the parent's destField, a slice of the child struct or
+// pointers to it. Parents without children get an empty slice.
+//
+//	err := posts.Preload(ctx, users, "", "user_id", "Posts")
+func (r *BaseRepository) Preload(ctx context.Context, parents interface{}, parentIDField, childFKField, destField string) error {
+	if err := r.notInTx("Preload"); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(parents)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice {
+		return errors.New("parents must be a slice or pointer to a slice")
+	}
+	if v.Len() == 0 {
+		return nil
+	}
+
+	parentType := indirect(v.Type().Elem())
+	if parentType.Kind() != reflect.Struct {
+		return fmt.Errorf("parents must hold structs, got %s", v.Type().Elem())
+	}
+	dest, ok := parentType.FieldByName(destField)
+	if !ok || dest.Type.Kind() != reflect.Slice {
+		return fmt.Errorf("%s has no slice field %s", parentType, destField)
+	}
+	childType := indirect(dest.Type.Elem())
+	if childType.Kind() != reflect.Struct {
+		return fmt.Errorf("%s.%s must be a slice of structs, got %s", parentType, destField, dest.Type)
+	}
+	fk, ok := propertyField(childType, childFKField)
+	if !ok {
+		return fmt.Errorf("%s has no field for property %s", childType, childFKField)
+	}
+
+	// Collect the distinct parent IDs
+	ids := make([]interface{}, v.Len())
+	var distinct []interface{}
+	seen := make(map[interface{}]bool)
+	for i := range ids {
+		p := v.Index(i)
+		if p.Kind() == reflect.Ptr && p.IsNil() {
+			continue
+		}
+		id, err := parentID(p, parentIDField)
+		if err != nil {
+			return err
+		}
+		ids[i] = id
+		if id == nil || reflect.ValueOf(id).IsZero() || seen[groupKey(id)] {
+			continue
+		}
+		seen[groupKey(id)] = true
+		distinct = append(distinct, id)
+	}
+
+	// Query the children chunk by chunk and group them by foreign key
+	groups := make(map[interface{}]reflect.Value)
+	for start := 0; start < len(distinct); start += PreloadChunkSize {
+		end := min(start+PreloadChunkSize, len(distinct))
+
+		b, err := r.newBuilder()
+		if err != nil {
+			return err
+		}
+		b.Filter(childFKField, builder.In, distinct[start:end])
+
+		children := reflect.New(dest.Type)
+		if _, err := r.executor.RunBuilder(ctx, b, children.Interface()); err != nil {
+			return err
+		}
+
+		children = children.Elem()
+		for j := 0; j < children.Len(); j++ {
+			child := children.Index(j)
+			k := groupKey(reflect.Indirect(child).FieldByIndex(fk).Interface())
+			group, ok := groups[k]
+			if !ok {
+				group = reflect.MakeSlice(dest.Type, 0, 1)
+			}
+			groups[k] = reflect.Append(group, child)
+		}
+	}
+
+	for i, id := range ids {
+		p := reflect.Indirect(v.Index(i))
+		if !p.IsValid() {
+			continue
+		}
+
+		group, ok := groups[groupKey(id)]
+		if id == nil || !ok {
+			group = reflect.MakeSlice(dest.Type, 0, 0)
+		}
+		p.FieldByIndex(dest.Index).Set(group)
+	}
+	return nil
+}
+
+// parentID reads the ID of parent p from field, or from its ID field
+func parentID(p reflect.Value, field string) (interface{}, error) {
+	if field == "" {
+		id, ok := contextKey.ID(p.Interface())
+		if !ok {
+			return nil, fmt.Errorf("%s has no ID field", p.Type())
+		}
+		return id, nil
+	}
+
+	f := reflect.Indirect(p).FieldByName(field)
+	if !f.IsValid() {
+		return nil, fmt.Errorf("%s has no field %s", p.Type(), field)
+	}
+	return f.Interface(), nil
+}
+
+// propertyField returns the index of the field of struct type t stored as
+// the Datastore property name
+func propertyField(t reflect.Type, name string) ([]int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		prop, _, _ := strings.Cut(f.Tag.Get("datastore"), ",")
+		if prop == "" {
+			prop = f.Name
+		}
+		if prop == name {
+			return f.Index, true
+		}
+	}
+	return nil, false
+}
+
+// groupKey makes parent IDs and foreign keys of different but compatible
+// types compare equal: integers as int64, keys by their encoding
+func groupKey(v interface{}) interface{} {
+	switch k := v.(type) {
+	case *datastore.Key:
+		if k == nil {
+			return nil
+		}
+		return k.Encode()
+	case int:
+		return int64(k)
+	case int32:
+		return int64(k)
+	}
+	return v
+}
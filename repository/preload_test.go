@@ -0,0 +1,81 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type userWithPosts struct {
+	testutil.TestUser
+	Posts []*testutil.TestPost
+}
+
+func TestPreload(t *testing.T) {
+	ctx, client := emulatorClient(t)
+	testPreload(ctx, t, repository.NewBaseRepository(client, "posts"))
+}
+
+func TestPreloadWithMock(t *testing.T) {
+	testPreload(context.Background(), t, repository.NewBaseRepositoryWithClient(testutil.NewMockClient(), "posts"))
+}
+
+func testPreload(ctx context.Context, t *testing.T, posts *repository.BaseRepository) {
+	for _, p := range testutil.CreateTestPosts() {
+		if err := posts.Create(ctx, p.ID, &p); err != nil {
+			t.Fatalf("Create %s: %v", p.ID, err)
+		}
+	}
+
+	var users []userWithPosts
+	for _, u := range testutil.CreateTestUsers()[:3] {
+		users = append(users, userWithPosts{TestUser: u})
+	}
+
+	if err := posts.Preload(ctx, users, "ID", "user_id", "Posts"); err != nil {
+		t.Fatalf("Preload: %v", err)
+	}
+
+	want := map[string]int{"user1": 2, "user2": 1, "user3": 0}
+	for _, u := range users {
+		if u.Posts == nil {
+			t.Errorf("%s: expected an empty slice, got nil", u.ID)
+			continue
+		}
+		if len(u.Posts) != want[u.ID] {
+			t.Errorf("%s: expected %d posts, got %d", u.ID, want[u.ID], len(u.Posts))
+		}
+		for _, p := range u.Posts {
+			if p.UserID != u.ID || p.ID == "" {
+				t.Errorf("%s: got post %q of %s", u.ID, p.ID, p.UserID)
+			}
+		}
+	}
+}
+
+func TestPreloadInvalidFields(t *testing.T) {
+	ctx := context.Background()
+	posts := repository.NewBaseRepositoryWithClient(testutil.NewMockClient(), "posts")
+	users := []userWithPosts{{TestUser: testutil.TestUser{ID: "user1"}}}
+
+	tests := []struct {
+		name                   string
+		parents                interface{}
+		idField, fkField, dest string
+	}{
+		{"parents not a slice", users[0], "ID", "user_id", "Posts"},
+		{"missing dest field", users, "ID", "user_id", "Comments"},
+		{"dest not a slice", users, "ID", "user_id", "Name"},
+		{"missing foreign key", users, "ID", "author_id", "Posts"},
+		{"missing ID field", users, "Key", "user_id", "Posts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := posts.Preload(ctx, tt.parents, tt.idField, tt.fkField, tt.dest); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
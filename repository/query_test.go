@@ -0,0 +1,129 @@
+package repository_test
+
+import (
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestQueryResults(t *testing.T) {
+	ctx, client := emulatorClient(t)
+
+	users := testutil.CreateTestUsers()
+	base := repository.NewBaseRepository(client, "users")
+	for _, u := range users {
+		if err := base.Create(ctx, u.ID, &u); err != nil {
+			t.Fatalf("Create %s: %v", u.ID, err)
+		}
+	}
+
+	var want []testutil.TestUser
+	keys, err := client.GetAll(ctx, datastore.NewQuery("users").Order("age"), &want)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	for i := range want {
+		want[i].ID = keys[i].Name
+	}
+
+	params := &builder.QueryParams{Orders: []builder.OrderParam{{Field: "age", Direction: builder.Ascending}}}
+
+	t.Run("prototype", func(t *testing.T) {
+		repo := repository.NewBaseRepositoryWithOptions(client, "users", repository.WithPrototype(testutil.TestUser{}))
+		results, pagination, err := repo.Query(ctx, params)
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		if pagination.Total != len(want) || len(results) != len(want) {
+			t.Fatalf("expected %d results, got %d (Total %d)", len(want), len(results), pagination.Total)
+		}
+		for i, res := range results {
+			u, ok := res.(*testutil.TestUser)
+			if !ok {
+				t.Fatalf("result %d: expected *testutil.TestUser, got %T", i, res)
+			}
+			if !reflect.DeepEqual(*u, want[i]) {
+				t.Errorf("result %d: got %+v, want %+v", i, *u, want[i])
+			}
+		}
+	})
+
+	t.Run("typed", func(t *testing.T) {
+		got, _, err := repository.NewTyped[testutil.TestUser](client, "users").Query(ctx, params)
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		results, pagination, err := base.QueryRaw(ctx, params)
+		if err != nil {
+			t.Fatalf("QueryRaw: %v", err)
+		}
+		if pagination.Total != len(want) {
+			t.Fatalf("expected Total %d, got %d", len(want), pagination.Total)
+		}
+		for i, res := range results {
+			m := res.(map[string]interface{})
+			if k, ok := m[repository.KeyField].(*datastore.Key); !ok || k.Name != want[i].ID {
+				t.Errorf("result %d: expected key %s, got %v", i, want[i].ID, m[repository.KeyField])
+			}
+			if age, ok := m["age"].(int64); !ok || age != int64(want[i].Age) {
+				t.Errorf("result %d: expected int64 age %d, got %T %v", i, want[i].Age, m["age"], m["age"])
+			}
+		}
+	})
+}
+
+func TestQueryWithCursor(t *testing.T) {
+	ctx, client := emulatorClient(t)
+
+	users := testutil.CreateTestUsers()
+	repo := repository.NewTyped[testutil.TestUser](client, "users")
+	for i := range users {
+		if _, err := repo.Save(ctx, &users[i]); err != nil {
+			t.Fatalf("Save %s: %v", users[i].ID, err)
+		}
+	}
+
+	// Page through the fixtures twice; both passes must see every user once
+	for pass := 0; pass < 2; pass++ {
+		params := &builder.QueryParams{
+			Limit:  2,
+			Orders: []builder.OrderParam{{Field: "age", Direction: builder.Ascending}},
+		}
+		seen := make(map[string]bool)
+		for pages := 0; ; pages++ {
+			if pages > len(users) {
+				t.Fatalf("pass %d: paging did not stop", pass)
+			}
+			page, pagination, err := repo.QueryWithCursor(ctx, params)
+			if err != nil {
+				t.Fatalf("pass %d: QueryWithCursor: %v", pass, err)
+			}
+			for _, u := range page {
+				if seen[u.ID] {
+					t.Fatalf("pass %d: %s returned twice", pass, u.ID)
+				}
+				seen[u.ID] = true
+			}
+			if !pagination.HasMore {
+				break
+			}
+			if pagination.NextCursor == "" {
+				t.Fatalf("pass %d: HasMore without NextCursor", pass)
+			}
+			params.Cursor = pagination.NextCursor
+		}
+		if len(seen) != len(users) {
+			t.Fatalf("pass %d: saw %d users, want %d", pass, len(seen), len(users))
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package repository_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"cloud.google.com/go/datastore/apiv1/datastorepb"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+// queryLog records the queries it is told about
+type queryLog struct {
+	mu      sync.Mutex
+	queries []builder.QueryInfo
+}
+
+func (l *queryLog) LogQuery(ctx context.Context, info builder.QueryInfo) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.queries = append(l.queries, info)
+}
+
+// last returns the last query logged, failing unless n were logged in all
+func (l *queryLog) last(t *testing.T, n int) builder.QueryInfo {
+	t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.queries) != n {
+		t.Fatalf("expected %d queries logged, got %d", n, len(l.queries))
+	}
+	return l.queries[n-1]
+}
+
+// slowGetAll delays GetAll
+type slowGetAll struct {
+	gostore.Client
+	delay time.Duration
+}
+
+func (c slowGetAll) GetAll(ctx context.Context, q *datastore.Query, dst any) ([]*datastore.Key, error) {
+	time.Sleep(c.delay)
+	return c.Client.GetAll(ctx, q, dst)
+}
+
+// aggregating answers aggregation queries with result, as real Datastore
+// would
+type aggregating struct {
+	gostore.Client
+	result datastore.AggregationResult
+}
+
+func (c aggregating) RunAggregationQuery(ctx context.Context, aq *datastore.AggregationQuery) (datastore.AggregationResult, error) {
+	return c.result, nil
+}
+
+func TestQueryLogger(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	log := &queryLog{}
+	repo := repository.NewBaseRepositoryWithClient(slowGetAll{Client: mock, delay: 5 * time.Millisecond}, "users",
+		repository.WithQueryLogger(log))
+
+	users := testutil.CreateTestUsers()
+	for i := range users {
+		if err := repo.Create(ctx, users[i].ID, &users[i]); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	if len(log.queries) != 0 {
+		t.Fatalf("expected writes not to be logged, got %+v", log.queries)
+	}
+
+	t.Run("FindAll", func(t *testing.T) {
+		var all []testutil.TestUser
+		if err := repo.FindAll(ctx, &all); err != nil {
+			t.Fatalf("FindAll failed: %v", err)
+		}
+
+		info := log.last(t, 1)
+		if info.Kind != "users" || info.Returned != 4 || info.Err != nil {
+			t.Errorf("expected 4 users returned, got %+v", info)
+		}
+		if info.Duration < 5*time.Millisecond {
+			t.Errorf("expected a duration of at least 5ms, got %v", info.Duration)
+		}
+		if info.Query != "SELECT * FROM users" || info.Limit != 0 || info.Cursor {
+			t.Errorf("expected a full scan without a limit, got %+v", info)
+		}
+	})
+
+	t.Run("FindWhere", func(t *testing.T) {
+		var active []testutil.TestUser
+		if err := repo.FindWhere(ctx, map[string]interface{}{"status": "active"}, &active); err != nil {
+			t.Fatalf("FindWhere failed: %v", err)
+		}
+
+		info := log.last(t, 2)
+		if info.Returned != 3 || !strings.Contains(info.Query, `status = "active"`) {
+			t.Errorf("expected 3 active users returned, got %+v", info)
+		}
+	})
+
+	t.Run("FindOne", func(t *testing.T) {
+		var user testutil.TestUser
+		if err := repo.FindOne(ctx, map[string]interface{}{"status": "inactive"}, &user); err != nil {
+			t.Fatalf("FindOne failed: %v", err)
+		}
+
+		if info := log.last(t, 3); info.Returned != 1 || info.Limit != 1 {
+			t.Errorf("expected a single user returned with a limit of 1, got %+v", info)
+		}
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		n, err := repo.Count(ctx, map[string]interface{}{"status": "active"})
+		if err != nil {
+			t.Fatalf("Count failed: %v", err)
+		}
+
+		if info := log.last(t, 4); info.Returned != n || n != 3 {
+			t.Errorf("expected 3 users counted, got %d and %+v", n, info)
+		}
+	})
+
+	t.Run("Paginate", func(t *testing.T) {
+		var page []testutil.TestUser
+		result, err := repo.Paginate(ctx, nil, 2, 3, &page)
+		if err != nil {
+			t.Fatalf("Paginate failed: %v", err)
+		}
+
+		// The page, then the total, counted by keys on the mock
+		if info := log.queries[4]; info.Returned != 1 || info.Limit != 3 || info.Offset != 3 {
+			t.Errorf("expected the second page of 1 user, got %+v", info)
+		}
+		if info := log.last(t, 6); info.Returned != result.Total || result.Total != 4 {
+			t.Errorf("expected a total of 4 users, got %d and %+v", result.Total, info)
+		}
+	})
+
+	t.Run("Aggregations", func(t *testing.T) {
+		log := &queryLog{}
+		client := aggregating{Client: mock, result: datastore.AggregationResult{
+			"count": &datastorepb.Value{ValueType: &datastorepb.Value_IntegerValue{IntegerValue: 3}},
+			"sum":   &datastorepb.Value{ValueType: &datastorepb.Value_IntegerValue{IntegerValue: 90}},
+		}}
+		repo := repository.NewBaseRepositoryWithClient(client, "users", repository.WithQueryLogger(log))
+
+		n, err := repo.Count(ctx, map[string]interface{}{"status": "active"})
+		if err != nil {
+			t.Fatalf("Count failed: %v", err)
+		}
+		if info := log.last(t, 1); info.Returned != n || n != 3 || !strings.Contains(info.Query, `status = "active"`) {
+			t.Errorf("expected the aggregated count of 3 logged, got %d and %+v", n, info)
+		}
+
+		if _, err := repo.Sum(ctx, "age", nil); err != nil {
+			t.Fatalf("Sum failed: %v", err)
+		}
+		if info := log.last(t, 2); info.Query != "SELECT * FROM users" || info.Err != nil {
+			t.Errorf("expected the sum over all users logged, got %+v", info)
+		}
+	})
+
+	t.Run("QueryCounter", func(t *testing.T) {
+		counter := &builder.QueryCounter{}
+		repo := repository.NewBaseRepositoryWithClient(mock, "users", repository.WithQueryLogger(counter))
+
+		var all []testutil.TestUser
+		for range 2 {
+			if err := repo.FindAll(ctx, &all); err != nil {
+				t.Fatalf("FindAll failed: %v", err)
+			}
+		}
+		if _, err := repo.Count(ctx, nil); err != nil {
+			t.Fatalf("Count failed: %v", err)
+		}
+
+		if got := counter.Counts(); len(got) != 1 || counter.Count("users") != 3 {
+			t.Errorf("expected 3 queries counted on users, got %v", got)
+		}
+		counter.Reset()
+		if counter.Count("users") != 0 {
+			t.Error("expected Reset to forget the counts")
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"cloud.google.com/go/datastore"
+)
+
+var (
+	// ErrKindRegistered is returned when registering a kind twice
+	ErrKindRegistered = errors.New("kind already registered")
+
+	// ErrKindNotRegistered is returned for a kind without a factory
+	ErrKindNotRegistered = errors.New("kind not registered")
+)
+
+// Factory creates the repository of a kind over client
+type Factory func(client *datastore.Client) Repository
+
+// Registry maps kinds to their repositories, for code that picks a
+// repository at runtime. Each repository is created by its factory on first
+// Get and reused afterwards, so hooks and scopes added to it stick. A
+// Registry is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	client    *datastore.Client
+	factories map[string]Factory
+	repos     map[string]Repository
+}
+
+// NewRegistry creates a registry whose factories receive client
+func NewRegistry(client *datastore.Client) *Registry {
+	return &Registry{
+		client:    client,
+		factories: make(map[string]Factory),
+		repos:     make(map[string]Repository),
+	}
+}
+
+// SetClient changes the client passed to factories. Repositories already
+// created are dropped and recreated on their next Get.
+func (r *Registry) SetClient(client *datastore.Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.client = client
+	clear(r.repos)
+}
+
+// Register adds the factory for kind. Registering a kind twice returns an
+// error matching ErrKindRegistered.
+func (r *Registry) Register(kind string, factory Factory) error {
+	if factory == nil {
+		return fmt.Errorf("nil factory for kind %s", kind)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.factories[kind]; ok {
+		return fmt.Errorf("%w: %s", ErrKindRegistered, kind)
+	}
+	r.factories[kind] = factory
+	return nil
+}
+
+// Get returns the repository for kind, creating it on first use. An unknown
+// kind returns an error matching ErrKindNotRegistered.
+func (r *Registry) Get(kind string) (Repository, error) {
+	r.mu.RLock()
+	repo, ok := r.repos[kind]
+	r.mu.RUnlock()
+	if ok {
+		return repo, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if repo, ok := r.repos[kind]; ok {
+		return repo, nil
+	}
+	factory, ok := r.factories[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKindNotRegistered, kind)
+	}
+	repo = factory(r.client)
+	r.repos[kind] = repo
+	return repo, nil
+}
+
+// MustGet is like Get but panics if kind is not registered
+func (r *Registry) MustGet(kind string) Repository {
+	repo, err := r.Get(kind)
+	if err != nil {
+		panic(err)
+	}
+	return repo
+}
+
+// Kinds returns the registered kinds in sorted order
+func (r *Registry) Kinds() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	kinds := make([]string, 0, len(r.factories))
+	for kind := range r.factories {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+	return kinds
+}
+
+// DefaultRegistry is the registry used by the package-level Register, Get
+// and MustGet. Like any global it is shared by everything in the process,
+// including tests; prefer passing a Registry explicitly where practical. Its
+// client is nil until SetClient is called.
+var DefaultRegistry = NewRegistry(nil)
+
+// Register adds the factory for kind to DefaultRegistry
+func Register(kind string, factory Factory) error {
+	return DefaultRegistry.Register(kind, factory)
+}
+
+// Get returns the repository for kind from DefaultRegistry
+func Get(kind string) (Repository, error) {
+	return DefaultRegistry.Get(kind)
+}
+
+// MustGet returns the repository for kind from DefaultRegistry, panicking if
+// kind is not registered
+func MustGet(kind string) Repository {
+	return DefaultRegistry.MustGet(kind)
+}
@@ -0,0 +1,98 @@
+package repository_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+// mockFactory returns a factory creating kind over a mock client
+func mockFactory(kind string) repository.Factory {
+	return func(*datastore.Client) repository.Repository {
+		return repository.NewBaseRepositoryWithClient(testutil.NewMockClient(), kind)
+	}
+}
+
+func kindOf(repo repository.Repository) string {
+	return repo.(interface{ GetKind() string }).GetKind()
+}
+
+func TestRegistry(t *testing.T) {
+	t.Run("register and get", func(t *testing.T) {
+		reg := repository.NewRegistry(nil)
+		if err := reg.Register("users", mockFactory("users")); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+
+		repo, err := reg.Get("users")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if kindOf(repo) != "users" {
+			t.Errorf("expected users repository, got %s", kindOf(repo))
+		}
+		if reg.MustGet("users") != repo {
+			t.Error("expected Get to reuse the repository")
+		}
+		if kinds := reg.Kinds(); len(kinds) != 1 || kinds[0] != "users" {
+			t.Errorf("unexpected kinds %v", kinds)
+		}
+	})
+
+	t.Run("duplicate kind", func(t *testing.T) {
+		reg := repository.NewRegistry(nil)
+		reg.Register("users", mockFactory("users"))
+		if err := reg.Register("users", mockFactory("users")); !errors.Is(err, repository.ErrKindRegistered) {
+			t.Errorf("expected ErrKindRegistered, got %v", err)
+		}
+	})
+
+	t.Run("unknown kind", func(t *testing.T) {
+		reg := repository.NewRegistry(nil)
+		if _, err := reg.Get("posts"); !errors.Is(err, repository.ErrKindNotRegistered) {
+			t.Errorf("expected ErrKindNotRegistered, got %v", err)
+		}
+
+		defer func() {
+			if recover() == nil {
+				t.Error("expected MustGet to panic")
+			}
+		}()
+		reg.MustGet("posts")
+	})
+
+	t.Run("concurrent register and get", func(t *testing.T) {
+		reg := repository.NewRegistry(nil)
+		var wg sync.WaitGroup
+		for i := range 20 {
+			kind := fmt.Sprintf("kind%d", i)
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				if err := reg.Register(kind, mockFactory(kind)); err != nil {
+					t.Errorf("Register %s: %v", kind, err)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for {
+					if repo, err := reg.Get(kind); err == nil {
+						if kindOf(repo) != kind {
+							t.Errorf("expected %s, got %s", kind, kindOf(repo))
+						}
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		if len(reg.Kinds()) != 20 {
+			t.Errorf("expected 20 kinds, got %d", len(reg.Kinds()))
+		}
+	})
+}
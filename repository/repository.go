@@ -2,8 +2,17 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
 	"github.com/AndroX7/gostore/builder"
 	"github.com/AndroX7/gostore/exec"
 )
@@ -21,85 +30,293 @@ type Repository interface {
 
 // BaseRepository implements common repository operations
 type BaseRepository struct {
-	client   *datastore.Client
-	kind     string
-	executor *exec.Exec
+	client     gostore.Client
+	kind       string
+	executor   *exec.Exec
+	hooks      *hooks
+	scopes     *scopes
+	active     []string
+	implicit   func(b *builder.Builder) // applied before scopes, by wrappers
+	entityType reflect.Type
+	cache      Cache
+	cacheTTL   time.Duration
+	tx         *exec.TxExec
+	// txCommit, on a view bound by inTx, delays cache invalidation until
+	// the transaction commits
+	txCommit   *exec.TxExec
+	validators []func(entity any) error
+	defaults   queryDefaults
+	statsCount bool
+	naming     builder.NamingStrategy
+	// encrypted repositories bypass the cache, which would hold the
+	// decrypted fields in plaintext
+	encrypted bool
 }
 
-// NewBaseRepository creates a new base repository
-func NewBaseRepository(client *datastore.Client, kind string) *BaseRepository {
-	return &BaseRepository{
-		client:   client,
-		kind:     kind,
-		executor: exec.NewExec(),
-	}
+// NewBaseRepository creates a new base repository. opts configure the
+// underlying exec.Exec, e.g. exec.WithNamespace or exec.WithRetryPolicy.
+func NewBaseRepository(client *datastore.Client, kind string, opts ...exec.Option) *BaseRepository {
+	return NewBaseRepositoryWithOptions(client, kind, WithExecOptions(opts...))
 }
 
-// GetByID retrieves entity by ID
+// GetByID retrieves entity by ID, through the cache if one is configured and
+// the repository has no cipher
 func (r *BaseRepository) GetByID(ctx context.Context, id interface{}, dest interface{}) error {
-	return r.executor.GetByID(ctx, r.kind, id, dest)
+	if r.cache == nil || r.tx != nil || r.encrypted {
+		return r.store().GetByID(ctx, r.kind, id, dest)
+	}
+
+	key, err := r.executor.Key(r.kind, id)
+	if err != nil {
+		return err
+	}
+	if r.cacheGet(key, dest) {
+		return nil
+	}
+
+	if err := r.store().GetByID(ctx, r.kind, id, dest); err != nil {
+		return err
+	}
+	r.cacheSet(key, dest)
+	return nil
+}
+
+// GetByKey retrieves the entity stored under key, which must be of the
+// repository's kind
+func (r *BaseRepository) GetByKey(ctx context.Context, key *datastore.Key, dest interface{}) error {
+	if err := r.checkKind(key); err != nil {
+		return err
+	}
+	return r.store().GetByKey(ctx, key, dest)
+}
+
+// GetProjection retrieves only the given fields of an entity
+func (r *BaseRepository) GetProjection(ctx context.Context, id interface{}, fields []string, dest interface{}) error {
+	if err := r.notInTx("GetProjection"); err != nil {
+		return err
+	}
+	return r.executor.GetProjection(ctx, r.kind, id, fields, dest)
 }
 
 // GetMulti retrieves multiple entities
 func (r *BaseRepository) GetMulti(ctx context.Context, ids []interface{}, dest interface{}) error {
-	return r.executor.GetMulti(ctx, r.kind, ids, dest)
+	return r.store().GetMulti(ctx, r.kind, ids, dest)
 }
 
 // Create creates a new entity
 func (r *BaseRepository) Create(ctx context.Context, id interface{}, entity interface{}) error {
-	return r.executor.Create(ctx, r.kind, id, entity)
+	_, err := r.CreateWithKey(ctx, id, entity)
+	return err
+}
+
+// CreateWithKey creates a new entity and returns its key
+func (r *BaseRepository) CreateWithKey(ctx context.Context, id interface{}, entity interface{}) (*datastore.Key, error) {
+	if err := r.validate(entity); err != nil {
+		return nil, err
+	}
+	if err := r.hooks.fire(ctx, beforeCreate, r.kind, id, entity); err != nil {
+		return nil, err
+	}
+	if id != nil {
+		defer r.invalidateIDs(id)
+	}
+
+	key, err := r.store().CreateWithKey(ctx, r.kind, id, entity)
+	// A nil id may still name an existing entity, from keypart fields
+	r.invalidate(key)
+	if err != nil {
+		return nil, err
+	}
+	return key, r.hooks.fire(ctx, afterCreate, r.kind, key, entity)
 }
 
 // CreateMulti creates multiple entities
 func (r *BaseRepository) CreateMulti(ctx context.Context, ids []interface{}, entities interface{}) error {
-	return r.executor.CreateMulti(ctx, r.kind, ids, entities)
+	_, err := r.CreateMultiWithKeys(ctx, ids, entities)
+	return err
+}
+
+// CreateMultiWithKeys creates multiple entities and returns their keys in order
+func (r *BaseRepository) CreateMultiWithKeys(ctx context.Context, ids []interface{}, entities interface{}) ([]*datastore.Key, error) {
+	if err := checkLengths(ids, entities); err != nil {
+		return nil, err
+	}
+	if err := r.validateEach(entities); err != nil {
+		return nil, err
+	}
+	if err := r.hooks.fireEach(ctx, beforeCreate, r.kind, ids, entities); err != nil {
+		return nil, err
+	}
+	defer r.invalidateIDs(ids...)
+
+	keys, err := r.store().CreateMultiWithKeys(ctx, r.kind, ids, entities)
+	// Nil ids may still name existing entities, from keypart fields
+	r.invalidate(keys...)
+	if err != nil {
+		return nil, err
+	}
+	return keys, r.hooks.fireKeys(ctx, afterCreate, r.kind, keys, entities)
 }
 
 // Update updates an entity
 func (r *BaseRepository) Update(ctx context.Context, id interface{}, entity interface{}) error {
-	return r.executor.Update(ctx, r.kind, id, entity)
+	_, err := r.UpdateWithKey(ctx, id, entity)
+	return err
+}
+
+// UpdateWithKey updates an entity and returns its key
+func (r *BaseRepository) UpdateWithKey(ctx context.Context, id interface{}, entity interface{}) (*datastore.Key, error) {
+	if err := r.validate(entity); err != nil {
+		return nil, err
+	}
+	if err := r.hooks.fire(ctx, beforeUpdate, r.kind, id, entity); err != nil {
+		return nil, err
+	}
+	defer r.invalidateIDs(id)
+
+	key, err := r.store().UpdateWithKey(ctx, r.kind, id, entity)
+	if err != nil {
+		return nil, err
+	}
+	return key, r.hooks.fire(ctx, afterUpdate, r.kind, key, entity)
 }
 
 // UpdateMulti updates multiple entities
 func (r *BaseRepository) UpdateMulti(ctx context.Context, ids []interface{}, entities interface{}) error {
-	return r.executor.UpdateMulti(ctx, r.kind, ids, entities)
+	_, err := r.UpdateMultiWithKeys(ctx, ids, entities)
+	return err
+}
+
+// UpdateMultiWithKeys updates multiple entities and returns their keys in
+// order
+func (r *BaseRepository) UpdateMultiWithKeys(ctx context.Context, ids []interface{}, entities interface{}) ([]*datastore.Key, error) {
+	if err := checkLengths(ids, entities); err != nil {
+		return nil, err
+	}
+	if err := r.validateEach(entities); err != nil {
+		return nil, err
+	}
+	if err := r.hooks.fireEach(ctx, beforeUpdate, r.kind, ids, entities); err != nil {
+		return nil, err
+	}
+	defer r.invalidateIDs(ids...)
+
+	keys, err := r.store().UpdateMultiWithKeys(ctx, r.kind, ids, entities)
+	if err != nil {
+		return nil, err
+	}
+	return keys, r.hooks.fireKeys(ctx, afterUpdate, r.kind, keys, entities)
 }
 
 // Delete deletes an entity
 func (r *BaseRepository) Delete(ctx context.Context, id interface{}) error {
-	return r.executor.Delete(ctx, r.kind, id)
+	if err := r.hooks.fire(ctx, beforeDelete, r.kind, id, nil); err != nil {
+		return err
+	}
+	defer r.invalidateIDs(id)
+
+	if err := r.store().Delete(ctx, r.kind, id); err != nil {
+		return err
+	}
+
+	key, err := r.executor.Key(r.kind, id)
+	if err != nil {
+		return err
+	}
+	return r.hooks.fire(ctx, afterDelete, r.kind, key, nil)
+}
+
+// DeleteByKey deletes the entity stored under key, which must be of the
+// repository's kind
+func (r *BaseRepository) DeleteByKey(ctx context.Context, key *datastore.Key) error {
+	if err := r.checkKind(key); err != nil {
+		return err
+	}
+	if err := r.hooks.fire(ctx, beforeDelete, r.kind, key, nil); err != nil {
+		return err
+	}
+	defer r.invalidate(key)
+
+	if err := r.store().DeleteByKey(ctx, key); err != nil {
+		return err
+	}
+	return r.hooks.fire(ctx, afterDelete, r.kind, key, nil)
 }
 
 // DeleteMulti deletes multiple entities
 func (r *BaseRepository) DeleteMulti(ctx context.Context, ids []interface{}) error {
-	return r.executor.DeleteMulti(ctx, r.kind, ids)
+	for _, id := range ids {
+		if err := r.hooks.fire(ctx, beforeDelete, r.kind, id, nil); err != nil {
+			return err
+		}
+	}
+	defer r.invalidateIDs(ids...)
+
+	if err := r.store().DeleteMulti(ctx, r.kind, ids); err != nil {
+		return err
+	}
+
+	keys, err := r.executor.Keys(r.kind, ids)
+	if err != nil {
+		return err
+	}
+	return r.hooks.fireKeys(ctx, afterDelete, r.kind, keys, nil)
 }
 
 // Exists checks if entity exists
 func (r *BaseRepository) Exists(ctx context.Context, id interface{}) (bool, error) {
-	return r.executor.Exists(ctx, r.kind, id)
+	return r.store().Exists(ctx, r.kind, id)
 }
 
-// Query executes a query with flexible parameters
+// Query executes a query with flexible parameters: *builder.QueryParams, a
+// map of filters and paging keys, or a filter struct. A repository created
+// with WithPrototype (or by For and NewTyped) returns pointers to its entity
+// type with ID fields populated; otherwise results are the maps of QueryRaw.
 func (r *BaseRepository) Query(ctx context.Context, params interface{}) ([]interface{}, *builder.PaginationResult, error) {
-	b := builder.New().Kind(r.kind)
+	if r.entityType == nil {
+		return r.QueryRaw(ctx, params)
+	}
 
-	// Parse params
-	switch p := params.(type) {
-	case *builder.QueryParams:
-		return r.queryWithParams(ctx, b, p)
-	case builder.QueryParams:
-		return r.queryWithParams(ctx, b, &p)
-	case map[string]interface{}:
-		return r.queryWithMap(ctx, b, p)
-	default:
-		return r.queryWithStruct(ctx, b, params)
+	dest := reflect.New(reflect.SliceOf(reflect.PointerTo(r.entityType)))
+	pagination, err := r.QueryTyped(ctx, params, dest.Interface())
+	if err != nil {
+		return nil, nil, err
 	}
+
+	results := make([]interface{}, dest.Elem().Len())
+	for i := range results {
+		results[i] = dest.Elem().Index(i).Interface()
+	}
+	return results, pagination, nil
+}
+
+// QueryRaw executes a query like Query and returns every entity as a
+// map[string]interface{} of its stored property values, with its key under
+// KeyField
+func (r *BaseRepository) QueryRaw(ctx context.Context, params interface{}) ([]interface{}, *builder.PaginationResult, error) {
+	var entities []rawEntity
+	pagination, err := r.QueryTyped(ctx, params, &entities)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := make([]interface{}, len(entities))
+	for i, e := range entities {
+		results[i] = map[string]interface{}(e)
+	}
+	return results, pagination, nil
 }
 
 // QueryTyped executes query and returns typed results
 func (r *BaseRepository) QueryTyped(ctx context.Context, params interface{}, dest interface{}) (*builder.PaginationResult, error) {
-	b := builder.New().Kind(r.kind)
+	if err := r.notInTx("QueryTyped"); err != nil {
+		return nil, err
+	}
+	b, err := r.newBuilder()
+	if err != nil {
+		return nil, err
+	}
+
 	// Parse params
 	switch p := params.(type) {
 	case *builder.QueryParams:
@@ -107,17 +324,115 @@ func (r *BaseRepository) QueryTyped(ctx context.Context, params interface{}, des
 	case builder.QueryParams:
 		r.applyQueryParams(b, &p)
 	case map[string]interface{}:
-		r.applyMapParams(b, p)
+		if err := r.applyMapParams(b, p); err != nil {
+			return nil, err
+		}
 	default:
-		r.applyStructParams(b, params)
+		if err := r.applyStructParams(b, params); err != nil {
+			return nil, err
+		}
 	}
+	r.defaults.applyOrders(b)
 
-	return b.Execute(ctx, r.client, dest)
+	return r.executor.RunBuilder(ctx, b, dest)
 }
 
-// Count counts entities matching filters
+// QueryWithCursor runs one page of a query for cursor-based paging. params
+// may carry the Cursor returned by the previous page, which must be valid
+// (see builder.ValidateCursor), and should set a Limit: when a full page comes
+// back, HasMore is set and NextCursor resumes after it. Results are appended
+// to dest, a pointer to a slice.
+func (r *BaseRepository) QueryWithCursor(ctx context.Context, params *builder.QueryParams, dest interface{}) (*builder.PaginationResult, error) {
+	if err := r.notInTx("QueryWithCursor"); err != nil {
+		return nil, err
+	}
+	if params == nil {
+		params = &builder.QueryParams{}
+	}
+	if err := builder.ValidateCursor(params.Cursor); err != nil {
+		return nil, err
+	}
+
+	b, err := r.newBuilder()
+	if err != nil {
+		return nil, err
+	}
+	r.applyQueryParams(b, params)
+	r.defaults.applyOrders(b)
+
+	v := reflect.ValueOf(dest)
+	before := 0
+	if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Slice {
+		before = v.Elem().Len()
+	}
+
+	next, err := r.executor.RunBuilderPage(ctx, b, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	n := v.Elem().Len() - before
+	result := &builder.PaginationResult{
+		Total:    n,
+		PageSize: params.Limit,
+		HasMore:  params.Limit > 0 && n == params.Limit,
+	}
+	if result.HasMore {
+		result.NextCursor = next
+	}
+	return result, nil
+}
+
+// Count counts entities matching filters. With WithStatsCount, the count
+// of an unfiltered kind comes from its statistics.
 func (r *BaseRepository) Count(ctx context.Context, filters interface{}) (int, error) {
-	b := builder.New().Kind(r.kind)
+	if err := r.notInTx("Count"); err != nil {
+		return 0, err
+	}
+	scope, err := r.scope()
+	if err != nil {
+		return 0, err
+	}
+
+	if r.statsCount && scope == nil && noFilters(filters) {
+		stats, err := r.executor.KindStats(ctx, r.kind)
+		if err == nil {
+			return int(stats.Count), nil
+		}
+		if !errors.Is(err, exec.ErrStatsUnavailable) {
+			return 0, err
+		}
+	}
+
+	b, err := r.newBuilder()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := applyFilters(b, filters); err != nil {
+		return 0, err
+	}
+
+	return r.executor.CountBuilder(ctx, b)
+}
+
+// noFilters reports whether filters, as taken by applyFilters, is empty
+func noFilters(filters interface{}) bool {
+	switch f := filters.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(f) == 0
+	case []builder.FilterParam:
+		return len(f) == 0
+	}
+	return false
+}
+
+// applyFilters adds filters given as a map, a []builder.FilterParam or a
+// struct (see builder.Filter.FromStruct) to b. A struct field with an unknown
+// op fails rather than being left out, which would widen the query.
+func applyFilters(b *builder.Builder, filters interface{}) error {
 	switch f := filters.(type) {
 	case map[string]interface{}:
 		fb := builder.NewFilter().FromMap(f)
@@ -130,93 +445,188 @@ func (r *BaseRepository) Count(ctx context.Context, filters interface{}) (int, e
 		}
 	default:
 		fb := builder.NewFilter().FromStruct(filters)
+		if err := fb.Err(); err != nil {
+			return err
+		}
 		for _, filter := range fb.Build() {
 			b.Filter(filter.Field, filter.Operator, filter.Value)
 		}
 	}
-
-	return b.Count(ctx, r.client)
+	return nil
 }
 
 // FindAll retrieves all entities
 func (r *BaseRepository) FindAll(ctx context.Context, dest interface{}) error {
-	return r.executor.FindAll(ctx, r.kind, dest)
+	if err := r.notInTx("FindAll"); err != nil {
+		return err
+	}
+	opts, err := r.queryOptions()
+	if err != nil {
+		return err
+	}
+	return r.executor.FindAll(ctx, r.kind, dest, opts...)
 }
 
 // FindWhere retrieves entities matching filters
 func (r *BaseRepository) FindWhere(ctx context.Context, filters map[string]interface{}, dest interface{}) error {
-	return r.executor.FindWhere(ctx, r.kind, filters, dest)
+	if err := r.notInTx("FindWhere"); err != nil {
+		return err
+	}
+	opts, err := r.queryOptions()
+	if err != nil {
+		return err
+	}
+	return r.executor.FindWhere(ctx, r.kind, filters, dest, opts...)
 }
 
 // FindOne retrieves first matching entity
 func (r *BaseRepository) FindOne(ctx context.Context, filters map[string]interface{}, dest interface{}) error {
-	return r.executor.FindOne(ctx, r.kind, filters, dest)
+	if err := r.notInTx("FindOne"); err != nil {
+		return err
+	}
+	opts, err := r.queryOptions()
+	if err != nil {
+		return err
+	}
+	return r.executor.FindOne(ctx, r.kind, filters, dest, opts...)
 }
 
 // Paginate retrieves paginated results
 func (r *BaseRepository) Paginate(ctx context.Context, filters map[string]interface{}, page, pageSize int, dest interface{}) (*builder.PaginationResult, error) {
-	return r.executor.Paginate(ctx, r.kind, filters, page, pageSize, dest)
+	if err := r.notInTx("Paginate"); err != nil {
+		return nil, err
+	}
+	opts, err := r.queryOptions()
+	if err != nil {
+		return nil, err
+	}
+	return r.executor.Paginate(ctx, r.kind, filters, page, pageSize, dest, opts...)
 }
 
 // BulkCreate creates entities in batches
 func (r *BaseRepository) BulkCreate(ctx context.Context, entities interface{}, batchSize int) error {
-	return r.executor.BulkCreate(ctx, r.kind, entities, batchSize)
-}
-
-// BulkDelete deletes entities matching query
-func (r *BaseRepository) BulkDelete(ctx context.Context, filters map[string]interface{}) (int, error) {
-	return r.executor.BulkDelete(ctx, r.kind, filters)
+	return r.BulkCreateWithOptions(ctx, entities, batchSize, exec.BulkOptions{})
 }
 
-// Private helper methods
-func (r *BaseRepository) queryWithParams(ctx context.Context, b *builder.Builder, params *builder.QueryParams) ([]interface{}, *builder.PaginationResult, error) {
-	r.applyQueryParams(b, params)
-	var results []map[string]interface{}
-	pagination, err := b.Execute(ctx, r.client, &results)
-	if err != nil {
-		return nil, nil, err
+// BulkCreateWithOptions creates entities in batches with progress reporting
+// and resume support. Entities before opts.StartAt, written by an earlier
+// run, are neither validated nor passed to hooks.
+func (r *BaseRepository) BulkCreateWithOptions(ctx context.Context, entities interface{}, batchSize int, opts exec.BulkOptions) error {
+	if err := r.notInTx("BulkCreateWithOptions"); err != nil {
+		return err
+	}
+	v := reflect.ValueOf(entities)
+	if v.Kind() != reflect.Slice {
+		return errors.New("entities must be a slice")
+	}
+	if opts.StartAt < 0 || opts.StartAt > v.Len() {
+		return fmt.Errorf("start offset %d out of range [0, %d]", opts.StartAt, v.Len())
+	}
+	if err := r.validateFrom(entities, opts.StartAt); err != nil {
+		return err
+	}
+	pending := v.Slice(opts.StartAt, v.Len()).Interface()
+	if err := r.hooks.fireEach(ctx, beforeCreate, r.kind, nil, pending); err != nil {
+		return err
 	}
 
-	// Convert to []interface{}
-	interfaceResults := make([]interface{}, len(results))
-	for i, v := range results {
-		interfaceResults[i] = v
+	var hookErr error
+	if r.hooks.has(afterCreate) || r.cache != nil {
+		onBatch := opts.OnBatch
+		opts.OnBatch = func(done, total int, keys []*datastore.Key, err error) {
+			r.invalidate(keys...)
+			if err == nil && hookErr == nil {
+				batch := v.Slice(done-len(keys), done).Interface()
+				hookErr = r.hooks.fireKeys(ctx, afterCreate, r.kind, keys, batch)
+			}
+			if onBatch != nil {
+				onBatch(done, total, keys, err)
+			}
+		}
 	}
 
-	return interfaceResults, pagination, nil
+	if err := r.executor.BulkCreateWithOptions(ctx, r.kind, entities, batchSize, opts); err != nil {
+		return err
+	}
+	return hookErr
 }
-func (r *BaseRepository) queryWithMap(ctx context.Context, b *builder.Builder, params map[string]interface{}) ([]interface{}, *builder.PaginationResult, error) {
-	r.applyMapParams(b, params)
-	var results []map[string]interface{}
-	pagination, err := b.Execute(ctx, r.client, &results)
+
+// BulkDelete deletes entities matching query
+func (r *BaseRepository) BulkDelete(ctx context.Context, filters map[string]interface{}) (int, error) {
+	if err := r.notInTx("BulkDelete"); err != nil {
+		return 0, err
+	}
+	scope, err := r.scope()
 	if err != nil {
-		return nil, nil, err
+		return 0, err
+	}
+	opts := exec.BulkDeleteOptions{Scope: scope}
+	if !r.hooks.has(beforeDelete) && !r.hooks.has(afterDelete) && r.cache == nil {
+		return r.executor.BulkDeleteWithOptions(ctx, r.kind, filters, opts)
 	}
 
-	// Convert to []interface{}
-	interfaceResults := make([]interface{}, len(results))
-	for i, v := range results {
-		interfaceResults[i] = v
+	var hookErr error
+	opts.BeforeBatch = func(keys []*datastore.Key) error {
+		return r.hooks.fireKeys(ctx, beforeDelete, r.kind, keys, nil)
 	}
+	opts.OnBatch = func(done, total int, keys []*datastore.Key, err error) {
+		r.invalidate(keys...)
+		if err == nil && hookErr == nil {
+			hookErr = r.hooks.fireKeys(ctx, afterDelete, r.kind, keys, nil)
+		}
+	}
+	n, err := r.executor.BulkDeleteWithOptions(ctx, r.kind, filters, opts)
+	if err != nil {
+		return n, err
+	}
+	return n, hookErr
+}
 
-	return interfaceResults, pagination, nil
+// Patch sets the given properties on an existing entity
+func (r *BaseRepository) Patch(ctx context.Context, id interface{}, changes map[string]interface{}) error {
+	defer r.invalidateIDs(id)
+	return r.store().Patch(ctx, r.kind, id, changes)
 }
-func (r *BaseRepository) queryWithStruct(ctx context.Context, b *builder.Builder, params interface{}) ([]interface{}, *builder.PaginationResult, error) {
-	r.applyStructParams(b, params)
-	var results []map[string]interface{}
-	pagination, err := b.Execute(ctx, r.client, &results)
+
+// UpdateWhere sets the given properties on every entity matching filters and
+// returns the number updated, like exec.Exec.UpdateWhere. Scopes apply to the
+// selection; hooks are not called, as for Patch.
+func (r *BaseRepository) UpdateWhere(ctx context.Context, filters map[string]interface{}, changes map[string]interface{}) (int, error) {
+	if err := r.notInTx("UpdateWhere"); err != nil {
+		return 0, err
+	}
+	scope, err := r.scope()
 	if err != nil {
-		return nil, nil, err
+		return 0, err
+	}
+
+	opts := exec.UpdateWhereOptions{Scope: scope}
+	if r.cache != nil {
+		opts.OnBatch = func(done, total int, keys []*datastore.Key, err error) {
+			r.invalidate(keys...)
+		}
 	}
+	return r.executor.UpdateWhereWithOptions(ctx, r.kind, filters, changes, opts)
+}
 
-	// Convert to []interface{}
-	interfaceResults := make([]interface{}, len(results))
-	for i, v := range results {
-		interfaceResults[i] = v
+// Touch sets a timestamp property of an entity to the current time
+func (r *BaseRepository) Touch(ctx context.Context, id interface{}, field string) error {
+	if err := r.notInTx("Touch"); err != nil {
+		return err
 	}
+	defer r.invalidateIDs(id)
+	return r.executor.Touch(ctx, r.kind, id, field)
+}
 
-	return interfaceResults, pagination, nil
+// TouchMulti sets a timestamp property of multiple entities to the current time
+func (r *BaseRepository) TouchMulti(ctx context.Context, ids []interface{}, field string) (int, error) {
+	if err := r.notInTx("TouchMulti"); err != nil {
+		return 0, err
+	}
+	defer r.invalidateIDs(ids...)
+	return r.executor.TouchMulti(ctx, r.kind, ids, field)
 }
+
 func (r *BaseRepository) applyQueryParams(b *builder.Builder, params *builder.QueryParams) {
 	for _, filter := range params.Filters {
 		b.Filter(filter.Field, filter.Operator, filter.Value)
@@ -254,36 +664,239 @@ func (r *BaseRepository) applyQueryParams(b *builder.Builder, params *builder.Qu
 		b.Ancestor(params.Ancestor.Kind, params.Ancestor.ID)
 	}
 }
-func (r *BaseRepository) applyMapParams(b *builder.Builder, params map[string]interface{}) {
+
+// applyMapParams applies a map of filters and paging keys. "limit" and
+// "offset" take any integer type, an integral float64, a json.Number or a
+// numeric string, as decoded JSON carries. "order_by" takes a field, "-field"
+// or "field desc" for descending order, or several of those as a
+// comma-separated string or a slice.
+func (r *BaseRepository) applyMapParams(b *builder.Builder, params map[string]interface{}) error {
 	for key, value := range params {
 		switch key {
 		case "limit":
-			if v, ok := value.(int); ok {
-				b.Limit(v)
+			v, err := paramInt(key, value)
+			if err != nil {
+				return err
 			}
+			b.Limit(v)
 		case "offset":
-			if v, ok := value.(int); ok {
-				b.Offset(v)
+			v, err := paramInt(key, value)
+			if err != nil {
+				return err
 			}
+			b.Offset(v)
 		case "cursor":
 			if v, ok := value.(string); ok {
 				b.Cursor(v)
 			}
 		case "order_by":
-			if v, ok := value.(string); ok {
-				b.OrderAsc(v)
+			orders, err := paramOrders(value)
+			if err != nil {
+				return err
+			}
+			for _, o := range orders {
+				b.Order(o.Field, o.Direction)
 			}
 		default:
 			// Treat as filter
 			b.Where(key, value)
 		}
 	}
+	return nil
 }
-func (r *BaseRepository) applyStructParams(b *builder.Builder, params interface{}) {
-	fb := builder.NewFilter().FromStruct(params)
-	for _, filter := range fb.Build() {
-		b.Filter(filter.Field, filter.Operator, filter.Value)
+
+// paramInt converts a limit or offset param to an int
+func paramInt(key string, value interface{}) (int, error) {
+	var n int64
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int32:
+		n = int64(v)
+	case int64:
+		n = v
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("%s must be an integer, got %v", key, v)
+		}
+		n = int64(v)
+	case json.Number:
+		i, err := v.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("%s must be an integer, got %q", key, v.String())
+		}
+		n = i
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 0)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be an integer, got %q", key, v)
+		}
+		n = i
+	default:
+		return 0, fmt.Errorf("%s must be an integer, got %T", key, value)
+	}
+	if n < math.MinInt32 || n > math.MaxInt32 {
+		return 0, fmt.Errorf("%s %d is out of range", key, n)
+	}
+	return int(n), nil
+}
+
+// paramOrders parses an order_by param
+func paramOrders(value interface{}) ([]builder.OrderParam, error) {
+	var specs []string
+	switch v := value.(type) {
+	case string:
+		specs = strings.Split(v, ",")
+	case []string:
+		specs = v
+	case []interface{}:
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("order_by entries must be strings, got %T", e)
+			}
+			specs = append(specs, s)
+		}
+	default:
+		return nil, fmt.Errorf("order_by must be a string or a list of strings, got %T", value)
+	}
+
+	orders := make([]builder.OrderParam, 0, len(specs))
+	for _, spec := range specs {
+		fields := strings.Fields(spec)
+		var order builder.OrderParam
+		switch {
+		case len(fields) == 1 && strings.HasPrefix(fields[0], "-"):
+			order = builder.OrderParam{Field: fields[0][1:], Direction: builder.Descending}
+		case len(fields) == 1:
+			order = builder.OrderParam{Field: fields[0], Direction: builder.Ascending}
+		case len(fields) == 2 && strings.EqualFold(fields[1], "desc"):
+			order = builder.OrderParam{Field: fields[0], Direction: builder.Descending}
+		case len(fields) == 2 && strings.EqualFold(fields[1], "asc"):
+			order = builder.OrderParam{Field: fields[0], Direction: builder.Ascending}
+		default:
+			return nil, fmt.Errorf("invalid order %q", spec)
+		}
+		if order.Field == "" {
+			return nil, fmt.Errorf("invalid order %q", spec)
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// applyStructParams applies a filter struct (see builder.FilterBuilder's
+// FromStruct). Fields tagged gostore:"limit", "offset", "cursor" or "order"
+// set paging and ordering, taking the same values as the map params, and a
+// field of type builder.QueryParams is applied as a whole. A non-zero tagged
+// field takes precedence over the same setting of that QueryParams; a tagged
+// order replaces its orders.
+func (r *BaseRepository) applyStructParams(b *builder.Builder, params interface{}) error {
+	v := reflect.ValueOf(params)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	// qp holds an embedded QueryParams, tagged holds the tagged fields
+	var qp, tagged builder.QueryParams
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		switch {
+		case field.Type == reflect.TypeOf(qp):
+			qp = value.Interface().(builder.QueryParams)
+			continue
+		case field.Type == reflect.TypeOf(&qp):
+			if !value.IsNil() {
+				qp = *value.Interface().(*builder.QueryParams)
+			}
+			continue
+		}
+
+		tag := field.Tag.Get("gostore")
+		if tag == "" || value.IsZero() {
+			continue
+		}
+		var err error
+		switch tag {
+		case "limit":
+			tagged.Limit, err = paramInt(tag, value.Interface())
+		case "offset":
+			tagged.Offset, err = paramInt(tag, value.Interface())
+		case "cursor":
+			tagged.Cursor = value.String()
+		case "order":
+			tagged.Orders, err = paramOrders(value.Interface())
+		default:
+			err = fmt.Errorf("field %s: unknown gostore tag %q", field.Name, tag)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if tagged.Limit != 0 {
+		qp.Limit = tagged.Limit
+	}
+	if tagged.Offset != 0 {
+		qp.Offset = tagged.Offset
+	}
+	if tagged.Cursor != "" {
+		qp.Cursor = tagged.Cursor
+	}
+	if len(tagged.Orders) > 0 {
+		qp.Orders = tagged.Orders
+	}
+	fb := builder.NewFilter().WithNaming(r.naming).FromStruct(v.Interface())
+	if err := fb.Err(); err != nil {
+		return err
+	}
+	qp.Filters = append(fb.Build(), qp.Filters...)
+	r.applyQueryParams(b, &qp)
+	return nil
+}
+
+// KeyField is the map entry holding the *datastore.Key of an entity returned
+// by QueryRaw
+const KeyField = "__key__"
+
+// rawEntity loads an entity into a map, including its key
+type rawEntity map[string]interface{}
+
+func (e *rawEntity) Load(props []datastore.Property) error {
+	if *e == nil {
+		*e = make(rawEntity, len(props)+1)
+	}
+	for _, p := range props {
+		(*e)[p.Name] = p.Value
+	}
+	return nil
+}
+
+func (e *rawEntity) LoadKey(k *datastore.Key) error {
+	if *e == nil {
+		*e = make(rawEntity)
+	}
+	(*e)[KeyField] = k
+	return nil
+}
+
+func (e *rawEntity) Save() ([]datastore.Property, error) {
+	props := make([]datastore.Property, 0, len(*e))
+	for name, value := range *e {
+		if name != KeyField {
+			props = append(props, datastore.Property{Name: name, Value: value})
+		}
 	}
+	return props, nil
 }
 
 // GetKind returns the kind name
@@ -291,7 +904,35 @@ func (r *BaseRepository) GetKind() string {
 	return r.kind
 }
 
-// GetClient returns the datastore client
+// GetClient returns the datastore client, or nil if the repository was
+// created over another gostore.Client
 func (r *BaseRepository) GetClient() *datastore.Client {
+	client, _ := gostore.Unwrap(r.client)
+	return client
+}
+
+// Client returns the client the repository uses
+func (r *BaseRepository) Client() gostore.Client {
 	return r.client
 }
+
+// checkLengths verifies entities is a slice with one element per ID, before
+// any hook sees them
+func checkLengths(ids []interface{}, entities interface{}) error {
+	v := reflect.ValueOf(entities)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("entities must be a slice, got %T", entities)
+	}
+	if v.Len() != len(ids) {
+		return fmt.Errorf("ids and entities must have the same length, got %d ids and %d entities", len(ids), v.Len())
+	}
+	return nil
+}
+
+// checkKind verifies key belongs to the repository's kind
+func (r *BaseRepository) checkKind(key *datastore.Key) error {
+	if key != nil && key.Kind != r.kind {
+		return fmt.Errorf("key kind %q does not match repository kind %q", key.Kind, r.kind)
+	}
+	return nil
+}
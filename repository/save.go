@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+// Save writes entity, a pointer to a struct with an ID field (see
+// key.SetID), deciding between insert and update from that field. A zero ID
+// inserts the entity under a new ID, written back into the field: a name from
+// the exec ID generator for string fields, an allocated numeric ID otherwise.
+// A non-zero ID upserts the entity under it, as does an entity with fields
+// tagged gostore:"keypart=N", under the name they make (see
+// key.CompositeID). Create or update hooks fire accordingly.
+func (r *BaseRepository) Save(ctx context.Context, entity interface{}) (*datastore.Key, error) {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("entity must be a non-nil pointer to a struct, got %T", entity)
+	}
+
+	id, insert, err := saveID(entity)
+	if err != nil {
+		return nil, err
+	}
+	if !insert {
+		return r.UpdateWithKey(ctx, id, entity)
+	}
+
+	key, err := r.CreateWithKey(ctx, id, entity)
+	if err != nil {
+		return nil, err
+	}
+	contextKey.SetID(entity, key)
+	return key, nil
+}
+
+// SaveMulti saves every element of entities, a slice of structs or struct
+// pointers, like Save, and returns their keys in order. Inserts and upserts
+// are written in one batch each.
+func (r *BaseRepository) SaveMulti(ctx context.Context, entities interface{}) ([]*datastore.Key, error) {
+	v := reflect.ValueOf(entities)
+	if v.Kind() != reflect.Slice {
+		return nil, errors.New("entities must be a slice")
+	}
+	// Validate up front so errors carry indexes into entities, not into the
+	// insert or update subset
+	if err := r.validateEach(entities); err != nil {
+		return nil, err
+	}
+
+	ids := make([]interface{}, v.Len())
+	var inserts, updates []int
+	for i := range ids {
+		id, insert, err := saveID(v.Index(i).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("entity at index %d: %w", i, err)
+		}
+		ids[i] = id
+		if insert {
+			inserts = append(inserts, i)
+		} else {
+			updates = append(updates, i)
+		}
+	}
+
+	keys := make([]*datastore.Key, len(ids))
+
+	// Writes a subset of entities, copying the elements back afterwards so
+	// stamped timestamps reach the caller's slice
+	write := func(indexes []int, fn func(context.Context, []interface{}, interface{}) ([]*datastore.Key, error)) error {
+		if len(indexes) == 0 {
+			return nil
+		}
+
+		sub := reflect.MakeSlice(v.Type(), len(indexes), len(indexes))
+		subIDs := make([]interface{}, len(indexes))
+		for j, i := range indexes {
+			sub.Index(j).Set(v.Index(i))
+			subIDs[j] = ids[i]
+		}
+
+		written, err := fn(ctx, subIDs, sub.Interface())
+		if err != nil {
+			return err
+		}
+		for j, i := range indexes {
+			v.Index(i).Set(sub.Index(j))
+			keys[i] = written[j]
+		}
+		return nil
+	}
+
+	if err := write(inserts, r.CreateMultiWithKeys); err != nil {
+		return nil, err
+	}
+	if err := write(updates, r.UpdateMultiWithKeys); err != nil {
+		return nil, err
+	}
+
+	contextKey.SetIDs(entities, keys)
+	return keys, nil
+}
+
+// saveID returns the ID Save writes entity under and whether it is an insert
+func saveID(entity interface{}) (id interface{}, insert bool, err error) {
+	if name, ok, err := contextKey.CompositeID(entity); ok || err != nil {
+		return name, false, err
+	}
+
+	field, ok := contextKey.ID(entity)
+	if !ok {
+		if t := reflect.TypeOf(entity); t == nil || indirect(t).Kind() != reflect.Struct {
+			return nil, false, fmt.Errorf("entity must be a struct, got %T", entity)
+		}
+		return nil, false, fmt.Errorf("%T has no ID field", entity)
+	}
+
+	switch f := field.(type) {
+	case string:
+		if f == "" {
+			return exec.AutoUUID, true, nil
+		}
+	case *datastore.Key:
+		switch {
+		case f == nil:
+			return nil, true, nil
+		case f.Parent != nil:
+			return nil, false, fmt.Errorf("cannot save %T under key %v with a parent", entity, f)
+		case f.Name != "":
+			return f.Name, false, nil
+		default:
+			return f.ID, false, nil
+		}
+	default:
+		if reflect.ValueOf(field).IsZero() {
+			return nil, true, nil
+		}
+	}
+	return field, false, nil
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,128 @@
+package repository_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type savedItem struct {
+	ID   int64 `datastore:"-"`
+	Name string
+}
+
+func TestSave(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	users := repository.NewBaseRepositoryWithClient(mock, "users")
+	items := repository.NewBaseRepositoryWithClient(mock, "items")
+
+	t.Run("insert generates a name for string IDs", func(t *testing.T) {
+		user := testutil.TestUser{Email: "new@example.com"}
+		key, err := users.Save(ctx, &user)
+		if err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if key.Name == "" || user.ID != key.Name {
+			t.Errorf("expected generated name written back, got ID %q and key %v", user.ID, key)
+		}
+	})
+
+	t.Run("insert allocates numeric IDs", func(t *testing.T) {
+		item := savedItem{Name: "widget"}
+		key, err := items.Save(ctx, &item)
+		if err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if key.ID == 0 || item.ID != key.ID {
+			t.Errorf("expected allocated ID written back, got ID %d and key %v", item.ID, key)
+		}
+	})
+
+	t.Run("upsert with an ID", func(t *testing.T) {
+		user := testutil.TestUser{ID: "fixed", Name: "First"}
+		if _, err := users.Save(ctx, &user); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		user.Name = "Second"
+		key, err := users.Save(ctx, &user)
+		if err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if key.Name != "fixed" {
+			t.Errorf("expected key fixed, got %v", key)
+		}
+
+		var got testutil.TestUser
+		if err := users.GetByID(ctx, "fixed", &got); err != nil || got.Name != "Second" {
+			t.Errorf("expected updated entity, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("SaveMulti mixes inserts and upserts", func(t *testing.T) {
+		batch := []savedItem{{Name: "a"}, {ID: 500, Name: "b"}, {Name: "c"}}
+		keys, err := items.SaveMulti(ctx, batch)
+		if err != nil {
+			t.Fatalf("SaveMulti: %v", err)
+		}
+		if keys[1].ID != 500 {
+			t.Errorf("expected upsert under 500, got %v", keys[1])
+		}
+		for i, item := range batch {
+			if item.ID == 0 || item.ID != keys[i].ID {
+				t.Errorf("item %d: expected ID %d written back, got %d", i, keys[i].ID, item.ID)
+			}
+		}
+		if batch[0].ID == batch[2].ID {
+			t.Error("inserts got the same ID")
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		name := "not a struct"
+		if _, err := users.Save(ctx, &name); err == nil || !strings.Contains(err.Error(), "pointer to a struct") {
+			t.Errorf("expected non-struct error, got %v", err)
+		}
+
+		type noID struct{ Name string }
+		if _, err := users.Save(ctx, &noID{}); err == nil || !strings.Contains(err.Error(), "no ID field") {
+			t.Errorf("expected missing ID field error, got %v", err)
+		}
+		if _, err := users.SaveMulti(ctx, []noID{{}}); err == nil || !strings.Contains(err.Error(), "index 0") {
+			t.Errorf("expected SaveMulti to report the index, got %v", err)
+		}
+	})
+}
+
+type tenantUser struct {
+	TenantID string `datastore:"tenant_id" gostore:"keypart=1"`
+	Email    string `datastore:"email" gostore:"keypart=2"`
+	Name     string `datastore:"name"`
+}
+
+func TestSaveCompositeKey(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	users := repository.NewBaseRepositoryWithClient(mock, "users")
+
+	user := &tenantUser{TenantID: "acme", Email: "bob@example.com", Name: "Bob"}
+	key, err := users.Save(ctx, user)
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if key.Name != "acme|bob@example.com" {
+		t.Errorf("expected the key named after the key parts, got %v", key)
+	}
+
+	user.Name = "Robert"
+	if _, err := users.Save(ctx, user); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	var got tenantUser
+	if err := users.GetByID(ctx, key.Name, &got); err != nil || got.Name != "Robert" || mock.Count("users") != 1 {
+		t.Errorf("expected the entity updated in place, got %+v, %v", got, err)
+	}
+}
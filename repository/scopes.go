@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/exec"
+)
+
+// scopes holds the named scopes registered on a repository
+type scopes struct {
+	mu  sync.RWMutex
+	fns map[string]func(b *builder.Builder)
+}
+
+func (s *scopes) add(name string, fn func(b *builder.Builder)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fns == nil {
+		s.fns = make(map[string]func(b *builder.Builder))
+	}
+	s.fns[name] = fn
+}
+
+func (s *scopes) get(name string) (func(b *builder.Builder), bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn, ok := s.fns[name]
+	return fn, ok
+}
+
+// DefaultScopes makes the repository apply the named scopes to every query
+// unless Unscoped is used. The scopes can be registered with AddScope after
+// the repository is created.
+func DefaultScopes(names ...string) Option {
+	return func(o *options) {
+		o.scopes = append(o.scopes, names...)
+	}
+}
+
+// AddScope registers fn as the scope name, replacing any scope of that name.
+// A scope adds filters (or orders, ancestors...) to a query before the
+// caller's filters; it is shared by the repository and all its views.
+func (r *BaseRepository) AddScope(name string, fn func(b *builder.Builder)) {
+	r.scopes.add(name, fn)
+}
+
+// Scoped returns a view of the repository that also applies the named scopes
+// to Query, QueryTyped, Count, FindAll, FindWhere, FindOne, Paginate and
+// BulkDelete. Those methods fail if a name has no registered scope.
+func (r *BaseRepository) Scoped(names ...string) *BaseRepository {
+	view := *r
+	view.active = append(append([]string(nil), r.active...), names...)
+	return &view
+}
+
+// Unscoped returns a view of the repository without any named scopes,
+// including default ones. Chain Scoped to apply specific scopes only.
+func (r *BaseRepository) Unscoped() *BaseRepository {
+	view := *r
+	view.active = nil
+	return &view
+}
+
+// scope returns a function applying the active scopes in order, or nil if
+// there are none
+func (r *BaseRepository) scope() (func(b *builder.Builder), error) {
+	if len(r.active) == 0 && r.implicit == nil && len(r.defaults.filters) == 0 {
+		return nil, nil
+	}
+
+	fns := make([]func(b *builder.Builder), 0, len(r.active)+2)
+	if len(r.defaults.filters) > 0 {
+		fns = append(fns, r.defaults.applyFilters)
+	}
+	if r.implicit != nil {
+		fns = append(fns, r.implicit)
+	}
+	for _, name := range r.active {
+		fn, ok := r.scopes.get(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown scope %q for kind %s", name, r.kind)
+		}
+		fns = append(fns, fn)
+	}
+
+	return func(b *builder.Builder) {
+		for _, fn := range fns {
+			fn(b)
+		}
+	}, nil
+}
+
+// queryOptions returns the exec options applying the active scopes and the
+// default orders
+func (r *BaseRepository) queryOptions() ([]exec.QueryOption, error) {
+	scope, err := r.scope()
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []exec.QueryOption
+	if scope != nil {
+		opts = append(opts, exec.Scope(scope))
+	}
+	if len(r.defaults.orders) > 0 {
+		opts = append(opts, exec.Scope(r.defaults.applyOrders))
+	}
+	return opts, nil
+}
+
+// newBuilder returns a query on the repository's kind and namespace with the
+// active scopes applied
+func (r *BaseRepository) newBuilder() (*builder.Builder, error) {
+	scope, err := r.scope()
+	if err != nil {
+		return nil, err
+	}
+
+	b := builder.New().Kind(r.kind).Namespace(r.executor.Namespace())
+	if scope != nil {
+		scope(b)
+	}
+	return b, nil
+}
@@ -0,0 +1,129 @@
+package repository_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestScopes(t *testing.T) {
+	ctx, client := emulatorClient(t)
+	repo := repository.NewBaseRepositoryWithOptions(client, "users", repository.DefaultScopes("active"))
+	repo.AddScope("active", func(b *builder.Builder) {
+		b.Where("status", "active")
+	})
+	repo.AddScope("over30", func(b *builder.Builder) {
+		b.Filter("age", builder.GreaterThan, 30)
+	})
+
+	for _, u := range testutil.CreateTestUsers() {
+		if err := repo.Create(ctx, u.ID, &u); err != nil {
+			t.Fatalf("Create %s: %v", u.ID, err)
+		}
+	}
+
+	t.Run("default scope filters every query", func(t *testing.T) {
+		var all []testutil.TestUser
+		if err := repo.FindAll(ctx, &all); err != nil {
+			t.Fatalf("FindAll: %v", err)
+		}
+		if len(all) != 3 {
+			t.Errorf("FindAll: expected 3 active users, got %d", len(all))
+		}
+
+		var one testutil.TestUser
+		if err := repo.FindOne(ctx, map[string]interface{}{"name": "Bob Wilson"}, &one); err == nil {
+			t.Error("FindOne: expected inactive user to be filtered out")
+		}
+
+		n, err := repo.Count(ctx, map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("Count: %v", err)
+		}
+		if n != 3 {
+			t.Errorf("Count: expected 3, got %d", n)
+		}
+
+		var page []testutil.TestUser
+		result, err := repo.Paginate(ctx, map[string]interface{}{}, 1, 10, &page)
+		if err != nil {
+			t.Fatalf("Paginate: %v", err)
+		}
+		if result.Total != 3 || len(page) != 3 {
+			t.Errorf("Paginate: expected 3 of 3, got %d of %d", len(page), result.Total)
+		}
+
+		rows, _, err := repo.Query(ctx, map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		if len(rows) != 3 {
+			t.Errorf("Query: expected 3, got %d", len(rows))
+		}
+	})
+
+	t.Run("Unscoped bypasses default scopes", func(t *testing.T) {
+		n, err := repo.Unscoped().Count(ctx, map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("Count: %v", err)
+		}
+		if n != 4 {
+			t.Errorf("expected 4, got %d", n)
+		}
+	})
+
+	t.Run("scopes compose with caller filters", func(t *testing.T) {
+		var users []testutil.TestUser
+		if err := repo.FindWhere(ctx, map[string]interface{}{"age": 25}, &users); err != nil {
+			t.Fatalf("FindWhere: %v", err)
+		}
+		if len(users) != 1 || users[0].ID != "user2" {
+			t.Errorf("expected user2, got %+v", users)
+		}
+
+		// A caller filter on the scoped field narrows further instead of
+		// replacing the scope
+		users = nil
+		if err := repo.FindWhere(ctx, map[string]interface{}{"status": "inactive"}, &users); err != nil {
+			t.Fatalf("FindWhere: %v", err)
+		}
+		if len(users) != 0 {
+			t.Errorf("expected no users, got %+v", users)
+		}
+
+		n, err := repo.Unscoped().Scoped("over30").Count(ctx, map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("Count: %v", err)
+		}
+		if n != 1 {
+			t.Errorf("expected 1 user over 30, got %d", n)
+		}
+	})
+
+	t.Run("BulkDelete honours scopes", func(t *testing.T) {
+		deleted, err := repo.BulkDelete(ctx, map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("BulkDelete: %v", err)
+		}
+		if deleted != 3 {
+			t.Errorf("expected 3 deleted, got %d", deleted)
+		}
+		if exists, _ := repo.Exists(ctx, "user3"); !exists {
+			t.Error("inactive user deleted despite scope")
+		}
+	})
+}
+
+func TestScopedUnknown(t *testing.T) {
+	repo := repository.NewBaseRepositoryWithClient(testutil.NewMockClient(), "users")
+
+	var users []testutil.TestUser
+	err := repo.Scoped("missing").FindAll(context.Background(), &users)
+	if err == nil || !strings.Contains(err.Error(), `unknown scope "missing"`) {
+		t.Errorf("expected unknown scope error, got %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/exec"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+type trashedMode int
+
+const (
+	withoutTrashed trashedMode = iota
+	withTrashed
+	onlyTrashed
+)
+
+// SoftDeleteRepository wraps a BaseRepository so that Delete marks entities as
+// deleted instead of removing them. Reads, queries, counts and pagination
+// skip soft-deleted entities unless WithTrashed or OnlyTrashed is used.
+//
+// Like exec.WithSoftDelete, queries match "field = nil", so entities must
+// always carry the field with a null value (e.g. a nil *time.Time) to be
+// found by them.
+type SoftDeleteRepository struct {
+	base    *BaseRepository
+	field   string
+	trashed trashedMode
+}
+
+var _ Repository = (*SoftDeleteRepository)(nil)
+
+// NewSoftDeleteRepository wraps base, marking deleted entities by setting
+// field to the deletion time. An empty field uses exec.DefaultDeletedAtField.
+func NewSoftDeleteRepository(base *BaseRepository, field string) *SoftDeleteRepository {
+	if field == "" {
+		field = exec.DefaultDeletedAtField
+	}
+	return newSoftDeleteRepository(base, field, withoutTrashed)
+}
+
+func newSoftDeleteRepository(base *BaseRepository, field string, mode trashedMode) *SoftDeleteRepository {
+	view := *base
+	switch mode {
+	case withoutTrashed:
+		view.implicit = func(b *builder.Builder) {
+			b.Filter(field, builder.Equal, nil)
+		}
+	case onlyTrashed:
+		// null sorts before every other value
+		view.implicit = func(b *builder.Builder) {
+			b.Filter(field, builder.GreaterThan, nil)
+		}
+	default:
+		view.implicit = nil
+	}
+	return &SoftDeleteRepository{base: &view, field: field, trashed: mode}
+}
+
+// WithTrashed returns a view of the repository that includes soft-deleted
+// entities
+func (r *SoftDeleteRepository) WithTrashed() *SoftDeleteRepository {
+	return newSoftDeleteRepository(r.base, r.field, withTrashed)
+}
+
+// OnlyTrashed returns a view of the repository that only sees soft-deleted
+// entities. Its queries use an inequality filter on the deleted-at field, so
+// they cannot combine it with inequality filters or orders on other fields.
+func (r *SoftDeleteRepository) OnlyTrashed() *SoftDeleteRepository {
+	return newSoftDeleteRepository(r.base, r.field, onlyTrashed)
+}
+
+// Base returns the wrapped repository, which sees every entity
+func (r *SoftDeleteRepository) Base() *BaseRepository {
+	view := *r.base
+	view.implicit = nil
+	return &view
+}
+
+// GetKind returns the kind name
+func (r *SoftDeleteRepository) GetKind() string {
+	return r.base.kind
+}
+
+// GetByID retrieves an entity by ID. A soft-deleted entity is reported as
+// exec.ErrNotFound.
+func (r *SoftDeleteRepository) GetByID(ctx context.Context, id interface{}, dest interface{}) error {
+	var props datastore.PropertyList
+	if err := r.base.GetByID(ctx, id, &props); err != nil {
+		return err
+	}
+	if !r.visible(props) {
+		return fmt.Errorf("%w: %s %v is deleted", exec.ErrNotFound, r.base.kind, id)
+	}
+
+	if err := loadProps(dest, props); err != nil {
+		return err
+	}
+	if key, err := r.base.executor.Key(r.base.kind, id); err == nil {
+		contextKey.SetID(dest, key)
+	}
+	return nil
+}
+
+// GetMulti retrieves multiple entities into dest, a slice as long as ids.
+// Soft-deleted entities are reported as exec.ErrNotFound in a
+// datastore.MultiError, like missing ones.
+func (r *SoftDeleteRepository) GetMulti(ctx context.Context, ids []interface{}, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Slice || v.Len() != len(ids) {
+		return fmt.Errorf("dest must be a slice of length %d", len(ids))
+	}
+
+	props := make([]datastore.PropertyList, len(ids))
+	err := r.base.GetMulti(ctx, ids, props)
+
+	var multiErr datastore.MultiError
+	if err != nil && !errors.As(err, &multiErr) {
+		return err
+	}
+
+	errs := make(datastore.MultiError, len(ids))
+	failed := false
+	for i := range ids {
+		switch {
+		case multiErr != nil && multiErr[i] != nil:
+			errs[i] = multiErr[i]
+		case !r.visible(props[i]):
+			errs[i] = fmt.Errorf("%w: %s %v is deleted", exec.ErrNotFound, r.base.kind, ids[i])
+		default:
+			errs[i] = r.loadElem(v.Index(i), props[i], ids[i])
+		}
+		failed = failed || errs[i] != nil
+	}
+	if failed {
+		return errs
+	}
+	return nil
+}
+
+// Exists reports whether an entity exists and is visible to this view
+func (r *SoftDeleteRepository) Exists(ctx context.Context, id interface{}) (bool, error) {
+	var props datastore.PropertyList
+	err := r.base.GetByID(ctx, id, &props)
+	if errors.Is(err, datastore.ErrNoSuchEntity) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return r.visible(props), nil
+}
+
+// Create creates a new entity
+func (r *SoftDeleteRepository) Create(ctx context.Context, id interface{}, entity interface{}) error {
+	return r.base.Create(ctx, id, entity)
+}
+
+// Update updates an entity
+func (r *SoftDeleteRepository) Update(ctx context.Context, id interface{}, entity interface{}) error {
+	return r.base.Update(ctx, id, entity)
+}
+
+// Delete soft-deletes an entity by setting its deleted-at field to the
+// current time. Delete hooks fire as for a hard delete.
+func (r *SoftDeleteRepository) Delete(ctx context.Context, id interface{}) error {
+	if err := r.base.hooks.fire(ctx, beforeDelete, r.base.kind, id, nil); err != nil {
+		return err
+	}
+	if err := r.base.Patch(ctx, id, map[string]interface{}{r.field: time.Now()}); err != nil {
+		return err
+	}
+
+	key, err := r.base.executor.Key(r.base.kind, id)
+	if err != nil {
+		return err
+	}
+	return r.base.hooks.fire(ctx, afterDelete, r.base.kind, key, nil)
+}
+
+// Restore clears the deleted-at field of a soft-deleted entity
+func (r *SoftDeleteRepository) Restore(ctx context.Context, id interface{}) error {
+	return r.base.Patch(ctx, id, map[string]interface{}{r.field: nil})
+}
+
+// ForceDelete permanently removes an entity, deleted or not
+func (r *SoftDeleteRepository) ForceDelete(ctx context.Context, id interface{}) error {
+	return r.base.Delete(ctx, id)
+}
+
+// Query executes a query with flexible parameters, like BaseRepository.Query
+func (r *SoftDeleteRepository) Query(ctx context.Context, params interface{}) ([]interface{}, *builder.PaginationResult, error) {
+	return r.base.Query(ctx, params)
+}
+
+// QueryTyped executes query and returns typed results
+func (r *SoftDeleteRepository) QueryTyped(ctx context.Context, params interface{}, dest interface{}) (*builder.PaginationResult, error) {
+	return r.base.QueryTyped(ctx, params, dest)
+}
+
+// Count counts entities matching filters
+func (r *SoftDeleteRepository) Count(ctx context.Context, filters interface{}) (int, error) {
+	return r.base.Count(ctx, filters)
+}
+
+// CountWhere counts visible entities matching filters
+func (r *SoftDeleteRepository) CountWhere(ctx context.Context, filters interface{}) (int64, error) {
+	return r.base.CountWhere(ctx, filters)
+}
+
+// Sum sums field over visible entities matching filters
+func (r *SoftDeleteRepository) Sum(ctx context.Context, field string, filters interface{}) (float64, error) {
+	return r.base.Sum(ctx, field, filters)
+}
+
+// Avg averages field over visible entities matching filters
+func (r *SoftDeleteRepository) Avg(ctx context.Context, field string, filters interface{}) (float64, error) {
+	return r.base.Avg(ctx, field, filters)
+}
+
+// MinMax returns the smallest and largest values of field among visible
+// entities matching filters
+func (r *SoftDeleteRepository) MinMax(ctx context.Context, field string, filters interface{}) (lo, hi interface{}, err error) {
+	return r.base.MinMax(ctx, field, filters)
+}
+
+// FindAll retrieves all entities
+func (r *SoftDeleteRepository) FindAll(ctx context.Context, dest interface{}) error {
+	return r.base.FindAll(ctx, dest)
+}
+
+// FindWhere retrieves entities matching filters
+func (r *SoftDeleteRepository) FindWhere(ctx context.Context, filters map[string]interface{}, dest interface{}) error {
+	return r.base.FindWhere(ctx, filters, dest)
+}
+
+// FindOne retrieves first matching entity
+func (r *SoftDeleteRepository) FindOne(ctx context.Context, filters map[string]interface{}, dest interface{}) error {
+	return r.base.FindOne(ctx, filters, dest)
+}
+
+// Paginate retrieves paginated results; Total only counts visible entities
+func (r *SoftDeleteRepository) Paginate(ctx context.Context, filters map[string]interface{}, page, pageSize int, dest interface{}) (*builder.PaginationResult, error) {
+	return r.base.Paginate(ctx, filters, page, pageSize, dest)
+}
+
+// QueryWithCursor runs one page of a query over the visible entities, like
+// BaseRepository.QueryWithCursor
+func (r *SoftDeleteRepository) QueryWithCursor(ctx context.Context, params *builder.QueryParams, dest interface{}) (*builder.PaginationResult, error) {
+	return r.base.QueryWithCursor(ctx, params, dest)
+}
+
+// Preload loads the visible children of parents, like BaseRepository.Preload
+func (r *SoftDeleteRepository) Preload(ctx context.Context, parents interface{}, parentIDField, childFKField, destField string) error {
+	return r.base.Preload(ctx, parents, parentIDField, childFKField, destField)
+}
+
+// UpdateWhere patches the visible entities matching filters, like
+// BaseRepository.UpdateWhere
+func (r *SoftDeleteRepository) UpdateWhere(ctx context.Context, filters map[string]interface{}, changes map[string]interface{}) (int, error) {
+	return r.base.UpdateWhere(ctx, filters, changes)
+}
+
+// Pluck loads one property of the visible entities, like
+// BaseRepository.Pluck
+func (r *SoftDeleteRepository) Pluck(ctx context.Context, field string, filters map[string]any, dest any) error {
+	return r.base.Pluck(ctx, field, filters, dest)
+}
+
+// PluckDistinct is like Pluck but returns every value once
+func (r *SoftDeleteRepository) PluckDistinct(ctx context.Context, field string, filters map[string]any, dest any) error {
+	return r.base.PluckDistinct(ctx, field, filters, dest)
+}
+
+// visible reports whether an entity with props is seen by this view
+func (r *SoftDeleteRepository) visible(props datastore.PropertyList) bool {
+	deleted := false
+	for _, p := range props {
+		if p.Name == r.field {
+			deleted = p.Value != nil
+		}
+	}
+
+	switch r.trashed {
+	case withTrashed:
+		return true
+	case onlyTrashed:
+		return deleted
+	default:
+		return !deleted
+	}
+}
+
+// loadElem loads props into e, an element of a GetMulti destination
+func (r *SoftDeleteRepository) loadElem(e reflect.Value, props datastore.PropertyList, id interface{}) error {
+	if e.Kind() == reflect.Ptr && e.IsNil() {
+		e.Set(reflect.New(e.Type().Elem()))
+	}
+	dest := e.Interface()
+	if e.Kind() != reflect.Ptr && e.Kind() != reflect.Interface {
+		dest = e.Addr().Interface()
+	}
+
+	if err := loadProps(dest, props); err != nil {
+		return err
+	}
+	if key, err := r.base.executor.Key(r.base.kind, id); err == nil {
+		contextKey.SetID(dest, key)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/repository"
+)
+
+type softNote struct {
+	ID        string     `datastore:"-"`
+	Text      string     `datastore:"text"`
+	DeletedAt *time.Time `datastore:"deleted_at"`
+}
+
+func TestSoftDeleteRepository(t *testing.T) {
+	ctx, client := emulatorClient(t)
+	repo := repository.NewSoftDeleteRepository(repository.NewBaseRepository(client, "notes"), "")
+
+	for _, id := range []string{"n1", "n2"} {
+		if err := repo.Create(ctx, id, &softNote{Text: id}); err != nil {
+			t.Fatalf("Create %s: %v", id, err)
+		}
+	}
+
+	visible := func(t *testing.T, want int) {
+		t.Helper()
+		n, err := repo.Count(ctx, map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("Count: %v", err)
+		}
+		var page []softNote
+		result, err := repo.Paginate(ctx, map[string]interface{}{}, 1, 10, &page)
+		if err != nil {
+			t.Fatalf("Paginate: %v", err)
+		}
+		if n != want || result.Total != want || len(page) != want {
+			t.Errorf("expected %d visible, got Count=%d Total=%d page=%d", want, n, result.Total, len(page))
+		}
+	}
+
+	t.Run("delete hides the entity", func(t *testing.T) {
+		if err := repo.Delete(ctx, "n1"); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+
+		var note softNote
+		if err := repo.GetByID(ctx, "n1", &note); !errors.Is(err, exec.ErrNotFound) {
+			t.Errorf("GetByID: expected ErrNotFound, got %v", err)
+		}
+		if exists, _ := repo.Exists(ctx, "n1"); exists {
+			t.Error("Exists: deleted entity reported")
+		}
+		notes := make([]softNote, 2)
+		var multiErr datastore.MultiError
+		if err := repo.GetMulti(ctx, []interface{}{"n1", "n2"}, notes); !errors.As(err, &multiErr) ||
+			!errors.Is(multiErr[0], exec.ErrNotFound) || multiErr[1] != nil {
+			t.Errorf("GetMulti: expected only n1 to fail, got %v", err)
+		}
+		visible(t, 1)
+	})
+
+	t.Run("trashed views", func(t *testing.T) {
+		var all, trashed []softNote
+		if err := repo.WithTrashed().FindAll(ctx, &all); err != nil {
+			t.Fatalf("WithTrashed: %v", err)
+		}
+		if err := repo.OnlyTrashed().FindAll(ctx, &trashed); err != nil {
+			t.Fatalf("OnlyTrashed: %v", err)
+		}
+		if len(all) != 2 || len(trashed) != 1 || trashed[0].ID != "n1" {
+			t.Errorf("expected 2 with trashed and only n1 trashed, got %+v and %+v", all, trashed)
+		}
+
+		var note softNote
+		if err := repo.WithTrashed().GetByID(ctx, "n1", &note); err != nil || note.DeletedAt == nil {
+			t.Errorf("WithTrashed GetByID: got %+v, %v", note, err)
+		}
+	})
+
+	t.Run("restore makes it visible again", func(t *testing.T) {
+		if err := repo.Restore(ctx, "n1"); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		var note softNote
+		if err := repo.GetByID(ctx, "n1", &note); err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if note.ID != "n1" || note.DeletedAt != nil {
+			t.Errorf("unexpected note %+v", note)
+		}
+		visible(t, 2)
+	})
+
+	t.Run("ForceDelete removes the entity", func(t *testing.T) {
+		if err := repo.ForceDelete(ctx, "n2"); err != nil {
+			t.Fatalf("ForceDelete: %v", err)
+		}
+		if exists, _ := repo.WithTrashed().Exists(ctx, "n2"); exists {
+			t.Error("entity still stored after ForceDelete")
+		}
+		visible(t, 1)
+	})
+}
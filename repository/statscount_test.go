@@ -0,0 +1,61 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestWithStatsCount(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	repo := repository.NewBaseRepositoryWithClient(mock, "users", repository.WithStatsCount())
+
+	for _, user := range testutil.CreateTestUsers() {
+		if err := repo.Create(ctx, nil, &user); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	t.Run("Falls back to an exact count without statistics", func(t *testing.T) {
+		n, err := repo.Count(ctx, nil)
+		if err != nil || n != len(testutil.CreateTestUsers()) {
+			t.Errorf("expected the exact count, got %d, %v", n, err)
+		}
+	})
+
+	props := datastore.PropertyList{
+		{Name: "kind_name", Value: "users"},
+		{Name: "count", Value: int64(5000)},
+		{Name: "timestamp", Value: time.Now()},
+	}
+	if _, err := mock.Put(ctx, datastore.NameKey("__Stat_Kind__", "users", nil), &props); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	t.Run("Unfiltered counts use statistics", func(t *testing.T) {
+		n, err := repo.Count(ctx, map[string]interface{}{})
+		if err != nil || n != 5000 {
+			t.Errorf("expected the statistics count 5000, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("Filtered counts are exact", func(t *testing.T) {
+		n, err := repo.Count(ctx, map[string]interface{}{"age >": 0})
+		if err != nil || n != len(testutil.CreateTestUsers()) {
+			t.Errorf("expected the exact count, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("Without the option counts are exact", func(t *testing.T) {
+		plain := repository.NewBaseRepositoryWithClient(mock, "users")
+		n, err := plain.Count(ctx, nil)
+		if err != nil || n != len(testutil.CreateTestUsers()) {
+			t.Errorf("expected the exact count, got %d, %v", n, err)
+		}
+	})
+}
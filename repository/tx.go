@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/exec"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+// ErrNotSupportedInTx is returned by repository methods that cannot run inside
+// a transaction, such as queries and bulk operations
+var ErrNotSupportedInTx = errors.New("not supported in a transaction")
+
+// WithTx returns a view of the repository whose GetByID, GetByKey, GetMulti,
+// Exists, Create, Update, Delete and Patch methods (and their Multi and
+// WithKey variants) run inside tx. Its query, bulk and touch methods return
+// ErrNotSupportedInTx. Hooks fire as for the repository and reads bypass the
+// cache. As tx is committed by the caller, writes invalidate the cache when
+// issued; repositories of a TxScope invalidate it once the transaction
+// commits instead.
+func (r *BaseRepository) WithTx(tx gostore.Transaction) *BaseRepository {
+	view := *r
+	view.tx = r.executor.InTx(tx)
+	view.txCommit = nil
+	return &view
+}
+
+// inTx returns a view of the repository like WithTx bound to txe, started by
+// exec.Exec.Transaction, whose writes invalidate the cache once it commits
+func (r *BaseRepository) inTx(txe *exec.TxExec) *BaseRepository {
+	view := r.WithTx(txe.Transaction())
+	view.txCommit = txe
+	return view
+}
+
+// notInTx returns ErrNotSupportedInTx for method if r is a transaction view
+func (r *BaseRepository) notInTx(method string) error {
+	if r.tx != nil {
+		return fmt.Errorf("%s: %w", method, ErrNotSupportedInTx)
+	}
+	return nil
+}
+
+// TxScope hands out repositories bound to one transaction
+type TxScope struct {
+	client gostore.Client
+	tx     *exec.TxExec
+}
+
+// Bind returns repo as a view bound to the scope's transaction, whose writes
+// invalidate the cache once it commits
+func (s *TxScope) Bind(repo *BaseRepository) *BaseRepository {
+	return repo.inTx(s.tx)
+}
+
+// Repository returns a new repository for kind bound to the scope's
+// transaction
+func (s *TxScope) Repository(kind string, opts ...Option) *BaseRepository {
+	return NewBaseRepositoryWithClient(s.client, kind, opts...).inTx(s.tx)
+}
+
+// Tx returns the scope's transaction
+func (s *TxScope) Tx() gostore.Transaction {
+	return s.tx.Transaction()
+}
+
+// RunInTransaction runs fn in a transaction on client, retrying it on
+// contention like exec.Exec.Transaction, and returns the commit. Writes made
+// through the scope's repositories are applied together when fn returns nil
+// and discarded when it returns an error.
+func RunInTransaction(ctx context.Context, client *datastore.Client, fn func(scope *TxScope) error, opts ...exec.TxOption) (*datastore.Commit, error) {
+	c := gostore.Wrap(client)
+	if c == nil {
+		return nil, contextKey.ErrClientNotInitialized
+	}
+
+	h := exec.NewExecWithOptions(exec.WithClient(c))
+	return h.Transaction(ctx, func(tx *exec.TxExec) error {
+		return fn(&TxScope{client: c, tx: tx})
+	}, opts...)
+}
+
+// store is the part of exec.Exec the repository delegates single-entity
+// operations to, also implemented by exec.TxExec
+type store interface {
+	GetByID(ctx context.Context, kind string, id any, dest any) error
+	GetByKey(ctx context.Context, key *datastore.Key, dest any) error
+	GetMulti(ctx context.Context, kind string, ids []any, dest any) error
+	Exists(ctx context.Context, kind string, id any) (bool, error)
+	CreateWithKey(ctx context.Context, kind string, id any, entity any) (*datastore.Key, error)
+	CreateMultiWithKeys(ctx context.Context, kind string, ids []any, entities any) ([]*datastore.Key, error)
+	UpdateWithKey(ctx context.Context, kind string, id any, entity any) (*datastore.Key, error)
+	UpdateMultiWithKeys(ctx context.Context, kind string, ids []any, entities any) ([]*datastore.Key, error)
+	Delete(ctx context.Context, kind string, id any) error
+	DeleteByKey(ctx context.Context, key *datastore.Key) error
+	DeleteMulti(ctx context.Context, kind string, ids []any) error
+	Patch(ctx context.Context, kind string, id any, changes map[string]any) error
+}
+
+// store returns the transaction of a WithTx view, or else the executor
+func (r *BaseRepository) store() store {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.executor
+}
@@ -0,0 +1,113 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type txOrder struct {
+	Product  string
+	Quantity int
+}
+
+type txStock struct {
+	Available int
+}
+
+func TestRunInTransaction(t *testing.T) {
+	ctx, client := emulatorClient(t)
+
+	orders := repository.NewBaseRepository(client, "orders")
+	stock := repository.NewBaseRepository(client, "stock")
+	if err := stock.Create(ctx, "widget", &txStock{Available: 5}); err != nil {
+		t.Fatalf("Create stock: %v", err)
+	}
+
+	placeOrder := func(scope *repository.TxScope, id string, qty int, fail bool) error {
+		s := scope.Bind(stock)
+		var item txStock
+		if err := s.GetByID(ctx, "widget", &item); err != nil {
+			return err
+		}
+		item.Available -= qty
+		if err := s.Update(ctx, "widget", &item); err != nil {
+			return err
+		}
+		if err := scope.Repository("orders").Create(ctx, id, &txOrder{Product: "widget", Quantity: qty}); err != nil {
+			return err
+		}
+		if fail {
+			return errors.New("forced rollback")
+		}
+		return nil
+	}
+
+	t.Run("rollback leaves both kinds unchanged", func(t *testing.T) {
+		_, err := repository.RunInTransaction(ctx, client, func(scope *repository.TxScope) error {
+			return placeOrder(scope, "o1", 2, true)
+		})
+		if err == nil || err.Error() != "forced rollback" {
+			t.Fatalf("expected forced rollback, got %v", err)
+		}
+
+		if exists, err := orders.Exists(ctx, "o1"); err != nil || exists {
+			t.Errorf("order written despite rollback (exists=%v, err=%v)", exists, err)
+		}
+		var item txStock
+		if err := stock.GetByID(ctx, "widget", &item); err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if item.Available != 5 {
+			t.Errorf("expected 5 available, got %d", item.Available)
+		}
+	})
+
+	t.Run("commit applies both kinds", func(t *testing.T) {
+		if _, err := repository.RunInTransaction(ctx, client, func(scope *repository.TxScope) error {
+			return placeOrder(scope, "o2", 2, false)
+		}); err != nil {
+			t.Fatalf("RunInTransaction: %v", err)
+		}
+
+		var order txOrder
+		if err := orders.GetByID(ctx, "o2", &order); err != nil {
+			t.Fatalf("order not written: %v", err)
+		}
+		var item txStock
+		if err := stock.GetByID(ctx, "widget", &item); err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if item.Available != 3 {
+			t.Errorf("expected 3 available, got %d", item.Available)
+		}
+	})
+
+	t.Run("WithTx accepts a datastore transaction", func(t *testing.T) {
+		if _, err := client.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+			return orders.WithTx(tx).Delete(ctx, "o2")
+		}); err != nil {
+			t.Fatalf("RunInTransaction: %v", err)
+		}
+		if exists, _ := orders.Exists(ctx, "o2"); exists {
+			t.Error("order not deleted")
+		}
+	})
+}
+
+func TestWithTxRejectsQueries(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewBaseRepositoryWithClient(testutil.NewMockClient(), "users").WithTx(nil)
+
+	var users []testutil.TestUser
+	if err := repo.FindAll(ctx, &users); !errors.Is(err, repository.ErrNotSupportedInTx) {
+		t.Errorf("FindAll: expected ErrNotSupportedInTx, got %v", err)
+	}
+	if _, err := repo.Count(ctx, nil); !errors.Is(err, repository.ErrNotSupportedInTx) {
+		t.Errorf("Count: expected ErrNotSupportedInTx, got %v", err)
+	}
+}
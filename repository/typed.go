@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/exec"
+)
+
+// Typed is a repository for entities of type T. It wraps a BaseRepository,
+// so errors, chunking and exec options behave the same, but takes and returns
+// T values instead of interface{} destinations.
+type Typed[T any] struct {
+	base *BaseRepository
+}
+
+// NewTyped creates a typed repository for kind
+func NewTyped[T any](client *datastore.Client, kind string, opts ...exec.Option) *Typed[T] {
+	return &Typed[T]{base: NewBaseRepositoryWithOptions(client, kind, WithPrototype(new(T)), WithExecOptions(opts...))}
+}
+
+// NewTypedFrom wraps an existing BaseRepository
+func NewTypedFrom[T any](base *BaseRepository) *Typed[T] {
+	return &Typed[T]{base: base}
+}
+
+// Base returns the underlying untyped repository
+func (r *Typed[T]) Base() *BaseRepository {
+	return r.base
+}
+
+// Scoped returns a view applying the named scopes, like
+// BaseRepository.Scoped
+func (r *Typed[T]) Scoped(names ...string) *Typed[T] {
+	return &Typed[T]{base: r.base.Scoped(names...)}
+}
+
+// Unscoped returns a view without any scopes, like BaseRepository.Unscoped
+func (r *Typed[T]) Unscoped() *Typed[T] {
+	return &Typed[T]{base: r.base.Unscoped()}
+}
+
+// IgnoreDefaults returns a view without default filters and orders, like
+// BaseRepository.IgnoreDefaults
+func (r *Typed[T]) IgnoreDefaults() *Typed[T] {
+	return &Typed[T]{base: r.base.IgnoreDefaults()}
+}
+
+// Namespace returns a view whose keys and queries are all in namespace, like
+// BaseRepository.Namespace
+func (r *Typed[T]) Namespace(namespace string) *Typed[T] {
+	return &Typed[T]{base: r.base.Namespace(namespace)}
+}
+
+// GetKind returns the kind name
+func (r *Typed[T]) GetKind() string {
+	return r.base.kind
+}
+
+// Get retrieves the entity with the given ID
+func (r *Typed[T]) Get(ctx context.Context, id any) (*T, error) {
+	var dest T
+	if err := r.base.GetByID(ctx, id, &dest); err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
+
+// GetByKey retrieves the entity stored under key
+func (r *Typed[T]) GetByKey(ctx context.Context, key *datastore.Key) (*T, error) {
+	var dest T
+	if err := r.base.GetByKey(ctx, key, &dest); err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
+
+// GetMulti retrieves the entities with the given IDs, in the same order
+func (r *Typed[T]) GetMulti(ctx context.Context, ids []any) ([]T, error) {
+	dest := make([]T, len(ids))
+	if err := r.base.GetMulti(ctx, ids, dest); err != nil {
+		return nil, err
+	}
+	return dest, nil
+}
+
+// Create creates a new entity and returns its key. A nil or exec.AutoUUID id
+// allocates or generates the ID.
+func (r *Typed[T]) Create(ctx context.Context, id any, entity *T) (*datastore.Key, error) {
+	return r.base.CreateWithKey(ctx, id, entity)
+}
+
+// CreateMulti creates multiple entities and returns their keys in order
+func (r *Typed[T]) CreateMulti(ctx context.Context, ids []any, entities []T) ([]*datastore.Key, error) {
+	return r.base.CreateMultiWithKeys(ctx, ids, entities)
+}
+
+// Save inserts entity when its ID field is zero, writing the new ID back,
+// and upserts it otherwise
+func (r *Typed[T]) Save(ctx context.Context, entity *T) (*datastore.Key, error) {
+	return r.base.Save(ctx, entity)
+}
+
+// SaveMulti saves every entity like Save and returns their keys in order
+func (r *Typed[T]) SaveMulti(ctx context.Context, entities []T) ([]*datastore.Key, error) {
+	return r.base.SaveMulti(ctx, entities)
+}
+
+// FirstOrCreate returns the first entity matching filters, or creates
+// defaults if there is none, like BaseRepository.FirstOrCreate
+func (r *Typed[T]) FirstOrCreate(ctx context.Context, filters map[string]any, defaults *T) (*T, bool, error) {
+	var dest T
+	created, err := r.base.FirstOrCreate(ctx, filters, defaults, &dest)
+	if err != nil {
+		return nil, false, err
+	}
+	return &dest, created, nil
+}
+
+// FirstOrCreateByID returns the entity stored under id, or transactionally
+// creates defaults under id, like BaseRepository.FirstOrCreateByID
+func (r *Typed[T]) FirstOrCreateByID(ctx context.Context, id any, defaults *T) (*T, bool, error) {
+	var dest T
+	created, err := r.base.FirstOrCreateByID(ctx, id, defaults, &dest)
+	if err != nil {
+		return nil, false, err
+	}
+	return &dest, created, nil
+}
+
+// Update replaces an existing entity
+func (r *Typed[T]) Update(ctx context.Context, id any, entity *T) error {
+	return r.base.Update(ctx, id, entity)
+}
+
+// UpdateMulti replaces multiple existing entities
+func (r *Typed[T]) UpdateMulti(ctx context.Context, ids []any, entities []T) error {
+	return r.base.UpdateMulti(ctx, ids, entities)
+}
+
+// Delete deletes an entity
+func (r *Typed[T]) Delete(ctx context.Context, id any) error {
+	return r.base.Delete(ctx, id)
+}
+
+// DeleteMulti deletes multiple entities
+func (r *Typed[T]) DeleteMulti(ctx context.Context, ids []any) error {
+	return r.base.DeleteMulti(ctx, ids)
+}
+
+// Exists checks if an entity exists
+func (r *Typed[T]) Exists(ctx context.Context, id any) (bool, error) {
+	return r.base.Exists(ctx, id)
+}
+
+// List runs a query built from params
+func (r *Typed[T]) List(ctx context.Context, params *builder.QueryParams) ([]T, *builder.PaginationResult, error) {
+	if params == nil {
+		params = &builder.QueryParams{}
+	}
+
+	var dest []T
+	pagination, err := r.base.QueryTyped(ctx, params, &dest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dest, pagination, nil
+}
+
+// Query runs a query built from params, which take any form accepted by
+// BaseRepository.Query
+func (r *Typed[T]) Query(ctx context.Context, params any) ([]T, *builder.PaginationResult, error) {
+	var dest []T
+	pagination, err := r.base.QueryTyped(ctx, params, &dest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dest, pagination, nil
+}
+
+// Chunk calls fn with the entities matching filters, size at a time, like
+// BaseRepository.Chunk
+func (r *Typed[T]) Chunk(ctx context.Context, filters map[string]any, size int, fn func(page []T) error) error {
+	return r.base.chunk(ctx, filters, size, reflect.TypeOf([]T(nil)), func(page reflect.Value) error {
+		return fn(page.Interface().([]T))
+	})
+}
+
+// QueryWithCursor runs one page of a query for cursor-based paging, like
+// BaseRepository.QueryWithCursor
+func (r *Typed[T]) QueryWithCursor(ctx context.Context, params *builder.QueryParams) ([]T, *builder.PaginationResult, error) {
+	var dest []T
+	pagination, err := r.base.QueryWithCursor(ctx, params, &dest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dest, pagination, nil
+}
+
+// FindAll retrieves all entities
+func (r *Typed[T]) FindAll(ctx context.Context) ([]T, error) {
+	var dest []T
+	if err := r.base.FindAll(ctx, &dest); err != nil {
+		return nil, err
+	}
+	return dest, nil
+}
+
+// FindWhere retrieves the entities matching filters
+func (r *Typed[T]) FindWhere(ctx context.Context, filters map[string]any) ([]T, error) {
+	var dest []T
+	if err := r.base.FindWhere(ctx, filters, &dest); err != nil {
+		return nil, err
+	}
+	return dest, nil
+}
+
+// FindOne retrieves the first entity matching filters. No match returns an
+// error matching exec.ErrNotFound.
+func (r *Typed[T]) FindOne(ctx context.Context, filters map[string]any) (*T, error) {
+	var dest T
+	if err := r.base.FindOne(ctx, filters, &dest); err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
+
+// Paginate retrieves one page of the entities matching filters
+func (r *Typed[T]) Paginate(ctx context.Context, filters map[string]any, page, pageSize int) ([]T, *builder.PaginationResult, error) {
+	var dest []T
+	pagination, err := r.base.Paginate(ctx, filters, page, pageSize, &dest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dest, pagination, nil
+}
+
+// Count counts entities matching filters
+func (r *Typed[T]) Count(ctx context.Context, filters any) (int, error) {
+	return r.base.Count(ctx, filters)
+}
+
+// CountWhere counts entities matching filters, like BaseRepository.CountWhere
+func (r *Typed[T]) CountWhere(ctx context.Context, filters any) (int64, error) {
+	return r.base.CountWhere(ctx, filters)
+}
+
+// Sum sums field over entities matching filters, like BaseRepository.Sum
+func (r *Typed[T]) Sum(ctx context.Context, field string, filters any) (float64, error) {
+	return r.base.Sum(ctx, field, filters)
+}
+
+// Avg averages field over entities matching filters, like BaseRepository.Avg
+func (r *Typed[T]) Avg(ctx context.Context, field string, filters any) (float64, error) {
+	return r.base.Avg(ctx, field, filters)
+}
+
+// MinMax returns the smallest and largest values of field among entities
+// matching filters, like BaseRepository.MinMax
+func (r *Typed[T]) MinMax(ctx context.Context, field string, filters any) (lo, hi any, err error) {
+	return r.base.MinMax(ctx, field, filters)
+}
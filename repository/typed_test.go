@@ -0,0 +1,113 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/exec"
+	contextKey "github.com/AndroX7/gostore/key"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	testutil.StopEmulator()
+	os.Exit(code)
+}
+
+// emulatorClient returns a client connected to the Datastore emulator, using a
+// fresh project so tests don't share data, and a context carrying it. The test
+// is skipped when there is no emulator (see testutil.RequireEmulator).
+func emulatorClient(t testing.TB) (context.Context, *datastore.Client) {
+	t.Helper()
+
+	client := testutil.StartEmulator(t)
+	return context.WithValue(context.Background(), contextKey.NOSQL_KEY, client), client
+}
+
+func TestTyped(t *testing.T) {
+	ctx, client := emulatorClient(t)
+	repo := repository.NewTyped[testutil.TestUser](client, "users")
+
+	users := testutil.CreateTestUsers()
+	ids := make([]any, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	if _, err := repo.CreateMulti(ctx, ids, users); err != nil {
+		t.Fatalf("CreateMulti failed: %v", err)
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		user, err := repo.Get(ctx, "user1")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if user.Email != "john@example.com" {
+			t.Errorf("expected john@example.com, got %s", user.Email)
+		}
+
+		if _, err := repo.Get(ctx, "missing"); !errors.Is(err, datastore.ErrNoSuchEntity) {
+			t.Errorf("expected ErrNoSuchEntity, got %v", err)
+		}
+	})
+
+	t.Run("GetMulti", func(t *testing.T) {
+		got, err := repo.GetMulti(ctx, []any{"user2", "user1"})
+		if err != nil {
+			t.Fatalf("GetMulti failed: %v", err)
+		}
+		if len(got) != 2 || got[0].Name != "Jane Smith" || got[1].Name != "John Doe" {
+			t.Errorf("expected Jane Smith and John Doe, got %+v", got)
+		}
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		key, err := repo.Create(ctx, nil, &testutil.TestUser{Name: "New", Status: "pending"})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if key.Incomplete() {
+			t.Error("expected a complete key")
+		}
+		if err := repo.Delete(ctx, key.ID); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		got, _, err := repo.List(ctx, &builder.QueryParams{
+			Filters: []builder.FilterParam{{Field: "status", Operator: builder.Equal, Value: "active"}},
+		})
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(got) != 3 {
+			t.Errorf("expected 3 active users, got %d", len(got))
+		}
+		for _, u := range got {
+			if u.Status != "active" {
+				t.Errorf("expected active user, got %s", u.Status)
+			}
+		}
+	})
+
+	t.Run("FindOne", func(t *testing.T) {
+		user, err := repo.FindOne(ctx, map[string]any{"email": "bob@example.com"})
+		if err != nil {
+			t.Fatalf("FindOne failed: %v", err)
+		}
+		if user.Name != "Bob Wilson" {
+			t.Errorf("expected Bob Wilson, got %s", user.Name)
+		}
+
+		if _, err := repo.FindOne(ctx, map[string]any{"email": "nobody"}); !errors.Is(err, exec.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+}
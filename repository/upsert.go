@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+)
+
+// BulkUpsertOptions configures BulkUpsertWithOptions
+type BulkUpsertOptions struct {
+	// AutoID lets entities with an empty ID be inserted under a new one, as
+	// Save does, instead of failing the whole call
+	AutoID bool
+
+	// OnBatch is called after every batch, including failed ones, like
+	// exec.BulkOptions.OnBatch
+	OnBatch func(done, total int, keys []*datastore.Key, err error)
+
+	// StartAt skips the first StartAt entities, so a failed run can be resumed
+	// from exec.BulkError.Offset
+	StartAt int
+}
+
+// BulkUpsert writes entities, a slice of structs or struct pointers, in
+// batches under the IDs in their ID fields (see key.SetID), so importing the
+// same records twice overwrites rather than duplicates them. Every entity
+// must have an ID; see BulkUpsertWithOptions to insert the ones without.
+func (r *BaseRepository) BulkUpsert(ctx context.Context, entities interface{}, batchSize int) error {
+	return r.BulkUpsertWithOptions(ctx, entities, batchSize, BulkUpsertOptions{})
+}
+
+// BulkUpsertWithOptions is BulkUpsert with progress reporting, resume support
+// and, with opts.AutoID, inserts of entities without an ID. Each batch is
+// written like SaveMulti: new IDs are written back into the entities and
+// create or update hooks fire accordingly. IDs are checked before anything
+// is written. On failure it returns an *exec.BulkError carrying the offset to
+// resume from; if ctx is cancelled it stops before the next batch with an
+// *exec.PartialError.
+func (r *BaseRepository) BulkUpsertWithOptions(ctx context.Context, entities interface{}, batchSize int, opts BulkUpsertOptions) error {
+	if err := r.notInTx("BulkUpsert"); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(entities)
+	if v.Kind() != reflect.Slice {
+		return errors.New("entities must be a slice")
+	}
+	if batchSize <= 0 || batchSize > exec.MaxBatchSize {
+		batchSize = exec.MaxBatchSize
+	}
+	total := v.Len()
+	if opts.StartAt < 0 || opts.StartAt > total {
+		return fmt.Errorf("start offset %d out of range [0, %d]", opts.StartAt, total)
+	}
+
+	for i := opts.StartAt; i < total; i++ {
+		_, insert, err := saveID(v.Index(i).Interface())
+		if err != nil {
+			return fmt.Errorf("entity at index %d: %w", i, err)
+		}
+		if insert && !opts.AutoID {
+			return fmt.Errorf("entity at index %d has an empty ID", i)
+		}
+	}
+
+	batchIndex := 0
+	for i := opts.StartAt; i < total; i += batchSize {
+		if err := ctx.Err(); err != nil {
+			return &exec.PartialError{Completed: i, Err: err}
+		}
+
+		end := min(i+batchSize, total)
+		keys, err := r.SaveMulti(ctx, v.Slice(i, end).Interface())
+		if opts.OnBatch != nil {
+			opts.OnBatch(end, total, keys, err)
+		}
+		if err != nil {
+			return &exec.BulkError{BatchIndex: batchIndex, Offset: i, Err: err}
+		}
+		batchIndex++
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func importUsers(n int) []testutil.TestUser {
+	users := make([]testutil.TestUser, n)
+	for i := range users {
+		users[i] = testutil.TestUser{
+			ID:     fmt.Sprintf("import-%03d", i),
+			Email:  fmt.Sprintf("user%d@example.com", i),
+			Status: "active",
+		}
+	}
+	return users
+}
+
+func TestBulkUpsertIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	repo := repository.NewBaseRepositoryWithClient(mock, "users")
+
+	for run := 0; run < 2; run++ {
+		if err := repo.BulkUpsert(ctx, importUsers(100), 30); err != nil {
+			t.Fatalf("run %d: BulkUpsert: %v", run, err)
+		}
+		if n := mock.Count("users"); n != 100 {
+			t.Fatalf("run %d: expected 100 entities, got %d", run, n)
+		}
+	}
+}
+
+func TestBulkUpsertAutoID(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	repo := repository.NewBaseRepositoryWithClient(mock, "users")
+
+	users := importUsers(100)
+	for i := 0; i < len(users); i += 10 {
+		users[i].ID = ""
+	}
+
+	if err := repo.BulkUpsert(ctx, users, 30); err == nil {
+		t.Fatal("expected an error for empty IDs")
+	}
+	if n := mock.Count("users"); n != 0 {
+		t.Fatalf("expected nothing written, got %d entities", n)
+	}
+
+	// Rerunning with AutoID inserts the ten entities without an ID again
+	for run := 1; run <= 2; run++ {
+		batch := append([]testutil.TestUser(nil), users...)
+		if err := repo.BulkUpsertWithOptions(ctx, batch, 30, repository.BulkUpsertOptions{AutoID: true}); err != nil {
+			t.Fatalf("run %d: BulkUpsertWithOptions: %v", run, err)
+		}
+		if n, want := mock.Count("users"), 90+10*run; n != want {
+			t.Fatalf("run %d: expected %d entities, got %d", run, want, n)
+		}
+		if batch[0].ID == "" {
+			t.Errorf("run %d: expected the generated ID written back", run)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Validator is implemented by entities that check themselves before being
+// written. Create, Update, Save, their Multi variants and BulkCreate call
+// Validate before any hook runs and return its error without writing.
+type Validator interface {
+	Validate() error
+}
+
+// WithValidator adds fn to the checks run on every entity written, after the
+// entity's own Validate method. Use it for types the caller doesn't own.
+func WithValidator(fn func(entity any) error) Option {
+	return func(o *options) {
+		o.validators = append(o.validators, fn)
+	}
+}
+
+// validate checks entity with its Validate method and the repository's
+// validators
+func (r *BaseRepository) validate(entity any) error {
+	if v, ok := entity.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, fn := range r.validators {
+		if err := fn(entity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateEach validates every element of entities, reporting the index of
+// the first invalid one
+func (r *BaseRepository) validateEach(entities any) error {
+	return r.validateFrom(entities, 0)
+}
+
+// validateFrom validates the elements of entities from index start on, like
+// validateEach
+func (r *BaseRepository) validateFrom(entities any, start int) error {
+	v := reflect.ValueOf(entities)
+	if v.Kind() != reflect.Slice {
+		return errors.New("entities must be a slice")
+	}
+	for i := start; i < v.Len(); i++ {
+		if err := r.validate(elem(v, i)); err != nil {
+			return fmt.Errorf("entity at index %d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+var errNoEmail = errors.New("email is required")
+
+type validatedUser struct {
+	ID    string `datastore:"-"`
+	Email string `datastore:"email"`
+}
+
+func (u *validatedUser) Validate() error {
+	if u.Email == "" {
+		return errNoEmail
+	}
+	return nil
+}
+
+func TestValidator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("failing validation blocks the write before hooks", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		repo := repository.NewBaseRepositoryWithClient(mock, "users")
+		repo.BeforeCreate(func(ctx context.Context, kind string, id any, entity any) error {
+			t.Error("hook called for an invalid entity")
+			return nil
+		})
+
+		if err := repo.Create(ctx, "u1", &validatedUser{}); !errors.Is(err, errNoEmail) {
+			t.Errorf("Create: expected validation error, got %v", err)
+		}
+		if _, err := repo.Save(ctx, &validatedUser{ID: "u1"}); !errors.Is(err, errNoEmail) {
+			t.Errorf("Save: expected validation error, got %v", err)
+		}
+		if mock.Count("users") != 0 {
+			t.Errorf("expected nothing written, got %d entities", mock.Count("users"))
+		}
+	})
+
+	t.Run("multi operations report the failing index", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		repo := repository.NewBaseRepositoryWithClient(mock, "users")
+		users := []validatedUser{{Email: "a@example.com"}, {Email: "b@example.com"}, {}}
+
+		err := repo.CreateMulti(ctx, []interface{}{"u1", "u2", "u3"}, users)
+		if !errors.Is(err, errNoEmail) || !strings.Contains(err.Error(), "index 2") {
+			t.Errorf("CreateMulti: expected validation error at index 2, got %v", err)
+		}
+		if _, err := repo.SaveMulti(ctx, users); err == nil || !strings.Contains(err.Error(), "index 2") {
+			t.Errorf("SaveMulti: expected validation error at index 2, got %v", err)
+		}
+		if mock.Count("users") != 0 {
+			t.Errorf("expected nothing written, got %d entities", mock.Count("users"))
+		}
+	})
+
+	t.Run("repository validators check types they don't own", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		errMinor := errors.New("user must be an adult")
+		repo := repository.NewBaseRepositoryWithClient(mock, "users", repository.WithValidator(func(entity any) error {
+			if u, ok := entity.(*testutil.TestUser); ok && u.Age < 18 {
+				return errMinor
+			}
+			return nil
+		}))
+
+		if err := repo.Update(ctx, "u1", &testutil.TestUser{Age: 12}); !errors.Is(err, errMinor) {
+			t.Errorf("Update: expected validation error, got %v", err)
+		}
+		if err := repo.Create(ctx, "u1", &testutil.TestUser{Age: 30}); err != nil {
+			t.Errorf("Create: %v", err)
+		}
+		if mock.Count("users") != 1 {
+			t.Errorf("expected the valid user written, got %d entities", mock.Count("users"))
+		}
+	})
+}
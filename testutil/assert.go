@@ -0,0 +1,211 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/builder"
+)
+
+// The assertions below report failures with t.Errorf, naming the kind, key
+// and field involved, and return whether they passed, so a test can stop with
+// t.FailNow when later checks depend on them. They work with any
+// gostore.Client: the mock, or gostore.Wrap of an emulator client. IDs are
+// string names, integer IDs or *datastore.Key.
+
+// AssertEntityExists checks that an entity of kind is stored under id
+func AssertEntityExists(t testing.TB, ctx context.Context, client gostore.Client, kind string, id any) bool {
+	t.Helper()
+
+	key, props, ok := getProps(t, ctx, client, kind, id)
+	if ok && props == nil {
+		t.Errorf("expected %s to exist, but it does not", key)
+		return false
+	}
+	return ok
+}
+
+// AssertEntityAbsent checks that no entity of kind is stored under id
+func AssertEntityAbsent(t testing.TB, ctx context.Context, client gostore.Client, kind string, id any) bool {
+	t.Helper()
+
+	key, props, ok := getProps(t, ctx, client, kind, id)
+	if ok && props != nil {
+		t.Errorf("expected %s to be absent, but it exists with %s", key, formatProps(props))
+		return false
+	}
+	return ok
+}
+
+// AssertCount checks that want entities of kind match filters, given as for
+// exec.FindWhere: property names, optionally followed by an operator such as
+// "age >", mapped to values
+func AssertCount(t testing.TB, ctx context.Context, client gostore.Client, kind string, filters map[string]any, want int) bool {
+	t.Helper()
+
+	b := builder.New().Kind(kind)
+	for _, f := range builder.NewFilter().FromMap(filters).Build() {
+		b.Filter(f.Field, f.Operator, f.Value)
+	}
+
+	keys, err := client.GetAll(ctx, b.KeysOnly().Build(), nil)
+	if err != nil {
+		t.Errorf("count %s matching %v: %v", kind, filters, err)
+		return false
+	}
+	if len(keys) != want {
+		t.Errorf("expected %d %s entities matching %v, got %d: %v", want, kind, filters, len(keys), keyIDs(keys))
+		return false
+	}
+	return true
+}
+
+// AssertFieldEquals checks that property field of the entity of kind stored
+// under id equals want. Values are compared as Datastore stores them, so an
+// int matches an int64 property and a []string an array of strings.
+func AssertFieldEquals(t testing.TB, ctx context.Context, client gostore.Client, kind string, id any, field string, want any) bool {
+	t.Helper()
+
+	key, props, ok := getProps(t, ctx, client, kind, id)
+	if !ok {
+		return false
+	}
+	if props == nil {
+		t.Errorf("expected %s field %s to be %v, but the entity does not exist", key, field, want)
+		return false
+	}
+
+	got, found := propertyValue(props, field)
+	if !found {
+		t.Errorf("expected %s field %s to be %v, but it has no such property: %s", key, field, want, formatProps(props))
+		return false
+	}
+	if !equalValue(storedValue(got), storedValue(want)) {
+		t.Errorf("%s field %s: expected %v (%T), got %v (%T)", key, field, want, want, got, got)
+		return false
+	}
+	return true
+}
+
+// AssertQueryReturns checks that b returns the entities with IDs wantIDs:
+// key names, or integer IDs in decimal. If b has orders the IDs must come in
+// the same order; otherwise any order passes.
+func AssertQueryReturns(t testing.TB, ctx context.Context, client gostore.Client, b *builder.Builder, wantIDs []string) bool {
+	t.Helper()
+
+	keys, err := client.GetAll(ctx, b.Clone().KeysOnly().Build(), nil)
+	if err != nil {
+		t.Errorf("query %s: %v", b.GetKind(), err)
+		return false
+	}
+
+	got := keyIDs(keys)
+	want := slices.Clone(wantIDs)
+	ordered := len(b.GetOrders()) > 0
+	if !ordered {
+		slices.Sort(got)
+		slices.Sort(want)
+	}
+	if slices.Equal(got, want) {
+		return true
+	}
+
+	missing, unexpected := idDiff(got, want)
+	if len(missing) == 0 && len(unexpected) == 0 {
+		t.Errorf("query %s returned %v, want %v: same IDs in a different order", b.GetKind(), got, want)
+	} else {
+		t.Errorf("query %s returned %v, want %v: missing %v, unexpected %v", b.GetKind(), got, want, missing, unexpected)
+	}
+	return false
+}
+
+// getProps loads the entity of kind stored under id, returning nil props if
+// there is none. ok is false if the lookup failed and was reported.
+func getProps(t testing.TB, ctx context.Context, client gostore.Client, kind string, id any) (*datastore.Key, datastore.PropertyList, bool) {
+	t.Helper()
+
+	key, err := idKey(kind, id)
+	if err != nil {
+		t.Errorf("%s %v: %v", kind, id, err)
+		return nil, nil, false
+	}
+
+	var props datastore.PropertyList
+	err = client.Get(ctx, key, &props)
+	if errors.Is(err, datastore.ErrNoSuchEntity) {
+		return key, nil, true
+	}
+	if err != nil {
+		t.Errorf("get %s: %v", key, err)
+		return key, nil, false
+	}
+	return key, props, true
+}
+
+func propertyValue(props datastore.PropertyList, name string) (any, bool) {
+	for _, p := range props {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	return nil, false
+}
+
+// storedValue converts v to the type Datastore stores it as, turning slices
+// other than []byte into []interface{}
+func storedValue(v any) any {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		values := make([]interface{}, rv.Len())
+		for i := range values {
+			values[i] = storedValue(rv.Index(i).Interface())
+		}
+		return values
+	}
+	return normalize(v)
+}
+
+func formatProps(props datastore.PropertyList) string {
+	fields := make([]string, len(props))
+	for i, p := range props {
+		fields[i] = fmt.Sprintf("%s:%v", p.Name, p.Value)
+	}
+	return "{" + strings.Join(fields, " ") + "}"
+}
+
+// keyIDs returns the names of keys, or their integer IDs in decimal
+func keyIDs(keys []*datastore.Key) []string {
+	ids := make([]string, len(keys))
+	for i, k := range keys {
+		if k.Name != "" {
+			ids[i] = k.Name
+		} else {
+			ids[i] = strconv.FormatInt(k.ID, 10)
+		}
+	}
+	return ids
+}
+
+// idDiff returns the IDs of want missing from got and those of got not in
+// want
+func idDiff(got, want []string) (missing, unexpected []string) {
+	for _, id := range want {
+		if !slices.Contains(got, id) {
+			missing = append(missing, id)
+		}
+	}
+	for _, id := range got {
+		if !slices.Contains(want, id) {
+			unexpected = append(unexpected, id)
+		}
+	}
+	return missing, unexpected
+}
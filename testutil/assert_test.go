@@ -0,0 +1,133 @@
+package testutil_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/builder"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+// fakeT records the failures reported to it instead of failing the test
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+// testAssertions checks every assertion passes and fails as it should, with
+// messages naming what failed
+func testAssertions(t *testing.T, client gostore.Client) {
+	ctx := context.Background()
+
+	users := testutil.CreateTestUsers()
+	keys := make([]*datastore.Key, len(users))
+	for i, u := range users {
+		keys[i] = datastore.NameKey("users", u.ID, nil)
+	}
+	if _, err := client.PutMulti(ctx, keys, users); err != nil {
+		t.Fatalf("failed to seed users: %v", err)
+	}
+	tagged := &taggedItem{Tags: []string{"go", "db"}}
+	if _, err := client.Put(ctx, datastore.IDKey("items", 7, nil), tagged); err != nil {
+		t.Fatalf("failed to store item: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		assert func(t testing.TB) bool
+		fails  []string // substrings of the failure message; nil for a pass
+	}{
+		{"Exists", func(t testing.TB) bool {
+			return testutil.AssertEntityExists(t, ctx, client, "users", "user1")
+		}, nil},
+		{"Exists by integer ID", func(t testing.TB) bool {
+			return testutil.AssertEntityExists(t, ctx, client, "items", 7)
+		}, nil},
+		{"Exists fails", func(t testing.TB) bool {
+			return testutil.AssertEntityExists(t, ctx, client, "users", "nobody")
+		}, []string{"/users,nobody", "to exist"}},
+		{"Absent", func(t testing.TB) bool {
+			return testutil.AssertEntityAbsent(t, ctx, client, "users", "nobody")
+		}, nil},
+		{"Absent fails", func(t testing.TB) bool {
+			return testutil.AssertEntityAbsent(t, ctx, client, "users", "user2")
+		}, []string{"/users,user2", "to be absent", "jane@example.com"}},
+		{"Count", func(t testing.TB) bool {
+			return testutil.AssertCount(t, ctx, client, "users", map[string]any{"status": "active"}, 3)
+		}, nil},
+		{"Count with an operator", func(t testing.TB) bool {
+			return testutil.AssertCount(t, ctx, client, "users", map[string]any{"age >": 29}, 2)
+		}, nil},
+		{"Count fails", func(t testing.TB) bool {
+			return testutil.AssertCount(t, ctx, client, "users", map[string]any{"status": "inactive"}, 2)
+		}, []string{"expected 2 users", "got 1", "user3"}},
+		{"Field equals", func(t testing.TB) bool {
+			return testutil.AssertFieldEquals(t, ctx, client, "users", "user1", "age", 30)
+		}, nil},
+		{"Array field equals", func(t testing.TB) bool {
+			return testutil.AssertFieldEquals(t, ctx, client, "items", 7, "tags", []string{"go", "db"})
+		}, nil},
+		{"Field differs", func(t testing.TB) bool {
+			return testutil.AssertFieldEquals(t, ctx, client, "users", "user1", "name", "Jane Doe")
+		}, []string{"/users,user1", "field name", "Jane Doe", "John Doe"}},
+		{"Field missing", func(t testing.TB) bool {
+			return testutil.AssertFieldEquals(t, ctx, client, "users", "user1", "nickname", "JD")
+		}, []string{"/users,user1", "no such property"}},
+		{"Query returns in any order", func(t testing.TB) bool {
+			b := builder.New().Kind("users").Where("status", "active")
+			return testutil.AssertQueryReturns(t, ctx, client, b, []string{"user4", "user1", "user2"})
+		}, nil},
+		{"Ordered query returns in order", func(t testing.TB) bool {
+			b := builder.New().Kind("users").OrderDesc("age")
+			return testutil.AssertQueryReturns(t, ctx, client, b, []string{"user3", "user1", "user4", "user2"})
+		}, nil},
+		{"Ordered query in the wrong order", func(t testing.TB) bool {
+			b := builder.New().Kind("users").OrderAsc("age")
+			return testutil.AssertQueryReturns(t, ctx, client, b, []string{"user3", "user1", "user4", "user2"})
+		}, []string{"query users", "different order"}},
+		{"Query returns other entities", func(t testing.TB) bool {
+			b := builder.New().Kind("users").Where("status", "inactive")
+			return testutil.AssertQueryReturns(t, ctx, client, b, []string{"user1"})
+		}, []string{"missing [user1]", "unexpected [user3]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeT{TB: t}
+			passed := tt.assert(fake)
+
+			if tt.fails == nil {
+				if !passed || len(fake.errors) != 0 {
+					t.Errorf("expected a pass, got %v", fake.errors)
+				}
+				return
+			}
+			if passed || len(fake.errors) != 1 {
+				t.Fatalf("expected one failure, got %v", fake.errors)
+			}
+			for _, want := range tt.fails {
+				if !strings.Contains(fake.errors[0], want) {
+					t.Errorf("failure %q lacks %q", fake.errors[0], want)
+				}
+			}
+		})
+	}
+}
+
+func TestAssertionsWithMock(t *testing.T) {
+	testAssertions(t, testutil.NewMockClient())
+}
+
+func TestAssertionsWithEmulator(t *testing.T) {
+	testAssertions(t, gostore.Wrap(testutil.StartEmulator(t)))
+}
@@ -0,0 +1,109 @@
+package testutil_test
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	testutil.StopEmulator()
+	os.Exit(code)
+}
+
+type taggedItem struct {
+	Tags []string `datastore:"tags"`
+}
+
+// conformanceScenarios are queries over CreateTestUsers and a few tagged
+// items with the names of the entities they must return, in order
+func conformanceScenarios(now time.Time) []struct {
+	name  string
+	query *datastore.Query
+	want  []string
+} {
+	users := datastore.NewQuery("users")
+	return []struct {
+		name  string
+		query *datastore.Query
+		want  []string
+	}{
+		{"all in key order", users, []string{"user1", "user2", "user3", "user4"}},
+		{"equality", users.FilterField("status", "=", "active"), []string{"user1", "user2", "user4"}},
+		{"not equal", users.FilterField("status", "!=", "active"), []string{"user3"}},
+		{"inequality sorts by its property", users.FilterField("age", ">", 28), []string{"user1", "user3"}},
+		{"inequality with descending order", users.FilterField("age", ">=", 28).Order("-age"), []string{"user3", "user1", "user4"}},
+		{"multiple orders", users.Order("status").Order("-age"), []string{"user1", "user4", "user2", "user3"}},
+		{"offset and limit", users.Order("age").Offset(1).Limit(2), []string{"user4", "user1"}},
+		{"zero limit", users.Limit(0), nil},
+		{"time comparison", users.FilterField("created_at", "<", now.Add(-20*time.Hour)), []string{"user3", "user1"}},
+		{"in", users.FilterField("name", "in", []interface{}{"Bob Wilson", "Jane Smith"}), []string{"user2", "user3"}},
+		{"not in", users.FilterField("name", "not-in", []interface{}{"Bob Wilson", "Jane Smith"}).Order("name"), []string{"user4", "user1"}},
+		{"keys only", users.FilterField("status", "=", "inactive").KeysOnly(), []string{"user3"}},
+		{"projection", users.Project("name").Order("name"), []string{"user4", "user3", "user2", "user1"}},
+		{"array property", datastore.NewQuery("items").FilterField("tags", "=", "go"), []string{"item1", "item3"}},
+		{"array property ordered", datastore.NewQuery("items").Order("-tags"), []string{"item2", "item3", "item1"}},
+	}
+}
+
+func runConformance(ctx context.Context, t *testing.T, client gostore.Client) {
+	now := time.Now()
+	for _, u := range testutil.CreateTestUsers() {
+		if _, err := client.Put(ctx, datastore.NameKey("users", u.ID, nil), &u); err != nil {
+			t.Fatalf("Put %s: %v", u.ID, err)
+		}
+	}
+	items := map[string][]string{
+		"item1": {"go", "datastore"},
+		"item2": {"rust", "zig"},
+		"item3": {"go", "mock"},
+	}
+	for name, tags := range items {
+		if _, err := client.Put(ctx, datastore.NameKey("items", name, nil), &taggedItem{Tags: tags}); err != nil {
+			t.Fatalf("Put %s: %v", name, err)
+		}
+	}
+
+	for _, sc := range conformanceScenarios(now) {
+		t.Run(sc.name, func(t *testing.T) {
+			var dst []datastore.PropertyList
+			keys, err := client.GetAll(ctx, sc.query, &dst)
+			if err != nil {
+				t.Fatalf("GetAll: %v", err)
+			}
+
+			var got []string
+			for _, k := range keys {
+				got = append(got, k.Name)
+			}
+			if !slices.Equal(got, sc.want) {
+				t.Errorf("got %v, want %v", got, sc.want)
+			}
+
+			// Projections only carry the projected properties
+			if sc.name == "projection" {
+				for i, props := range dst {
+					if len(props) != 1 || props[0].Name != "name" {
+						t.Errorf("result %d: expected only name, got %v", i, props)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMockConformance(t *testing.T) {
+	runConformance(context.Background(), t, testutil.NewMockClient())
+}
+
+func TestEmulatorConformance(t *testing.T) {
+	client := testutil.StartEmulator(t)
+	runConformance(context.Background(), t, gostore.Wrap(client))
+}
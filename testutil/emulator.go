@@ -0,0 +1,286 @@
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	osexec "os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+// EmulatorHostEnv is the environment variable the Datastore client reads the
+// emulator address from
+const EmulatorHostEnv = "DATASTORE_EMULATOR_HOST"
+
+// EmulatorImage is the container image StartEmulator runs when gcloud is not
+// installed
+var EmulatorImage = "gcr.io/google.com/cloudsdktool/google-cloud-cli:emulators"
+
+// EmulatorStartTimeout bounds how long StartEmulator waits for an emulator it
+// started to become ready
+var EmulatorStartTimeout = 2 * time.Minute
+
+var emulator struct {
+	once sync.Once
+	mu   sync.Mutex
+	host string
+	stop func()
+	err  error
+}
+
+var emulatorProjects atomic.Int64
+
+// maxBatchSize is the most keys one Datastore call accepts, like
+// exec.MaxBatchSize, which exec's internal tests keep testutil from importing
+const maxBatchSize = 500
+
+// RequireEmulator skips t unless a Datastore emulator is available and
+// returns its address. An emulator named by DATASTORE_EMULATOR_HOST is used
+// as is. Otherwise one is started, once per test binary, with gcloud or,
+// failing that, docker, and DATASTORE_EMULATOR_HOST is set to it. In -short
+// mode nothing is started.
+func RequireEmulator(t testing.TB) string {
+	t.Helper()
+
+	if host := os.Getenv(EmulatorHostEnv); host != "" {
+		return host
+	}
+	if testing.Short() {
+		t.Skip(EmulatorHostEnv + " not set, skipping emulator test in short mode")
+	}
+
+	emulator.once.Do(func() {
+		emulator.mu.Lock()
+		defer emulator.mu.Unlock()
+
+		emulator.host, emulator.stop, emulator.err = startEmulator()
+		if emulator.err == nil {
+			emulator.err = os.Setenv(EmulatorHostEnv, emulator.host)
+		}
+	})
+	if emulator.err != nil {
+		t.Skipf("no Datastore emulator, skipping emulator test: %v", emulator.err)
+	}
+	return emulator.host
+}
+
+// StartEmulator returns a client connected to the Datastore emulator (see
+// RequireEmulator), skipping t if there is none. Each call uses a fresh
+// project, so tests don't share data, even when run in parallel. The client
+// is closed, and the entities of its project deleted, when t finishes.
+func StartEmulator(t testing.TB) *datastore.Client {
+	t.Helper()
+	RequireEmulator(t)
+
+	ctx := context.Background()
+	project := fmt.Sprintf("gostore-test-%d-%d", time.Now().UnixNano(), emulatorProjects.Add(1))
+	client, err := datastore.NewClient(ctx, project)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := clearProject(ctx, client); err != nil {
+			t.Errorf("failed to clear emulator project %s: %v", project, err)
+		}
+		client.Close()
+	})
+	return client
+}
+
+// ResetEmulator deletes every entity in the emulator, in all projects, with
+// its /reset endpoint. Tests running in parallel against the same emulator
+// lose their data too; StartEmulator's per-test projects make this
+// unnecessary for most tests.
+func ResetEmulator(t testing.TB) {
+	t.Helper()
+
+	host := RequireEmulator(t)
+	resp, err := http.Post("http://"+host+"/reset", "", nil)
+	if err != nil {
+		t.Fatalf("failed to reset emulator: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("failed to reset emulator: %s", resp.Status)
+	}
+}
+
+// StopEmulator stops the emulator RequireEmulator started, if any. Call it
+// from TestMain after m.Run; an emulator named by DATASTORE_EMULATOR_HOST is
+// left running.
+//
+//	func TestMain(m *testing.M) {
+//		code := m.Run()
+//		testutil.StopEmulator()
+//		os.Exit(code)
+//	}
+func StopEmulator() {
+	emulator.mu.Lock()
+	defer emulator.mu.Unlock()
+
+	if emulator.stop != nil {
+		emulator.stop()
+		emulator.stop = nil
+	}
+}
+
+// startEmulator starts an emulator on a free local port with gcloud or docker
+// and waits for it to be ready
+func startEmulator() (string, func(), error) {
+	port, err := freePort()
+	if err != nil {
+		return "", nil, err
+	}
+	host := fmt.Sprintf("localhost:%d", port)
+
+	var errs []error
+	if gcloud, err := osexec.LookPath("gcloud"); err == nil {
+		stop, err := startGcloudEmulator(gcloud, host)
+		if err == nil {
+			return host, stop, nil
+		}
+		errs = append(errs, fmt.Errorf("gcloud: %w", err))
+	}
+	if docker, err := osexec.LookPath("docker"); err == nil {
+		stop, err := startDockerEmulator(docker, host, port)
+		if err == nil {
+			return host, stop, nil
+		}
+		errs = append(errs, fmt.Errorf("docker: %w", err))
+	}
+
+	if len(errs) == 0 {
+		return "", nil, errors.New(EmulatorHostEnv + " not set and neither gcloud nor docker is installed")
+	}
+	return "", nil, errors.Join(errs...)
+}
+
+func startGcloudEmulator(gcloud, host string) (func(), error) {
+	var output bytes.Buffer
+	cmd := osexec.Command(gcloud, "beta", "emulators", "datastore", "start",
+		"--host-port="+host, "--no-store-on-disk", "--consistency=1.0")
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	stop := func() {
+		// The emulator runs in a JVM started by gcloud, which killing gcloud
+		// would orphan, so ask it to shut down first
+		if resp, err := http.Post("http://"+host+"/shutdown", "", nil); err == nil {
+			resp.Body.Close()
+		}
+		select {
+		case <-exited:
+		case <-time.After(10 * time.Second):
+			cmd.Process.Kill()
+		}
+	}
+
+	if err := waitForEmulator(host, exited); err != nil {
+		stop()
+		return nil, fmt.Errorf("%w\n%s", err, strings.TrimSpace(output.String()))
+	}
+	return stop, nil
+}
+
+func startDockerEmulator(docker, host string, port int) (func(), error) {
+	name := fmt.Sprintf("gostore-emulator-%d-%d", os.Getpid(), port)
+	out, err := osexec.Command(docker, "run", "--rm", "--detach", "--name", name,
+		"--publish", fmt.Sprintf("127.0.0.1:%d:8081", port), EmulatorImage,
+		"gcloud", "beta", "emulators", "datastore", "start",
+		"--host-port=0.0.0.0:8081", "--no-store-on-disk", "--consistency=1.0").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%w\n%s", err, strings.TrimSpace(string(out)))
+	}
+
+	stop := func() {
+		osexec.Command(docker, "rm", "--force", name).Run()
+	}
+
+	if err := waitForEmulator(host, nil); err != nil {
+		stop()
+		return nil, err
+	}
+	return stop, nil
+}
+
+// waitForEmulator polls the emulator at host until it answers, it exits or
+// EmulatorStartTimeout passes
+func waitForEmulator(host string, exited <-chan struct{}) error {
+	deadline := time.After(EmulatorStartTimeout)
+	client := &http.Client{Timeout: time.Second}
+	for {
+		if resp, err := client.Get("http://" + host + "/"); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		select {
+		case <-exited:
+			return errors.New("emulator exited before becoming ready")
+		case <-deadline:
+			return fmt.Errorf("emulator not ready after %s", EmulatorStartTimeout)
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// clearProject deletes every entity of client's project, in all namespaces
+func clearProject(ctx context.Context, client *datastore.Client) error {
+	namespaces, err := client.GetAll(ctx, datastore.NewQuery("__namespace__").KeysOnly(), nil)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		keys, err := client.GetAll(ctx, datastore.NewQuery("").Namespace(ns.Name).KeysOnly(), nil)
+		if err != nil {
+			return err
+		}
+
+		// Kindless queries can include the emulator's metadata kinds
+		user := keys[:0]
+		for _, k := range keys {
+			if !strings.HasPrefix(k.Kind, "__") {
+				user = append(user, k)
+			}
+		}
+
+		for start := 0; start < len(user); start += maxBatchSize {
+			end := min(start+maxBatchSize, len(user))
+			if err := client.DeleteMulti(ctx, user[start:end]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
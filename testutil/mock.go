@@ -2,93 +2,296 @@ package testutil
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
 	"sync"
+	"time"
 
 	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-// MockDatastoreClient is a mock implementation for testing
+// checkBatch fails a call of op with more keys than Datastore accepts in one
+// call, as Datastore does
+func checkBatch(op string, keys []*datastore.Key) error {
+	if len(keys) > maxBatchSize {
+		return status.Errorf(codes.InvalidArgument, "%s: cannot handle more than %d keys in a single call, got %d", op, maxBatchSize, len(keys))
+	}
+	return nil
+}
+
+// MockDatastoreClient is an in-memory gostore.Client for testing. Entities are
+// stored as property lists, so any struct, datastore.PropertyLoadSaver or
+// map[string]interface{} can be written and read back. Queries are evaluated
+// in memory with Datastore's filter, ordering and projection semantics, but
+// without requiring indexes; aggregations are not supported yet. Batches of
+// more than 500 keys are rejected like Datastore does. Failures can
+// be injected with FailNext, FailEveryN and FailKey, and latency with
+// SetLatency; Operations lists the calls made.
 type MockDatastoreClient struct {
 	mu       sync.RWMutex
-	entities map[string]map[string]interface{} // kind -> id -> entity
+	entities map[string]map[string]mockEntity // kind -> encoded key -> entity
+	nextID   int64
+	txAborts int
+	faults   mockFaults
+	latency  mockLatency
+	log      mockLog
 }
 
+type mockEntity struct {
+	key   *datastore.Key
+	props datastore.PropertyList
+}
+
+var _ gostore.Client = (*MockDatastoreClient)(nil)
+
+// errNotSupported is returned by the operations the mock does not implement
+var errNotSupported = errors.New("not supported by MockDatastoreClient")
+
 // NewMockClient creates a new mock datastore client
 func NewMockClient() *MockDatastoreClient {
 	return &MockDatastoreClient{
-		entities: make(map[string]map[string]interface{}),
+		entities: make(map[string]map[string]mockEntity),
 	}
 }
 
-// Put stores an entity
-func (m *MockDatastoreClient) Put(ctx context.Context, key *datastore.Key, entity interface{}) error {
+// Put stores an entity, allocating an ID for an incomplete key
+func (m *MockDatastoreClient) Put(ctx context.Context, key *datastore.Key, entity interface{}) (*datastore.Key, error) {
+	m.log.record(OpPut, []*datastore.Key{key})
+	if err := m.beginKey(ctx, OpPut, key); err != nil {
+		return nil, err
+	}
+
+	props, err := saveEntity(entity)
+	if err != nil {
+		return nil, err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	return m.put(key, props), nil
+}
 
-	kind := key.Kind
-	id := key.Name
-	if id == "" {
-		id = fmt.Sprintf("%d", key.ID)
+// Get retrieves an entity
+func (m *MockDatastoreClient) Get(ctx context.Context, key *datastore.Key, entity interface{}) error {
+	m.log.record(OpGet, []*datastore.Key{key})
+	if err := m.beginKey(ctx, OpGet, key); err != nil {
+		return err
 	}
+	return m.load(key, entity)
+}
 
-	if m.entities[kind] == nil {
-		m.entities[kind] = make(map[string]interface{})
+// Delete removes an entity
+func (m *MockDatastoreClient) Delete(ctx context.Context, key *datastore.Key) error {
+	m.log.record(OpDelete, []*datastore.Key{key})
+	if err := m.beginKey(ctx, OpDelete, key); err != nil {
+		return err
 	}
 
-	m.entities[kind][id] = entity
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.delete(key)
 	return nil
 }
 
-// Get retrieves an entity
-func (m *MockDatastoreClient) Get(ctx context.Context, key *datastore.Key, entity interface{}) error {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+// GetMulti retrieves entities into dst, a slice as long as keys. Missing
+// entities are reported in a datastore.MultiError.
+func (m *MockDatastoreClient) GetMulti(ctx context.Context, keys []*datastore.Key, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Slice || v.Len() != len(keys) {
+		return fmt.Errorf("dst must be a slice of length %d", len(keys))
+	}
+	m.log.record(OpGetMulti, keys)
+	if err := checkBatch(OpGetMulti, keys); err != nil {
+		return err
+	}
+	if err := m.begin(ctx, OpGetMulti); err != nil {
+		return err
+	}
+
+	errs := make(datastore.MultiError, len(keys))
+	failed := false
+	for i, key := range keys {
+		errs[i] = m.faults.key(key)
+		if errs[i] == nil {
+			errs[i] = m.load(key, elemPointer(v, i))
+		}
+		failed = failed || errs[i] != nil
+	}
+	if failed {
+		return errs
+	}
+	return nil
+}
 
-	kind := key.Kind
-	id := key.Name
-	if id == "" {
-		id = fmt.Sprintf("%d", key.ID)
+// PutMulti stores entities from src, a slice as long as keys. Entities that
+// cannot be saved, or whose keys fail (see FailKey), are reported in a
+// datastore.MultiError and nothing is written.
+func (m *MockDatastoreClient) PutMulti(ctx context.Context, keys []*datastore.Key, src interface{}) ([]*datastore.Key, error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() != reflect.Slice || v.Len() != len(keys) {
+		return nil, fmt.Errorf("src must be a slice of length %d", len(keys))
+	}
+	m.log.record(OpPutMulti, keys)
+	if err := checkBatch(OpPutMulti, keys); err != nil {
+		return nil, err
+	}
+	if err := m.begin(ctx, OpPutMulti); err != nil {
+		return nil, err
 	}
 
-	kindEntities, ok := m.entities[kind]
-	if !ok {
-		return datastore.ErrNoSuchEntity
+	props := make([]datastore.PropertyList, len(keys))
+	errs := make(datastore.MultiError, len(keys))
+	failed := false
+	for i, key := range keys {
+		errs[i] = m.faults.key(key)
+		if errs[i] == nil {
+			props[i], errs[i] = saveEntity(elemPointer(v, i))
+		}
+		failed = failed || errs[i] != nil
+	}
+	if failed {
+		return nil, errs
 	}
 
-	stored, ok := kindEntities[id]
-	if !ok {
-		return datastore.ErrNoSuchEntity
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := make([]*datastore.Key, len(keys))
+	for i, key := range keys {
+		stored[i] = m.put(key, props[i])
+	}
+	return stored, nil
+}
+
+// DeleteMulti removes entities. If any key fails (see FailKey) the failures
+// are reported in a datastore.MultiError and nothing is deleted.
+func (m *MockDatastoreClient) DeleteMulti(ctx context.Context, keys []*datastore.Key) error {
+	m.log.record(OpDeleteMulti, keys)
+	if err := checkBatch(OpDeleteMulti, keys); err != nil {
+		return err
+	}
+	if err := m.beginKeys(ctx, OpDeleteMulti, keys); err != nil {
+		return err
 	}
 
-	// Simple copy (in real implementation, would need proper reflection)
-	*entity.(*map[string]interface{}) = stored.(map[string]interface{})
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		m.delete(key)
+	}
 	return nil
 }
 
-// Delete removes an entity
-func (m *MockDatastoreClient) Delete(ctx context.Context, key *datastore.Key) error {
+// AllocateIDs completes incomplete keys with unused IDs
+func (m *MockDatastoreClient) AllocateIDs(ctx context.Context, keys []*datastore.Key) ([]*datastore.Key, error) {
+	m.log.record(OpAllocateIDs, keys)
+	if err := m.begin(ctx, OpAllocateIDs); err != nil {
+		return nil, err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	kind := key.Kind
-	id := key.Name
-	if id == "" {
-		id = fmt.Sprintf("%d", key.ID)
+	allocated := make([]*datastore.Key, len(keys))
+	for i, key := range keys {
+		allocated[i] = m.complete(key)
+	}
+	return allocated, nil
+}
+
+// GetAll appends the entities matching q to dst, a pointer to a slice, and
+// returns their keys. dst is ignored for a keys-only query.
+func (m *MockDatastoreClient) GetAll(ctx context.Context, q *datastore.Query, dst interface{}) ([]*datastore.Key, error) {
+	query, results, err := m.runQuery(ctx, OpGetAll, q)
+	if err != nil {
+		return nil, err
+	}
+
+	// A field mismatch still returns the keys and the loaded entities
+	var loadErr error
+	if !query.keysOnly {
+		loadErr = loadAll(dst, results.results)
+		var mismatch *datastore.ErrFieldMismatch
+		if loadErr != nil && !errors.As(loadErr, &mismatch) {
+			return nil, loadErr
+		}
+	}
+
+	keys := make([]*datastore.Key, len(results.results))
+	for i, r := range results.results {
+		keys[i] = r.key
+	}
+	return keys, loadErr
+}
+
+// Run returns an iterator over the entities matching q. Its cursors are only
+// valid for queries of the same shape on this mock.
+func (m *MockDatastoreClient) Run(ctx context.Context, q *datastore.Query) gostore.Iterator {
+	query, results, err := m.runQuery(ctx, OpRun, q)
+	if err != nil {
+		return errIterator{err}
 	}
+	return &mockIterator{mockResults: results, keysOnly: query.keysOnly, faults: &m.faults}
+}
+
+// runQuery evaluates q for a call of op
+func (m *MockDatastoreClient) runQuery(ctx context.Context, op string, q *datastore.Query) (*mockQuery, *mockResults, error) {
+	start := time.Now()
+	query, err := parseQuery(q)
+	if err != nil {
+		m.log.add(Operation{Method: op, Time: start})
+		return nil, nil, err
+	}
+
+	results, err := m.evaluate(ctx, op, query)
+	n := 0
+	if results != nil {
+		n = len(results.results)
+	}
+	m.log.add(Operation{Method: op, Kind: query.kind, Count: n, Time: start})
+	return query, results, err
+}
+
+func (m *MockDatastoreClient) evaluate(ctx context.Context, op string, query *mockQuery) (*mockResults, error) {
+	if err := m.begin(ctx, op); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.query(query)
+}
 
-	if m.entities[kind] != nil {
-		delete(m.entities[kind], id)
+// RunAggregationQuery is not supported yet. It fails with codes.Unimplemented,
+// as the emulator does, so exec counts fall back to keys-only queries.
+func (m *MockDatastoreClient) RunAggregationQuery(ctx context.Context, aq *datastore.AggregationQuery) (datastore.AggregationResult, error) {
+	m.log.record(OpRunAggregationQuery, nil)
+	if err := m.begin(ctx, OpRunAggregationQuery); err != nil {
+		return nil, err
 	}
+	return nil, status.Error(codes.Unimplemented, "RunAggregationQuery is not supported by MockDatastoreClient")
+}
 
+// Close does nothing
+func (m *MockDatastoreClient) Close() error {
 	return nil
 }
 
-// Clear removes all entities
+// Clear removes all entities, injected failures and latency, pending
+// transaction aborts and recorded operations
 func (m *MockDatastoreClient) Clear() {
+	m.faults.reset()
+	m.latency.reset()
+	m.ResetOperations()
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.entities = make(map[string]map[string]interface{})
+	m.entities = make(map[string]map[string]mockEntity)
+	m.txAborts = 0
 }
 
 // Count returns total entities in a kind
@@ -101,3 +304,129 @@ func (m *MockDatastoreClient) Count(kind string) int {
 	}
 	return len(m.entities[kind])
 }
+
+// load fills dst from the entity stored under key
+func (m *MockDatastoreClient) load(key *datastore.Key, dst interface{}) error {
+	m.mu.RLock()
+	stored, ok := m.get(key)
+	m.mu.RUnlock()
+
+	if !ok {
+		return datastore.ErrNoSuchEntity
+	}
+	return loadEntity(dst, stored.props)
+}
+
+// put stores props under key, completing it if needed. m.mu must be held.
+func (m *MockDatastoreClient) put(key *datastore.Key, props datastore.PropertyList) *datastore.Key {
+	key = m.complete(key)
+
+	if m.entities[key.Kind] == nil {
+		m.entities[key.Kind] = make(map[string]mockEntity)
+	}
+	m.entities[key.Kind][key.Encode()] = mockEntity{key: key, props: props}
+	return key
+}
+
+// delete removes the entity stored under key. m.mu must be held.
+func (m *MockDatastoreClient) delete(key *datastore.Key) {
+	if m.entities[key.Kind] != nil {
+		delete(m.entities[key.Kind], key.Encode())
+	}
+}
+
+// get returns the entity stored under key. m.mu must be held.
+func (m *MockDatastoreClient) get(key *datastore.Key) (mockEntity, bool) {
+	stored, ok := m.entities[key.Kind][key.Encode()]
+	return stored, ok
+}
+
+// complete returns key with an allocated ID if it is incomplete. m.mu must be
+// held.
+func (m *MockDatastoreClient) complete(key *datastore.Key) *datastore.Key {
+	if !key.Incomplete() {
+		return key
+	}
+
+	m.nextID++
+	completed := *key
+	completed.ID = m.nextID
+	return &completed
+}
+
+// saveEntity converts a struct, PropertyLoadSaver or map to properties
+func saveEntity(src interface{}) (datastore.PropertyList, error) {
+	switch e := src.(type) {
+	case datastore.PropertyLoadSaver:
+		return e.Save()
+	case map[string]interface{}:
+		return mapToProps(e), nil
+	case *map[string]interface{}:
+		return mapToProps(*e), nil
+	}
+	return datastore.SaveStruct(src)
+}
+
+// loadEntity fills dst, a pointer to a struct, PropertyLoadSaver or map, or a
+// non-nil map, from a copy of props. Like the real client, properties that
+// don't fit a struct produce a *datastore.ErrFieldMismatch after the rest are
+// loaded.
+func loadEntity(dst interface{}, props datastore.PropertyList) error {
+	props = append(datastore.PropertyList(nil), props...)
+
+	switch e := dst.(type) {
+	case datastore.PropertyLoadSaver:
+		return e.Load(props)
+	case *map[string]interface{}:
+		if e == nil {
+			return errors.New("dst must be a non-nil pointer to a map")
+		}
+		*e = make(map[string]interface{}, len(props))
+		propsToMap(*e, props)
+		return nil
+	case map[string]interface{}:
+		if e == nil {
+			return errors.New("dst must be a non-nil map")
+		}
+		propsToMap(e, props)
+		return nil
+	}
+	return datastore.LoadStruct(dst, props)
+}
+
+func propsToMap(m map[string]interface{}, props datastore.PropertyList) {
+	for _, p := range props {
+		m[p.Name] = p.Value
+	}
+}
+
+func mapToProps(m map[string]interface{}) datastore.PropertyList {
+	props := make(datastore.PropertyList, 0, len(m))
+	for name, value := range m {
+		props = append(props, datastore.Property{Name: name, Value: value})
+	}
+	return props
+}
+
+// elemPointer returns element i of slice v as a pointer, unless it already is
+// one
+func elemPointer(v reflect.Value, i int) interface{} {
+	e := v.Index(i)
+	if e.Kind() == reflect.Ptr || e.Kind() == reflect.Interface || e.Kind() == reflect.Map {
+		return e.Interface()
+	}
+	return e.Addr().Interface()
+}
+
+// errIterator is an iterator that always fails
+type errIterator struct {
+	err error
+}
+
+func (it errIterator) Next(dst interface{}) (*datastore.Key, error) {
+	return nil, it.err
+}
+
+func (it errIterator) Cursor() (datastore.Cursor, error) {
+	return datastore.Cursor{}, it.err
+}
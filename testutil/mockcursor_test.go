@@ -0,0 +1,105 @@
+package testutil_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/testutil"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMockCursors(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+	for i := 0; i < 25; i++ {
+		user := testutil.TestUser{Age: i}
+		if _, err := mock.Put(ctx, datastore.NameKey("users", fmt.Sprintf("user%02d", i), nil), &user); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	query := datastore.NewQuery("users").Order("-age")
+
+	t.Run("pages", func(t *testing.T) {
+		seen := make(map[string]bool)
+		var sizes []int
+		var cursor datastore.Cursor
+		for page := 0; ; page++ {
+			if page > 3 {
+				t.Fatal("paging did not end")
+			}
+
+			it := mock.Run(ctx, query.Start(cursor).Limit(10))
+			n := 0
+			for {
+				var user testutil.TestUser
+				key, err := it.Next(&user)
+				if err == iterator.Done {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Next: %v", err)
+				}
+				if seen[key.Name] {
+					t.Fatalf("%s returned twice", key.Name)
+				}
+				if want := 24 - len(seen); user.Age != want {
+					t.Fatalf("expected age %d, got %d", want, user.Age)
+				}
+				seen[key.Name] = true
+				n++
+			}
+			if n == 0 {
+				break
+			}
+			sizes = append(sizes, n)
+
+			var err error
+			if cursor, err = it.Cursor(); err != nil {
+				t.Fatalf("Cursor: %v", err)
+			}
+		}
+
+		if len(seen) != 25 || fmt.Sprint(sizes) != "[10 10 5]" {
+			t.Errorf("expected 25 users in pages of [10 10 5], got %d in %v", len(seen), sizes)
+		}
+	})
+
+	t.Run("end cursor", func(t *testing.T) {
+		it := mock.Run(ctx, query.Limit(5))
+		for i := 0; i < 5; i++ {
+			if _, err := it.Next(nil); err != nil {
+				t.Fatalf("Next: %v", err)
+			}
+		}
+		end, err := it.Cursor()
+		if err != nil {
+			t.Fatalf("Cursor: %v", err)
+		}
+
+		var users []testutil.TestUser
+		if _, err := mock.GetAll(ctx, query.End(end), &users); err != nil || len(users) != 5 {
+			t.Errorf("expected 5 users before the end cursor, got %d (%v)", len(users), err)
+		}
+	})
+
+	t.Run("cursor from another query", func(t *testing.T) {
+		it := mock.Run(ctx, query)
+		if _, err := it.Next(nil); err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		cursor, err := it.Cursor()
+		if err != nil {
+			t.Fatalf("Cursor: %v", err)
+		}
+
+		other := datastore.NewQuery("users").Order("age").Start(cursor)
+		if _, err := mock.GetAll(ctx, other, &[]testutil.TestUser{}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected InvalidArgument, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"cloud.google.com/go/datastore"
+)
+
+// Operations of a MockDatastoreClient, for FailNext, FailEveryN and
+// SetLatency. They are named after the client methods; inside a transaction
+// Get, GetMulti, Put, PutMulti, Delete and DeleteMulti name the transaction's
+// methods, and Commit its commit. OpNext is a call of Next on an iterator
+// returned by Run, so a query can fail part way through; it only takes
+// failures and is not recorded by Operations.
+const (
+	OpGet                 = "Get"
+	OpGetMulti            = "GetMulti"
+	OpPut                 = "Put"
+	OpPutMulti            = "PutMulti"
+	OpDelete              = "Delete"
+	OpDeleteMulti         = "DeleteMulti"
+	OpAllocateIDs         = "AllocateIDs"
+	OpGetAll              = "GetAll"
+	OpRun                 = "Run"
+	OpRunAggregationQuery = "RunAggregationQuery"
+	OpRunInTransaction    = "RunInTransaction"
+	OpCommit              = "Commit"
+	OpNext                = "Next"
+
+	// OpAny matches every operation
+	OpAny = "*"
+)
+
+var mockOps = map[string]bool{
+	OpGet: true, OpGetMulti: true, OpPut: true, OpPutMulti: true,
+	OpDelete: true, OpDeleteMulti: true, OpAllocateIDs: true, OpGetAll: true,
+	OpRun: true, OpRunAggregationQuery: true, OpRunInTransaction: true,
+	OpCommit: true, OpNext: true, OpAny: true,
+}
+
+// mockFaults holds the failures injected into a MockDatastoreClient. It has
+// its own lock so that it can be consulted with or without the client's.
+type mockFaults struct {
+	mu    sync.Mutex
+	rules []*faultRule
+	keys  map[string]error // encoded key -> error
+}
+
+// faultRule fails the next times calls of op, or every nth one
+type faultRule struct {
+	op    string
+	err   error
+	times int
+	every int
+	calls int
+}
+
+// FailNext makes the next times calls of op fail with err before touching
+// any data. op is one of the Op constants, such as OpPut; OpAny matches every
+// operation. Rules added earlier are consumed first.
+func (m *MockDatastoreClient) FailNext(op string, err error, times int) {
+	checkOp(op)
+	if times <= 0 {
+		return
+	}
+
+	m.faults.mu.Lock()
+	defer m.faults.mu.Unlock()
+	m.faults.rules = append(m.faults.rules, &faultRule{op: op, err: err, times: times})
+}
+
+// FailEveryN makes every nth call of op fail with err, counting from when it
+// is called, until Clear
+func (m *MockDatastoreClient) FailEveryN(op string, n int, err error) {
+	checkOp(op)
+	if n <= 0 {
+		return
+	}
+
+	m.faults.mu.Lock()
+	defer m.faults.mu.Unlock()
+	m.faults.rules = append(m.faults.rules, &faultRule{op: op, err: err, every: n})
+}
+
+// FailKey makes every read, write or delete of key fail with err until Clear,
+// or until FailKey is called again with a nil err. Multi operations fail only
+// at key's index, with a datastore.MultiError; writes and deletes are then
+// not applied for any of their keys, like a failed Datastore commit. Queries
+// are not affected.
+func (m *MockDatastoreClient) FailKey(key *datastore.Key, err error) {
+	m.faults.mu.Lock()
+	defer m.faults.mu.Unlock()
+
+	if err == nil {
+		delete(m.faults.keys, key.Encode())
+		return
+	}
+	if m.faults.keys == nil {
+		m.faults.keys = make(map[string]error)
+	}
+	m.faults.keys[key.Encode()] = err
+}
+
+func checkOp(op string) {
+	if !mockOps[op] {
+		panic(fmt.Sprintf("testutil: unknown mock operation %q", op))
+	}
+}
+
+// call returns the failure injected for the next call of op, if any. Every
+// FailEveryN rule for op counts the call, whichever rule fails it.
+func (f *mockFaults) call(op string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var err error
+	kept := f.rules[:0]
+	for _, r := range f.rules {
+		if r.op != op && r.op != OpAny {
+			kept = append(kept, r)
+			continue
+		}
+
+		if r.every > 0 {
+			r.calls++
+			if err == nil && r.calls%r.every == 0 {
+				err = r.err
+			}
+		} else if err == nil {
+			err = r.err
+			r.times--
+		}
+		if r.every > 0 || r.times > 0 {
+			kept = append(kept, r)
+		}
+	}
+	f.rules = kept
+	return err
+}
+
+// key returns the failure injected for key, if any
+func (f *mockFaults) key(key *datastore.Key) error {
+	if key == nil {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.keys[key.Encode()]
+}
+
+// begin simulates the latency of a call of op and returns the failure
+// injected into it, if any
+func (m *MockDatastoreClient) begin(ctx context.Context, op string) error {
+	if err := m.latency.wait(ctx, op); err != nil {
+		return err
+	}
+	return m.faults.call(op)
+}
+
+// beginKey is begin for a call of op on key, which can fail on its own
+func (m *MockDatastoreClient) beginKey(ctx context.Context, op string, key *datastore.Key) error {
+	if err := m.begin(ctx, op); err != nil {
+		return err
+	}
+	return m.faults.key(key)
+}
+
+// beginKeys is begin for a call of op on keys, returning a
+// datastore.MultiError if any of them fails
+func (m *MockDatastoreClient) beginKeys(ctx context.Context, op string, keys []*datastore.Key) error {
+	if err := m.begin(ctx, op); err != nil {
+		return err
+	}
+
+	errs := make(datastore.MultiError, len(keys))
+	failed := false
+	for i, key := range keys {
+		errs[i] = m.faults.key(key)
+		failed = failed || errs[i] != nil
+	}
+	if failed {
+		return errs
+	}
+	return nil
+}
+
+// reset removes all injected failures
+func (f *mockFaults) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = nil
+	f.keys = nil
+}
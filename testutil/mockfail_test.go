@@ -0,0 +1,243 @@
+package testutil_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFailureInjection(t *testing.T) {
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+
+	userKeys := func() []*datastore.Key {
+		return []*datastore.Key{
+			datastore.NameKey("users", "a", nil),
+			datastore.NameKey("users", "b", nil),
+			datastore.NameKey("users", "c", nil),
+		}
+	}
+	seed := func(t *testing.T, m *testutil.MockDatastoreClient) {
+		t.Helper()
+		users := []testutil.TestUser{{Name: "A"}, {Name: "B"}, {Name: "C"}}
+		if _, err := m.PutMulti(ctx, userKeys(), users); err != nil {
+			t.Fatalf("PutMulti failed: %v", err)
+		}
+	}
+
+	t.Run("FailNext", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		m.FailNext(testutil.OpPut, errBoom, 2)
+
+		user := &testutil.TestUser{Name: "A"}
+		for i := 0; i < 2; i++ {
+			if _, err := m.Put(ctx, datastore.NameKey("users", "a", nil), user); !errors.Is(err, errBoom) {
+				t.Fatalf("call %d: expected boom, got %v", i+1, err)
+			}
+		}
+		if m.Count("users") != 0 {
+			t.Error("expected failed puts to write nothing")
+		}
+		if _, err := m.Put(ctx, datastore.NameKey("users", "a", nil), user); err != nil {
+			t.Errorf("expected the third put to succeed, got %v", err)
+		}
+	})
+
+	t.Run("FailEveryN", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		seed(t, m)
+		m.FailEveryN(testutil.OpGet, 3, errBoom)
+
+		var failed []int
+		for i := 1; i <= 7; i++ {
+			var user testutil.TestUser
+			if err := m.Get(ctx, datastore.NameKey("users", "a", nil), &user); err != nil {
+				failed = append(failed, i)
+			}
+		}
+		if len(failed) != 2 || failed[0] != 3 || failed[1] != 6 {
+			t.Errorf("expected calls 3 and 6 to fail, got %v", failed)
+		}
+	})
+
+	t.Run("FailKey in multi operations", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		seed(t, m)
+		m.FailKey(datastore.NameKey("users", "b", nil), errBoom)
+
+		users := make([]testutil.TestUser, 3)
+		err := m.GetMulti(ctx, userKeys(), users)
+		var me datastore.MultiError
+		if !errors.As(err, &me) || me[0] != nil || !errors.Is(me[1], errBoom) || me[2] != nil {
+			t.Fatalf("expected only index 1 to fail, got %v", err)
+		}
+		if users[0].Name != "A" || users[2].Name != "C" {
+			t.Errorf("expected the other users to load, got %+v", users)
+		}
+
+		err = m.DeleteMulti(ctx, userKeys())
+		if !errors.As(err, &me) || !errors.Is(me[1], errBoom) {
+			t.Fatalf("expected index 1 to fail, got %v", err)
+		}
+		if m.Count("users") != 3 {
+			t.Errorf("expected a failed DeleteMulti to delete nothing, got %d users left", m.Count("users"))
+		}
+
+		m.FailKey(datastore.NameKey("users", "b", nil), nil)
+		if err := m.DeleteMulti(ctx, userKeys()); err != nil {
+			t.Errorf("expected the cleared key to succeed, got %v", err)
+		}
+	})
+
+	t.Run("Queries", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		seed(t, m)
+		m.FailNext(testutil.OpAny, errBoom, 1)
+
+		if _, err := m.Run(ctx, datastore.NewQuery("users")).Next(&testutil.TestUser{}); !errors.Is(err, errBoom) {
+			t.Errorf("expected the iterator to fail, got %v", err)
+		}
+		if _, err := m.GetAll(ctx, datastore.NewQuery("users"), &[]testutil.TestUser{}); err != nil {
+			t.Errorf("expected GetAll to succeed, got %v", err)
+		}
+	})
+
+	t.Run("Iterator Next", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		seed(t, m)
+
+		it := m.Run(ctx, datastore.NewQuery("users"))
+		if _, err := it.Next(&testutil.TestUser{}); err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		m.FailNext(testutil.OpNext, errBoom, 1)
+		if _, err := it.Next(&testutil.TestUser{}); !errors.Is(err, errBoom) {
+			t.Errorf("expected the second Next to fail, got %v", err)
+		}
+		if key, err := it.Next(&testutil.TestUser{}); err != nil || key.Name != "b" {
+			t.Errorf("expected the failed result to come next, got %v, %v", key, err)
+		}
+	})
+
+	t.Run("Transactions", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		seed(t, m)
+		m.FailNext(testutil.OpCommit, status.Error(codes.Aborted, "contention"), 1)
+		m.FailKey(datastore.NameKey("users", "c", nil), errBoom)
+
+		attempts := 0
+		_, err := m.RunInTransaction(ctx, func(tx gostore.Transaction) error {
+			attempts++
+			if err := tx.Get(datastore.NameKey("users", "c", nil), &testutil.TestUser{}); !errors.Is(err, errBoom) {
+				t.Errorf("expected the transactional get to fail, got %v", err)
+			}
+			_, err := tx.Put(datastore.NameKey("users", "d", nil), &testutil.TestUser{Name: "D"})
+			return err
+		})
+		if err != nil {
+			t.Fatalf("expected the aborted commit to be retried, got %v", err)
+		}
+		if attempts != 2 || m.Count("users") != 4 {
+			t.Errorf("expected 2 attempts and 4 users, got %d and %d", attempts, m.Count("users"))
+		}
+	})
+
+	t.Run("Clear resets injection", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		m.FailNext(testutil.OpPut, errBoom, 5)
+		m.FailEveryN(testutil.OpGet, 1, errBoom)
+		m.FailKey(datastore.NameKey("users", "a", nil), errBoom)
+		m.Clear()
+
+		seed(t, m)
+		var user testutil.TestUser
+		if err := m.Get(ctx, datastore.NameKey("users", "a", nil), &user); err != nil {
+			t.Errorf("expected no failures after Clear, got %v", err)
+		}
+	})
+
+	t.Run("Concurrent calls", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		seed(t, m)
+		m.FailNext(testutil.OpGet, errBoom, 10)
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		failures := 0
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var user testutil.TestUser
+				if err := m.Get(ctx, datastore.NameKey("users", "a", nil), &user); err != nil {
+					mu.Lock()
+					failures++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		if failures != 10 {
+			t.Errorf("expected exactly 10 failures, got %d", failures)
+		}
+	})
+
+	t.Run("Unknown operation", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected a panic for an unknown operation")
+			}
+		}()
+		testutil.NewMockClient().FailNext("Gte", errBoom, 1)
+	})
+}
+
+func TestMockBatchLimit(t *testing.T) {
+	ctx := context.Background()
+	mock := testutil.NewMockClient()
+
+	keys := make([]*datastore.Key, 501)
+	for i := range keys {
+		keys[i] = datastore.IDKey("users", int64(i+1), nil)
+	}
+	users := make([]testutil.TestUser, len(keys))
+
+	if _, err := mock.PutMulti(ctx, keys, users); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected PutMulti of 501 keys to fail with InvalidArgument, got %v", err)
+	}
+	if n := mock.Count("users"); n != 0 {
+		t.Fatalf("expected nothing written, got %d users", n)
+	}
+
+	if _, err := testutil.PutInBatches(ctx, mock, keys, users); err != nil {
+		t.Fatalf("PutInBatches failed: %v", err)
+	}
+	if n := mock.Count("users"); n != len(keys) {
+		t.Errorf("expected %d users, got %d", len(keys), n)
+	}
+	if n := len(mock.OperationsFor(testutil.OpPutMulti)); n != 3 {
+		t.Errorf("expected the rejected call and 2 batches, got %d calls", n)
+	}
+
+	if err := mock.GetMulti(ctx, keys, make([]testutil.TestUser, len(keys))); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected GetMulti of 501 keys to fail with InvalidArgument, got %v", err)
+	}
+	if err := mock.DeleteMulti(ctx, keys); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected DeleteMulti of 501 keys to fail with InvalidArgument, got %v", err)
+	}
+
+	_, err := mock.RunInTransaction(ctx, func(tx gostore.Transaction) error {
+		_, err := tx.PutMulti(keys, users)
+		return err
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected a transactional PutMulti of 501 keys to fail with InvalidArgument, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// mockLatency holds the simulated latency of a MockDatastoreClient's
+// operations
+type mockLatency struct {
+	mu   sync.Mutex
+	byOp map[string]time.Duration
+	fn   func(op string) time.Duration
+}
+
+// SetLatency makes every call of op take d before it runs. op is one of the
+// Op constants; OpAny sets the latency of operations without their own.
+// Multi and bulk operations pay it per call, so a bulk write in ten batches
+// takes ten times d. A zero d removes the latency.
+func (m *MockDatastoreClient) SetLatency(op string, d time.Duration) {
+	checkOp(op)
+
+	m.latency.mu.Lock()
+	defer m.latency.mu.Unlock()
+
+	if d <= 0 {
+		delete(m.latency.byOp, op)
+		return
+	}
+	if m.latency.byOp == nil {
+		m.latency.byOp = make(map[string]time.Duration)
+	}
+	m.latency.byOp[op] = d
+}
+
+// SetLatencyFn adds fn(op) to the latency of every call, for jitter or
+// latency that changes over a test. fn may be called concurrently. A nil fn
+// removes it.
+func (m *MockDatastoreClient) SetLatencyFn(fn func(op string) time.Duration) {
+	m.latency.mu.Lock()
+	defer m.latency.mu.Unlock()
+	m.latency.fn = fn
+}
+
+// wait sleeps for the latency of op, returning ctx's error instead if it is
+// done first
+func (l *mockLatency) wait(ctx context.Context, op string) error {
+	l.mu.Lock()
+	d, ok := l.byOp[op]
+	if !ok {
+		d = l.byOp[OpAny]
+	}
+	fn := l.fn
+	l.mu.Unlock()
+
+	if fn != nil {
+		d += fn(op)
+	}
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reset removes all latency
+func (l *mockLatency) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.byOp = nil
+	l.fn = nil
+}
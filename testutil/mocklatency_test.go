@@ -0,0 +1,91 @@
+package testutil_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func TestLatency(t *testing.T) {
+	t.Run("Per operation", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		m.SetLatency(testutil.OpPut, 20*time.Millisecond)
+
+		start := time.Now()
+		if _, err := m.Put(context.Background(), datastore.NameKey("users", "a", nil), &testutil.TestUser{}); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		if d := time.Since(start); d < 20*time.Millisecond {
+			t.Errorf("expected Put to take at least 20ms, took %v", d)
+		}
+
+		start = time.Now()
+		if err := m.Get(context.Background(), datastore.NameKey("users", "a", nil), &testutil.TestUser{}); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if d := time.Since(start); d >= 20*time.Millisecond {
+			t.Errorf("expected Get to be fast, took %v", d)
+		}
+	})
+
+	t.Run("Cancellation interrupts the wait", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		m.SetLatency(testutil.OpAny, time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		_, err := m.GetAll(ctx, datastore.NewQuery("users"), &[]testutil.TestUser{})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected Canceled, got %v", err)
+		}
+	})
+
+	t.Run("Bulk writes pay it per batch", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		m.SetLatency(testutil.OpPutMulti, 10*time.Millisecond)
+		h := exec.NewExecWithOptions(exec.WithClient(m))
+
+		users := make([]testutil.TestUser, 30)
+		start := time.Now()
+		if err := h.BulkCreate(context.Background(), "users", users, 10); err != nil {
+			t.Fatalf("BulkCreate failed: %v", err)
+		}
+		if d := time.Since(start); d < 30*time.Millisecond {
+			t.Errorf("expected 3 batches of 10ms, took %v", d)
+		}
+	})
+
+	t.Run("Latency function", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		var calls atomic.Int32
+		m.SetLatencyFn(func(op string) time.Duration {
+			calls.Add(1)
+			if op == testutil.OpDelete {
+				return 15 * time.Millisecond
+			}
+			return 0
+		})
+
+		start := time.Now()
+		if err := m.Delete(context.Background(), datastore.NameKey("users", "a", nil)); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if d := time.Since(start); d < 15*time.Millisecond || calls.Load() != 1 {
+			t.Errorf("expected one call adding 15ms, got %d calls and %v", calls.Load(), d)
+		}
+
+		m.Clear()
+		start = time.Now()
+		m.Delete(context.Background(), datastore.NameKey("users", "a", nil))
+		if d := time.Since(start); d >= 15*time.Millisecond || calls.Load() != 1 {
+			t.Errorf("expected Clear to remove the latency function, got %d calls and %v", calls.Load(), d)
+		}
+	})
+}
@@ -0,0 +1,773 @@
+package testutil
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+	"unsafe"
+
+	"cloud.google.com/go/datastore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// mockQuery holds the parts of a *datastore.Query the mock evaluates.
+// datastore.Query keeps them unexported, so they are read by reflection.
+type mockQuery struct {
+	kind       string
+	namespace  string
+	ancestor   *datastore.Key
+	filters    []datastore.EntityFilter
+	orders     []mockOrder
+	projection []string
+	// distinctOn are the properties results are deduplicated on, nil if
+	// they are not
+	distinctOn []string
+	keysOnly   bool
+	// limit is negative when unset
+	limit  int
+	offset int
+	// start and end are the raw cursors, nil when unset
+	start, end []byte
+}
+
+type mockOrder struct {
+	field      string
+	descending bool
+}
+
+// keyProperty is the pseudo-property filters and orders use for the key
+const keyProperty = "__key__"
+
+// parseQuery reads q into a mockQuery, returning the error q carries, if any
+func parseQuery(q *datastore.Query) (*mockQuery, error) {
+	if q == nil {
+		return nil, errors.New("query must not be nil")
+	}
+	if err := queryLayout(); err != nil {
+		return nil, err
+	}
+	if err, _ := queryField(q, "err").Interface().(error); err != nil {
+		return nil, err
+	}
+
+	query := &mockQuery{
+		kind:       queryField(q, "kind").String(),
+		namespace:  queryField(q, "namespace").String(),
+		ancestor:   queryField(q, "ancestor").Interface().(*datastore.Key),
+		filters:    queryField(q, "filter").Interface().([]datastore.EntityFilter),
+		projection: queryField(q, "projection").Interface().([]string),
+		distinctOn: queryField(q, "distinctOn").Interface().([]string),
+		keysOnly:   queryField(q, "keysOnly").Bool(),
+		limit:      int(queryField(q, "limit").Int()),
+		offset:     int(queryField(q, "offset").Int()),
+		start:      queryField(q, "start").Bytes(),
+		end:        queryField(q, "end").Bytes(),
+	}
+	// Checked by the real client when it sends the query
+	if len(query.projection) > 0 && query.keysOnly {
+		return nil, errors.New("datastore: query cannot both project and be keys-only")
+	}
+	if queryField(q, "distinct").Bool() {
+		if len(query.distinctOn) > 0 {
+			return nil, errors.New("datastore: query cannot be both distinct and distinct-on")
+		}
+		query.distinctOn = query.projection
+	}
+
+	orders := queryField(q, "order")
+	for i := 0; i < orders.Len(); i++ {
+		o := orders.Index(i)
+		query.orders = append(query.orders, mockOrder{
+			field:      o.FieldByName("FieldName").String(),
+			descending: o.FieldByName("Direction").Bool(),
+		})
+	}
+
+	// Like Datastore, sort by the inequality property when no order is given
+	if len(query.orders) == 0 {
+		if field, ok := inequalityField(query.filters); ok {
+			query.orders = []mockOrder{{field: field}}
+		}
+	}
+	return query, nil
+}
+
+// queryFields are the unexported fields of datastore.Query the mock reads,
+// with a check of their types
+var queryFields = map[string]func(reflect.Type) bool{
+	"err":        isType[error],
+	"kind":       isType[string],
+	"namespace":  isType[string],
+	"ancestor":   isType[*datastore.Key],
+	"filter":     isType[[]datastore.EntityFilter],
+	"projection": isType[[]string],
+	"distinctOn": isType[[]string],
+	"distinct":   isType[bool],
+	"keysOnly":   isType[bool],
+	"limit":      isInt,
+	"offset":     isInt,
+	"start":      isType[[]byte],
+	"end":        isType[[]byte],
+	"order":      isOrders,
+}
+
+// queryLayout checks once that datastore.Query has queryFields, so that a
+// change of the client's internals fails queries with an error rather than
+// panicking in queryField
+var queryLayout = sync.OnceValue(func() error {
+	t := reflect.TypeFor[datastore.Query]()
+	for name, ok := range queryFields {
+		if f, found := t.FieldByName(name); !found || !ok(f.Type) {
+			return fmt.Errorf("testutil: the mock cannot read field %s of datastore.Query, unsupported by this version of cloud.google.com/go/datastore", name)
+		}
+	}
+	return nil
+})
+
+// queryField returns the unexported field name of q, one of queryFields
+// checked by queryLayout
+func queryField(q *datastore.Query, name string) reflect.Value {
+	f := reflect.ValueOf(q).Elem().FieldByName(name)
+	return reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+}
+
+// isType reports whether t is T
+func isType[T any](t reflect.Type) bool {
+	return t == reflect.TypeFor[T]()
+}
+
+// isInt reports whether t is a signed integer type
+func isInt(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+// isOrders reports whether t is a slice of orders with a string FieldName
+// and a boolean Direction, true when descending
+func isOrders(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	field, ok := t.Elem().FieldByName("FieldName")
+	if !ok || field.Type.Kind() != reflect.String {
+		return false
+	}
+	dir, ok := t.Elem().FieldByName("Direction")
+	return ok && dir.Type.Kind() == reflect.Bool
+}
+
+// inequalityField returns the property of the first inequality filter
+func inequalityField(filters []datastore.EntityFilter) (string, bool) {
+	for _, f := range filters {
+		switch f := f.(type) {
+		case datastore.PropertyFilter:
+			switch f.Operator {
+			case "<", "<=", ">", ">=", "!=", "not-in":
+				return f.FieldName, true
+			}
+		case datastore.AndFilter:
+			if field, ok := inequalityField(f.Filters); ok {
+				return field, true
+			}
+		}
+	}
+	return "", false
+}
+
+// mockResult is a query result with its position among all the results of
+// the query, before cursors, offset and limit are applied
+type mockResult struct {
+	mockEntity
+	pos int
+}
+
+// mockResults are the results of a query, with the position a cursor taken
+// before the first one points to
+type mockResults struct {
+	results []mockResult
+	start   int
+	shape   uint64
+}
+
+// query returns the stored entities matching q, ordered, positioned, offset
+// and limited as q asks. m.mu must be held.
+func (m *MockDatastoreClient) query(q *mockQuery) (*mockResults, error) {
+	shape := q.shape()
+	start, err := cursorPos(q.start, shape, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []mockEntity
+	for _, e := range m.entities[q.kind] {
+		if e.key.Namespace != q.namespace {
+			continue
+		}
+		if q.ancestor != nil && !hasAncestor(e.key, q.ancestor) {
+			continue
+		}
+		ok, err := matchAll(e, q.filters)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || !hasProperties(e, q.orders, q.projection) {
+			continue
+		}
+		if len(q.projection) > 0 {
+			matches = append(matches, explode(e, q.projection)...)
+		} else {
+			matches = append(matches, e)
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b mockEntity) int {
+		for _, o := range q.orders {
+			c := compareValues(sortValue(a, o), sortValue(b, o))
+			if o.descending {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return compareKeys(a.key, b.key)
+	})
+
+	if len(q.projection) > 0 {
+		matches = project(matches, q.projection, q.distinctOn)
+	}
+
+	end, err := cursorPos(q.end, shape, len(matches))
+	if err != nil {
+		return nil, err
+	}
+	start, end = min(start, len(matches)), min(end, len(matches))
+
+	var results []mockResult
+	for pos := start; pos < end; pos++ {
+		results = append(results, mockResult{mockEntity: matches[pos], pos: pos})
+	}
+	if q.offset > 0 {
+		results = results[min(q.offset, len(results)):]
+	}
+	if q.limit >= 0 && q.limit < len(results) {
+		results = results[:q.limit]
+	}
+	return &mockResults{results: results, start: start, shape: shape}, nil
+}
+
+// shape hashes the parts of q that determine its results, not where they
+// start and end, so a cursor only applies to queries of the same shape
+func (q *mockQuery) shape() uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%s|%v|%v|%v|%v|%v", q.kind, q.namespace, q.ancestor, q.filters, q.orders, q.projection, q.distinctOn)
+	return h.Sum64()
+}
+
+// cursorPrefix starts every mock cursor, to tell them from real ones
+const cursorPrefix = "gostore-mock:"
+
+// mockCursor returns a cursor pointing to pos in the results of queries of
+// shape
+func mockCursor(shape uint64, pos int) datastore.Cursor {
+	raw := fmt.Sprintf("%s%x:%d", cursorPrefix, shape, pos)
+	c, _ := datastore.DecodeCursor(base64.URLEncoding.EncodeToString([]byte(raw)))
+	return c
+}
+
+// cursorPos returns the position a raw cursor points to, or def if it is nil.
+// A cursor not made by the mock for a query of shape is rejected like
+// Datastore rejects a cursor from another query.
+func cursorPos(raw []byte, shape uint64, def int) (int, error) {
+	if raw == nil {
+		return def, nil
+	}
+
+	invalid := status.Error(codes.InvalidArgument, "invalid cursor for this query")
+	rest, ok := bytes.CutPrefix(raw, []byte(cursorPrefix))
+	if !ok {
+		return 0, invalid
+	}
+	hash, pos, ok := bytes.Cut(rest, []byte(":"))
+	if !ok || string(hash) != strconv.FormatUint(shape, 16) {
+		return 0, invalid
+	}
+	n, err := strconv.Atoi(string(pos))
+	if err != nil || n < 0 {
+		return 0, invalid
+	}
+	return n, nil
+}
+
+// matchAll reports whether e matches every filter
+func matchAll(e mockEntity, filters []datastore.EntityFilter) (bool, error) {
+	for _, f := range filters {
+		ok, err := match(e, f)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// match reports whether e matches f. A property holding an array matches if
+// any of its elements does, as in Datastore.
+func match(e mockEntity, f datastore.EntityFilter) (bool, error) {
+	switch f := f.(type) {
+	case datastore.AndFilter:
+		return matchAll(e, f.Filters)
+	case datastore.OrFilter:
+		for _, sub := range f.Filters {
+			ok, err := match(e, sub)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case datastore.PropertyFilter:
+		values, ok := propertyValues(e, f.FieldName)
+		if !ok {
+			return false, nil
+		}
+		if f.Operator == "not-in" {
+			for _, v := range values {
+				if in, err := isIn(v, f.Value); err != nil || in {
+					return false, err
+				}
+			}
+			return true, nil
+		}
+		for _, v := range values {
+			ok, err := compareOp(v, f.Operator, f.Value)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("unsupported filter %T", f)
+}
+
+// compareOp reports whether v op want holds
+func compareOp(v interface{}, op string, want interface{}) (bool, error) {
+	if op == "in" {
+		return isIn(v, want)
+	}
+
+	c := compareValues(v, normalize(want))
+	switch op {
+	case "=":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	}
+	return false, fmt.Errorf("unsupported operator %q", op)
+}
+
+// isIn reports whether v equals one of the elements of values, a slice
+func isIn(v interface{}, values interface{}) (bool, error) {
+	rv := reflect.ValueOf(values)
+	if rv.Kind() != reflect.Slice {
+		return false, fmt.Errorf("IN filter value must be a slice, got %T", values)
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if compareValues(v, normalize(rv.Index(i).Interface())) == 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// propertyValues returns the values stored for name, one per array element,
+// and whether e has the property
+func propertyValues(e mockEntity, name string) ([]interface{}, bool) {
+	if name == keyProperty {
+		return []interface{}{e.key}, true
+	}
+
+	var values []interface{}
+	found := false
+	for _, p := range e.props {
+		if p.Name != name {
+			continue
+		}
+		found = true
+		if array, ok := p.Value.([]interface{}); ok {
+			for _, v := range array {
+				values = append(values, normalize(v))
+			}
+		} else {
+			values = append(values, normalize(p.Value))
+		}
+	}
+	return values, found && len(values) > 0
+}
+
+// hasProperties reports whether e has the properties it is ordered by and
+// projected to, indexed, without which Datastore leaves it out
+func hasProperties(e mockEntity, orders []mockOrder, projection []string) bool {
+	for _, o := range orders {
+		if !indexed(e, o.field) {
+			return false
+		}
+	}
+	for _, name := range projection {
+		if !indexed(e, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexed reports whether e has a value for name that is indexed
+func indexed(e mockEntity, name string) bool {
+	if _, ok := propertyValues(e, name); !ok {
+		return false
+	}
+	for _, p := range e.props {
+		if p.Name == name && p.NoIndex {
+			return false
+		}
+	}
+	return true
+}
+
+// sortValue returns the value e is sorted by for o: the smallest element of
+// an array ascending and the largest descending
+func sortValue(e mockEntity, o mockOrder) interface{} {
+	values, _ := propertyValues(e, o.field)
+	best := values[0]
+	for _, v := range values[1:] {
+		c := compareValues(v, best)
+		if (c < 0 && !o.descending) || (c > 0 && o.descending) {
+			best = v
+		}
+	}
+	return best
+}
+
+// explode returns e once for every combination of the elements of the
+// projected arrays it stores, each with those arrays replaced by one element,
+// as a projection query returns an entity once per index entry
+func explode(e mockEntity, projection []string) []mockEntity {
+	entities := []mockEntity{e}
+	for _, name := range projection {
+		i := slices.IndexFunc(e.props, func(p datastore.Property) bool { return p.Name == name })
+		if i < 0 {
+			continue
+		}
+		array, ok := e.props[i].Value.([]interface{})
+		if !ok {
+			continue
+		}
+
+		var exploded []mockEntity
+		for _, entity := range entities {
+			for _, v := range array {
+				props := slices.Clone(entity.props)
+				props[i].Value = v
+				exploded = append(exploded, mockEntity{key: entity.key, props: props})
+			}
+		}
+		entities = exploded
+	}
+	return entities
+}
+
+// project reduces results to the projected properties, keeping only the
+// first result for each combination of the distinctOn values
+func project(results []mockEntity, projection, distinctOn []string) []mockEntity {
+	projected := make([]mockEntity, 0, len(results))
+	for _, e := range results {
+		var props datastore.PropertyList
+		for _, name := range projection {
+			for _, p := range e.props {
+				if p.Name == name {
+					props = append(props, p)
+					break
+				}
+			}
+		}
+
+		if len(distinctOn) > 0 && slices.ContainsFunc(projected, func(prev mockEntity) bool {
+			return sameProperties(pick(prev.props, distinctOn), pick(props, distinctOn))
+		}) {
+			continue
+		}
+		projected = append(projected, mockEntity{key: e.key, props: props})
+	}
+	return projected
+}
+
+// pick returns the properties of props named in names
+func pick(props datastore.PropertyList, names []string) datastore.PropertyList {
+	var picked datastore.PropertyList
+	for _, p := range props {
+		if slices.Contains(names, p.Name) {
+			picked = append(picked, p)
+		}
+	}
+	return picked
+}
+
+func sameProperties(a, b datastore.PropertyList) bool {
+	return slices.EqualFunc(a, b, func(x, y datastore.Property) bool {
+		return x.Name == y.Name && compareValues(normalize(x.Value), normalize(y.Value)) == 0
+	})
+}
+
+// normalize converts a Go value to the type Datastore stores it as, so that
+// filter values compare with stored properties
+func normalize(v interface{}) interface{} {
+	switch x := v.(type) {
+	case int:
+		return int64(x)
+	case int8:
+		return int64(x)
+	case int16:
+		return int64(x)
+	case int32:
+		return int64(x)
+	case uint8:
+		return int64(x)
+	case uint16:
+		return int64(x)
+	case uint32:
+		return int64(x)
+	case float32:
+		return float64(x)
+	case time.Time:
+		return x.UTC()
+	case *time.Time:
+		if x == nil {
+			return nil
+		}
+		return x.UTC()
+	}
+	return v
+}
+
+// typeRank orders values of different types as Datastore does
+func typeRank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case int64, time.Time:
+		return 1
+	case bool:
+		return 2
+	case []byte:
+		return 3
+	case string:
+		return 4
+	case float64:
+		return 5
+	case datastore.GeoPoint:
+		return 6
+	case *datastore.Key:
+		return 7
+	}
+	return 8
+}
+
+// compareValues compares two normalized values in Datastore order
+func compareValues(a, b interface{}) int {
+	if c := cmp.Compare(typeRank(a), typeRank(b)); c != 0 {
+		return c
+	}
+
+	switch x := a.(type) {
+	case int64:
+		return cmp.Compare(x, fixedPoint(b))
+	case time.Time:
+		return cmp.Compare(fixedPoint(x), fixedPoint(b))
+	case bool:
+		y := b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case !x:
+			return -1
+		}
+		return 1
+	case []byte:
+		return slices.Compare(x, b.([]byte))
+	case string:
+		return cmp.Compare(x, b.(string))
+	case float64:
+		return compareFloats(x, b.(float64))
+	case datastore.GeoPoint:
+		y := b.(datastore.GeoPoint)
+		if c := cmp.Compare(x.Lat, y.Lat); c != 0 {
+			return c
+		}
+		return cmp.Compare(x.Lng, y.Lng)
+	case *datastore.Key:
+		return compareKeys(x, b.(*datastore.Key))
+	}
+	return 0
+}
+
+// fixedPoint returns an integer or a time in microseconds, as Datastore
+// stores times
+func fixedPoint(v interface{}) int64 {
+	if t, ok := v.(time.Time); ok {
+		return t.UnixMicro()
+	}
+	return v.(int64)
+}
+
+// compareFloats orders NaN before every other number, as Datastore does
+func compareFloats(a, b float64) int {
+	switch {
+	case math.IsNaN(a) && math.IsNaN(b):
+		return 0
+	case math.IsNaN(a):
+		return -1
+	case math.IsNaN(b):
+		return 1
+	}
+	return cmp.Compare(a, b)
+}
+
+// hasAncestor reports whether ancestor is key or one of its parents
+func hasAncestor(key, ancestor *datastore.Key) bool {
+	for k := key; k != nil; k = k.Parent {
+		if k.Equal(ancestor) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareKeys orders keys as Datastore does: by path from the root, with
+// numeric IDs before names within a kind
+func compareKeys(a, b *datastore.Key) int {
+	pa, pb := keyPath(a), keyPath(b)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		x, y := pa[i], pb[i]
+		if c := cmp.Compare(x.Kind, y.Kind); c != 0 {
+			return c
+		}
+		switch {
+		case x.Name == "" && y.Name != "":
+			return -1
+		case x.Name != "" && y.Name == "":
+			return 1
+		}
+		if c := cmp.Compare(x.ID, y.ID); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(x.Name, y.Name); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(pa), len(pb))
+}
+
+// keyPath returns the keys from the root of key's path down to key
+func keyPath(key *datastore.Key) []*datastore.Key {
+	var path []*datastore.Key
+	for k := key; k != nil; k = k.Parent {
+		path = append(path, k)
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// loadAll appends the entities of results to dst, a pointer to a slice of
+// structs, struct pointers, maps or property lists. The first
+// *datastore.ErrFieldMismatch is returned after every entity is loaded.
+func loadAll(dst interface{}, results []mockResult) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dst must be a pointer to a slice, got %T", dst)
+	}
+	slice := v.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+
+	var mismatch error
+	for _, e := range results {
+		elem := reflect.New(elemType)
+		if err := loadEntity(elem.Interface(), e.props); err != nil {
+			var fm *datastore.ErrFieldMismatch
+			if !errors.As(err, &fm) {
+				return err
+			}
+			if mismatch == nil {
+				mismatch = err
+			}
+		}
+		if isPtr {
+			slice.Set(reflect.Append(slice, elem))
+		} else {
+			slice.Set(reflect.Append(slice, elem.Elem()))
+		}
+	}
+	return mismatch
+}
+
+// mockIterator iterates over the results of a query
+type mockIterator struct {
+	*mockResults
+	keysOnly bool
+	next     int
+	faults   *mockFaults
+}
+
+// Next returns the next result, or the failure injected for OpNext without
+// moving on
+func (it *mockIterator) Next(dst interface{}) (*datastore.Key, error) {
+	if err := it.faults.call(OpNext); err != nil {
+		return nil, err
+	}
+	if it.next >= len(it.results) {
+		return nil, iterator.Done
+	}
+	r := it.results[it.next]
+	it.next++
+
+	if !it.keysOnly && dst != nil {
+		// Like the real iterator, a field mismatch still returns the key
+		if err := loadEntity(dst, r.props); err != nil {
+			return r.key, err
+		}
+	}
+	return r.key, nil
+}
+
+// Cursor returns a cursor after the last result returned by Next, or at the
+// start of the query before the first
+func (it *mockIterator) Cursor() (datastore.Cursor, error) {
+	pos := it.start
+	if it.next > 0 {
+		pos = it.results[it.next-1].pos + 1
+	}
+	return mockCursor(it.shape, pos), nil
+}
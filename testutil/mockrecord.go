@@ -0,0 +1,88 @@
+package testutil
+
+import (
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+// Operation is a call made on a MockDatastoreClient, as recorded for
+// Operations. Failed calls are recorded too.
+type Operation struct {
+	// Method is one of the Op constants, such as OpPutMulti
+	Method string
+	// Kind is the kind of the query, or of the first key
+	Kind string
+	// Keys are the keys passed to the call, or written by a commit
+	Keys []*datastore.Key
+	// Count is the number of keys, the number of results of a query, or
+	// the number of attempts of a transaction
+	Count int
+	Time  time.Time
+	// Ops are the operations made inside a transaction, across all its
+	// attempts, including each Commit
+	Ops []Operation
+}
+
+// mockLog is a concurrency-safe list of operations
+type mockLog struct {
+	mu  sync.Mutex
+	ops []Operation
+}
+
+// Operations returns the calls made on the client since it was created or
+// ResetOperations was called, in order. Calls made inside a transaction are
+// nested in its OpRunInTransaction operation.
+func (m *MockDatastoreClient) Operations() []Operation {
+	return m.log.list("")
+}
+
+// OperationsFor returns the top-level calls of method, one of the Op
+// constants, made on the client
+func (m *MockDatastoreClient) OperationsFor(method string) []Operation {
+	checkOp(method)
+	return m.log.list(method)
+}
+
+// ResetOperations forgets the recorded calls
+func (m *MockDatastoreClient) ResetOperations() {
+	m.log.mu.Lock()
+	defer m.log.mu.Unlock()
+	m.log.ops = nil
+}
+
+// record adds a call of method on keys
+func (l *mockLog) record(method string, keys []*datastore.Key) {
+	kind := ""
+	if len(keys) > 0 && keys[0] != nil {
+		kind = keys[0].Kind
+	}
+	l.add(Operation{Method: method, Kind: kind, Keys: keys, Count: len(keys)})
+}
+
+// add adds op, timed now unless it already has a time
+func (l *mockLog) add(op Operation) {
+	if op.Time.IsZero() {
+		op.Time = time.Now()
+	}
+	op.Keys = append([]*datastore.Key(nil), op.Keys...)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ops = append(l.ops, op)
+}
+
+// list returns the operations of method, or all of them for ""
+func (l *mockLog) list(method string) []Operation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var ops []Operation
+	for _, op := range l.ops {
+		if method == "" || op.Method == method || method == OpAny {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
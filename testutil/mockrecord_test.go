@@ -0,0 +1,148 @@
+package testutil_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+func methods(ops []testutil.Operation) []string {
+	names := make([]string, len(ops))
+	for i, op := range ops {
+		names[i] = op.Method
+	}
+	return names
+}
+
+func TestOperations(t *testing.T) {
+	ctx := context.Background()
+	a := datastore.NameKey("users", "a", nil)
+	b := datastore.NameKey("users", "b", nil)
+
+	t.Run("Calls are recorded in order", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		users := []testutil.TestUser{{Name: "A"}, {Name: "B"}}
+
+		if _, err := m.PutMulti(ctx, []*datastore.Key{a, b}, users); err != nil {
+			t.Fatalf("PutMulti failed: %v", err)
+		}
+		if err := m.Get(ctx, a, &testutil.TestUser{}); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if _, err := m.GetAll(ctx, datastore.NewQuery("users"), &[]testutil.TestUser{}); err != nil {
+			t.Fatalf("GetAll failed: %v", err)
+		}
+
+		ops := m.Operations()
+		want := []string{testutil.OpPutMulti, testutil.OpGet, testutil.OpGetAll}
+		if got := methods(ops); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+		if ops[0].Kind != "users" || ops[0].Count != 2 || len(ops[0].Keys) != 2 {
+			t.Errorf("expected PutMulti of 2 users, got %+v", ops[0])
+		}
+		if !ops[1].Keys[0].Equal(a) || ops[1].Count != 1 {
+			t.Errorf("expected Get of %v, got %+v", a, ops[1])
+		}
+		if ops[2].Kind != "users" || ops[2].Count != 2 {
+			t.Errorf("expected GetAll of users returning 2, got %+v", ops[2])
+		}
+		if ops[1].Time.Before(ops[0].Time) || ops[2].Time.Before(ops[1].Time) {
+			t.Error("expected operations to be timed in order")
+		}
+	})
+
+	t.Run("Failed calls are recorded", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		m.FailNext(testutil.OpGetAll, errors.New("unavailable"), 1)
+
+		m.Get(ctx, a, &testutil.TestUser{})
+		m.GetAll(ctx, datastore.NewQuery("users"), &[]testutil.TestUser{})
+
+		if n := len(m.OperationsFor(testutil.OpGet)); n != 1 {
+			t.Errorf("expected 1 Get, got %d", n)
+		}
+		ops := m.OperationsFor(testutil.OpGetAll)
+		if len(ops) != 1 || ops[0].Count != 0 {
+			t.Errorf("expected 1 GetAll with no results, got %+v", ops)
+		}
+	})
+
+	t.Run("Transactions nest their operations", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		m.AbortNextTx(1)
+
+		_, err := m.RunInTransaction(ctx, func(tx gostore.Transaction) error {
+			if err := tx.Get(a, &testutil.TestUser{}); !errors.Is(err, datastore.ErrNoSuchEntity) {
+				return err
+			}
+			_, err := tx.Put(a, &testutil.TestUser{Name: "A"})
+			return err
+		})
+		if err != nil {
+			t.Fatalf("RunInTransaction failed: %v", err)
+		}
+
+		ops := m.Operations()
+		if len(ops) != 1 || ops[0].Method != testutil.OpRunInTransaction {
+			t.Fatalf("expected a single RunInTransaction, got %v", methods(ops))
+		}
+		if ops[0].Count != 2 {
+			t.Errorf("expected 2 attempts, got %d", ops[0].Count)
+		}
+
+		inner := methods(ops[0].Ops)
+		want := []string{testutil.OpGet, testutil.OpPut, testutil.OpCommit, testutil.OpGet, testutil.OpPut, testutil.OpCommit}
+		if len(inner) != len(want) {
+			t.Fatalf("expected %v, got %v", want, inner)
+		}
+		for i := range want {
+			if inner[i] != want[i] {
+				t.Fatalf("expected %v, got %v", want, inner)
+			}
+		}
+		if commit := ops[0].Ops[5]; commit.Count != 1 || !commit.Keys[0].Equal(a) {
+			t.Errorf("expected the commit to write %v, got %+v", a, commit)
+		}
+	})
+
+	t.Run("Reset and Clear forget operations", func(t *testing.T) {
+		m := testutil.NewMockClient()
+		m.Put(ctx, a, &testutil.TestUser{})
+
+		m.ResetOperations()
+		if ops := m.Operations(); len(ops) != 0 {
+			t.Errorf("expected no operations after reset, got %v", methods(ops))
+		}
+
+		m.Put(ctx, a, &testutil.TestUser{})
+		m.Clear()
+		if ops := m.Operations(); len(ops) != 0 {
+			t.Errorf("expected no operations after Clear, got %v", methods(ops))
+		}
+	})
+
+	t.Run("Concurrent calls are all recorded", func(t *testing.T) {
+		m := testutil.NewMockClient()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				m.Put(ctx, datastore.IncompleteKey("users", nil), &testutil.TestUser{})
+				m.Operations()
+			}()
+		}
+		wg.Wait()
+
+		if n := len(m.OperationsFor(testutil.OpPut)); n != 50 {
+			t.Errorf("expected 50 Puts, got %d", n)
+		}
+	})
+}
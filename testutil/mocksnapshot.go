@@ -0,0 +1,155 @@
+package testutil
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+// MockState is a copy of the entities of a MockDatastoreClient, taken with
+// Snapshot
+type MockState struct {
+	entities map[string]map[string]mockEntity
+	nextID   int64
+}
+
+// ChangeType says how an entity changed between a snapshot and now
+type ChangeType string
+
+const (
+	// ChangeCreated entities exist now but not in the snapshot
+	ChangeCreated ChangeType = "created"
+	// ChangeUpdated entities exist in both with different properties
+	ChangeUpdated ChangeType = "updated"
+	// ChangeDeleted entities exist in the snapshot but not now
+	ChangeDeleted ChangeType = "deleted"
+)
+
+// Change is an entity that differs between a snapshot and now
+type Change struct {
+	Type ChangeType
+	Key  *datastore.Key
+}
+
+// Snapshot copies the stored entities. The copy is deep, so later writes,
+// and changes to values read back, don't affect it.
+func (m *MockDatastoreClient) Snapshot() *MockState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return &MockState{entities: copyEntities(m.entities), nextID: m.nextID}
+}
+
+// Restore replaces the stored entities with those of s, which can be
+// restored again later. IDs are allocated again from where they were when s
+// was taken. Injected failures and latency are kept.
+func (m *MockDatastoreClient) Restore(s *MockState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entities = copyEntities(s.entities)
+	m.nextID = s.nextID
+}
+
+// Diff returns the entities created, updated or deleted since s was taken,
+// ordered by key. Writing an entity with the properties it already had is not
+// a change.
+func (m *MockDatastoreClient) Diff(s *MockState) []Change {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var changes []Change
+	for kind, entities := range m.entities {
+		for encoded, e := range entities {
+			before, ok := s.entities[kind][encoded]
+			switch {
+			case !ok:
+				changes = append(changes, Change{Type: ChangeCreated, Key: e.key})
+			case !equalProps(before.props, e.props):
+				changes = append(changes, Change{Type: ChangeUpdated, Key: e.key})
+			}
+		}
+	}
+	for kind, entities := range s.entities {
+		for encoded, e := range entities {
+			if _, ok := m.entities[kind][encoded]; !ok {
+				changes = append(changes, Change{Type: ChangeDeleted, Key: e.key})
+			}
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b Change) int {
+		return compareKeys(a.Key, b.Key)
+	})
+	return changes
+}
+
+func copyEntities(src map[string]map[string]mockEntity) map[string]map[string]mockEntity {
+	dst := make(map[string]map[string]mockEntity, len(src))
+	for kind, entities := range src {
+		copied := make(map[string]mockEntity, len(entities))
+		for encoded, e := range entities {
+			copied[encoded] = mockEntity{key: e.key, props: copyProps(e.props)}
+		}
+		dst[kind] = copied
+	}
+	return dst
+}
+
+func copyProps(props datastore.PropertyList) datastore.PropertyList {
+	copied := make(datastore.PropertyList, len(props))
+	for i, p := range props {
+		p.Value = copyValue(p.Value)
+		copied[i] = p
+	}
+	return copied
+}
+
+// copyValue deep copies the mutable property values: slices, byte strings
+// and nested entities
+func copyValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case []interface{}:
+		copied := make([]interface{}, len(x))
+		for i, e := range x {
+			copied[i] = copyValue(e)
+		}
+		return copied
+	case []byte:
+		return bytes.Clone(x)
+	case *datastore.Entity:
+		if x == nil {
+			return x
+		}
+		return &datastore.Entity{Key: x.Key, Properties: copyProps(x.Properties)}
+	}
+	return v
+}
+
+func equalProps(a, b datastore.PropertyList) bool {
+	return slices.EqualFunc(a, b, func(x, y datastore.Property) bool {
+		return x.Name == y.Name && x.NoIndex == y.NoIndex && equalValue(x.Value, y.Value)
+	})
+}
+
+func equalValue(a, b interface{}) bool {
+	switch x := a.(type) {
+	case []interface{}:
+		y, ok := b.([]interface{})
+		return ok && slices.EqualFunc(x, y, equalValue)
+	case time.Time:
+		y, ok := b.(time.Time)
+		return ok && x.Equal(y)
+	case *datastore.Key:
+		y, ok := b.(*datastore.Key)
+		return ok && x.Equal(y)
+	case *datastore.Entity:
+		y, ok := b.(*datastore.Entity)
+		if !ok || x == nil || y == nil {
+			return ok && x == y
+		}
+		return (x.Key == nil && y.Key == nil || x.Key != nil && x.Key.Equal(y.Key)) && equalProps(x.Properties, y.Properties)
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,95 @@
+package testutil_test
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type taggedUser struct {
+	Name string   `datastore:"name"`
+	Tags []string `datastore:"tags"`
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	m := testutil.NewMockClient()
+	key := func(name string) *datastore.Key { return datastore.NameKey("users", name, nil) }
+
+	for _, name := range []string{"a", "b", "c"} {
+		if _, err := m.Put(ctx, key(name), &taggedUser{Name: name, Tags: []string{"x"}}); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	base := m.Snapshot()
+
+	if diff := m.Diff(base); len(diff) != 0 {
+		t.Errorf("expected no changes right after the snapshot, got %v", diff)
+	}
+
+	// Rewrite a unchanged, update b, delete c, create d and an allocated key
+	m.Put(ctx, key("a"), &taggedUser{Name: "a", Tags: []string{"x"}})
+	m.Put(ctx, key("b"), &taggedUser{Name: "b", Tags: []string{"x", "y"}})
+	m.Delete(ctx, key("c"))
+	m.Put(ctx, key("d"), &taggedUser{Name: "d"})
+	allocated, _ := m.Put(ctx, datastore.IncompleteKey("users", nil), &taggedUser{Name: "e"})
+
+	diff := m.Diff(base)
+	want := []testutil.Change{
+		{Type: testutil.ChangeCreated, Key: allocated},
+		{Type: testutil.ChangeUpdated, Key: key("b")},
+		{Type: testutil.ChangeDeleted, Key: key("c")},
+		{Type: testutil.ChangeCreated, Key: key("d")},
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("expected %v, got %v", want, diff)
+	}
+	for i := range want {
+		if diff[i].Type != want[i].Type || !diff[i].Key.Equal(want[i].Key) {
+			t.Errorf("change %d: expected %v, got %v", i, want[i], diff[i])
+		}
+	}
+
+	m.Restore(base)
+	if diff := m.Diff(base); len(diff) != 0 {
+		t.Errorf("expected no changes after Restore, got %v", diff)
+	}
+	var c taggedUser
+	if err := m.Get(ctx, key("c"), &c); err != nil || c.Name != "c" {
+		t.Errorf("expected c to be back, got %+v, %v", c, err)
+	}
+	again, _ := m.Put(ctx, datastore.IncompleteKey("users", nil), &taggedUser{Name: "e"})
+	if !again.Equal(allocated) {
+		t.Errorf("expected ID allocation to restart from the snapshot, got %v and %v", again, allocated)
+	}
+
+	t.Run("Snapshot is isolated from later writes", func(t *testing.T) {
+		m.Restore(base)
+
+		// A value read back shares nothing with the snapshot
+		var props datastore.PropertyList
+		if err := m.Get(ctx, key("a"), &props); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		for _, p := range props {
+			if tags, ok := p.Value.([]interface{}); ok {
+				tags[0] = "mutated"
+			}
+		}
+		m.Clear()
+
+		m.Restore(base)
+		var a taggedUser
+		if err := m.Get(ctx, key("a"), &a); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if len(a.Tags) != 1 || a.Tags[0] != "x" {
+			t.Errorf("expected the snapshot to keep tags [x], got %v", a.Tags)
+		}
+		if m.Count("users") != 3 {
+			t.Errorf("expected 3 users after restoring, got %d", m.Count("users"))
+		}
+	})
+}
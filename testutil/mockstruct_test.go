@@ -0,0 +1,149 @@
+package testutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+type address struct {
+	City string `datastore:"city"`
+	Zip  string `datastore:"zip,noindex"`
+}
+
+type customer struct {
+	Name    string  `datastore:"name"`
+	Note    string  `datastore:"note,omitempty"`
+	Address address `datastore:"address,flatten"`
+}
+
+func TestMockStructs(t *testing.T) {
+	ctx := context.Background()
+	m := testutil.NewMockClient()
+
+	t.Run("TestUser and TestPost round-trip", func(t *testing.T) {
+		user := testutil.CreateTestUsers()[0]
+		if _, err := m.Put(ctx, datastore.NameKey("users", user.ID, nil), &user); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		var gotUser testutil.TestUser
+		if err := m.Get(ctx, datastore.NameKey("users", user.ID, nil), &gotUser); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if !gotUser.CreatedAt.Equal(user.CreatedAt) {
+			t.Errorf("expected created_at %v, got %v", user.CreatedAt, gotUser.CreatedAt)
+		}
+		gotUser.ID, gotUser.CreatedAt, user.CreatedAt = user.ID, time.Time{}, time.Time{}
+		if gotUser != user {
+			t.Errorf("expected %+v, got %+v", user, gotUser)
+		}
+
+		posts := testutil.CreateTestPosts()
+		keys := make([]*datastore.Key, len(posts))
+		for i, p := range posts {
+			keys[i] = datastore.NameKey("posts", p.ID, nil)
+		}
+		if _, err := m.PutMulti(ctx, keys, posts); err != nil {
+			t.Fatalf("PutMulti failed: %v", err)
+		}
+		var got []testutil.TestPost
+		if _, err := m.GetAll(ctx, datastore.NewQuery("posts").FilterField("published", "=", true), &got); err != nil {
+			t.Fatalf("GetAll failed: %v", err)
+		}
+		if len(got) != 2 || got[0].Title != "First Post" || got[1].Title != "Second Post" {
+			t.Errorf("expected the two published posts, got %+v", got)
+		}
+	})
+
+	t.Run("Map destinations", func(t *testing.T) {
+		var got map[string]interface{}
+		if err := m.Get(ctx, datastore.NameKey("users", "user1", nil), &got); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if got["email"] != "john@example.com" || got["age"] != int64(30) {
+			t.Errorf("expected the user's properties, got %v", got)
+		}
+
+		into := map[string]interface{}{"extra": true}
+		if err := m.Get(ctx, datastore.NameKey("users", "user1", nil), into); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if into["name"] != "John Doe" || into["extra"] != true {
+			t.Errorf("expected the properties added to the map, got %v", into)
+		}
+	})
+
+	t.Run("Flattened, noindex and omitempty fields", func(t *testing.T) {
+		c := customer{Name: "Acme", Address: address{City: "Oslo", Zip: "0150"}}
+		key := datastore.NameKey("customers", "acme", nil)
+		if _, err := m.Put(ctx, key, &c); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+
+		var props datastore.PropertyList
+		if err := m.Get(ctx, key, &props); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		names := map[string]bool{}
+		for _, p := range props {
+			names[p.Name] = true
+			if p.Name == "address.zip" && !p.NoIndex {
+				t.Error("expected address.zip to be unindexed")
+			}
+		}
+		if !names["address.city"] || !names["address.zip"] || names["note"] {
+			t.Errorf("expected flattened properties without note, got %v", props)
+		}
+
+		var got []customer
+		if _, err := m.GetAll(ctx, datastore.NewQuery("customers").FilterField("address.city", "=", "Oslo"), &got); err != nil {
+			t.Fatalf("GetAll failed: %v", err)
+		}
+		if len(got) != 1 || got[0] != c {
+			t.Errorf("expected %+v, got %+v", c, got)
+		}
+	})
+
+	t.Run("Mismatches are errors, not panics", func(t *testing.T) {
+		key := datastore.NameKey("things", "a", nil)
+		m.Put(ctx, key, &map[string]interface{}{"name": "A", "age": "thirty", "unknown": 1})
+
+		var user testutil.TestUser
+		err := m.Get(ctx, key, &user)
+		var mismatch *datastore.ErrFieldMismatch
+		if !errors.As(err, &mismatch) {
+			t.Fatalf("expected ErrFieldMismatch, got %v", err)
+		}
+		if user.Name != "A" {
+			t.Errorf("expected the matching fields to load, got %+v", user)
+		}
+
+		var users []testutil.TestUser
+		keys, err := m.GetAll(ctx, datastore.NewQuery("things"), &users)
+		if !errors.As(err, &mismatch) || len(keys) != 1 || len(users) != 1 {
+			t.Errorf("expected ErrFieldMismatch with the entity loaded, got %v, %d keys", err, len(keys))
+		}
+
+		if err := m.Get(ctx, key, user); err == nil {
+			t.Error("expected an error for a non-pointer destination")
+		}
+		if err := m.Get(ctx, key, (*map[string]interface{})(nil)); err == nil {
+			t.Error("expected an error for a nil map pointer")
+		}
+	})
+
+	t.Run("Times keep their instant", func(t *testing.T) {
+		now := time.Now()
+		key := datastore.NameKey("users", "timed", nil)
+		m.Put(ctx, key, &testutil.TestUser{CreatedAt: now})
+
+		var got testutil.TestUser
+		if err := m.Get(ctx, key, &got); err != nil || !got.CreatedAt.Equal(now) {
+			t.Errorf("expected %v, got %v (%v)", now, got.CreatedAt, err)
+		}
+	})
+}
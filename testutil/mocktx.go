@@ -0,0 +1,280 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+	"unsafe"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultTxAttempts is how many times RunInTransaction attempts a
+// transaction without datastore.MaxAttempts, as the real client does
+const defaultTxAttempts = 3
+
+// mockTx is a transaction on a MockDatastoreClient. Reads see the committed
+// state overlaid with the transaction's own writes, which are buffered and
+// applied together when the transaction commits.
+type mockTx struct {
+	ctx    context.Context
+	client *MockDatastoreClient
+	writes []mockWrite
+	log    *mockLog // shared by all attempts of the transaction
+}
+
+// mockWrite is a buffered Put, or a Delete when props is nil
+type mockWrite struct {
+	key   *datastore.Key
+	props datastore.PropertyList
+}
+
+var _ gostore.Transaction = (*mockTx)(nil)
+
+// AbortNextTx makes the next times transaction attempts abort at commit, as
+// if they had lost to a concurrent transaction: their writes are discarded
+// and RunInTransaction retries them, calling the callback again each time,
+// like the real client, until datastore.MaxAttempts (3 by default) is reached
+// and it returns datastore.ErrConcurrentTransaction.
+func (m *MockDatastoreClient) AbortNextTx(times int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.txAborts = times
+}
+
+// RunInTransaction runs f in a transaction whose writes are applied together
+// if f succeeds and discarded if it fails. Transactions are not isolated from
+// each other; contention can be simulated with AbortNextTx, or with an
+// aborted error injected into OpRunInTransaction or OpCommit. Attempts failing
+// with an aborted error are retried up to datastore.MaxAttempts; other
+// options are ignored.
+func (m *MockDatastoreClient) RunInTransaction(ctx context.Context, f func(tx gostore.Transaction) error, opts ...datastore.TransactionOption) (*datastore.Commit, error) {
+	attempts := txAttempts(opts)
+
+	log := &mockLog{}
+	start, made := time.Now(), 0
+	defer func() {
+		m.log.add(Operation{Method: OpRunInTransaction, Count: made, Time: start, Ops: log.list("")})
+	}()
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		made++
+		err = m.begin(ctx, OpRunInTransaction)
+		if err == nil {
+			tx := &mockTx{ctx: ctx, client: m, log: log}
+			err = f(tx)
+			if err == nil {
+				if err = m.commit(tx); err == nil {
+					return &datastore.Commit{}, nil
+				}
+			}
+		}
+		if !errors.Is(err, datastore.ErrConcurrentTransaction) && status.Code(err) != codes.Aborted {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// commit applies the writes of tx, unless an abort or a failure was
+// injected. An injected aborted error becomes
+// datastore.ErrConcurrentTransaction, as the real commit reports it.
+func (m *MockDatastoreClient) commit(tx *mockTx) error {
+	keys := make([]*datastore.Key, len(tx.writes))
+	for i, w := range tx.writes {
+		keys[i] = w.key
+	}
+	tx.log.record(OpCommit, keys)
+	if err := m.begin(tx.ctx, OpCommit); err != nil {
+		if status.Code(err) == codes.Aborted {
+			return datastore.ErrConcurrentTransaction
+		}
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.txAborts > 0 {
+		m.txAborts--
+		return datastore.ErrConcurrentTransaction
+	}
+	for _, w := range tx.writes {
+		if w.props == nil {
+			m.delete(w.key)
+		} else {
+			m.put(w.key, w.props)
+		}
+	}
+	return nil
+}
+
+// txAttempts reads datastore.MaxAttempts from opts. Its type is unexported,
+// so it is recognized by reflection.
+func txAttempts(opts []datastore.TransactionOption) int {
+	attempts := defaultTxAttempts
+	for _, opt := range opts {
+		v := reflect.ValueOf(opt)
+		if v.Type().Name() == "maxAttempts" && v.Kind() == reflect.Int && v.Int() > 0 {
+			attempts = int(v.Int())
+		}
+	}
+	return attempts
+}
+
+// buffered returns the last write of key made in tx, if any
+func (tx *mockTx) buffered(key *datastore.Key) (mockWrite, bool) {
+	for i := len(tx.writes) - 1; i >= 0; i-- {
+		if tx.writes[i].key.Equal(key) {
+			return tx.writes[i], true
+		}
+	}
+	return mockWrite{}, false
+}
+
+func (tx *mockTx) Get(key *datastore.Key, dst interface{}) error {
+	tx.log.record(OpGet, []*datastore.Key{key})
+	if err := tx.client.beginKey(tx.ctx, OpGet, key); err != nil {
+		return err
+	}
+	return tx.get(key, dst)
+}
+
+// get reads key as of the transaction's own writes
+func (tx *mockTx) get(key *datastore.Key, dst interface{}) error {
+	w, ok := tx.buffered(key)
+	if !ok {
+		return tx.client.load(key, dst)
+	}
+	if w.props == nil {
+		return datastore.ErrNoSuchEntity
+	}
+	return loadEntity(dst, w.props)
+}
+
+func (tx *mockTx) GetMulti(keys []*datastore.Key, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Slice || v.Len() != len(keys) {
+		return fmt.Errorf("dst must be a slice of length %d", len(keys))
+	}
+	tx.log.record(OpGetMulti, keys)
+	if err := checkBatch(OpGetMulti, keys); err != nil {
+		return err
+	}
+	if err := tx.client.begin(tx.ctx, OpGetMulti); err != nil {
+		return err
+	}
+
+	errs := make(datastore.MultiError, len(keys))
+	failed := false
+	for i, key := range keys {
+		errs[i] = tx.client.faults.key(key)
+		if errs[i] == nil {
+			errs[i] = tx.get(key, elemPointer(v, i))
+		}
+		failed = failed || errs[i] != nil
+	}
+	if failed {
+		return errs
+	}
+	return nil
+}
+
+func (tx *mockTx) Put(key *datastore.Key, src interface{}) (*datastore.PendingKey, error) {
+	tx.log.record(OpPut, []*datastore.Key{key})
+	if err := tx.client.beginKey(tx.ctx, OpPut, key); err != nil {
+		return nil, err
+	}
+	return tx.put(key, src)
+}
+
+// put buffers a write of src under key
+func (tx *mockTx) put(key *datastore.Key, src interface{}) (*datastore.PendingKey, error) {
+	props, err := saveEntity(src)
+	if err != nil {
+		return nil, err
+	}
+
+	tx.client.mu.Lock()
+	key = tx.client.complete(key)
+	tx.client.mu.Unlock()
+
+	p, err := pendingKey(key)
+	if err != nil {
+		return nil, err
+	}
+	tx.writes = append(tx.writes, mockWrite{key: key, props: props})
+	return p, nil
+}
+
+func (tx *mockTx) PutMulti(keys []*datastore.Key, src interface{}) ([]*datastore.PendingKey, error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() != reflect.Slice || v.Len() != len(keys) {
+		return nil, fmt.Errorf("src must be a slice of length %d", len(keys))
+	}
+	tx.log.record(OpPutMulti, keys)
+	if err := checkBatch(OpPutMulti, keys); err != nil {
+		return nil, err
+	}
+	if err := tx.client.beginKeys(tx.ctx, OpPutMulti, keys); err != nil {
+		return nil, err
+	}
+
+	pending := make([]*datastore.PendingKey, len(keys))
+	for i, key := range keys {
+		p, err := tx.put(key, elemPointer(v, i))
+		if err != nil {
+			return nil, fmt.Errorf("entity at index %d: %w", i, err)
+		}
+		pending[i] = p
+	}
+	return pending, nil
+}
+
+func (tx *mockTx) Delete(key *datastore.Key) error {
+	tx.log.record(OpDelete, []*datastore.Key{key})
+	if err := tx.client.beginKey(tx.ctx, OpDelete, key); err != nil {
+		return err
+	}
+
+	tx.writes = append(tx.writes, mockWrite{key: key})
+	return nil
+}
+
+func (tx *mockTx) DeleteMulti(keys []*datastore.Key) error {
+	tx.log.record(OpDeleteMulti, keys)
+	if err := checkBatch(OpDeleteMulti, keys); err != nil {
+		return err
+	}
+	if err := tx.client.beginKeys(tx.ctx, OpDeleteMulti, keys); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		tx.writes = append(tx.writes, mockWrite{key: key})
+	}
+	return nil
+}
+
+// pendingKey returns a PendingKey that datastore.Commit.Key resolves to key.
+// Its fields are unexported, so key is set by reflection, after checking
+// that the field is still there.
+func pendingKey(key *datastore.Key) (*datastore.PendingKey, error) {
+	p := &datastore.PendingKey{}
+	f := reflect.ValueOf(p).Elem().FieldByName("key")
+	if !f.IsValid() || f.Type() != reflect.TypeFor[*datastore.Key]() {
+		return nil, errors.New("testutil: the mock cannot set the key of datastore.PendingKey, unsupported by this version of cloud.google.com/go/datastore")
+	}
+	reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem().Set(reflect.ValueOf(key))
+	return p, nil
+}
@@ -0,0 +1,153 @@
+package testutil_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/exec"
+	"github.com/AndroX7/gostore/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMockTransaction(t *testing.T) {
+	ctx := context.Background()
+	key := datastore.NameKey("users", "user1", nil)
+
+	t.Run("committed writes are visible afterwards", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		_, err := mock.RunInTransaction(ctx, func(tx gostore.Transaction) error {
+			_, err := tx.Put(key, &testutil.TestUser{Name: "John"})
+			return err
+		})
+		if err != nil {
+			t.Fatalf("RunInTransaction: %v", err)
+		}
+
+		var got testutil.TestUser
+		if err := mock.Get(ctx, key, &got); err != nil || got.Name != "John" {
+			t.Errorf("expected John, got %+v (%v)", got, err)
+		}
+	})
+
+	t.Run("rolled back writes are invisible", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		if _, err := mock.Put(ctx, key, &testutil.TestUser{Name: "John"}); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+
+		failure := errors.New("failure")
+		_, err := mock.RunInTransaction(ctx, func(tx gostore.Transaction) error {
+			if _, err := tx.Put(datastore.NameKey("users", "user2", nil), &testutil.TestUser{}); err != nil {
+				return err
+			}
+			if err := tx.Delete(key); err != nil {
+				return err
+			}
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("expected the callback error, got %v", err)
+		}
+		if n := mock.Count("users"); n != 1 {
+			t.Errorf("expected only user1, got %d users", n)
+		}
+	})
+
+	t.Run("reads see the transaction's own writes", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		if _, err := mock.Put(ctx, key, &testutil.TestUser{Name: "John"}); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+
+		_, err := mock.RunInTransaction(ctx, func(tx gostore.Transaction) error {
+			if _, err := tx.Put(key, &testutil.TestUser{Name: "Jane"}); err != nil {
+				return err
+			}
+			var got testutil.TestUser
+			if err := tx.Get(key, &got); err != nil || got.Name != "Jane" {
+				t.Errorf("expected the buffered Jane, got %+v (%v)", got, err)
+			}
+
+			var outside testutil.TestUser
+			if err := mock.Get(ctx, key, &outside); err != nil || outside.Name != "John" {
+				t.Errorf("expected John outside the transaction, got %+v (%v)", outside, err)
+			}
+
+			if err := tx.Delete(key); err != nil {
+				return err
+			}
+			if err := tx.Get(key, &got); !errors.Is(err, datastore.ErrNoSuchEntity) {
+				t.Errorf("expected the buffered delete, got %v", err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("RunInTransaction: %v", err)
+		}
+		if n := mock.Count("users"); n != 0 {
+			t.Errorf("expected the delete committed, got %d users", n)
+		}
+	})
+
+	t.Run("aborted attempts are retried", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		mock.AbortNextTx(2)
+
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+		retries := 0
+		_, err := h.Transaction(ctx, func(tx *exec.TxExec) error {
+			return tx.Create(ctx, "users", "user1", &testutil.TestUser{Name: "John"})
+		}, exec.OnRetry(func(attempt int, err error) {
+			retries++
+		}))
+		if err != nil {
+			t.Fatalf("Transaction: %v", err)
+		}
+		if retries != 2 || mock.Count("users") != 1 {
+			t.Errorf("expected 2 retries and the user stored, got %d and %d users", retries, mock.Count("users"))
+		}
+	})
+
+	t.Run("attempts are bounded by MaxAttempts", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		mock.AbortNextTx(5)
+
+		h := exec.NewExecWithOptions(exec.WithClient(mock))
+		attempts := 0
+		_, err := h.Transaction(ctx, func(tx *exec.TxExec) error {
+			attempts++
+			return tx.Create(ctx, "users", "user1", &testutil.TestUser{})
+		}, exec.MaxAttempts(4))
+		if !errors.Is(err, datastore.ErrConcurrentTransaction) || attempts != 4 {
+			t.Errorf("expected ErrConcurrentTransaction after 4 attempts, got %v after %d", err, attempts)
+		}
+		if !strings.Contains(err.Error(), "after 4 attempts") {
+			t.Errorf("expected the error to report 4 attempts, got %q", err)
+		}
+		if n := mock.Count("users"); n != 0 {
+			t.Errorf("expected nothing stored, got %d users", n)
+		}
+	})
+	t.Run("aborted commits surface as ErrConcurrentTransaction", func(t *testing.T) {
+		mock := testutil.NewMockClient()
+		mock.FailNext(testutil.OpCommit, status.Error(codes.Aborted, "contention"), 3)
+
+		calls := 0
+		_, err := mock.RunInTransaction(ctx, func(tx gostore.Transaction) error {
+			calls++
+			_, err := tx.Put(key, &testutil.TestUser{})
+			return err
+		})
+		if err != datastore.ErrConcurrentTransaction || calls != 3 {
+			t.Errorf("expected ErrConcurrentTransaction after 3 calls, got %v after %d", err, calls)
+		}
+		if n := mock.Count("users"); n != 0 {
+			t.Errorf("expected nothing stored, got %d users", n)
+		}
+	})
+}
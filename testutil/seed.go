@@ -0,0 +1,216 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	contextKey "github.com/AndroX7/gostore/key"
+)
+
+// SeedRepository is the part of *repository.BaseRepository a Seeder uses, so
+// that testutil does not import repository, whose own tests import testutil
+type SeedRepository interface {
+	GetKind() string
+	Client() gostore.Client
+	CreateMultiWithKeys(ctx context.Context, ids []interface{}, entities interface{}) ([]*datastore.Key, error)
+}
+
+// Seeder stores fixtures for a test and deletes them when the test finishes.
+// Only the keys it created are deleted, never the whole kind, so tests
+// seeding the same kind can run in parallel against one client. The zero
+// value is ready to use.
+type Seeder struct {
+	mu   sync.Mutex
+	keys []*datastore.Key
+}
+
+// NewSeeder creates a Seeder
+func NewSeeder() *Seeder {
+	return &Seeder{}
+}
+
+// Seed creates entities, a slice or pointer to a slice of structs or struct
+// pointers, through repo, so its validators and hooks run, under the IDs read
+// from their ID fields (see key.ID). Entities with a zero ID get an allocated
+// one, written back into them. The created keys are deleted, with the
+// repository's client or else the one in ctx, when t finishes.
+func (s *Seeder) Seed(ctx context.Context, t testing.TB, repo SeedRepository, entities any) []*datastore.Key {
+	t.Helper()
+
+	v := seedSlice(t, entities)
+	ids := make([]interface{}, v.Len())
+	for i := range ids {
+		id, ok := contextKey.ID(v.Index(i).Interface())
+		if !ok {
+			t.Fatalf("seed %s: %s has no ID field", repo.GetKind(), v.Index(i).Type())
+		}
+		if !reflect.ValueOf(id).IsZero() {
+			ids[i] = id
+		}
+	}
+
+	keys := make([]*datastore.Key, 0, len(ids))
+	for start := 0; start < len(ids); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(ids))
+		batch, err := repo.CreateMultiWithKeys(ctx, ids[start:end], v.Slice(start, end).Interface())
+		if err != nil {
+			t.Fatalf("seed %s: %v", repo.GetKind(), err)
+		}
+		keys = append(keys, batch...)
+	}
+	contextKey.SetIDs(entities, keys)
+
+	client := repo.Client()
+	if client == nil {
+		var err error
+		client, err = contextKey.ClientFromContext(ctx)
+		if err != nil {
+			t.Fatalf("seed %s: %v", repo.GetKind(), err)
+		}
+	}
+	s.cleanup(ctx, t, client, keys)
+	return keys
+}
+
+// SeedKind stores entities, a slice or pointer to a slice of structs or
+// struct pointers, as kind directly with client, under the IDs read from their ID fields like Seed.
+// The created keys are deleted when t finishes.
+func (s *Seeder) SeedKind(ctx context.Context, t testing.TB, client gostore.Client, kind string, entities any) []*datastore.Key {
+	t.Helper()
+
+	v := seedSlice(t, entities)
+	keys := make([]*datastore.Key, v.Len())
+	for i := range keys {
+		key, err := seedKey(kind, v.Index(i).Interface())
+		if err != nil {
+			t.Fatalf("seed %s: entity at index %d: %v", kind, i, err)
+		}
+		keys[i] = key
+	}
+
+	keys, err := PutInBatches(ctx, client, keys, v.Interface())
+	if err != nil {
+		t.Fatalf("seed %s: %v", kind, err)
+	}
+	contextKey.SetIDs(entities, keys)
+
+	s.cleanup(ctx, t, client, keys)
+	return keys
+}
+
+// PutInBatches writes src, a slice with one entity per key, with client in
+// PutMulti calls of at most 500 keys, the most Datastore accepts, and returns
+// the written keys in order
+func PutInBatches(ctx context.Context, client gostore.Client, keys []*datastore.Key, src any) ([]*datastore.Key, error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() != reflect.Slice || v.Len() != len(keys) {
+		return nil, fmt.Errorf("src must be a slice of length %d, got %T", len(keys), src)
+	}
+
+	stored := make([]*datastore.Key, 0, len(keys))
+	for start := 0; start < len(keys); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(keys))
+		batch, err := client.PutMulti(ctx, keys[start:end], v.Slice(start, end).Interface())
+		if err != nil {
+			return stored, err
+		}
+		stored = append(stored, batch...)
+	}
+	return stored, nil
+}
+
+// Keys returns the keys seeded so far and not yet cleaned up
+func (s *Seeder) Keys() []*datastore.Key {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*datastore.Key(nil), s.keys...)
+}
+
+// cleanup records keys and registers their deletion with t
+func (s *Seeder) cleanup(ctx context.Context, t testing.TB, client gostore.Client, keys []*datastore.Key) {
+	s.mu.Lock()
+	s.keys = append(s.keys, keys...)
+	s.mu.Unlock()
+
+	// ctx may be cancelled by the time the test finishes
+	ctx = context.WithoutCancel(ctx)
+	t.Cleanup(func() {
+		for start := 0; start < len(keys); start += maxBatchSize {
+			end := min(start+maxBatchSize, len(keys))
+			if err := client.DeleteMulti(ctx, keys[start:end]); err != nil {
+				t.Errorf("failed to delete seeded entities: %v", err)
+				return
+			}
+		}
+		s.forget(keys)
+	})
+}
+
+// forget drops keys from the seeded keys
+func (s *Seeder) forget(keys []*datastore.Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		deleted[k.String()] = true
+	}
+	kept := s.keys[:0]
+	for _, k := range s.keys {
+		if !deleted[k.String()] {
+			kept = append(kept, k)
+		}
+	}
+	s.keys = kept
+}
+
+func seedSlice(t testing.TB, entities any) reflect.Value {
+	t.Helper()
+
+	v := reflect.ValueOf(entities)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice {
+		t.Fatalf("seed: entities must be a slice, got %T", entities)
+	}
+	return v
+}
+
+// seedKey builds the key of entity from its ID field, incomplete for a zero
+// ID
+func seedKey(kind string, entity any) (*datastore.Key, error) {
+	id, ok := contextKey.ID(entity)
+	if !ok {
+		return nil, fmt.Errorf("%T has no ID field", entity)
+	}
+	if reflect.ValueOf(id).IsZero() {
+		return datastore.IncompleteKey(kind, nil), nil
+	}
+	return idKey(kind, id)
+}
+
+// idKey builds the key of kind for id: a string name, an integer ID or a
+// *datastore.Key, used as is
+func idKey(kind string, id any) (*datastore.Key, error) {
+	if k, ok := id.(*datastore.Key); ok {
+		if k == nil {
+			return nil, fmt.Errorf("nil %s key", kind)
+		}
+		return k, nil
+	}
+
+	id, err := contextKey.NormalizeID(id)
+	if err != nil {
+		return nil, err
+	}
+	if name, ok := id.(string); ok {
+		return datastore.NameKey(kind, name, nil), nil
+	}
+	return datastore.IDKey(kind, id.(int64), nil), nil
+}
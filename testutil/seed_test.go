@@ -0,0 +1,75 @@
+package testutil_test
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+	"github.com/AndroX7/gostore"
+	"github.com/AndroX7/gostore/repository"
+	"github.com/AndroX7/gostore/testutil"
+)
+
+// testSeeder checks a seeder cleans up its own entities and leaves another
+// test's data in the same kind alone
+func testSeeder(t *testing.T, client gostore.Client) {
+	ctx := context.Background()
+
+	other := []testutil.TestUser{{ID: "other", Name: "Other test"}}
+	if _, err := client.PutMulti(ctx, []*datastore.Key{datastore.NameKey("users", "other", nil)}, other); err != nil {
+		t.Fatalf("failed to store other user: %v", err)
+	}
+
+	count := func() int {
+		keys, err := client.GetAll(ctx, datastore.NewQuery("users").KeysOnly(), nil)
+		if err != nil {
+			t.Fatalf("GetAll failed: %v", err)
+		}
+		return len(keys)
+	}
+
+	seeder := testutil.NewSeeder()
+	t.Run("Seed", func(t *testing.T) {
+		repo := repository.NewBaseRepositoryWithClient(client, "users")
+		users := testutil.CreateTestUsers()
+		users = append(users, testutil.TestUser{Name: "No ID"})
+
+		keys := seeder.Seed(ctx, t, repo, users)
+		if len(keys) != 5 || users[4].ID == "" {
+			t.Fatalf("expected 5 keys and an allocated ID, got %v and %q", keys, users[4].ID)
+		}
+		if n := count(); n != 6 {
+			t.Errorf("expected 6 users while seeded, got %d", n)
+		}
+	})
+	if n := count(); n != 1 {
+		t.Errorf("expected only the other user after cleanup, got %d", n)
+	}
+
+	t.Run("SeedKind", func(t *testing.T) {
+		posts := testutil.CreateTestPosts()
+		seeder.SeedKind(ctx, t, client, "posts", &posts)
+		seeder.SeedKind(ctx, t, client, "users", []*testutil.TestUser{{ID: "seeded"}})
+
+		if n := len(seeder.Keys()); n != 4 {
+			t.Errorf("expected 4 seeded keys, got %d", n)
+		}
+	})
+	if n := count(); n != 1 {
+		t.Errorf("expected only the other user after cleanup, got %d", n)
+	}
+	if keys, err := client.GetAll(ctx, datastore.NewQuery("posts").KeysOnly(), nil); err != nil || len(keys) != 0 {
+		t.Errorf("expected no posts after cleanup, got %v, %v", keys, err)
+	}
+	if keys := seeder.Keys(); len(keys) != 0 {
+		t.Errorf("expected no seeded keys after cleanup, got %v", keys)
+	}
+}
+
+func TestSeederWithMock(t *testing.T) {
+	testSeeder(t, testutil.NewMockClient())
+}
+
+func TestSeederWithEmulator(t *testing.T) {
+	testSeeder(t, gostore.Wrap(testutil.StartEmulator(t)))
+}